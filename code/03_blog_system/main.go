@@ -71,13 +71,10 @@ func createTestData() error {
 		{Name: "学习笔记", Description: "学习过程中的笔记", Slug: "study"},
 	}
 
+	// 已存在的分类和标签不重复创建，多个实例同时启动时也不会因唯一索引冲突失败
 	for _, category := range categories {
-		var existingCategory models.Category
-		// 检查分类是否已存在
-		if err := config.DB.Where("slug = ?", category.Slug).First(&existingCategory).Error; err != nil {
-			if err := config.DB.Create(&category).Error; err != nil {
-				return err
-			}
+		if _, _, err := services.CategoryService.FirstOrCreateBySlug(category.Slug, category); err != nil {
+			return err
 		}
 	}
 
@@ -91,11 +88,8 @@ func createTestData() error {
 	}
 
 	for _, tag := range tags {
-		var existingTag models.Tag
-		if err := config.DB.Where("slug = ?", tag.Slug).First(&existingTag).Error; err != nil {
-			if err := config.DB.Create(&tag).Error; err != nil {
-				return err
-			}
+		if _, _, err := services.TagService.FirstOrCreateBySlug(tag.Slug, tag); err != nil {
+			return err
 		}
 	}
 
@@ -13,6 +13,7 @@ import (
 
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // 全局服务实例
@@ -339,6 +340,49 @@ func (s *categoryService) GetCategoryBySlug(slug string) (*models.Category, erro
 	return &category, nil
 }
 
+// FirstOrCreateBySlug 按slug查找分类，不存在时以defaults创建，返回分类和是否新建
+func (s *categoryService) FirstOrCreateBySlug(slug string, defaults models.Category) (*models.Category, bool, error) {
+	defaults.Slug = slug
+	created, err := firstOrCreateBySlug(s.db, slug, &defaults)
+	if err != nil {
+		return nil, false, fmt.Errorf("查询或创建分类失败: %w", err)
+	}
+	return &defaults, created, nil
+}
+
+// firstOrCreateBySlug 按slug查找记录，不存在时创建row，找到时row被替换为已有记录
+// FirstOrCreate 先查询再插入，两个请求同时创建同一slug时后插入的会违反唯一索引；
+// 这里插入时带 ON CONFLICT DO NOTHING，没有插入成功说明已被并发请求创建，重新查询返回该记录
+func firstOrCreateBySlug[T any](db *gorm.DB, slug string, row *T) (bool, error) {
+	var existing T
+	err := db.Where("slug = ?", slug).First(&existing).Error
+	if err == nil {
+		*row = existing
+		return false, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, err
+	}
+
+	result := db.Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "slug"}}, DoNothing: true}).Create(row)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected > 0 {
+		return true, nil
+	}
+
+	// 已软删除的记录同样占用slug，此时查询不到，返回唯一约束冲突的错误
+	if err := db.Where("slug = ?", slug).First(&existing).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, fmt.Errorf("slug %s 已被已删除的记录占用", slug)
+		}
+		return false, err
+	}
+	*row = existing
+	return false, nil
+}
+
 // ===== 标签服务 =====
 
 type tagService struct {
@@ -350,6 +394,16 @@ func (s *tagService) CreateTag(tag *models.Tag) error {
 	return s.db.Create(tag).Error
 }
 
+// FirstOrCreateBySlug 按slug查找标签，不存在时以defaults创建，返回标签和是否新建
+func (s *tagService) FirstOrCreateBySlug(slug string, defaults models.Tag) (*models.Tag, bool, error) {
+	defaults.Slug = slug
+	created, err := firstOrCreateBySlug(s.db, slug, &defaults)
+	if err != nil {
+		return nil, false, fmt.Errorf("查询或创建标签失败: %w", err)
+	}
+	return &defaults, created, nil
+}
+
 // GetTags 获取标签列表
 func (s *tagService) GetTags() ([]models.Tag, error) {
 	var tags []models.Tag
@@ -372,14 +426,13 @@ func (s *tagService) GetTagsByNames(names []string) ([]models.Tag, error) {
 		var tag models.Tag
 		if err := s.db.Where("name = ?", name).First(&tag).Error; err != nil {
 			if err == gorm.ErrRecordNotFound {
-				// 标签不存在，创建新标签
-				tag = models.Tag{
-					Name: name,
-					Slug: strings.ToLower(strings.ReplaceAll(name, " ", "-")),
-				}
-				if err := s.db.Create(&tag).Error; err != nil {
-					return nil, fmt.Errorf("创建标签失败: %w", err)
+				// 标签不存在，创建新标签；并发创建同一标签时返回已创建的标签
+				slug := strings.ToLower(strings.ReplaceAll(name, " ", "-"))
+				t, _, err := s.FirstOrCreateBySlug(slug, models.Tag{Name: name})
+				if err != nil {
+					return nil, err
 				}
+				tag = *t
 			} else {
 				return nil, fmt.Errorf("查询标签失败: %w", err)
 			}
@@ -0,0 +1,151 @@
+package services
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"blog-system/config"
+	"blog-system/models"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTestDB 创建临时文件中的SQLite数据库并初始化服务；
+// 多个连接并发写入时通过busy_timeout等待写锁，而不是直接返回 database is locked
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := fmt.Sprintf("file:%s?_busy_timeout=10000&_journal_mode=WAL", filepath.Join(t.TempDir(), "blog.db"))
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
+		Logger:                                   logger.Default.LogMode(logger.Silent),
+		DisableForeignKeyConstraintWhenMigrating: true,
+		NamingStrategy:                           &config.CustomNamingStrategy{},
+	})
+	if err != nil {
+		t.Fatalf("打开测试数据库失败: %v", err)
+	}
+	if err := db.AutoMigrate(&models.Category{}, &models.Tag{}); err != nil {
+		t.Fatalf("迁移测试数据库失败: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("获取数据库连接池失败: %v", err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	InitServices(db)
+	return db
+}
+
+// runConcurrently 同时启动n个goroutine执行fn，等待全部结束
+func runConcurrently(n int, fn func(i int)) {
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			fn(i)
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+}
+
+// TestCategoryFirstOrCreateBySlugConcurrent 并发创建同一slug的分类，只插入一行，所有调用返回同一条记录
+func TestCategoryFirstOrCreateBySlugConcurrent(t *testing.T) {
+	db := newTestDB(t)
+	const workers = 20
+
+	categories := make([]*models.Category, workers)
+	created := make([]bool, workers)
+	errs := make([]error, workers)
+	runConcurrently(workers, func(i int) {
+		categories[i], created[i], errs[i] = CategoryService.FirstOrCreateBySlug("golang", models.Category{
+			Name: fmt.Sprintf("Go语言%d", i),
+		})
+	})
+
+	createdCount := 0
+	for i := 0; i < workers; i++ {
+		if errs[i] != nil {
+			t.Fatalf("第%d个调用失败: %v", i, errs[i])
+		}
+		if created[i] {
+			createdCount++
+		}
+		if categories[i].ID == 0 || categories[i].ID != categories[0].ID || categories[i].Name != categories[0].Name {
+			t.Errorf("第%d个调用返回 id=%d name=%s，与第0个 id=%d name=%s 不同",
+				i, categories[i].ID, categories[i].Name, categories[0].ID, categories[0].Name)
+		}
+	}
+	if createdCount != 1 {
+		t.Errorf("报告新建的调用有%d个，期望1个", createdCount)
+	}
+
+	var count int64
+	if err := db.Model(&models.Category{}).Where("slug = ?", "golang").Count(&count).Error; err != nil {
+		t.Fatalf("统计分类失败: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("slug为golang的分类有%d行，期望1行", count)
+	}
+}
+
+// TestTagFirstOrCreateBySlugConcurrent 并发创建同一slug的标签，只插入一行，所有调用返回同一条记录
+func TestTagFirstOrCreateBySlugConcurrent(t *testing.T) {
+	db := newTestDB(t)
+	const workers = 20
+
+	tags := make([]*models.Tag, workers)
+	created := make([]bool, workers)
+	errs := make([]error, workers)
+	runConcurrently(workers, func(i int) {
+		tags[i], created[i], errs[i] = TagService.FirstOrCreateBySlug("gorm", models.Tag{Name: "GORM"})
+	})
+
+	createdCount := 0
+	for i := 0; i < workers; i++ {
+		if errs[i] != nil {
+			t.Fatalf("第%d个调用失败: %v", i, errs[i])
+		}
+		if created[i] {
+			createdCount++
+		}
+		if tags[i].ID == 0 || tags[i].ID != tags[0].ID {
+			t.Errorf("第%d个调用返回 id=%d，与第0个 id=%d 不同", i, tags[i].ID, tags[0].ID)
+		}
+	}
+	if createdCount != 1 {
+		t.Errorf("报告新建的调用有%d个，期望1个", createdCount)
+	}
+
+	var count int64
+	if err := db.Model(&models.Tag{}).Where("slug = ?", "gorm").Count(&count).Error; err != nil {
+		t.Fatalf("统计标签失败: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("slug为gorm的标签有%d行，期望1行", count)
+	}
+}
+
+// TestFirstOrCreateBySlugSoftDeleted slug被已软删除的记录占用时返回错误，不新建也不返回已删除的记录
+func TestFirstOrCreateBySlugSoftDeleted(t *testing.T) {
+	db := newTestDB(t)
+
+	category, created, err := CategoryService.FirstOrCreateBySlug("archived", models.Category{Name: "归档"})
+	if err != nil || !created {
+		t.Fatalf("首次创建: created=%v err=%v", created, err)
+	}
+	if err := db.Delete(category).Error; err != nil {
+		t.Fatalf("软删除分类失败: %v", err)
+	}
+
+	if _, _, err := CategoryService.FirstOrCreateBySlug("archived", models.Category{Name: "归档"}); err == nil {
+		t.Fatal("slug被已删除的分类占用时应返回错误")
+	}
+}
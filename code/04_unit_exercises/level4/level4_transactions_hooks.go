@@ -9,10 +9,16 @@
 package main
 
 import (
-	"errors" // 错误处理
-	"fmt"    // 格式化输出
-	"log"    // 日志记录
-	"time"   // 时间处理
+	"context"       // 定时计息的取消控制
+	"encoding/json" // 审计日志值的JSON编码
+	"errors"        // 错误处理
+	"fmt"           // 格式化输出
+	"log"           // 日志记录
+	"math"          // 金额取整
+	"reflect"       // 审计日志值比较
+	"sort"          // 审计日志字段排序
+	"strings"       // 字符串处理
+	"time"          // 时间处理
 
 	"gorm.io/driver/mysql"  // MySQL数据库驱动
 	"gorm.io/driver/sqlite" // SQLite数据库驱动
@@ -97,6 +103,10 @@ type Account struct {
 	Currency    string  `gorm:"size:3;not null;default:'CNY'" json:"currency"`          // 货币类型，3位货币代码，默认人民币
 	IsActive    bool    `gorm:"default:true;index" json:"is_active"`                    // 账户是否激活，默认激活，建立索引
 	DailyLimit  float64 `gorm:"precision:15;scale:2;default:10000" json:"daily_limit"`  // 日交易限额，默认10000
+	Frozen      bool    `gorm:"default:false;index" json:"frozen"`                      // 是否被冻结（如涉嫌欺诈），冻结期间只能入账，不能取款和转出
+
+	// 计息相关字段
+	LastInterestDate string `gorm:"size:10" json:"last_interest_date,omitempty"` // 最近一次计息的日期（2006-01-02），储蓄账户同一天只计息一次
 
 	// 关联关系定义
 	User         User          `gorm:"foreignKey:UserID" json:"user,omitempty"`            // 所属用户，通过UserID外键关联
@@ -116,6 +126,7 @@ type User struct {
 	FullName    string     `gorm:"size:100;not null" json:"full_name"`           // 用户全名，最大100字符，非空
 	Phone       string     `gorm:"size:20;index" json:"phone"`                   // 手机号码，最大20字符，建立索引
 	IsActive    bool       `gorm:"default:true;index" json:"is_active"`          // 用户是否激活，默认激活，建立索引
+	IsAdmin     bool       `gorm:"default:false" json:"is_admin"`                // 是否为管理员，管理员才能查询审计日志
 	LastLoginAt *time.Time `json:"last_login_at"`                                // 最后登录时间，可为空
 
 	// 关联关系定义
@@ -132,21 +143,24 @@ type User struct {
 // 记录交易前后的余额变化，确保数据一致性
 type Transaction struct {
 	BaseModel               // 继承基础模型字段
-	AccountID       uint    `gorm:"not null;index" json:"account_id"`                       // 账户ID外键，建立索引，非空
-	UserID          uint    `gorm:"not null;index" json:"user_id"`                          // 用户ID外键，建立索引，非空
-	TransactionType string  `gorm:"size:20;not null;index" json:"transaction_type"`         // 交易类型：deposit(存款), withdraw(取款), transfer(转账)
-	Amount          float64 `gorm:"precision:15;scale:2;not null" json:"amount"`            // 交易金额，精度15位，小数点后2位，非空
-	BalanceBefore   float64 `gorm:"precision:15;scale:2;not null" json:"balance_before"`    // 交易前账户余额，用于审计和对账
-	BalanceAfter    float64 `gorm:"precision:15;scale:2;not null" json:"balance_after"`     // 交易后账户余额，用于审计和对账
-	Description     string  `gorm:"size:500" json:"description"`                            // 交易描述，最大500字符
-	Reference       string  `gorm:"size:100;index" json:"reference"`                        // 交易参考号，用于交易追踪，建立索引
-	Status          string  `gorm:"size:20;not null;default:'pending';index" json:"status"` // 交易状态：pending(待处理), completed(已完成), failed(失败), cancelled(已取消)
+	AccountID       uint    `gorm:"not null;index;uniqueIndex:idx_account_reference,priority:1" json:"account_id"` // 账户ID外键，建立索引，非空；与参考号组成唯一索引
+	UserID          uint    `gorm:"not null;index" json:"user_id"`                                                 // 用户ID外键，建立索引，非空
+	TransactionType string  `gorm:"size:20;not null;index" json:"transaction_type"`                                // 交易类型：deposit(存款), withdraw(取款), transfer(转账), interest(利息)
+	Amount          float64 `gorm:"precision:15;scale:2;not null" json:"amount"`                                   // 交易金额，精度15位，小数点后2位，非空
+	BalanceBefore   float64 `gorm:"precision:15;scale:2;not null" json:"balance_before"`                           // 交易前账户余额，用于审计和对账
+	BalanceAfter    float64 `gorm:"precision:15;scale:2;not null" json:"balance_after"`                            // 交易后账户余额，用于审计和对账
+	Description     string  `gorm:"size:500" json:"description"`                                                   // 交易描述，最大500字符
+	Reference       string  `gorm:"size:100;index;uniqueIndex:idx_account_reference,priority:2" json:"reference"`  // 交易参考号，用于交易追踪；同一账户下唯一，重复提交的交易不会执行两次
+	Status          string  `gorm:"size:20;not null;default:'pending';index" json:"status"`                        // 交易状态：pending(待处理), completed(已完成), failed(失败), cancelled(已取消)
 
 	// 转账相关字段
 	ToAccountID  *uint   `gorm:"index" json:"to_account_id,omitempty"`                // 转账目标账户ID，仅转账交易使用，建立索引
 	TransferFee  float64 `gorm:"precision:15;scale:2;default:0" json:"transfer_fee"`  // 转账手续费，默认为0
 	ExchangeRate float64 `gorm:"precision:10;scale:6;default:1" json:"exchange_rate"` // 汇率，用于跨币种转账，默认为1
 
+	// 查询结果字段，不存储到数据库
+	RunningBalance float64 `gorm:"-" json:"running_balance,omitempty"` // 由交易记录推算的交易后余额，仅对账单查询时填充
+
 	// 关联关系定义
 	Account   Account  `gorm:"foreignKey:AccountID" json:"account,omitempty"`      // 交易所属账户
 	User      User     `gorm:"foreignKey:UserID" json:"user,omitempty"`            // 交易发起用户
@@ -160,15 +174,18 @@ type Transaction struct {
 // 包含IP地址和用户代理信息，用于安全分析
 type AuditLog struct {
 	BaseModel          // 继承基础模型字段
-	UserID      uint   `gorm:"not null;index" json:"user_id"`            // 操作用户ID外键，建立索引，非空
-	Action      string `gorm:"size:50;not null;index" json:"action"`     // 操作类型：CREATE(创建), UPDATE(更新), DELETE(删除)
-	TableName   string `gorm:"size:50;not null;index" json:"table_name"` // 操作的数据表名，建立索引
-	RecordID    uint   `gorm:"not null;index" json:"record_id"`          // 操作记录的ID，建立索引
-	OldValues   string `gorm:"type:text" json:"old_values"`              // 操作前的数据值，JSON格式存储
-	NewValues   string `gorm:"type:text" json:"new_values"`              // 操作后的数据值，JSON格式存储
-	IPAddress   string `gorm:"size:45" json:"ip_address"`                // 操作者IP地址，支持IPv4和IPv6
-	UserAgent   string `gorm:"size:500" json:"user_agent"`               // 用户代理字符串，用于识别客户端
-	Description string `gorm:"size:500" json:"description"`              // 操作描述，最大500字符
+	UserID      uint   `gorm:"not null;index" json:"user_id"`                                              // 操作用户ID外键，建立索引，非空
+	Action      string `gorm:"size:50;not null;index" json:"action"`                                       // 操作类型：CREATE(创建), UPDATE(更新), DELETE(删除)
+	TableName   string `gorm:"size:50;not null;index:idx_audit_table_record,priority:1" json:"table_name"` // 操作的数据表名，与RecordID组成联合索引
+	RecordID    uint   `gorm:"not null;index;index:idx_audit_table_record,priority:2" json:"record_id"`    // 操作记录的ID，建立索引
+	OldValues   string `gorm:"type:text" json:"old_values"`                                                // 操作前的数据值，JSON格式存储
+	NewValues   string `gorm:"type:text" json:"new_values"`                                                // 操作后的数据值，JSON格式存储
+	IPAddress   string `gorm:"size:45" json:"ip_address"`                                                  // 操作者IP地址，支持IPv4和IPv6
+	UserAgent   string `gorm:"size:500" json:"user_agent"`                                                 // 用户代理字符串，用于识别客户端
+	Description string `gorm:"size:500" json:"description"`                                                // 操作描述，最大500字符
+
+	// 查询结果字段，不存储到数据库
+	Changes []AuditChange `gorm:"-" json:"changes,omitempty"` // 由OldValues和NewValues解析出的字段变更，仅审计查询时填充
 
 	// 关联关系定义
 	User User `gorm:"foreignKey:UserID" json:"user,omitempty"` // 执行操作的用户
@@ -252,12 +269,12 @@ func (u *User) AfterCreate(tx *gorm.DB) error {
 	// 记录审计日志
 	// 为新用户创建操作记录审计日志，用于安全监控和合规要求
 	auditLog := AuditLog{
-		UserID:      u.ID,                                                        // 操作用户ID
-		Action:      "CREATE",                                                    // 操作类型为创建
-		TableName:   "users",                                                     // 操作的表名
-		RecordID:    u.ID,                                                        // 操作记录的ID
-		NewValues:   fmt.Sprintf("username: %s, email: %s", u.Username, u.Email), // 记录新创建的数据值
-		Description: "新用户注册",                                                     // 操作描述
+		UserID:      u.ID,                                                                          // 操作用户ID
+		Action:      "CREATE",                                                                      // 操作类型为创建
+		TableName:   "users",                                                                       // 操作的表名
+		RecordID:    u.ID,                                                                          // 操作记录的ID
+		NewValues:   auditValues(map[string]interface{}{"username": u.Username, "email": u.Email}), // 记录新创建的数据值
+		Description: "新用户注册",                                                                       // 操作描述
 	}
 
 	// 在同一事务中创建审计日志
@@ -310,12 +327,12 @@ func (u *User) AfterUpdate(tx *gorm.DB) error {
 	// 记录审计日志
 	// 用户信息更新是敏感操作，需要记录审计日志用于安全监控
 	auditLog := AuditLog{
-		UserID:      u.ID,                                                        // 操作用户ID
-		Action:      "UPDATE",                                                    // 操作类型为更新
-		TableName:   "users",                                                     // 操作的表名
-		RecordID:    u.ID,                                                        // 操作记录的ID
-		NewValues:   fmt.Sprintf("username: %s, email: %s", u.Username, u.Email), // 记录更新后的数据值
-		Description: "用户信息更新",                                                    // 操作描述
+		UserID:      u.ID,                                                                          // 操作用户ID
+		Action:      "UPDATE",                                                                      // 操作类型为更新
+		TableName:   "users",                                                                       // 操作的表名
+		RecordID:    u.ID,                                                                          // 操作记录的ID
+		NewValues:   auditValues(map[string]interface{}{"username": u.Username, "email": u.Email}), // 记录更新后的数据值
+		Description: "用户信息更新",                                                                      // 操作描述
 	}
 
 	// 在同一事务中创建审计日志，确保数据一致性
@@ -387,12 +404,12 @@ func (a *Account) AfterCreate(tx *gorm.DB) error {
 	// 记录审计日志
 	// 账户创建是重要的业务操作，需要记录审计日志用于合规和安全监控
 	auditLog := AuditLog{
-		UserID:      a.UserID,                                                                 // 操作用户ID
-		Action:      "CREATE",                                                                 // 操作类型为创建
-		TableName:   "accounts",                                                               // 操作的表名
-		RecordID:    a.ID,                                                                     // 新创建账户的ID
-		NewValues:   fmt.Sprintf("account_type: %s, balance: %.2f", a.AccountType, a.Balance), // 记录新账户的关键信息
-		Description: "新账户创建",                                                                  // 操作描述
+		UserID:      a.UserID,                                                                                 // 操作用户ID
+		Action:      "CREATE",                                                                                 // 操作类型为创建
+		TableName:   "accounts",                                                                               // 操作的表名
+		RecordID:    a.ID,                                                                                     // 新创建账户的ID
+		NewValues:   auditValues(map[string]interface{}{"account_type": a.AccountType, "balance": a.Balance}), // 记录新账户的关键信息
+		Description: "新账户创建",                                                                                  // 操作描述
 	}
 
 	// 在同一事务中创建审计日志，确保数据一致性
@@ -414,18 +431,27 @@ func (t *Transaction) BeforeCreate(tx *gorm.DB) error {
 		t.AccountID, t.TransactionType, t.Amount)
 
 	// 验证交易类型
-	// 银行系统只支持三种基本交易类型：存款、取款和转账
+	// 银行系统支持存款、取款、转账三种基本交易类型，以及系统生成的利息交易
 	// 这是核心业务规则，确保系统只处理合法的交易类型
-	validTypes := []string{"deposit", "withdraw", "transfer"}
+	validTypes := []string{"deposit", "withdraw", "transfer", "interest"}
 	if !containsString(validTypes, t.TransactionType) {
 		return errors.New("无效的交易类型")
 	}
 
 	// 验证交易金额
-	// 银行业务规则：所有交易金额必须为正数，不允许零金额或负金额交易
-	// 这是基本的数据完整性检查，防止异常交易
-	if t.Amount <= 0 {
-		return errors.New("交易金额必须大于0")
+	// 银行业务规则：所有交易金额必须为正数且最多两位小数，不允许零金额、负金额或NaN等异常值
+	// 这是基本的数据完整性检查，防止异常交易；之后的余额计算都按分进行
+	amountCents, err := toCents(t.Amount)
+	if err != nil {
+		return err
+	}
+
+	// 检查参考号是否重复
+	// 客户端重试时会带上相同的参考号，同一账户下已有该参考号的交易时拒绝，避免重复记账
+	if t.Reference != "" {
+		if original, ok := findTransactionByReference(tx, t.AccountID, t.Reference); ok {
+			return &DuplicateTransactionError{Original: *original}
+		}
 	}
 
 	// 获取并验证账户信息
@@ -445,13 +471,19 @@ func (t *Transaction) BeforeCreate(tx *gorm.DB) error {
 	// 记录交易前余额
 	// 用于审计追踪和数据一致性验证
 	t.BalanceBefore = account.Balance
+	balanceCents := balanceToCents(account.Balance)
 
 	// 对取款和转账交易进行额外验证
 	// 这些交易会减少账户余额，需要进行余额和限额检查
 	if t.TransactionType == "withdraw" || t.TransactionType == "transfer" {
+		// 冻结的账户不能出账，存款、转入和利息不受影响
+		if account.Frozen {
+			return ErrAccountFrozen
+		}
+
 		// 验证账户余额是否充足
 		// 防止透支，确保账户资金安全
-		if account.Balance < t.Amount {
+		if balanceCents < amountCents {
 			return errors.New("账户余额不足")
 		}
 
@@ -466,24 +498,24 @@ func (t *Transaction) BeforeCreate(tx *gorm.DB) error {
 			Select("COALESCE(SUM(amount), 0)").Scan(&todayWithdrawTotal)
 
 		// 验证是否超出日限额
-		if todayWithdrawTotal+t.Amount > account.DailyLimit {
+		if balanceToCents(todayWithdrawTotal)+amountCents > balanceToCents(account.DailyLimit) {
 			return fmt.Errorf("超出日限额 %.2f，今日已使用 %.2f",
 				account.DailyLimit, todayWithdrawTotal)
 		}
 
 		// 计算交易后余额（减少）
-		t.BalanceAfter = account.Balance - t.Amount
+		t.BalanceAfter = fromCents(balanceCents - amountCents)
 	} else {
 		// 存款交易：计算交易后余额（增加）
-		t.BalanceAfter = account.Balance + t.Amount
+		t.BalanceAfter = fromCents(balanceCents + amountCents)
 	}
 
 	// 生成唯一交易参考号
 	// 如果没有提供参考号，系统自动生成一个基于交易类型、账户ID和时间戳的唯一标识
-	// 格式：交易类型_账户ID_时间戳，确保交易的可追溯性和唯一性
+	// 格式：交易类型_账户ID_纳秒时间戳，同一账户同一秒内的多笔交易也不会重复
 	if t.Reference == "" {
 		t.Reference = fmt.Sprintf("%s_%d_%d",
-			t.TransactionType, t.AccountID, time.Now().Unix())
+			t.TransactionType, t.AccountID, time.Now().UnixNano())
 	}
 
 	return nil
@@ -499,19 +531,19 @@ func (t *Transaction) AfterCreate(tx *gorm.DB) error {
 	fmt.Printf("[Hook] 交易创建后: ID %d, 参考号 %s\n", t.ID, t.Reference)
 
 	// 更新账户余额
-	// 根据交易类型计算余额变化：存款为正，取款和转账为负
+	// 根据交易类型计算余额变化：存款和利息为正，取款和转账为负
 	// 使用数据库级别的原子操作确保并发安全
 	var balanceChange float64
-	if t.TransactionType == "deposit" {
-		balanceChange = t.Amount // 存款增加余额
+	if isCreditType(t.TransactionType) {
+		balanceChange = t.Amount // 存款和利息增加余额
 	} else {
 		balanceChange = -t.Amount // 取款和转账减少余额
 	}
 
 	// 使用GORM的Expr进行原子更新，避免并发问题
-	// 直接在数据库层面进行余额计算，确保数据一致性
+	// 直接在数据库层面进行余额计算并四舍五入到分，确保数据一致性，避免浮点误差在余额中累积
 	if err := tx.Model(&Account{}).Where("id = ?", t.AccountID).
-		Update("balance", gorm.Expr("balance + ?", balanceChange)).Error; err != nil {
+		Update("balance", gorm.Expr("ROUND(balance + ?, 2)", balanceChange)).Error; err != nil {
 		return fmt.Errorf("更新账户余额失败: %v", err)
 	}
 
@@ -530,8 +562,8 @@ func (t *Transaction) AfterCreate(tx *gorm.DB) error {
 		Action:    "CREATE",       // 操作类型
 		TableName: "transactions", // 操作表名
 		RecordID:  t.ID,           // 交易记录ID
-		NewValues: fmt.Sprintf("type: %s, amount: %.2f, reference: %s", // 记录交易详情
-			t.TransactionType, t.Amount, t.Reference),
+		NewValues: auditValues(map[string]interface{}{ // 记录交易详情
+			"type": t.TransactionType, "amount": t.Amount, "reference": t.Reference}),
 		Description: fmt.Sprintf("%s 交易", t.TransactionType), // 操作描述
 	}
 
@@ -567,6 +599,12 @@ func (t *Transaction) AfterCreate(tx *gorm.DB) error {
 }
 
 // 辅助函数
+
+// isCreditType 是否为增加余额的交易类型：存款（含转入）和利息
+func isCreditType(transactionType string) bool {
+	return transactionType == "deposit" || transactionType == "interest"
+}
+
 func contains(s, substr string) bool {
 	for i := 0; i <= len(s)-len(substr); i++ {
 		if s[i:i+len(substr)] == substr {
@@ -762,13 +800,47 @@ func CreateUserWithAccount(db *gorm.DB, username, email, fullName string, initia
 // 参数 db: GORM数据库实例
 // 参数 fromAccountID: 转出账户ID
 // 参数 toAccountID: 转入账户ID
-// 参数 amount: 转账金额（必须大于0）
+// 参数 amount: 转账金额（必须大于0，最多两位小数，否则返回 ErrInvalidAmount）
 // 参数 description: 转账描述信息
 // 返回 error: 操作过程中的错误信息
 func TransferMoney(db *gorm.DB, fromAccountID, toAccountID uint, amount float64, description string) error {
+	_, err := TransferMoneyWithReference(db, fromAccountID, toAccountID, amount, description, "")
+	return err
+}
+
+// TransferMoneyWithReference 按参考号幂等地转账，跨币种时使用 DefaultRateProvider 的汇率
+// 参考号为空时自动生成；转出账户下已有该参考号的交易时不再转账，返回原转出交易和 ErrDuplicateTransaction
+// 参数 reference: 转账参考号，客户端重试时须使用同一参考号
+// 返回 *Transaction: 转出交易（重复提交时为第一次的转出交易）
+// 返回 error: 操作过程中的错误信息
+func TransferMoneyWithReference(db *gorm.DB, fromAccountID, toAccountID uint, amount float64, description, reference string) (*Transaction, error) {
+	return TransferMoneyWithRates(db, DefaultRateProvider, fromAccountID, toAccountID, amount, description, reference)
+}
+
+// TransferMoneyWithRates 按指定的汇率来源转账
+// amount 为转出账户币种的金额；两个账户币种不同时按汇率换算为转入账户币种的金额（四舍五入到分）入账，
+// 转出和转入两笔交易都记录所用汇率；没有对应汇率时拒绝转账，返回 ErrRateUnavailable
+// 参数 rates: 汇率来源，为nil时只能在相同币种的账户间转账
+// 返回 *Transaction: 转出交易（重复提交时为第一次的转出交易）
+// 返回 error: 操作过程中的错误信息
+func TransferMoneyWithRates(db *gorm.DB, rates RateProvider, fromAccountID, toAccountID uint, amount float64, description, reference string) (*Transaction, error) {
+	// 金额在进入事务前校验，之后按分计算，只在写入交易记录时转换回元
+	amountCents, err := toCents(amount)
+	if err != nil {
+		return nil, err
+	}
+
+	// 重试的转账直接返回原交易，不再检查账户状态和余额
+	if reference != "" {
+		if original, ok := findTransactionByReference(db, fromAccountID, reference); ok {
+			return original, &DuplicateTransactionError{Original: *original}
+		}
+	}
+
+	var withdrawTx Transaction
 	// 使用GORM事务确保转账操作的原子性
 	// 转账涉及多个数据库操作，必须保证要么全部成功，要么全部失败
-	return db.Transaction(func(tx *gorm.DB) error {
+	err = db.Transaction(func(tx *gorm.DB) error {
 		// 验证转出和转入账户的存在性和活跃状态
 		// 只有活跃的账户才能参与转账操作
 		var fromAccount, toAccount Account
@@ -778,6 +850,9 @@ func TransferMoney(db *gorm.DB, fromAccountID, toAccountID uint, amount float64,
 		if err := tx.Where("id = ? AND is_active = ?", fromAccountID, true).First(&fromAccount).Error; err != nil {
 			return fmt.Errorf("源账户不存在或已冻结: %v", err)
 		}
+		if fromAccount.Frozen {
+			return ErrAccountFrozen
+		}
 
 		// 查询并验证转入账户
 		// 同样检查账户的存在性和活跃状态
@@ -791,24 +866,38 @@ func TransferMoney(db *gorm.DB, fromAccountID, toAccountID uint, amount float64,
 			return errors.New("不能向同一账户转账")
 		}
 
+		// 确定汇率和转入金额
+		// 相同币种汇率为1；不同币种按汇率换算为转入账户币种的金额
+		rate, err := exchangeRate(rates, fromAccount.Currency, toAccount.Currency)
+		if err != nil {
+			return err
+		}
+		creditCents := int64(math.Round(float64(amountCents) * rate))
+		creditAmount := fromCents(creditCents)
+		if creditCents <= 0 {
+			return fmt.Errorf("转账金额 %.2f %s 换算后不足 0.01 %s", amount, fromAccount.Currency, toAccount.Currency)
+		}
+
 		// 创建转出交易记录
 		// 记录资金从源账户转出的操作
 		// 会触发Transaction的BeforeCreate钩子进行余额验证
-		withdrawTx := Transaction{
+		withdrawTx = Transaction{
 			AccountID:       fromAccountID,                                         // 转出账户ID
 			UserID:          fromAccount.UserID,                                    // 转出账户所属用户ID
 			TransactionType: "transfer",                                            // 交易类型：转账
-			Amount:          amount,                                                // 转账金额
+			Amount:          fromCents(amountCents),                                // 转账金额
 			Description:     fmt.Sprintf("转账至账户 %d: %s", toAccountID, description), // 交易描述
 			ToAccountID:     &toAccountID,                                          // 目标账户ID（用于关联转账记录）
+			Reference:       reference,                                             // 转账参考号，为空时由钩子生成
 			Status:          "pending",                                             // 交易状态：待处理
+			ExchangeRate:    rate,                                                  // 转出币种到转入币种的汇率
 		}
 
 		// 在事务中创建转出交易记录
 		// BeforeCreate钩子会验证余额是否充足
 		// AfterCreate钩子会更新账户余额并记录审计日志
 		if err := tx.Create(&withdrawTx).Error; err != nil {
-			return fmt.Errorf("创建转出交易失败: %v", err)
+			return fmt.Errorf("创建转出交易失败: %w", err)
 		}
 
 		// 创建转入交易记录
@@ -817,16 +906,17 @@ func TransferMoney(db *gorm.DB, fromAccountID, toAccountID uint, amount float64,
 			AccountID:       toAccountID,                                                // 转入账户ID
 			UserID:          toAccount.UserID,                                           // 转入账户所属用户ID
 			TransactionType: "deposit",                                                  // 交易类型：存款
-			Amount:          amount,                                                     // 转账金额
+			Amount:          creditAmount,                                               // 转入金额（转入账户币种）
 			Description:     fmt.Sprintf("来自账户 %d 的转账: %s", fromAccountID, description), // 交易描述
 			Reference:       withdrawTx.Reference,                                       // 使用相同的参考号关联转账记录
 			Status:          "pending",                                                  // 交易状态：待处理
+			ExchangeRate:    rate,                                                       // 与转出交易相同的汇率
 		}
 
 		// 手动设置余额变化信息
 		// 虽然钩子函数会自动处理余额更新，但这里预设值有助于数据一致性检查
-		depositTx.BalanceBefore = toAccount.Balance         // 转账前余额
-		depositTx.BalanceAfter = toAccount.Balance + amount // 转账后余额
+		depositTx.BalanceBefore = toAccount.Balance                                         // 转账前余额
+		depositTx.BalanceAfter = fromCents(balanceToCents(toAccount.Balance) + creditCents) // 转账后余额
 
 		// 在事务中创建转入交易记录
 		// AfterCreate钩子会更新目标账户余额并发送通知
@@ -834,9 +924,18 @@ func TransferMoney(db *gorm.DB, fromAccountID, toAccountID uint, amount float64,
 			return fmt.Errorf("创建转入交易失败: %v", err)
 		}
 
+		if rate != 1 {
+			fmt.Printf("✓ 转账成功: 从账户 %d 向账户 %d 转账 %.2f %s，按汇率 %.6f 入账 %.2f %s\n",
+				fromAccountID, toAccountID, amount, fromAccount.Currency, rate, creditAmount, toAccount.Currency)
+			return nil
+		}
 		fmt.Printf("✓ 转账成功: 从账户 %d 向账户 %d 转账 %.2f\n", fromAccountID, toAccountID, amount)
 		return nil
 	})
+	if err != nil {
+		return duplicateOrError(db, fromAccountID, reference, err)
+	}
+	return &withdrawTx, nil
 }
 
 // BatchCreateTransactions 批量创建交易（事务）
@@ -917,6 +1016,8 @@ func UpdateAccountStatus(db *gorm.DB, accountID uint, isActive bool, reason stri
 
 		// 更新账户的活跃状态
 		// 会触发Account模型的BeforeUpdate和AfterUpdate钩子
+		// Update会同时修改account.IsActive，先保存变更前的值用于审计日志
+		wasActive := account.IsActive
 		if err := tx.Model(&account).Update("is_active", isActive).Error; err != nil {
 			return fmt.Errorf("更新账户状态失败: %v", err)
 		}
@@ -924,13 +1025,13 @@ func UpdateAccountStatus(db *gorm.DB, accountID uint, isActive bool, reason stri
 		// 创建审计日志记录
 		// 记录账户状态变更的详细信息，用于合规性和安全审计
 		auditLog := AuditLog{
-			UserID:      account.UserID,                                 // 账户所属用户ID
-			Action:      "UPDATE",                                       // 操作类型：更新
-			TableName:   "accounts",                                     // 操作的表名
-			RecordID:    accountID,                                      // 被操作记录的ID
-			OldValues:   fmt.Sprintf("is_active: %t", account.IsActive), // 变更前的值
-			NewValues:   fmt.Sprintf("is_active: %t", isActive),         // 变更后的值
-			Description: fmt.Sprintf("账户状态变更: %s", reason),              // 变更描述和原因
+			UserID:      account.UserID,                                              // 账户所属用户ID
+			Action:      "UPDATE",                                                    // 操作类型：更新
+			TableName:   "accounts",                                                  // 操作的表名
+			RecordID:    accountID,                                                   // 被操作记录的ID
+			OldValues:   auditValues(map[string]interface{}{"is_active": wasActive}), // 变更前的值
+			NewValues:   auditValues(map[string]interface{}{"is_active": isActive}),  // 变更后的值
+			Description: fmt.Sprintf("账户状态变更: %s", reason),                           // 变更描述和原因
 		}
 
 		// 在事务中创建审计日志
@@ -1042,6 +1143,685 @@ func GetAuditLogs(db *gorm.DB, userID uint, limit int) ([]AuditLog, error) {
 	return logs, err
 }
 
+// ==================== 审计日志查询 ====================
+// 审计日志的OldValues和NewValues以JSON对象存储，查询时解析为逐字段的变更列表
+// 审计日志包含所有用户的操作记录，只允许管理员查询
+
+// ErrAuditForbidden 非管理员查询审计日志时返回的错误
+var ErrAuditForbidden = errors.New("只有管理员可以查询审计日志")
+
+// AuditChange 审计日志中单个字段的变更
+// 创建操作只有New，删除操作只有Old，更新操作两者都有
+type AuditChange struct {
+	Field string      `json:"field"`         // 字段名
+	Old   interface{} `json:"old,omitempty"` // 变更前的值
+	New   interface{} `json:"new,omitempty"` // 变更后的值
+}
+
+// AuditFilter 审计日志查询条件，零值的条件不生效
+type AuditFilter struct {
+	UserID    uint      // 操作用户ID
+	Action    string    // 操作类型：CREATE、UPDATE、DELETE
+	TableName string    // 数据表名
+	RecordID  uint      // 记录ID，通常与TableName一起使用
+	StartTime time.Time // 创建时间 >= StartTime
+	EndTime   time.Time // 创建时间 < EndTime
+}
+
+// AuditService 审计日志查询服务
+// operatorID为发起查询的用户，只有管理员可以查询
+type AuditService struct {
+	db         *gorm.DB
+	operatorID uint
+}
+
+// NewAuditService 创建审计日志查询服务
+// 参数 db: GORM数据库实例
+// 参数 operatorID: 发起查询的用户ID
+func NewAuditService(db *gorm.DB, operatorID uint) *AuditService {
+	return &AuditService{db: db, operatorID: operatorID}
+}
+
+// Search 按条件分页查询审计日志，按创建时间倒序（最新的在前）
+// 按表名+记录ID查询时使用 idx_audit_table_record 联合索引
+// 参数 filter: 查询条件
+// 参数 page: 页码，从1开始
+// 参数 pageSize: 每页数量，默认20，最大100
+// 返回 []AuditLog: 当前页的审计日志，Changes字段为解析出的字段变更
+// 返回 int64: 符合条件的总数
+// 返回 error: 非管理员查询时返回 ErrAuditForbidden
+func (s *AuditService) Search(filter AuditFilter, page, pageSize int) ([]AuditLog, int64, error) {
+	// 检查查询者是否为管理员
+	var operator User
+	if err := s.db.Select("id", "is_admin").First(&operator, s.operatorID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, 0, ErrAuditForbidden
+		}
+		return nil, 0, err
+	}
+	if !operator.IsAdmin {
+		return nil, 0, ErrAuditForbidden
+	}
+
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	// 构建查询条件，总数和列表使用同一组条件
+	query := s.db.Model(&AuditLog{})
+	if filter.UserID != 0 {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.TableName != "" {
+		query = query.Where("table_name = ?", filter.TableName)
+	}
+	if filter.RecordID != 0 {
+		query = query.Where("record_id = ?", filter.RecordID)
+	}
+	if !filter.StartTime.IsZero() {
+		query = query.Where("created_at >= ?", filter.StartTime)
+	}
+	if !filter.EndTime.IsZero() {
+		query = query.Where("created_at < ?", filter.EndTime)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var logs []AuditLog
+	// 同一时间的记录按ID倒序，分页结果稳定
+	err := query.Order("created_at DESC").Order("id DESC").
+		Offset((page - 1) * pageSize).Limit(pageSize).
+		Find(&logs).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// 解析每条日志的变更前后值
+	for i := range logs {
+		logs[i].Changes = diffAuditValues(logs[i].OldValues, logs[i].NewValues)
+	}
+	return logs, total, nil
+}
+
+// auditValues 将字段值编码为JSON对象，用作审计日志的OldValues或NewValues
+func auditValues(fields map[string]interface{}) string {
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return fmt.Sprintf("%v", fields)
+	}
+	return string(data)
+}
+
+// parseAuditValues 解析审计日志中存储的值
+// 优先按JSON对象解析；早期记录为 "key: value, key: value" 格式，解析为字符串值
+func parseAuditValues(values string) map[string]interface{} {
+	fields := make(map[string]interface{})
+	if strings.TrimSpace(values) == "" {
+		return fields
+	}
+	if err := json.Unmarshal([]byte(values), &fields); err == nil {
+		return fields
+	}
+
+	fields = make(map[string]interface{})
+	for _, pair := range strings.Split(values, ", ") {
+		key, value, ok := strings.Cut(pair, ": ")
+		if !ok {
+			continue
+		}
+		fields[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	return fields
+}
+
+// diffAuditValues 比较变更前后的值，返回按字段名排序的变更列表，前后相同的字段不列出
+func diffAuditValues(oldValues, newValues string) []AuditChange {
+	oldFields := parseAuditValues(oldValues)
+	newFields := parseAuditValues(newValues)
+
+	names := make([]string, 0, len(oldFields)+len(newFields))
+	for name := range oldFields {
+		names = append(names, name)
+	}
+	for name := range newFields {
+		if _, ok := oldFields[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var changes []AuditChange
+	for _, name := range names {
+		oldValue, newValue := oldFields[name], newFields[name]
+		if reflect.DeepEqual(oldValue, newValue) {
+			continue
+		}
+		changes = append(changes, AuditChange{Field: name, Old: oldValue, New: newValue})
+	}
+	return changes
+}
+
+// ==================== 账户对账单 ====================
+// 对账单列出账户在一段时间内已完成的交易，并按交易记录推算每笔交易后的余额
+// 转入的钱由TransferMoney在目标账户下记为一笔deposit交易（与转出方的transfer交易参考号相同），
+// 因此按account_id查询即包含转入；转出方那笔ToAccountID为本账户的记录改变的是转出账户的余额，不计入本账户
+
+// AccountService 账户服务：对账单查询、冻结和解冻
+type AccountService struct {
+	db *gorm.DB
+}
+
+// NewAccountService 创建账户查询服务
+// 参数 db: GORM数据库实例
+func NewAccountService(db *gorm.DB) *AccountService {
+	return &AccountService{db: db}
+}
+
+// signedAmountSQL 交易对本账户余额的影响：存款（含转入）和利息为正，取款和转出为负，与isCreditType一致
+const signedAmountSQL = "CASE WHEN transaction_type IN ('deposit', 'interest') THEN amount ELSE -amount END"
+
+// GetStatement 查询账户对账单，按时间倒序（最新的在前）分页
+// 每笔交易的RunningBalance由账户当前余额减去之后所有交易的金额推算，正常情况下与BalanceAfter一致，
+// 不一致说明账户余额与交易记录对不上，需要人工对账
+// 参数 accountID: 账户ID
+// 参数 start, end: 交易时间范围 [start, end)，零值表示不限制
+// 参数 page: 页码，从1开始
+// 参数 pageSize: 每页数量，默认20，最大100
+// 返回 []Transaction: 当前页的交易记录
+// 返回 int64: 时间范围内的交易总数
+// 返回 error: 账户不存在或查询失败时的错误信息
+func (s *AccountService) GetStatement(accountID uint, start, end time.Time, page, pageSize int) ([]Transaction, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	var transactions []Transaction
+	var total int64
+	// 余额和交易记录在同一个事务中读取，推算时不会混入查询期间新发生的交易
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var account Account
+		if err := tx.Select("id", "balance").First(&account, accountID).Error; err != nil {
+			return fmt.Errorf("账户不存在: %w", err)
+		}
+
+		// 只有已完成的交易会改变余额
+		query := tx.Model(&Transaction{}).Where("account_id = ? AND status = ?", accountID, "completed")
+		if !start.IsZero() {
+			query = query.Where("created_at >= ?", start)
+		}
+		if !end.IsZero() {
+			query = query.Where("created_at < ?", end)
+		}
+		if err := query.Count(&total).Error; err != nil {
+			return err
+		}
+
+		// ID即余额变动的先后顺序，按ID倒序即最新的在前
+		err := query.Order("id DESC").Offset((page - 1) * pageSize).Limit(pageSize).Find(&transactions).Error
+		if err != nil || len(transactions) == 0 {
+			return err
+		}
+
+		// 本页第一笔交易后的余额 = 当前余额 - 其后所有交易（不限时间范围）的金额
+		var later float64
+		err = tx.Model(&Transaction{}).
+			Where("account_id = ? AND status = ? AND id > ?", accountID, "completed", transactions[0].ID).
+			Select("COALESCE(SUM(" + signedAmountSQL + "), 0)").Scan(&later).Error
+		if err != nil {
+			return err
+		}
+
+		balance := account.Balance - later
+		for i := range transactions {
+			transactions[i].RunningBalance = roundCents(balance)
+			balance -= signedAmount(transactions[i])
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return transactions, total, nil
+}
+
+// signedAmount 交易对本账户余额的影响，与signedAmountSQL一致
+func signedAmount(t Transaction) float64 {
+	if isCreditType(t.TransactionType) {
+		return t.Amount
+	}
+	return -t.Amount
+}
+
+// roundCents 金额保留两位小数，避免浮点累加误差
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+// ==================== 金额校验 ====================
+// 交易金额须大于0、为有限数且最多两位小数；校验通过后转换为整数分参与余额、限额和汇率计算，
+// 只在写入float64列时转换回元，避免浮点运算的累积误差
+
+// ErrInvalidAmount 交易金额不合法
+var ErrInvalidAmount = errors.New("交易金额无效，须为大于0的有限数且最多两位小数")
+
+// maxAmountCents 单笔交易金额上限（分），与余额列 precision:15;scale:2 能表示的最大值一致
+const maxAmountCents = 1e15 - 1
+
+// toCents 校验交易金额并转换为整数分
+// 1.005 之类超过两位小数的金额直接拒绝，不做四舍五入
+// 参数 amount: 交易金额（元）
+// 返回 int64: 金额（分）
+// 返回 error: 金额不合法时返回包装了 ErrInvalidAmount 的错误
+func toCents(amount float64) (int64, error) {
+	if math.IsNaN(amount) || math.IsInf(amount, 0) || amount <= 0 {
+		return 0, fmt.Errorf("%w: %v", ErrInvalidAmount, amount)
+	}
+	cents := math.Round(amount * 100)
+	// 两位小数的金额乘以100后与整数的差只来自浮点表示误差，远小于1e-6
+	if math.Abs(amount*100-cents) > 1e-6 || cents > maxAmountCents {
+		return 0, fmt.Errorf("%w: %v", ErrInvalidAmount, amount)
+	}
+	return int64(cents), nil
+}
+
+// balanceToCents 已保存的余额或限额转换为整数分，四舍五入消除浮点表示误差
+func balanceToCents(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
+
+// fromCents 整数分转换为元，用于写入float64列
+func fromCents(cents int64) float64 {
+	return float64(cents) / 100
+}
+
+// ==================== 交易幂等 ====================
+// 同一账户下交易参考号唯一（account_id + reference 唯一索引），客户端重试存款、取款或转账时带上相同的参考号，
+// 交易只执行一次；BeforeCreate钩子先查询是否重复，并发提交时由唯一索引拒绝后到的一笔
+
+// ErrDuplicateTransaction 同一账户下已存在相同参考号的交易
+var ErrDuplicateTransaction = errors.New("相同参考号的交易已存在")
+
+// DuplicateTransactionError 重复提交的交易，errors.Is(err, ErrDuplicateTransaction) 成立
+type DuplicateTransactionError struct {
+	Original Transaction // 第一次提交时创建的交易
+}
+
+func (e *DuplicateTransactionError) Error() string {
+	return fmt.Sprintf("%v: 参考号 %s，原交易ID %d", ErrDuplicateTransaction, e.Original.Reference, e.Original.ID)
+}
+
+func (e *DuplicateTransactionError) Unwrap() error {
+	return ErrDuplicateTransaction
+}
+
+// SubmitTransaction 按参考号幂等地提交存款或取款交易
+// 参考号为空时由钩子自动生成（不具备幂等性）；参考号重复时不执行，返回原交易和 ErrDuplicateTransaction，
+// 调用方可以把重复提交视为成功
+// 参数 db: GORM数据库实例
+// 参数 t: 待提交的交易，成功时回填ID、余额等字段
+// 返回 *Transaction: 新创建的交易，重复提交时为原交易
+// 返回 error: 操作过程中的错误信息
+func SubmitTransaction(db *gorm.DB, t *Transaction) (*Transaction, error) {
+	if err := db.Create(t).Error; err != nil {
+		return duplicateOrError(db, t.AccountID, t.Reference, err)
+	}
+	return t, nil
+}
+
+// duplicateOrError 交易创建失败时判断是否为重复提交
+// 钩子检查出的重复直接返回原交易；并发提交时钩子的检查都可能通过，插入被唯一索引拒绝，
+// 各数据库的唯一约束错误不同，因此按参考号重新查询，查到即视为重复
+func duplicateOrError(db *gorm.DB, accountID uint, reference string, err error) (*Transaction, error) {
+	var dup *DuplicateTransactionError
+	if errors.As(err, &dup) {
+		return &dup.Original, err
+	}
+	if reference != "" {
+		if original, ok := findTransactionByReference(db, accountID, reference); ok {
+			return original, &DuplicateTransactionError{Original: *original}
+		}
+	}
+	return nil, err
+}
+
+// findTransactionByReference 按账户和参考号查询交易，包括已软删除的（唯一索引同样包含它们）
+func findTransactionByReference(db *gorm.DB, accountID uint, reference string) (*Transaction, bool) {
+	var original Transaction
+	result := db.Unscoped().Where("account_id = ? AND reference = ?", accountID, reference).Limit(1).Find(&original)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return nil, false
+	}
+	return &original, true
+}
+
+// ==================== 账户冻结 ====================
+// 冻结（如涉嫌欺诈时的止付）与停用账户（IsActive=false）不同：冻结的账户不能取款和转出，
+// 但仍可以收到存款、转账和利息；冻结和解冻都记录审计日志
+
+// ErrAccountFrozen 账户已冻结，不能出账
+var ErrAccountFrozen = errors.New("账户已冻结，不能取款或转出")
+
+// Freeze 冻结账户，已冻结时不做任何修改
+// 参数 accountID: 账户ID
+// 参数 reason: 冻结原因，记录在审计日志中
+// 返回 error: 账户不存在或更新失败时返回错误
+func (s *AccountService) Freeze(accountID uint, reason string) error {
+	return s.setFrozen(accountID, true, reason)
+}
+
+// Unfreeze 解冻账户，未冻结时不做任何修改
+// 参数 accountID: 账户ID
+// 参数 reason: 解冻原因，记录在审计日志中
+// 返回 error: 账户不存在或更新失败时返回错误
+func (s *AccountService) Unfreeze(accountID uint, reason string) error {
+	return s.setFrozen(accountID, false, reason)
+}
+
+// setFrozen 修改账户的冻结状态并记录审计日志
+// 按原状态条件更新，并发冻结同一账户时只有一次生效，只记录一条审计日志
+func (s *AccountService) setFrozen(accountID uint, frozen bool, reason string) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var account Account
+		if err := tx.First(&account, accountID).Error; err != nil {
+			return fmt.Errorf("账户不存在: %v", err)
+		}
+
+		result := tx.Model(&Account{}).Where("id = ? AND frozen = ?", accountID, !frozen).Update("frozen", frozen)
+		if result.Error != nil {
+			return fmt.Errorf("更新账户冻结状态失败: %v", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		action := "账户冻结"
+		if !frozen {
+			action = "账户解冻"
+		}
+		auditLog := AuditLog{
+			UserID:      account.UserID,
+			Action:      "UPDATE",
+			TableName:   "accounts",
+			RecordID:    accountID,
+			OldValues:   auditValues(map[string]interface{}{"frozen": !frozen}),
+			NewValues:   auditValues(map[string]interface{}{"frozen": frozen}),
+			Description: fmt.Sprintf("%s: %s", action, reason),
+		}
+		if err := tx.Create(&auditLog).Error; err != nil {
+			return fmt.Errorf("创建审计日志失败: %v", err)
+		}
+		return nil
+	})
+}
+
+// ==================== 跨币种转账 ====================
+// 账户币种不同时，转账金额按转出币种扣减，按汇率换算为转入币种后入账（四舍五入到分），
+// 汇率由 RateProvider 提供并记录在两笔交易的 ExchangeRate 上
+
+// ErrRateUnavailable 没有两种货币之间的汇率
+var ErrRateUnavailable = errors.New("没有可用的汇率")
+
+// RateProvider 汇率来源
+// Rate 返回1单位 from 货币可兑换的 to 货币数量，没有汇率时返回 ErrRateUnavailable
+type RateProvider interface {
+	Rate(from, to string) (float64, error)
+}
+
+// StaticRateProvider 固定汇率表，键为 "转出币种/转入币种"（如 "CNY/USD"）
+// 只配置了反方向的汇率时取其倒数
+type StaticRateProvider map[string]float64
+
+// Rate 查询固定汇率，货币代码不区分大小写
+func (p StaticRateProvider) Rate(from, to string) (float64, error) {
+	from, to = strings.ToUpper(from), strings.ToUpper(to)
+	if from == to {
+		return 1, nil
+	}
+	if rate, ok := p[from+"/"+to]; ok && rate > 0 {
+		return rate, nil
+	}
+	if rate, ok := p[to+"/"+from]; ok && rate > 0 {
+		return 1 / rate, nil
+	}
+	return 0, fmt.Errorf("%w: %s → %s", ErrRateUnavailable, from, to)
+}
+
+// DefaultRateProvider TransferMoney 使用的汇率来源，示例中为固定汇率，实际使用时替换为行情服务
+var DefaultRateProvider RateProvider = StaticRateProvider{
+	"USD/CNY": 7.2,
+	"EUR/CNY": 7.8,
+	"HKD/CNY": 0.92,
+}
+
+// exchangeRate 查询两种货币之间的汇率，保留6位小数（与 ExchangeRate 列的精度一致）
+func exchangeRate(rates RateProvider, from, to string) (float64, error) {
+	if strings.EqualFold(from, to) {
+		return 1, nil
+	}
+	if rates == nil {
+		return 0, fmt.Errorf("%w: %s → %s", ErrRateUnavailable, from, to)
+	}
+	rate, err := rates.Rate(from, to)
+	if err != nil {
+		return 0, err
+	}
+	rate = math.Round(rate*1e6) / 1e6
+	if rate <= 0 {
+		return 0, fmt.Errorf("%w: %s → %s", ErrRateUnavailable, from, to)
+	}
+	return rate, nil
+}
+
+// ==================== 账户汇总 ====================
+// 按币种汇总用户的所有账户；不同币种的余额不换算、不相加，避免汇率变动让汇总结果失真
+
+// CurrencyHolding 用户在某一币种下的账户及余额合计
+type CurrencyHolding struct {
+	Currency string    `json:"currency"` // 货币代码
+	Total    float64   `json:"total"`    // 该币种所有账户（含停用账户）的余额合计
+	Accounts []Account `json:"accounts"` // 该币种的账户，按ID排序
+}
+
+// Portfolio 用户所有账户的汇总
+type Portfolio struct {
+	UserID           uint              `json:"user_id"`
+	Currencies       []CurrencyHolding `json:"currencies"`        // 按货币代码排序
+	ActiveAccounts   int               `json:"active_accounts"`   // 激活的账户数
+	InactiveAccounts int               `json:"inactive_accounts"` // 停用的账户数
+}
+
+// GetUserPortfolio 查询用户的所有账户，按币种分组并计算每个币种的余额合计
+// 只查询账户本身，不加载交易记录；停用账户的余额仍计入合计，账户数按是否激活分别统计
+// 参数 userID: 用户ID
+// 返回 *Portfolio: 账户汇总，用户没有账户时Currencies为空
+// 返回 error: 用户不存在或查询失败时的错误信息
+func (s *AccountService) GetUserPortfolio(userID uint) (*Portfolio, error) {
+	var accounts []Account
+	if err := s.db.Where("user_id = ?", userID).Order("currency, id").Find(&accounts).Error; err != nil {
+		return nil, fmt.Errorf("查询账户失败: %w", err)
+	}
+	if len(accounts) == 0 {
+		// 没有账户时区分用户不存在和用户尚未开户
+		if err := s.db.Select("id").First(&User{}, userID).Error; err != nil {
+			return nil, fmt.Errorf("用户不存在: %w", err)
+		}
+	}
+
+	portfolio := &Portfolio{UserID: userID, Currencies: []CurrencyHolding{}}
+	for _, account := range accounts {
+		if account.IsActive {
+			portfolio.ActiveAccounts++
+		} else {
+			portfolio.InactiveAccounts++
+		}
+
+		// 账户已按币种排序，同一币种的账户相邻
+		n := len(portfolio.Currencies)
+		if n == 0 || portfolio.Currencies[n-1].Currency != account.Currency {
+			portfolio.Currencies = append(portfolio.Currencies, CurrencyHolding{Currency: account.Currency})
+			n++
+		}
+		holding := &portfolio.Currencies[n-1]
+		holding.Accounts = append(holding.Accounts, account)
+		holding.Total = roundCents(holding.Total + account.Balance)
+	}
+	return portfolio, nil
+}
+
+// ==================== 储蓄账户计息 ====================
+// 每天对激活的储蓄账户按余额计息，利息记为一笔interest交易并增加余额
+// 计息规则：日利息(分) = 余额(分) × 年利率 ÷ 365，四舍五入到分；不足1分的不生成交易
+
+// daysPerYear 按日计息使用的年天数
+const daysPerYear = 365
+
+// interestBatchSize 每批处理的账户数
+const interestBatchSize = 100
+
+// ErrInvalidInterestRate 年利率不在 (0, 1) 范围内
+var ErrInvalidInterestRate = errors.New("年利率必须大于0且小于1")
+
+// InterestService 储蓄账户计息服务
+type InterestService struct {
+	db  *gorm.DB
+	now func() time.Time // 当前时间，决定计息日期，测试时可替换
+}
+
+// NewInterestService 创建计息服务
+// 参数 db: GORM数据库实例
+func NewInterestService(db *gorm.DB) *InterestService {
+	return &InterestService{db: db, now: time.Now}
+}
+
+// dailyInterest 计算一天的利息，余额先换算为分，结果四舍五入到分
+// 参数 balance: 账户余额（元）
+// 参数 rate: 年利率，如0.015表示1.5%
+// 返回 float64: 利息（元），保留两位小数
+func dailyInterest(balance, rate float64) float64 {
+	cents := math.Round(balance * 100)
+	return math.Round(cents*rate/daysPerYear) / 100
+}
+
+// AccrueDaily 为当天尚未计息的激活储蓄账户计息，分批处理，每个账户一个事务
+// 每个账户的LastInterestDate记录最近的计息日期，同一天重复执行不会重复计息；
+// 利息不足1分的账户只记录计息日期，不生成交易。单个账户失败不影响其他账户
+// 参数 rate: 年利率，如0.015表示1.5%
+// 返回 int: 生成利息交易的账户数
+// 返回 error: 利率无效，或部分账户计息失败时的错误信息
+func (s *InterestService) AccrueDaily(rate float64) (int, error) {
+	if rate <= 0 || rate >= 1 {
+		return 0, ErrInvalidInterestRate
+	}
+	today := s.now().Format("2006-01-02")
+
+	accrued := 0
+	var errs []error
+	var accounts []Account
+	result := s.db.Where("account_type = ? AND is_active = ? AND (last_interest_date IS NULL OR last_interest_date < ?)",
+		"savings", true, today).
+		FindInBatches(&accounts, interestBatchSize, func(batch *gorm.DB, _ int) error {
+			for _, account := range accounts {
+				ok, err := s.accrueAccount(account.ID, rate, today)
+				if err != nil {
+					errs = append(errs, fmt.Errorf("账户 %d 计息失败: %w", account.ID, err))
+					continue
+				}
+				if ok {
+					accrued++
+				}
+			}
+			return nil
+		})
+	if result.Error != nil {
+		errs = append(errs, result.Error)
+	}
+	return accrued, errors.Join(errs...)
+}
+
+// accrueAccount 在一个事务中为单个账户计息
+// 先用条件更新把LastInterestDate改为today占住当天的计息，更新不到行说明当天已计过息（如并发执行），直接返回
+// 返回 bool: 是否生成了利息交易
+func (s *InterestService) accrueAccount(accountID uint, rate float64, today string) (bool, error) {
+	created := false
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		claim := tx.Model(&Account{}).
+			Where("id = ? AND (last_interest_date IS NULL OR last_interest_date < ?)", accountID, today).
+			Update("last_interest_date", today)
+		if claim.Error != nil || claim.RowsAffected == 0 {
+			return claim.Error
+		}
+
+		// 占住计息日期后再读取余额，按最新余额计息
+		var account Account
+		if err := tx.First(&account, accountID).Error; err != nil {
+			return err
+		}
+		interest := dailyInterest(account.Balance, rate)
+		if interest <= 0 {
+			return nil
+		}
+
+		// 参考号按账户和日期生成，同一账户同一天只有一笔利息交易
+		// BeforeCreate钩子记录交易前后余额，AfterCreate钩子增加账户余额
+		transaction := Transaction{
+			AccountID:       account.ID,
+			UserID:          account.UserID,
+			TransactionType: "interest",
+			Amount:          interest,
+			Description:     fmt.Sprintf("%s 利息（年利率 %g%%）", today, rate*100),
+			Reference:       fmt.Sprintf("interest_%d_%s", account.ID, today),
+			Status:          "pending",
+		}
+		if err := tx.Create(&transaction).Error; err != nil {
+			return err
+		}
+		created = true
+		return nil
+	})
+	return created && err == nil, err
+}
+
+// StartDailyAccrual 在后台每天hour点执行一次计息，ctx取消时停止
+// 参数 rate: 年利率
+// 参数 hour: 每天执行的整点（0-23）
+func (s *InterestService) StartDailyAccrual(ctx context.Context, rate float64, hour int) {
+	go func() {
+		for {
+			now := s.now()
+			next := time.Date(now.Year(), now.Month(), now.Day(), hour, 0, 0, 0, now.Location())
+			if !next.After(now) {
+				next = next.AddDate(0, 0, 1)
+			}
+
+			timer := time.NewTimer(next.Sub(now))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+
+			count, err := s.AccrueDaily(rate)
+			if err != nil {
+				log.Printf("储蓄账户计息出错: %v", err)
+			}
+			log.Printf("储蓄账户计息完成，%d 个账户生成利息", count)
+		}
+	}()
+}
+
 // ==================== 主函数演示 ====================
 // 演示GORM事务管理和钩子函数的完整使用流程
 // 包括用户创建、转账操作、批量交易、状态更新等核心业务场景
@@ -1162,6 +1942,78 @@ func main() {
 	bobBalance, _ := GetAccountBalance(db, bobAccount.ID)
 	fmt.Printf("转账后余额 - Alice: %.2f, Bob: %.2f\n", aliceBalance, bobBalance)
 
+	// 查询Bob账户的对账单，转入的300元也在其中
+	// RunningBalance由交易记录推算，应与每笔交易记录的BalanceAfter一致
+	statement, _, err := NewAccountService(db).GetStatement(bobAccount.ID, time.Time{}, time.Time{}, 1, 10)
+	if err != nil {
+		fmt.Printf("查询对账单失败: %v\n", err)
+	} else {
+		fmt.Println("Bob账户对账单:")
+		for _, t := range statement {
+			fmt.Printf("  - %s %.2f, 余额 %.2f (记录 %.2f) %s\n",
+				t.TransactionType, t.Amount, t.RunningBalance, t.BalanceAfter, t.Description)
+		}
+	}
+
+	// 储蓄账户计息：年利率1.5%，同一天第二次执行不会重复计息
+	interestService := NewInterestService(db)
+	for i := 0; i < 2; i++ {
+		count, err := interestService.AccrueDaily(0.015)
+		if err != nil {
+			fmt.Printf("计息失败: %v\n", err)
+		}
+		fmt.Printf("第%d次计息: %d 个储蓄账户生成利息\n", i+1, count)
+	}
+
+	// 冻结Bob的账户：转出被拒绝，转入不受影响，解冻后恢复
+	accountService := NewAccountService(db)
+	if err := accountService.Freeze(bobAccount.ID, "疑似欺诈交易"); err != nil {
+		fmt.Printf("冻结账户失败: %v\n", err)
+	} else {
+		fmt.Printf("✓ 账户冻结: 账户 %d，原因: 疑似欺诈交易\n", bobAccount.ID)
+	}
+	if err := TransferMoney(db, bobAccount.ID, aliceAccount.ID, 50.0, "冻结期间转出"); errors.Is(err, ErrAccountFrozen) {
+		fmt.Printf("冻结期间转出被拒绝: %v\n", err)
+	}
+	if err := TransferMoney(db, aliceAccount.ID, bobAccount.ID, 50.0, "冻结期间转入"); err != nil {
+		fmt.Printf("冻结期间转入失败: %v\n", err)
+	}
+	if err := accountService.Unfreeze(bobAccount.ID, "核查无异常"); err != nil {
+		fmt.Printf("解冻账户失败: %v\n", err)
+	} else {
+		fmt.Printf("✓ 账户解冻: 账户 %d，原因: 核查无异常\n", bobAccount.ID)
+	}
+
+	// 按参考号幂等转账：网络超时后用同一参考号重试，只转账一次
+	for i := 0; i < 2; i++ {
+		transfer, err := TransferMoneyWithReference(db, aliceAccount.ID, bobAccount.ID, 20.0, "房租", "rent-2024-06")
+		if errors.Is(err, ErrDuplicateTransaction) {
+			fmt.Printf("重复提交，返回原转账交易 ID %d\n", transfer.ID)
+		} else if err != nil {
+			fmt.Printf("转账失败: %v\n", err)
+		}
+	}
+
+	// 跨币种转账：Bob开立美元账户，Alice从人民币账户转入100元，按汇率换算为美元入账
+	bobUSDAccount := Account{UserID: bobAccount.UserID, AccountType: "checking", Currency: "USD"}
+	if err := db.Create(&bobUSDAccount).Error; err != nil {
+		fmt.Printf("开立美元账户失败: %v\n", err)
+	} else if err := TransferMoney(db, aliceAccount.ID, bobUSDAccount.ID, 100.0, "换汇"); err != nil {
+		fmt.Printf("跨币种转账失败: %v\n", err)
+	} else {
+		usdBalance, _ := GetAccountBalance(db, bobUSDAccount.ID)
+		fmt.Printf("Bob美元账户余额: %.2f USD\n", usdBalance)
+	}
+
+	// 账户汇总：Bob的人民币和美元账户分币种统计，不相加
+	if portfolio, err := accountService.GetUserPortfolio(bobAccount.UserID); err != nil {
+		fmt.Printf("查询账户汇总失败: %v\n", err)
+	} else {
+		for _, holding := range portfolio.Currencies {
+			fmt.Printf("Bob的%s账户: %d 个，合计 %.2f\n", holding.Currency, len(holding.Accounts), holding.Total)
+		}
+	}
+
 	// ==================== 演示3：批量交易（事务） ====================
 	// 演示批量创建交易记录的事务处理
 	// 确保所有交易要么全部成功，要么全部失败，维护数据一致性
@@ -1276,6 +2128,26 @@ func main() {
 		}
 	}
 
+	// 管理员按表名和记录ID查询Alice账户的审计日志
+	// Search会把OldValues和NewValues解析为逐字段的变更，便于查看账户状态的变化过程
+	auditor := User{Username: "auditor", Email: "auditor@bank.com", FullName: "Auditor", IsAdmin: true}
+	if err := db.Where("username = ?", auditor.Username).FirstOrCreate(&auditor).Error; err != nil {
+		fmt.Printf("创建审计管理员失败: %v\n", err)
+	}
+	accountLogs, total, err := NewAuditService(db, auditor.ID).
+		Search(AuditFilter{TableName: "accounts", RecordID: aliceAccount.ID}, 1, 10)
+	if err != nil {
+		fmt.Printf("查询账户审计日志失败: %v\n", err)
+	} else {
+		fmt.Printf("\nAlice账户的审计日志 (%d条):\n", total)
+		for _, log := range accountLogs {
+			fmt.Printf("  - %s %s\n", log.Action, log.Description)
+			for _, change := range log.Changes {
+				fmt.Printf("      %s: %v -> %v\n", change.Field, change.Old, change.New)
+			}
+		}
+	}
+
 	// ==================== 演示6：错误处理和回滚 ====================
 	// 演示事务的错误处理和自动回滚机制
 	// 展示数据验证、业务规则检查和事务完整性保护
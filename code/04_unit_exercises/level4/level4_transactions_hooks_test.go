@@ -0,0 +1,397 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var testDBSeq int64
+
+// newTestDB 创建独立的内存SQLite数据库并执行 AutoMigrate，测试结束时关闭
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := fmt.Sprintf("file:level4_%d?mode=memory&cache=shared&_busy_timeout=5000", atomic.AddInt64(&testDBSeq, 1))
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("打开测试数据库失败: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("获取数据库连接失败: %v", err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	if err := AutoMigrate(db); err != nil {
+		t.Fatalf("迁移测试数据库失败: %v", err)
+	}
+	return db
+}
+
+// newCustomer 通过 CreateUserWithAccount 创建用户，返回用户和钩子自动创建的储蓄账户
+func newCustomer(t *testing.T, db *gorm.DB, username string, deposit float64) (User, Account) {
+	t.Helper()
+	if err := CreateUserWithAccount(db, username, username+"@example.com", username, deposit); err != nil {
+		t.Fatalf("创建用户 %s 失败: %v", username, err)
+	}
+	var user User
+	if err := db.Where("username = ?", username).First(&user).Error; err != nil {
+		t.Fatalf("查询用户失败: %v", err)
+	}
+	return user, loadAccount(t, db, user.ID, "savings")
+}
+
+// openAccount 为用户开立指定类型和币种的账户
+func openAccount(t *testing.T, db *gorm.DB, userID uint, accountType, currency string) Account {
+	t.Helper()
+	account := Account{UserID: userID, AccountType: accountType, Currency: currency, IsActive: true, DailyLimit: 10000}
+	if err := db.Create(&account).Error; err != nil {
+		t.Fatalf("开立 %s 账户失败: %v", accountType, err)
+	}
+	return account
+}
+
+func loadAccount(t *testing.T, db *gorm.DB, userID uint, accountType string) Account {
+	t.Helper()
+	var account Account
+	if err := db.Where("user_id = ? AND account_type = ?", userID, accountType).First(&account).Error; err != nil {
+		t.Fatalf("查询 %s 账户失败: %v", accountType, err)
+	}
+	return account
+}
+
+func assertBalance(t *testing.T, db *gorm.DB, accountID uint, want float64) {
+	t.Helper()
+	balance, err := GetAccountBalance(db, accountID)
+	if err != nil {
+		t.Fatalf("查询余额失败: %v", err)
+	}
+	if balance != want {
+		t.Errorf("账户 %d 余额为 %.2f，期望 %.2f", accountID, balance, want)
+	}
+}
+
+// TestAuditSearchRequiresAdmin 非管理员和不存在的用户查询审计日志时返回 ErrAuditForbidden，管理员可以按条件查询并看到字段变更
+func TestAuditSearchRequiresAdmin(t *testing.T) {
+	db := newTestDB(t)
+	alice, account := newCustomer(t, db, "alice", 100)
+	admin, _ := newCustomer(t, db, "admin", 0)
+	if err := db.Model(&admin).Update("is_admin", true).Error; err != nil {
+		t.Fatalf("设置管理员失败: %v", err)
+	}
+
+	for _, operatorID := range []uint{alice.ID, 9999} {
+		logs, total, err := NewAuditService(db, operatorID).Search(AuditFilter{}, 1, 20)
+		if !errors.Is(err, ErrAuditForbidden) || logs != nil || total != 0 {
+			t.Errorf("用户 %d 查询返回 %d 条、总数 %d、错误 %v，期望 ErrAuditForbidden", operatorID, len(logs), total, err)
+		}
+	}
+
+	if err := UpdateAccountStatus(db, account.ID, false, "测试停用"); err != nil {
+		t.Fatalf("停用账户失败: %v", err)
+	}
+	logs, total, err := NewAuditService(db, admin.ID).Search(AuditFilter{TableName: "accounts", RecordID: account.ID, Action: "UPDATE"}, 1, 20)
+	if err != nil {
+		t.Fatalf("管理员查询失败: %v", err)
+	}
+	want := []AuditChange{{Field: "is_active", Old: true, New: false}}
+	if total != 1 || len(logs) != 1 || !reflect.DeepEqual(logs[0].Changes, want) {
+		t.Errorf("查询结果总数 %d、日志 %+v，期望一条 is_active true→false 的变更", total, logs)
+	}
+}
+
+// TestStatementRunningBalanceAcrossPages 分页查询对账单时，每笔交易推算的余额与交易后余额一致，相邻两页首尾衔接
+func TestStatementRunningBalanceAcrossPages(t *testing.T) {
+	db := newTestDB(t)
+	alice, account := newCustomer(t, db, "alice", 1000)
+	_, bobAccount := newCustomer(t, db, "bob", 500)
+
+	steps := []Transaction{
+		{TransactionType: "withdraw", Amount: 100.10},
+		{TransactionType: "deposit", Amount: 50.25},
+		{TransactionType: "withdraw", Amount: 0.15},
+	}
+	for _, step := range steps {
+		step.AccountID, step.UserID = account.ID, alice.ID
+		if _, err := SubmitTransaction(db, &step); err != nil {
+			t.Fatalf("提交交易失败: %v", err)
+		}
+	}
+	if err := TransferMoney(db, account.ID, bobAccount.ID, 200, "房租"); err != nil {
+		t.Fatalf("转出失败: %v", err)
+	}
+	if err := TransferMoney(db, bobAccount.ID, account.ID, 30.5, "还款"); err != nil {
+		t.Fatalf("转入失败: %v", err)
+	}
+	assertBalance(t, db, account.ID, 780.5)
+
+	service := NewAccountService(db)
+	var all []Transaction
+	for page := 1; page <= 3; page++ {
+		transactions, total, err := service.GetStatement(account.ID, time.Time{}, time.Time{}, page, 2)
+		if err != nil {
+			t.Fatalf("查询第 %d 页失败: %v", page, err)
+		}
+		if total != 6 {
+			t.Fatalf("第 %d 页的总数为 %d，期望 6（初始存款、3笔交易、转出和转入）", page, total)
+		}
+		all = append(all, transactions...)
+	}
+
+	wantBalances := []float64{780.5, 750, 950, 950.15, 899.9, 1000}
+	if len(all) != len(wantBalances) {
+		t.Fatalf("三页共 %d 笔交易，期望 %d", len(all), len(wantBalances))
+	}
+	for i, transaction := range all {
+		if transaction.RunningBalance != wantBalances[i] || transaction.RunningBalance != transaction.BalanceAfter {
+			t.Errorf("第 %d 笔 %s 推算余额 %.2f、交易后余额 %.2f，期望 %.2f",
+				i+1, transaction.TransactionType, transaction.RunningBalance, transaction.BalanceAfter, wantBalances[i])
+		}
+	}
+}
+
+// TestAccrueDailyOncePerDay 同一天执行两次只计息一次，第二天再次计息；利息不足1分的账户不生成交易
+func TestAccrueDailyOncePerDay(t *testing.T) {
+	db := newTestDB(t)
+	_, account := newCustomer(t, db, "alice", 1000)
+	_, empty := newCustomer(t, db, "bob", 0)
+
+	day := time.Date(2024, 6, 1, 9, 0, 0, 0, time.Local)
+	interest := NewInterestService(db)
+	interest.now = func() time.Time { return day }
+
+	// 1000元 × 3.65% ÷ 365 = 0.10元
+	for i, want := range []int{1, 0} {
+		count, err := interest.AccrueDaily(0.0365)
+		if err != nil || count != want {
+			t.Fatalf("第 %d 次计息生成 %d 笔（%v），期望 %d", i+1, count, err, want)
+		}
+	}
+	assertBalance(t, db, account.ID, 1000.10)
+	assertBalance(t, db, empty.ID, 0)
+	if got := loadAccount(t, db, empty.UserID, "savings").LastInterestDate; got != "2024-06-01" {
+		t.Errorf("零余额账户的计息日期为 %q，期望 2024-06-01", got)
+	}
+
+	day = day.AddDate(0, 0, 1)
+	if count, err := interest.AccrueDaily(0.0365); err != nil || count != 1 {
+		t.Fatalf("第二天计息生成 %d 笔（%v），期望 1", count, err)
+	}
+	assertBalance(t, db, account.ID, 1000.20)
+
+	var references []string
+	db.Model(&Transaction{}).Where("transaction_type = ?", "interest").Order("id").Pluck("reference", &references)
+	want := []string{fmt.Sprintf("interest_%d_2024-06-01", account.ID), fmt.Sprintf("interest_%d_2024-06-02", account.ID)}
+	if !reflect.DeepEqual(references, want) {
+		t.Errorf("利息交易参考号为 %v，期望 %v", references, want)
+	}
+
+	if _, err := interest.AccrueDaily(1); !errors.Is(err, ErrInvalidInterestRate) {
+		t.Errorf("年利率为1时返回 %v，期望 ErrInvalidInterestRate", err)
+	}
+}
+
+// TestFrozenAccountBlocksDebits 冻结期间取款和转出被拒绝，存款和转入照常；重复冻结只记录一条审计日志
+func TestFrozenAccountBlocksDebits(t *testing.T) {
+	db := newTestDB(t)
+	alice, account := newCustomer(t, db, "alice", 100)
+	_, bobAccount := newCustomer(t, db, "bob", 100)
+	service := NewAccountService(db)
+
+	for i := 0; i < 2; i++ {
+		if err := service.Freeze(account.ID, "疑似欺诈"); err != nil {
+			t.Fatalf("冻结账户失败: %v", err)
+		}
+	}
+	var freezeLogs int64
+	db.Model(&AuditLog{}).Where("table_name = ? AND record_id = ? AND description LIKE ?", "accounts", account.ID, "账户冻结%").Count(&freezeLogs)
+	if freezeLogs != 1 {
+		t.Errorf("冻结审计日志 %d 条，期望 1", freezeLogs)
+	}
+
+	withdraw := &Transaction{AccountID: account.ID, UserID: alice.ID, TransactionType: "withdraw", Amount: 10}
+	if _, err := SubmitTransaction(db, withdraw); !errors.Is(err, ErrAccountFrozen) {
+		t.Errorf("冻结期间取款返回 %v，期望 ErrAccountFrozen", err)
+	}
+	if err := TransferMoney(db, account.ID, bobAccount.ID, 10, "转出"); !errors.Is(err, ErrAccountFrozen) {
+		t.Errorf("冻结期间转出返回 %v，期望 ErrAccountFrozen", err)
+	}
+	deposit := &Transaction{AccountID: account.ID, UserID: alice.ID, TransactionType: "deposit", Amount: 5}
+	if _, err := SubmitTransaction(db, deposit); err != nil {
+		t.Errorf("冻结期间存款失败: %v", err)
+	}
+	if err := TransferMoney(db, bobAccount.ID, account.ID, 10, "转入"); err != nil {
+		t.Errorf("冻结期间转入失败: %v", err)
+	}
+	assertBalance(t, db, account.ID, 115)
+
+	if err := service.Unfreeze(account.ID, "核查无异常"); err != nil {
+		t.Fatalf("解冻账户失败: %v", err)
+	}
+	withdraw = &Transaction{AccountID: account.ID, UserID: alice.ID, TransactionType: "withdraw", Amount: 10}
+	if _, err := SubmitTransaction(db, withdraw); err != nil {
+		t.Errorf("解冻后取款失败: %v", err)
+	}
+	assertBalance(t, db, account.ID, 105)
+}
+
+// TestDuplicateReferenceReturnsOriginal 相同参考号重复提交存款或转账时返回原交易和 ErrDuplicateTransaction，余额只变动一次
+func TestDuplicateReferenceReturnsOriginal(t *testing.T) {
+	db := newTestDB(t)
+	alice, account := newCustomer(t, db, "alice", 100)
+	_, bobAccount := newCustomer(t, db, "bob", 0)
+
+	var first *Transaction
+	for i := 0; i < 2; i++ {
+		deposit := &Transaction{AccountID: account.ID, UserID: alice.ID, TransactionType: "deposit", Amount: 20, Reference: "dep-1"}
+		got, err := SubmitTransaction(db, deposit)
+		if i == 0 {
+			if err != nil {
+				t.Fatalf("第一次存款失败: %v", err)
+			}
+			first = got
+			continue
+		}
+		if !errors.Is(err, ErrDuplicateTransaction) || got == nil || got.ID != first.ID {
+			t.Errorf("重复存款返回交易 %+v、错误 %v，期望原交易 %d 和 ErrDuplicateTransaction", got, err, first.ID)
+		}
+	}
+	assertBalance(t, db, account.ID, 120)
+
+	var transfers []uint
+	for i := 0; i < 2; i++ {
+		transfer, err := TransferMoneyWithReference(db, account.ID, bobAccount.ID, 30, "房租", "rent-1")
+		if i > 0 && !errors.Is(err, ErrDuplicateTransaction) {
+			t.Errorf("重复转账返回 %v，期望 ErrDuplicateTransaction", err)
+		} else if i == 0 && err != nil {
+			t.Fatalf("转账失败: %v", err)
+		}
+		transfers = append(transfers, transfer.ID)
+	}
+	if transfers[0] != transfers[1] {
+		t.Errorf("重复转账返回交易 %d，期望原交易 %d", transfers[1], transfers[0])
+	}
+	assertBalance(t, db, account.ID, 90)
+	assertBalance(t, db, bobAccount.ID, 30)
+}
+
+// TestCrossCurrencyTransferRounding 跨币种转账按保留6位小数的汇率换算，入账金额四舍五入到分，两笔交易记录同一汇率
+func TestCrossCurrencyTransferRounding(t *testing.T) {
+	db := newTestDB(t)
+	alice, cny := newCustomer(t, db, "alice", 1000)
+	usd := openAccount(t, db, alice.ID, "checking", "USD")
+	rates := StaticRateProvider{"USD/CNY": 7.2}
+
+	// 1/7.2 = 0.13888…，保留6位为0.138889；100元 × 0.138889 = 13.8889 → 13.89美元
+	transfer, err := TransferMoneyWithRates(db, rates, cny.ID, usd.ID, 100, "换汇", "fx-1")
+	if err != nil {
+		t.Fatalf("跨币种转账失败: %v", err)
+	}
+	if transfer.ExchangeRate != 0.138889 {
+		t.Errorf("转出交易汇率为 %v，期望 0.138889", transfer.ExchangeRate)
+	}
+	var credit Transaction
+	if err := db.Where("account_id = ? AND reference = ?", usd.ID, "fx-1").First(&credit).Error; err != nil {
+		t.Fatalf("查询转入交易失败: %v", err)
+	}
+	if credit.Amount != 13.89 || credit.ExchangeRate != 0.138889 {
+		t.Errorf("转入交易金额 %v、汇率 %v，期望 13.89、0.138889", credit.Amount, credit.ExchangeRate)
+	}
+	assertBalance(t, db, cny.ID, 900)
+	assertBalance(t, db, usd.ID, 13.89)
+
+	// 反方向使用配置的汇率：10.01美元 × 7.2 = 72.072 → 72.07元
+	if _, err := TransferMoneyWithRates(db, rates, usd.ID, cny.ID, 10.01, "换回", ""); err != nil {
+		t.Fatalf("反向转账失败: %v", err)
+	}
+	assertBalance(t, db, cny.ID, 972.07)
+	assertBalance(t, db, usd.ID, 3.88)
+
+	// 换算后不足1分、没有汇率时拒绝，余额不变
+	if _, err := TransferMoneyWithRates(db, rates, cny.ID, usd.ID, 0.01, "太少", ""); err == nil {
+		t.Error("换算后不足1分的转账成功了")
+	}
+	if _, err := TransferMoneyWithRates(db, nil, cny.ID, usd.ID, 10, "无汇率", ""); !errors.Is(err, ErrRateUnavailable) {
+		t.Errorf("没有汇率时返回 %v，期望 ErrRateUnavailable", err)
+	}
+	assertBalance(t, db, cny.ID, 972.07)
+
+	if rate, err := exchangeRate(StaticRateProvider{"CNY/JPY": 20.12345678}, "cny", "JPY"); err != nil || rate != 20.123457 {
+		t.Errorf("汇率为 %v（%v），期望保留6位小数的 20.123457", rate, err)
+	}
+}
+
+// TestUserPortfolioTotalsByCurrency 账户按币种分组，每个币种的合计包括停用账户并四舍五入到分
+func TestUserPortfolioTotalsByCurrency(t *testing.T) {
+	db := newTestDB(t)
+	alice, savings := newCustomer(t, db, "alice", 0.1)
+	usd := openAccount(t, db, alice.ID, "checking", "USD")
+	credit := openAccount(t, db, alice.ID, "credit", "CNY")
+	if err := db.Model(&usd).Update("balance", 20.05).Error; err != nil {
+		t.Fatalf("设置余额失败: %v", err)
+	}
+	if err := db.Model(&credit).Updates(map[string]interface{}{"balance": 0.2, "is_active": false}).Error; err != nil {
+		t.Fatalf("停用账户失败: %v", err)
+	}
+
+	portfolio, err := NewAccountService(db).GetUserPortfolio(alice.ID)
+	if err != nil {
+		t.Fatalf("查询账户汇总失败: %v", err)
+	}
+	if portfolio.ActiveAccounts != 2 || portfolio.InactiveAccounts != 1 {
+		t.Errorf("激活 %d 个、停用 %d 个，期望 2、1", portfolio.ActiveAccounts, portfolio.InactiveAccounts)
+	}
+
+	type holding struct {
+		currency string
+		total    float64
+		accounts []uint
+	}
+	var got []holding
+	for _, h := range portfolio.Currencies {
+		var ids []uint
+		for _, account := range h.Accounts {
+			ids = append(ids, account.ID)
+		}
+		got = append(got, holding{h.Currency, h.Total, ids})
+	}
+	// 0.1 + 0.2 按浮点相加为 0.30000000000000004，合计四舍五入为 0.3
+	want := []holding{
+		{"CNY", 0.3, []uint{savings.ID, credit.ID}},
+		{"USD", 20.05, []uint{usd.ID}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("按币种汇总为 %+v，期望 %+v", got, want)
+	}
+
+	if _, err := NewAccountService(db).GetUserPortfolio(9999); err == nil {
+		t.Error("不存在的用户查询成功了")
+	}
+}
+
+// TestToCents 金额须大于0、为有限数且最多两位小数，超过两位小数时拒绝而不是四舍五入
+func TestToCents(t *testing.T) {
+	valid := map[float64]int64{
+		0.01: 1, 0.29: 29, 1.1: 110, 19.99: 1999, 1005.5: 100550, 12345678.91: 1234567891,
+	}
+	for amount, want := range valid {
+		if got, err := toCents(amount); err != nil || got != want {
+			t.Errorf("toCents(%v) = %d, %v，期望 %d", amount, got, err, want)
+		}
+	}
+
+	invalid := []float64{1.005, 0.001, 0, -1, math.NaN(), math.Inf(1), math.Inf(-1), 1e13}
+	for _, amount := range invalid {
+		if got, err := toCents(amount); !errors.Is(err, ErrInvalidAmount) {
+			t.Errorf("toCents(%v) = %d, %v，期望 ErrInvalidAmount", amount, got, err)
+		}
+	}
+}
@@ -6,9 +6,11 @@
 package main
 
 import (
+	"context"   // 上下文，用于在数据库操作中传递当前操作人
 	"fmt"       // 格式化输出，用于打印测试结果和日志信息
 	"log"       // 日志记录，用于错误处理和调试信息输出
 	"math/rand" // 随机数生成，用于生成测试数据
+	"reflect"   // 反射，用于批量创建时逐条设置操作人字段
 	"time"      // 时间处理，用于性能测试计时和时间字段
 
 	"gorm.io/driver/mysql"  // MySQL数据库驱动，支持MySQL数据库连接
@@ -79,6 +81,93 @@ type BaseModel struct {
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"` // 删除时间，用于软删除，建立索引以提高查询性能
 }
 
+// Authored 操作人字段
+// 嵌入该结构体的模型在注册AuthorshipPlugin后，创建和更新时自动记录操作人ID
+// 上下文中没有操作人时字段保持为空（如后台任务、数据初始化）
+type Authored struct {
+	CreatedBy *uint `gorm:"index" json:"created_by"` // 创建人ID，创建时写入，之后不再修改
+	UpdatedBy *uint `json:"updated_by"`              // 最后修改人ID，创建和每次更新时写入
+}
+
+// 操作人插件相关定义
+
+// actingUserKey 上下文中保存操作人ID的键，使用私有类型避免与其他包的键冲突
+type actingUserKey struct{}
+
+// WithActingUser 返回携带操作人ID的上下文
+// 用法：db.WithContext(WithActingUser(ctx, userID)).Create(&post)
+func WithActingUser(ctx context.Context, userID uint) context.Context {
+	return context.WithValue(ctx, actingUserKey{}, userID)
+}
+
+// ActingUserFrom 从上下文中取出操作人ID
+func ActingUserFrom(ctx context.Context) (uint, bool) {
+	if ctx == nil {
+		return 0, false
+	}
+	userID, ok := ctx.Value(actingUserKey{}).(uint)
+	return userID, ok && userID != 0
+}
+
+// AuthorshipPlugin 操作人插件
+// 在GORM的创建、更新回调之前，为嵌入了Authored的模型填充CreatedBy、UpdatedBy
+// 注册方式：db.Use(&AuthorshipPlugin{})
+type AuthorshipPlugin struct{}
+
+// Name 插件名称，GORM用它避免重复注册
+func (p *AuthorshipPlugin) Name() string {
+	return "authorship"
+}
+
+// Initialize 注册创建和更新回调
+func (p *AuthorshipPlugin) Initialize(db *gorm.DB) error {
+	if err := db.Callback().Create().Before("gorm:create").Register("authorship:create", stampCreatedBy); err != nil {
+		return err
+	}
+	return db.Callback().Update().Before("gorm:update").Register("authorship:update", stampUpdatedBy)
+}
+
+// stampCreatedBy 创建时写入创建人和修改人，支持单条和批量创建
+// 已经手动设置了CreatedBy的记录不会被覆盖
+func stampCreatedBy(db *gorm.DB) {
+	userID, ok := ActingUserFrom(db.Statement.Context)
+	if !ok || db.Statement.Schema == nil {
+		return
+	}
+	createdBy := db.Statement.Schema.LookUpField("CreatedBy")
+	updatedBy := db.Statement.Schema.LookUpField("UpdatedBy")
+	if createdBy == nil || updatedBy == nil {
+		return // 模型没有嵌入Authored
+	}
+
+	stamp := func(rv reflect.Value) {
+		ctx := db.Statement.Context
+		if _, isZero := createdBy.ValueOf(ctx, rv); isZero {
+			db.AddError(createdBy.Set(ctx, rv, &userID))
+		}
+		db.AddError(updatedBy.Set(ctx, rv, &userID))
+	}
+
+	switch rv := db.Statement.ReflectValue; rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			stamp(reflect.Indirect(rv.Index(i)))
+		}
+	case reflect.Struct:
+		stamp(rv)
+	}
+}
+
+// stampUpdatedBy 更新时写入修改人
+// SetColumn同时支持Save(&model)、Updates(struct)和Updates(map)几种更新方式
+func stampUpdatedBy(db *gorm.DB) {
+	userID, ok := ActingUserFrom(db.Statement.Context)
+	if !ok || db.Statement.Schema == nil || db.Statement.Schema.LookUpField("UpdatedBy") == nil {
+		return
+	}
+	db.Statement.SetColumn("UpdatedBy", &userID, true)
+}
+
 // 业务模型定义
 
 // User 用户模型
@@ -133,6 +222,7 @@ type Category struct {
 // 与User、Category、Comment、Tag等模型建立关联关系，支持复杂的内容查询和统计
 type Post struct {
 	BaseModel               // 嵌入基础模型，获得ID、创建时间、更新时间、删除时间等通用字段
+	Authored                // 嵌入操作人字段，由AuthorshipPlugin自动填充创建人和修改人
 	Title        string     `gorm:"size:200;not null;index:idx_title" json:"title"`               // 文章标题，最大200字符，非空，建立索引用于标题搜索和排序
 	Slug         string     `gorm:"uniqueIndex:idx_post_slug;size:200;not null" json:"slug"`      // 文章别名，最大200字符，唯一索引，用于SEO友好的URL标识
 	Content      string     `gorm:"type:text;not null" json:"content"`                            // 文章正文内容，文本类型，非空，可存储长篇文章内容和富文本
@@ -201,6 +291,7 @@ type Tag struct {
 // 与User、OrderItem模型建立关联关系，支持复杂的订单查询和统计
 type Order struct {
 	BaseModel              // 嵌入基础模型，获得ID、创建时间、更新时间、删除时间等通用字段
+	Authored               // 嵌入操作人字段，由AuthorshipPlugin自动填充创建人和修改人
 	OrderNumber string     `gorm:"uniqueIndex:idx_order_number;size:50;not null" json:"order_number"`  // 订单号，最大50字符，非空，唯一索引确保订单号不重复，用于订单标识和查询
 	UserID      uint       `gorm:"not null;index:idx_user" json:"user_id"`                             // 用户ID，非空，建立索引用于按用户查询订单和用户订单统计
 	TotalAmount float64    `gorm:"precision:10;scale:2;not null;index:idx_amount" json:"total_amount"` // 订单总金额，精度10位小数2位，非空，建立索引用于金额统计和财务分析
@@ -293,6 +384,11 @@ func InitDatabase(config *DatabaseConfig) *gorm.DB {
 		log.Fatalf("Failed to connect to %s database: %v", config.Type, err)
 	}
 
+	// 注册操作人插件，自动填充CreatedBy/UpdatedBy
+	if err := db.Use(&AuthorshipPlugin{}); err != nil {
+		log.Fatalf("Failed to register authorship plugin: %v", err)
+	}
+
 	// 获取底层sql.DB对象用于连接池配置
 	sqlDB, err := db.DB()
 	if err != nil {
@@ -967,6 +1063,51 @@ func showOptimizationTips() {
 	fmt.Println("   - 使用适当的日志级别")  // 生产环境避免过多的SQL日志输出
 }
 
+// demonstrateAuthorship 演示操作人插件
+// 参数db: GORM数据库实例（已注册AuthorshipPlugin）
+// 通过WithActingUser把操作人放入上下文，创建和更新文章时自动写入CreatedBy、UpdatedBy
+func demonstrateAuthorship(db *gorm.DB) {
+	fmt.Println("\n=== 操作人插件演示 ===")
+
+	var author User
+	if err := db.First(&author).Error; err != nil {
+		fmt.Printf("没有可用的用户: %v\n", err)
+		return
+	}
+
+	// 以作者身份创建文章
+	ctx := WithActingUser(context.Background(), author.ID)
+	post := Post{
+		Title:    "操作人插件演示",
+		Slug:     fmt.Sprintf("authorship-demo-%d", time.Now().UnixNano()),
+		Content:  "创建时自动记录创建人",
+		AuthorID: author.ID,
+	}
+	if err := db.WithContext(ctx).Create(&post).Error; err != nil {
+		fmt.Printf("创建文章失败: %v\n", err)
+		return
+	}
+	fmt.Printf("文章 %d 创建人: %d, 修改人: %d\n", post.ID, *post.CreatedBy, *post.UpdatedBy)
+
+	// 以另一个操作人更新文章，创建人保持不变
+	editorCtx := WithActingUser(context.Background(), author.ID+1)
+	if err := db.WithContext(editorCtx).Model(&post).Update("status", "published").Error; err != nil {
+		fmt.Printf("更新文章失败: %v\n", err)
+		return
+	}
+	var saved Post
+	db.First(&saved, post.ID)
+	fmt.Printf("更新后 创建人: %d, 修改人: %d\n", *saved.CreatedBy, *saved.UpdatedBy)
+
+	// 上下文中没有操作人时不写入
+	anonymous := Post{Title: "匿名写入", Slug: fmt.Sprintf("authorship-anon-%d", time.Now().UnixNano()), Content: "-", AuthorID: author.ID}
+	db.Create(&anonymous)
+	fmt.Printf("无操作人时 CreatedBy 为空: %v\n", anonymous.CreatedBy == nil)
+
+	// 清理演示数据
+	db.Unscoped().Delete(&[]Post{post, anonymous})
+}
+
 // runPerformanceTests 运行性能测试的核心函数
 // 参数db: 数据库连接实例
 // 参数dbType: 数据库类型字符串，用于显示
@@ -979,6 +1120,9 @@ func runPerformanceTests(db *gorm.DB, dbType string) {
 	// 这个数据量足以体现不同查询策略的性能差异
 	generateTestData(db, 2000, 5000, 10000)
 
+	// 演示操作人插件 - 创建和更新时自动记录操作人
+	demonstrateAuthorship(db)
+
 	// 运行性能测试 - 按照从简单到复杂的顺序进行测试
 	benchmarkBasicQueries(db)             // 测试基础查询操作的性能
 	benchmarkPreloading(db)               // 测试预加载vs N+1查询的性能差异
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var testDBSeq int64
+
+// newTestDB 创建注册了AuthorshipPlugin的内存SQLite数据库，只迁移传入的模型，测试结束时关闭
+// SQLite的索引名在整个库内唯一，而User、Category、Post、Order等模型共用idx_active、idx_status这类索引名，
+// AutoMigrate又会连带迁移关联的模型，因此这里用CreateTable只建传入模型的表，并且不创建外键约束
+func newTestDB(t *testing.T, models ...interface{}) *gorm.DB {
+	t.Helper()
+	dsn := fmt.Sprintf("file:level5_%d?mode=memory&cache=shared&_busy_timeout=5000", atomic.AddInt64(&testDBSeq, 1))
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
+		Logger:                                   logger.Default.LogMode(logger.Silent),
+		DisableForeignKeyConstraintWhenMigrating: true,
+	})
+	if err != nil {
+		t.Fatalf("打开测试数据库失败: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("获取数据库连接失败: %v", err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	if err := db.Use(&AuthorshipPlugin{}); err != nil {
+		t.Fatalf("注册操作人插件失败: %v", err)
+	}
+	if err := db.Migrator().CreateTable(models...); err != nil {
+		t.Fatalf("迁移测试数据库失败: %v", err)
+	}
+	return db
+}
+
+// testAuthorID 测试文章的作者ID，与操作人ID无关
+const testAuthorID = 1
+
+// loadAuthored 重新查询文章的创建人和修改人，nil记为0
+func loadAuthored(t *testing.T, db *gorm.DB, postID uint) (createdBy, updatedBy uint) {
+	t.Helper()
+	var post Post
+	if err := db.First(&post, postID).Error; err != nil {
+		t.Fatalf("查询文章失败: %v", err)
+	}
+	if post.CreatedBy != nil {
+		createdBy = *post.CreatedBy
+	}
+	if post.UpdatedBy != nil {
+		updatedBy = *post.UpdatedBy
+	}
+	return createdBy, updatedBy
+}
+
+// TestAuthorshipFromContext 创建时CreatedBy、UpdatedBy取上下文中的操作人；
+// Update、Updates、Save更新时只改UpdatedBy，创建人保持不变
+func TestAuthorshipFromContext(t *testing.T) {
+	db := newTestDB(t, &Post{})
+
+	post := Post{Title: "标题", Slug: "post-1", Content: "正文", AuthorID: testAuthorID}
+	if err := db.WithContext(WithActingUser(context.Background(), 7)).Create(&post).Error; err != nil {
+		t.Fatalf("创建文章失败: %v", err)
+	}
+	if post.CreatedBy == nil || *post.CreatedBy != 7 || post.UpdatedBy == nil || *post.UpdatedBy != 7 {
+		t.Errorf("创建后的操作人为 %v/%v，期望 7/7", post.CreatedBy, post.UpdatedBy)
+	}
+	if createdBy, updatedBy := loadAuthored(t, db, post.ID); createdBy != 7 || updatedBy != 7 {
+		t.Errorf("库中创建人 %d、修改人 %d，期望 7、7", createdBy, updatedBy)
+	}
+
+	updates := []struct {
+		name   string
+		userID uint
+		update func(tx *gorm.DB) error
+	}{
+		{"Update", 8, func(tx *gorm.DB) error { return tx.Model(&post).Update("status", "published").Error }},
+		{"Updates(map)", 9, func(tx *gorm.DB) error {
+			return tx.Model(&Post{}).Where("id = ?", post.ID).Updates(map[string]interface{}{"view_count": 3}).Error
+		}},
+		{"Updates(struct)", 10, func(tx *gorm.DB) error { return tx.Model(&post).Updates(Post{Title: "新标题"}).Error }},
+		{"Save", 11, func(tx *gorm.DB) error {
+			var saved Post
+			if err := tx.First(&saved, post.ID).Error; err != nil {
+				return err
+			}
+			saved.Excerpt = "摘要"
+			return tx.Save(&saved).Error
+		}},
+	}
+	for _, u := range updates {
+		if err := u.update(db.WithContext(WithActingUser(context.Background(), u.userID))); err != nil {
+			t.Fatalf("%s: 更新文章失败: %v", u.name, err)
+		}
+		if createdBy, updatedBy := loadAuthored(t, db, post.ID); createdBy != 7 || updatedBy != u.userID {
+			t.Errorf("%s: 创建人 %d、修改人 %d，期望 7、%d", u.name, createdBy, updatedBy, u.userID)
+		}
+	}
+}
+
+// TestAuthorshipBatchCreate 批量创建时逐条写入操作人，手动设置了CreatedBy的记录不被覆盖
+func TestAuthorshipBatchCreate(t *testing.T) {
+	db := newTestDB(t, &Post{})
+
+	imported := uint(3)
+	posts := []Post{
+		{Title: "一", Slug: "batch-1", Content: "正文", AuthorID: testAuthorID},
+		{Title: "二", Slug: "batch-2", Content: "正文", AuthorID: testAuthorID, Authored: Authored{CreatedBy: &imported}},
+	}
+	if err := db.WithContext(WithActingUser(context.Background(), 5)).Create(&posts).Error; err != nil {
+		t.Fatalf("批量创建文章失败: %v", err)
+	}
+	for i, want := range []uint{5, 3} {
+		if createdBy, updatedBy := loadAuthored(t, db, posts[i].ID); createdBy != want || updatedBy != 5 {
+			t.Errorf("第 %d 篇文章创建人 %d、修改人 %d，期望 %d、5", i+1, createdBy, updatedBy, want)
+		}
+	}
+}
+
+// TestAuthorshipOrder 订单同样嵌入了Authored，创建和更新时记录操作人
+func TestAuthorshipOrder(t *testing.T) {
+	db := newTestDB(t, &Order{})
+
+	order := Order{OrderNumber: "NO-1", UserID: 1, TotalAmount: 99}
+	if err := db.WithContext(WithActingUser(context.Background(), 5)).Create(&order).Error; err != nil {
+		t.Fatalf("创建订单失败: %v", err)
+	}
+	if err := db.WithContext(WithActingUser(context.Background(), 6)).Model(&order).Update("status", "paid").Error; err != nil {
+		t.Fatalf("更新订单失败: %v", err)
+	}
+	var saved Order
+	if err := db.First(&saved, order.ID).Error; err != nil {
+		t.Fatalf("查询订单失败: %v", err)
+	}
+	if saved.CreatedBy == nil || *saved.CreatedBy != 5 || saved.UpdatedBy == nil || *saved.UpdatedBy != 6 {
+		t.Errorf("订单创建人 %v、修改人 %v，期望 5、6", saved.CreatedBy, saved.UpdatedBy)
+	}
+}
+
+// TestAuthorshipWithoutActingUser 上下文中没有操作人（或操作人为0）时创建不写入，更新不修改已有的操作人
+func TestAuthorshipWithoutActingUser(t *testing.T) {
+	db := newTestDB(t, &Post{})
+
+	anonymous := Post{Title: "匿名", Slug: "anonymous", Content: "正文", AuthorID: testAuthorID}
+	if err := db.Create(&anonymous).Error; err != nil {
+		t.Fatalf("创建文章失败: %v", err)
+	}
+	if createdBy, updatedBy := loadAuthored(t, db, anonymous.ID); createdBy != 0 || updatedBy != 0 {
+		t.Errorf("无操作人时创建人 %d、修改人 %d，期望为空", createdBy, updatedBy)
+	}
+
+	post := Post{Title: "标题", Slug: "owned", Content: "正文", AuthorID: testAuthorID}
+	if err := db.WithContext(WithActingUser(context.Background(), 7)).Create(&post).Error; err != nil {
+		t.Fatalf("创建文章失败: %v", err)
+	}
+	for name, tx := range map[string]*gorm.DB{
+		"无操作人":  db,
+		"操作人为0": db.WithContext(WithActingUser(context.Background(), 0)),
+	} {
+		if err := tx.Model(&post).Update("view_count", 1).Error; err != nil {
+			t.Fatalf("%s: 更新文章失败: %v", name, err)
+		}
+		if createdBy, updatedBy := loadAuthored(t, db, post.ID); createdBy != 7 || updatedBy != 7 {
+			t.Errorf("%s: 更新后创建人 %d、修改人 %d，期望 7、7", name, createdBy, updatedBy)
+		}
+	}
+}
@@ -50,15 +50,21 @@ func main() {
 
 	// 初始化Service层
 	userService := services.NewUserService(db)
-	postService := services.NewPostService(db)
+	settingService := services.NewSettingService(db, services.DefaultSettingCacheTTL)
+	if err := settingService.SeedDefaults(); err != nil {
+		log.Fatalf("初始化系统设置失败: %v", err)
+	}
+	postService := services.NewPostService(db, settingService)
 	commentService := services.NewCommentService(db)
 	analyticsService := services.NewAnalyticsService(db)
+	healthService := services.NewDefaultHealthService(db, config.MigrationModels())
 
 	// 初始化Handler层
 	userHandler := handlers.NewUserHandler(userService)
 	postHandler := handlers.NewPostHandler(postService)
 	commentHandler := handlers.NewCommentHandler(commentService)
 	analyticsHandler := handlers.NewAnalyticsHandler(analyticsService)
+	healthHandler := handlers.NewHealthHandler(healthService)
 
 	// 设置Gin模式
 	if cfg.App.Environment == "production" {
@@ -71,7 +77,7 @@ func main() {
 	r := gin.New()
 
 	// 设置路由
-	routes.SetupRoutes(r, userHandler, postHandler, commentHandler, analyticsHandler)
+	routes.SetupRoutes(r, userHandler, postHandler, commentHandler, analyticsHandler, healthHandler, settingService)
 
 	// 创建HTTP服务器
 	srv := &http.Server{
@@ -87,6 +93,7 @@ func main() {
 		log.Printf("🚀 博客系统启动成功，监听端口: %d", cfg.Server.Port)
 		log.Printf("📖 API文档地址: http://localhost:%d/docs", cfg.Server.Port)
 		log.Printf("💚 健康检查地址: http://localhost:%d/health", cfg.Server.Port)
+		log.Printf("🩺 详细健康检查: http://localhost:%d/health/detailed", cfg.Server.Port)
 		log.Printf("🌍 环境: %s", cfg.App.Environment)
 		
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
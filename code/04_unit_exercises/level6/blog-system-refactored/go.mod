@@ -7,6 +7,7 @@ toolchain go1.24.6
 require (
 	github.com/gin-gonic/gin v1.9.1
 	github.com/glebarez/sqlite v1.11.0
+	golang.org/x/sync v0.5.0
 	golang.org/x/time v0.5.0
 	gorm.io/driver/mysql v1.5.2
 	gorm.io/gorm v1.25.7
@@ -105,14 +105,11 @@ func TestConnection(db *gorm.DB) error {
 	return nil
 }
 
-// AutoMigrate 自动迁移数据库表结构
-// 参数: db - GORM数据库实例
-// 返回: error - 错误信息
-func AutoMigrate(db *gorm.DB) error {
-	log.Println("🔄 开始数据库表结构迁移...")
-
-	// 定义所有需要迁移的模型
-	models := []interface{}{
+// MigrationModels 所有需要迁移的模型，按依赖顺序排列
+// 健康检查根据这个列表判断迁移是否已全部执行
+// 返回: []interface{} - 模型列表
+func MigrationModels() []interface{} {
+	return []interface{}{
 		// 用户相关表
 		&models.User{},
 		&models.UserProfile{},
@@ -133,7 +130,23 @@ func AutoMigrate(db *gorm.DB) error {
 
 		// 分析统计表
 		&models.Analytics{},
+
+		// 审计日志表
+		&models.AuditLog{},
+
+		// 系统设置表
+		&models.Setting{},
 	}
+}
+
+// AutoMigrate 自动迁移数据库表结构
+// 参数: db - GORM数据库实例
+// 返回: error - 错误信息
+func AutoMigrate(db *gorm.DB) error {
+	log.Println("🔄 开始数据库表结构迁移...")
+
+	// 定义所有需要迁移的模型
+	models := MigrationModels()
 
 	// 先删除所有表（如果存在）
 	log.Println("🗑️ 清理现有表结构...")
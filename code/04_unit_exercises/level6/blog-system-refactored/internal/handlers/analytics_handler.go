@@ -181,7 +181,7 @@ type RealTimeStatsResponse struct {
 // @Failure 500 {object} ErrorResponse
 // @Router /api/analytics/dashboard [get]
 func (h *AnalyticsHandler) GetDashboardStats(c *gin.Context) {
-	stats, err := h.analyticsService.GetDashboardStats()
+	stats, err := h.analyticsService.GetDashboardStats(c.Request.Context())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Error:   "获取仪表板统计失败",
@@ -260,7 +260,7 @@ func (h *AnalyticsHandler) GetContentStats(c *gin.Context) {
 func (h *AnalyticsHandler) GetUserStats(c *gin.Context) {
 	// 注意：当前实现不使用日期范围参数
 	
-	stats, err := h.analyticsService.GetDashboardStats()
+	stats, err := h.analyticsService.GetDashboardStats(c.Request.Context())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Error:   "获取用户统计失败",
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"fmt"
 	"net/http"
 	"strconv"
 	"time"
@@ -88,6 +89,26 @@ type ReportRequest struct {
 	Details   string `json:"details,omitempty"`           // 详细说明
 }
 
+// BatchCommentStatusRequest 批量更新评论状态请求
+type BatchCommentStatusRequest struct {
+	IDs    []uint `json:"ids" binding:"required,min=1,max=1000"`                           // 评论ID列表
+	Status string `json:"status" binding:"required,oneof=pending approved rejected spam"` // 新状态
+	DryRun bool   `json:"dry_run"`                                                        // 只统计将受影响的评论数，不修改数据
+}
+
+// BatchDeleteCommentsRequest 批量删除评论请求
+type BatchDeleteCommentsRequest struct {
+	IDs    []uint `json:"ids" binding:"required,min=1,max=1000"` // 评论ID列表
+	DryRun bool   `json:"dry_run"`                              // 只统计将受影响的评论数，不修改数据
+}
+
+// BatchOperationResponse 批量操作响应
+// 预演时Affected为将受影响的数量，管理员确认后以 dry_run=false 重新提交执行
+type BatchOperationResponse struct {
+	Affected int64 `json:"affected"` // 受影响（预演时为将受影响）的评论数
+	DryRun   bool  `json:"dry_run"`  // 是否为预演
+}
+
 // 评论基本操作API
 
 // CreateComment 创建评论
@@ -586,6 +607,119 @@ func (h *CommentHandler) MarkAsSpam(c *gin.Context) {
 	})
 }
 
+// 批量管理API
+
+// BatchUpdateCommentStatus 批量更新评论状态
+// @Summary 批量更新评论状态
+// @Description 批量审核、拒绝或标记评论，dry_run为true时只返回将受影响的评论数
+// @Tags comments
+// @Accept json
+// @Produce json
+// @Param request body BatchCommentStatusRequest true "评论ID和新状态"
+// @Success 200 {object} BatchOperationResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /api/comments/batch/status [put]
+func (h *CommentHandler) BatchUpdateCommentStatus(c *gin.Context) {
+	var req BatchCommentStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "参数验证失败",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	affected, err := h.commentService.BatchUpdateStatus(req.IDs, req.Status, req.DryRun)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "批量更新评论状态失败",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, NewSuccessResponse(batchMessage("更新", affected, req.DryRun),
+		BatchOperationResponse{Affected: affected, DryRun: req.DryRun}))
+}
+
+// BatchDeleteComments 批量删除评论
+// @Summary 批量删除评论
+// @Description 批量软删除评论及其回复，dry_run为true时只返回将受影响的评论数
+// @Tags comments
+// @Accept json
+// @Produce json
+// @Param request body BatchDeleteCommentsRequest true "评论ID列表"
+// @Success 200 {object} BatchOperationResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /api/comments/batch/delete [post]
+func (h *CommentHandler) BatchDeleteComments(c *gin.Context) {
+	var req BatchDeleteCommentsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "参数验证失败",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	affected, err := h.commentService.BatchDeleteComments(req.IDs, req.DryRun)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "批量删除评论失败",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, NewSuccessResponse(batchMessage("删除", affected, req.DryRun),
+		BatchOperationResponse{Affected: affected, DryRun: req.DryRun}))
+}
+
+// PurgeDeletedComments 彻底清除软删除的评论
+// @Summary 清除已删除评论
+// @Description 彻底删除软删除超过指定天数的评论，dry_run为true时只返回将受影响的评论数
+// @Tags comments
+// @Produce json
+// @Param older_than_days query int true "软删除超过的天数"
+// @Param dry_run query bool false "只统计，不删除"
+// @Success 200 {object} BatchOperationResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /api/comments/purge [delete]
+func (h *CommentHandler) PurgeDeletedComments(c *gin.Context) {
+	days, err := strconv.Atoi(c.Query("older_than_days"))
+	if err != nil || days < 1 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "无效的天数",
+			Message: "older_than_days必须是大于0的整数",
+		})
+		return
+	}
+	dryRun, err := strconv.ParseBool(c.DefaultQuery("dry_run", "false"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "无效的参数",
+			Message: "dry_run必须是true或false",
+		})
+		return
+	}
+
+	before := time.Now().AddDate(0, 0, -days)
+	affected, err := h.commentService.PurgeDeletedOlderThan(before, dryRun)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "清除评论失败",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, NewSuccessResponse(batchMessage("清除", affected, dryRun),
+		BatchOperationResponse{Affected: affected, DryRun: dryRun}))
+}
+
 // 点赞和举报API
 
 // LikeTarget 点赞
@@ -754,6 +888,16 @@ func (h *CommentHandler) isAdmin(c *gin.Context) bool {
 	return role == "admin"
 }
 
+// batchMessage 批量操作的提示信息
+// 参数: action - 操作名称, affected - 受影响的评论数, dryRun - 是否为预演
+// 返回: string - 提示信息
+func batchMessage(action string, affected int64, dryRun bool) string {
+	if dryRun {
+		return fmt.Sprintf("预演：将%s%d条评论，未修改数据", action, affected)
+	}
+	return fmt.Sprintf("已%s%d条评论", action, affected)
+}
+
 // toCommentResponse 将评论模型转换为响应格式
 // 参数: comment - 评论模型
 // 返回: CommentResponse - 评论响应格式
@@ -768,7 +912,7 @@ func (h *CommentHandler) toCommentResponse(comment *models.Comment) CommentRespo
 		PostID:    comment.PostID,
 		ParentID:  parentID,
 		Content:   comment.Content,
-		Status:    string(comment.Status),
+		Status:    comment.Status.String(),
 		LikeCount: int64(comment.LikeCount),
 		Email:     "", // 字段不存在，设为空
 		Website:   "", // 字段不存在，设为空
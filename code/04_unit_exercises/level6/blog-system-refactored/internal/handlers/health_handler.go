@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"net/http"
+
+	"blog-system-refactored/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// HealthHandler 健康检查处理器
+// 负责汇总各子系统的健康状态
+type HealthHandler struct {
+	healthService services.HealthService
+}
+
+// NewHealthHandler 创建健康检查处理器实例
+// 参数: healthService - 健康检查服务接口
+// 返回: *HealthHandler - 健康检查处理器实例
+func NewHealthHandler(healthService services.HealthService) *HealthHandler {
+	return &HealthHandler{
+		healthService: healthService,
+	}
+}
+
+// Detailed 详细健康检查
+// @Summary 详细健康检查
+// @Description 检查数据库连接、迁移状态和通知积压，返回每项检查的状态、耗时和错误，整体状态取最差的一项
+// @Tags 系统
+// @Produce json
+// @Success 200 {object} services.HealthReport "ok或degraded"
+// @Failure 503 {object} services.HealthReport "down"
+// @Router /health/detailed [get]
+func (h *HealthHandler) Detailed(c *gin.Context) {
+	report := h.healthService.Check(c.Request.Context())
+
+	// degraded时服务仍可用，只有down返回503，避免负载均衡摘除还能提供服务的实例
+	status := http.StatusOK
+	if report.Status == services.HealthStatusDown {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, report)
+}
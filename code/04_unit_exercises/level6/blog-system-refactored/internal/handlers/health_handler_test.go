@@ -0,0 +1,127 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"blog-system-refactored/internal/config"
+	"blog-system-refactored/internal/handlers"
+	"blog-system-refactored/internal/models"
+	"blog-system-refactored/internal/services"
+	"blog-system-refactored/internal/testutil"
+)
+
+// getDetailedHealth 请求 /health/detailed，返回状态码和解析后的汇总
+func getDetailedHealth(t *testing.T, svc services.HealthService) (int, services.HealthReport) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/health/detailed", handlers.NewHealthHandler(svc).Detailed)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health/detailed", nil))
+	var report services.HealthReport
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+		t.Fatalf("解析响应失败: %v\n%s", err, w.Body.String())
+	}
+	return w.Code, report
+}
+
+// checkStatuses 各项检查的名称和状态，按注册顺序
+func checkStatuses(report services.HealthReport) []string {
+	var got []string
+	for _, c := range report.Checks {
+		got = append(got, c.Name+"="+string(c.Status))
+	}
+	return got
+}
+
+// TestDetailedHealthOK 迁移完整、没有积压时三项检查都正常
+func TestDetailedHealthOK(t *testing.T) {
+	db := testutil.NewDB(t)
+	code, report := getDetailedHealth(t, services.NewDefaultHealthService(db, config.MigrationModels()))
+
+	want := "database=ok migrations=ok notification_backlog=ok"
+	if got := strings.Join(checkStatuses(report), " "); code != http.StatusOK || report.Status != services.HealthStatusOK || got != want {
+		t.Errorf("返回 %d、%s、%s，期望 200、ok、%s", code, report.Status, got, want)
+	}
+}
+
+// TestDetailedHealthDegraded 缺少表或通知积压时整体为degraded，仍返回200，details中列出缺少的表
+func TestDetailedHealthDegraded(t *testing.T) {
+	db := testutil.NewDB(t)
+	if err := db.Migrator().DropTable(&models.Setting{}); err != nil {
+		t.Fatalf("删除表失败: %v", err)
+	}
+	user := testutil.CreateUser(t, db)
+	for i := 0; i < 3; i++ {
+		if err := db.Create(&models.Notification{UserID: user.ID, Type: 1, Title: "通知"}).Error; err != nil {
+			t.Fatalf("创建通知失败: %v", err)
+		}
+	}
+
+	svc := services.NewHealthService(
+		services.DatabaseHealthCheck(db),
+		services.MigrationHealthCheck(db, config.MigrationModels()),
+		services.NotificationBacklogHealthCheck(db, 2),
+	)
+	code, report := getDetailedHealth(t, svc)
+
+	want := "database=ok migrations=degraded notification_backlog=degraded"
+	if got := strings.Join(checkStatuses(report), " "); code != http.StatusOK || report.Status != services.HealthStatusDegraded || got != want {
+		t.Fatalf("返回 %d、%s、%s，期望 200、degraded、%s", code, report.Status, got, want)
+	}
+	details, _ := json.Marshal(report.Checks[1].Details)
+	if !strings.Contains(string(details), `"missing":["settings"]`) {
+		t.Errorf("迁移检查的details为 %s，期望缺少 settings", details)
+	}
+}
+
+// TestDetailedHealthDown 数据库连接不可用时整体为down，返回503
+func TestDetailedHealthDown(t *testing.T) {
+	db := testutil.NewDB(t)
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("获取数据库连接失败: %v", err)
+	}
+	sqlDB.Close()
+
+	code, report := getDetailedHealth(t, services.NewHealthService(services.DatabaseHealthCheck(db)))
+	if code != http.StatusServiceUnavailable || report.Status != services.HealthStatusDown || report.Checks[0].Error == "" {
+		t.Errorf("返回 %d、%+v，期望 503、down", code, report)
+	}
+}
+
+// TestHealthCheckTimeout 超时的检查不再等待，记为失败；其他检查的结果不受影响
+func TestHealthCheckTimeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	svc := services.NewHealthService(
+		services.HealthCheck{Name: "slow", Timeout: 20 * time.Millisecond, Run: func(ctx context.Context) (interface{}, error) {
+			<-release // 忽略ctx，模拟不响应取消的检查
+			return nil, nil
+		}},
+		services.HealthCheck{Name: "fast", Run: func(ctx context.Context) (interface{}, error) {
+			return "fine", nil
+		}},
+	)
+
+	start := time.Now()
+	report := svc.Check(context.Background())
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("检查耗时 %v，超时的检查没有被放弃", elapsed)
+	}
+	if got := strings.Join(checkStatuses(*report), " "); got != "slow=degraded fast=ok" || report.Status != services.HealthStatusDegraded {
+		t.Errorf("检查结果为 %s（整体 %s），期望 slow=degraded fast=ok", got, report.Status)
+	}
+	if !strings.Contains(report.Checks[0].Error, "超时") || report.Checks[1].Details != "fine" {
+		t.Errorf("检查结果为 %+v", report.Checks)
+	}
+}
@@ -431,7 +431,7 @@ func (h *PostHandler) DeletePost(c *gin.Context) {
 // @Tags posts
 // @Produce json
 // @Param page query int false "页码" default(1)
-// @Param page_size query int false "每页数量" default(10)
+// @Param page_size query int false "每页数量，默认为 posts_per_page 设置，最大100"
 // @Param status query string false "文章状态"
 // @Param category_id query int false "分类ID"
 // @Param author_id query int false "作者ID"
@@ -446,7 +446,7 @@ func (h *PostHandler) DeletePost(c *gin.Context) {
 func (h *PostHandler) ListPosts(c *gin.Context) {
 	// 解析查询参数
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
+	pageSize, _ := strconv.Atoi(c.Query("page_size"))
 	status := c.Query("status")
 	categoryID, _ := strconv.ParseUint(c.Query("category_id"), 10, 32)
 	authorID, _ := strconv.ParseUint(c.Query("author_id"), 10, 32)
@@ -458,9 +458,8 @@ func (h *PostHandler) ListPosts(c *gin.Context) {
 	if page < 1 {
 		page = 1
 	}
-	if pageSize < 1 || pageSize > 100 {
-		pageSize = 10
-	}
+	// 未指定（或无效）时使用 posts_per_page 设置
+	pageSize = h.postService.PostsPerPage(pageSize)
 
 	// 构建筛选条件
 	filters := map[string]interface{}{}
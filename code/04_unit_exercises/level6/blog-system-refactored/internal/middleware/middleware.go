@@ -1,12 +1,16 @@
 package middleware
 
 import (
+	"errors"
 	"fmt"
+	"log"
 	"net/http"
 	"strconv"
 	"strings"
 	"time"
 
+	"blog-system-refactored/internal/models"
+	"blog-system-refactored/internal/services"
 	"github.com/gin-gonic/gin"
 	"golang.org/x/time/rate"
 )
@@ -83,6 +87,64 @@ func RateLimit() gin.HandlerFunc {
 	}
 }
 
+// defaultMaintenanceMessage 未设置 maintenance_message 时的维护提示
+const defaultMaintenanceMessage = "系统维护中，请稍后再试"
+
+// MaintenanceMode 维护模式中间件
+// maintenance_mode 设置开启时，除健康检查外的请求返回503，提示取 maintenance_message 设置；
+// 管理员（按token中的角色判断）不受影响，可以继续访问全部接口。设置由 SettingService 缓存，不会每个请求都查询数据库
+// 参数: settings - 系统设置服务
+// 返回: gin.HandlerFunc - Gin中间件函数
+func MaintenanceMode(settings services.SettingService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if strings.HasPrefix(c.Request.URL.Path, "/health") {
+			c.Next()
+			return
+		}
+
+		enabled, err := settings.GetBool(models.SettingMaintenanceMode)
+		if err != nil {
+			// 读取设置失败时不拦截请求，避免设置表异常导致整站不可用
+			if !errors.Is(err, services.ErrSettingNotFound) {
+				log.Printf("⚠️ 读取维护模式设置失败: %v", err)
+			}
+			c.Next()
+			return
+		}
+		if !enabled || isAdminRequest(c) {
+			c.Next()
+			return
+		}
+
+		message, err := settings.GetString(models.SettingMaintenanceMessage)
+		if err != nil || strings.TrimSpace(message) == "" {
+			message = defaultMaintenanceMessage
+		}
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": "Service Unavailable",
+			"message": message,
+			"code": "MAINTENANCE_MODE",
+		})
+		c.Abort()
+	}
+}
+
+// isAdminRequest 请求是否来自管理员
+// 全局中间件在认证中间件之前执行，这里自行解析Authorization头中的token取得角色
+// 参数: c - Gin上下文
+// 返回: bool - 是否为管理员
+func isAdminRequest(c *gin.Context) bool {
+	authorization := c.GetHeader("Authorization")
+	if !strings.HasPrefix(authorization, "Bearer ") {
+		return false
+	}
+	_, role, err := validateToken(authorization[7:])
+	if err != nil {
+		return false
+	}
+	return role == "admin" || role == "super_admin"
+}
+
 // AuthRequired 认证中间件
 // 用于验证用户是否已登录
 // 返回: gin.HandlerFunc - Gin中间件函数
@@ -0,0 +1,122 @@
+package middleware_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"blog-system-refactored/internal/middleware"
+	"blog-system-refactored/internal/models"
+	"blog-system-refactored/internal/services"
+	"blog-system-refactored/internal/testutil"
+)
+
+// newMaintenanceRouter 创建只挂载维护模式中间件的路由，/api/posts 和 /health 都返回200
+func newMaintenanceRouter(settings services.SettingService) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middleware.MaintenanceMode(settings))
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	r.GET("/api/posts", ok)
+	r.GET("/health", ok)
+	return r
+}
+
+// request 发送GET请求，token不为空时带上Authorization头
+func request(r *gin.Engine, path, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+// TestMaintenanceMode 开启维护模式后普通用户的请求返回503和设置的提示，关闭后恢复
+func TestMaintenanceMode(t *testing.T) {
+	db := testutil.NewDB(t)
+	settings := services.NewSettingService(db, 0)
+	if err := settings.SeedDefaults(); err != nil {
+		t.Fatalf("创建内置设置失败: %v", err)
+	}
+	r := newMaintenanceRouter(settings)
+
+	if w := request(r, "/api/posts", ""); w.Code != http.StatusOK {
+		t.Fatalf("维护模式关闭时返回 %d，期望 200", w.Code)
+	}
+
+	if err := settings.Set(models.SettingMaintenanceMessage, "升级数据库，预计10分钟"); err != nil {
+		t.Fatalf("修改维护提示失败: %v", err)
+	}
+	if err := settings.Set(models.SettingMaintenanceMode, "true"); err != nil {
+		t.Fatalf("开启维护模式失败: %v", err)
+	}
+	// 未登录和普通用户的token都被拦截
+	for _, token := range []string{"", "user-token"} {
+		w := request(r, "/api/posts", token)
+		if w.Code != http.StatusServiceUnavailable {
+			t.Fatalf("维护模式开启时（token=%q）返回 %d，期望 503", token, w.Code)
+		}
+		var body struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatalf("解析响应失败: %v", err)
+		}
+		if body.Code != "MAINTENANCE_MODE" || body.Message != "升级数据库，预计10分钟" {
+			t.Errorf("返回 %+v，期望 MAINTENANCE_MODE 和设置的提示", body)
+		}
+	}
+	// 健康检查不受维护模式影响
+	if w := request(r, "/health", ""); w.Code != http.StatusOK {
+		t.Errorf("维护模式开启时健康检查返回 %d，期望 200", w.Code)
+	}
+
+	if err := settings.Set(models.SettingMaintenanceMode, "false"); err != nil {
+		t.Fatalf("关闭维护模式失败: %v", err)
+	}
+	if w := request(r, "/api/posts", ""); w.Code != http.StatusOK {
+		t.Errorf("维护模式关闭后返回 %d，期望 200", w.Code)
+	}
+}
+
+// TestMaintenanceModeWithoutSetting 没有维护模式设置时不拦截请求
+func TestMaintenanceModeWithoutSetting(t *testing.T) {
+	db := testutil.NewDB(t)
+	r := newMaintenanceRouter(services.NewSettingService(db, 0))
+
+	if w := request(r, "/api/posts", ""); w.Code != http.StatusOK {
+		t.Errorf("没有设置时返回 %d，期望 200", w.Code)
+	}
+}
+
+// TestMaintenanceModeDefaultMessage 维护提示为空时使用默认提示
+func TestMaintenanceModeDefaultMessage(t *testing.T) {
+	db := testutil.NewDB(t)
+	settings := services.NewSettingService(db, 0)
+	if err := settings.SeedDefaults(); err != nil {
+		t.Fatalf("创建内置设置失败: %v", err)
+	}
+	if err := settings.Set(models.SettingMaintenanceMessage, " "); err != nil {
+		t.Fatalf("修改维护提示失败: %v", err)
+	}
+	if err := settings.Set(models.SettingMaintenanceMode, "1"); err != nil {
+		t.Fatalf("开启维护模式失败: %v", err)
+	}
+
+	w := request(newMaintenanceRouter(settings), "/api/posts", "")
+	var body struct {
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("解析响应失败: %v", err)
+	}
+	if w.Code != http.StatusServiceUnavailable || body.Message != "系统维护中，请稍后再试" {
+		t.Errorf("返回 %d、%q，期望 503 和默认提示", w.Code, body.Message)
+	}
+}
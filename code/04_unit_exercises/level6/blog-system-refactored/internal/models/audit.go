@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+)
+
+// AuditLog 审计日志模型
+// 记录管理员的批量操作及原因，只追加不修改
+type AuditLog struct {
+	ID         uint      `gorm:"primarykey" json:"id"`                 // 主键ID
+	Action     string    `gorm:"size:50;not null;index" json:"action"` // 操作，如 comment.bulk_soft_delete
+	TargetType string    `gorm:"size:50;not null" json:"target_type"`  // 操作对象类型，如 comment
+	TargetIDs  string    `gorm:"type:text;not null" json:"target_ids"` // 受影响记录的ID（JSON数组）
+	Affected   int64     `gorm:"not null;default:0" json:"affected"`   // 受影响的记录数
+	Reason     string    `gorm:"size:255" json:"reason"`               // 操作原因
+	CreatedAt  time.Time `gorm:"index" json:"created_at"`              // 操作时间
+}
+
+// TableName 自定义表名
+func (AuditLog) TableName() string {
+	return "audit_logs"
+}
+
+// 审计操作
+const (
+	AuditActionCommentBulkSoftDelete = "comment.bulk_soft_delete" // 批量软删除评论
+)
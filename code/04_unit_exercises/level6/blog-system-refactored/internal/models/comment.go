@@ -18,6 +18,7 @@ type Comment struct {
 	IPAddress string        `gorm:"size:45" json:"ip_address,omitempty"`        // IP地址
 	UserAgent string        `gorm:"size:255" json:"user_agent,omitempty"`       // 用户代理
 	IsSpam    bool          `gorm:"default:false" json:"is_spam"`               // 是否为垃圾评论
+	DeletedReason *string   `gorm:"size:255" json:"deleted_reason,omitempty"`   // 删除原因（管理员批量删除时填写）
 	
 	// 关联关系
 	Post     *Post     `gorm:"foreignKey:PostID" json:"post,omitempty"`     // 文章
@@ -66,6 +67,18 @@ func (s CommentStatus) IsValid() bool {
 	return s >= CommentStatusPending && s <= CommentStatusDeleted
 }
 
+// ParseCommentStatus 将状态名称（pending、approved等）解析为评论状态
+// 参数: name - 状态名称
+// 返回: CommentStatus - 评论状态, bool - 名称是否有效
+func ParseCommentStatus(name string) (CommentStatus, bool) {
+	for s := CommentStatusPending; s <= CommentStatusDeleted; s++ {
+		if s.String() == name {
+			return s, true
+		}
+	}
+	return 0, false
+}
+
 // Like 点赞模型
 // 存储用户对文章或评论的点赞信息
 type Like struct {
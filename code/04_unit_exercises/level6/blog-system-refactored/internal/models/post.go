@@ -3,6 +3,8 @@ package models
 import (
 	"strings"
 	"time"
+
+	"gorm.io/gorm"
 )
 
 // Post 文章模型
@@ -69,6 +71,17 @@ func (s PostStatus) IsValid() bool {
 	return s >= PostStatusDraft && s <= PostStatusTrash
 }
 
+// Published 已发布且已到发布时间的文章（GORM作用域）
+// 用法: db.Scopes(models.Published())；条件带 posts 表名，与其他表JOIN时不会有列名歧义
+// 定时发布的文章 published_at 在未来，到时间之前不会出现在列表、搜索、推荐和统计中
+// 返回: func(*gorm.DB) *gorm.DB - 查询作用域
+func Published() func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("posts.status = ? AND posts.published_at IS NOT NULL AND posts.published_at <= ?",
+			PostStatusPublished, time.Now())
+	}
+}
+
 // Category 分类模型
 // 存储文章分类信息
 type Category struct {
@@ -0,0 +1,47 @@
+package models_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"blog-system-refactored/internal/models"
+	"blog-system-refactored/internal/testutil"
+)
+
+// TestPublishedScope 只包括已发布且发布时间已到的文章：草稿、定时发布、缺少发布时间和已删除的文章都不包括；
+// 与同样有 status 列的评论表JOIN时条件不会有歧义
+func TestPublishedScope(t *testing.T) {
+	db := testutil.NewDB(t)
+	author := testutil.CreateUser(t, db)
+
+	visible := testutil.CreatePost(t, db, author.ID, models.PostStatusPublished)
+	scheduled := testutil.CreatePost(t, db, author.ID, models.PostStatusPublished)
+	noTime := testutil.CreatePost(t, db, author.ID, models.PostStatusPublished)
+	draft := testutil.CreatePost(t, db, author.ID, models.PostStatusDraft)
+	deleted := testutil.CreatePost(t, db, author.ID, models.PostStatusPublished)
+	db.Model(&scheduled).Update("published_at", time.Now().Add(time.Hour))
+	db.Model(&noTime).Update("published_at", nil)
+	db.Model(&draft).Update("published_at", time.Now().Add(-time.Hour))
+	if err := db.Delete(&deleted).Error; err != nil {
+		t.Fatalf("删除文章失败: %v", err)
+	}
+
+	var ids []uint
+	if err := db.Model(&models.Post{}).Scopes(models.Published()).Order("id").Pluck("id", &ids).Error; err != nil {
+		t.Fatalf("查询文章失败: %v", err)
+	}
+	if want := []uint{visible.ID}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("已发布的文章为 %v，期望 %v", ids, want)
+	}
+
+	for _, post := range []models.Post{visible, scheduled, draft} {
+		testutil.CreateComment(t, db, post.ID, author.ID, 0, models.CommentStatusApproved)
+	}
+	var n int64
+	err := db.Model(&models.Comment{}).Joins("JOIN posts ON posts.id = comments.post_id").
+		Scopes(models.Published()).Count(&n).Error
+	if err != nil || n != 1 {
+		t.Errorf("已发布文章的评论有 %d 条（%v），期望 1", n, err)
+	}
+}
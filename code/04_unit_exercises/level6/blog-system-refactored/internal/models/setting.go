@@ -0,0 +1,33 @@
+package models
+
+// Setting 系统设置模型
+// 键值对形式的系统配置，Value 统一按文本保存，读取时按 Type 转换
+type Setting struct {
+	BaseModel
+	Key         string `gorm:"uniqueIndex;size:100;not null" json:"key"` // 配置键名，唯一索引
+	Value       string `gorm:"type:text" json:"value"`                   // 配置值
+	Type        string `gorm:"size:20;default:'string'" json:"type"`     // 数据类型(string/integer/boolean/json)
+	Description string `gorm:"size:255" json:"description"`              // 配置描述
+	Group       string `gorm:"size:50;index" json:"group"`               // 配置分组
+	IsPublic    bool   `gorm:"default:false" json:"is_public"`           // 是否为公开配置
+}
+
+// TableName 自定义表名
+func (Setting) TableName() string {
+	return "settings"
+}
+
+// 设置的数据类型
+const (
+	SettingTypeString  = "string"  // 字符串
+	SettingTypeInteger = "integer" // 整数
+	SettingTypeBoolean = "boolean" // 布尔值
+	SettingTypeJSON    = "json"    // JSON
+)
+
+// 系统设置键名
+const (
+	SettingMaintenanceMode    = "maintenance_mode"    // 维护模式，开启后非管理员请求返回503
+	SettingMaintenanceMessage = "maintenance_message" // 维护模式下返回给用户的提示
+	SettingPostsPerPage       = "posts_per_page"      // 文章列表未指定每页数量时的默认值
+)
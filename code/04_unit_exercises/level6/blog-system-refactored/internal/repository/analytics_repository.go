@@ -238,7 +238,7 @@ func (r *analyticsRepository) GetDashboardStats() (*models.DashboardStats, error
 	r.db.Model(&models.User{}).Where("last_login_at >= ?", weekAgo).Count(&stats.ActiveUsers)
 	
 	// 已发布文章数
-	r.db.Model(&models.Post{}).Where("status = ?", "published").Count(&stats.PublishedPosts)
+	r.db.Model(&models.Post{}).Scopes(models.Published()).Count(&stats.PublishedPosts)
 	
 	// 待审核评论数
 	r.db.Model(&models.Comment{}).Where("status = ?", "pending").Count(&stats.PendingComments)
@@ -303,7 +303,7 @@ func (r *analyticsRepository) GetContentStats() (*models.ContentStats, error) {
 	
 	// 文章统计
 	r.db.Model(&models.Post{}).Count(&stats.TotalPosts)
-	r.db.Model(&models.Post{}).Where("status = ?", "published").Count(&stats.PublishedPosts)
+	r.db.Model(&models.Post{}).Scopes(models.Published()).Count(&stats.PublishedPosts)
 	r.db.Model(&models.Post{}).Where("status = ?", "draft").Count(&stats.DraftPosts)
 	
 	// 评论统计
@@ -379,7 +379,8 @@ func (r *analyticsRepository) GetPopularPosts(limit int, days int) ([]models.Pop
 			WHERE target_type = 'post' AND created_at >= ?
 			GROUP BY target_id
 		) like_counts ON posts.id = like_counts.target_id`, startDate).
-		Where("posts.status = ? AND posts.published_at >= ?", "published", startDate).
+		Scopes(models.Published()).
+		Where("posts.published_at >= ?", startDate).
 		Order("popularity_score DESC").Limit(limit).Scan(&popularPosts).Error
 	
 	return popularPosts, err
@@ -600,7 +601,8 @@ func (r *analyticsRepository) GetViewTrends(days int) ([]TrendData, error) {
 	
 	err := r.db.Table("posts").
 		Select("DATE(published_at) as date, SUM(view_count) as value").
-		Where("published_at >= ? AND status = ?", startDate, "published").
+		Scopes(models.Published()).
+		Where("published_at >= ?", startDate).
 		Group("DATE(published_at)").Order("date").Scan(&trends).Error
 	
 	return trends, err
@@ -657,7 +659,8 @@ func (r *analyticsRepository) GetTopPerformingContent(contentType string, metric
 		
 		err := r.db.Table("posts").
 			Select("id, title, 'post' as type, view_count as metric_value, created_at").
-			Where("created_at >= ? AND status = ?", startDate, "published").
+			Scopes(models.Published()).
+			Where("created_at >= ?", startDate).
 			Order(orderBy).Limit(limit).Scan(&performanceData).Error
 		return performanceData, err
 		
@@ -855,7 +858,8 @@ func (r *analyticsRepository) GetMetricsByDateRange(metric string, startDate, en
 	case "views":
 		err := r.db.Table("posts").
 			Select("DATE(published_at) as date, 'views' as metric, SUM(view_count) as value").
-			Where("published_at BETWEEN ? AND ? AND status = ?", startDate, endDate, "published").
+			Scopes(models.Published()).
+			Where("published_at BETWEEN ? AND ?", startDate, endDate).
 			Group("DATE(published_at)").Order("date").Scan(&metricData).Error
 		return metricData, err
 		
@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+)
+
+// applyOrCount 执行批量修改，或只统计将受影响的行数（预演）
+// 参数: query - 带有筛选条件的查询, dryRun - 为true时只用同一查询条件COUNT，不修改数据, apply - 在query上执行修改
+// 返回: int64 - 受影响（预演时为将受影响）的行数, error - 错误信息
+// 预演和实际执行使用同一个query，两者的WHERE条件完全相同
+func applyOrCount(query *gorm.DB, dryRun bool, apply func(tx *gorm.DB) *gorm.DB) (int64, error) {
+	if dryRun {
+		var count int64
+		err := query.Count(&count).Error
+		return count, err
+	}
+
+	result := apply(query)
+	return result.RowsAffected, result.Error
+}
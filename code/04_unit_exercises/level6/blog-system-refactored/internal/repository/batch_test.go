@@ -0,0 +1,56 @@
+package repository_test
+
+import (
+	"testing"
+
+	"blog-system-refactored/internal/models"
+	"blog-system-refactored/internal/repository"
+	"blog-system-refactored/internal/testutil"
+)
+
+// TestBatchDeleteDryRun 文章和用户的批量删除预演返回将删除的行数，不修改数据；已删除和不存在的ID不计入
+func TestBatchDeleteDryRun(t *testing.T) {
+	db := testutil.NewDB(t)
+	posts := repository.NewPostRepository(db)
+	users := repository.NewUserRepository(db)
+
+	author := testutil.CreateUser(t, db)
+	other := testutil.CreateUser(t, db)
+	p1 := testutil.CreatePost(t, db, author.ID, models.PostStatusPublished)
+	p2 := testutil.CreatePost(t, db, author.ID, models.PostStatusDraft)
+	p3 := testutil.CreatePost(t, db, author.ID, models.PostStatusDraft)
+	if err := db.Delete(&models.Post{}, p3.ID).Error; err != nil {
+		t.Fatalf("删除文章失败: %v", err)
+	}
+	postIDs := []uint{p1.ID, p2.ID, p3.ID, 9999}
+
+	countLive := func(model interface{}) int64 {
+		var n int64
+		db.Model(model).Count(&n)
+		return n
+	}
+
+	if affected, err := posts.BatchDelete(postIDs, true); err != nil || affected != 2 {
+		t.Fatalf("文章预演返回 %d（%v），期望 2", affected, err)
+	}
+	if n := countLive(&models.Post{}); n != 2 {
+		t.Errorf("预演后剩余 %d 篇文章，期望 2", n)
+	}
+	if affected, err := posts.BatchDelete(postIDs, false); err != nil || affected != 2 {
+		t.Fatalf("文章删除返回 %d（%v），期望 2", affected, err)
+	}
+	if n := countLive(&models.Post{}); n != 0 {
+		t.Errorf("删除后剩余 %d 篇文章，期望 0", n)
+	}
+
+	if affected, err := users.BatchDelete([]uint{author.ID, other.ID}, true); err != nil || affected != 2 {
+		t.Fatalf("用户预演返回 %d（%v），期望 2", affected, err)
+	}
+	if n := countLive(&models.User{}); n != 2 {
+		t.Errorf("预演后剩余 %d 个用户，期望 2", n)
+	}
+
+	if _, err := posts.BatchDelete(nil, true); err == nil {
+		t.Error("ID列表为空时预演成功")
+	}
+}
@@ -14,13 +14,13 @@ import (
 type CommentRepository interface {
 	// 基本CRUD操作
 	Create(comment *models.Comment) error                      // 创建评论
-	GetByID(id uint) (*models.Comment, error)                 // 根据ID获取评论
+	GetByID(id uint, opts ...QueryOption) (*models.Comment, error) // 根据ID获取评论
 	Update(comment *models.Comment) error                     // 更新评论
 	Delete(id uint) error                                     // 删除评论（软删除）
 	HardDelete(id uint) error                                 // 硬删除评论
 	
 	// 查询操作
-	List(offset, limit int) ([]models.Comment, error)        // 分页获取评论列表
+	List(offset, limit int, opts ...QueryOption) ([]models.Comment, error) // 分页获取评论列表
 	ListByPost(postID uint, offset, limit int) ([]models.Comment, error) // 根据文章获取评论
 	ListByUser(userID uint, offset, limit int) ([]models.Comment, error) // 根据用户获取评论
 	ListByStatus(status string, offset, limit int) ([]models.Comment, error) // 根据状态获取评论
@@ -42,7 +42,7 @@ type CommentRepository interface {
 	Approve(id uint) error                                   // 批准评论
 	Reject(id uint) error                                    // 拒绝评论
 	MarkAsSpam(id uint) error                                // 标记为垃圾评论
-	BatchUpdateStatus(commentIDs []uint, status string) error // 批量更新状态
+	BatchUpdateStatus(commentIDs []uint, status string, dryRun bool) (int64, error) // 批量更新状态，dryRun时只统计
 	
 	// 点赞操作
 	AddLike(commentID, userID uint) error                    // 添加点赞
@@ -56,7 +56,8 @@ type CommentRepository interface {
 	
 	// 批量操作
 	BatchCreate(comments []models.Comment) error             // 批量创建评论
-	BatchDelete(commentIDs []uint) error                     // 批量删除评论
+	BatchDelete(commentIDs []uint, dryRun bool) (int64, error) // 批量删除评论，dryRun时只统计
+	PurgeDeletedOlderThan(before time.Time, dryRun bool) (int64, error) // 彻底清除早于指定时间软删除的评论，dryRun时只统计
 	
 	// 高级查询
 	GetRecentComments(limit int) ([]models.Comment, error)   // 获取最新评论
@@ -149,15 +150,16 @@ func (r *commentRepository) Create(comment *models.Comment) error {
 }
 
 // GetByID 根据ID获取评论
-// 参数: id - 评论ID
+// 参数: id - 评论ID, opts - 查询选项，同时决定评论和作者、文章的可见性
 // 返回: *models.Comment - 评论对象, error - 错误信息
-func (r *commentRepository) GetByID(id uint) (*models.Comment, error) {
+func (r *commentRepository) GetByID(id uint, opts ...QueryOption) (*models.Comment, error) {
 	if id == 0 {
 		return nil, errors.New("评论ID不能为空")
 	}
 	
 	comment := &models.Comment{}
-	err := r.db.Preload("User").Preload("Post").First(comment, id).Error
+	query := preload(preload(scoped(r.db, opts...), "User", opts...), "Post", opts...)
+	err := query.First(comment, id).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, errors.New("评论不存在")
@@ -218,9 +220,9 @@ func (r *commentRepository) HardDelete(id uint) error {
 // 查询操作实现
 
 // List 分页获取评论列表
-// 参数: offset - 偏移量, limit - 限制数量
+// 参数: offset - 偏移量, limit - 限制数量, opts - 查询选项，同时决定评论和作者、文章的可见性
 // 返回: []models.Comment - 评论列表, error - 错误信息
-func (r *commentRepository) List(offset, limit int) ([]models.Comment, error) {
+func (r *commentRepository) List(offset, limit int, opts ...QueryOption) ([]models.Comment, error) {
 	if offset < 0 {
 		offset = 0
 	}
@@ -229,7 +231,8 @@ func (r *commentRepository) List(offset, limit int) ([]models.Comment, error) {
 	}
 	
 	var comments []models.Comment
-	err := r.db.Preload("User").Preload("Post").
+	query := preload(preload(scoped(r.db, opts...), "User", opts...), "Post", opts...)
+	err := query.
 		Offset(offset).Limit(limit).Order("created_at DESC").Find(&comments).Error
 	return comments, err
 }
@@ -516,17 +519,21 @@ func (r *commentRepository) MarkAsSpam(id uint) error {
 }
 
 // BatchUpdateStatus 批量更新状态
-// 参数: commentIDs - 评论ID列表, status - 新状态
-// 返回: error - 错误信息
-func (r *commentRepository) BatchUpdateStatus(commentIDs []uint, status string) error {
+// 参数: commentIDs - 评论ID列表, status - 新状态, dryRun - 为true时只统计将被更新的行数，不修改数据
+// 返回: int64 - 受影响（预演时为将受影响）的行数, error - 错误信息
+func (r *commentRepository) BatchUpdateStatus(commentIDs []uint, status string, dryRun bool) (int64, error) {
 	if len(commentIDs) == 0 {
-		return errors.New("评论ID列表不能为空")
+		return 0, errors.New("评论ID列表不能为空")
 	}
 	if status == "" {
-		return errors.New("状态不能为空")
+		return 0, errors.New("状态不能为空")
 	}
 	
-	return r.db.Model(&models.Comment{}).Where("id IN (?)", commentIDs).Update("status", status).Error
+	// 已是目标状态的行不计入，预演统计的行数与实际更新的行数一致
+	query := r.db.Model(&models.Comment{}).Where("id IN (?) AND status <> ?", commentIDs, status)
+	return applyOrCount(query, dryRun, func(tx *gorm.DB) *gorm.DB {
+		return tx.Update("status", status)
+	})
 }
 
 // 点赞操作实现
@@ -657,15 +664,56 @@ func (r *commentRepository) BatchCreate(comments []models.Comment) error {
 	return r.db.CreateInBatches(comments, 100).Error
 }
 
-// BatchDelete 批量删除评论
-// 参数: commentIDs - 评论ID列表
-// 返回: error - 错误信息
-func (r *commentRepository) BatchDelete(commentIDs []uint) error {
+// BatchDelete 批量删除评论（软删除）
+// 参数: commentIDs - 评论ID列表, dryRun - 为true时只统计将被删除的行数，不修改数据
+// 返回: int64 - 受影响（预演时为将受影响）的行数, error - 错误信息
+func (r *commentRepository) BatchDelete(commentIDs []uint, dryRun bool) (int64, error) {
 	if len(commentIDs) == 0 {
-		return errors.New("评论ID列表不能为空")
+		return 0, errors.New("评论ID列表不能为空")
+	}
+	
+	query := r.db.Model(&models.Comment{}).Where("id IN (?)", commentIDs)
+	return applyOrCount(query, dryRun, func(tx *gorm.DB) *gorm.DB {
+		return tx.Delete(&models.Comment{})
+	})
+}
+
+// PurgeDeletedOlderThan 彻底清除软删除时间早于before的评论及其点赞记录，清除后无法恢复
+// 参数: before - 软删除时间上限（不含）, dryRun - 为true时只统计将被清除的评论数，不修改数据
+// 返回: int64 - 清除（预演时为将被清除）的评论数, error - 错误信息
+func (r *commentRepository) PurgeDeletedOlderThan(before time.Time, dryRun bool) (int64, error) {
+	if before.IsZero() {
+		return 0, errors.New("清除时间不能为空")
+	}
+	
+	purgeable := func(tx *gorm.DB) *gorm.DB {
+		return scoped(tx, OnlyDeleted()).Model(&models.Comment{}).Where("deleted_at < ?", before)
+	}
+	if dryRun {
+		return applyOrCount(purgeable(r.db), true, nil)
+	}
+	
+	var purged int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// 先硬删除这些评论的点赞记录，避免留下指向不存在评论的数据
+		ids := purgeable(tx).Select("id")
+		if err := tx.Unscoped().
+			Where("comment_id IN (?) OR (target_type = ? AND target_id IN (?))", ids, "comment", ids).
+			Delete(&models.Like{}).Error; err != nil {
+			return err
+		}
+		
+		var err error
+		purged, err = applyOrCount(purgeable(tx), false, func(q *gorm.DB) *gorm.DB {
+			return q.Unscoped().Delete(&models.Comment{})
+		})
+		return err
+	})
+	if err != nil {
+		return 0, err
 	}
 	
-	return r.db.Delete(&models.Comment{}, commentIDs).Error
+	return purged, nil
 }
 
 // 高级查询实现
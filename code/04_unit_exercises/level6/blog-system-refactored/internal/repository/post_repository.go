@@ -13,14 +13,14 @@ import (
 type PostRepository interface {
 	// 基本CRUD操作
 	Create(post *models.Post) error                             // 创建文章
-	GetByID(id uint) (*models.Post, error)                     // 根据ID获取文章
-	GetBySlug(slug string) (*models.Post, error)               // 根据slug获取文章
+	GetByID(id uint, opts ...QueryOption) (*models.Post, error)       // 根据ID获取文章
+	GetBySlug(slug string, opts ...QueryOption) (*models.Post, error) // 根据slug获取文章
 	Update(post *models.Post) error                            // 更新文章
 	Delete(id uint) error                                      // 删除文章（软删除）
 	HardDelete(id uint) error                                  // 硬删除文章
 	
 	// 查询操作
-	List(offset, limit int) ([]models.Post, error)            // 分页获取文章列表
+	List(offset, limit int, opts ...QueryOption) ([]models.Post, error) // 分页获取文章列表
 	ListPublished(offset, limit int) ([]models.Post, error)   // 分页获取已发布文章
 	ListByAuthor(authorID uint, offset, limit int) ([]models.Post, error) // 根据作者获取文章
 	ListByCategory(categoryID uint, offset, limit int) ([]models.Post, error) // 根据分类获取文章
@@ -57,11 +57,11 @@ type PostRepository interface {
 	
 	// 批量操作
 	BatchCreate(posts []models.Post) error                     // 批量创建文章
-	BatchUpdateStatus(postIDs []uint, status string) error    // 批量更新状态
-	BatchDelete(postIDs []uint) error                         // 批量删除文章
+	BatchUpdateStatus(postIDs []uint, status string, dryRun bool) (int64, error) // 批量更新状态，dryRun时只统计
+	BatchDelete(postIDs []uint, dryRun bool) (int64, error) // 批量删除文章，dryRun时只统计
 	
 	// 高级查询
-	GetPostWithDetails(id uint) (*PostWithDetails, error)     // 获取文章详细信息
+	GetPostWithDetails(id uint, opts ...QueryOption) (*PostWithDetails, error) // 获取文章详细信息
 	GetPostsWithStats(offset, limit int) ([]PostWithStats, error) // 获取文章及统计信息
 	GetArchive(year, month int) ([]models.Post, error)        // 获取归档文章
 	GetSitemap() ([]PostSitemap, error)                       // 获取站点地图数据
@@ -162,15 +162,15 @@ func (r *postRepository) Create(post *models.Post) error {
 }
 
 // GetByID 根据ID获取文章
-// 参数: id - 文章ID
+// 参数: id - 文章ID, opts - 查询选项（默认不包括已删除的文章）
 // 返回: *models.Post - 文章对象, error - 错误信息
-func (r *postRepository) GetByID(id uint) (*models.Post, error) {
+func (r *postRepository) GetByID(id uint, opts ...QueryOption) (*models.Post, error) {
 	if id == 0 {
 		return nil, errors.New("文章ID不能为空")
 	}
 	
 	post := &models.Post{}
-	err := r.db.First(post, id).Error
+	err := scoped(r.db, opts...).First(post, id).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, errors.New("文章不存在")
@@ -182,15 +182,15 @@ func (r *postRepository) GetByID(id uint) (*models.Post, error) {
 }
 
 // GetBySlug 根据slug获取文章
-// 参数: slug - 文章slug
+// 参数: slug - 文章slug, opts - 查询选项（默认不包括已删除的文章）
 // 返回: *models.Post - 文章对象, error - 错误信息
-func (r *postRepository) GetBySlug(slug string) (*models.Post, error) {
+func (r *postRepository) GetBySlug(slug string, opts ...QueryOption) (*models.Post, error) {
 	if slug == "" {
 		return nil, errors.New("slug不能为空")
 	}
 	
 	post := &models.Post{}
-	err := r.db.Where("slug = ?", slug).First(post).Error
+	err := scoped(r.db, opts...).Where("slug = ?", slug).First(post).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, errors.New("文章不存在")
@@ -267,9 +267,9 @@ func (r *postRepository) HardDelete(id uint) error {
 // 查询操作实现
 
 // List 分页获取文章列表
-// 参数: offset - 偏移量, limit - 限制数量
+// 参数: offset - 偏移量, limit - 限制数量, opts - 查询选项，同时决定文章和作者、分类的可见性
 // 返回: []models.Post - 文章列表, error - 错误信息
-func (r *postRepository) List(offset, limit int) ([]models.Post, error) {
+func (r *postRepository) List(offset, limit int, opts ...QueryOption) ([]models.Post, error) {
 	if offset < 0 {
 		offset = 0
 	}
@@ -278,7 +278,8 @@ func (r *postRepository) List(offset, limit int) ([]models.Post, error) {
 	}
 	
 	var posts []models.Post
-	err := r.db.Preload("Author").Preload("Category").
+	query := preload(preload(scoped(r.db, opts...), "Author", opts...), "Category", opts...)
+	err := query.
 		Offset(offset).Limit(limit).Order("created_at DESC").Find(&posts).Error
 	return posts, err
 }
@@ -296,7 +297,7 @@ func (r *postRepository) ListPublished(offset, limit int) ([]models.Post, error)
 	
 	var posts []models.Post
 	err := r.db.Preload("Author").Preload("Category").
-		Where("status = ? AND published_at <= ?", "published", time.Now()).
+		Scopes(models.Published()).
 		Offset(offset).Limit(limit).Order("published_at DESC").Find(&posts).Error
 	return posts, err
 }
@@ -340,7 +341,8 @@ func (r *postRepository) ListByCategory(categoryID uint, offset, limit int) ([]m
 	
 	var posts []models.Post
 	err := r.db.Preload("Author").Preload("Category").
-		Where("category_id = ? AND status = ?", categoryID, "published").
+		Scopes(models.Published()).
+		Where("category_id = ?", categoryID).
 		Offset(offset).Limit(limit).Order("published_at DESC").Find(&posts).Error
 	return posts, err
 }
@@ -363,7 +365,8 @@ func (r *postRepository) ListByTag(tagID uint, offset, limit int) ([]models.Post
 	var posts []models.Post
 	err := r.db.Preload("Author").Preload("Category").
 		Joins("JOIN post_tags ON posts.id = post_tags.post_id").
-		Where("post_tags.tag_id = ? AND posts.status = ?", tagID, "published").
+		Scopes(models.Published()).
+		Where("post_tags.tag_id = ?", tagID).
 		Offset(offset).Limit(limit).Order("posts.published_at DESC").Find(&posts).Error
 	return posts, err
 }
@@ -433,8 +436,8 @@ func (r *postRepository) SearchPublished(keyword string, offset, limit int) ([]m
 	var posts []models.Post
 	keyword = "%" + keyword + "%"
 	err := r.db.Preload("Author").Preload("Category").
-		Where("(title LIKE ? OR content LIKE ? OR excerpt LIKE ?) AND status = ? AND published_at <= ?", 
-			keyword, keyword, keyword, "published", time.Now()).
+		Scopes(models.Published()).
+		Where("title LIKE ? OR content LIKE ? OR excerpt LIKE ?", keyword, keyword, keyword).
 		Offset(offset).Limit(limit).Order("published_at DESC").Find(&posts).Error
 	return posts, err
 }
@@ -580,7 +583,8 @@ func (r *postRepository) GetPopularPosts(limit int, days int) ([]models.Post, er
 	startDate := time.Now().AddDate(0, 0, -days)
 	
 	err := r.db.Preload("Author").Preload("Category").
-		Where("status = ? AND published_at >= ? AND published_at <= ?", "published", startDate, time.Now()).
+		Scopes(models.Published()).
+		Where("published_at >= ?", startDate).
 		Order("view_count DESC, (SELECT COUNT(*) FROM comments WHERE post_id = posts.id) DESC").
 		Limit(limit).Find(&posts).Error
 	
@@ -597,7 +601,7 @@ func (r *postRepository) GetRecentPosts(limit int) ([]models.Post, error) {
 	
 	var posts []models.Post
 	err := r.db.Preload("Author").Preload("Category").
-		Where("status = ? AND published_at <= ?", "published", time.Now()).
+		Scopes(models.Published()).
 		Order("published_at DESC").Limit(limit).Find(&posts).Error
 	
 	return posts, err
@@ -618,8 +622,8 @@ func (r *postRepository) GetRecommendedPosts(userID uint, limit int) ([]models.P
 		// 获取用户关注的作者的文章
 		err := r.db.Preload("Author").Preload("Category").
 			Joins("JOIN follows ON posts.author_id = follows.following_id").
-			Where("follows.follower_id = ? AND posts.status = ? AND posts.published_at <= ?", 
-				userID, "published", time.Now()).
+			Scopes(models.Published()).
+			Where("follows.follower_id = ?", userID).
 			Order("posts.published_at DESC").Limit(limit).Find(&posts).Error
 		
 		if err == nil && len(posts) > 0 {
@@ -653,8 +657,8 @@ func (r *postRepository) GetRelatedPosts(postID uint, limit int) ([]models.Post,
 	// 首先尝试获取同分类的文章
 	if post.CategoryID != nil && *post.CategoryID != 0 {
 		err = r.db.Preload("Author").Preload("Category").
-			Where("category_id = ? AND id != ? AND status = ? AND published_at <= ?", 
-				*post.CategoryID, postID, "published", time.Now()).
+			Scopes(models.Published()).
+			Where("category_id = ? AND id != ?", *post.CategoryID, postID).
 			Order("published_at DESC").Limit(limit).Find(&relatedPosts).Error
 		
 		if err == nil && len(relatedPosts) >= limit {
@@ -672,8 +676,8 @@ func (r *postRepository) GetRelatedPosts(postID uint, limit int) ([]models.Post,
 		}
 		
 		r.db.Preload("Author").Preload("Category").
-			Where("author_id = ? AND id NOT IN (?) AND status = ? AND published_at <= ?", 
-				post.AuthorID, excludeIDs, "published", time.Now()).
+			Scopes(models.Published()).
+			Where("author_id = ? AND id NOT IN (?)", post.AuthorID, excludeIDs).
 			Order("published_at DESC").Limit(remaining).Find(&authorPosts)
 		
 		relatedPosts = append(relatedPosts, authorPosts...)
@@ -704,7 +708,7 @@ func (r *postRepository) GetMostViewedPosts(limit int, days int) ([]models.Post,
 	}
 	
 	query := r.db.Preload("Author").Preload("Category").
-		Where("status = ?", "published")
+		Scopes(models.Published())
 	
 	if days > 0 {
 		startDate := time.Now().AddDate(0, 0, -days)
@@ -854,14 +858,14 @@ func (r *postRepository) BatchCreate(posts []models.Post) error {
 }
 
 // BatchUpdateStatus 批量更新状态
-// 参数: postIDs - 文章ID列表, status - 新状态
-// 返回: error - 错误信息
-func (r *postRepository) BatchUpdateStatus(postIDs []uint, status string) error {
+// 参数: postIDs - 文章ID列表, status - 新状态, dryRun - 为true时只统计将被更新的行数，不修改数据
+// 返回: int64 - 受影响（预演时为将受影响）的行数, error - 错误信息
+func (r *postRepository) BatchUpdateStatus(postIDs []uint, status string, dryRun bool) (int64, error) {
 	if len(postIDs) == 0 {
-		return errors.New("文章ID列表不能为空")
+		return 0, errors.New("文章ID列表不能为空")
 	}
 	if status == "" {
-		return errors.New("状态不能为空")
+		return 0, errors.New("状态不能为空")
 	}
 	
 	updateData := map[string]interface{}{"status": status}
@@ -869,33 +873,41 @@ func (r *postRepository) BatchUpdateStatus(postIDs []uint, status string) error
 		updateData["published_at"] = time.Now()
 	}
 	
-	return r.db.Model(&models.Post{}).Where("id IN (?)", postIDs).Updates(updateData).Error
+	// 已是目标状态的行不计入，预演统计的行数与实际更新的行数一致
+	query := r.db.Model(&models.Post{}).Where("id IN (?) AND status <> ?", postIDs, status)
+	return applyOrCount(query, dryRun, func(tx *gorm.DB) *gorm.DB {
+		return tx.Updates(updateData)
+	})
 }
 
-// BatchDelete 批量删除文章
-// 参数: postIDs - 文章ID列表
-// 返回: error - 错误信息
-func (r *postRepository) BatchDelete(postIDs []uint) error {
+// BatchDelete 批量删除文章（软删除）
+// 参数: postIDs - 文章ID列表, dryRun - 为true时只统计将被删除的行数，不修改数据
+// 返回: int64 - 受影响（预演时为将受影响）的行数, error - 错误信息
+func (r *postRepository) BatchDelete(postIDs []uint, dryRun bool) (int64, error) {
 	if len(postIDs) == 0 {
-		return errors.New("文章ID列表不能为空")
+		return 0, errors.New("文章ID列表不能为空")
 	}
 	
-	return r.db.Delete(&models.Post{}, postIDs).Error
+	query := r.db.Model(&models.Post{}).Where("id IN (?)", postIDs)
+	return applyOrCount(query, dryRun, func(tx *gorm.DB) *gorm.DB {
+		return tx.Delete(&models.Post{})
+	})
 }
 
 // 高级查询实现
 
 // GetPostWithDetails 获取文章详细信息
-// 参数: id - 文章ID
+// 参数: id - 文章ID, opts - 查询选项，同时决定文章和作者、分类的可见性
 // 返回: *PostWithDetails - 文章详细信息, error - 错误信息
-func (r *postRepository) GetPostWithDetails(id uint) (*PostWithDetails, error) {
+func (r *postRepository) GetPostWithDetails(id uint, opts ...QueryOption) (*PostWithDetails, error) {
 	if id == 0 {
 		return nil, errors.New("文章ID不能为空")
 	}
 	
 	// 获取文章基本信息
 	post := &models.Post{}
-	err := r.db.Preload("Author").Preload("Category").First(post, id).Error
+	query := preload(preload(scoped(r.db, opts...), "Author", opts...), "Category", opts...)
+	err := query.First(post, id).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, errors.New("文章不存在")
@@ -972,7 +984,8 @@ func (r *postRepository) GetArchive(year, month int) ([]models.Post, error) {
 	}
 	
 	query := r.db.Preload("Author").Preload("Category").
-		Where("status = ? AND YEAR(published_at) = ?", "published", year)
+		Scopes(models.Published()).
+		Where("YEAR(published_at) = ?", year)
 	
 	if month > 0 && month <= 12 {
 		query = query.Where("MONTH(published_at) = ?", month)
@@ -990,7 +1003,7 @@ func (r *postRepository) GetSitemap() ([]PostSitemap, error) {
 	
 	err := r.db.Table("posts").
 		Select("id, slug, title, updated_at, published_at").
-		Where("status = ? AND published_at <= ?", "published", time.Now()).
+		Scopes(models.Published()).
 		Order("published_at DESC").
 		Scan(&sitemap).Error
 	
@@ -13,15 +13,15 @@ import (
 type UserRepository interface {
 	// 基本CRUD操作
 	Create(user *models.User) error                              // 创建用户
-	GetByID(id uint) (*models.User, error)                      // 根据ID获取用户
-	GetByUsername(username string) (*models.User, error)        // 根据用户名获取用户
-	GetByEmail(email string) (*models.User, error)              // 根据邮箱获取用户
+	GetByID(id uint, opts ...QueryOption) (*models.User, error)                 // 根据ID获取用户
+	GetByUsername(username string, opts ...QueryOption) (*models.User, error)   // 根据用户名获取用户
+	GetByEmail(email string, opts ...QueryOption) (*models.User, error)         // 根据邮箱获取用户
 	Update(user *models.User) error                             // 更新用户信息
 	Delete(id uint) error                                       // 删除用户（软删除）
 	HardDelete(id uint) error                                   // 硬删除用户
 	
 	// 查询操作
-	List(offset, limit int) ([]models.User, error)             // 分页获取用户列表
+	List(offset, limit int, opts ...QueryOption) ([]models.User, error) // 分页获取用户列表
 	Search(keyword string, offset, limit int) ([]models.User, error) // 搜索用户
 	GetActiveUsers(offset, limit int) ([]models.User, error)   // 获取活跃用户
 	GetUsersByStatus(status string, offset, limit int) ([]models.User, error) // 根据状态获取用户
@@ -51,11 +51,11 @@ type UserRepository interface {
 	
 	// 批量操作
 	BatchCreate(users []models.User) error                      // 批量创建用户
-	BatchUpdateStatus(userIDs []uint, status string) error     // 批量更新状态
-	BatchDelete(userIDs []uint) error                          // 批量删除用户
+	BatchUpdateStatus(userIDs []uint, status string, dryRun bool) (int64, error) // 批量更新状态，dryRun时只统计
+	BatchDelete(userIDs []uint, dryRun bool) (int64, error) // 批量删除用户，dryRun时只统计
 	
 	// 高级查询
-	GetUserWithProfile(userID uint) (*models.User, error)      // 获取用户及其资料
+	GetUserWithProfile(userID uint, opts ...QueryOption) (*models.User, error) // 获取用户及其资料
 	GetUserWithStats(userID uint) (*UserWithStats, error)      // 获取用户及其统计信息
 	GetTopActiveUsers(limit int, days int) ([]models.User, error) // 获取最活跃用户
 	GetRecentUsers(limit int) ([]models.User, error)           // 获取最近注册用户
@@ -115,15 +115,15 @@ func (r *userRepository) Create(user *models.User) error {
 }
 
 // GetByID 根据ID获取用户
-// 参数: id - 用户ID
+// 参数: id - 用户ID, opts - 查询选项（默认不包括已删除的用户）
 // 返回: *models.User - 用户对象, error - 错误信息
-func (r *userRepository) GetByID(id uint) (*models.User, error) {
+func (r *userRepository) GetByID(id uint, opts ...QueryOption) (*models.User, error) {
 	if id == 0 {
 		return nil, errors.New("用户ID不能为空")
 	}
 	
 	user := &models.User{}
-	err := r.db.First(user, id).Error
+	err := scoped(r.db, opts...).First(user, id).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, errors.New("用户不存在")
@@ -135,15 +135,15 @@ func (r *userRepository) GetByID(id uint) (*models.User, error) {
 }
 
 // GetByUsername 根据用户名获取用户
-// 参数: username - 用户名
+// 参数: username - 用户名, opts - 查询选项（默认不包括已删除的用户）
 // 返回: *models.User - 用户对象, error - 错误信息
-func (r *userRepository) GetByUsername(username string) (*models.User, error) {
+func (r *userRepository) GetByUsername(username string, opts ...QueryOption) (*models.User, error) {
 	if username == "" {
 		return nil, errors.New("用户名不能为空")
 	}
 	
 	user := &models.User{}
-	err := r.db.Where("username = ?", username).First(user).Error
+	err := scoped(r.db, opts...).Where("username = ?", username).First(user).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, errors.New("用户不存在")
@@ -155,15 +155,15 @@ func (r *userRepository) GetByUsername(username string) (*models.User, error) {
 }
 
 // GetByEmail 根据邮箱获取用户
-// 参数: email - 邮箱地址
+// 参数: email - 邮箱地址, opts - 查询选项（默认不包括已删除的用户）
 // 返回: *models.User - 用户对象, error - 错误信息
-func (r *userRepository) GetByEmail(email string) (*models.User, error) {
+func (r *userRepository) GetByEmail(email string, opts ...QueryOption) (*models.User, error) {
 	if email == "" {
 		return nil, errors.New("邮箱不能为空")
 	}
 	
 	user := &models.User{}
-	err := r.db.Where("email = ?", email).First(user).Error
+	err := scoped(r.db, opts...).Where("email = ?", email).First(user).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, errors.New("用户不存在")
@@ -236,9 +236,9 @@ func (r *userRepository) HardDelete(id uint) error {
 // 查询操作实现
 
 // List 分页获取用户列表
-// 参数: offset - 偏移量, limit - 限制数量
+// 参数: offset - 偏移量, limit - 限制数量, opts - 查询选项（默认不包括已删除的用户，公开的用户列表不要传入选项）
 // 返回: []models.User - 用户列表, error - 错误信息
-func (r *userRepository) List(offset, limit int) ([]models.User, error) {
+func (r *userRepository) List(offset, limit int, opts ...QueryOption) ([]models.User, error) {
 	if offset < 0 {
 		offset = 0
 	}
@@ -247,7 +247,7 @@ func (r *userRepository) List(offset, limit int) ([]models.User, error) {
 	}
 	
 	var users []models.User
-	err := r.db.Offset(offset).Limit(limit).Order("created_at DESC").Find(&users).Error
+	err := scoped(r.db, opts...).Offset(offset).Limit(limit).Order("created_at DESC").Find(&users).Error
 	return users, err
 }
 
@@ -634,42 +634,49 @@ func (r *userRepository) BatchCreate(users []models.User) error {
 }
 
 // BatchUpdateStatus 批量更新状态
-// 参数: userIDs - 用户ID列表, status - 新状态
-// 返回: error - 错误信息
-func (r *userRepository) BatchUpdateStatus(userIDs []uint, status string) error {
+// 参数: userIDs - 用户ID列表, status - 新状态, dryRun - 为true时只统计将被更新的行数，不修改数据
+// 返回: int64 - 受影响（预演时为将受影响）的行数, error - 错误信息
+func (r *userRepository) BatchUpdateStatus(userIDs []uint, status string, dryRun bool) (int64, error) {
 	if len(userIDs) == 0 {
-		return errors.New("用户ID列表不能为空")
+		return 0, errors.New("用户ID列表不能为空")
 	}
 	if status == "" {
-		return errors.New("状态不能为空")
+		return 0, errors.New("状态不能为空")
 	}
 	
-	return r.db.Model(&models.User{}).Where("id IN (?)", userIDs).Update("status", status).Error
+	// 已是目标状态的行不计入，预演统计的行数与实际更新的行数一致
+	query := r.db.Model(&models.User{}).Where("id IN (?) AND status <> ?", userIDs, status)
+	return applyOrCount(query, dryRun, func(tx *gorm.DB) *gorm.DB {
+		return tx.Update("status", status)
+	})
 }
 
-// BatchDelete 批量删除用户
-// 参数: userIDs - 用户ID列表
-// 返回: error - 错误信息
-func (r *userRepository) BatchDelete(userIDs []uint) error {
+// BatchDelete 批量删除用户（软删除）
+// 参数: userIDs - 用户ID列表, dryRun - 为true时只统计将被删除的行数，不修改数据
+// 返回: int64 - 受影响（预演时为将受影响）的行数, error - 错误信息
+func (r *userRepository) BatchDelete(userIDs []uint, dryRun bool) (int64, error) {
 	if len(userIDs) == 0 {
-		return errors.New("用户ID列表不能为空")
+		return 0, errors.New("用户ID列表不能为空")
 	}
 	
-	return r.db.Delete(&models.User{}, userIDs).Error
+	query := r.db.Model(&models.User{}).Where("id IN (?)", userIDs)
+	return applyOrCount(query, dryRun, func(tx *gorm.DB) *gorm.DB {
+		return tx.Delete(&models.User{})
+	})
 }
 
 // 高级查询实现
 
 // GetUserWithProfile 获取用户及其资料
-// 参数: userID - 用户ID
+// 参数: userID - 用户ID, opts - 查询选项，同时决定用户和资料的可见性
 // 返回: *models.User - 用户对象（包含资料）, error - 错误信息
-func (r *userRepository) GetUserWithProfile(userID uint) (*models.User, error) {
+func (r *userRepository) GetUserWithProfile(userID uint, opts ...QueryOption) (*models.User, error) {
 	if userID == 0 {
 		return nil, errors.New("用户ID不能为空")
 	}
 	
 	user := &models.User{}
-	err := r.db.Preload("Profile").First(user, userID).Error
+	err := preload(scoped(r.db, opts...), "Profile", opts...).First(user, userID).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, errors.New("用户不存在")
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 软删除可见性
+// GORM 默认给带 DeletedAt 字段的模型加上 deleted_at IS NULL 条件。需要看到已删除记录的读操作（回收站、数据导出等）
+// 通过 WithDeleted / OnlyDeleted 选项声明，由 scoped 统一决定是否使用 Unscoped；
+// 读操作不要在本文件之外直接调用 Unscoped，硬删除和彻底清除除外
+
+// Visibility 软删除记录的可见性
+type Visibility int
+
+const (
+	VisibleLive        Visibility = iota // 只包括未删除的记录（默认）
+	VisibleWithDeleted                   // 包括已删除的记录
+	VisibleOnlyDeleted                   // 只包括已删除的记录
+)
+
+// QueryOption 读操作的查询选项
+type QueryOption func(*queryOptions)
+
+// queryOptions 读操作的查询选项集合
+type queryOptions struct {
+	visibility Visibility
+}
+
+// WithDeleted 查询结果包括已软删除的记录
+func WithDeleted() QueryOption {
+	return func(o *queryOptions) {
+		o.visibility = VisibleWithDeleted
+	}
+}
+
+// OnlyDeleted 查询结果只包括已软删除的记录
+func OnlyDeleted() QueryOption {
+	return func(o *queryOptions) {
+		o.visibility = VisibleOnlyDeleted
+	}
+}
+
+// newQueryOptions 合并查询选项，后面的选项覆盖前面的
+func newQueryOptions(opts []QueryOption) queryOptions {
+	var o queryOptions
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&o)
+		}
+	}
+	return o
+}
+
+// scoped 按选项的可见性返回主表查询
+// 参数: db - 数据库连接, opts - 查询选项
+// 返回: *gorm.DB - 已应用可见性的查询
+func scoped(db *gorm.DB, opts ...QueryOption) *gorm.DB {
+	switch newQueryOptions(opts).visibility {
+	case VisibleWithDeleted:
+		return db.Unscoped()
+	case VisibleOnlyDeleted:
+		return db.Unscoped().Where(clause.Neq{
+			Column: clause.Column{Table: clause.CurrentTable, Name: "deleted_at"},
+			Value:  nil,
+		})
+	default:
+		return db
+	}
+}
+
+// preload 按选项的可见性预加载关联
+// 不依赖 GORM 是否把主查询的 Unscoped 传递给预加载，显式指定关联的可见性：
+// 默认只加载未删除的关联；WithDeleted 和 OnlyDeleted 时加载包括已删除在内的关联，
+// 已删除的文章或评论仍能显示其作者，而不会因关联被删除而显示为空
+// 参数: db - 查询, name - 关联名称, opts - 查询选项
+// 返回: *gorm.DB - 添加了预加载的查询
+func preload(db *gorm.DB, name string, opts ...QueryOption) *gorm.DB {
+	if newQueryOptions(opts).visibility == VisibleLive {
+		return db.Preload(name)
+	}
+	return db.Preload(name, func(tx *gorm.DB) *gorm.DB {
+		return tx.Unscoped()
+	})
+}
@@ -0,0 +1,110 @@
+package repository_test
+
+import (
+	"reflect"
+	"testing"
+
+	"gorm.io/gorm"
+
+	"blog-system-refactored/internal/models"
+	"blog-system-refactored/internal/repository"
+	"blog-system-refactored/internal/testutil"
+)
+
+func softDelete(t *testing.T, db *gorm.DB, model interface{}, id uint) {
+	t.Helper()
+	if err := db.Delete(model, id).Error; err != nil {
+		t.Fatalf("软删除 %d 失败: %v", id, err)
+	}
+}
+
+func postIDs(posts []models.Post) []uint {
+	ids := make([]uint, len(posts))
+	for i, p := range posts {
+		ids[i] = p.ID
+	}
+	return ids
+}
+
+// TestGetByIDVisibility 默认查不到已删除的记录；WithDeleted 都能查到；OnlyDeleted 只能查到已删除的
+func TestGetByIDVisibility(t *testing.T) {
+	db := testutil.NewDB(t)
+	users := repository.NewUserRepository(db)
+	live := testutil.CreateUser(t, db)
+	deleted := testutil.CreateUser(t, db)
+	softDelete(t, db, &models.User{}, deleted.ID)
+
+	cases := []struct {
+		name          string
+		opts          []repository.QueryOption
+		live, deleted bool
+	}{
+		{"默认", nil, true, false},
+		{"WithDeleted", []repository.QueryOption{repository.WithDeleted()}, true, true},
+		{"OnlyDeleted", []repository.QueryOption{repository.OnlyDeleted()}, false, true},
+		{"后面的选项覆盖前面的", []repository.QueryOption{repository.OnlyDeleted(), repository.WithDeleted()}, true, true},
+	}
+	for _, tc := range cases {
+		_, err := users.GetByID(live.ID, tc.opts...)
+		if (err == nil) != tc.live {
+			t.Errorf("%s: 查询未删除用户返回 %v", tc.name, err)
+		}
+		_, err = users.GetByUsername(deleted.Username, tc.opts...)
+		if (err == nil) != tc.deleted {
+			t.Errorf("%s: 查询已删除用户返回 %v", tc.name, err)
+		}
+	}
+}
+
+// TestListVisibilityPreloadsDeletedAuthor WithDeleted、OnlyDeleted 列表中的文章仍带有已删除的作者；
+// 默认列表不包括已删除的文章，也不加载已删除的作者
+func TestListVisibilityPreloadsDeletedAuthor(t *testing.T) {
+	db := testutil.NewDB(t)
+	posts := repository.NewPostRepository(db)
+	author := testutil.CreateUser(t, db)
+	gone := testutil.CreateUser(t, db)
+	p1 := testutil.CreatePost(t, db, author.ID, models.PostStatusPublished)
+	p2 := testutil.CreatePost(t, db, gone.ID, models.PostStatusPublished)
+	p3 := testutil.CreatePost(t, db, gone.ID, models.PostStatusPublished)
+	softDelete(t, db, &models.Post{}, p3.ID)
+	softDelete(t, db, &models.User{}, gone.ID)
+
+	list, err := posts.List(0, 10)
+	if err != nil {
+		t.Fatalf("查询文章失败: %v", err)
+	}
+	if got := postIDs(list); !reflect.DeepEqual(got, []uint{p2.ID, p1.ID}) {
+		t.Errorf("默认列表为 %v，期望 [%d %d]", got, p2.ID, p1.ID)
+	}
+	for _, p := range list {
+		if p.ID == p2.ID && p.Author != nil {
+			t.Errorf("默认列表加载了已删除的作者 %d", p.Author.ID)
+		}
+	}
+
+	all, err := posts.List(0, 10, repository.WithDeleted())
+	if err != nil || len(all) != 3 {
+		t.Fatalf("WithDeleted 列表有 %d 篇文章（%v），期望 3", len(all), err)
+	}
+	for _, p := range all {
+		if p.Author == nil {
+			t.Errorf("文章 %d 没有加载作者", p.ID)
+		}
+	}
+
+	trash, err := posts.List(0, 10, repository.OnlyDeleted())
+	if err != nil || len(trash) != 1 || trash[0].ID != p3.ID {
+		t.Fatalf("OnlyDeleted 列表为 %v（%v），期望 [%d]", postIDs(trash), err, p3.ID)
+	}
+	if trash[0].Author == nil || trash[0].Author.ID != gone.ID {
+		t.Errorf("已删除文章的作者为 %v，期望已删除的用户 %d", trash[0].Author, gone.ID)
+	}
+
+	details, err := posts.GetPostWithDetails(p3.ID, repository.OnlyDeleted())
+	if err != nil || details.Author == nil || details.Author.ID != gone.ID {
+		t.Errorf("已删除文章详情为 %+v（%v），期望带有作者 %d", details, err, gone.ID)
+	}
+	if _, err := posts.GetPostWithDetails(p3.ID); err == nil {
+		t.Error("默认可以查到已删除文章的详情")
+	}
+}
@@ -4,10 +4,11 @@ import (
 	"github.com/gin-gonic/gin"
 	"blog-system-refactored/internal/handlers"
 	"blog-system-refactored/internal/middleware"
+	"blog-system-refactored/internal/services"
 )
 
 // SetupRoutes 设置所有路由
-// 参数: r - Gin路由器, userHandler - 用户处理器, postHandler - 文章处理器, commentHandler - 评论处理器, analyticsHandler - 分析处理器
+// 参数: r - Gin路由器, userHandler - 用户处理器, postHandler - 文章处理器, commentHandler - 评论处理器, analyticsHandler - 分析处理器, healthHandler - 健康检查处理器, settingService - 系统设置服务（维护模式）
 // 返回: 无
 func SetupRoutes(
 	r *gin.Engine,
@@ -15,12 +16,15 @@ func SetupRoutes(
 	postHandler *handlers.PostHandler,
 	commentHandler *handlers.CommentHandler,
 	analyticsHandler *handlers.AnalyticsHandler,
+	healthHandler *handlers.HealthHandler,
+	settingService services.SettingService,
 ) {
 	// 设置全局中间件
 	r.Use(middleware.CORS())           // 跨域中间件
 	r.Use(middleware.Logger())         // 日志中间件
 	r.Use(middleware.Recovery())       // 恢复中间件
 	r.Use(middleware.RateLimit())      // 限流中间件
+	r.Use(middleware.MaintenanceMode(settingService)) // 维护模式中间件
 
 	// API版本1路由组
 	v1 := r.Group("/api/v1")
@@ -45,6 +49,7 @@ func SetupRoutes(
 			"message": "Blog system is running",
 		})
 	})
+	r.GET("/health/detailed", healthHandler.Detailed) // 各子系统的详细健康状态
 
 	// API文档路由
 	r.GET("/docs", func(c *gin.Context) {
@@ -189,6 +194,11 @@ func setupCommentRoutes(rg *gin.RouterGroup, handler *handlers.CommentHandler) {
 				admin.PUT("/:id/approve", handler.ApproveComment)   // 审核通过
 				admin.PUT("/:id/reject", handler.RejectComment)     // 审核拒绝
 				admin.PUT("/:id/spam", handler.MarkAsSpam)          // 标记为垃圾评论
+
+				// 批量管理，dry_run为true时只返回将受影响的评论数
+				admin.PUT("/batch/status", handler.BatchUpdateCommentStatus) // 批量更新状态
+				admin.POST("/batch/delete", handler.BatchDeleteComments)     // 批量删除
+				admin.DELETE("/purge", handler.PurgeDeletedComments)         // 清除已删除评论
 				// TODO: 实现获取待审核和被举报评论功能
 				// admin.GET("/pending", handler.GetPendingComments)   // 获取待审核评论
 				// admin.GET("/reported", handler.GetReportedComments) // 获取被举报评论
@@ -1,10 +1,12 @@
 package services
 
 import (
+	"context"
 	"errors"
 	"time"
 
 	"blog-system-refactored/internal/models"
+	"golang.org/x/sync/errgroup"
 	"gorm.io/gorm"
 )
 
@@ -12,8 +14,8 @@ import (
 // 定义数据统计和分析相关的业务操作
 type AnalyticsService interface {
 	// 仪表板统计
-	GetDashboardStats() (*models.DashboardStats, error)           // 获取仪表板统计数据
-	GetDashboardStatsForPeriod(days int) (*models.DashboardStats, error) // 获取指定时间段的仪表板统计
+	GetDashboardStats(ctx context.Context) (*models.DashboardStats, error)           // 获取仪表板统计数据
+	GetDashboardStatsForPeriod(ctx context.Context, days int) (*models.DashboardStats, error) // 获取指定时间段的仪表板统计
 	
 	// 内容统计
 	GetContentStats() (*models.ContentStats, error)              // 获取内容统计
@@ -116,63 +118,96 @@ type RealTimeStats struct {
 
 // 仪表板统计实现
 
+// dashboardConcurrency 仪表板统计同时执行的查询数上限
+const dashboardConcurrency = 4
+
 // GetDashboardStats 获取仪表板统计数据
+// 参数: ctx - 请求上下文
 // 返回: *models.DashboardStats - 仪表板统计数据, error - 错误信息
-func (s *analyticsService) GetDashboardStats() (*models.DashboardStats, error) {
-	return s.GetDashboardStatsForPeriod(30) // 默认30天
+func (s *analyticsService) GetDashboardStats(ctx context.Context) (*models.DashboardStats, error) {
+	return s.GetDashboardStatsForPeriod(ctx, 30) // 默认30天
 }
 
 // GetDashboardStatsForPeriod 获取指定时间段的仪表板统计
-// 参数: days - 统计天数
+// 各项计数互不依赖，最多dashboardConcurrency个查询并发执行，每个查询只写自己的变量，无需加锁；
+// 任一查询失败时取消其余查询并返回第一个错误。增长率依赖上期和当期两个计数，等全部查询完成后再计算
+// 参数: ctx - 请求上下文, days - 统计天数
 // 返回: *models.DashboardStats - 仪表板统计数据, error - 错误信息
-func (s *analyticsService) GetDashboardStatsForPeriod(days int) (*models.DashboardStats, error) {
+func (s *analyticsService) GetDashboardStatsForPeriod(ctx context.Context, days int) (*models.DashboardStats, error) {
 	if days <= 0 {
 		days = 30
 	}
 	
 	stats := &models.DashboardStats{}
-	startDate := time.Now().AddDate(0, 0, -days)
+	now := time.Now()
+	startDate := now.AddDate(0, 0, -days)
+	prevStartDate := startDate.AddDate(0, 0, -days)
+	
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(dashboardConcurrency)
+	query := func(fn func(db *gorm.DB) *gorm.DB) {
+		g.Go(func() error {
+			return fn(s.db.WithContext(gctx)).Error
+		})
+	}
 	
 	// 总用户数
-	var totalUsers int64
-	s.db.Model(&models.User{}).Count(&totalUsers)
-	stats.TotalUsers = totalUsers
+	query(func(db *gorm.DB) *gorm.DB {
+		return db.Model(&models.User{}).Count(&stats.TotalUsers)
+	})
 	
 	// 新用户数（指定时间段内）
-	var newUsers int64
-	s.db.Model(&models.User{}).Where("created_at >= ?", startDate).Count(&newUsers)
-	stats.TodayUsers = newUsers
+	query(func(db *gorm.DB) *gorm.DB {
+		return db.Model(&models.User{}).Where("created_at >= ?", startDate).Count(&stats.TodayUsers)
+	})
 	
 	// 总文章数
-	var totalPosts int64
-	s.db.Model(&models.Post{}).Count(&totalPosts)
-	stats.TotalPosts = totalPosts
+	query(func(db *gorm.DB) *gorm.DB {
+		return db.Model(&models.Post{}).Count(&stats.TotalPosts)
+	})
 	
 	// 新文章数（指定时间段内）
-	var newPosts int64
-	s.db.Model(&models.Post{}).Where("created_at >= ?", startDate).Count(&newPosts)
-	stats.TodayPosts = newPosts
+	query(func(db *gorm.DB) *gorm.DB {
+		return db.Model(&models.Post{}).Where("created_at >= ?", startDate).Count(&stats.TodayPosts)
+	})
 	
 	// 总评论数
-	var totalComments int64
-	s.db.Model(&models.Comment{}).Count(&totalComments)
-	stats.TotalComments = totalComments
+	query(func(db *gorm.DB) *gorm.DB {
+		return db.Model(&models.Comment{}).Count(&stats.TotalComments)
+	})
 	
 	// 新评论数（指定时间段内）
-	var newComments int64
-	s.db.Model(&models.Comment{}).Where("created_at >= ?", startDate).Count(&newComments)
-	stats.TodayComments = newComments
+	query(func(db *gorm.DB) *gorm.DB {
+		return db.Model(&models.Comment{}).Where("created_at >= ?", startDate).Count(&stats.TodayComments)
+	})
 	
 	// 总浏览量
-	var totalViews int64
-	s.db.Model(&models.Post{}).Select("COALESCE(SUM(view_count), 0)").Scan(&totalViews)
-	stats.TotalViews = totalViews
+	query(func(db *gorm.DB) *gorm.DB {
+		return db.Model(&models.Post{}).Select("COALESCE(SUM(view_count), 0)").Scan(&stats.TotalViews)
+	})
+	
+	// 增长率所需的上期、当期数量
+	tables := []string{"users", "posts", "comments"}
+	prevCounts := make([]int64, len(tables))
+	currCounts := make([]int64, len(tables))
+	for i, table := range tables {
+		i, table := i, table
+		query(func(db *gorm.DB) *gorm.DB {
+			return db.Table(table).Where("created_at BETWEEN ? AND ?", prevStartDate, startDate).Count(&prevCounts[i])
+		})
+		query(func(db *gorm.DB) *gorm.DB {
+			return db.Table(table).Where("created_at BETWEEN ? AND ?", startDate, now).Count(&currCounts[i])
+		})
+	}
+	
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
 	
 	// 计算增长率
-	prevStartDate := startDate.AddDate(0, 0, -days)
-	stats.UserGrowthRate = s.calculateGrowthRate("users", prevStartDate, startDate, startDate, time.Now())
-	stats.PostGrowthRate = s.calculateGrowthRate("posts", prevStartDate, startDate, startDate, time.Now())
-	stats.CommentGrowthRate = s.calculateGrowthRate("comments", prevStartDate, startDate, startDate, time.Now())
+	stats.UserGrowthRate = calculateGrowthRate(prevCounts[0], currCounts[0])
+	stats.PostGrowthRate = calculateGrowthRate(prevCounts[1], currCounts[1])
+	stats.CommentGrowthRate = calculateGrowthRate(prevCounts[2], currCounts[2])
 	
 	return stats, nil
 }
@@ -186,7 +221,7 @@ func (s *analyticsService) GetContentStats() (*models.ContentStats, error) {
 	
 	// 已发布文章数
 	var publishedPosts int64
-	s.db.Model(&models.Post{}).Where("status = ?", models.PostStatusPublished).Count(&publishedPosts)
+	s.db.Model(&models.Post{}).Scopes(models.Published()).Count(&publishedPosts)
 	stats.PublishedPosts = publishedPosts
 	
 	// 草稿文章数
@@ -206,12 +241,12 @@ func (s *analyticsService) GetContentStats() (*models.ContentStats, error) {
 	
 	// 平均文章长度
 	var avgWordCount float64
-	s.db.Model(&models.Post{}).Where("status = ?", "published").Select("AVG(word_count)").Scan(&avgWordCount)
+	s.db.Model(&models.Post{}).Scopes(models.Published()).Select("AVG(word_count)").Scan(&avgWordCount)
 	stats.AvgPostLength = avgWordCount
 	
 	// 平均阅读时间
 	var avgReadTime float64
-	s.db.Model(&models.Post{}).Where("status = ?", "published").Select("AVG(read_time)").Scan(&avgReadTime)
+	s.db.Model(&models.Post{}).Scopes(models.Published()).Select("AVG(read_time)").Scan(&avgReadTime)
 	stats.AvgReadTime = avgReadTime
 	
 	return stats, nil
@@ -253,7 +288,8 @@ func (s *analyticsService) GetPopularPosts(limit int, days int) ([]models.Popula
 			WHERE created_at >= ?
 			GROUP BY post_id
 		) comment_counts ON posts.id = comment_counts.post_id`, startDate).
-		Where("posts.status = ? AND posts.published_at >= ?", "published", startDate).
+		Scopes(models.Published()).
+		Where("posts.published_at >= ?", startDate).
 		Order("popularity_score DESC").
 		Limit(limit).
 		Scan(&posts).Error
@@ -610,7 +646,7 @@ func (s *analyticsService) GetTopPerformingContent(limit int, metric string) ([]
 			FROM comments 
 			GROUP BY post_id
 		) comment_counts ON posts.id = comment_counts.post_id`).
-		Where("posts.status = ?", "published").
+		Scopes(models.Published()).
 		Order(orderBy).
 		Limit(limit).
 		Scan(&content).Error
@@ -736,17 +772,9 @@ func (s *analyticsService) UpdateRealTimeStats(event string, data map[string]int
 // 辅助方法
 
 // calculateGrowthRate 计算增长率
-// 参数: table - 表名, prevStart - 上期开始时间, prevEnd - 上期结束时间, currStart - 当期开始时间, currEnd - 当期结束时间
+// 参数: prevCount - 上期数量, currCount - 当期数量
 // 返回: float64 - 增长率
-func (s *analyticsService) calculateGrowthRate(table string, prevStart, prevEnd, currStart, currEnd time.Time) float64 {
-	var prevCount, currCount int64
-	
-	// 获取上期数量
-	s.db.Table(table).Where("created_at BETWEEN ? AND ?", prevStart, prevEnd).Count(&prevCount)
-	
-	// 获取当期数量
-	s.db.Table(table).Where("created_at BETWEEN ? AND ?", currStart, currEnd).Count(&currCount)
-	
+func calculateGrowthRate(prevCount, currCount int64) float64 {
 	if prevCount == 0 {
 		if currCount > 0 {
 			return 100.0 // 从0增长到有数据，视为100%增长
@@ -1,12 +1,14 @@
 package services
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"strings"
 	"time"
 
 	"blog-system-refactored/internal/models"
+	"blog-system-refactored/internal/repository"
 	"gorm.io/gorm"
 )
 
@@ -26,6 +28,12 @@ type CommentService interface {
 	MarkAsSpam(id uint) error                                      // 标记为垃圾评论
 	UnmarkSpam(id uint) error                                      // 取消垃圾标记
 	
+	// 批量管理（dryRun为true时只返回将受影响的行数，不修改数据）
+	BatchUpdateStatus(commentIDs []uint, status string, dryRun bool) (int64, error) // 批量更新状态
+	BatchDeleteComments(commentIDs []uint, dryRun bool) (int64, error)              // 批量删除评论及其回复
+	PurgeDeletedOlderThan(before time.Time, dryRun bool) (int64, error)             // 彻底清除早于指定时间软删除的评论
+	BulkSoftDelete(ids []uint, reason string) (int64, error)                        // 批量软删除评论并记录原因和审计日志
+	
 	// 评论查询
 	GetCommentsByPost(postID uint, offset, limit int) ([]models.Comment, int64, error) // 获取文章评论
 	GetCommentsByUser(userID uint, offset, limit int) ([]models.Comment, int64, error) // 获取用户评论
@@ -48,7 +56,8 @@ type CommentService interface {
 
 // commentService 评论服务实现
 type commentService struct {
-	db *gorm.DB
+	db       *gorm.DB
+	comments repository.CommentRepository // 评论数据访问，软删除记录的读取和彻底清除都经过这里
 }
 
 // NewCommentService 创建评论服务实例
@@ -56,7 +65,8 @@ type commentService struct {
 // 返回: CommentService - 评论服务接口实例
 func NewCommentService(db *gorm.DB) CommentService {
 	return &commentService{
-		db: db,
+		db:       db,
+		comments: repository.NewCommentRepository(db),
 	}
 }
 
@@ -356,6 +366,123 @@ func (s *commentService) UnmarkSpam(id uint) error {
 	return s.db.Model(&models.Comment{}).Where("id = ?", id).Updates(updates).Error
 }
 
+// 批量管理实现
+
+// BatchUpdateStatus 批量更新评论状态
+// 参数: commentIDs - 评论ID列表, status - 新状态名称（pending、approved等）, dryRun - 为true时只统计将被更新的评论数，不修改数据
+// 返回: int64 - 更新（预演时为将被更新）的评论数, error - 错误信息
+func (s *commentService) BatchUpdateStatus(commentIDs []uint, status string, dryRun bool) (int64, error) {
+	if len(commentIDs) == 0 {
+		return 0, errors.New("评论ID列表不能为空")
+	}
+	if status == "" {
+		return 0, errors.New("状态不能为空")
+	}
+	newStatus, ok := models.ParseCommentStatus(status)
+	if !ok {
+		return 0, fmt.Errorf("无效的评论状态: %s", status)
+	}
+	
+	// 已是目标状态的评论不计入，预演统计的数量与实际更新的数量一致
+	query := s.db.Model(&models.Comment{}).Where("id IN ? AND status <> ?", commentIDs, newStatus)
+	return applyOrCount(query, dryRun, func(tx *gorm.DB) *gorm.DB {
+		return tx.Update("status", newStatus)
+	})
+}
+
+// BatchDeleteComments 批量删除评论（软删除），与 DeleteComment 一样连同回复一起删除
+// 参数: commentIDs - 评论ID列表, dryRun - 为true时只统计将被删除的评论数（含回复），不修改数据
+// 返回: int64 - 删除（预演时为将被删除）的评论数, error - 错误信息
+func (s *commentService) BatchDeleteComments(commentIDs []uint, dryRun bool) (int64, error) {
+	if len(commentIDs) == 0 {
+		return 0, errors.New("评论ID列表不能为空")
+	}
+	
+	query := s.db.Model(&models.Comment{}).Where("id IN ? OR parent_id IN ?", commentIDs, commentIDs)
+	return applyOrCount(query, dryRun, func(tx *gorm.DB) *gorm.DB {
+		return tx.Delete(&models.Comment{})
+	})
+}
+
+// PurgeDeletedOlderThan 彻底清除软删除时间早于before的评论及其点赞记录，清除后无法恢复
+// 参数: before - 软删除时间上限（不含）, dryRun - 为true时只统计将被清除的评论数，不修改数据
+// 返回: int64 - 清除（预演时为将被清除）的评论数, error - 错误信息
+func (s *commentService) PurgeDeletedOlderThan(before time.Time, dryRun bool) (int64, error) {
+	return s.comments.PurgeDeletedOlderThan(before, dryRun)
+}
+
+// BulkSoftDelete 批量软删除评论（如清理垃圾评论），记录删除原因
+// 在同一事务中软删除评论并写入 deleted_reason、按文章扣减评论数（不低于0）、写入一条审计日志；
+// 已删除或不存在的ID忽略，不计入受影响数量，没有评论被删除时不写日志。回复不会随之删除，需要时一并传入ID
+// 参数: ids - 评论ID列表, reason - 删除原因
+// 返回: int64 - 删除的评论数, error - 错误信息
+func (s *commentService) BulkSoftDelete(ids []uint, reason string) (int64, error) {
+	if len(ids) == 0 {
+		return 0, errors.New("评论ID列表不能为空")
+	}
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return 0, errors.New("删除原因不能为空")
+	}
+	if len(reason) > 255 {
+		return 0, errors.New("删除原因不能超过255个字符")
+	}
+	
+	var affected int64
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		// 先查出未删除的评论，审计日志只记录实际删除的ID
+		var targets []models.Comment
+		if err := tx.Select("id", "post_id").Where("id IN ?", ids).Order("id").Find(&targets).Error; err != nil {
+			return err
+		}
+		if len(targets) == 0 {
+			return nil
+		}
+		
+		deletedIDs := make([]uint, 0, len(targets))
+		perPost := make(map[uint]int)
+		for _, c := range targets {
+			deletedIDs = append(deletedIDs, c.ID)
+			perPost[c.PostID]++
+		}
+		
+		result := tx.Model(&models.Comment{}).Where("id IN ?", deletedIDs).Updates(map[string]interface{}{
+			"deleted_at":     time.Now(),
+			"deleted_reason": reason,
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+		affected = result.RowsAffected
+		
+		// 扣减文章评论数，计数已经偏小时不减成负数
+		for postID, n := range perPost {
+			err := tx.Model(&models.Post{}).Where("id = ?", postID).
+				UpdateColumn("comment_count", gorm.Expr("CASE WHEN comment_count > ? THEN comment_count - ? ELSE 0 END", n, n)).Error
+			if err != nil {
+				return err
+			}
+		}
+		
+		targetIDs, err := json.Marshal(deletedIDs)
+		if err != nil {
+			return err
+		}
+		return tx.Create(&models.AuditLog{
+			Action:     models.AuditActionCommentBulkSoftDelete,
+			TargetType: "comment",
+			TargetIDs:  string(targetIDs),
+			Affected:   affected,
+			Reason:     reason,
+		}).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+	
+	return affected, nil
+}
+
 // 评论查询实现
 
 // GetCommentsByPost 获取文章评论
@@ -811,6 +938,20 @@ func (s *commentService) buildCommentTree(comments []models.Comment, parentID *u
 	return nodes
 }
 
+// applyOrCount 执行批量修改，或只统计将受影响的行数（预演）
+// 参数: query - 带有筛选条件的查询, dryRun - 为true时只用同一查询条件COUNT，不修改数据, apply - 在query上执行修改
+// 返回: int64 - 受影响（预演时为将受影响）的行数, error - 错误信息
+func applyOrCount(query *gorm.DB, dryRun bool, apply func(tx *gorm.DB) *gorm.DB) (int64, error) {
+	if dryRun {
+		var count int64
+		err := query.Count(&count).Error
+		return count, err
+	}
+	
+	result := apply(query)
+	return result.RowsAffected, result.Error
+}
+
 // applyCommentFilters 应用评论筛选条件
 // 参数: query - GORM查询对象, filters - 筛选条件
 // 返回: *gorm.DB - 应用筛选后的查询对象
@@ -0,0 +1,220 @@
+package services_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+
+	"blog-system-refactored/internal/models"
+	"blog-system-refactored/internal/services"
+	"blog-system-refactored/internal/testutil"
+)
+
+// commentStatuses 按ID顺序返回评论状态（含已删除的评论）
+func commentStatuses(t *testing.T, db *gorm.DB, ids ...uint) []models.CommentStatus {
+	t.Helper()
+	var comments []models.Comment
+	if err := db.Unscoped().Where("id IN ?", ids).Order("id").Find(&comments).Error; err != nil {
+		t.Fatalf("查询评论失败: %v", err)
+	}
+	statuses := make([]models.CommentStatus, len(comments))
+	for i, c := range comments {
+		statuses[i] = c.Status
+	}
+	return statuses
+}
+
+// countComments 统计评论数，unscoped为true时包括已软删除的评论
+func countComments(t *testing.T, db *gorm.DB, unscoped bool) int64 {
+	t.Helper()
+	tx := db
+	if unscoped {
+		tx = db.Unscoped()
+	}
+	var n int64
+	if err := tx.Model(&models.Comment{}).Count(&n).Error; err != nil {
+		t.Fatalf("统计评论失败: %v", err)
+	}
+	return n
+}
+
+// TestBatchUpdateCommentStatusDryRun 预演只返回将被更新的评论数，不修改数据；已是目标状态的评论不计入
+func TestBatchUpdateCommentStatusDryRun(t *testing.T) {
+	db := testutil.NewDB(t)
+	svc := services.NewCommentService(db)
+	user := testutil.CreateUser(t, db)
+	post := testutil.CreatePost(t, db, user.ID, models.PostStatusPublished)
+	c1 := testutil.CreateComment(t, db, post.ID, user.ID, 0, models.CommentStatusPending)
+	c2 := testutil.CreateComment(t, db, post.ID, user.ID, 0, models.CommentStatusApproved)
+	c3 := testutil.CreateComment(t, db, post.ID, user.ID, c1.ID, models.CommentStatusPending)
+	ids := []uint{c1.ID, c2.ID, c3.ID}
+
+	affected, err := svc.BatchUpdateStatus(ids, "approved", true)
+	if err != nil || affected != 2 {
+		t.Fatalf("预演返回 %d（%v），期望 2", affected, err)
+	}
+	pending, approved := models.CommentStatusPending, models.CommentStatusApproved
+	if got := commentStatuses(t, db, ids...); got[0] != pending || got[1] != approved || got[2] != pending {
+		t.Errorf("预演后评论状态为 %v，期望不变", got)
+	}
+
+	affected, err = svc.BatchUpdateStatus(ids, "approved", false)
+	if err != nil || affected != 2 {
+		t.Fatalf("执行返回 %d（%v），期望与预演相同的 2", affected, err)
+	}
+	for _, s := range commentStatuses(t, db, ids...) {
+		if s != approved {
+			t.Errorf("执行后评论状态为 %v，期望 approved", s)
+		}
+	}
+	if affected, err := svc.BatchUpdateStatus(ids, "approved", true); err != nil || affected != 0 {
+		t.Errorf("再次预演返回 %d（%v），期望 0", affected, err)
+	}
+
+	if _, err := svc.BatchUpdateStatus(ids, "published", false); err == nil {
+		t.Error("无效的状态名称更新成功")
+	}
+}
+
+// TestBatchDeleteCommentsDryRun 预演统计评论及其回复，不删除；执行后删除相同数量的评论
+func TestBatchDeleteCommentsDryRun(t *testing.T) {
+	db := testutil.NewDB(t)
+	svc := services.NewCommentService(db)
+	user := testutil.CreateUser(t, db)
+	post := testutil.CreatePost(t, db, user.ID, models.PostStatusPublished)
+	root := testutil.CreateComment(t, db, post.ID, user.ID, 0, models.CommentStatusApproved)
+	testutil.CreateComment(t, db, post.ID, user.ID, root.ID, models.CommentStatusApproved)
+	testutil.CreateComment(t, db, post.ID, user.ID, 0, models.CommentStatusApproved)
+
+	affected, err := svc.BatchDeleteComments([]uint{root.ID}, true)
+	if err != nil || affected != 2 {
+		t.Fatalf("预演返回 %d（%v），期望评论和回复共 2", affected, err)
+	}
+	if n := countComments(t, db, false); n != 3 {
+		t.Errorf("预演后剩余 %d 条评论，期望 3", n)
+	}
+
+	affected, err = svc.BatchDeleteComments([]uint{root.ID}, false)
+	if err != nil || affected != 2 {
+		t.Fatalf("执行返回 %d（%v），期望 2", affected, err)
+	}
+	if n := countComments(t, db, false); n != 1 {
+		t.Errorf("执行后剩余 %d 条评论，期望 1", n)
+	}
+}
+
+// TestPurgeDeletedCommentsDryRun 预演只统计软删除早于指定时间的评论；执行后连同点赞记录彻底删除
+func TestPurgeDeletedCommentsDryRun(t *testing.T) {
+	db := testutil.NewDB(t)
+	svc := services.NewCommentService(db)
+	user := testutil.CreateUser(t, db)
+	post := testutil.CreatePost(t, db, user.ID, models.PostStatusPublished)
+	old := testutil.CreateComment(t, db, post.ID, user.ID, 0, models.CommentStatusApproved)
+	recent := testutil.CreateComment(t, db, post.ID, user.ID, 0, models.CommentStatusApproved)
+	testutil.CreateComment(t, db, post.ID, user.ID, 0, models.CommentStatusApproved)
+
+	like := models.Like{UserID: user.ID, TargetID: old.ID, TargetType: "comment", CommentID: &old.ID}
+	if err := db.Create(&like).Error; err != nil {
+		t.Fatalf("创建点赞失败: %v", err)
+	}
+	if err := db.Delete(&models.Comment{}, []uint{old.ID, recent.ID}).Error; err != nil {
+		t.Fatalf("删除评论失败: %v", err)
+	}
+	tenDaysAgo := time.Now().AddDate(0, 0, -10)
+	if err := db.Unscoped().Model(&models.Comment{}).Where("id = ?", old.ID).Update("deleted_at", tenDaysAgo).Error; err != nil {
+		t.Fatalf("修改删除时间失败: %v", err)
+	}
+
+	before := time.Now().AddDate(0, 0, -7)
+	affected, err := svc.PurgeDeletedOlderThan(before, true)
+	if err != nil || affected != 1 {
+		t.Fatalf("预演返回 %d（%v），期望 1", affected, err)
+	}
+	var likes int64
+	db.Unscoped().Model(&models.Like{}).Count(&likes)
+	if n := countComments(t, db, true); n != 3 || likes != 1 {
+		t.Errorf("预演后共 %d 条评论、%d 条点赞，期望 3、1", n, likes)
+	}
+
+	affected, err = svc.PurgeDeletedOlderThan(before, false)
+	if err != nil || affected != 1 {
+		t.Fatalf("执行返回 %d（%v），期望 1", affected, err)
+	}
+	db.Unscoped().Model(&models.Like{}).Count(&likes)
+	if n := countComments(t, db, true); n != 2 || likes != 0 {
+		t.Errorf("执行后共 %d 条评论、%d 条点赞，期望 2、0", n, likes)
+	}
+}
+
+// TestBulkSoftDeleteAudit 批量软删除写入删除原因、扣减文章评论数（不低于0），并写入一条只含实际删除ID的审计日志；
+// 重复删除不计数，也不再写日志
+func TestBulkSoftDeleteAudit(t *testing.T) {
+	db := testutil.NewDB(t)
+	svc := services.NewCommentService(db)
+	user := testutil.CreateUser(t, db)
+	postA := testutil.CreatePost(t, db, user.ID, models.PostStatusPublished)
+	postB := testutil.CreatePost(t, db, user.ID, models.PostStatusPublished)
+	a1 := testutil.CreateComment(t, db, postA.ID, user.ID, 0, models.CommentStatusApproved)
+	a2 := testutil.CreateComment(t, db, postA.ID, user.ID, 0, models.CommentStatusApproved)
+	a3 := testutil.CreateComment(t, db, postA.ID, user.ID, 0, models.CommentStatusApproved)
+	b1 := testutil.CreateComment(t, db, postB.ID, user.ID, 0, models.CommentStatusApproved)
+	b2 := testutil.CreateComment(t, db, postB.ID, user.ID, 0, models.CommentStatusApproved)
+	db.Model(&models.Post{}).Where("id = ?", postA.ID).UpdateColumn("comment_count", 3)
+	db.Model(&models.Post{}).Where("id = ?", postB.ID).UpdateColumn("comment_count", 1) // 计数已经偏小
+	if err := db.Delete(&models.Comment{}, a3.ID).Error; err != nil {
+		t.Fatalf("删除评论失败: %v", err)
+	}
+
+	ids := []uint{b2.ID, a1.ID, a3.ID, 9999, a2.ID, b1.ID}
+	affected, err := svc.BulkSoftDelete(ids, "  广告  ")
+	if err != nil || affected != 4 {
+		t.Fatalf("批量删除返回 %d（%v），期望 4", affected, err)
+	}
+
+	var deleted []models.Comment
+	db.Unscoped().Where("id IN ?", ids).Order("id").Find(&deleted)
+	for _, c := range deleted {
+		reason := ""
+		if c.DeletedReason != nil {
+			reason = *c.DeletedReason
+		}
+		wantReason := "广告"
+		if c.ID == a3.ID {
+			wantReason = "" // 之前已删除，不受影响
+		}
+		if !c.DeletedAt.Valid || reason != wantReason {
+			t.Errorf("评论 %d 删除状态 %v、原因 %q，期望已删除、%q", c.ID, c.DeletedAt.Valid, reason, wantReason)
+		}
+	}
+
+	for postID, want := range map[uint]int{postA.ID: 1, postB.ID: 0} {
+		var post models.Post
+		db.First(&post, postID)
+		if post.CommentCount != want {
+			t.Errorf("文章 %d 评论数为 %d，期望 %d", postID, post.CommentCount, want)
+		}
+	}
+
+	var logs []models.AuditLog
+	db.Find(&logs)
+	wantIDs := fmt.Sprintf("[%d,%d,%d,%d]", a1.ID, a2.ID, b1.ID, b2.ID)
+	if len(logs) != 1 || logs[0].Action != models.AuditActionCommentBulkSoftDelete || logs[0].TargetType != "comment" ||
+		logs[0].TargetIDs != wantIDs || logs[0].Affected != 4 || logs[0].Reason != "广告" {
+		t.Fatalf("审计日志为 %+v，期望一条删除 %s 的记录", logs, wantIDs)
+	}
+
+	if affected, err := svc.BulkSoftDelete(ids, "广告"); err != nil || affected != 0 {
+		t.Errorf("重复删除返回 %d（%v），期望 0", affected, err)
+	}
+	var n int64
+	db.Model(&models.AuditLog{}).Count(&n)
+	if n != 1 {
+		t.Errorf("重复删除后有 %d 条审计日志，期望 1", n)
+	}
+
+	if _, err := svc.BulkSoftDelete(ids, "   "); err == nil {
+		t.Error("删除原因为空时删除成功")
+	}
+}
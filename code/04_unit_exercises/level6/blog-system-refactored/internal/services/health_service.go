@@ -0,0 +1,253 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"blog-system-refactored/internal/models"
+	"gorm.io/gorm"
+)
+
+// HealthStatus 健康状态，ok < degraded < down，汇总时取最差的一个
+type HealthStatus string
+
+const (
+	HealthStatusOK       HealthStatus = "ok"       // 正常
+	HealthStatusDegraded HealthStatus = "degraded" // 可用但有问题，如迁移未执行完或通知积压
+	HealthStatusDown     HealthStatus = "down"     // 不可用，如数据库连接失败
+)
+
+// 健康检查的默认参数
+const (
+	DefaultHealthCheckTimeout       = 2 * time.Second // 每项检查的默认超时时间
+	DefaultNotificationBacklogLimit = 1000            // 未读通知超过该数量时视为积压
+)
+
+// severity 状态的严重程度，用于取最差状态
+func (s HealthStatus) severity() int {
+	switch s {
+	case HealthStatusOK:
+		return 0
+	case HealthStatusDegraded:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// HealthCheck 一项子系统检查
+// Run 返回的details会原样放入结果；返回错误时，Critical的检查状态为down，其他为degraded
+type HealthCheck struct {
+	Name     string                                                     // 检查名称
+	Timeout  time.Duration                                              // 超时时间，为0时使用 DefaultHealthCheckTimeout
+	Critical bool                                                       // 失败时是否视为整个服务不可用
+	Run      func(ctx context.Context) (details interface{}, err error) // 检查函数，须在ctx取消后尽快返回
+}
+
+// HealthCheckResult 单项检查的结果
+type HealthCheckResult struct {
+	Name      string       `json:"name"`              // 检查名称
+	Status    HealthStatus `json:"status"`            // 检查状态
+	LatencyMs float64      `json:"latency_ms"`        // 检查耗时（毫秒）
+	Error     string       `json:"error,omitempty"`   // 失败原因
+	Details   interface{}  `json:"details,omitempty"` // 检查的附加信息
+}
+
+// HealthReport 健康检查汇总
+type HealthReport struct {
+	Status    HealthStatus        `json:"status"`    // 所有检查中最差的状态
+	Checks    []HealthCheckResult `json:"checks"`    // 各项检查结果，顺序与注册顺序一致
+	Timestamp time.Time           `json:"timestamp"` // 检查时间
+}
+
+// HealthService 健康检查服务接口
+type HealthService interface {
+	Check(ctx context.Context) *HealthReport // 并发执行所有检查并汇总结果
+}
+
+// healthService 健康检查服务实现
+type healthService struct {
+	checks []HealthCheck
+}
+
+// NewHealthService 创建健康检查服务实例
+// 参数: checks - 要执行的检查
+// 返回: HealthService - 健康检查服务接口实例
+func NewHealthService(checks ...HealthCheck) HealthService {
+	return &healthService{
+		checks: checks,
+	}
+}
+
+// NewDefaultHealthService 创建包含数据库、迁移状态和通知积压三项检查的健康检查服务
+// 参数: db - 数据库连接, migrationModels - 应已迁移的模型
+// 返回: HealthService - 健康检查服务接口实例
+func NewDefaultHealthService(db *gorm.DB, migrationModels []interface{}) HealthService {
+	return NewHealthService(
+		DatabaseHealthCheck(db),
+		MigrationHealthCheck(db, migrationModels),
+		NotificationBacklogHealthCheck(db, DefaultNotificationBacklogLimit),
+	)
+}
+
+// Check 并发执行所有检查，每项检查有各自的超时时间，整体状态取最差的一项
+// 参数: ctx - 上下文，取消时未完成的检查记为超时
+// 返回: *HealthReport - 健康检查汇总
+func (s *healthService) Check(ctx context.Context) *HealthReport {
+	report := &HealthReport{
+		Status:    HealthStatusOK,
+		Checks:    make([]HealthCheckResult, len(s.checks)),
+		Timestamp: time.Now(),
+	}
+
+	var wg sync.WaitGroup
+	for i, check := range s.checks {
+		wg.Add(1)
+		go func(i int, check HealthCheck) {
+			defer wg.Done()
+			report.Checks[i] = runHealthCheck(ctx, check)
+		}(i, check)
+	}
+	wg.Wait()
+
+	for _, result := range report.Checks {
+		if result.Status.severity() > report.Status.severity() {
+			report.Status = result.Status
+		}
+	}
+	return report
+}
+
+// runHealthCheck 在超时时间内执行一项检查
+// 检查函数没有在超时前返回时不再等待，结果记为超时失败
+func runHealthCheck(ctx context.Context, check HealthCheck) HealthCheckResult {
+	timeout := check.Timeout
+	if timeout <= 0 {
+		timeout = DefaultHealthCheckTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	type outcome struct {
+		details interface{}
+		err     error
+	}
+	done := make(chan outcome, 1)
+	start := time.Now()
+	go func() {
+		details, err := check.Run(ctx)
+		done <- outcome{details: details, err: err}
+	}()
+
+	var out outcome
+	select {
+	case out = <-done:
+	case <-ctx.Done():
+		out.err = fmt.Errorf("检查超时（%v）: %v", timeout, ctx.Err())
+	}
+
+	result := HealthCheckResult{
+		Name:      check.Name,
+		Status:    HealthStatusOK,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+		Details:   out.details,
+	}
+	if out.err != nil {
+		result.Error = out.err.Error()
+		result.Status = HealthStatusDegraded
+		if check.Critical {
+			result.Status = HealthStatusDown
+		}
+	}
+	return result
+}
+
+// DatabaseHealthCheck 数据库连接检查，ping失败时服务不可用
+// 参数: db - 数据库连接
+// 返回: HealthCheck - 检查项
+func DatabaseHealthCheck(db *gorm.DB) HealthCheck {
+	return HealthCheck{
+		Name:     "database",
+		Critical: true,
+		Run: func(ctx context.Context) (interface{}, error) {
+			sqlDB, err := db.DB()
+			if err != nil {
+				return nil, fmt.Errorf("获取底层数据库连接失败: %v", err)
+			}
+			if err := sqlDB.PingContext(ctx); err != nil {
+				return nil, fmt.Errorf("数据库连接测试失败: %v", err)
+			}
+			stats := sqlDB.Stats()
+			return map[string]int{"open_connections": stats.OpenConnections, "in_use": stats.InUse}, nil
+		},
+	}
+}
+
+// ErrMigrationPending 数据库表结构落后于模型定义
+var ErrMigrationPending = errors.New("数据库迁移未完成")
+
+// MigrationHealthCheck 迁移状态检查：每个模型的表和字段都已存在
+// 缺少表或字段时返回 ErrMigrationPending，details中列出缺少的表和字段
+// 参数: db - 数据库连接, migrationModels - 应已迁移的模型
+// 返回: HealthCheck - 检查项
+func MigrationHealthCheck(db *gorm.DB, migrationModels []interface{}) HealthCheck {
+	return HealthCheck{
+		Name: "migrations",
+		Run: func(ctx context.Context) (interface{}, error) {
+			tx := db.WithContext(ctx)
+			migrator := tx.Migrator()
+			var missing []string
+			for _, model := range migrationModels {
+				stmt := &gorm.Statement{DB: tx}
+				if err := stmt.Parse(model); err != nil {
+					return nil, fmt.Errorf("解析模型失败: %v", err)
+				}
+				table := stmt.Schema.Table
+				if !migrator.HasTable(model) {
+					missing = append(missing, table)
+					continue
+				}
+				for _, field := range stmt.Schema.Fields {
+					if field.DBName != "" && !migrator.HasColumn(model, field.DBName) {
+						missing = append(missing, table+"."+field.DBName)
+					}
+				}
+			}
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+
+			details := map[string]interface{}{"models": len(migrationModels)}
+			if len(missing) > 0 {
+				details["missing"] = missing
+				return details, fmt.Errorf("%w: 缺少 %d 个表或字段", ErrMigrationPending, len(missing))
+			}
+			return details, nil
+		},
+	}
+}
+
+// NotificationBacklogHealthCheck 通知积压检查：未读通知超过limit时视为积压
+// 参数: db - 数据库连接, limit - 未读通知数量上限
+// 返回: HealthCheck - 检查项
+func NotificationBacklogHealthCheck(db *gorm.DB, limit int64) HealthCheck {
+	return HealthCheck{
+		Name: "notification_backlog",
+		Run: func(ctx context.Context) (interface{}, error) {
+			var pending int64
+			if err := db.WithContext(ctx).Model(&models.Notification{}).
+				Where("is_read = ?", false).Count(&pending).Error; err != nil {
+				return nil, fmt.Errorf("统计未读通知失败: %v", err)
+			}
+
+			details := map[string]int64{"pending": pending, "limit": limit}
+			if pending > limit {
+				return details, fmt.Errorf("未读通知积压 %d 条，超过上限 %d", pending, limit)
+			}
+			return details, nil
+		},
+	}
+}
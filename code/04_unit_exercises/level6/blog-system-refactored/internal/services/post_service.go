@@ -3,6 +3,7 @@ package services
 import (
 	"errors"
 	"fmt"
+	"log"
 	"strings"
 	"time"
 
@@ -20,6 +21,7 @@ type PostService interface {
 	UpdatePost(post *models.Post) error                    // 更新文章
 	DeletePost(id uint) error                              // 删除文章
 	ListPosts(offset, limit int, filters PostFilters) ([]models.Post, int64, error) // 分页获取文章列表
+	PostsPerPage(requested int) int                        // 计算每页文章数量
 	
 	// 文章状态操作
 	PublishPost(id uint) error                             // 发布文章
@@ -55,17 +57,25 @@ type PostService interface {
 	GetPopularTags(limit int) ([]models.Tag, error)        // 获取热门标签
 }
 
+// 文章列表每页数量
+const (
+	DefaultPostsPerPage = 10  // 未指定且 posts_per_page 设置不可用时的每页数量
+	MaxPostsPerPage     = 100 // 每页数量上限，客户端指定和 posts_per_page 设置都不能超过
+)
+
 // postService 文章服务实现
 type postService struct {
-	db *gorm.DB
+	db       *gorm.DB
+	settings SettingService
 }
 
 // NewPostService 创建文章服务实例
-// 参数: db - 数据库连接
+// 参数: db - 数据库连接, settings - 系统设置服务（读取 posts_per_page），为nil时使用 DefaultPostsPerPage
 // 返回: PostService - 文章服务接口实例
-func NewPostService(db *gorm.DB) PostService {
+func NewPostService(db *gorm.DB, settings SettingService) PostService {
 	return &postService{
-		db: db,
+		db:       db,
+		settings: settings,
 	}
 }
 
@@ -246,9 +256,7 @@ func (s *postService) ListPosts(offset, limit int, filters PostFilters) ([]model
 	if offset < 0 {
 		offset = 0
 	}
-	if limit <= 0 || limit > 100 {
-		limit = 20
-	}
+	limit = s.PostsPerPage(limit)
 	
 	var posts []models.Post
 	var total int64
@@ -287,6 +295,43 @@ func (s *postService) ListPosts(offset, limit int, filters PostFilters) ([]model
 	return posts, total, nil
 }
 
+// PostsPerPage 计算每页文章数量
+// requested<=0（客户端未指定）时使用 posts_per_page 设置，设置通过缓存读取，修改后无需重启即可生效；
+// 设置不存在或无法转换为整数时使用 DefaultPostsPerPage。结果限制在 1 到 MaxPostsPerPage 之间
+// 参数: requested - 客户端指定的每页数量
+// 返回: int - 每页数量
+func (s *postService) PostsPerPage(requested int) int {
+	size := requested
+	if size <= 0 {
+		size = s.defaultPostsPerPage()
+	}
+	if size > MaxPostsPerPage {
+		size = MaxPostsPerPage
+	}
+	return size
+}
+
+// defaultPostsPerPage 读取 posts_per_page 设置，不可用时返回 DefaultPostsPerPage
+func (s *postService) defaultPostsPerPage() int {
+	if s.settings == nil {
+		return DefaultPostsPerPage
+	}
+	value, err := s.settings.GetInt(models.SettingPostsPerPage)
+	if err != nil {
+		if !errors.Is(err, ErrSettingNotFound) {
+			log.Printf("读取每页文章数量设置失败: %v", err)
+		}
+		return DefaultPostsPerPage
+	}
+	if value <= 0 {
+		return DefaultPostsPerPage
+	}
+	if value > MaxPostsPerPage {
+		return MaxPostsPerPage
+	}
+	return int(value)
+}
+
 // 文章状态操作实现
 
 // PublishPost 发布文章
@@ -493,7 +538,8 @@ func (s *postService) GetPopularPosts(limit int, days int) ([]models.Post, error
 	// 根据浏览量、点赞数等综合排序
 	startDate := time.Now().AddDate(0, 0, -days)
 	err := s.db.Preload("Author").Preload("Category").Preload("Tags").
-		Where("status = ? AND published_at >= ?", "published", startDate).
+		Scopes(models.Published()).
+		Where("published_at >= ?", startDate).
 		Order("view_count DESC, (SELECT COUNT(*) FROM likes WHERE target_type = 'post' AND target_id = posts.id) DESC").
 		Limit(limit).
 		Find(&posts).Error
@@ -516,7 +562,7 @@ func (s *postService) GetRecentPosts(limit int) ([]models.Post, error) {
 	var posts []models.Post
 	
 	err := s.db.Preload("Author").Preload("Category").Preload("Tags").
-		Where("status = ?", "published").
+		Scopes(models.Published()).
 		Order("published_at DESC").
 		Limit(limit).
 		Find(&posts).Error
@@ -547,7 +593,8 @@ func (s *postService) GetRecommendedPosts(userID uint, limit int) ([]models.Post
 	// 获取用户关注的作者的文章
 	err := s.db.Preload("Author").Preload("Category").Preload("Tags").
 		Joins("JOIN follows ON posts.author_id = follows.following_id").
-		Where("follows.follower_id = ? AND posts.status = ?", userID, "published").
+		Scopes(models.Published()).
+		Where("follows.follower_id = ?", userID).
 		Order("posts.published_at DESC").
 		Limit(limit).
 		Find(&posts).Error
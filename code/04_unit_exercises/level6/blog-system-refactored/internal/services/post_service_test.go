@@ -0,0 +1,79 @@
+package services_test
+
+import (
+	"testing"
+	"time"
+
+	"blog-system-refactored/internal/models"
+	"blog-system-refactored/internal/services"
+	"blog-system-refactored/internal/testutil"
+)
+
+// TestPostsPerPage 未指定每页数量时使用 posts_per_page 设置，结果不超过 MaxPostsPerPage
+func TestPostsPerPage(t *testing.T) {
+	db := testutil.NewDB(t)
+	settings := services.NewSettingService(db, time.Hour)
+
+	// 没有设置服务或设置不存在时使用 DefaultPostsPerPage
+	if got := services.NewPostService(db, nil).PostsPerPage(0); got != services.DefaultPostsPerPage {
+		t.Errorf("没有设置服务时为 %d，期望 %d", got, services.DefaultPostsPerPage)
+	}
+	posts := services.NewPostService(db, settings)
+	if got := posts.PostsPerPage(0); got != services.DefaultPostsPerPage {
+		t.Errorf("没有 posts_per_page 设置时为 %d，期望 %d", got, services.DefaultPostsPerPage)
+	}
+
+	createSetting(t, db, models.SettingPostsPerPage, "25", models.SettingTypeInteger)
+	settings = services.NewSettingService(db, time.Hour)
+	posts = services.NewPostService(db, settings)
+
+	tests := []struct {
+		setting   string
+		requested int
+		want      int
+	}{
+		{"25", 0, 25},
+		{"25", -1, 25},
+		{"25", 30, 30},   // 客户端指定时不使用设置
+		{"25", 500, 100}, // 客户端指定的数量不超过上限
+		{"500", 0, 100},  // 设置的数量同样不超过上限
+		{"0", 0, services.DefaultPostsPerPage},
+		{"-3", 0, services.DefaultPostsPerPage},
+	}
+	for _, tt := range tests {
+		// 通过 Set 修改，不需要重建服务即可生效
+		if err := settings.Set(models.SettingPostsPerPage, tt.setting); err != nil {
+			t.Fatalf("修改设置失败: %v", err)
+		}
+		if got := posts.PostsPerPage(tt.requested); got != tt.want {
+			t.Errorf("设置为 %s、请求 %d 时为 %d，期望 %d", tt.setting, tt.requested, got, tt.want)
+		}
+	}
+}
+
+// TestListPostsDefaultPageSize 列表未指定每页数量时按 posts_per_page 返回
+func TestListPostsDefaultPageSize(t *testing.T) {
+	db := testutil.NewDB(t)
+	author := testutil.CreateUser(t, db)
+	for i := 0; i < 7; i++ {
+		testutil.CreatePost(t, db, author.ID, models.PostStatusPublished)
+	}
+	createSetting(t, db, models.SettingPostsPerPage, "5", models.SettingTypeInteger)
+	posts := services.NewPostService(db, services.NewSettingService(db, time.Hour))
+
+	list, total, err := posts.ListPosts(0, 0, services.PostFilters{})
+	if err != nil {
+		t.Fatalf("获取文章列表失败: %v", err)
+	}
+	if len(list) != 5 || total != 7 {
+		t.Errorf("返回 %d 篇、共 %d 篇，期望 5 篇、共 7 篇", len(list), total)
+	}
+
+	list, _, err = posts.ListPosts(0, 3, services.PostFilters{})
+	if err != nil {
+		t.Fatalf("获取文章列表失败: %v", err)
+	}
+	if len(list) != 3 {
+		t.Errorf("指定每页3篇时返回 %d 篇", len(list))
+	}
+}
@@ -0,0 +1,363 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"blog-system-refactored/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DefaultSettingCacheTTL 设置缓存的默认有效期，中间件每个请求都会读取设置，缓存避免每次查询数据库
+const DefaultSettingCacheTTL = 5 * time.Second
+
+// ErrSettingNotFound 设置不存在
+var ErrSettingNotFound = errors.New("设置不存在")
+
+// SettingTypeError 设置的值无法转换为要求的类型
+type SettingTypeError struct {
+	Key    string // 设置键名
+	Type   string // 设置的类型
+	Value  string // 设置的值
+	Target string // 要求的类型，如 int、bool
+	Err    error  // 转换失败的原因
+}
+
+// Error 实现error接口
+func (e *SettingTypeError) Error() string {
+	return fmt.Sprintf("设置%s（类型%s）的值%q不能转换为%s: %v", e.Key, e.Type, e.Value, e.Target, e.Err)
+}
+
+// Unwrap 返回转换失败的原因
+func (e *SettingTypeError) Unwrap() error {
+	return e.Err
+}
+
+// SettingChangeFunc 设置变化时的回调，参数为键名、变化前和变化后的值
+type SettingChangeFunc func(key, oldValue, newValue string)
+
+// SettingService 系统设置服务接口
+// 读取的设置缓存一段时间（TTL）；通过 Set 修改时同时写数据库和缓存（write-through），本实例立即读到新值，
+// 直接修改数据库或其他实例修改时，在缓存过期重新读取后生效
+type SettingService interface {
+	GetString(key string) (string, error)       // 读取设置的原始值
+	GetInt(key string) (int64, error)           // 按设置的类型转换为整数
+	GetBool(key string) (bool, error)           // 按设置的类型转换为bool
+	GetJSON(key string, dest interface{}) error // 按设置的类型解析到dest
+	Set(key, value string) error                // 修改已有设置的值，值须符合设置的类型
+	OnChange(key string, fn SettingChangeFunc)  // 订阅设置的变化
+	SeedDefaults() error                        // 创建缺少的内置设置，已有的不修改
+}
+
+// settingService 系统设置服务实现
+type settingService struct {
+	db  *gorm.DB
+	ttl time.Duration
+
+	mu          sync.RWMutex
+	cache       map[string]cachedSetting
+	subscribers map[string][]SettingChangeFunc
+}
+
+// cachedSetting 缓存的设置，setting为nil表示设置不存在（同样缓存，避免反复查询不存在的键）
+type cachedSetting struct {
+	setting   *models.Setting
+	expiresAt time.Time
+}
+
+// NewSettingService 创建系统设置服务实例
+// 参数: db - 数据库连接, ttl - 缓存有效期，为0时使用 DefaultSettingCacheTTL
+// 返回: SettingService - 系统设置服务接口实例
+func NewSettingService(db *gorm.DB, ttl time.Duration) SettingService {
+	if ttl <= 0 {
+		ttl = DefaultSettingCacheTTL
+	}
+	return &settingService{
+		db:          db,
+		ttl:         ttl,
+		cache:       make(map[string]cachedSetting),
+		subscribers: make(map[string][]SettingChangeFunc),
+	}
+}
+
+// defaultSettings 内置设置，SeedDefaults 时创建
+var defaultSettings = []models.Setting{
+	{Key: models.SettingMaintenanceMode, Value: "false", Type: models.SettingTypeBoolean, Description: "维护模式，开启后非管理员请求返回503", Group: "system"},
+	{Key: models.SettingMaintenanceMessage, Value: "系统维护中，请稍后再试", Type: models.SettingTypeString, Description: "维护模式下返回给用户的提示", Group: "system"},
+	{Key: models.SettingPostsPerPage, Value: "10", Type: models.SettingTypeInteger, Description: "每页文章数量", Group: "display", IsPublic: true},
+}
+
+// get 读取设置，优先使用未过期的缓存
+// 缓存过期后重新读取时，值与缓存中的不同（直接修改了数据库或其他实例修改）同样通知订阅者
+// 参数: key - 设置键名
+// 返回: *models.Setting - 设置, error - 错误信息，设置不存在时返回 ErrSettingNotFound
+func (s *settingService) get(key string) (*models.Setting, error) {
+	now := time.Now()
+	s.mu.RLock()
+	cached, ok := s.cache[key]
+	s.mu.RUnlock()
+	if ok && now.Before(cached.expiresAt) {
+		if cached.setting == nil {
+			return nil, ErrSettingNotFound
+		}
+		return cached.setting, nil
+	}
+
+	var setting models.Setting
+	err := s.db.Where(settingKey(key)).First(&setting).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("读取设置%s失败: %v", key, err)
+	}
+
+	var loaded *models.Setting
+	if err == nil {
+		loaded = &setting
+	}
+	s.store(key, loaded, now)
+
+	if loaded == nil {
+		return nil, ErrSettingNotFound
+	}
+	return loaded, nil
+}
+
+// store 写入缓存，值与缓存中原来的不同时通知订阅者（缓存中没有时不通知）
+func (s *settingService) store(key string, setting *models.Setting, now time.Time) {
+	s.mu.Lock()
+	previous, hadPrevious := s.cache[key]
+	s.cache[key] = cachedSetting{setting: setting, expiresAt: now.Add(s.ttl)}
+	subscribers := s.subscribers[key]
+	s.mu.Unlock()
+
+	if !hadPrevious || len(subscribers) == 0 {
+		return
+	}
+	oldValue, newValue := settingValue(previous.setting), settingValue(setting)
+	if oldValue != newValue {
+		s.notify(key, subscribers, oldValue, newValue)
+	}
+}
+
+// notify 依次调用订阅者，回调panic时记录日志，不影响其他订阅者和读取设置的请求
+func (s *settingService) notify(key string, subscribers []SettingChangeFunc, oldValue, newValue string) {
+	for _, fn := range subscribers {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					log.Printf("⚠️ 设置%s的变化回调失败: %v", key, r)
+				}
+			}()
+			fn(key, oldValue, newValue)
+		}()
+	}
+}
+
+// settingValue 设置的值，设置不存在时为空字符串
+func settingValue(setting *models.Setting) string {
+	if setting == nil {
+		return ""
+	}
+	return setting.Value
+}
+
+// settingKey 按键名查询设置的条件，key 是保留字，由GORM按数据库方言加引号
+func settingKey(key string) clause.Eq {
+	return clause.Eq{Column: clause.Column{Name: "key"}, Value: key}
+}
+
+// invalidate 删除设置的缓存，下次读取时重新查询
+func (s *settingService) invalidate(key string) {
+	s.mu.Lock()
+	delete(s.cache, key)
+	s.mu.Unlock()
+}
+
+// GetString 读取设置的原始值，任何类型的设置都可以读取
+// 参数: key - 设置键名
+// 返回: string - 设置值, error - 错误信息
+func (s *settingService) GetString(key string) (string, error) {
+	setting, err := s.get(key)
+	if err != nil {
+		return "", err
+	}
+	return setting.Value, nil
+}
+
+// GetInt 按设置的类型转换为整数
+// integer和string类型按十进制整数解析，json类型须为整数；boolean类型不能转换
+// 参数: key - 设置键名
+// 返回: int64 - 设置值, error - 错误信息，无法转换时返回 *SettingTypeError
+func (s *settingService) GetInt(key string) (int64, error) {
+	setting, err := s.get(key)
+	if err != nil {
+		return 0, err
+	}
+	value, err := settingInt(setting)
+	if err != nil {
+		return 0, newSettingTypeError(setting, "int", err)
+	}
+	return value, nil
+}
+
+// GetBool 按设置的类型转换为bool
+// boolean和string类型按 strconv.ParseBool 解析（true/false/1/0等），integer类型非0为true，json类型须为布尔值
+// 参数: key - 设置键名
+// 返回: bool - 设置值, error - 错误信息，无法转换时返回 *SettingTypeError
+func (s *settingService) GetBool(key string) (bool, error) {
+	setting, err := s.get(key)
+	if err != nil {
+		return false, err
+	}
+	value, err := settingBool(setting)
+	if err != nil {
+		return false, newSettingTypeError(setting, "bool", err)
+	}
+	return value, nil
+}
+
+// GetJSON 按设置的类型解析到dest
+// json、integer、boolean类型的值本身是JSON，直接解析；string类型作为JSON字符串解析，dest须为字符串
+// 参数: key - 设置键名, dest - 解析结果，须为指针
+// 返回: error - 错误信息，无法解析时返回 *SettingTypeError
+func (s *settingService) GetJSON(key string, dest interface{}) error {
+	setting, err := s.get(key)
+	if err != nil {
+		return err
+	}
+	data := []byte(strings.TrimSpace(setting.Value))
+	if setting.Type == models.SettingTypeString || setting.Type == "" {
+		if data, err = json.Marshal(setting.Value); err != nil {
+			return newSettingTypeError(setting, fmt.Sprintf("%T", dest), err)
+		}
+	}
+	if err := json.Unmarshal(data, dest); err != nil {
+		return newSettingTypeError(setting, fmt.Sprintf("%T", dest), err)
+	}
+	return nil
+}
+
+// newSettingTypeError 创建设置类型转换错误
+func newSettingTypeError(setting *models.Setting, target string, err error) *SettingTypeError {
+	return &SettingTypeError{Key: setting.Key, Type: setting.Type, Value: setting.Value, Target: target, Err: err}
+}
+
+// settingInt 按设置的类型把值转换为整数
+func settingInt(setting *models.Setting) (int64, error) {
+	value := strings.TrimSpace(setting.Value)
+	switch setting.Type {
+	case models.SettingTypeInteger, models.SettingTypeString, "":
+		return strconv.ParseInt(value, 10, 64)
+	case models.SettingTypeJSON:
+		var n int64
+		err := json.Unmarshal([]byte(value), &n)
+		return n, err
+	default:
+		return 0, fmt.Errorf("%s类型不能转换为整数", setting.Type)
+	}
+}
+
+// settingBool 按设置的类型把值转换为bool
+func settingBool(setting *models.Setting) (bool, error) {
+	value := strings.TrimSpace(setting.Value)
+	switch setting.Type {
+	case models.SettingTypeBoolean, models.SettingTypeString, "":
+		return strconv.ParseBool(value)
+	case models.SettingTypeInteger:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return false, err
+		}
+		return n != 0, nil
+	case models.SettingTypeJSON:
+		var b bool
+		err := json.Unmarshal([]byte(value), &b)
+		return b, err
+	default:
+		return false, fmt.Errorf("不支持的设置类型")
+	}
+}
+
+// Set 修改已有设置的值，值须符合设置的类型
+// 写入数据库后同时更新缓存（write-through），值有变化时通知订阅者
+// 参数: key - 设置键名, value - 新的值
+// 返回: error - 错误信息，设置不存在时返回 ErrSettingNotFound，值不符合类型时返回 *SettingTypeError
+func (s *settingService) Set(key, value string) error {
+	var setting models.Setting
+	if err := s.db.Where(settingKey(key)).First(&setting).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrSettingNotFound
+		}
+		return fmt.Errorf("读取设置%s失败: %v", key, err)
+	}
+
+	oldValue := setting.Value
+	setting.Value = value
+	if err := validateSettingValue(&setting); err != nil {
+		return newSettingTypeError(&setting, setting.Type, err)
+	}
+	if err := s.db.Model(&setting).Update("value", value).Error; err != nil {
+		return fmt.Errorf("修改设置%s失败: %v", key, err)
+	}
+
+	// 缓存中的值可能已过期或还没读取过，以数据库中修改前的值判断是否变化
+	s.mu.Lock()
+	s.cache[key] = cachedSetting{setting: &setting, expiresAt: time.Now().Add(s.ttl)}
+	subscribers := s.subscribers[key]
+	s.mu.Unlock()
+	if oldValue != value {
+		s.notify(key, subscribers, oldValue, value)
+	}
+	return nil
+}
+
+// OnChange 订阅设置的变化，设置的值变化后调用fn
+// 本实例通过 Set 修改时立即通知；直接修改数据库或其他实例修改时，在缓存过期重新读取到新值后通知
+// 参数: key - 设置键名, fn - 回调函数，在修改或读取设置的goroutine中同步执行，应尽快返回
+func (s *settingService) OnChange(key string, fn SettingChangeFunc) {
+	s.mu.Lock()
+	s.subscribers[key] = append(s.subscribers[key], fn)
+	s.mu.Unlock()
+}
+
+// validateSettingValue 校验设置值是否符合设置的类型
+func validateSettingValue(setting *models.Setting) error {
+	value := strings.TrimSpace(setting.Value)
+	switch setting.Type {
+	case models.SettingTypeBoolean:
+		_, err := strconv.ParseBool(value)
+		return err
+	case models.SettingTypeInteger:
+		_, err := strconv.ParseInt(value, 10, 64)
+		return err
+	case models.SettingTypeJSON:
+		if !json.Valid([]byte(value)) {
+			return errors.New("不是有效的JSON")
+		}
+	}
+	return nil
+}
+
+// SeedDefaults 创建缺少的内置设置（如维护模式），已有的设置不修改
+// 返回: error - 错误信息
+func (s *settingService) SeedDefaults() error {
+	settings := make([]models.Setting, len(defaultSettings))
+	copy(settings, defaultSettings)
+	err := s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "key"}},
+		DoNothing: true,
+	}).Create(&settings).Error
+	if err != nil {
+		return fmt.Errorf("创建内置设置失败: %v", err)
+	}
+	for _, setting := range settings {
+		s.invalidate(setting.Key)
+	}
+	return nil
+}
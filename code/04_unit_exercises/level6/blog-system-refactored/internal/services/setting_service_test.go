@@ -0,0 +1,196 @@
+package services_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+
+	"blog-system-refactored/internal/models"
+	"blog-system-refactored/internal/services"
+	"blog-system-refactored/internal/testutil"
+)
+
+// createSetting 直接在数据库中创建一个设置
+func createSetting(t *testing.T, db *gorm.DB, key, value, settingType string) {
+	t.Helper()
+	if err := db.Create(&models.Setting{Key: key, Value: value, Type: settingType}).Error; err != nil {
+		t.Fatalf("创建设置失败: %v", err)
+	}
+}
+
+// updateSettingInDB 绕过 SettingService 直接修改数据库，模拟其他实例修改设置
+func updateSettingInDB(t *testing.T, db *gorm.DB, key, value string) {
+	t.Helper()
+	if err := db.Model(&models.Setting{}).Where(&models.Setting{Key: key}).Update("value", value).Error; err != nil {
+		t.Fatalf("修改设置失败: %v", err)
+	}
+}
+
+// settingChange 订阅回调收到的一次变化
+type settingChange struct {
+	Key, Old, New string
+}
+
+// TestSettingTypedGetters 按设置的类型转换，无法转换时返回 SettingTypeError
+func TestSettingTypedGetters(t *testing.T) {
+	db := testutil.NewDB(t)
+	createSetting(t, db, "int_value", " 42 ", models.SettingTypeInteger)
+	createSetting(t, db, "int_text", "7", models.SettingTypeString)
+	createSetting(t, db, "bool_value", "true", models.SettingTypeBoolean)
+	createSetting(t, db, "bool_int", "0", models.SettingTypeInteger)
+	createSetting(t, db, "json_bool", "true", models.SettingTypeJSON)
+	createSetting(t, db, "json_list", `["a","b"]`, models.SettingTypeJSON)
+	createSetting(t, db, "text", "hello", models.SettingTypeString)
+	settings := services.NewSettingService(db, time.Hour)
+
+	if n, err := settings.GetInt("int_value"); err != nil || n != 42 {
+		t.Errorf("GetInt(int_value) = %d, %v，期望 42", n, err)
+	}
+	if n, err := settings.GetInt("int_text"); err != nil || n != 7 {
+		t.Errorf("GetInt(int_text) = %d, %v，期望 7", n, err)
+	}
+	if b, err := settings.GetBool("bool_value"); err != nil || !b {
+		t.Errorf("GetBool(bool_value) = %v, %v，期望 true", b, err)
+	}
+	if b, err := settings.GetBool("bool_int"); err != nil || b {
+		t.Errorf("GetBool(bool_int) = %v, %v，期望 false", b, err)
+	}
+	if b, err := settings.GetBool("json_bool"); err != nil || !b {
+		t.Errorf("GetBool(json_bool) = %v, %v，期望 true", b, err)
+	}
+	var list []string
+	if err := settings.GetJSON("json_list", &list); err != nil || !reflect.DeepEqual(list, []string{"a", "b"}) {
+		t.Errorf("GetJSON(json_list) = %v, %v，期望 [a b]", list, err)
+	}
+	var text string
+	if err := settings.GetJSON("text", &text); err != nil || text != "hello" {
+		t.Errorf("GetJSON(text) = %q, %v，期望 hello", text, err)
+	}
+
+	// 无法转换的组合
+	var typeErr *services.SettingTypeError
+	if _, err := settings.GetInt("bool_value"); !errors.As(err, &typeErr) || typeErr.Key != "bool_value" || typeErr.Target != "int" {
+		t.Errorf("GetInt(bool_value) 返回 %v，期望 bool_value 转 int 的 SettingTypeError", err)
+	}
+	if _, err := settings.GetBool("text"); !errors.As(err, &typeErr) || typeErr.Target != "bool" {
+		t.Errorf("GetBool(text) 返回 %v，期望转 bool 的 SettingTypeError", err)
+	}
+	if _, err := settings.GetInt("json_list"); !errors.As(err, &typeErr) {
+		t.Errorf("GetInt(json_list) 返回 %v，期望 SettingTypeError", err)
+	}
+
+	if _, err := settings.GetString("missing"); !errors.Is(err, services.ErrSettingNotFound) {
+		t.Errorf("读取不存在的设置返回 %v，期望 ErrSettingNotFound", err)
+	}
+}
+
+// TestSettingCacheTTL 直接修改数据库后，缓存过期前读到旧值，过期后读到新值并通知订阅者
+func TestSettingCacheTTL(t *testing.T) {
+	db := testutil.NewDB(t)
+	createSetting(t, db, "site_name", "旧名称", models.SettingTypeString)
+	settings := services.NewSettingService(db, 50*time.Millisecond)
+	var changes []settingChange
+	settings.OnChange("site_name", func(key, oldValue, newValue string) {
+		changes = append(changes, settingChange{key, oldValue, newValue})
+	})
+
+	if v, err := settings.GetString("site_name"); err != nil || v != "旧名称" {
+		t.Fatalf("GetString = %q, %v，期望 旧名称", v, err)
+	}
+	updateSettingInDB(t, db, "site_name", "新名称")
+
+	if v, _ := settings.GetString("site_name"); v != "旧名称" {
+		t.Errorf("缓存过期前读到 %q，期望仍为 旧名称", v)
+	}
+	if len(changes) != 0 {
+		t.Errorf("缓存过期前不应通知订阅者，实际收到 %v", changes)
+	}
+
+	time.Sleep(80 * time.Millisecond)
+	if v, _ := settings.GetString("site_name"); v != "新名称" {
+		t.Errorf("缓存过期后读到 %q，期望 新名称", v)
+	}
+	want := []settingChange{{"site_name", "旧名称", "新名称"}}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("订阅者收到 %v，期望 %v", changes, want)
+	}
+}
+
+// TestSettingSetWriteThrough Set 同时写数据库和缓存，值有变化时立即通知订阅者
+func TestSettingSetWriteThrough(t *testing.T) {
+	db := testutil.NewDB(t)
+	createSetting(t, db, "posts_per_page", "10", models.SettingTypeInteger)
+	settings := services.NewSettingService(db, time.Hour)
+	var changes []settingChange
+	settings.OnChange("posts_per_page", func(key, oldValue, newValue string) {
+		changes = append(changes, settingChange{key, oldValue, newValue})
+	})
+	// 回调panic不影响后面的订阅者
+	settings.OnChange("posts_per_page", func(string, string, string) { panic("订阅者出错") })
+	var second int
+	settings.OnChange("posts_per_page", func(string, string, string) { second++ })
+
+	if n, _ := settings.GetInt("posts_per_page"); n != 10 {
+		t.Fatalf("GetInt = %d，期望 10", n)
+	}
+	if err := settings.Set("posts_per_page", "20"); err != nil {
+		t.Fatalf("修改设置失败: %v", err)
+	}
+	// 缓存有效期为1小时，读到新值说明缓存同时被更新
+	if n, _ := settings.GetInt("posts_per_page"); n != 20 {
+		t.Errorf("Set 后读到 %d，期望 20", n)
+	}
+	var stored models.Setting
+	if err := db.Where(&models.Setting{Key: "posts_per_page"}).First(&stored).Error; err != nil || stored.Value != "20" {
+		t.Errorf("数据库中的值为 %q（%v），期望 20", stored.Value, err)
+	}
+
+	// 值没有变化时不通知
+	if err := settings.Set("posts_per_page", "20"); err != nil {
+		t.Fatalf("修改设置失败: %v", err)
+	}
+	want := []settingChange{{"posts_per_page", "10", "20"}}
+	if !reflect.DeepEqual(changes, want) || second != 1 {
+		t.Errorf("订阅者收到 %v、%d 次，期望 %v、1 次", changes, second, want)
+	}
+
+	// 值不符合类型时拒绝，数据库不变
+	var typeErr *services.SettingTypeError
+	if err := settings.Set("posts_per_page", "many"); !errors.As(err, &typeErr) {
+		t.Errorf("写入非整数返回 %v，期望 SettingTypeError", err)
+	}
+	if n, _ := settings.GetInt("posts_per_page"); n != 20 {
+		t.Errorf("写入失败后读到 %d，期望仍为 20", n)
+	}
+
+	if err := settings.Set("missing", "1"); !errors.Is(err, services.ErrSettingNotFound) {
+		t.Errorf("修改不存在的设置返回 %v，期望 ErrSettingNotFound", err)
+	}
+}
+
+// TestSettingSeedDefaults 只创建缺少的内置设置，已有设置的值不变
+func TestSettingSeedDefaults(t *testing.T) {
+	db := testutil.NewDB(t)
+	createSetting(t, db, models.SettingPostsPerPage, "25", models.SettingTypeInteger)
+	settings := services.NewSettingService(db, time.Hour)
+
+	for i := 0; i < 2; i++ {
+		if err := settings.SeedDefaults(); err != nil {
+			t.Fatalf("创建内置设置失败: %v", err)
+		}
+	}
+	if n, err := settings.GetInt(models.SettingPostsPerPage); err != nil || n != 25 {
+		t.Errorf("已有的 posts_per_page 为 %d（%v），期望保持 25", n, err)
+	}
+	if b, err := settings.GetBool(models.SettingMaintenanceMode); err != nil || b {
+		t.Errorf("maintenance_mode 为 %v（%v），期望创建为 false", b, err)
+	}
+	var count int64
+	db.Model(&models.Setting{}).Count(&count)
+	if count != 3 {
+		t.Errorf("共有 %d 个设置，期望 3", count)
+	}
+}
@@ -15,6 +15,7 @@ type UserService interface {
 	// 用户基本操作
 	CreateUser(user *models.User) error                    // 创建用户
 	GetUserByID(id uint) (*models.User, error)             // 根据ID获取用户
+	GetUsersByIDsOrdered(ids []uint) ([]models.User, error) // 根据ID批量获取用户，按传入顺序返回
 	GetUserByUsername(username string) (*models.User, error) // 根据用户名获取用户
 	GetUserByEmail(email string) (*models.User, error)     // 根据邮箱获取用户
 	UpdateUser(user *models.User) error                    // 更新用户信息
@@ -126,6 +127,34 @@ func (s *userService) GetUserByID(id uint) (*models.User, error) {
 	return user, nil
 }
 
+// GetUsersByIDsOrdered 根据ID批量获取用户，按传入的顺序返回
+// 用于信息流、排行榜等已有用户ID列表的场景：一次查询取出全部用户，再按ids的顺序排列，避免逐个查询；
+// 不存在的ID直接跳过，重复的ID按出现次数返回
+// 参数: ids - 用户ID列表
+// 返回: []models.User - 用户列表（顺序与ids一致）, error - 错误信息
+func (s *userService) GetUsersByIDsOrdered(ids []uint) ([]models.User, error) {
+	if len(ids) == 0 {
+		return []models.User{}, nil
+	}
+
+	var found []models.User
+	if err := s.db.Where("id IN ?", ids).Find(&found).Error; err != nil {
+		return nil, err
+	}
+
+	byID := make(map[uint]*models.User, len(found))
+	for i := range found {
+		byID[found[i].ID] = &found[i]
+	}
+	users := make([]models.User, 0, len(ids))
+	for _, id := range ids {
+		if user, ok := byID[id]; ok {
+			users = append(users, *user)
+		}
+	}
+	return users, nil
+}
+
 // GetUserByUsername 根据用户名获取用户
 // 参数: username - 用户名
 // 返回: *models.User - 用户模型, error - 错误信息
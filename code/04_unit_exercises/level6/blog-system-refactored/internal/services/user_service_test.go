@@ -0,0 +1,63 @@
+package services_test
+
+import (
+	"reflect"
+	"testing"
+
+	"blog-system-refactored/internal/models"
+	"blog-system-refactored/internal/services"
+	"blog-system-refactored/internal/testutil"
+)
+
+// userIDs 用户列表的ID，按列表顺序
+func userIDs(users []models.User) []uint {
+	ids := []uint{}
+	for _, u := range users {
+		ids = append(ids, u.ID)
+	}
+	return ids
+}
+
+// TestGetUsersByIDsOrdered 按传入的顺序返回用户，跳过不存在和已删除的ID，重复的ID按次数返回
+func TestGetUsersByIDsOrdered(t *testing.T) {
+	db := testutil.NewDB(t)
+	a := testutil.CreateUser(t, db)
+	b := testutil.CreateUser(t, db)
+	c := testutil.CreateUser(t, db)
+	deleted := testutil.CreateUser(t, db)
+	if err := db.Delete(&deleted).Error; err != nil {
+		t.Fatalf("删除用户失败: %v", err)
+	}
+	users := services.NewUserService(db)
+
+	tests := []struct {
+		name string
+		ids  []uint
+		want []uint
+	}{
+		{"倒序", []uint{c.ID, a.ID, b.ID}, []uint{c.ID, a.ID, b.ID}},
+		{"跳过不存在的ID", []uint{b.ID, 9999, a.ID}, []uint{b.ID, a.ID}},
+		{"跳过已删除的用户", []uint{deleted.ID, c.ID}, []uint{c.ID}},
+		{"重复的ID", []uint{a.ID, b.ID, a.ID}, []uint{a.ID, b.ID, a.ID}},
+		{"全部不存在", []uint{9998, 9999}, []uint{}},
+		{"空列表", nil, []uint{}},
+	}
+	for _, tt := range tests {
+		got, err := users.GetUsersByIDsOrdered(tt.ids)
+		if err != nil {
+			t.Fatalf("%s: 查询失败: %v", tt.name, err)
+		}
+		if got == nil {
+			t.Errorf("%s: 返回nil，期望空列表", tt.name)
+		}
+		if ids := userIDs(got); !reflect.DeepEqual(ids, tt.want) {
+			t.Errorf("%s: 返回 %v，期望 %v", tt.name, ids, tt.want)
+		}
+	}
+
+	// 返回完整的用户记录，而不只是ID
+	got, _ := users.GetUsersByIDsOrdered([]uint{b.ID})
+	if len(got) != 1 || got[0].Username != b.Username {
+		t.Errorf("返回 %+v，期望用户 %s", got, b.Username)
+	}
+}
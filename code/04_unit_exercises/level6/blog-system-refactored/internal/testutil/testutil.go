@@ -0,0 +1,98 @@
+// Package testutil 测试用的数据库和数据构造函数
+// 每个测试使用独立的内存SQLite数据库，表结构与 config.MigrationModels 一致
+package testutil
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"blog-system-refactored/internal/config"
+	"blog-system-refactored/internal/models"
+)
+
+var seq int64
+
+// NewDB 创建独立的内存SQLite数据库并迁移全部模型，测试结束时关闭
+// 参数: t - 测试对象
+// 返回: *gorm.DB - 数据库连接
+func NewDB(t testing.TB) *gorm.DB {
+	t.Helper()
+	dsn := fmt.Sprintf("file:blog_test_%d?mode=memory&cache=shared&_pragma=busy_timeout(5000)", atomic.AddInt64(&seq, 1))
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("打开测试数据库失败: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("获取数据库连接失败: %v", err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	if err := db.AutoMigrate(config.MigrationModels()...); err != nil {
+		t.Fatalf("迁移测试数据库失败: %v", err)
+	}
+	return db
+}
+
+// CreateUser 创建一个用户名唯一的激活用户
+// 参数: t - 测试对象, db - 数据库连接
+// 返回: models.User - 创建的用户
+func CreateUser(t testing.TB, db *gorm.DB) models.User {
+	t.Helper()
+	n := atomic.AddInt64(&seq, 1)
+	user := models.User{
+		Username:     fmt.Sprintf("user%d", n),
+		Email:        fmt.Sprintf("user%d@example.com", n),
+		PasswordHash: "x",
+		Status:       models.StatusActive,
+	}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatalf("创建用户失败: %v", err)
+	}
+	return user
+}
+
+// CreatePost 创建一篇指定状态的文章，已发布的文章发布时间为一小时前
+// 参数: t - 测试对象, db - 数据库连接, authorID - 作者ID, status - 文章状态
+// 返回: models.Post - 创建的文章
+func CreatePost(t testing.TB, db *gorm.DB, authorID uint, status models.PostStatus) models.Post {
+	t.Helper()
+	n := atomic.AddInt64(&seq, 1)
+	post := models.Post{
+		Title:    fmt.Sprintf("文章%d", n),
+		Slug:     fmt.Sprintf("post-%d", n),
+		Content:  "正文",
+		Status:   status,
+		AuthorID: authorID,
+	}
+	if status == models.PostStatusPublished {
+		publishedAt := time.Now().Add(-time.Hour)
+		post.PublishedAt = &publishedAt
+	}
+	if err := db.Create(&post).Error; err != nil {
+		t.Fatalf("创建文章失败: %v", err)
+	}
+	return post
+}
+
+// CreateComment 创建一条指定状态的评论，parentID不为0时作为该评论的回复
+// 参数: t - 测试对象, db - 数据库连接, postID - 文章ID, userID - 评论人ID, parentID - 父评论ID, status - 评论状态
+// 返回: models.Comment - 创建的评论
+func CreateComment(t testing.TB, db *gorm.DB, postID, userID, parentID uint, status models.CommentStatus) models.Comment {
+	t.Helper()
+	comment := models.Comment{PostID: postID, UserID: userID, Content: "评论", Status: status}
+	if parentID != 0 {
+		comment.ParentID = &parentID
+		comment.Level = 2
+	}
+	if err := db.Create(&comment).Error; err != nil {
+		t.Fatalf("创建评论失败: %v", err)
+	}
+	return comment
+}
@@ -7,10 +7,14 @@
 package main
 
 import (
-	"fmt"       // 格式化输出
-	"log"       // 日志记录
-	"math/rand" // 随机数生成
-	"time"      // 时间处理
+	"database/sql/driver" // 自定义字段类型的数据库值转换
+	"encoding/json"       // JSON编解码
+	"fmt"                 // 格式化输出
+	"log"                 // 日志记录
+	"math/rand"           // 随机数生成
+	"regexp"              // 正则表达式
+	"strings"             // 字符串处理
+	"time"                // 时间处理
 
 	"gorm.io/driver/mysql"  // MySQL数据库驱动
 	"gorm.io/driver/sqlite" // SQLite数据库驱动
@@ -150,23 +154,165 @@ type User struct {
 // 存储用户的详细个人资料信息，与User模型形成一对一关系
 // 包含职业信息、教育背景、技能特长、社交链接等扩展信息
 type UserProfile struct {
-	BaseModel           // 嵌入基础模型
-	UserID       uint   `gorm:"uniqueIndex:idx_user_profile;not null" json:"user_id"` // 用户ID，外键关联User表，唯一索引确保一对一关系
-	Company      string `gorm:"size:100" json:"company"`                              // 公司名称，最大100字符
-	JobTitle     string `gorm:"size:100" json:"job_title"`                            // 职位名称，最大100字符
-	Education    string `gorm:"size:200" json:"education"`                            // 教育背景，最大200字符
-	Skills       string `gorm:"type:text" json:"skills"`                              // 技能列表，文本类型，可存储JSON格式
-	Experience   int    `gorm:"default:0" json:"experience"`                          // 工作经验年数，默认0
-	SalaryRange  string `gorm:"size:50" json:"salary_range"`                          // 薪资范围，最大50字符
-	Languages    string `gorm:"type:text" json:"languages"`                           // 语言能力，文本类型，可存储JSON格式
-	Interests    string `gorm:"type:text" json:"interests"`                           // 兴趣爱好，文本类型，可存储JSON格式
-	SocialLinks  string `gorm:"type:text" json:"social_links"`                        // 社交媒体链接，文本类型，可存储JSON格式
-	PrivacyLevel string `gorm:"size:20;default:'public'" json:"privacy_level"`        // 隐私级别(public/private/friends)，默认public
+	BaseModel                // 嵌入基础模型
+	UserID       uint        `gorm:"uniqueIndex:idx_user_profile;not null" json:"user_id"` // 用户ID，外键关联User表，唯一索引确保一对一关系
+	Company      string      `gorm:"size:100" json:"company"`                              // 公司名称，最大100字符
+	JobTitle     string      `gorm:"size:100" json:"job_title"`                            // 职位名称，最大100字符
+	Education    string      `gorm:"size:200" json:"education"`                            // 教育背景，最大200字符
+	Skills       StringList  `json:"skills"`                                               // 技能列表，JSON数组
+	Experience   int         `gorm:"default:0" json:"experience"`                          // 工作经验年数，默认0
+	SalaryRange  string      `gorm:"size:50" json:"salary_range"`                          // 薪资范围，最大50字符
+	Languages    StringList  `json:"languages"`                                            // 语言能力，JSON数组
+	Interests    string      `gorm:"type:text" json:"interests"`                           // 兴趣爱好，文本类型，可存储JSON格式
+	SocialLinks  SocialLinks `json:"social_links"`                                         // 社交媒体链接，JSON对象，平台名 → 链接
+	PrivacyLevel string      `gorm:"size:20;default:'public'" json:"privacy_level"`        // 隐私级别(public/private/friends)，默认public
 
 	// 关联关系
 	User User `gorm:"foreignKey:UserID" json:"user,omitempty"` // 反向关联到User模型
 }
 
+// JSON字段类型
+// 实现 driver.Valuer 和 sql.Scanner，读写时自动进行JSON编解码，业务代码直接使用Go类型；
+// 实现 GormDBDataType，MySQL中使用原生JSON列（可以用JSON函数查询），SQLite中使用TEXT列
+
+// SocialLinks 社交媒体链接，键为平台名（如 github、weibo），值为链接
+type SocialLinks map[string]string
+
+// Value 写入数据库时编码为JSON对象，空值存为NULL
+func (l SocialLinks) Value() (driver.Value, error) {
+	if len(l) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal(map[string]string(l))
+	return string(data), err
+}
+
+// Scan 从数据库读取时解码JSON对象，NULL和空字符串解码为nil
+func (l *SocialLinks) Scan(value interface{}) error {
+	data, err := jsonBytes(value)
+	if err != nil || len(data) == 0 {
+		*l = nil
+		return err
+	}
+	return json.Unmarshal(data, (*map[string]string)(l))
+}
+
+// GormDataType GORM解析模型时使用的通用类型
+func (SocialLinks) GormDataType() string {
+	return "json"
+}
+
+// GormDBDataType 按数据库类型返回列类型
+func (SocialLinks) GormDBDataType(db *gorm.DB, field *schema.Field) string {
+	return jsonDBDataType(db)
+}
+
+// StringList 字符串列表，如技能、语言能力
+type StringList []string
+
+// Value 写入数据库时编码为JSON数组，空值存为NULL
+func (l StringList) Value() (driver.Value, error) {
+	if len(l) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal([]string(l))
+	return string(data), err
+}
+
+// Scan 从数据库读取时解码JSON数组，NULL和空字符串解码为nil
+func (l *StringList) Scan(value interface{}) error {
+	data, err := jsonBytes(value)
+	if err != nil || len(data) == 0 {
+		*l = nil
+		return err
+	}
+	return json.Unmarshal(data, (*[]string)(l))
+}
+
+// GormDataType GORM解析模型时使用的通用类型
+func (StringList) GormDataType() string {
+	return "json"
+}
+
+// GormDBDataType 按数据库类型返回列类型
+func (StringList) GormDBDataType(db *gorm.DB, field *schema.Field) string {
+	return jsonDBDataType(db)
+}
+
+// jsonBytes 把数据库驱动返回的值转换为字节切片
+func jsonBytes(value interface{}) ([]byte, error) {
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case []byte:
+		return v, nil
+	case string:
+		return []byte(v), nil
+	default:
+		return nil, fmt.Errorf("不支持的JSON字段值类型: %T", value)
+	}
+}
+
+// jsonDBDataType JSON字段的列类型：MySQL使用JSON，其他数据库使用TEXT
+func jsonDBDataType(db *gorm.DB) string {
+	if db.Dialector.Name() == "mysql" {
+		return "JSON"
+	}
+	return "TEXT"
+}
+
+// migrateUserProfileJSON 把用户资料中旧的文本格式转换为JSON，需在 AutoMigrate 之前执行
+// 旧数据中技能、语言能力是逗号分隔的文本（如"Go, Python"），转换为JSON数组；
+// 社交链接已是JSON对象的保留，其他非空文本作为 {"other": 原文本} 保存，不丢弃数据。
+// MySQL把列改为JSON类型时要求已有的值都是合法JSON，所以转换必须在 AutoMigrate 修改列类型之前完成。可重复执行
+func migrateUserProfileJSON(db *gorm.DB) error {
+	if !db.Migrator().HasTable(&UserProfile{}) {
+		return nil
+	}
+
+	type legacyProfile struct {
+		ID          uint
+		Skills      *string
+		Languages   *string
+		SocialLinks *string
+	}
+	var rows []legacyProfile
+	if err := db.Model(&UserProfile{}).Unscoped().
+		Select("id", "skills", "languages", "social_links").Scan(&rows).Error; err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		updates := map[string]interface{}{}
+		for column, value := range map[string]*string{"skills": row.Skills, "languages": row.Languages} {
+			if value == nil || json.Valid([]byte(*value)) {
+				continue
+			}
+			var list StringList
+			for _, item := range strings.Split(*value, ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					list = append(list, item)
+				}
+			}
+			updates[column] = list
+		}
+		if row.SocialLinks != nil && !json.Valid([]byte(*row.SocialLinks)) {
+			if text := strings.TrimSpace(*row.SocialLinks); text != "" {
+				updates["social_links"] = SocialLinks{"other": text}
+			} else {
+				updates["social_links"] = nil
+			}
+		}
+		if len(updates) == 0 {
+			continue
+		}
+		if err := db.Model(&UserProfile{}).Unscoped().Where("id = ?", row.ID).UpdateColumns(updates).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // Category 分类模型
 // 表示博客文章的分类体系，支持层级结构(树形结构)
 // 每个分类可以有父分类和子分类，形成完整的分类层次
@@ -417,6 +563,11 @@ func initDB(config DatabaseConfig) *gorm.DB {
 	// SetConnMaxLifetime: 设置连接可复用的最大时间
 	sqlDB.SetConnMaxLifetime(config.MaxLifetime)
 
+	// 用户资料的JSON字段由文本格式改为JSON前，先转换旧数据
+	if err := migrateUserProfileJSON(db); err != nil {
+		log.Fatal("用户资料数据转换失败:", err)
+	}
+
 	// 自动迁移数据库表结构
 	// 按照依赖关系的顺序进行迁移，确保外键关系正确建立
 	err = db.AutoMigrate(
@@ -760,6 +911,110 @@ func (f *Follow) AfterDelete(tx *gorm.DB) error {
 	return nil
 }
 
+// ==================== 更新时间（Touch） ====================
+// 只把记录的 updated_at 改为当前时间，不修改其他字段
+// 用于缓存失效、"最近更新"排序等场景，例如新增评论后让文章显示为最近更新
+
+// touchParent 记录的上级记录：Touch 时可以一并更新上级的 updated_at
+type touchParent struct {
+	model      interface{} // 上级模型，如 &Post{}
+	foreignKey string      // 本表中指向上级记录的外键列
+}
+
+// touchParentsOf 返回模型的上级记录，没有上级时返回nil
+// 上级记录本身也可能有上级（评论 -> 文章 -> 分类），Touch 时逐级向上更新
+func touchParentsOf(model interface{}) []touchParent {
+	switch model.(type) {
+	case *Comment:
+		return []touchParent{{model: &Post{}, foreignKey: "post_id"}}
+	case *PostMeta:
+		return []touchParent{{model: &Post{}, foreignKey: "post_id"}}
+	case *Post:
+		return []touchParent{{model: &Category{}, foreignKey: "category_id"}}
+	case *UserProfile:
+		return []touchParent{{model: &User{}, foreignKey: "user_id"}}
+	}
+	return nil
+}
+
+// touchOptions Touch 的可选参数
+type touchOptions struct {
+	parents bool // 是否一并更新上级记录
+}
+
+// TouchOption Touch 的可选参数
+type TouchOption func(*touchOptions)
+
+// TouchParents 一并更新上级记录的 updated_at，如更新评论时同时更新所属文章和文章所属分类
+func TouchParents() TouchOption {
+	return func(o *touchOptions) {
+		o.parents = true
+	}
+}
+
+// Touch 只把记录的 updated_at 更新为当前时间
+// 使用 UpdateColumn，不执行 BeforeUpdate/AfterUpdate 等钩子，
+// 也就不会触发 User.BeforeUpdate 中重新统计文章数、评论数、粉丝数的查询
+// 参数:
+//   - db: GORM数据库实例
+//   - model: 模型指针，用于确定表名，如 &Comment{}
+//   - id: 记录ID
+//   - opts: 可选参数，如 TouchParents()
+//
+// 返回:
+//   - error: 记录不存在（或已软删除）时返回 gorm.ErrRecordNotFound
+func Touch(db *gorm.DB, model interface{}, id uint, opts ...TouchOption) error {
+	var options touchOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	now := time.Now()
+	if !options.parents {
+		return touchRecord(db, model, id, now)
+	}
+	// 更新上级记录时放在同一事务中，所有记录使用同一个时间
+	return db.Transaction(func(tx *gorm.DB) error {
+		return touchWithParents(tx, model, id, now)
+	})
+}
+
+// touchRecord 更新单条记录的 updated_at
+func touchRecord(db *gorm.DB, model interface{}, id uint, now time.Time) error {
+	result := db.Model(model).Where("id = ?", id).UpdateColumn("updated_at", now)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// touchWithParents 更新记录及其各级上级记录的 updated_at
+// 外键为空（如未分类的文章）或上级记录已被删除时跳过该上级
+func touchWithParents(tx *gorm.DB, model interface{}, id uint, now time.Time) error {
+	if err := touchRecord(tx, model, id, now); err != nil {
+		return err
+	}
+
+	for _, parent := range touchParentsOf(model) {
+		var parentIDs []uint
+		if err := tx.Model(model).Where("id = ? AND "+parent.foreignKey+" IS NOT NULL", id).
+			Pluck(parent.foreignKey, &parentIDs).Error; err != nil {
+			return err
+		}
+		if len(parentIDs) == 0 {
+			continue
+		}
+		err := touchWithParents(tx, parent.model, parentIDs[0], now)
+		if err != nil && err != gorm.ErrRecordNotFound {
+			return err
+		}
+	}
+	return nil
+}
+
 // ==================== 业务逻辑函数 ====================
 // 以下是应用程序的核心业务逻辑实现
 // 采用服务层模式，将业务逻辑与数据访问层分离
@@ -849,6 +1104,34 @@ func (s *UserService) UpdateUserProfile(userID uint, profile *UserProfile) error
 	return s.db.Model(&UserProfile{}).Where("user_id = ?", userID).Updates(profile).Error
 }
 
+// socialPlatformPattern 社交平台名只能包含小写字母、数字、下划线和连字符，拼入JSON路径前校验
+var socialPlatformPattern = regexp.MustCompile(`^[a-z0-9_-]{1,32}$`)
+
+// FindUsersBySocialPlatform 查找在资料中填写了指定社交平台链接的用户
+// MySQL使用 JSON_CONTAINS_PATH 按键查询JSON列；SQLite没有原生JSON列，退化为对JSON文本的LIKE匹配
+// 参数:
+//   - platform: 平台名，如 github
+//
+// 返回:
+//   - []User: 用户列表（包含资料信息）
+//   - error: 平台名不合法或查询失败时返回错误信息
+func (s *UserService) FindUsersBySocialPlatform(platform string) ([]User, error) {
+	if !socialPlatformPattern.MatchString(platform) {
+		return nil, fmt.Errorf("社交平台名不合法: %q", platform)
+	}
+
+	profiles := s.db.Model(&UserProfile{}).Select("user_id")
+	if s.db.Dialector.Name() == "mysql" {
+		profiles = profiles.Where("JSON_CONTAINS_PATH(social_links, 'one', ?) = 1", `$."`+platform+`"`)
+	} else {
+		profiles = profiles.Where("social_links LIKE ?", `%"`+platform+`":%`)
+	}
+
+	var users []User
+	err := s.db.Preload("Profile").Where("id IN (?)", profiles).Find(&users).Error
+	return users, err
+}
+
 // FollowUser 关注用户
 // 创建用户之间的关注关系
 // 参数:
@@ -1774,15 +2057,16 @@ func demonstrateComprehensiveScenarios(db *gorm.DB) {
 
 		// 完善用户详细资料信息
 		profile := &UserProfile{
-			Company:      "科技公司",                   // 公司名称
-			JobTitle:     "软件工程师",                  // 职位
-			Education:    "计算机科学学士",                // 教育背景
-			Skills:       "Go, Python, JavaScript", // 技能
-			Experience:   3,                        // 工作经验年数
-			SalaryRange:  "10k-15k",                // 薪资范围
-			Languages:    "中文, 英文",                 // 语言能力
-			Interests:    "编程, 阅读, 旅游",             // 兴趣爱好
-			PrivacyLevel: "public",                 // 隐私级别
+			Company:      "科技公司",                                              // 公司名称
+			JobTitle:     "软件工程师",                                             // 职位
+			Education:    "计算机科学学士",                                           // 教育背景
+			Skills:       StringList{"Go", "Python", "JavaScript"},            // 技能
+			Experience:   3,                                                   // 工作经验年数
+			SalaryRange:  "10k-15k",                                           // 薪资范围
+			Languages:    StringList{"中文", "英文"},                              // 语言能力
+			SocialLinks:  SocialLinks{"github": "https://github.com/newuser"}, // 社交链接
+			Interests:    "编程, 阅读, 旅游",                                        // 兴趣爱好
+			PrivacyLevel: "public",                                            // 隐私级别
 		}
 
 		// 更新用户资料
@@ -1790,6 +2074,13 @@ func demonstrateComprehensiveScenarios(db *gorm.DB) {
 			fmt.Printf("资料更新失败: %v\n", err)
 		} else {
 			fmt.Println("✓ 用户资料更新成功")
+
+			// 按JSON字段中的键查询
+			if users, err := userService.FindUsersBySocialPlatform("github"); err != nil {
+				fmt.Printf("按社交平台查询失败: %v\n", err)
+			} else {
+				fmt.Printf("✓ 填写了GitHub链接的用户: %d 个\n", len(users))
+			}
 		}
 	}
 
@@ -1848,6 +2139,13 @@ func demonstrateComprehensiveScenarios(db *gorm.DB) {
 		fmt.Printf("评论创建失败: %v\n", err)
 	} else {
 		fmt.Printf("✓ 评论创建成功，ID: %d\n", newComment.ID)
+
+		// 新评论让文章（及其分类）显示为最近更新，只更新 updated_at，不触发统计钩子
+		if err := Touch(db, &Comment{}, newComment.ID, TouchParents()); err != nil {
+			fmt.Printf("更新文章时间失败: %v\n", err)
+		} else {
+			fmt.Println("✓ 已更新评论所属文章和分类的更新时间")
+		}
 	}
 
 	// 用户点赞文章（用户ID=2，文章ID=1）
@@ -0,0 +1,350 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var testDBSeq int64
+
+// newTestDB 创建内存SQLite数据库，只迁移传入的模型，测试结束时关闭
+// SQLite的索引名在整个库内唯一，而User、Post、Comment等模型共用idx_status、idx_likes这类索引名，
+// AutoMigrate又会连带迁移关联的模型，因此这里用CreateTable只建传入模型的表，并且不创建外键约束
+func newTestDB(t *testing.T, models ...interface{}) *gorm.DB {
+	t.Helper()
+	dsn := fmt.Sprintf("file:level6_%d?mode=memory&cache=shared&_busy_timeout=5000", atomic.AddInt64(&testDBSeq, 1))
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
+		Logger:                                   logger.Default.LogMode(logger.Silent),
+		DisableForeignKeyConstraintWhenMigrating: true,
+	})
+	if err != nil {
+		t.Fatalf("打开测试数据库失败: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("获取数据库连接失败: %v", err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	if err := db.Migrator().CreateTable(models...); err != nil {
+		t.Fatalf("迁移测试数据库失败: %v", err)
+	}
+	return db
+}
+
+// newUser 通过 UserService 创建用户，AfterCreate 钩子会同时创建用户资料
+func newUser(t *testing.T, db *gorm.DB, username string) User {
+	t.Helper()
+	user := User{
+		Username:     username,
+		Email:        username + "@example.com",
+		PasswordHash: "x",
+		FirstName:    username,
+		LastName:     "test",
+	}
+	if err := NewUserService(db).CreateUser(&user); err != nil {
+		t.Fatalf("创建用户失败: %v", err)
+	}
+	return user
+}
+
+// loadProfile 重新查询用户资料
+func loadProfile(t *testing.T, db *gorm.DB, userID uint) UserProfile {
+	t.Helper()
+	var profile UserProfile
+	if err := db.Where("user_id = ?", userID).First(&profile).Error; err != nil {
+		t.Fatalf("查询用户资料失败: %v", err)
+	}
+	return profile
+}
+
+// rawColumn 直接读取用户资料某一列存储的文本，NULL返回nil
+func rawColumn(t *testing.T, db *gorm.DB, userID uint, column string) *string {
+	t.Helper()
+	var value *string
+	if err := db.Raw("SELECT "+column+" FROM user_profiles WHERE user_id = ?", userID).Row().Scan(&value); err != nil {
+		t.Fatalf("读取 %s 失败: %v", column, err)
+	}
+	return value
+}
+
+func TestUserProfileJSONFields(t *testing.T) {
+	db := newTestDB(t, &User{}, &UserProfile{})
+	service := NewUserService(db)
+	alice := newUser(t, db, "alice")
+	bob := newUser(t, db, "bob")
+
+	want := UserProfile{
+		Skills:      StringList{"Go", "SQL"},
+		Languages:   StringList{"中文", "English"},
+		SocialLinks: SocialLinks{"github": "https://github.com/alice"},
+	}
+	if err := service.UpdateUserProfile(alice.ID, &want); err != nil {
+		t.Fatalf("更新用户资料失败: %v", err)
+	}
+
+	got, err := service.GetUserByID(alice.ID)
+	if err != nil {
+		t.Fatalf("查询用户失败: %v", err)
+	}
+	if got.Profile == nil {
+		t.Fatal("没有预加载用户资料")
+	}
+	if !reflect.DeepEqual(got.Profile.Skills, want.Skills) ||
+		!reflect.DeepEqual(got.Profile.Languages, want.Languages) ||
+		!reflect.DeepEqual(got.Profile.SocialLinks, want.SocialLinks) {
+		t.Errorf("读回的资料为 %v %v %v，期望 %v %v %v",
+			got.Profile.Skills, got.Profile.Languages, got.Profile.SocialLinks,
+			want.Skills, want.Languages, want.SocialLinks)
+	}
+	if v := rawColumn(t, db, alice.ID, "skills"); v == nil || *v != `["Go","SQL"]` {
+		t.Errorf("skills 应以JSON数组存储，实际为 %v", v)
+	}
+	if v := rawColumn(t, db, alice.ID, "social_links"); v == nil || *v != `{"github":"https://github.com/alice"}` {
+		t.Errorf("social_links 应以JSON对象存储，实际为 %v", v)
+	}
+
+	// 没有填写的字段存为NULL，读回为nil
+	for _, column := range []string{"skills", "languages", "social_links"} {
+		if v := rawColumn(t, db, bob.ID, column); v != nil {
+			t.Errorf("空的 %s 应存为NULL，实际为 %q", column, *v)
+		}
+	}
+	empty := loadProfile(t, db, bob.ID)
+	if empty.Skills != nil || empty.Languages != nil || empty.SocialLinks != nil {
+		t.Errorf("NULL应读回nil，实际为 %v %v %v", empty.Skills, empty.Languages, empty.SocialLinks)
+	}
+}
+
+func TestMigrateUserProfileJSON(t *testing.T) {
+	db := newTestDB(t, &User{}, &UserProfile{})
+	alice := newUser(t, db, "alice")
+	bob := newUser(t, db, "bob")
+
+	// 写入旧的文本格式：逗号分隔的列表、已经是JSON的列表和非JSON的社交链接
+	if err := db.Exec("UPDATE user_profiles SET skills = ?, languages = ?, social_links = ? WHERE user_id = ?",
+		"Go, Python ,", `["中文"]`, "weibo.com/alice", alice.ID).Error; err != nil {
+		t.Fatalf("写入旧数据失败: %v", err)
+	}
+	if err := db.Exec("UPDATE user_profiles SET skills = ?, social_links = ? WHERE user_id = ?",
+		"", "  ", bob.ID).Error; err != nil {
+		t.Fatalf("写入旧数据失败: %v", err)
+	}
+
+	// 第二次执行时数据已是JSON，结果应保持不变
+	for run := 1; run <= 2; run++ {
+		if err := migrateUserProfileJSON(db); err != nil {
+			t.Fatalf("第%d次转换失败: %v", run, err)
+		}
+
+		profile := loadProfile(t, db, alice.ID)
+		if want := (StringList{"Go", "Python"}); !reflect.DeepEqual(profile.Skills, want) {
+			t.Errorf("第%d次转换后 skills 为 %v，期望 %v", run, profile.Skills, want)
+		}
+		if want := (StringList{"中文"}); !reflect.DeepEqual(profile.Languages, want) {
+			t.Errorf("第%d次转换后 languages 为 %v，期望 %v", run, profile.Languages, want)
+		}
+		if want := (SocialLinks{"other": "weibo.com/alice"}); !reflect.DeepEqual(profile.SocialLinks, want) {
+			t.Errorf("第%d次转换后 social_links 为 %v，期望 %v", run, profile.SocialLinks, want)
+		}
+
+		// 空文本转换为NULL
+		for _, column := range []string{"skills", "social_links"} {
+			if v := rawColumn(t, db, bob.ID, column); v != nil {
+				t.Errorf("第%d次转换后空的 %s 应为NULL，实际为 %q", run, column, *v)
+			}
+		}
+	}
+}
+
+func TestFindUsersBySocialPlatform(t *testing.T) {
+	db := newTestDB(t, &User{}, &UserProfile{})
+	service := NewUserService(db)
+	alice := newUser(t, db, "alice")
+	bob := newUser(t, db, "bob")
+	newUser(t, db, "carol")
+
+	links := map[uint]SocialLinks{
+		alice.ID: {"github": "https://github.com/alice", "weibo": "https://weibo.com/alice"},
+		bob.ID:   {"weibo": "https://weibo.com/bob"},
+	}
+	for userID, l := range links {
+		if err := service.UpdateUserProfile(userID, &UserProfile{SocialLinks: l}); err != nil {
+			t.Fatalf("更新用户资料失败: %v", err)
+		}
+	}
+
+	tests := []struct {
+		platform string
+		want     []string
+	}{
+		{"github", []string{"alice"}},
+		{"weibo", []string{"alice", "bob"}},
+		{"twitter", nil},
+	}
+	for _, tt := range tests {
+		users, err := service.FindUsersBySocialPlatform(tt.platform)
+		if err != nil {
+			t.Fatalf("按 %s 查询失败: %v", tt.platform, err)
+		}
+		var got []string
+		for _, u := range users {
+			got = append(got, u.Username)
+			if u.Profile == nil || u.Profile.SocialLinks[tt.platform] == "" {
+				t.Errorf("用户 %s 没有预加载包含 %s 的资料", u.Username, tt.platform)
+			}
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("按 %s 查询得到 %v，期望 %v", tt.platform, got, tt.want)
+		}
+	}
+
+	// 平台名会拼进JSON路径和LIKE模式，不合法时直接拒绝
+	for _, platform := range []string{"", `git"hub`, "git%", "GitHub"} {
+		if _, err := service.FindUsersBySocialPlatform(platform); err == nil {
+			t.Errorf("平台名 %q 应被拒绝", platform)
+		}
+	}
+}
+
+// backdate 把记录的 updated_at 改为一天前，便于判断 Touch 是否更新了它
+func backdate(t *testing.T, db *gorm.DB, model interface{}, id uint) time.Time {
+	t.Helper()
+	old := time.Now().Add(-24 * time.Hour).Truncate(time.Second)
+	if err := db.Model(model).Where("id = ?", id).UpdateColumn("updated_at", old).Error; err != nil {
+		t.Fatalf("修改更新时间失败: %v", err)
+	}
+	return old
+}
+
+// updatedAt 重新查询记录的 updated_at
+func updatedAt(t *testing.T, db *gorm.DB, model interface{}, id uint) time.Time {
+	t.Helper()
+	var value time.Time
+	if err := db.Model(model).Unscoped().Where("id = ?", id).Select("updated_at").Row().Scan(&value); err != nil {
+		t.Fatalf("查询更新时间失败: %v", err)
+	}
+	return value
+}
+
+func TestTouchSkipsUpdateHooks(t *testing.T) {
+	db := newTestDB(t, &User{}, &UserProfile{})
+	created := newUser(t, db, "alice")
+	if err := db.Model(&User{}).Where("id = ?", created.ID).UpdateColumn("post_count", 3).Error; err != nil {
+		t.Fatalf("修改文章数失败: %v", err)
+	}
+	old := backdate(t, db, &User{}, created.ID)
+
+	var user User
+	if err := db.First(&user, created.ID).Error; err != nil {
+		t.Fatalf("查询用户失败: %v", err)
+	}
+	// BeforeUpdate 会执行统计查询并改写传入模型的统计字段
+	var queries int
+	if err := db.Callback().Query().Before("gorm:query").Register("test:count_queries", func(*gorm.DB) { queries++ }); err != nil {
+		t.Fatalf("注册回调失败: %v", err)
+	}
+
+	if err := Touch(db, &user, user.ID); err != nil {
+		t.Fatalf("Touch 失败: %v", err)
+	}
+	if queries != 0 || user.PostCount != 3 {
+		t.Errorf("Touch 执行了 %d 次查询、文章数变为 %d，不应执行 BeforeUpdate", queries, user.PostCount)
+	}
+
+	var got User
+	if err := db.First(&got, user.ID).Error; err != nil {
+		t.Fatalf("查询用户失败: %v", err)
+	}
+	if !got.UpdatedAt.After(old) || got.PostCount != 3 {
+		t.Errorf("updated_at 为 %v、文章数为 %d，期望晚于 %v、文章数不变", got.UpdatedAt, got.PostCount, old)
+	}
+}
+
+func TestTouchParents(t *testing.T) {
+	db := newTestDB(t, &Category{}, &Post{}, &PostMeta{})
+	category := Category{Name: "Go", Slug: "go"}
+	if err := db.Create(&category).Error; err != nil {
+		t.Fatalf("创建分类失败: %v", err)
+	}
+	post := Post{Title: "Touch", Slug: "touch", Content: "正文", AuthorID: 1, CategoryID: &category.ID}
+	if err := db.Create(&post).Error; err != nil {
+		t.Fatalf("创建文章失败: %v", err)
+	}
+	meta := PostMeta{PostID: post.ID, MetaKey: "views"}
+	if err := db.Create(&meta).Error; err != nil {
+		t.Fatalf("创建文章元数据失败: %v", err)
+	}
+	old := backdate(t, db, &Category{}, category.ID)
+	backdate(t, db, &Post{}, post.ID)
+	backdate(t, db, &PostMeta{}, meta.ID)
+
+	// 不带 TouchParents 时只更新记录本身
+	if err := Touch(db, &PostMeta{}, meta.ID); err != nil {
+		t.Fatalf("Touch 失败: %v", err)
+	}
+	if !updatedAt(t, db, &PostMeta{}, meta.ID).After(old) {
+		t.Error("元数据的 updated_at 没有更新")
+	}
+	if got := updatedAt(t, db, &Post{}, post.ID); !got.Equal(old) {
+		t.Errorf("没有 TouchParents 时文章的 updated_at 变为 %v", got)
+	}
+
+	// 带 TouchParents 时逐级更新文章和分类，各级使用同一个时间
+	if err := Touch(db, &PostMeta{}, meta.ID, TouchParents()); err != nil {
+		t.Fatalf("Touch 失败: %v", err)
+	}
+	metaTime := updatedAt(t, db, &PostMeta{}, meta.ID)
+	postTime := updatedAt(t, db, &Post{}, post.ID)
+	categoryTime := updatedAt(t, db, &Category{}, category.ID)
+	if !metaTime.After(old) || !postTime.Equal(metaTime) || !categoryTime.Equal(metaTime) {
+		t.Errorf("各级 updated_at 为 %v、%v、%v，期望都等于同一个新时间", metaTime, postTime, categoryTime)
+	}
+
+	// 上级不存在（未分类的文章）或已删除时跳过
+	uncategorized := Post{Title: "未分类", Slug: "uncategorized", Content: "正文", AuthorID: 1}
+	if err := db.Create(&uncategorized).Error; err != nil {
+		t.Fatalf("创建文章失败: %v", err)
+	}
+	if err := Touch(db, &Post{}, uncategorized.ID, TouchParents()); err != nil {
+		t.Errorf("未分类的文章 Touch 失败: %v", err)
+	}
+	if err := db.Delete(&category).Error; err != nil {
+		t.Fatalf("删除分类失败: %v", err)
+	}
+	deletedAt := updatedAt(t, db, &Category{}, category.ID)
+	if err := Touch(db, &Post{}, post.ID, TouchParents()); err != nil {
+		t.Errorf("分类已删除时 Touch 失败: %v", err)
+	}
+	if got := updatedAt(t, db, &Category{}, category.ID); !got.Equal(deletedAt) {
+		t.Errorf("已删除分类的 updated_at 变为 %v", got)
+	}
+}
+
+func TestTouchNotFound(t *testing.T) {
+	db := newTestDB(t, &Category{}, &Post{})
+	post := Post{Title: "Touch", Slug: "touch", Content: "正文", AuthorID: 1}
+	if err := db.Create(&post).Error; err != nil {
+		t.Fatalf("创建文章失败: %v", err)
+	}
+	if err := db.Delete(&post).Error; err != nil {
+		t.Fatalf("删除文章失败: %v", err)
+	}
+
+	for _, id := range []uint{9999, post.ID} {
+		if err := Touch(db, &Post{}, id); !errors.Is(err, gorm.ErrRecordNotFound) {
+			t.Errorf("Touch(%d) 返回 %v，期望 gorm.ErrRecordNotFound", id, err)
+		}
+		if err := Touch(db, &Post{}, id, TouchParents()); !errors.Is(err, gorm.ErrRecordNotFound) {
+			t.Errorf("Touch(%d, TouchParents()) 返回 %v，期望 gorm.ErrRecordNotFound", id, err)
+		}
+	}
+}
@@ -1,12 +1,14 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
 	"time"
 
 	"gorm-advanced-exercises/exercise2_business_logic/services"
+	"gorm-advanced-exercises/exercise2_business_logic/models"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
@@ -56,23 +58,7 @@ func ConnectDatabase(config DatabaseConfig) (*gorm.DB, error) {
 // MigrateDatabase 迁移数据库
 func MigrateDatabase(db *gorm.DB) error {
 	// 自动迁移所有模型
-	err := db.AutoMigrate(
-		&User{},
-		&UserProfile{},
-		&Address{},
-		&Category{},
-		&Brand{},
-		&Product{},
-		&ProductImage{},
-		&ProductSKU{},
-		&ProductReview{},
-		&Cart{},
-		&Order{},
-		&OrderItem{},
-		&Payment{},
-		&Coupon{},
-		&UserCoupon{},
-	)
+	err := db.AutoMigrate(models.All()...)
 
 	if err != nil {
 		return fmt.Errorf("数据库迁移失败: %w", err)
@@ -87,7 +73,7 @@ func SeedTestData(db *gorm.DB) error {
 	fmt.Println("开始填充测试数据...")
 
 	// 创建用户
-	user := &User{
+	user := &models.User{
 		Username: "testuser",
 		Email:    "test@example.com",
 		Phone:    "13800138000",
@@ -100,7 +86,7 @@ func SeedTestData(db *gorm.DB) error {
 	}
 
 	// 创建用户资料
-	profile := &UserProfile{
+	profile := &models.UserProfile{
 		UserID:   user.ID,
 		RealName: "张三",
 		Company:  "测试公司",
@@ -111,7 +97,7 @@ func SeedTestData(db *gorm.DB) error {
 	}
 
 	// 创建收货地址
-	address := &Address{
+	address := &models.Address{
 		UserID:    user.ID,
 		Name:      "张三",
 		Phone:     "13800138000",
@@ -126,7 +112,7 @@ func SeedTestData(db *gorm.DB) error {
 	}
 
 	// 创建分类
-	category := &Category{
+	category := &models.Category{
 		Name:        "电子产品",
 		Slug:        "electronics",
 		Description: "各种电子产品",
@@ -137,7 +123,7 @@ func SeedTestData(db *gorm.DB) error {
 	}
 
 	// 创建品牌
-	brand := &Brand{
+	brand := &models.Brand{
 		Name:        "苹果",
 		Slug:        "apple",
 		Description: "苹果公司",
@@ -149,7 +135,7 @@ func SeedTestData(db *gorm.DB) error {
 	}
 
 	// 创建商品
-	product := &Product{
+	product := &models.Product{
 		Name:        "iPhone 15 Pro",
 		SKU:         "IPHONE15PRO",
 		Description: "最新款iPhone",
@@ -166,7 +152,7 @@ func SeedTestData(db *gorm.DB) error {
 	}
 
 	// 创建商品SKU
-	sku1 := &ProductSKU{
+	sku1 := &models.ProductSKU{
 		ProductID: product.ID,
 		SKU:       "IPHONE15PRO-128GB-BLACK",
 		Name:      "iPhone 15 Pro 128GB 深空黑色",
@@ -175,7 +161,7 @@ func SeedTestData(db *gorm.DB) error {
 		Specs:     json.RawMessage(`{"storage":"128GB","color":"深空黑色"}`),
 		Status:    1,
 	}
-	sku2 := &ProductSKU{
+	sku2 := &models.ProductSKU{
 		ProductID: product.ID,
 		SKU:       "IPHONE15PRO-256GB-BLACK",
 		Name:      "iPhone 15 Pro 256GB 深空黑色",
@@ -184,12 +170,12 @@ func SeedTestData(db *gorm.DB) error {
 		Specs:     json.RawMessage(`{"storage":"256GB","color":"深空黑色"}`),
 		Status:    1,
 	}
-	if err := db.Create([]*ProductSKU{sku1, sku2}).Error; err != nil {
+	if err := db.Create([]*models.ProductSKU{sku1, sku2}).Error; err != nil {
 		return fmt.Errorf("创建商品SKU失败: %w", err)
 	}
 
 	// 创建优惠券
-	coupon := &Coupon{
+	coupon := &models.Coupon{
 		Name:          "新用户专享",
 		Code:          "NEWUSER100",
 		Type:          1, // 满减
@@ -207,7 +193,7 @@ func SeedTestData(db *gorm.DB) error {
 	}
 
 	// 给用户发放优惠券
-	userCoupon := &UserCoupon{
+	userCoupon := &models.UserCoupon{
 		UserID:   user.ID,
 		CouponID: coupon.ID,
 		Status:   1, // 未使用
@@ -217,7 +203,7 @@ func SeedTestData(db *gorm.DB) error {
 	}
 
 	// 添加到购物车
-	cart := &Cart{
+	cart := &models.Cart{
 		UserID:    user.ID,
 		ProductID: product.ID,
 		SKUID:     &sku1.ID,
@@ -238,16 +224,16 @@ func demonstrateOrderService(db *gorm.DB) {
 	orderService := services.NewOrderService(db)
 
 	// 获取测试用户和地址
-	var user User
+	var user models.User
 	db.First(&user, "username = ?", "testuser")
 
-	var address Address
+	var address models.Address
 	db.First(&address, "user_id = ?", user.ID)
 
-	var sku ProductSKU
+	var sku models.ProductSKU
 	db.First(&sku, "sku = ?", "IPHONE15PRO-128GB-BLACK")
 
-	var coupon Coupon
+	var coupon models.Coupon
 	db.First(&coupon, "code = ?", "NEWUSER100")
 
 	// 创建订单请求
@@ -276,7 +262,7 @@ func demonstrateOrderService(db *gorm.DB) {
 	fmt.Printf("订单创建成功: %s, 订单金额: %.2f元\n", order.OrderNo, float64(order.PayAmount)/100)
 
 	// 查询订单详情
-	var orderDetail Order
+	var orderDetail models.Order
 	db.Preload("Items").Preload("User").Preload("Coupon").First(&orderDetail, order.ID)
 	fmt.Printf("订单详情: %+v\n", orderDetail)
 
@@ -303,8 +289,10 @@ func demonstrateStatisticsService(db *gorm.DB) {
 	startDate := time.Now().AddDate(0, 0, -7) // 最近7天
 	endDate := time.Now()
 
+	filter := services.NewStatFilter().DateRange(startDate, endDate)
+
 	fmt.Println("获取销售统计...")
-	salesStats, err := statisticsService.GetSalesStatistics(startDate, endDate)
+	salesStats, err := statisticsService.GetSalesStatistics(filter)
 	if err != nil {
 		fmt.Printf("获取销售统计失败: %v\n", err)
 	} else {
@@ -313,7 +301,7 @@ func demonstrateStatisticsService(db *gorm.DB) {
 
 	// 获取商品销量排行
 	fmt.Println("\n获取商品销量排行...")
-	productRank, err := statisticsService.GetProductSalesRank(startDate, endDate, 10)
+	productRank, err := statisticsService.GetProductSalesRank(filter, 10)
 	if err != nil {
 		fmt.Printf("获取商品销量排行失败: %v\n", err)
 	} else {
@@ -342,15 +330,16 @@ func demonstrateStatisticsService(db *gorm.DB) {
 // createTestOrders 创建测试订单数据
 func createTestOrders(db *gorm.DB) {
 	// 获取测试数据
-	var user User
+	var user models.User
 	db.First(&user, "username = ?", "testuser")
 
-	var product Product
+	var product models.Product
 	db.First(&product)
 
 	// 创建几个测试订单
 	for i := 0; i < 5; i++ {
-		order := &Order{
+		order := &models.Order{
+			BaseModel:       models.BaseModel{CreatedAt: time.Now().AddDate(0, 0, -i)}, // 不同日期
 			OrderNo:         fmt.Sprintf("TEST%d%d", time.Now().Unix(), i),
 			UserID:          user.ID,
 			Status:          4, // 已完成
@@ -359,12 +348,11 @@ func createTestOrders(db *gorm.DB) {
 			ReceiverName:    "测试用户",
 			ReceiverPhone:   "13800138000",
 			ReceiverAddress: "测试地址",
-			CreatedAt:       time.Now().AddDate(0, 0, -i), // 不同日期
 		}
 		db.Create(order)
 
 		// 创建订单项
-		orderItem := &OrderItem{
+		orderItem := &models.OrderItem{
 			OrderID:     order.ID,
 			ProductID:   product.ID,
 			Quantity:    i + 1,
@@ -383,7 +371,7 @@ func demonstrateComplexQueries(db *gorm.DB) {
 
 	// 1. 子查询：查找购买过商品的用户
 	fmt.Println("1. 查找购买过商品的用户:")
-	var users []User
+	var users []models.User
 	db.Where("id IN (?)", db.Table("orders").Select("DISTINCT user_id").Where("status >= ?", 2)).Find(&users)
 	fmt.Printf("购买过商品的用户数量: %d\n", len(users))
 
@@ -476,13 +464,24 @@ func main() {
 
 	// 检查是否需要填充测试数据
 	var userCount int64
-	db.Model(&User{}).Count(&userCount)
+	db.Model(&models.User{}).Count(&userCount)
 	if userCount == 0 {
 		if err := SeedTestData(db); err != nil {
 			log.Fatal("填充测试数据失败:", err)
 		}
 	}
 
+	// 启动过期库存预留清理任务
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	services.NewInventoryService(db).StartSweeper(ctx, time.Minute)
+
+	// 启动自动确认收货任务
+	services.NewOrderService(db).StartAutoCompleter(ctx, time.Hour)
+
+	// 启动发件箱投递任务，事件推送到设置中为该事件类型配置的Webhook地址
+	services.NewOutboxService(db).StartOutboxRelay(ctx, services.NewWebhookService(db).Dispatcher())
+
 	// 演示订单服务
 	demonstrateOrderService(db)
 
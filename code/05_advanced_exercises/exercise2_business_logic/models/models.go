@@ -1,4 +1,4 @@
-package main
+package models
 
 import (
 	"encoding/json"
@@ -138,7 +138,7 @@ type Product struct {
 	Price        int64           `gorm:"not null;comment:价格(分)" json:"price"`
 	MarketPrice  int64           `gorm:"comment:市场价(分)" json:"market_price"`
 	CostPrice    int64           `gorm:"comment:成本价(分)" json:"cost_price"`
-	Stock        int             `gorm:"default:0" json:"stock"`
+	Stock        int             `gorm:"default:0;comment:实际库存(支付后扣减)" json:"stock"`
 	Sales        int             `gorm:"default:0" json:"sales"`
 	Views        int             `gorm:"default:0" json:"views"`
 	Weight       float64         `gorm:"comment:重量(kg)" json:"weight"`
@@ -148,6 +148,8 @@ type Product struct {
 	Attributes   json.RawMessage `gorm:"type:json" json:"attributes"`
 	Status       int8            `gorm:"default:1;comment:1-上架,2-下架" json:"status"`
 	Sort         int             `gorm:"default:0" json:"sort"`
+	ReviewCount  int             `gorm:"default:0;comment:显示中的评价数" json:"review_count"`
+	AvgRating    float64         `gorm:"default:0;comment:显示中的评价平均分" json:"avg_rating"`
 	
 	// 关联关系
 	Category     Category       `gorm:"foreignKey:CategoryID" json:"category,omitempty"`
@@ -208,21 +210,23 @@ func (ProductSKU) TableName() string {
 }
 
 // ProductReview 商品评价
+// 每个订单项只能评价一次（user_id + order_item_id 唯一）
 type ProductReview struct {
 	BaseModel
-	ProductID uint   `gorm:"index;not null" json:"product_id"`
-	UserID    uint   `gorm:"index;not null" json:"user_id"`
-	OrderID   uint   `gorm:"index;not null" json:"order_id"`
-	Rating    int8   `gorm:"not null;comment:评分1-5" json:"rating"`
-	Content   string `gorm:"type:text" json:"content"`
-	Images    json.RawMessage `gorm:"type:json" json:"images"`
-	Reply     string `gorm:"type:text" json:"reply"`
-	Status    int8   `gorm:"default:1;comment:1-显示,2-隐藏" json:"status"`
+	ProductID   uint   `gorm:"index;not null" json:"product_id"`
+	UserID      uint   `gorm:"index;uniqueIndex:idx_review_user_item;not null" json:"user_id"`
+	OrderID     uint   `gorm:"index;not null" json:"order_id"`
+	OrderItemID uint   `gorm:"uniqueIndex:idx_review_user_item;not null" json:"order_item_id"`
+	Rating      int8   `gorm:"not null;comment:评分1-5" json:"rating"`
+	Content     string `gorm:"type:text" json:"content"`
+	Reply       string `gorm:"type:text" json:"reply"`
+	Status      int8   `gorm:"default:1;comment:1-显示,2-隐藏" json:"status"`
 	
 	// 关联关系
-	Product Product `gorm:"foreignKey:ProductID" json:"product,omitempty"`
-	User    User    `gorm:"foreignKey:UserID" json:"user,omitempty"`
-	Order   Order   `gorm:"foreignKey:OrderID" json:"order,omitempty"`
+	Product Product       `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+	User    User          `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	Order   Order         `gorm:"foreignKey:OrderID" json:"order,omitempty"`
+	Images  []ReviewImage `gorm:"foreignKey:ReviewID" json:"images,omitempty"`
 }
 
 // TableName 指定表名
@@ -230,6 +234,19 @@ func (ProductReview) TableName() string {
 	return "product_reviews"
 }
 
+// ReviewImage 评价图片，按Sort排列，每条评价最多9张
+type ReviewImage struct {
+	BaseModel
+	ReviewID uint   `gorm:"index;not null" json:"review_id"`
+	URL      string `gorm:"size:255;not null" json:"url"`
+	Sort     int    `gorm:"default:0" json:"sort"`
+}
+
+// TableName 指定表名
+func (ReviewImage) TableName() string {
+	return "review_images"
+}
+
 // Cart 购物车
 type Cart struct {
 	BaseModel
@@ -267,7 +284,8 @@ type Order struct {
 	ReceiverAddress string     `gorm:"size:255;not null" json:"receiver_address"`
 	Remark          string     `gorm:"type:text" json:"remark"`
 	PaidAt          *time.Time `json:"paid_at"`
-	ShippedAt       *time.Time `json:"shipped_at"`
+	TrackingNo      string     `gorm:"size:100;comment:物流单号" json:"tracking_no"`
+	ShippedAt       *time.Time `gorm:"index" json:"shipped_at"`
 	DeliveredAt     *time.Time `json:"delivered_at"`
 	FinishedAt      *time.Time `json:"finished_at"`
 	CancelTime      *time.Time `json:"cancel_time"`
@@ -376,4 +394,119 @@ type UserCoupon struct {
 // TableName 指定表名
 func (UserCoupon) TableName() string {
 	return "user_coupons"
-}
\ No newline at end of file
+}
+
+// StockReservation 库存预留
+// 下单时只创建预留，不扣减库存；支付后确认并扣减实际库存，超时未支付由清理任务释放
+// 可售库存 = 实际库存 - 未过期的预留中数量
+type StockReservation struct {
+	BaseModel
+	ProductID uint      `gorm:"index;not null" json:"product_id"`
+	SKUID     *uint     `gorm:"column:sku_id;index" json:"sku_id"`
+	OrderID   *uint     `gorm:"index" json:"order_id"`
+	Quantity  int       `gorm:"not null" json:"quantity"`
+	Status    int8      `gorm:"index;default:1;comment:1-预留中,2-已确认,3-已释放" json:"status"`
+	ExpiresAt time.Time `gorm:"index;not null" json:"expires_at"`
+
+	// 关联关系
+	Product Product     `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+	SKU     *ProductSKU `gorm:"foreignKey:SKUID" json:"sku,omitempty"`
+}
+
+// TableName 指定表名
+func (StockReservation) TableName() string {
+	return "stock_reservations"
+}
+
+// OrderNote 订单备注，记录系统或客服对订单的处理
+type OrderNote struct {
+	BaseModel
+	OrderID  uint   `gorm:"index;not null" json:"order_id"`
+	Operator string `gorm:"size:50;not null;comment:system-系统,其他为操作人" json:"operator"`
+	Content  string `gorm:"type:text;not null" json:"content"`
+
+	// 关联关系
+	Order Order `gorm:"foreignKey:OrderID" json:"order,omitempty"`
+}
+
+// TableName 指定表名
+func (OrderNote) TableName() string {
+	return "order_notes"
+}
+
+// OutboxEvent 事务发件箱事件
+// 与业务数据在同一事务中写入，由投递任务读取后发送通知或消息，保证业务提交后事件不丢失
+type OutboxEvent struct {
+	BaseModel
+	EventType   string     `gorm:"index;size:50;not null" json:"event_type"`
+	AggregateID uint       `gorm:"index;not null;comment:关联的业务ID，如订单ID" json:"aggregate_id"`
+	Payload     string     `gorm:"type:text" json:"payload"`
+	Status      int8       `gorm:"index;default:1;comment:1-待投递,2-已投递" json:"status"`
+	Attempts    int        `gorm:"default:0;comment:投递次数" json:"attempts"`
+	LastError   string     `gorm:"size:500;comment:最近一次投递失败原因" json:"last_error"`
+	DeliveredAt *time.Time `json:"delivered_at"`
+}
+
+// TableName 指定表名
+func (OutboxEvent) TableName() string {
+	return "outbox_events"
+}
+
+// WebhookDelivery Webhook投递记录，每次请求一条
+// 超过最大重试次数仍失败的投递最后一条记录状态为死信，需要人工处理
+type WebhookDelivery struct {
+	BaseModel
+	EventID    uint   `gorm:"index;not null;comment:发件箱事件ID" json:"event_id"`
+	EventType  string `gorm:"size:50;not null" json:"event_type"`
+	Endpoint   string `gorm:"size:255;not null" json:"endpoint"`
+	Attempt    int    `gorm:"not null;comment:第几次尝试，从1开始" json:"attempt"`
+	Status     int8   `gorm:"index;not null;comment:1-成功,2-失败,3-死信" json:"status"`
+	StatusCode int    `gorm:"comment:HTTP状态码，请求未发出时为0" json:"status_code"`
+	Error      string `gorm:"size:500" json:"error"`
+	DurationMs int64  `gorm:"comment:请求耗时(毫秒)" json:"duration_ms"`
+}
+
+// TableName 指定表名
+func (WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
+
+// Setting 系统设置，管理员可在后台修改
+type Setting struct {
+	BaseModel
+	Key         string `gorm:"uniqueIndex;size:100;not null" json:"key"`
+	Value       string `gorm:"type:text" json:"value"`
+	Description string `gorm:"size:255" json:"description"`
+}
+
+// TableName 指定表名
+func (Setting) TableName() string {
+	return "settings"
+}
+
+// All 返回需要迁移的全部模型
+func All() []interface{} {
+	return []interface{}{
+		&User{},
+		&UserProfile{},
+		&Address{},
+		&Category{},
+		&Brand{},
+		&Product{},
+		&ProductImage{},
+		&ProductSKU{},
+		&ProductReview{},
+		&ReviewImage{},
+		&Cart{},
+		&Order{},
+		&OrderItem{},
+		&Payment{},
+		&Coupon{},
+		&UserCoupon{},
+		&StockReservation{},
+		&OrderNote{},
+		&OutboxEvent{},
+		&WebhookDelivery{},
+		&Setting{},
+	}
+}
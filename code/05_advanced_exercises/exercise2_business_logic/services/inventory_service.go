@@ -0,0 +1,262 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm-advanced-exercises/exercise2_business_logic/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 库存预留状态
+const (
+	ReservationActive    int8 = 1 // 预留中
+	ReservationCommitted int8 = 2 // 已确认（已支付）
+	ReservationReleased  int8 = 3 // 已释放
+)
+
+// ErrReservationNotActive 预留已确认、已释放或已过期
+var ErrReservationNotActive = errors.New("库存预留已失效")
+
+// ErrInsufficientStock 可售库存不足
+var ErrInsufficientStock = errors.New("商品库存不足")
+
+// InventoryService 库存服务
+// 预留不改动库存，只记录一条预留中的StockReservation；可售库存为实际库存减去未过期的预留，
+// 确认（支付）时才扣减实际库存，释放时只修改预留状态
+type InventoryService struct {
+	db *gorm.DB
+}
+
+// NewInventoryService 创建库存服务实例
+func NewInventoryService(db *gorm.DB) *InventoryService {
+	return &InventoryService{
+		db: db,
+	}
+}
+
+// Reserve 预留商品库存，ttl后未确认的预留会被清理任务释放
+func (s *InventoryService) Reserve(productID uint, qty int, ttl time.Duration) (*models.StockReservation, error) {
+	return s.reserve(productID, nil, nil, qty, ttl)
+}
+
+// ReserveForOrder 为订单预留商品或SKU库存，skuID为nil时预留商品库存
+func (s *InventoryService) ReserveForOrder(orderID, productID uint, skuID *uint, qty int, ttl time.Duration) (*models.StockReservation, error) {
+	return s.reserve(productID, skuID, &orderID, qty, ttl)
+}
+
+func (s *InventoryService) reserve(productID uint, skuID, orderID *uint, qty int, ttl time.Duration) (*models.StockReservation, error) {
+	if qty <= 0 {
+		return nil, errors.New("预留数量必须大于0")
+	}
+
+	reservation := &models.StockReservation{
+		ProductID: productID,
+		SKUID:     skuID,
+		OrderID:   orderID,
+		Quantity:  qty,
+		Status:    ReservationActive,
+		ExpiresAt: time.Now().Add(ttl),
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		// 锁定库存行后再统计预留，同一商品的并发预留串行执行，不会超卖
+		available, err := s.available(tx.Clauses(clause.Locking{Strength: "UPDATE"}), productID, skuID)
+		if err != nil {
+			return err
+		}
+		if available < qty {
+			return ErrInsufficientStock
+		}
+
+		return tx.Create(reservation).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return reservation, nil
+}
+
+// AvailableStock 查询商品或SKU的可售库存（实际库存减去未过期的预留）
+func (s *InventoryService) AvailableStock(productID uint, skuID *uint) (int, error) {
+	return s.available(s.db, productID, skuID)
+}
+
+// available 计算可售库存，db带锁时在同一事务中锁定库存行
+func (s *InventoryService) available(db *gorm.DB, productID uint, skuID *uint) (int, error) {
+	var stock struct{ Stock int }
+	var err error
+	if skuID != nil {
+		err = db.Model(&models.ProductSKU{}).Select("stock").
+			Where("id = ? AND product_id = ?", *skuID, productID).Take(&stock).Error
+	} else {
+		err = db.Model(&models.Product{}).Select("stock").Where("id = ?", productID).Take(&stock).Error
+	}
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, errors.New("商品不存在")
+		}
+		return 0, err
+	}
+
+	held, err := s.heldQuantity(db.Session(&gorm.Session{NewDB: true}), productID, skuID)
+	if err != nil {
+		return 0, err
+	}
+	return stock.Stock - held, nil
+}
+
+// heldQuantity 统计未过期的预留中数量，已过期但尚未被清理的预留不再占用库存
+func (s *InventoryService) heldQuantity(db *gorm.DB, productID uint, skuID *uint) (int, error) {
+	query := db.Model(&models.StockReservation{}).
+		Where("product_id = ? AND status = ? AND expires_at > ?", productID, ReservationActive, time.Now())
+	if skuID != nil {
+		query = query.Where("sku_id = ?", *skuID)
+	} else {
+		query = query.Where("sku_id IS NULL")
+	}
+
+	var held int
+	err := query.Select("COALESCE(SUM(quantity), 0)").Scan(&held).Error
+	return held, err
+}
+
+// Commit 确认预留（支付成功），扣减实际库存
+func (s *InventoryService) Commit(reservationID uint) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		return s.finish(tx, reservationID, ReservationCommitted)
+	})
+}
+
+// Release 释放预留，预留数量重新计入可售库存
+func (s *InventoryService) Release(reservationID uint) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		return s.finish(tx, reservationID, ReservationReleased)
+	})
+}
+
+// CommitOrder 确认订单的所有预留，任一预留已过期或已释放时返回ErrReservationNotActive
+func (s *InventoryService) CommitOrder(orderID uint) error {
+	return s.finishOrder(orderID, ReservationCommitted)
+}
+
+// ReleaseOrder 释放订单仍在预留中的库存
+func (s *InventoryService) ReleaseOrder(orderID uint) error {
+	return s.finishOrder(orderID, ReservationReleased)
+}
+
+func (s *InventoryService) finishOrder(orderID uint, status int8) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var reservations []models.StockReservation
+		if err := tx.Where("order_id = ?", orderID).Find(&reservations).Error; err != nil {
+			return err
+		}
+
+		for _, r := range reservations {
+			if r.Status != ReservationActive {
+				// 释放时跳过已失效的预留；确认时任一预留失效则整单失败
+				if status == ReservationReleased {
+					continue
+				}
+				return ErrReservationNotActive
+			}
+			if status == ReservationCommitted && time.Now().After(r.ExpiresAt) {
+				return ErrReservationNotActive
+			}
+			if err := s.finish(tx, r.ID, status); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// finish 将预留从预留中改为确认或释放，确认时扣减实际库存
+func (s *InventoryService) finish(tx *gorm.DB, reservationID uint, status int8) error {
+	var reservation models.StockReservation
+	if err := tx.First(&reservation, reservationID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("库存预留不存在")
+		}
+		return err
+	}
+
+	// 带状态条件更新，避免清理任务与支付并发时重复处理，保证库存只扣减一次
+	result := tx.Model(&models.StockReservation{}).
+		Where("id = ? AND status = ?", reservationID, ReservationActive).
+		Update("status", status)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrReservationNotActive
+	}
+
+	if status != ReservationCommitted {
+		return nil
+	}
+
+	var deduct *gorm.DB
+	if reservation.SKUID != nil {
+		deduct = tx.Model(&models.ProductSKU{}).Where("id = ? AND stock >= ?", *reservation.SKUID, reservation.Quantity)
+	} else {
+		deduct = tx.Model(&models.Product{}).Where("id = ? AND stock >= ?", reservation.ProductID, reservation.Quantity)
+	}
+	result = deduct.UpdateColumn("stock", gorm.Expr("stock - ?", reservation.Quantity))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrInsufficientStock
+	}
+	return nil
+}
+
+// ReleaseExpired 释放所有已过期的预留，返回释放的数量
+func (s *InventoryService) ReleaseExpired() (int, error) {
+	var reservations []models.StockReservation
+	err := s.db.Where("status = ? AND expires_at <= ?", ReservationActive, time.Now()).
+		Find(&reservations).Error
+	if err != nil {
+		return 0, err
+	}
+
+	released := 0
+	for _, r := range reservations {
+		err := s.Release(r.ID)
+		if errors.Is(err, ErrReservationNotActive) {
+			continue // 已被支付确认或其他任务释放
+		}
+		if err != nil {
+			return released, fmt.Errorf("释放库存预留%d失败: %w", r.ID, err)
+		}
+		released++
+	}
+	return released, nil
+}
+
+// StartSweeper 启动过期预留清理任务，每隔interval执行一次，ctx取消时退出
+func (s *InventoryService) StartSweeper(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if n, err := s.ReleaseExpired(); err != nil {
+					log.Printf("释放过期库存预留失败: %v", err)
+				} else if n > 0 {
+					log.Printf("已释放%d个过期库存预留", n)
+				}
+			}
+		}
+	}()
+}
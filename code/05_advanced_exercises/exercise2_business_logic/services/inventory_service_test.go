@@ -0,0 +1,154 @@
+package services
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"gorm-advanced-exercises/exercise2_business_logic/models"
+	"gorm.io/gorm"
+)
+
+// TestReservationExpiry 过期的预留立即不再占用可售库存，清理任务把它标记为已释放；
+// 订单的预留过期后不能再确认，实际库存不变
+func TestReservationExpiry(t *testing.T) {
+	db := newTestDB(t)
+	inventory := NewInventoryService(db)
+	product := createProduct(t, db, 5)
+
+	held, err := inventory.Reserve(product.ID, 3, time.Hour)
+	if err != nil {
+		t.Fatalf("预留库存失败: %v", err)
+	}
+	assertAvailable(t, inventory, product.ID, 2)
+	if _, err := inventory.Reserve(product.ID, 3, time.Hour); !errors.Is(err, ErrInsufficientStock) {
+		t.Fatalf("超出可售库存的预留返回 %v，期望 ErrInsufficientStock", err)
+	}
+
+	// 还没被清理的过期预留同样不占库存
+	if err := db.Model(held).Update("expires_at", time.Now().Add(-time.Minute)).Error; err != nil {
+		t.Fatalf("修改过期时间失败: %v", err)
+	}
+	assertAvailable(t, inventory, product.ID, 5)
+
+	orderID := uint(1001)
+	if _, err := inventory.ReserveForOrder(orderID, product.ID, nil, 2, -time.Minute); err != nil {
+		t.Fatalf("为订单预留库存失败: %v", err)
+	}
+	if err := inventory.CommitOrder(orderID); !errors.Is(err, ErrReservationNotActive) {
+		t.Errorf("确认已过期的订单预留返回 %v，期望 ErrReservationNotActive", err)
+	}
+
+	released, err := inventory.ReleaseExpired()
+	if err != nil {
+		t.Fatalf("释放过期预留失败: %v", err)
+	}
+	if released != 2 {
+		t.Errorf("释放了 %d 个预留，期望 2", released)
+	}
+	var active int64
+	db.Model(&models.StockReservation{}).Where("status = ?", ReservationActive).Count(&active)
+	if active != 0 {
+		t.Errorf("仍有 %d 个预留中的记录", active)
+	}
+	if again, _ := inventory.ReleaseExpired(); again != 0 {
+		t.Errorf("再次清理释放了 %d 个预留，期望 0", again)
+	}
+	assertStock(t, db, product.ID, 5)
+}
+
+// TestReserveConcurrently 并发预留同一商品，成功的数量恰好等于库存，其余返回 ErrInsufficientStock
+func TestReserveConcurrently(t *testing.T) {
+	db := newTestDB(t)
+	inventory := NewInventoryService(db)
+	product := createProduct(t, db, 5)
+
+	const workers = 20
+	errs := make(chan error, workers)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := inventory.Reserve(product.ID, 1, time.Hour)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	succeeded := 0
+	for err := range errs {
+		switch {
+		case err == nil:
+			succeeded++
+		case !errors.Is(err, ErrInsufficientStock):
+			t.Errorf("预留失败: %v", err)
+		}
+	}
+	if succeeded != 5 {
+		t.Errorf("成功预留 %d 次，期望 5", succeeded)
+	}
+	assertAvailable(t, inventory, product.ID, 0)
+}
+
+// TestCommitRacesRelease 支付确认与清理释放同时处理同一预留，只有一个生效，库存最多扣减一次
+func TestCommitRacesRelease(t *testing.T) {
+	db := newTestDB(t)
+	inventory := NewInventoryService(db)
+	product := createProduct(t, db, 5)
+
+	reservation, err := inventory.Reserve(product.ID, 2, time.Hour)
+	if err != nil {
+		t.Fatalf("预留库存失败: %v", err)
+	}
+
+	var commitErr, releaseErr error
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		commitErr = inventory.Commit(reservation.ID)
+	}()
+	go func() {
+		defer wg.Done()
+		releaseErr = inventory.Release(reservation.ID)
+	}()
+	wg.Wait()
+
+	// 确认生效时扣减2件，释放生效时库存不变；两种情况下预留都不再占用可售库存
+	want := 0
+	switch {
+	case commitErr == nil && errors.Is(releaseErr, ErrReservationNotActive):
+		want = 3
+	case releaseErr == nil && errors.Is(commitErr, ErrReservationNotActive):
+		want = 5
+	default:
+		t.Fatalf("确认返回 %v，释放返回 %v，期望恰好一个成功", commitErr, releaseErr)
+	}
+	assertStock(t, db, product.ID, want)
+	assertAvailable(t, inventory, product.ID, want)
+}
+
+func assertAvailable(t *testing.T, inventory *InventoryService, productID uint, want int) {
+	t.Helper()
+	got, err := inventory.AvailableStock(productID, nil)
+	if err != nil {
+		t.Fatalf("查询可售库存失败: %v", err)
+	}
+	if got != want {
+		t.Errorf("可售库存为 %d，期望 %d", got, want)
+	}
+}
+
+func assertStock(t *testing.T, db *gorm.DB, productID uint, want int) {
+	t.Helper()
+	var product models.Product
+	if err := db.First(&product, productID).Error; err != nil {
+		t.Fatalf("查询商品失败: %v", err)
+	}
+	if product.Stock != want {
+		t.Errorf("实际库存为 %d，期望 %d", product.Stock, want)
+	}
+}
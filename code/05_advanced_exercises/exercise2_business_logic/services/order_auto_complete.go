@@ -0,0 +1,115 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm-advanced-exercises/exercise2_business_logic/models"
+	"gorm.io/gorm"
+)
+
+// autoCompleteBatchSize 自动确认收货每批处理的订单数
+const autoCompleteBatchSize = 100
+
+// AutoCompleteOrders 自动确认收货
+// 发货超过设置天数（order.auto_complete_days）仍未确认收货的订单改为已完成，
+// 按批处理，每个订单单独提交事务；用户同时手动确认收货时以先提交的为准
+func (s *OrderService) AutoCompleteOrders() (int, error) {
+	days, err := NewSettingsService(s.db).GetInt(SettingOrderAutoCompleteDays)
+	if err != nil {
+		return 0, fmt.Errorf("读取自动确认收货天数失败: %w", err)
+	}
+	if days <= 0 {
+		return 0, nil // 未开启自动确认收货
+	}
+	cutoff := time.Now().AddDate(0, 0, -days)
+
+	completed := 0
+	var lastID uint
+	for {
+		var orderIDs []uint
+		err := s.db.Model(&models.Order{}).
+			Where("status = ? AND shipped_at <= ? AND id > ?", 3, cutoff, lastID).
+			Order("id").Limit(autoCompleteBatchSize).
+			Pluck("id", &orderIDs).Error
+		if err != nil {
+			return completed, err
+		}
+		if len(orderIDs) == 0 {
+			return completed, nil
+		}
+		lastID = orderIDs[len(orderIDs)-1]
+
+		for _, orderID := range orderIDs {
+			ok, err := s.autoComplete(orderID, cutoff, days)
+			if err != nil {
+				return completed, fmt.Errorf("自动确认收货订单%d失败: %w", orderID, err)
+			}
+			if ok {
+				completed++
+			}
+		}
+	}
+}
+
+// autoComplete 自动确认收货单个订单，订单已被用户确认或状态已变化时返回false
+func (s *OrderService) autoComplete(orderID uint, cutoff time.Time, days int) (bool, error) {
+	completed := false
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		// 带状态和发货时间条件更新，用户已手动确认收货时不会重复处理
+		now := time.Now()
+		result := tx.Model(&models.Order{}).
+			Where("id = ? AND status = ? AND shipped_at <= ?", orderID, 3, cutoff).
+			Updates(map[string]interface{}{
+				"status":      4, // 已完成
+				"finished_at": &now,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		if err := tx.Create(&models.OrderNote{
+			OrderID:  orderID,
+			Operator: "system",
+			Content:  fmt.Sprintf("发货%d天未确认收货，系统自动确认收货", days),
+		}).Error; err != nil {
+			return err
+		}
+		if err := addOutboxEvent(tx, EventOrderCompleted, orderID, map[string]interface{}{
+			"auto":        true,
+			"finished_at": now,
+		}); err != nil {
+			return err
+		}
+
+		completed = true
+		return nil
+	})
+	return completed, err
+}
+
+// StartAutoCompleter 启动自动确认收货任务，每隔interval执行一次，ctx取消时退出
+func (s *OrderService) StartAutoCompleter(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if n, err := s.AutoCompleteOrders(); err != nil {
+					log.Printf("自动确认收货失败: %v", err)
+				} else if n > 0 {
+					log.Printf("已自动确认收货%d个订单", n)
+				}
+			}
+		}
+	}()
+}
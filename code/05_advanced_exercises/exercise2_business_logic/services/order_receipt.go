@@ -0,0 +1,97 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"gorm-advanced-exercises/exercise2_business_logic/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 确认收货的错误
+var (
+	ErrOrderNotFound      = errors.New("订单不存在")
+	ErrOrderForbidden     = errors.New("无权操作该订单")
+	ErrOrderNotReceivable = errors.New("订单状态不允许确认收货")
+)
+
+// EventOrderReviewPrompt 订单完成后提醒买家评价未评价的商品
+const EventOrderReviewPrompt = "order.review_prompt"
+
+// ReviewPromptItem 评价提醒中待评价的商品
+type ReviewPromptItem struct {
+	ProductID   uint   `json:"product_id"`
+	ProductName string `json:"product_name"`
+}
+
+// ConfirmReceipt 买家确认收货，待收货的订单改为已完成，并提醒评价尚未评价过的商品
+// 订单已完成时直接返回，重复调用不会报错，也不会重复发送评价提醒
+func (s *OrderService) ConfirmReceipt(orderID, userID uint) (*models.Order, error) {
+	var order models.Order
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		// 锁定订单，与自动确认收货任务并发时只有一方生效
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&order, orderID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrOrderNotFound
+			}
+			return err
+		}
+		if order.UserID != userID {
+			return ErrOrderForbidden
+		}
+		if order.Status == 4 {
+			return nil // 已完成
+		}
+		if order.Status != 3 {
+			return ErrOrderNotReceivable
+		}
+
+		now := time.Now()
+		result := tx.Model(&models.Order{}).Where("id = ? AND status = ?", orderID, 3).Updates(map[string]interface{}{
+			"status":      4, // 已完成
+			"finished_at": &now,
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrOrderNotReceivable
+		}
+		order.Status, order.FinishedAt = 4, &now
+
+		if err := addOutboxEvent(tx, EventOrderCompleted, order.ID, map[string]interface{}{
+			"auto":        false,
+			"finished_at": now,
+		}); err != nil {
+			return err
+		}
+
+		items, err := s.reviewPromptItems(tx, order.ID, userID)
+		if err != nil || len(items) == 0 {
+			return err
+		}
+		return addOutboxEvent(tx, EventOrderReviewPrompt, order.ID, map[string]interface{}{
+			"order_no": order.OrderNo,
+			"user_id":  userID,
+			"products": items,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+// reviewPromptItems 查询订单中用户尚未评价过的商品
+func (s *OrderService) reviewPromptItems(db *gorm.DB, orderID, userID uint) ([]ReviewPromptItem, error) {
+	var items []ReviewPromptItem
+	err := db.Table("order_items oi").
+		Select("oi.product_id, MIN(oi.product_name) as product_name").
+		Joins("LEFT JOIN product_reviews pr ON pr.product_id = oi.product_id AND pr.user_id = ? AND pr.deleted_at IS NULL", userID).
+		Where("oi.order_id = ? AND oi.deleted_at IS NULL AND pr.id IS NULL", orderID).
+		Group("oi.product_id").
+		Order("oi.product_id").
+		Scan(&items).Error
+	return items, err
+}
@@ -4,9 +4,9 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"sync"
 	"time"
 
+	"gorm-advanced-exercises/exercise2_business_logic/models"
 	"gorm.io/gorm"
 )
 
@@ -26,10 +26,12 @@ type CreateOrderItemRequest struct {
 	Quantity  int  `json:"quantity" binding:"required,min=1"`
 }
 
+// orderPaymentTimeout 订单支付时限，超时后预留的库存会被释放
+const orderPaymentTimeout = 30 * time.Minute
+
 // OrderService 订单服务
 type OrderService struct {
 	db *gorm.DB
-	mu sync.RWMutex // 用于并发控制
 }
 
 // NewOrderService 创建订单服务实例
@@ -40,7 +42,7 @@ func NewOrderService(db *gorm.DB) *OrderService {
 }
 
 // CreateOrder 创建订单
-func (s *OrderService) CreateOrder(req *CreateOrderRequest) (*Order, error) {
+func (s *OrderService) CreateOrder(req *CreateOrderRequest) (*models.Order, error) {
 	// 参数验证
 	if err := s.validateCreateOrderRequest(req); err != nil {
 		return nil, fmt.Errorf("参数验证失败: %w", err)
@@ -93,7 +95,7 @@ func (s *OrderService) CreateOrder(req *CreateOrderRequest) (*Order, error) {
 	}
 
 	// 创建订单
-	order := &Order{
+	order := &models.Order{
 		OrderNo:         s.generateOrderNo(),
 		UserID:          req.UserID,
 		Status:          1, // 待付款
@@ -115,7 +117,7 @@ func (s *OrderService) CreateOrder(req *CreateOrderRequest) (*Order, error) {
 
 	// 创建订单项
 	for _, item := range validatedItems {
-		orderItem := &OrderItem{
+		orderItem := &models.OrderItem{
 			OrderID:      order.ID,
 			ProductID:    item.ProductID,
 			SKUID:        item.SKUID,
@@ -134,11 +136,12 @@ func (s *OrderService) CreateOrder(req *CreateOrderRequest) (*Order, error) {
 		}
 	}
 
-	// 扣减库存
+	// 预留库存：下单不扣减库存，支付时确认预留后才扣减
+	inventory := NewInventoryService(tx)
 	for _, item := range validatedItems {
-		if err := s.deductStock(tx, item.ProductID, item.SKUID, item.Quantity); err != nil {
+		if _, err := inventory.ReserveForOrder(order.ID, item.ProductID, item.SKUID, item.Quantity, orderPaymentTimeout); err != nil {
 			tx.Rollback()
-			return nil, fmt.Errorf("扣减库存失败: %w", err)
+			return nil, fmt.Errorf("预留库存失败: %w", err)
 		}
 	}
 
@@ -148,6 +151,16 @@ func (s *OrderService) CreateOrder(req *CreateOrderRequest) (*Order, error) {
 		return nil, fmt.Errorf("清空购物车失败: %w", err)
 	}
 
+	// 订单事件与订单一起提交，由发件箱投递任务发出
+	if err := addOutboxEvent(tx, EventOrderCreated, order.ID, map[string]interface{}{
+		"order_no":   order.OrderNo,
+		"user_id":    order.UserID,
+		"pay_amount": order.PayAmount,
+	}); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("写入订单事件失败: %w", err)
+	}
+
 	// 提交事务
 	if err := tx.Commit().Error; err != nil {
 		return nil, fmt.Errorf("提交事务失败: %w", err)
@@ -187,8 +200,8 @@ func (s *OrderService) validateCreateOrderRequest(req *CreateOrderRequest) error
 }
 
 // validateAddress 验证收货地址
-func (s *OrderService) validateAddress(tx *gorm.DB, userID, addressID uint) (*Address, error) {
-	var address Address
+func (s *OrderService) validateAddress(tx *gorm.DB, userID, addressID uint) (*models.Address, error) {
+	var address models.Address
 	err := tx.Where("id = ? AND user_id = ?", addressID, userID).First(&address).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -219,7 +232,7 @@ func (s *OrderService) validateAndCalculateItems(tx *gorm.DB, items []CreateOrde
 	for _, item := range items {
 		if item.SKUID != nil {
 			// 验证SKU
-			var sku ProductSKU
+			var sku models.ProductSKU
 			err := tx.Preload("Product").Where("id = ? AND product_id = ? AND status = 1", *item.SKUID, item.ProductID).First(&sku).Error
 			if err != nil {
 				if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -228,9 +241,13 @@ func (s *OrderService) validateAndCalculateItems(tx *gorm.DB, items []CreateOrde
 				return nil, 0, err
 			}
 
-			// 检查库存
-			if sku.Stock < item.Quantity {
-				return nil, 0, fmt.Errorf("商品 %s 库存不足，当前库存：%d", sku.Product.Name, sku.Stock)
+			// 检查可售库存，下单时会在锁定库存行后再次校验
+			available, err := NewInventoryService(tx).AvailableStock(item.ProductID, item.SKUID)
+			if err != nil {
+				return nil, 0, err
+			}
+			if available < item.Quantity {
+				return nil, 0, fmt.Errorf("商品 %s 库存不足，当前可售库存：%d", sku.Product.Name, available)
 			}
 
 			validatedItem := ValidatedOrderItem{
@@ -247,7 +264,7 @@ func (s *OrderService) validateAndCalculateItems(tx *gorm.DB, items []CreateOrde
 			totalAmount += sku.Price * int64(item.Quantity)
 		} else {
 			// 验证商品
-			var product Product
+			var product models.Product
 			err := tx.Where("id = ? AND status = 1", item.ProductID).First(&product).Error
 			if err != nil {
 				if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -256,9 +273,13 @@ func (s *OrderService) validateAndCalculateItems(tx *gorm.DB, items []CreateOrde
 				return nil, 0, err
 			}
 
-			// 检查库存
-			if product.Stock < item.Quantity {
-				return nil, 0, fmt.Errorf("商品 %s 库存不足，当前库存：%d", product.Name, product.Stock)
+			// 检查可售库存，下单时会在锁定库存行后再次校验
+			available, err := NewInventoryService(tx).AvailableStock(item.ProductID, nil)
+			if err != nil {
+				return nil, 0, err
+			}
+			if available < item.Quantity {
+				return nil, 0, fmt.Errorf("商品 %s 库存不足，当前可售库存：%d", product.Name, available)
 			}
 
 			validatedItem := ValidatedOrderItem{
@@ -282,7 +303,7 @@ func (s *OrderService) validateAndCalculateItems(tx *gorm.DB, items []CreateOrde
 // validateAndUseCoupon 验证优惠券的有效性、计算折扣并更新优惠券使用状态
 func (s *OrderService) validateAndUseCoupon(tx *gorm.DB, userID, couponID uint, orderAmount int64) (int64, error) {
 	// 检查用户是否拥有该优惠券
-	var userCoupon UserCoupon
+	var userCoupon models.UserCoupon
 	err := tx.Preload("Coupon").Where("user_id = ? AND coupon_id = ? AND status = 1", userID, couponID).First(&userCoupon).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -342,7 +363,7 @@ func (s *OrderService) validateAndUseCoupon(tx *gorm.DB, userID, couponID uint,
 }
 
 // calculateFreight 计算运费
-func (s *OrderService) calculateFreight(address *Address, items []ValidatedOrderItem) int64 {
+func (s *OrderService) calculateFreight(address *models.Address, items []ValidatedOrderItem) int64 {
 	// 简单的运费计算逻辑，实际项目中可能需要更复杂的计算
 	// 这里假设：
 	// 1. 订单金额超过100元免运费
@@ -369,36 +390,6 @@ func (s *OrderService) calculateFreight(address *Address, items []ValidatedOrder
 	}
 }
 
-// deductStock 并发安全地扣减商品或SKU库存
-func (s *OrderService) deductStock(tx *gorm.DB, productID uint, skuID *uint, quantity int) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	if skuID != nil {
-		// 扣减SKU库存
-		result := tx.Model(&ProductSKU{}).Where("id = ? AND stock >= ?", *skuID, quantity).
-			UpdateColumn("stock", gorm.Expr("stock - ?", quantity))
-		if result.Error != nil {
-			return result.Error
-		}
-		if result.RowsAffected == 0 {
-			return errors.New("SKU库存不足")
-		}
-	} else {
-		// 扣减商品库存
-		result := tx.Model(&Product{}).Where("id = ? AND stock >= ?", productID, quantity).
-			UpdateColumn("stock", gorm.Expr("stock - ?", quantity))
-		if result.Error != nil {
-			return result.Error
-		}
-		if result.RowsAffected == 0 {
-			return errors.New("商品库存不足")
-		}
-	}
-
-	return nil
-}
-
 // clearCart 清空购物车中对应的商品
 func (s *OrderService) clearCart(tx *gorm.DB, userID uint, items []CreateOrderItemRequest) error {
 	for _, item := range items {
@@ -409,7 +400,7 @@ func (s *OrderService) clearCart(tx *gorm.DB, userID uint, items []CreateOrderIt
 			query = query.Where("sku_id IS NULL")
 		}
 
-		if err := query.Delete(&Cart{}).Error; err != nil {
+		if err := query.Delete(&models.Cart{}).Error; err != nil {
 			return err
 		}
 	}
@@ -436,7 +427,7 @@ func (s *OrderService) CancelOrder(orderID uint, userID uint, reason string) err
 	}()
 
 	// 查询订单
-	var order Order
+	var order models.Order
 	err := tx.Preload("Items").Where("id = ? AND user_id = ?", orderID, userID).First(&order).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -465,12 +456,10 @@ func (s *OrderService) CancelOrder(orderID uint, userID uint, reason string) err
 		return fmt.Errorf("更新订单状态失败: %w", err)
 	}
 
-	// 回滚库存
-	for _, item := range order.Items {
-		if err := s.rollbackStock(tx, item.ProductID, item.SKUID, item.Quantity); err != nil {
-			tx.Rollback()
-			return fmt.Errorf("回滚库存失败: %w", err)
-		}
+	// 释放库存预留，未支付的订单没有扣减过库存
+	if err := NewInventoryService(tx).ReleaseOrder(order.ID); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("释放库存预留失败: %w", err)
 	}
 
 	// 回滚优惠券
@@ -489,34 +478,124 @@ func (s *OrderService) CancelOrder(orderID uint, userID uint, reason string) err
 	return nil
 }
 
-// rollbackStock 回滚库存
-func (s *OrderService) rollbackStock(tx *gorm.DB, productID uint, skuID *uint, quantity int) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+// PayOrder 支付订单，确认下单时预留的库存
+// 预留已超时释放的订单不能再支付
+func (s *OrderService) PayOrder(orderID, userID uint, paymentMethod string) error {
+	// 开始事务
+	tx := s.db.Begin()
+	if tx.Error != nil {
+		return fmt.Errorf("开始事务失败: %w", tx.Error)
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		}
+	}()
 
-	if skuID != nil {
-		// 回滚SKU库存
-		err := tx.Model(&ProductSKU{}).Where("id = ?", *skuID).
-			UpdateColumn("stock", gorm.Expr("stock + ?", quantity)).Error
-		if err != nil {
-			return err
+	// 查询订单
+	var order models.Order
+	err := tx.Where("id = ? AND user_id = ?", orderID, userID).First(&order).Error
+	if err != nil {
+		tx.Rollback()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("订单不存在")
 		}
-	} else {
-		// 回滚商品库存
-		err := tx.Model(&Product{}).Where("id = ?", productID).
-			UpdateColumn("stock", gorm.Expr("stock + ?", quantity)).Error
-		if err != nil {
-			return err
+		return err
+	}
+
+	// 检查订单状态
+	if order.Status != 1 { // 只有待付款状态的订单可以支付
+		tx.Rollback()
+		return errors.New("订单状态不允许支付")
+	}
+
+	// 确认库存预留
+	if err := NewInventoryService(tx).CommitOrder(order.ID); err != nil {
+		tx.Rollback()
+		if errors.Is(err, ErrReservationNotActive) {
+			return errors.New("订单已超时，库存已释放")
 		}
+		return fmt.Errorf("确认库存预留失败: %w", err)
+	}
+
+	// 更新订单状态
+	now := time.Now()
+	result := tx.Model(&models.Order{}).Where("id = ? AND status = ?", order.ID, 1).Updates(map[string]interface{}{
+		"status":         2, // 待发货
+		"payment_method": paymentMethod,
+		"paid_at":        &now,
+	})
+	if result.Error != nil {
+		tx.Rollback()
+		return fmt.Errorf("更新订单状态失败: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		tx.Rollback()
+		return errors.New("订单状态不允许支付")
+	}
+
+	if err := addOutboxEvent(tx, EventOrderPaid, order.ID, map[string]interface{}{
+		"order_no":       order.OrderNo,
+		"user_id":        order.UserID,
+		"payment_method": paymentMethod,
+		"paid_at":        now,
+	}); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("写入订单事件失败: %w", err)
+	}
+
+	// 提交事务
+	if err := tx.Commit().Error; err != nil {
+		return fmt.Errorf("提交事务失败: %w", err)
 	}
 
 	return nil
 }
 
+// ShipOrder 订单发货，待发货的订单改为待收货并记录物流单号，同时通知买家
+func (s *OrderService) ShipOrder(orderID uint, trackingNo string) error {
+	if trackingNo == "" {
+		return errors.New("物流单号不能为空")
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var order models.Order
+		if err := tx.First(&order, orderID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("订单不存在")
+			}
+			return err
+		}
+
+		// 带状态条件更新，避免重复发货或与取消、退款并发
+		now := time.Now()
+		result := tx.Model(&models.Order{}).Where("id = ? AND status = ?", orderID, 2).Updates(map[string]interface{}{
+			"status":      3, // 待收货
+			"tracking_no": trackingNo,
+			"shipped_at":  &now,
+		})
+		if result.Error != nil {
+			return fmt.Errorf("更新订单状态失败: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("订单状态不允许发货")
+		}
+
+		// 买家通知由发件箱投递任务发送
+		return addOutboxEvent(tx, EventOrderShipped, order.ID, map[string]interface{}{
+			"order_no":    order.OrderNo,
+			"user_id":     order.UserID,
+			"tracking_no": trackingNo,
+			"shipped_at":  now,
+		})
+	})
+}
+
 // rollbackCoupon 回滚优惠券
 func (s *OrderService) rollbackCoupon(tx *gorm.DB, userID, couponID uint) error {
 	// 查找用户优惠券记录
-	var userCoupon UserCoupon
+	var userCoupon models.UserCoupon
 	err := tx.Where("user_id = ? AND coupon_id = ? AND status = 2", userID, couponID).First(&userCoupon).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -535,7 +614,7 @@ func (s *OrderService) rollbackCoupon(tx *gorm.DB, userID, couponID uint) error
 	}
 
 	// 减少优惠券使用数量
-	err = tx.Model(&Coupon{}).Where("id = ?", couponID).
+	err = tx.Model(&models.Coupon{}).Where("id = ?", couponID).
 		UpdateColumn("used_quantity", gorm.Expr("used_quantity - ?", 1)).Error
 	if err != nil {
 		return err
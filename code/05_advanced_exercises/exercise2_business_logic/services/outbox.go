@@ -0,0 +1,153 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"gorm-advanced-exercises/exercise2_business_logic/models"
+	"gorm.io/gorm"
+)
+
+// 发件箱事件类型
+const (
+	EventOrderCreated   = "order.created"   // 订单已创建
+	EventOrderPaid      = "order.paid"      // 订单已支付，通知商家发货
+	EventOrderShipped   = "order.shipped"   // 订单已发货，通知买家
+	EventOrderCompleted = "order.completed" // 订单已完成
+)
+
+// 发件箱事件状态
+const (
+	OutboxPending   int8 = 1 // 待投递
+	OutboxDelivered int8 = 2 // 已投递
+)
+
+const (
+	outboxRelayBatchSize = 100         // 投递任务每批读取的事件数
+	outboxRelayInterval  = time.Second // 投递任务轮询间隔
+)
+
+// WriteOutbox 在业务事务中写入发件箱事件，事件与业务数据一起提交，事务回滚时事件一并丢弃
+// 不关联具体业务记录的事件使用它；关联订单等记录的事件使用addOutboxEvent记录业务ID
+func WriteOutbox(tx *gorm.DB, eventType string, payload interface{}) error {
+	return addOutboxEvent(tx, eventType, 0, payload)
+}
+
+// addOutboxEvent 在业务事务中写入发件箱事件，事务回滚时事件一并丢弃
+func addOutboxEvent(tx *gorm.DB, eventType string, aggregateID uint, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	return tx.Create(&models.OutboxEvent{
+		EventType:   eventType,
+		AggregateID: aggregateID,
+		Payload:     string(data),
+		Status:      OutboxPending,
+	}).Error
+}
+
+// OutboxDispatcher 发件箱事件投递器，如进程内事件总线、Webhook
+// 返回nil表示投递成功。投递成功但标记失败（如进程崩溃）时事件会再次投递，接收方需按事件ID去重
+type OutboxDispatcher interface {
+	Dispatch(ctx context.Context, event models.OutboxEvent) error
+}
+
+// OutboxDispatcherFunc 让普通函数实现OutboxDispatcher
+type OutboxDispatcherFunc func(ctx context.Context, event models.OutboxEvent) error
+
+// Dispatch 调用函数本身
+func (f OutboxDispatcherFunc) Dispatch(ctx context.Context, event models.OutboxEvent) error {
+	return f(ctx, event)
+}
+
+// OutboxService 发件箱服务
+type OutboxService struct {
+	db *gorm.DB
+}
+
+// NewOutboxService 创建发件箱服务
+func NewOutboxService(db *gorm.DB) *OutboxService {
+	return &OutboxService{db: db}
+}
+
+// RelayOutbox 投递一轮待投递事件，返回投递成功的事件数
+// 按ID顺序逐条投递，成功后标记为已投递；投递失败的事件记录失败次数和原因，留到下一轮重试，
+// 不影响后面的事件
+func (s *OutboxService) RelayOutbox(ctx context.Context, dispatcher OutboxDispatcher) (int, error) {
+	delivered := 0
+	var lastID uint
+	for {
+		var events []models.OutboxEvent
+		err := s.db.WithContext(ctx).
+			Where("status = ? AND id > ?", OutboxPending, lastID).
+			Order("id").Limit(outboxRelayBatchSize).
+			Find(&events).Error
+		if err != nil {
+			return delivered, err
+		}
+		if len(events) == 0 {
+			return delivered, nil
+		}
+		lastID = events[len(events)-1].ID
+
+		for _, event := range events {
+			if err := ctx.Err(); err != nil {
+				return delivered, err
+			}
+
+			if err := dispatcher.Dispatch(ctx, event); err != nil {
+				log.Printf("投递发件箱事件%d(%s)失败: %v", event.ID, event.EventType, err)
+				if err := s.db.Model(&models.OutboxEvent{}).Where("id = ?", event.ID).Updates(map[string]interface{}{
+					"attempts":   gorm.Expr("attempts + 1"),
+					"last_error": truncate(err.Error(), 500),
+				}).Error; err != nil {
+					return delivered, err
+				}
+				continue
+			}
+
+			now := time.Now()
+			if err := s.db.Model(&models.OutboxEvent{}).
+				Where("id = ? AND status = ?", event.ID, OutboxPending).
+				Updates(map[string]interface{}{
+					"status":       OutboxDelivered,
+					"delivered_at": &now,
+					"attempts":     gorm.Expr("attempts + 1"),
+				}).Error; err != nil {
+				return delivered, err
+			}
+			delivered++
+		}
+	}
+}
+
+// StartOutboxRelay 启动发件箱投递任务，定期把待投递事件交给dispatcher，ctx取消时退出
+func (s *OutboxService) StartOutboxRelay(ctx context.Context, dispatcher OutboxDispatcher) {
+	go func() {
+		ticker := time.NewTicker(outboxRelayInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := s.RelayOutbox(ctx, dispatcher); err != nil && ctx.Err() == nil {
+					log.Printf("投递发件箱事件失败: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// truncate 截断字符串到最多n个字符
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
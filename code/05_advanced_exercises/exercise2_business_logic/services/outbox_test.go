@@ -0,0 +1,137 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"gorm-advanced-exercises/exercise2_business_logic/models"
+	"gorm.io/gorm"
+)
+
+// TestRelayOutboxRetriesFailedEvents 投递失败的事件记录次数和原因，不影响后面的事件，下一轮只重试失败的事件
+func TestRelayOutboxRetriesFailedEvents(t *testing.T) {
+	db := newTestDB(t)
+	ids := writeEvents(t, db, EventOrderCreated, EventOrderPaid, EventOrderShipped)
+
+	var dispatched []uint
+	failOnce := true
+	dispatcher := OutboxDispatcherFunc(func(ctx context.Context, event models.OutboxEvent) error {
+		dispatched = append(dispatched, event.ID)
+		if event.EventType == EventOrderPaid && failOnce {
+			failOnce = false
+			return errors.New("商家服务不可用")
+		}
+		return nil
+	})
+	outbox := NewOutboxService(db)
+
+	delivered, err := outbox.RelayOutbox(context.Background(), dispatcher)
+	if err != nil || delivered != 2 {
+		t.Fatalf("第一轮投递 %d 个事件（%v），期望 2", delivered, err)
+	}
+	paid := loadEvent(t, db, ids[1])
+	if paid.Status != OutboxPending || paid.Attempts != 1 || paid.LastError != "商家服务不可用" || paid.DeliveredAt != nil {
+		t.Errorf("失败的事件为 %+v，期望待投递、1次尝试并记录原因", paid)
+	}
+
+	delivered, err = outbox.RelayOutbox(context.Background(), dispatcher)
+	if err != nil || delivered != 1 {
+		t.Fatalf("第二轮投递 %d 个事件（%v），期望 1", delivered, err)
+	}
+	if delivered, _ := outbox.RelayOutbox(context.Background(), dispatcher); delivered != 0 {
+		t.Errorf("全部投递后又投递了 %d 个事件", delivered)
+	}
+	if want := []uint{ids[0], ids[1], ids[2], ids[1]}; !reflect.DeepEqual(dispatched, want) {
+		t.Errorf("投递顺序为 %v，期望 %v", dispatched, want)
+	}
+
+	for _, id := range ids {
+		event := loadEvent(t, db, id)
+		if event.Status != OutboxDelivered || event.DeliveredAt == nil {
+			t.Errorf("事件 %d 状态为 %d，期望已投递", id, event.Status)
+		}
+	}
+	if paid := loadEvent(t, db, ids[1]); paid.Attempts != 2 {
+		t.Errorf("重试后的事件尝试了 %d 次，期望 2", paid.Attempts)
+	}
+}
+
+// TestWriteOutboxRollsBackWithTransaction 业务事务回滚时事件一并丢弃
+func TestWriteOutboxRollsBackWithTransaction(t *testing.T) {
+	db := newTestDB(t)
+	rollback := errors.New("回滚")
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := WriteOutbox(tx, EventOrderCreated, map[string]uint{"order_id": 1}); err != nil {
+			return err
+		}
+		return rollback
+	})
+	if !errors.Is(err, rollback) {
+		t.Fatalf("事务返回 %v，期望回滚", err)
+	}
+	var n int64
+	db.Model(&models.OutboxEvent{}).Count(&n)
+	if n != 0 {
+		t.Errorf("回滚后仍有 %d 个事件", n)
+	}
+}
+
+// TestStartOutboxRelay 后台投递任务定期投递新事件，ctx取消后退出
+func TestStartOutboxRelay(t *testing.T) {
+	db := newTestDB(t)
+	ids := writeEvents(t, db, EventOrderCompleted)
+
+	var mu sync.Mutex
+	var dispatched []uint
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	NewOutboxService(db).StartOutboxRelay(ctx, OutboxDispatcherFunc(func(ctx context.Context, event models.OutboxEvent) error {
+		mu.Lock()
+		defer mu.Unlock()
+		dispatched = append(dispatched, event.ID)
+		return nil
+	}))
+
+	deadline := time.Now().Add(3 * outboxRelayInterval)
+	for loadEvent(t, db, ids[0]).Status != OutboxDelivered {
+		if time.Now().After(deadline) {
+			t.Fatalf("事件在 %v 内没有投递", 3*outboxRelayInterval)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if !reflect.DeepEqual(dispatched, ids) {
+		t.Errorf("投递的事件为 %v，期望 %v", dispatched, ids)
+	}
+}
+
+// writeEvents 按顺序写入待投递事件，返回事件ID
+func writeEvents(t *testing.T, db *gorm.DB, eventTypes ...string) []uint {
+	t.Helper()
+	var ids []uint
+	for i, eventType := range eventTypes {
+		if err := addOutboxEvent(db, eventType, uint(i+1), map[string]int{"order_id": i + 1}); err != nil {
+			t.Fatalf("写入发件箱事件失败: %v", err)
+		}
+		var event models.OutboxEvent
+		if err := db.Last(&event).Error; err != nil {
+			t.Fatalf("查询发件箱事件失败: %v", err)
+		}
+		ids = append(ids, event.ID)
+	}
+	return ids
+}
+
+func loadEvent(t *testing.T, db *gorm.DB, id uint) models.OutboxEvent {
+	t.Helper()
+	var event models.OutboxEvent
+	if err := db.First(&event, id).Error; err != nil {
+		t.Fatalf("查询发件箱事件失败: %v", err)
+	}
+	return event
+}
@@ -0,0 +1,169 @@
+package services
+
+import (
+	"errors"
+	"strings"
+
+	"gorm-advanced-exercises/exercise2_business_logic/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// MaxReviewImages 每条评价最多上传的图片数
+const MaxReviewImages = 9
+
+// 商品评价的错误
+var (
+	ErrReviewNotPurchased  = errors.New("未在该订单中购买此商品，不能评价")
+	ErrReviewAlreadyExists = errors.New("该订单中的商品已评价")
+	ErrReviewTooManyImages = errors.New("评价图片最多9张")
+	ErrReviewInvalidRating = errors.New("评分必须在1-5之间")
+	ErrReviewNotFound      = errors.New("评价不存在")
+)
+
+// ReviewService 商品评价服务
+// 评价数和平均分冗余在商品表中（review_count、avg_rating），评价新增、删除时在同一事务中按显示中的评价重新统计
+type ReviewService struct {
+	db *gorm.DB
+}
+
+// NewReviewService 创建商品评价服务
+func NewReviewService(db *gorm.DB) *ReviewService {
+	return &ReviewService{db: db}
+}
+
+// CreateReview 用户评价订单中购买的商品，评价和图片在同一事务中保存，图片按传入顺序排列
+// 订单须属于该用户且已付款（待发货、待收货、已完成）；同一商品在订单中有多个订单项（不同SKU）时依次评价，全部评价过后返回 ErrReviewAlreadyExists
+func (s *ReviewService) CreateReview(userID, productID, orderID uint, rating int8, content string, imageURLs []string) (*models.ProductReview, error) {
+	if rating < 1 || rating > 5 {
+		return nil, ErrReviewInvalidRating
+	}
+	if len(imageURLs) > MaxReviewImages {
+		return nil, ErrReviewTooManyImages
+	}
+
+	review := models.ProductReview{
+		ProductID: productID,
+		UserID:    userID,
+		OrderID:   orderID,
+		Rating:    rating,
+		Content:   strings.TrimSpace(content),
+		Status:    1,
+	}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var itemIDs []uint
+		if err := tx.Table("order_items oi").
+			Joins("JOIN orders o ON o.id = oi.order_id AND o.deleted_at IS NULL").
+			Where("oi.order_id = ? AND oi.product_id = ? AND oi.deleted_at IS NULL", orderID, productID).
+			Where("o.user_id = ? AND o.status IN ?", userID, []int{2, 3, 4}).
+			Order("oi.id").
+			Pluck("oi.id", &itemIDs).Error; err != nil {
+			return err
+		}
+		if len(itemIDs) == 0 {
+			return ErrReviewNotPurchased
+		}
+
+		// 已软删除的评价仍占用唯一索引，同样视为已评价
+		var reviewed []uint
+		if err := tx.Unscoped().Model(&models.ProductReview{}).
+			Where("user_id = ? AND order_item_id IN ?", userID, itemIDs).
+			Pluck("order_item_id", &reviewed).Error; err != nil {
+			return err
+		}
+		done := make(map[uint]bool, len(reviewed))
+		for _, id := range reviewed {
+			done[id] = true
+		}
+		for _, id := range itemIDs {
+			if !done[id] {
+				review.OrderItemID = id
+				break
+			}
+		}
+		if review.OrderItemID == 0 {
+			return ErrReviewAlreadyExists
+		}
+
+		// 并发提交同一订单项时由唯一索引拦截
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Omit("Images").Create(&review)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrReviewAlreadyExists
+		}
+
+		if len(imageURLs) > 0 {
+			images := make([]models.ReviewImage, len(imageURLs))
+			for i, url := range imageURLs {
+				images[i] = models.ReviewImage{ReviewID: review.ID, URL: strings.TrimSpace(url), Sort: i}
+			}
+			if err := tx.CreateInBatches(&images, MaxReviewImages).Error; err != nil {
+				return err
+			}
+			review.Images = images
+		}
+
+		return refreshProductRating(tx, productID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &review, nil
+}
+
+// DeleteReview 用户删除自己的评价（软删除），并重新统计商品的评价数和平均分
+func (s *ReviewService) DeleteReview(reviewID, userID uint) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var review models.ProductReview
+		if err := tx.Where("id = ? AND user_id = ?", reviewID, userID).First(&review).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrReviewNotFound
+			}
+			return err
+		}
+		if err := tx.Delete(&review).Error; err != nil {
+			return err
+		}
+		return refreshProductRating(tx, review.ProductID)
+	})
+}
+
+// ListReviews 商品显示中的评价，按时间倒序，图片按Sort预加载；withImages为true时只返回带图评价
+func (s *ReviewService) ListReviews(productID uint, withImages bool, page, pageSize int) ([]models.ProductReview, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	query := s.db.Model(&models.ProductReview{}).Where("product_id = ? AND status = ?", productID, 1)
+	if withImages {
+		query = query.Where("EXISTS (?)", s.db.Model(&models.ReviewImage{}).
+			Select("1").Where("review_images.review_id = product_reviews.id"))
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var reviews []models.ProductReview
+	err := query.Preload("Images", func(db *gorm.DB) *gorm.DB {
+		return db.Order("sort, id")
+	}).Order("created_at DESC, id DESC").
+		Offset((page - 1) * pageSize).Limit(pageSize).
+		Find(&reviews).Error
+	return reviews, total, err
+}
+
+// refreshProductRating 按显示中的评价重新统计商品的评价数和平均分，单条UPDATE完成，在调用方的事务中执行
+func refreshProductRating(tx *gorm.DB, productID uint) error {
+	visible := "product_reviews.product_id = ? AND product_reviews.status = 1 AND product_reviews.deleted_at IS NULL"
+	return tx.Model(&models.Product{}).Where("id = ?", productID).Updates(map[string]interface{}{
+		"review_count": gorm.Expr("(SELECT COUNT(*) FROM product_reviews WHERE "+visible+")", productID),
+		"avg_rating":   gorm.Expr("(SELECT COALESCE(AVG(rating), 0) FROM product_reviews WHERE "+visible+")", productID),
+	}).Error
+}
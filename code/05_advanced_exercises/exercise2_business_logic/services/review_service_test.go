@@ -0,0 +1,140 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync/atomic"
+	"testing"
+
+	"gorm-advanced-exercises/exercise2_business_logic/models"
+	"gorm.io/gorm"
+)
+
+// TestReviewRatingRecalculation 评价新增和删除后商品的评价数、平均分按显示中的评价重新统计，
+// 隐藏和已删除的评价不计入
+func TestReviewRatingRecalculation(t *testing.T) {
+	db := newTestDB(t)
+	reviews := NewReviewService(db)
+	product := createProduct(t, db, 10)
+	alice, bob := createUser(t, db), createUser(t, db)
+
+	// alice 的订单里同一商品有两个订单项（不同SKU），可以分别评价
+	aliceOrder := createOrder(t, db, alice.ID, 2, product.ID, product.ID)
+	bobOrder := createOrder(t, db, bob.ID, 4, product.ID)
+
+	first, err := reviews.CreateReview(alice.ID, product.ID, aliceOrder.ID, 5, " 很好 ", []string{"a.jpg", "b.jpg"})
+	if err != nil {
+		t.Fatalf("创建评价失败: %v", err)
+	}
+	if first.Content != "很好" || len(first.Images) != 2 || first.Images[1].Sort != 1 {
+		t.Errorf("评价为 %+v，期望去掉空白并按顺序保存两张图片", first)
+	}
+	assertRating(t, db, product.ID, 1, 5)
+
+	if _, err := reviews.CreateReview(alice.ID, product.ID, aliceOrder.ID, 2, "一般", nil); err != nil {
+		t.Fatalf("评价第二个订单项失败: %v", err)
+	}
+	assertRating(t, db, product.ID, 2, 3.5)
+	if _, err := reviews.CreateReview(alice.ID, product.ID, aliceOrder.ID, 4, "再评一次", nil); !errors.Is(err, ErrReviewAlreadyExists) {
+		t.Errorf("订单项全部评价后返回 %v，期望 ErrReviewAlreadyExists", err)
+	}
+
+	third, err := reviews.CreateReview(bob.ID, product.ID, bobOrder.ID, 4, "不错", nil)
+	if err != nil {
+		t.Fatalf("创建评价失败: %v", err)
+	}
+	assertRating(t, db, product.ID, 3, 11.0/3)
+
+	// 隐藏的评价在下次重新统计时不再计入
+	if err := db.Model(&models.ProductReview{}).Where("id = ?", first.ID).Update("status", 2).Error; err != nil {
+		t.Fatalf("隐藏评价失败: %v", err)
+	}
+	if err := reviews.DeleteReview(third.ID, alice.ID); !errors.Is(err, ErrReviewNotFound) {
+		t.Errorf("删除他人的评价返回 %v，期望 ErrReviewNotFound", err)
+	}
+	if err := reviews.DeleteReview(third.ID, bob.ID); err != nil {
+		t.Fatalf("删除评价失败: %v", err)
+	}
+	assertRating(t, db, product.ID, 1, 2)
+
+	// 已删除的评价仍占用订单项，不能重新评价
+	if _, err := reviews.CreateReview(bob.ID, product.ID, bobOrder.ID, 5, "重新评价", nil); !errors.Is(err, ErrReviewAlreadyExists) {
+		t.Errorf("删除后重新评价返回 %v，期望 ErrReviewAlreadyExists", err)
+	}
+}
+
+// TestReviewRequiresPaidOrder 只能评价自己已付款订单中的商品，校验失败时商品评分不变
+func TestReviewRequiresPaidOrder(t *testing.T) {
+	db := newTestDB(t)
+	reviews := NewReviewService(db)
+	product := createProduct(t, db, 10)
+	alice, bob := createUser(t, db), createUser(t, db)
+
+	pending := createOrder(t, db, alice.ID, 1, product.ID)
+	paid := createOrder(t, db, alice.ID, 3, product.ID)
+
+	cases := []struct {
+		name            string
+		userID, orderID uint
+		rating          int8
+		images          int
+		want            error
+	}{
+		{"待付款订单", alice.ID, pending.ID, 5, 0, ErrReviewNotPurchased},
+		{"他人的订单", bob.ID, paid.ID, 5, 0, ErrReviewNotPurchased},
+		{"评分为0", alice.ID, paid.ID, 0, 0, ErrReviewInvalidRating},
+		{"评分为6", alice.ID, paid.ID, 6, 0, ErrReviewInvalidRating},
+		{"图片超过9张", alice.ID, paid.ID, 5, MaxReviewImages + 1, ErrReviewTooManyImages},
+	}
+	for _, tc := range cases {
+		images := make([]string, tc.images)
+		for i := range images {
+			images[i] = fmt.Sprintf("%d.jpg", i)
+		}
+		if _, err := reviews.CreateReview(tc.userID, product.ID, tc.orderID, tc.rating, "评价", images); !errors.Is(err, tc.want) {
+			t.Errorf("%s: 返回 %v，期望 %v", tc.name, err, tc.want)
+		}
+	}
+	assertRating(t, db, product.ID, 0, 0)
+}
+
+// createOrder 创建指定状态的订单，每个商品ID一个订单项
+func createOrder(t *testing.T, db *gorm.DB, userID uint, status int8, productIDs ...uint) *models.Order {
+	t.Helper()
+	order := &models.Order{
+		OrderNo:         fmt.Sprintf("T%d", atomic.AddInt64(&testSeq, 1)),
+		UserID:          userID,
+		Status:          status,
+		TotalAmount:     9900,
+		PayAmount:       9900,
+		ReceiverName:    "张三",
+		ReceiverPhone:   "13800000000",
+		ReceiverAddress: "北京市",
+	}
+	for i, productID := range productIDs {
+		order.Items = append(order.Items, models.OrderItem{
+			ProductID:   productID,
+			Quantity:    1,
+			Price:       9900,
+			TotalPrice:  9900,
+			ProductName: "商品",
+			ProductSKU:  fmt.Sprintf("SKU-%d", i),
+		})
+	}
+	if err := db.Create(order).Error; err != nil {
+		t.Fatalf("创建订单失败: %v", err)
+	}
+	return order
+}
+
+func assertRating(t *testing.T, db *gorm.DB, productID uint, count int, avg float64) {
+	t.Helper()
+	var product models.Product
+	if err := db.First(&product, productID).Error; err != nil {
+		t.Fatalf("查询商品失败: %v", err)
+	}
+	if product.ReviewCount != count || math.Abs(product.AvgRating-avg) > 1e-9 {
+		t.Errorf("评价数为 %d、平均分为 %v，期望 %d、%v", product.ReviewCount, product.AvgRating, count, avg)
+	}
+}
@@ -0,0 +1,70 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"gorm-advanced-exercises/exercise2_business_logic/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 系统设置键
+const (
+	SettingOrderAutoCompleteDays  = "order.auto_complete_days" // 发货后自动确认收货的天数
+	SettingWebhookSecret          = "webhook.secret"           // Webhook签名密钥
+	SettingWebhookEndpointsPrefix = "webhook.endpoints."       // 加上事件类型为该事件的Webhook地址，多个地址用逗号分隔
+)
+
+// defaultSettings 未配置时使用的默认值
+var defaultSettings = map[string]string{
+	SettingOrderAutoCompleteDays: "7",
+}
+
+// SettingsService 系统设置服务
+type SettingsService struct {
+	db *gorm.DB
+}
+
+// NewSettingsService 创建系统设置服务实例
+func NewSettingsService(db *gorm.DB) *SettingsService {
+	return &SettingsService{
+		db: db,
+	}
+}
+
+// Get 获取设置值，未配置时返回默认值
+func (s *SettingsService) Get(key string) (string, error) {
+	var setting models.Setting
+	err := s.db.Where(&models.Setting{Key: key}).First(&setting).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return defaultSettings[key], nil
+		}
+		return "", err
+	}
+	return setting.Value, nil
+}
+
+// GetInt 获取整数设置值
+func (s *SettingsService) GetInt(key string) (int, error) {
+	value, err := s.Get(key)
+	if err != nil {
+		return 0, err
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("设置%s的值%q不是整数", key, value)
+	}
+	return n, nil
+}
+
+// Set 修改设置值，不存在时创建
+func (s *SettingsService) Set(key, value string) error {
+	setting := models.Setting{Key: key, Value: value}
+	return s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"value", "updated_at"}),
+	}).Create(&setting).Error
+}
@@ -0,0 +1,116 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// StatFilter 统计查询的通用筛选条件
+// 通过链式方法组合条件，再用OrderScope/ItemScope应用到查询上，保证各项统计使用同样的筛选逻辑：
+//
+//	filter := NewStatFilter().DateRange(start, end).CategoryID(3)
+//	stats, err := statisticsService.GetSalesStatistics(filter)
+//
+// 查询中订单表的别名须为 o；ItemScope还要求订单项表别名为 oi、商品表别名为 p
+type StatFilter struct {
+	startDate  *time.Time
+	endDate    *time.Time
+	statuses   []int
+	categoryID *uint
+	brandID    *uint
+}
+
+// NewStatFilter 创建统计筛选条件，默认只统计已支付（status >= 2）的订单
+func NewStatFilter() *StatFilter {
+	return &StatFilter{}
+}
+
+// DateRange 按下单时间筛选，包含起止时间
+func (f *StatFilter) DateRange(start, end time.Time) *StatFilter {
+	f.startDate = &start
+	f.endDate = &end
+	return f
+}
+
+// Status 按订单状态筛选，不设置时统计所有已支付的订单
+func (f *StatFilter) Status(statuses ...int) *StatFilter {
+	f.statuses = statuses
+	return f
+}
+
+// CategoryID 按商品分类筛选
+func (f *StatFilter) CategoryID(id uint) *StatFilter {
+	f.categoryID = &id
+	return f
+}
+
+// BrandID 按商品品牌筛选
+func (f *StatFilter) BrandID(id uint) *StatFilter {
+	f.brandID = &id
+	return f
+}
+
+// Validate 校验筛选条件
+func (f *StatFilter) Validate() error {
+	if f.startDate != nil && f.endDate != nil && f.startDate.After(*f.endDate) {
+		return errors.New("开始时间不能晚于结束时间")
+	}
+	return nil
+}
+
+// OrderScope 订单级统计使用的条件（查询以订单表 o 为主表）
+// 分类和品牌条件通过子查询匹配包含对应商品的订单
+func (f *StatFilter) OrderScope() func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		db = f.applyOrderConditions(db)
+		if f.categoryID != nil || f.brandID != nil {
+			sub := db.Session(&gorm.Session{NewDB: true}).
+				Table("order_items oi").
+				Select("oi.order_id").
+				Joins("JOIN products p ON oi.product_id = p.id")
+			sub = f.applyProductConditions(sub)
+			db = db.Where("o.id IN (?)", sub)
+		}
+		return db
+	}
+}
+
+// ItemScope 商品级统计使用的条件（查询已关联 order_items oi、orders o、products p）
+// 分类和品牌条件直接作用于商品，只统计符合条件的订单项
+func (f *StatFilter) ItemScope() func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return f.applyProductConditions(f.applyOrderConditions(db))
+	}
+}
+
+func (f *StatFilter) applyOrderConditions(db *gorm.DB) *gorm.DB {
+	if err := f.Validate(); err != nil {
+		db.AddError(err)
+		return db
+	}
+
+	if f.startDate != nil {
+		db = db.Where("o.created_at >= ?", *f.startDate)
+	}
+	if f.endDate != nil {
+		db = db.Where("o.created_at <= ?", *f.endDate)
+	}
+	if len(f.statuses) > 0 {
+		db = db.Where("o.status IN ?", f.statuses)
+	} else {
+		db = db.Where("o.status >= ?", 2)
+	}
+	return db
+}
+
+func (f *StatFilter) applyProductConditions(db *gorm.DB) *gorm.DB {
+	if f.categoryID != nil {
+		db = db.Where("p.category_id = ?", *f.categoryID)
+	}
+	if f.brandID != nil {
+		db = db.Where("p.brand_id = ?", *f.brandID)
+	}
+	return db
+}
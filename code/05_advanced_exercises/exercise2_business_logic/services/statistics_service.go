@@ -3,6 +3,7 @@ package services
 import (
 	"time"
 
+	"gorm-advanced-exercises/exercise2_business_logic/models"
 	"gorm.io/gorm"
 )
 
@@ -62,25 +63,20 @@ func NewStatisticsService(db *gorm.DB) *StatisticsService {
 	}
 }
 
-// GetSalesStatistics 获取销售统计数据
-func (s *StatisticsService) GetSalesStatistics(startDate, endDate time.Time) ([]SalesStatistics, error) {
+// GetSalesStatistics 获取销售统计数据（按天汇总）
+func (s *StatisticsService) GetSalesStatistics(filter *StatFilter) ([]SalesStatistics, error) {
 	var results []SalesStatistics
 
-	// 使用原生SQL进行复杂统计查询
-	sql := `
-		SELECT 
-			DATE(created_at) as date,
+	err := s.db.Table("orders o").
+		Select(`DATE(o.created_at) as date,
 			COUNT(*) as order_count,
-			SUM(pay_amount) as sales_amount,
-			COUNT(DISTINCT user_id) as user_count,
-			AVG(pay_amount) as avg_order_value
-		FROM orders 
-		WHERE created_at >= ? AND created_at <= ? AND status >= 2
-		GROUP BY DATE(created_at)
-		ORDER BY date
-	`
-
-	err := s.db.Raw(sql, startDate, endDate).Scan(&results).Error
+			SUM(o.pay_amount) as sales_amount,
+			COUNT(DISTINCT o.user_id) as user_count,
+			AVG(o.pay_amount) as avg_order_value`).
+		Scopes(filter.OrderScope()).
+		Group("DATE(o.created_at)").
+		Order("date").
+		Scan(&results).Error
 	if err != nil {
 		return nil, err
 	}
@@ -89,29 +85,25 @@ func (s *StatisticsService) GetSalesStatistics(startDate, endDate time.Time) ([]
 }
 
 // GetProductSalesRank 获取商品销量排行
-func (s *StatisticsService) GetProductSalesRank(startDate, endDate time.Time, limit int) ([]ProductSalesRank, error) {
+func (s *StatisticsService) GetProductSalesRank(filter *StatFilter, limit int) ([]ProductSalesRank, error) {
 	var results []ProductSalesRank
 
-	sql := `
-		SELECT 
-			p.id as product_id,
+	err := s.db.Table("order_items oi").
+		Select(`p.id as product_id,
 			p.name as product_name,
 			SUM(oi.quantity) as sales_count,
 			SUM(oi.total_price) as sales_amount,
 			c.name as category_name,
-			b.name as brand_name
-		FROM order_items oi
-		JOIN orders o ON oi.order_id = o.id
-		JOIN products p ON oi.product_id = p.id
-		LEFT JOIN categories c ON p.category_id = c.id
-		LEFT JOIN brands b ON p.brand_id = b.id
-		WHERE o.created_at >= ? AND o.created_at <= ? AND o.status >= 2
-		GROUP BY p.id, p.name, c.name, b.name
-		ORDER BY sales_count DESC
-		LIMIT ?
-	`
-
-	err := s.db.Raw(sql, startDate, endDate, limit).Scan(&results).Error
+			b.name as brand_name`).
+		Joins("JOIN orders o ON oi.order_id = o.id").
+		Joins("JOIN products p ON oi.product_id = p.id").
+		Joins("LEFT JOIN categories c ON p.category_id = c.id").
+		Joins("LEFT JOIN brands b ON p.brand_id = b.id").
+		Scopes(filter.ItemScope()).
+		Group("p.id, p.name, c.name, b.name").
+		Order("sales_count DESC").
+		Limit(limit).
+		Scan(&results).Error
 	if err != nil {
 		return nil, err
 	}
@@ -160,7 +152,7 @@ func (s *StatisticsService) GetDashboardData() (*DashboardData, error) {
 	data := &DashboardData{}
 
 	// 今日订单数
-	err := s.db.Model(&Order{}).Where("created_at >= ? AND status >= 2", today).Count(&data.TodayOrders).Error
+	err := s.db.Model(&models.Order{}).Where("created_at >= ? AND status >= 2", today).Count(&data.TodayOrders).Error
 	if err != nil {
 		return nil, err
 	}
@@ -169,7 +161,7 @@ func (s *StatisticsService) GetDashboardData() (*DashboardData, error) {
 	var todaySales struct {
 		Total int64
 	}
-	err = s.db.Model(&Order{}).Select("COALESCE(SUM(pay_amount), 0) as total").
+	err = s.db.Model(&models.Order{}).Select("COALESCE(SUM(pay_amount), 0) as total").
 		Where("created_at >= ? AND status >= 2", today).Scan(&todaySales).Error
 	if err != nil {
 		return nil, err
@@ -177,13 +169,13 @@ func (s *StatisticsService) GetDashboardData() (*DashboardData, error) {
 	data.TodaySales = todaySales.Total
 
 	// 今日新增用户
-	err = s.db.Model(&User{}).Where("created_at >= ?", today).Count(&data.TodayUsers).Error
+	err = s.db.Model(&models.User{}).Where("created_at >= ?", today).Count(&data.TodayUsers).Error
 	if err != nil {
 		return nil, err
 	}
 
 	// 总订单数
-	err = s.db.Model(&Order{}).Where("status >= 2").Count(&data.TotalOrders).Error
+	err = s.db.Model(&models.Order{}).Where("status >= 2").Count(&data.TotalOrders).Error
 	if err != nil {
 		return nil, err
 	}
@@ -192,7 +184,7 @@ func (s *StatisticsService) GetDashboardData() (*DashboardData, error) {
 	var totalSales struct {
 		Total int64
 	}
-	err = s.db.Model(&Order{}).Select("COALESCE(SUM(pay_amount), 0) as total").
+	err = s.db.Model(&models.Order{}).Select("COALESCE(SUM(pay_amount), 0) as total").
 		Where("status >= 2").Scan(&totalSales).Error
 	if err != nil {
 		return nil, err
@@ -200,13 +192,13 @@ func (s *StatisticsService) GetDashboardData() (*DashboardData, error) {
 	data.TotalSales = totalSales.Total
 
 	// 总用户数
-	err = s.db.Model(&User{}).Count(&data.TotalUsers).Error
+	err = s.db.Model(&models.User{}).Count(&data.TotalUsers).Error
 	if err != nil {
 		return nil, err
 	}
 
 	// 总商品数
-	err = s.db.Model(&Product{}).Where("status = 1").Count(&data.TotalProducts).Error
+	err = s.db.Model(&models.Product{}).Where("status = 1").Count(&data.TotalProducts).Error
 	if err != nil {
 		return nil, err
 	}
@@ -219,7 +211,7 @@ func (s *StatisticsService) GetDashboardData() (*DashboardData, error) {
 	// 计算增长率
 	// 昨日订单数
 	var yesterdayOrders int64
-	err = s.db.Model(&Order{}).Where("created_at >= ? AND created_at < ? AND status >= 2", yesterday, today).Count(&yesterdayOrders).Error
+	err = s.db.Model(&models.Order{}).Where("created_at >= ? AND created_at < ? AND status >= 2", yesterday, today).Count(&yesterdayOrders).Error
 	if err != nil {
 		return nil, err
 	}
@@ -228,7 +220,7 @@ func (s *StatisticsService) GetDashboardData() (*DashboardData, error) {
 	var yesterdaySales struct {
 		Total int64
 	}
-	err = s.db.Model(&Order{}).Select("COALESCE(SUM(pay_amount), 0) as total").
+	err = s.db.Model(&models.Order{}).Select("COALESCE(SUM(pay_amount), 0) as total").
 		Where("created_at >= ? AND created_at < ? AND status >= 2", yesterday, today).Scan(&yesterdaySales).Error
 	if err != nil {
 		return nil, err
@@ -246,27 +238,22 @@ func (s *StatisticsService) GetDashboardData() (*DashboardData, error) {
 }
 
 // GetSalesStatisticsByCategory 按分类获取销售统计
-func (s *StatisticsService) GetSalesStatisticsByCategory(startDate, endDate time.Time) ([]map[string]interface{}, error) {
+func (s *StatisticsService) GetSalesStatisticsByCategory(filter *StatFilter) ([]map[string]interface{}, error) {
 	var results []map[string]interface{}
 
-	sql := `
-		SELECT 
-			c.id as category_id,
+	err := s.db.Table("order_items oi").
+		Select(`c.id as category_id,
 			c.name as category_name,
 			COUNT(DISTINCT o.id) as order_count,
 			SUM(oi.quantity) as sales_count,
-			SUM(oi.total_price) as sales_amount
-		FROM categories c
-		LEFT JOIN products p ON c.id = p.category_id
-		LEFT JOIN order_items oi ON p.id = oi.product_id
-		LEFT JOIN orders o ON oi.order_id = o.id 
-			AND o.created_at >= ? AND o.created_at <= ? 
-			AND o.status >= 2
-		GROUP BY c.id, c.name
-		ORDER BY sales_amount DESC
-	`
-
-	err := s.db.Raw(sql, startDate, endDate).Scan(&results).Error
+			SUM(oi.total_price) as sales_amount`).
+		Joins("JOIN orders o ON oi.order_id = o.id").
+		Joins("JOIN products p ON oi.product_id = p.id").
+		Joins("JOIN categories c ON p.category_id = c.id").
+		Scopes(filter.ItemScope()).
+		Group("c.id, c.name").
+		Order("sales_amount DESC").
+		Find(&results).Error
 	if err != nil {
 		return nil, err
 	}
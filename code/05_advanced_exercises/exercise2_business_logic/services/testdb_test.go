@@ -0,0 +1,73 @@
+package services
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm-advanced-exercises/exercise2_business_logic/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var testSeq int64
+
+// newTestDB 在临时目录创建SQLite数据库并迁移 models.All()，测试结束时关闭
+// 使用文件库和 _txlock=immediate：事务开始即加写锁，并发事务按busy_timeout排队，而不是升级锁时直接失败
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := "file:" + filepath.Join(t.TempDir(), "exercise2.db") + "?_pragma=busy_timeout(5000)&_txlock=immediate"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("打开测试数据库失败: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("获取数据库连接失败: %v", err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	if err := db.AutoMigrate(models.All()...); err != nil {
+		t.Fatalf("迁移测试数据库失败: %v", err)
+	}
+	return db
+}
+
+// createUser 创建用户名、邮箱和手机号唯一的用户
+func createUser(t *testing.T, db *gorm.DB) *models.User {
+	t.Helper()
+	n := atomic.AddInt64(&testSeq, 1)
+	user := &models.User{
+		Username: fmt.Sprintf("user%d", n),
+		Email:    fmt.Sprintf("user%d@example.com", n),
+		Phone:    fmt.Sprintf("138%08d", n),
+		Password: "secret",
+	}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatalf("创建用户失败: %v", err)
+	}
+	return user
+}
+
+// createProduct 创建指定库存的上架商品（连同所属分类）
+func createProduct(t *testing.T, db *gorm.DB, stock int) *models.Product {
+	t.Helper()
+	n := atomic.AddInt64(&testSeq, 1)
+	category := &models.Category{Name: "分类", Slug: fmt.Sprintf("category-%d", n)}
+	if err := db.Create(category).Error; err != nil {
+		t.Fatalf("创建分类失败: %v", err)
+	}
+	product := &models.Product{
+		Name:       fmt.Sprintf("商品%d", n),
+		SKU:        fmt.Sprintf("P%d", n),
+		CategoryID: category.ID,
+		Price:      9900,
+		Stock:      stock,
+	}
+	if err := db.Create(product).Error; err != nil {
+		t.Fatalf("创建商品失败: %v", err)
+	}
+	return product
+}
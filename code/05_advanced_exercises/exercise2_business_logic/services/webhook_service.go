@@ -0,0 +1,178 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm-advanced-exercises/exercise2_business_logic/models"
+	"gorm.io/gorm"
+)
+
+// Webhook投递记录状态
+const (
+	WebhookDelivered  int8 = 1 // 成功
+	WebhookFailed     int8 = 2 // 失败，稍后重试
+	WebhookDeadLetter int8 = 3 // 超过最大尝试次数，转入死信
+)
+
+// Webhook请求头
+const (
+	WebhookEventHeader     = "X-Webhook-Event"     // 事件类型
+	WebhookIDHeader        = "X-Webhook-Id"        // 发件箱事件ID，接收方据此去重
+	WebhookTimestampHeader = "X-Webhook-Timestamp" // 发送时间（Unix秒），参与签名，接收方可拒绝过旧的请求
+	WebhookSignatureHeader = "X-Webhook-Signature" // 签名，格式为 sha256=十六进制HMAC
+)
+
+// ErrWebhookDeadLettered 超过最大尝试次数仍投递失败，投递已转入死信
+var ErrWebhookDeadLettered = errors.New("Webhook投递失败，已转入死信")
+
+// WebhookService Webhook投递服务，把发件箱事件推送到配置的地址
+type WebhookService struct {
+	db     *gorm.DB
+	client *http.Client
+
+	Timeout     time.Duration // 单次请求超时时间
+	MaxAttempts int           // 最大尝试次数（含第一次）
+	Backoff     time.Duration // 第一次重试前的等待时间，之后每次翻倍
+}
+
+// NewWebhookService 创建Webhook投递服务实例
+func NewWebhookService(db *gorm.DB) *WebhookService {
+	return &WebhookService{
+		db:          db,
+		client:      &http.Client{},
+		Timeout:     5 * time.Second,
+		MaxAttempts: 5,
+		Backoff:     time.Second,
+	}
+}
+
+// SignWebhook 计算Webhook签名：HMAC-SHA256(secret, 时间戳 + "." + 请求体)
+// 接收方用同样的方法计算后与签名请求头比较
+func SignWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Endpoints 获取事件类型配置的Webhook地址（设置 webhook.endpoints.<事件类型>，多个地址用逗号分隔）
+func (s *WebhookService) Endpoints(eventType string) ([]string, error) {
+	value, err := NewSettingsService(s.db).Get(SettingWebhookEndpointsPrefix + eventType)
+	if err != nil {
+		return nil, err
+	}
+
+	var endpoints []string
+	for _, endpoint := range strings.Split(value, ",") {
+		if endpoint = strings.TrimSpace(endpoint); endpoint != "" {
+			endpoints = append(endpoints, endpoint)
+		}
+	}
+	return endpoints, nil
+}
+
+// Deliver 把事件的JSON内容POST到endpoint，每次尝试记录一条投递记录
+// 非2xx响应或请求失败时按退避时间重试，超过最大尝试次数后最后一条记录标记为死信，返回ErrWebhookDeadLettered
+func (s *WebhookService) Deliver(endpoint string, event models.OutboxEvent) error {
+	secret, err := NewSettingsService(s.db).Get(SettingWebhookSecret)
+	if err != nil {
+		return err
+	}
+	if secret == "" {
+		return errors.New("未配置Webhook签名密钥" + SettingWebhookSecret)
+	}
+
+	var lastErr error
+	for attempt := 1; attempt <= s.MaxAttempts; attempt++ {
+		if attempt > 1 {
+			time.Sleep(s.Backoff << (attempt - 2))
+		}
+
+		start := time.Now()
+		statusCode, err := s.post(endpoint, secret, event)
+		delivery := models.WebhookDelivery{
+			EventID:    event.ID,
+			EventType:  event.EventType,
+			Endpoint:   endpoint,
+			Attempt:    attempt,
+			Status:     WebhookDelivered,
+			StatusCode: statusCode,
+			DurationMs: time.Since(start).Milliseconds(),
+		}
+		if err != nil {
+			delivery.Status = WebhookFailed
+			if attempt == s.MaxAttempts {
+				delivery.Status = WebhookDeadLetter
+			}
+			delivery.Error = truncate(err.Error(), 500)
+		}
+		if err := s.db.Create(&delivery).Error; err != nil {
+			return fmt.Errorf("记录Webhook投递失败: %w", err)
+		}
+
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+	}
+	return fmt.Errorf("%w: %s: %v", ErrWebhookDeadLettered, endpoint, lastErr)
+}
+
+// post 发送一次Webhook请求，返回HTTP状态码
+func (s *WebhookService) post(endpoint, secret string, event models.OutboxEvent) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.Timeout)
+	defer cancel()
+
+	body := []byte(event.Payload)
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookEventHeader, event.EventType)
+	req.Header.Set(WebhookIDHeader, strconv.FormatUint(uint64(event.ID), 10))
+	req.Header.Set(WebhookTimestampHeader, timestamp)
+	req.Header.Set(WebhookSignatureHeader, SignWebhook(secret, timestamp, body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("响应状态码%d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// Dispatcher 返回发件箱投递器，把事件推送到该事件类型配置的所有地址
+// 转入死信的投递已记录在webhook_deliveries中，不再由发件箱重试；其他错误返回给发件箱下一轮重试
+func (s *WebhookService) Dispatcher() OutboxDispatcher {
+	return OutboxDispatcherFunc(func(ctx context.Context, event models.OutboxEvent) error {
+		endpoints, err := s.Endpoints(event.EventType)
+		if err != nil {
+			return err
+		}
+		for _, endpoint := range endpoints {
+			if err := s.Deliver(endpoint, event); err != nil && !errors.Is(err, ErrWebhookDeadLettered) {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,141 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gorm-advanced-exercises/exercise2_business_logic/models"
+	"gorm.io/gorm"
+)
+
+// TestWebhookDeliverRetries 非2xx响应按退避重试，成功前的每次尝试都记为失败；请求带有可校验的签名
+func TestWebhookDeliverRetries(t *testing.T) {
+	db := newTestDB(t)
+	setWebhookSecret(t, db)
+	event := webhookEvent(t, db)
+
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		want := SignWebhook("test-secret", r.Header.Get(WebhookTimestampHeader), body)
+		if r.Header.Get(WebhookSignatureHeader) != want || r.Header.Get(WebhookEventHeader) != EventOrderPaid {
+			t.Errorf("请求头 %v 的签名或事件类型不正确", r.Header)
+		}
+		if atomic.AddInt32(&calls, 1) <= 2 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	webhooks := testWebhookService(db, 5)
+	if err := webhooks.Deliver(server.URL, event); err != nil {
+		t.Fatalf("投递失败: %v", err)
+	}
+	assertDeliveries(t, db, event.ID,
+		[]int8{WebhookFailed, WebhookFailed, WebhookDelivered},
+		[]int{http.StatusInternalServerError, http.StatusInternalServerError, http.StatusNoContent})
+}
+
+// TestWebhookDeliverDeadLetter 超过最大尝试次数仍失败时最后一条记录转入死信，返回 ErrWebhookDeadLettered
+func TestWebhookDeliverDeadLetter(t *testing.T) {
+	db := newTestDB(t)
+	setWebhookSecret(t, db)
+	event := webhookEvent(t, db)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	webhooks := testWebhookService(db, 3)
+	if err := webhooks.Deliver(server.URL, event); !errors.Is(err, ErrWebhookDeadLettered) {
+		t.Fatalf("投递返回 %v，期望 ErrWebhookDeadLettered", err)
+	}
+	assertDeliveries(t, db, event.ID,
+		[]int8{WebhookFailed, WebhookFailed, WebhookDeadLetter},
+		[]int{http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusServiceUnavailable})
+
+	// 发件箱投递器不再重试已转入死信的投递，事件标记为已投递
+	if err := NewSettingsService(db).Set(SettingWebhookEndpointsPrefix+EventOrderPaid, " "+server.URL+" ,"); err != nil {
+		t.Fatalf("配置Webhook地址失败: %v", err)
+	}
+	delivered, err := NewOutboxService(db).RelayOutbox(context.Background(), webhooks.Dispatcher())
+	if err != nil || delivered != 1 {
+		t.Fatalf("发件箱投递 %d 个事件（%v），期望 1", delivered, err)
+	}
+	var deadLetters int64
+	db.Model(&models.WebhookDelivery{}).Where("status = ?", WebhookDeadLetter).Count(&deadLetters)
+	if deadLetters != 2 {
+		t.Errorf("死信记录 %d 条，期望 2", deadLetters)
+	}
+}
+
+// TestWebhookDeliverRequiresSecret 未配置签名密钥时不发送请求
+func TestWebhookDeliverRequiresSecret(t *testing.T) {
+	db := newTestDB(t)
+	event := webhookEvent(t, db)
+
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+	}))
+	defer server.Close()
+
+	if err := testWebhookService(db, 3).Deliver(server.URL, event); err == nil {
+		t.Fatal("未配置密钥时投递成功")
+	}
+	if calls := atomic.LoadInt32(&calls); calls != 0 {
+		t.Errorf("未配置密钥时发送了 %d 次请求", calls)
+	}
+}
+
+// testWebhookService 退避时间缩短为1毫秒的Webhook服务
+func testWebhookService(db *gorm.DB, maxAttempts int) *WebhookService {
+	webhooks := NewWebhookService(db)
+	webhooks.MaxAttempts = maxAttempts
+	webhooks.Backoff = time.Millisecond
+	return webhooks
+}
+
+func setWebhookSecret(t *testing.T, db *gorm.DB) {
+	t.Helper()
+	if err := NewSettingsService(db).Set(SettingWebhookSecret, "test-secret"); err != nil {
+		t.Fatalf("配置签名密钥失败: %v", err)
+	}
+}
+
+// webhookEvent 写入一个待投递的订单支付事件
+func webhookEvent(t *testing.T, db *gorm.DB) models.OutboxEvent {
+	t.Helper()
+	return loadEvent(t, db, writeEvents(t, db, EventOrderPaid)[0])
+}
+
+// assertDeliveries 事件的投递记录按尝试顺序的状态和HTTP状态码
+func assertDeliveries(t *testing.T, db *gorm.DB, eventID uint, statuses []int8, codes []int) {
+	t.Helper()
+	var deliveries []models.WebhookDelivery
+	if err := db.Where("event_id = ?", eventID).Order("attempt").Find(&deliveries).Error; err != nil {
+		t.Fatalf("查询投递记录失败: %v", err)
+	}
+	var gotStatuses []int8
+	var gotCodes []int
+	for i, d := range deliveries {
+		if d.Attempt != i+1 {
+			t.Errorf("第 %d 条记录的尝试次数为 %d", i+1, d.Attempt)
+		}
+		gotStatuses = append(gotStatuses, d.Status)
+		gotCodes = append(gotCodes, d.StatusCode)
+	}
+	if !reflect.DeepEqual(gotStatuses, statuses) || !reflect.DeepEqual(gotCodes, codes) {
+		t.Errorf("投递记录状态为 %v、状态码为 %v，期望 %v、%v", gotStatuses, gotCodes, statuses, codes)
+	}
+}
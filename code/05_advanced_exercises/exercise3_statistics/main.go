@@ -1,85 +1,29 @@
 package main
 
 import (
+	"archive/zip"
+	"context"
+	"database/sql/driver"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"math"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
 	"time"
 
+	"golang.org/x/sync/errgroup"
+	"gorm-advanced-exercises/models"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 )
 
-// 使用exercise2的模型
-type BaseModel struct {
-	ID        uint           `gorm:"primarykey" json:"id"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at"`
-}
-
-type User struct {
-	BaseModel
-	Username    string     `gorm:"uniqueIndex;size:50;not null" json:"username"`
-	Email       string     `gorm:"uniqueIndex;size:100;not null" json:"email"`
-	Phone       string     `gorm:"uniqueIndex;size:20" json:"phone"`
-	Password    string     `gorm:"size:255;not null" json:"-"`
-	Nickname    string     `gorm:"size:50" json:"nickname"`
-	Status      int8       `gorm:"default:1;comment:1-正常,2-禁用" json:"status"`
-	LastLoginAt *time.Time `json:"last_login_at"`
-}
-
-type Category struct {
-	BaseModel
-	Name     string `gorm:"size:50;not null" json:"name"`
-	Slug     string `gorm:"uniqueIndex;size:100;not null" json:"slug"`
-	ParentID *uint  `gorm:"index" json:"parent_id"`
-	Status   int8   `gorm:"default:1;comment:1-启用,2-禁用" json:"status"`
-}
-
-type Brand struct {
-	BaseModel
-	Name   string `gorm:"uniqueIndex;size:50;not null" json:"name"`
-	Slug   string `gorm:"uniqueIndex;size:100;not null" json:"slug"`
-	Status int8   `gorm:"default:1;comment:1-启用,2-禁用" json:"status"`
-}
-
-type Product struct {
-	BaseModel
-	Name       string `gorm:"size:255;not null" json:"name"`
-	SKU        string `gorm:"uniqueIndex;size:100;not null" json:"sku"`
-	CategoryID uint   `gorm:"index;not null" json:"category_id"`
-	BrandID    *uint  `gorm:"index" json:"brand_id"`
-	Price      int64  `gorm:"not null;comment:价格(分)" json:"price"`
-	Stock      int    `gorm:"default:0" json:"stock"`
-	Sales      int    `gorm:"default:0" json:"sales"`
-	Views      int    `gorm:"default:0" json:"views"`
-	Status     int8   `gorm:"default:1;comment:1-上架,2-下架" json:"status"`
-}
-
-type Order struct {
-	BaseModel
-	OrderNo        string     `gorm:"uniqueIndex;size:50;not null" json:"order_no"`
-	UserID         uint       `gorm:"index;not null" json:"user_id"`
-	Status         int8       `gorm:"index;default:1;comment:1-待付款,2-待发货,3-待收货,4-已完成,5-已取消" json:"status"`
-	TotalAmount    int64      `gorm:"not null;comment:商品总金额(分)" json:"total_amount"`
-	PayAmount      int64      `gorm:"not null;comment:实付金额(分)" json:"pay_amount"`
-	FreightAmount  int64      `gorm:"default:0;comment:运费(分)" json:"freight_amount"`
-	DiscountAmount int64      `gorm:"default:0;comment:优惠金额(分)" json:"discount_amount"`
-	PaidAt         *time.Time `json:"paid_at"`
-	FinishedAt     *time.Time `json:"finished_at"`
-}
-
-type OrderItem struct {
-	BaseModel
-	OrderID     uint   `gorm:"index;not null" json:"order_id"`
-	ProductID   uint   `gorm:"index;not null" json:"product_id"`
-	Quantity    int    `gorm:"not null" json:"quantity"`
-	Price       int64  `gorm:"not null;comment:单价(分)" json:"price"`
-	TotalPrice  int64  `gorm:"not null;comment:总价(分)" json:"total_price"`
-	ProductName string `gorm:"size:255;not null" json:"product_name"`
-}
-
 // DatabaseConfig 数据库配置
 type DatabaseConfig struct {
 	Host     string
@@ -91,8 +35,9 @@ type DatabaseConfig struct {
 }
 
 // ConnectDatabase 连接数据库
+// 时间统一按UTC存储和读取（loc=UTC），统计按天、按小时分组时再换算到报表时区，结果不随运行查询的服务器时区变化
 func ConnectDatabase(config DatabaseConfig) (*gorm.DB, error) {
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=%s&parseTime=True&loc=Local",
+	dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=%s&parseTime=True&loc=UTC",
 		config.User, config.Password, config.Host, config.Port, config.DBName, config.Charset)
 
 	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{
@@ -106,14 +51,182 @@ func ConnectDatabase(config DatabaseConfig) (*gorm.DB, error) {
 	return db, nil
 }
 
+// settingUTCCutover 时间改为UTC存储的时间点（RFC3339），记录后旧数据不再转换
+const settingUTCCutover = "stats.utc_cutover"
+
+// legacyTZCheckYears 判断旧时区偏移是否固定时检查的年数，旧数据一般不会更早
+const legacyTZCheckYears = 10
+
+// Setting 键值设置
+type Setting struct {
+	Name      string `gorm:"primaryKey;size:100"`
+	Value     string `gorm:"size:500;not null"`
+	UpdatedAt time.Time
+}
+
+// MigrateToUTC 把按服务器本地时区（loc=Local）存储的旧数据转换为UTC，只执行一次
+// 转换和记录切换时间点（settings表的 stats.utc_cutover）在同一事务中完成，已有记录时直接返回，重复执行不会把旧数据再偏移一次。
+// 旧数据和新数据的时间值有重叠（东八区切换前8小时的本地时间晚于切换后的UTC时间），无法在查询时按时间区分，所以在切换时统一转换；
+// 执行前须停止仍按本地时区写入的旧版本实例。SQLite写入的时间带时区偏移，本身没有歧义，只记录切换时间点
+func MigrateToUTC(db *gorm.DB, legacyTZ *time.Location) error {
+	if err := db.AutoMigrate(&Setting{}); err != nil {
+		return err
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		var setting Setting
+		err := tx.Where("name = ?", settingUTCCutover).Take(&setting).Error
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		now := time.Now().UTC()
+		if tx.Dialector.Name() == "mysql" {
+			from, err := mysqlZone(legacyTZ, now.AddDate(-legacyTZCheckYears, 0, 0), now)
+			if err != nil {
+				return err
+			}
+			for _, model := range models.All() {
+				stmt := &gorm.Statement{DB: tx}
+				if err := stmt.Parse(model); err != nil {
+					return err
+				}
+				var sets []string
+				for _, field := range stmt.Schema.Fields {
+					if isTimeField(field.FieldType) && field.DBName != "" {
+						sets = append(sets, fmt.Sprintf("%s = CONVERT_TZ(%s, '%s', '+00:00')", field.DBName, field.DBName, from))
+					}
+				}
+				if len(sets) == 0 {
+					continue
+				}
+				if err := tx.Exec("UPDATE " + stmt.Schema.Table + " SET " + strings.Join(sets, ", ")).Error; err != nil {
+					return err
+				}
+			}
+		}
+
+		return tx.Create(&Setting{Name: settingUTCCutover, Value: now.Format(time.RFC3339)}).Error
+	})
+}
+
+// isTimeField 是否为时间类型的字段（time.Time、*time.Time、gorm.DeletedAt）
+func isTimeField(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t == reflect.TypeOf(time.Time{}) || t == reflect.TypeOf(gorm.DeletedAt{})
+}
+
+// DefaultReportTZ 统计报表的默认时区，reportTZ传nil时使用
+var DefaultReportTZ = loadReportTZ("Asia/Shanghai")
+
+// loadReportTZ 加载时区，系统没有时区数据库时退回固定的东八区
+func loadReportTZ(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return time.FixedZone(name, 8*3600)
+	}
+	return loc
+}
+
 // StatisticsService 统计服务
 type StatisticsService struct {
-	db *gorm.DB
+	db  *gorm.DB
+	now func() time.Time // 当前时间，数据大屏按它计算今日、昨日，用户行为分析按它计算注册天数
 }
 
 // NewStatisticsService 创建统计服务实例
 func NewStatisticsService(db *gorm.DB) *StatisticsService {
-	return &StatisticsService{db: db}
+	return &StatisticsService{db: db, now: time.Now}
+}
+
+// notDeleted 生成排除软删除记录的条件，参数为表名或别名
+// 原生SQL不会自动带上deleted_at条件，统计查询统一用它拼接，
+// LEFT JOIN的表要把条件放在ON里，避免把左表的行也过滤掉
+func notDeleted(tables ...string) string {
+	conds := make([]string, len(tables))
+	for i, table := range tables {
+		conds[i] = table + ".deleted_at IS NULL"
+	}
+	return strings.Join(conds, " AND ")
+}
+
+// 报表时区
+// 时间按UTC存储，按天、按小时分组的统计都有reportTZ参数：统计区间的边界在Go中按报表时区的自然日计算后转为UTC，
+// 分组表达式把时间列换算为报表时区的本地时间，MySQL用 CONVERT_TZ，SQLite用 datetime 的偏移修饰符
+
+// reportZone reportTZ为nil时使用默认时区
+func reportZone(reportTZ *time.Location) *time.Location {
+	if reportTZ == nil {
+		return DefaultReportTZ
+	}
+	return reportTZ
+}
+
+// startOfDay t在tz中所在自然日的零点
+func startOfDay(t time.Time, tz *time.Location) time.Time {
+	t = t.In(tz)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, tz)
+}
+
+// zoneOffset tz在[from, to]内的UTC偏移（秒），期间有夏令时切换时fixed为false
+// 夏令时至少持续数周，按天检查即可发现切换
+func zoneOffset(tz *time.Location, from, to time.Time) (offset int, fixed bool) {
+	_, offset = from.In(tz).Zone()
+	for t := from; t.Before(to); t = t.Add(24 * time.Hour) {
+		if _, o := t.In(tz).Zone(); o != offset {
+			return 0, false
+		}
+	}
+	if _, o := to.In(tz).Zone(); o != offset {
+		return 0, false
+	}
+	return offset, true
+}
+
+// mysqlZone CONVERT_TZ 使用的时区：区间内偏移固定时用偏移量（如 +08:00），不依赖MySQL的时区表；
+// 有夏令时切换时用时区名，需要MySQL已加载时区表（mysql_tzinfo_to_sql）
+func mysqlZone(tz *time.Location, from, to time.Time) (string, error) {
+	if offset, fixed := zoneOffset(tz, from, to); fixed {
+		sign := '+'
+		if offset < 0 {
+			sign, offset = '-', -offset
+		}
+		return fmt.Sprintf("%c%02d:%02d", sign, offset/3600, offset%3600/60), nil
+	}
+	name := tz.String()
+	if strings.ContainsAny(name, "'\\") {
+		return "", fmt.Errorf("无效的时区: %s", name)
+	}
+	return name, nil
+}
+
+// localTime 把UTC存储的时间列换算为报表时区本地时间的SQL表达式，from、to为统计区间
+func (s *StatisticsService) localTime(column string, tz *time.Location, from, to time.Time) (string, error) {
+	if s.db.Dialector.Name() == "sqlite" {
+		offset, fixed := zoneOffset(tz, from, to)
+		if !fixed {
+			return "", fmt.Errorf("SQLite不支持统计区间内有夏令时切换的时区: %s", tz)
+		}
+		return fmt.Sprintf("datetime(%s, '%+d minutes')", column, offset/60), nil
+	}
+	zone, err := mysqlZone(tz, from, to)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("CONVERT_TZ(%s, '+00:00', '%s')", column, zone), nil
+}
+
+// localHour 本地时间表达式的小时（0-23）
+func (s *StatisticsService) localHour(local string) string {
+	if s.db.Dialector.Name() == "sqlite" {
+		return "CAST(strftime('%H', " + local + ") AS INTEGER)"
+	}
+	return "HOUR(" + local + ")"
 }
 
 // SalesStatistics 销售统计数据
@@ -160,24 +273,33 @@ type DashboardData struct {
 	SalesGrowthRate float64 `json:"sales_growth_rate"`
 }
 
-// GetSalesStatistics 获取销售统计数据
-func (s *StatisticsService) GetSalesStatistics(startDate, endDate time.Time) ([]SalesStatistics, error) {
+// GetSalesStatistics 获取销售统计数据，按报表时区的自然日分组，统计startDate所在日到endDate所在日（含）
+func (s *StatisticsService) GetSalesStatistics(startDate, endDate time.Time, reportTZ *time.Location) ([]SalesStatistics, error) {
 	var results []SalesStatistics
 
+	tz := reportZone(reportTZ)
+	start := startOfDay(startDate, tz)
+	end := startOfDay(endDate, tz).AddDate(0, 0, 1)
+	local, err := s.localTime("created_at", tz, start, end)
+	if err != nil {
+		return nil, err
+	}
+
 	sql := `
 		SELECT 
-			DATE(created_at) as date,
+			DATE(` + local + `) as date,
 			COUNT(*) as order_count,
 			SUM(pay_amount) as sales_amount,
 			COUNT(DISTINCT user_id) as user_count,
 			AVG(pay_amount) as avg_order_value
 		FROM orders 
-		WHERE created_at >= ? AND created_at <= ? AND status >= 2
-		GROUP BY DATE(created_at)
+		WHERE created_at >= ? AND created_at < ? AND status >= 2
+			AND ` + notDeleted("orders") + `
+		GROUP BY DATE(` + local + `)
 		ORDER BY date
 	`
 
-	err := s.db.Raw(sql, startDate, endDate).Scan(&results).Error
+	err = s.db.Raw(sql, start.UTC(), end.UTC()).Scan(&results).Error
 	return results, err
 }
 
@@ -196,9 +318,10 @@ func (s *StatisticsService) GetProductSalesRank(startDate, endDate time.Time, li
 		FROM order_items oi
 		JOIN orders o ON oi.order_id = o.id
 		JOIN products p ON oi.product_id = p.id
-		LEFT JOIN categories c ON p.category_id = c.id
-		LEFT JOIN brands b ON p.brand_id = b.id
+		LEFT JOIN categories c ON p.category_id = c.id AND ` + notDeleted("c") + `
+		LEFT JOIN brands b ON p.brand_id = b.id AND ` + notDeleted("b") + `
 		WHERE o.created_at >= ? AND o.created_at <= ? AND o.status >= 2
+			AND ` + notDeleted("oi", "o", "p") + `
 		GROUP BY p.id, p.name, c.name, b.name
 		ORDER BY sales_count DESC
 		LIMIT ?
@@ -209,8 +332,17 @@ func (s *StatisticsService) GetProductSalesRank(startDate, endDate time.Time, li
 }
 
 // GetUserBehaviorAnalysis 获取用户行为分析
+// 注册天数在Go中根据注册时间计算，不依赖数据库的日期函数，MySQL和SQLite结果一致
 func (s *StatisticsService) GetUserBehaviorAnalysis(startDate, endDate time.Time, limit int) ([]UserBehaviorAnalysis, error) {
-	var results []UserBehaviorAnalysis
+	var rows []struct {
+		UserID       uint
+		Username     string
+		OrderCount   int64
+		TotalAmount  int64
+		AvgAmount    float64
+		LastOrderAt  aggregateTime
+		RegisteredAt time.Time
+	}
 
 	sql := `
 		SELECT 
@@ -220,56 +352,412 @@ func (s *StatisticsService) GetUserBehaviorAnalysis(startDate, endDate time.Time
 			COALESCE(SUM(o.pay_amount), 0) as total_amount,
 			COALESCE(AVG(o.pay_amount), 0) as avg_amount,
 			MAX(o.created_at) as last_order_at,
-			DATEDIFF(NOW(), u.created_at) as register_days
+			u.created_at as registered_at
 		FROM users u
 		LEFT JOIN orders o ON u.id = o.user_id 
 			AND o.created_at >= ? AND o.created_at <= ? 
 			AND o.status >= 2
-		WHERE u.created_at <= ?
+			AND ` + notDeleted("o") + `
+		WHERE u.created_at <= ? AND ` + notDeleted("u") + `
 		GROUP BY u.id, u.username, u.created_at
-		HAVING order_count > 0
+		HAVING COUNT(o.id) > 0
 		ORDER BY total_amount DESC
 		LIMIT ?
 	`
 
-	err := s.db.Raw(sql, startDate, endDate, endDate, limit).Scan(&results).Error
-	return results, err
+	if err := s.db.Raw(sql, startDate, endDate, endDate, limit).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	results := make([]UserBehaviorAnalysis, len(rows))
+	for i, row := range rows {
+		results[i] = UserBehaviorAnalysis{
+			UserID:       row.UserID,
+			Username:     row.Username,
+			OrderCount:   row.OrderCount,
+			TotalAmount:  row.TotalAmount,
+			AvgAmount:    row.AvgAmount,
+			LastOrderAt:  row.LastOrderAt.Time,
+			RegisterDays: daysBetween(row.RegisteredAt, now),
+		}
+	}
+	return results, nil
+}
+
+// Spender 消费排行榜中的用户
+type Spender struct {
+	UserID      uint      `json:"user_id"`
+	Username    string    `json:"username"`
+	TotalCents  int64     `json:"total_cents"`
+	OrderCount  int64     `json:"order_count"`
+	LastOrderAt time.Time `json:"last_order_at"`
+}
+
+// GetTopSpenders 获取消费排行榜（按已支付订单的实付金额）
+// 金额相同时按订单数、再按用户ID排序，保证每次查询顺序一致，分页结果稳定
+func (s *StatisticsService) GetTopSpenders(startDate, endDate time.Time, limit int) ([]Spender, error) {
+	var rows []struct {
+		UserID      uint
+		Username    string
+		TotalCents  int64
+		OrderCount  int64
+		LastOrderAt aggregateTime
+	}
+
+	sql := `
+		SELECT 
+			u.id as user_id,
+			u.username,
+			SUM(o.pay_amount) as total_cents,
+			COUNT(o.id) as order_count,
+			MAX(o.created_at) as last_order_at
+		FROM orders o
+		JOIN users u ON o.user_id = u.id
+		WHERE o.created_at >= ? AND o.created_at <= ? AND o.status >= 2
+			AND ` + notDeleted("o", "u") + `
+		GROUP BY u.id, u.username
+		ORDER BY total_cents DESC, order_count DESC, u.id ASC
+		LIMIT ?
+	`
+
+	if err := s.db.Raw(sql, startDate, endDate, limit).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	results := make([]Spender, len(rows))
+	for i, row := range rows {
+		results[i] = Spender{
+			UserID:      row.UserID,
+			Username:    row.Username,
+			TotalCents:  row.TotalCents,
+			OrderCount:  row.OrderCount,
+			LastOrderAt: row.LastOrderAt.Time,
+		}
+	}
+	return results, nil
+}
+
+// aggregateTime 接收MAX()等聚合函数返回的时间
+// MySQL按列类型返回time.Time，SQLite的聚合结果没有列类型，返回的是字符串
+type aggregateTime struct {
+	time.Time
+}
+
+// aggregateTimeLayouts SQLite驱动写入时间时使用的格式
+var aggregateTimeLayouts = []string{
+	"2006-01-02 15:04:05.999999999-07:00",
+	"2006-01-02T15:04:05.999999999-07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02 15:04:05",
+}
+
+// Scan 实现sql.Scanner
+func (t *aggregateTime) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		t.Time = time.Time{}
+		return nil
+	case time.Time:
+		t.Time = v
+		return nil
+	case []byte:
+		return t.parse(string(v))
+	case string:
+		return t.parse(v)
+	}
+	return fmt.Errorf("无法将 %T 转换为时间", value)
+}
+
+// Value 实现driver.Valuer
+func (t aggregateTime) Value() (driver.Value, error) {
+	return t.Time, nil
+}
+
+func (t *aggregateTime) parse(s string) error {
+	for _, layout := range aggregateTimeLayouts {
+		// 不带时区的时间按UTC存储
+		if parsed, err := time.ParseInLocation(layout, s, time.UTC); err == nil {
+			t.Time = parsed
+			return nil
+		}
+	}
+	return fmt.Errorf("无法解析时间: %s", s)
+}
+
+// daysBetween 计算两个时间之间相差的自然日数，与MySQL的DATEDIFF(to, from)一致
+func daysBetween(from, to time.Time) int {
+	from = from.In(to.Location())
+	start := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	end := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+	return int(end.Sub(start).Hours() / 24)
+}
+
+// ErrUnsupportedExportFormat 不支持的导出格式
+var ErrUnsupportedExportFormat = errors.New("不支持的导出格式，可选 csv、xlsx")
+
+// userBehaviorExportHeader 用户行为分析导出的表头
+var userBehaviorExportHeader = []interface{}{"用户ID", "用户名", "订单数", "总金额(元)", "平均金额(元)", "最近下单时间", "注册天数"}
+
+// ExportUserBehavior 导出用户行为分析，format为csv或xlsx
+// 金额转换为元，第一行为表头；数据逐行写入w，不在内存中拼接整个文件
+func (s *StatisticsService) ExportUserBehavior(w io.Writer, format string, start, end time.Time, limit int) error {
+	var writer exportWriter
+	switch format {
+	case "csv":
+		writer = newCSVExportWriter(w)
+	case "xlsx":
+		writer = newXLSXExportWriter(w)
+	default:
+		return ErrUnsupportedExportFormat
+	}
+
+	rows, err := s.GetUserBehaviorAnalysis(start, end, limit)
+	if err != nil {
+		return err
+	}
+
+	if err := writer.WriteRow(userBehaviorExportHeader); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		err := writer.WriteRow([]interface{}{
+			row.UserID,
+			row.Username,
+			row.OrderCount,
+			yuan(row.TotalAmount),
+			yuan(math.Round(row.AvgAmount)),
+			row.LastOrderAt.Format("2006-01-02 15:04:05"),
+			row.RegisterDays,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return writer.Close()
+}
+
+// yuan 金额从分转换为元
+type yuan int64
+
+// String 保留两位小数
+func (y yuan) String() string {
+	return strconv.FormatFloat(float64(y)/100, 'f', 2, 64)
+}
+
+// exportWriter 按行写入导出文件
+type exportWriter interface {
+	WriteRow(cells []interface{}) error
+	Close() error
+}
+
+// csvExportWriter CSV导出
+type csvExportWriter struct {
+	w      io.Writer
+	csv    *csv.Writer
+	header bool
+}
+
+func newCSVExportWriter(w io.Writer) *csvExportWriter {
+	return &csvExportWriter{w: w, csv: csv.NewWriter(w)}
+}
+
+// WriteRow 写入一行
+func (e *csvExportWriter) WriteRow(cells []interface{}) error {
+	if !e.header {
+		// 写入UTF-8 BOM，Excel直接打开时中文不会乱码
+		if _, err := io.WriteString(e.w, "\xEF\xBB\xBF"); err != nil {
+			return err
+		}
+		e.header = true
+	}
+
+	record := make([]string, len(cells))
+	for i, cell := range cells {
+		record[i] = fmt.Sprint(cell)
+	}
+	return e.csv.Write(record)
+}
+
+// Close 刷新缓冲区
+func (e *csvExportWriter) Close() error {
+	e.csv.Flush()
+	return e.csv.Error()
+}
+
+// xlsxExportWriter XLSX导出，直接生成只有一个工作表的最小xlsx文件（zip包），行数据流式写入工作表
+type xlsxExportWriter struct {
+	zip   *zip.Writer
+	sheet io.Writer
+	row   int
+	err   error
+}
+
+// xlsxStaticParts xlsx中除工作表外的固定文件
+var xlsxStaticParts = []struct{ name, content string }{
+	{"[Content_Types].xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`</Types>`},
+	{"_rels/.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`},
+	{"xl/workbook.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="Sheet1" sheetId="1" r:id="rId1"/></sheets></workbook>`},
+	{"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`</Relationships>`},
+}
+
+func newXLSXExportWriter(w io.Writer) *xlsxExportWriter {
+	e := &xlsxExportWriter{zip: zip.NewWriter(w)}
+	for _, part := range xlsxStaticParts {
+		e.writePart(part.name, part.content)
+	}
+
+	// 工作表放在最后，打开后一直写到Close
+	if e.err == nil {
+		e.sheet, e.err = e.zip.Create("xl/worksheets/sheet1.xml")
+	}
+	e.writeString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	return e
+}
+
+func (e *xlsxExportWriter) writePart(name, content string) {
+	if e.err != nil {
+		return
+	}
+	var part io.Writer
+	if part, e.err = e.zip.Create(name); e.err == nil {
+		_, e.err = io.WriteString(part, content)
+	}
+}
+
+func (e *xlsxExportWriter) writeString(s string) {
+	if e.err == nil {
+		_, e.err = io.WriteString(e.sheet, s)
+	}
+}
+
+// WriteRow 写入一行，数字写成数值单元格，其他写成文本单元格
+func (e *xlsxExportWriter) WriteRow(cells []interface{}) error {
+	e.row++
+	e.writeString(fmt.Sprintf(`<row r="%d">`, e.row))
+	for i, cell := range cells {
+		ref := xlsxCellRef(i, e.row)
+		switch v := cell.(type) {
+		case int, int64, uint, yuan:
+			e.writeString(fmt.Sprintf(`<c r="%s"><v>%v</v></c>`, ref, v))
+		default:
+			var text strings.Builder
+			xml.EscapeText(&text, []byte(fmt.Sprint(v)))
+			e.writeString(fmt.Sprintf(`<c r="%s" t="inlineStr"><is><t>%s</t></is></c>`, ref, text.String()))
+		}
+	}
+	e.writeString(`</row>`)
+	return e.err
 }
 
-// GetDashboardData 获取数据大屏数据
-func (s *StatisticsService) GetDashboardData() (*DashboardData, error) {
-	now := time.Now()
-	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
-	yesterday := today.AddDate(0, 0, -1)
+// Close 结束工作表并写入zip目录
+func (e *xlsxExportWriter) Close() error {
+	e.writeString(`</sheetData></worksheet>`)
+	if e.err != nil {
+		return e.err
+	}
+	return e.zip.Close()
+}
+
+// xlsxCellRef 单元格坐标，col从0开始，如(0,1)为A1
+func xlsxCellRef(col, row int) string {
+	name := ""
+	for col++; col > 0; col = (col - 1) / 26 {
+		name = string(rune('A'+(col-1)%26)) + name
+	}
+	return name + strconv.Itoa(row)
+}
+
+// dashboardConcurrency 数据大屏同时执行的统计查询数上限
+const dashboardConcurrency = 4
+
+// GetDashboardData 获取数据大屏数据，今日、昨日按报表时区的自然日计算
+// 各项统计互不依赖，最多dashboardConcurrency个查询并发执行，每个查询只写自己的字段，无需加锁；
+// 任一查询失败时取消其余查询并返回第一个错误。增长率依赖今日和昨日两项结果，等全部查询完成后再计算
+func (s *StatisticsService) GetDashboardData(ctx context.Context, reportTZ *time.Location) (*DashboardData, error) {
+	tz := reportZone(reportTZ)
+	todayStart := startOfDay(s.now(), tz)
+	today := todayStart.UTC()
+	yesterday := todayStart.AddDate(0, 0, -1).UTC()
 
 	data := &DashboardData{}
+	var todaySales, totalSales, yesterdaySales struct{ Total int64 }
+	var yesterdayOrders int64
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(dashboardConcurrency)
+	query := func(fn func(db *gorm.DB) *gorm.DB) {
+		g.Go(func() error {
+			return fn(s.db.WithContext(gctx)).Error
+		})
+	}
 
 	// 今日订单数
-	s.db.Model(&Order{}).Where("created_at >= ? AND status >= 2", today).Count(&data.TodayOrders)
+	query(func(db *gorm.DB) *gorm.DB {
+		return db.Model(&models.Order{}).Where("created_at >= ? AND status >= 2", today).Count(&data.TodayOrders)
+	})
 
 	// 今日销售额
-	var todaySales struct{ Total int64 }
-	s.db.Model(&Order{}).Select("COALESCE(SUM(pay_amount), 0) as total").
-		Where("created_at >= ? AND status >= 2", today).Scan(&todaySales)
-	data.TodaySales = todaySales.Total
+	query(func(db *gorm.DB) *gorm.DB {
+		return db.Model(&models.Order{}).Select("COALESCE(SUM(pay_amount), 0) as total").
+			Where("created_at >= ? AND status >= 2", today).Scan(&todaySales)
+	})
 
 	// 今日新增用户
-	s.db.Model(&User{}).Where("created_at >= ?", today).Count(&data.TodayUsers)
+	query(func(db *gorm.DB) *gorm.DB {
+		return db.Model(&models.User{}).Where("created_at >= ?", today).Count(&data.TodayUsers)
+	})
 
 	// 总订单数
-	s.db.Model(&Order{}).Where("status >= 2").Count(&data.TotalOrders)
+	query(func(db *gorm.DB) *gorm.DB {
+		return db.Model(&models.Order{}).Where("status >= 2").Count(&data.TotalOrders)
+	})
 
 	// 总销售额
-	var totalSales struct{ Total int64 }
-	s.db.Model(&Order{}).Select("COALESCE(SUM(pay_amount), 0) as total").
-		Where("status >= 2").Scan(&totalSales)
-	data.TotalSales = totalSales.Total
+	query(func(db *gorm.DB) *gorm.DB {
+		return db.Model(&models.Order{}).Select("COALESCE(SUM(pay_amount), 0) as total").
+			Where("status >= 2").Scan(&totalSales)
+	})
 
 	// 总用户数
-	s.db.Model(&User{}).Count(&data.TotalUsers)
+	query(func(db *gorm.DB) *gorm.DB {
+		return db.Model(&models.User{}).Count(&data.TotalUsers)
+	})
 
 	// 总商品数
-	s.db.Model(&Product{}).Where("status = 1").Count(&data.TotalProducts)
+	query(func(db *gorm.DB) *gorm.DB {
+		return db.Model(&models.Product{}).Where("status = 1").Count(&data.TotalProducts)
+	})
+
+	// 昨日订单数和销售额，用于计算增长率
+	query(func(db *gorm.DB) *gorm.DB {
+		return db.Model(&models.Order{}).Where("created_at >= ? AND created_at < ? AND status >= 2", yesterday, today).Count(&yesterdayOrders)
+	})
+	query(func(db *gorm.DB) *gorm.DB {
+		return db.Model(&models.Order{}).Select("COALESCE(SUM(pay_amount), 0) as total").
+			Where("created_at >= ? AND created_at < ? AND status >= 2", yesterday, today).Scan(&yesterdaySales)
+	})
+
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+	data.TodaySales = todaySales.Total
+	data.TotalSales = totalSales.Total
 
 	// 平均订单价值
 	if data.TotalOrders > 0 {
@@ -277,13 +765,6 @@ func (s *StatisticsService) GetDashboardData() (*DashboardData, error) {
 	}
 
 	// 计算增长率
-	var yesterdayOrders int64
-	s.db.Model(&Order{}).Where("created_at >= ? AND created_at < ? AND status >= 2", yesterday, today).Count(&yesterdayOrders)
-
-	var yesterdaySales struct{ Total int64 }
-	s.db.Model(&Order{}).Select("COALESCE(SUM(pay_amount), 0) as total").
-		Where("created_at >= ? AND created_at < ? AND status >= 2", yesterday, today).Scan(&yesterdaySales)
-
 	if yesterdayOrders > 0 {
 		data.OrderGrowthRate = float64(data.TodayOrders-yesterdayOrders) / float64(yesterdayOrders) * 100
 	}
@@ -294,61 +775,110 @@ func (s *StatisticsService) GetDashboardData() (*DashboardData, error) {
 	return data, nil
 }
 
+// CategorySalesStat 分类销售统计
+type CategorySalesStat struct {
+	CategoryID    uint    `json:"category_id"`
+	CategoryName  string  `json:"category_name"`
+	OrderCount    int64   `json:"order_count"`     // 包含该分类商品的订单数（去重）
+	SalesCount    int64   `json:"sales_count"`     // 销售件数
+	SalesAmount   int64   `json:"sales_amount"`    // 销售额（分）
+	AvgOrderValue float64 `json:"avg_order_value"` // 平均每单该分类的销售额（分）
+	ItemsPerOrder float64 `json:"items_per_order"` // 平均每单该分类的件数
+}
+
 // GetSalesStatisticsByCategory 按分类获取销售统计
-func (s *StatisticsService) GetSalesStatisticsByCategory(startDate, endDate time.Time) ([]map[string]interface{}, error) {
-	var results []map[string]interface{}
+// 先按 分类+订单 汇总订单项，再按分类汇总，订单数按订单去重，平均值的分母是订单数而不是订单项行数
+func (s *StatisticsService) GetSalesStatisticsByCategory(startDate, endDate time.Time) ([]CategorySalesStat, error) {
+	var results []CategorySalesStat
 
 	sql := `
 		SELECT 
 			c.id as category_id,
 			c.name as category_name,
-			COUNT(DISTINCT o.id) as order_count,
-			SUM(oi.quantity) as sales_count,
-			SUM(oi.total_price) as sales_amount
+			COUNT(co.order_id) as order_count,
+			COALESCE(SUM(co.quantity), 0) as sales_count,
+			COALESCE(SUM(co.amount), 0) as sales_amount
 		FROM categories c
-		LEFT JOIN products p ON c.id = p.category_id
-		LEFT JOIN order_items oi ON p.id = oi.product_id
-		LEFT JOIN orders o ON oi.order_id = o.id 
-			AND o.created_at >= ? AND o.created_at <= ? 
-			AND o.status >= 2
+		LEFT JOIN (
+			SELECT 
+				p.category_id,
+				oi.order_id,
+				SUM(oi.quantity) as quantity,
+				SUM(oi.total_price) as amount
+			FROM order_items oi
+			JOIN orders o ON oi.order_id = o.id
+			JOIN products p ON oi.product_id = p.id
+			WHERE o.created_at >= ? AND o.created_at <= ? AND o.status >= 2
+				AND ` + notDeleted("oi", "o", "p") + `
+			GROUP BY p.category_id, oi.order_id
+		) co ON co.category_id = c.id
+		WHERE ` + notDeleted("c") + `
 		GROUP BY c.id, c.name
-		ORDER BY sales_amount DESC
+		ORDER BY sales_amount DESC, c.id
 	`
 
-	err := s.db.Raw(sql, startDate, endDate).Scan(&results).Error
-	return results, err
+	if err := s.db.Raw(sql, startDate, endDate).Scan(&results).Error; err != nil {
+		return nil, err
+	}
+
+	for i := range results {
+		if results[i].OrderCount > 0 {
+			results[i].AvgOrderValue = float64(results[i].SalesAmount) / float64(results[i].OrderCount)
+			results[i].ItemsPerOrder = float64(results[i].SalesCount) / float64(results[i].OrderCount)
+		}
+	}
+	return results, nil
 }
 
-// GetHourlyOrderStatistics 获取小时级订单统计
-func (s *StatisticsService) GetHourlyOrderStatistics(date time.Time) ([]map[string]interface{}, error) {
+// GetHourlyOrderStatistics 获取小时级订单统计，统计date在报表时区所在的自然日，按本地时间的小时分组
+func (s *StatisticsService) GetHourlyOrderStatistics(date time.Time, reportTZ *time.Location) ([]map[string]interface{}, error) {
 	var results []map[string]interface{}
 
-	startOfDay := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
-	endOfDay := startOfDay.AddDate(0, 0, 1)
+	tz := reportZone(reportTZ)
+	dayStart := startOfDay(date, tz)
+	dayEnd := dayStart.AddDate(0, 0, 1)
+	local, err := s.localTime("created_at", tz, dayStart, dayEnd)
+	if err != nil {
+		return nil, err
+	}
+	hour := s.localHour(local)
 
 	sql := `
 		SELECT 
-			HOUR(created_at) as hour,
+			` + hour + ` as hour,
 			COUNT(*) as order_count,
 			SUM(pay_amount) as sales_amount,
 			COUNT(DISTINCT user_id) as user_count
 		FROM orders 
 		WHERE created_at >= ? AND created_at < ? AND status >= 2
-		GROUP BY HOUR(created_at)
+			AND ` + notDeleted("orders") + `
+		GROUP BY ` + hour + `
 		ORDER BY hour
 	`
 
-	err := s.db.Raw(sql, startOfDay, endOfDay).Scan(&results).Error
+	err = s.db.Raw(sql, dayStart.UTC(), dayEnd.UTC()).Scan(&results).Error
 	return results, err
 }
 
 // GetUserRetentionAnalysis 获取用户留存分析
-func (s *StatisticsService) GetUserRetentionAnalysis(startDate time.Time) ([]map[string]interface{}, error) {
+// 统计startDate在报表时区所在自然日注册的用户，在注册后第1、7、30天（同样按报表时区的自然日）是否下单
+func (s *StatisticsService) GetUserRetentionAnalysis(startDate time.Time, reportTZ *time.Location) ([]map[string]interface{}, error) {
 	var results []map[string]interface{}
 
+	tz := reportZone(reportTZ)
+	day := startOfDay(startDate, tz)
+	local, err := s.localTime("u.created_at", tz, day, day.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, err
+	}
+	// 第n天的区间[day+n, day+n+1)，按自然日加减，跨夏令时切换时同样准确
+	window := func(n int) []interface{} {
+		return []interface{}{day.AddDate(0, 0, n).UTC(), day.AddDate(0, 0, n+1).UTC()}
+	}
+
 	sql := `
 		SELECT 
-			DATE(u.created_at) as register_date,
+			DATE(` + local + `) as register_date,
 			COUNT(u.id) as register_count,
 			COUNT(CASE WHEN o1.user_id IS NOT NULL THEN 1 END) as day1_retention,
 			COUNT(CASE WHEN o7.user_id IS NOT NULL THEN 1 END) as day7_retention,
@@ -357,58 +887,74 @@ func (s *StatisticsService) GetUserRetentionAnalysis(startDate time.Time) ([]map
 		LEFT JOIN (
 			SELECT DISTINCT user_id 
 			FROM orders 
-			WHERE created_at >= DATE_ADD(?, INTERVAL 1 DAY) 
-				AND created_at < DATE_ADD(?, INTERVAL 2 DAY)
+			WHERE created_at >= ? AND created_at < ?
 				AND status >= 2
+				AND ` + notDeleted("orders") + `
 		) o1 ON u.id = o1.user_id
 		LEFT JOIN (
 			SELECT DISTINCT user_id 
 			FROM orders 
-			WHERE created_at >= DATE_ADD(?, INTERVAL 7 DAY) 
-				AND created_at < DATE_ADD(?, INTERVAL 8 DAY)
+			WHERE created_at >= ? AND created_at < ?
 				AND status >= 2
+				AND ` + notDeleted("orders") + `
 		) o7 ON u.id = o7.user_id
 		LEFT JOIN (
 			SELECT DISTINCT user_id 
 			FROM orders 
-			WHERE created_at >= DATE_ADD(?, INTERVAL 30 DAY) 
-				AND created_at < DATE_ADD(?, INTERVAL 31 DAY)
+			WHERE created_at >= ? AND created_at < ?
 				AND status >= 2
+				AND ` + notDeleted("orders") + `
 		) o30 ON u.id = o30.user_id
-		WHERE u.created_at >= ? AND u.created_at < DATE_ADD(?, INTERVAL 1 DAY)
-		GROUP BY DATE(u.created_at)
+		WHERE u.created_at >= ? AND u.created_at < ?
+			AND ` + notDeleted("u") + `
+		GROUP BY DATE(` + local + `)
 		ORDER BY register_date
 	`
 
-	err := s.db.Raw(sql, startDate, startDate, startDate, startDate, startDate, startDate, startDate, startDate).Scan(&results).Error
+	var args []interface{}
+	for _, n := range []int{1, 7, 30, 0} {
+		args = append(args, window(n)...)
+	}
+	err = s.db.Raw(sql, args...).Scan(&results).Error
 	return results, err
 }
 
-// GetCohortAnalysis 获取队列分析
-func (s *StatisticsService) GetCohortAnalysis(startDate time.Time, months int) ([]map[string]interface{}, error) {
+// GetCohortAnalysis 获取队列分析，注册月份和下单月份按报表时区计算（使用MySQL的日期函数）
+func (s *StatisticsService) GetCohortAnalysis(startDate time.Time, months int, reportTZ *time.Location) ([]map[string]interface{}, error) {
 	var results []map[string]interface{}
 
+	tz := reportZone(reportTZ)
+	registered, err := s.localTime("u.created_at", tz, startDate, s.now())
+	if err != nil {
+		return nil, err
+	}
+	ordered, err := s.localTime("o.created_at", tz, startDate, s.now())
+	if err != nil {
+		return nil, err
+	}
+
 	// 队列分析：按注册月份分组，分析每个月份用户在后续月份的购买行为
 	sql := `
 		SELECT 
-			DATE_FORMAT(u.created_at, '%Y-%m') as cohort_month,
+			DATE_FORMAT(` + registered + `, '%Y-%m') as cohort_month,
 			COUNT(DISTINCT u.id) as total_users,
-			COUNT(DISTINCT CASE WHEN PERIOD_DIFF(DATE_FORMAT(o.created_at, '%Y%m'), DATE_FORMAT(u.created_at, '%Y%m')) = 0 THEN u.id END) as month_0,
-			COUNT(DISTINCT CASE WHEN PERIOD_DIFF(DATE_FORMAT(o.created_at, '%Y%m'), DATE_FORMAT(u.created_at, '%Y%m')) = 1 THEN u.id END) as month_1,
-			COUNT(DISTINCT CASE WHEN PERIOD_DIFF(DATE_FORMAT(o.created_at, '%Y%m'), DATE_FORMAT(u.created_at, '%Y%m')) = 2 THEN u.id END) as month_2,
-			COUNT(DISTINCT CASE WHEN PERIOD_DIFF(DATE_FORMAT(o.created_at, '%Y%m'), DATE_FORMAT(u.created_at, '%Y%m')) = 3 THEN u.id END) as month_3
+			COUNT(DISTINCT CASE WHEN PERIOD_DIFF(DATE_FORMAT(` + ordered + `, '%Y%m'), DATE_FORMAT(` + registered + `, '%Y%m')) = 0 THEN u.id END) as month_0,
+			COUNT(DISTINCT CASE WHEN PERIOD_DIFF(DATE_FORMAT(` + ordered + `, '%Y%m'), DATE_FORMAT(` + registered + `, '%Y%m')) = 1 THEN u.id END) as month_1,
+			COUNT(DISTINCT CASE WHEN PERIOD_DIFF(DATE_FORMAT(` + ordered + `, '%Y%m'), DATE_FORMAT(` + registered + `, '%Y%m')) = 2 THEN u.id END) as month_2,
+			COUNT(DISTINCT CASE WHEN PERIOD_DIFF(DATE_FORMAT(` + ordered + `, '%Y%m'), DATE_FORMAT(` + registered + `, '%Y%m')) = 3 THEN u.id END) as month_3
 		FROM users u
-		LEFT JOIN orders o ON u.id = o.user_id AND o.status >= 2
-		WHERE u.created_at >= ?
-		GROUP BY DATE_FORMAT(u.created_at, '%Y-%m')
+		LEFT JOIN orders o ON u.id = o.user_id AND o.status >= 2 AND ` + notDeleted("o") + `
+		WHERE u.created_at >= ? AND ` + notDeleted("u") + `
+		GROUP BY DATE_FORMAT(` + registered + `, '%Y-%m')
 		ORDER BY cohort_month
 	`
 
-	err := s.db.Raw(sql, startDate).Scan(&results).Error
+	err = s.db.Raw(sql, startDate.UTC()).Scan(&results).Error
 	return results, err
 }
 
 // GetRFMAnalysis 获取RFM分析（最近购买时间、购买频率、购买金额）
+// 时间按UTC存储，最近购买天数以 UTC_TIMESTAMP() 为当前时间计算
 func (s *StatisticsService) GetRFMAnalysis() ([]map[string]interface{}, error) {
 	var results []map[string]interface{}
 
@@ -416,14 +962,14 @@ func (s *StatisticsService) GetRFMAnalysis() ([]map[string]interface{}, error) {
 		SELECT 
 			u.id as user_id,
 			u.username,
-			DATEDIFF(NOW(), MAX(o.created_at)) as recency,
+			DATEDIFF(UTC_TIMESTAMP(), MAX(o.created_at)) as recency,
 			COUNT(o.id) as frequency,
 			SUM(o.pay_amount) as monetary,
 			CASE 
-				WHEN DATEDIFF(NOW(), MAX(o.created_at)) <= 30 THEN 5
-				WHEN DATEDIFF(NOW(), MAX(o.created_at)) <= 60 THEN 4
-				WHEN DATEDIFF(NOW(), MAX(o.created_at)) <= 90 THEN 3
-				WHEN DATEDIFF(NOW(), MAX(o.created_at)) <= 180 THEN 2
+				WHEN DATEDIFF(UTC_TIMESTAMP(), MAX(o.created_at)) <= 30 THEN 5
+				WHEN DATEDIFF(UTC_TIMESTAMP(), MAX(o.created_at)) <= 60 THEN 4
+				WHEN DATEDIFF(UTC_TIMESTAMP(), MAX(o.created_at)) <= 90 THEN 3
+				WHEN DATEDIFF(UTC_TIMESTAMP(), MAX(o.created_at)) <= 180 THEN 2
 				ELSE 1
 			END as r_score,
 			CASE 
@@ -442,6 +988,7 @@ func (s *StatisticsService) GetRFMAnalysis() ([]map[string]interface{}, error) {
 			END as m_score
 		FROM users u
 		JOIN orders o ON u.id = o.user_id AND o.status >= 2
+		WHERE ` + notDeleted("u", "o") + `
 		GROUP BY u.id, u.username
 		ORDER BY monetary DESC
 	`
@@ -455,7 +1002,7 @@ func SeedTestData(db *gorm.DB) error {
 	fmt.Println("开始填充测试数据...")
 
 	// 创建用户
-	users := []User{
+	users := []models.User{
 		{Username: "user1", Email: "user1@example.com", Phone: "13800138001", Password: "password", Nickname: "用户1"},
 		{Username: "user2", Email: "user2@example.com", Phone: "13800138002", Password: "password", Nickname: "用户2"},
 		{Username: "user3", Email: "user3@example.com", Phone: "13800138003", Password: "password", Nickname: "用户3"},
@@ -463,7 +1010,7 @@ func SeedTestData(db *gorm.DB) error {
 	db.Create(&users)
 
 	// 创建分类
-	categories := []Category{
+	categories := []models.Category{
 		{Name: "电子产品", Slug: "electronics"},
 		{Name: "服装", Slug: "clothing"},
 		{Name: "图书", Slug: "books"},
@@ -471,7 +1018,7 @@ func SeedTestData(db *gorm.DB) error {
 	db.Create(&categories)
 
 	// 创建品牌
-	brands := []Brand{
+	brands := []models.Brand{
 		{Name: "苹果", Slug: "apple"},
 		{Name: "华为", Slug: "huawei"},
 		{Name: "小米", Slug: "xiaomi"},
@@ -479,7 +1026,7 @@ func SeedTestData(db *gorm.DB) error {
 	db.Create(&brands)
 
 	// 创建商品
-	products := []Product{
+	products := []models.Product{
 		{Name: "iPhone 15", SKU: "IPHONE15", CategoryID: categories[0].ID, BrandID: &brands[0].ID, Price: 599900, Stock: 100, Sales: 50},
 		{Name: "华为P60", SKU: "HUAWEIP60", CategoryID: categories[0].ID, BrandID: &brands[1].ID, Price: 499900, Stock: 80, Sales: 30},
 		{Name: "小米14", SKU: "XIAOMI14", CategoryID: categories[0].ID, BrandID: &brands[2].ID, Price: 399900, Stock: 120, Sales: 80},
@@ -496,17 +1043,17 @@ func SeedTestData(db *gorm.DB) error {
 		price := products[productID-1].Price
 		totalPrice := price * int64(quantity)
 
-		order := Order{
+		order := models.Order{
+			BaseModel:   models.BaseModel{CreatedAt: time.Now().AddDate(0, 0, -i)}, // 不同日期
 			OrderNo:     fmt.Sprintf("ORD%d", time.Now().UnixNano()+int64(i)),
 			UserID:      userID,
 			Status:      4, // 已完成
 			TotalAmount: totalPrice,
 			PayAmount:   totalPrice,
-			CreatedAt:   time.Now().AddDate(0, 0, -i), // 不同日期
 		}
 		db.Create(&order)
 
-		orderItem := OrderItem{
+		orderItem := models.OrderItem{
 			OrderID:     order.ID,
 			ProductID:   productID,
 			Quantity:    quantity,
@@ -531,7 +1078,7 @@ func demonstrateStatistics(db *gorm.DB) {
 	fmt.Println("\n1. 销售统计:")
 	startDate := time.Now().AddDate(0, 0, -30)
 	endDate := time.Now()
-	salesStats, err := statisticsService.GetSalesStatistics(startDate, endDate)
+	salesStats, err := statisticsService.GetSalesStatistics(startDate, endDate, DefaultReportTZ)
 	if err != nil {
 		fmt.Printf("获取销售统计失败: %v\n", err)
 	} else {
@@ -567,7 +1114,7 @@ func demonstrateStatistics(db *gorm.DB) {
 
 	// 4. 数据大屏
 	fmt.Println("\n4. 数据大屏:")
-	dashboard, err := statisticsService.GetDashboardData()
+	dashboard, err := statisticsService.GetDashboardData(context.Background(), DefaultReportTZ)
 	if err != nil {
 		fmt.Printf("获取数据大屏数据失败: %v\n", err)
 	} else {
@@ -586,15 +1133,15 @@ func demonstrateStatistics(db *gorm.DB) {
 		fmt.Printf("获取分类统计失败: %v\n", err)
 	} else {
 		for _, stat := range categoryStats {
-			fmt.Printf("分类: %v, 订单数: %v, 销量: %v, 销售额: %.2f元\n",
-				stat["category_name"], stat["order_count"], stat["sales_count"], 
-				float64(stat["sales_amount"].(int64))/100)
+			fmt.Printf("分类: %s, 订单数: %d, 销量: %d, 销售额: %.2f元, 平均订单价值: %.2f元, 每单件数: %.2f\n",
+				stat.CategoryName, stat.OrderCount, stat.SalesCount, float64(stat.SalesAmount)/100,
+				stat.AvgOrderValue/100, stat.ItemsPerOrder)
 		}
 	}
 
 	// 6. 小时级统计
 	fmt.Println("\n6. 今日小时级订单统计:")
-	hourlyStats, err := statisticsService.GetHourlyOrderStatistics(time.Now())
+	hourlyStats, err := statisticsService.GetHourlyOrderStatistics(time.Now(), DefaultReportTZ)
 	if err != nil {
 		fmt.Printf("获取小时级统计失败: %v\n", err)
 	} else {
@@ -617,6 +1164,25 @@ func demonstrateStatistics(db *gorm.DB) {
 				float64(rfm["monetary"].(int64))/100, rfm["r_score"], rfm["f_score"], rfm["m_score"])
 		}
 	}
+
+	// 8. 消费排行榜
+	fmt.Println("\n8. 消费排行榜:")
+	spenders, err := statisticsService.GetTopSpenders(startDate, endDate, 10)
+	if err != nil {
+		fmt.Printf("获取消费排行榜失败: %v\n", err)
+	} else {
+		for i, spender := range spenders {
+			fmt.Printf("排名%d: %s, 消费: %.2f元, 订单数: %d, 最近下单: %s\n",
+				i+1, spender.Username, float64(spender.TotalCents)/100, spender.OrderCount,
+				spender.LastOrderAt.Format("2006-01-02 15:04"))
+		}
+	}
+
+	// 9. 导出用户行为分析
+	fmt.Println("\n9. 导出用户行为分析(CSV):")
+	if err := statisticsService.ExportUserBehavior(os.Stdout, "csv", startDate, endDate, 10); err != nil {
+		fmt.Printf("导出用户行为分析失败: %v\n", err)
+	}
 }
 
 func main() {
@@ -638,11 +1204,16 @@ func main() {
 	}
 
 	// 迁移数据库
-	db.AutoMigrate(&User{}, &Category{}, &Brand{}, &Product{}, &Order{}, &OrderItem{})
+	db.AutoMigrate(models.All()...)
+
+	// 旧数据按东八区本地时间存储，转换为UTC（只执行一次）
+	if err := MigrateToUTC(db, DefaultReportTZ); err != nil {
+		log.Fatal("转换旧数据时区失败:", err)
+	}
 
 	// 检查是否需要填充测试数据
 	var userCount int64
-	db.Model(&User{}).Count(&userCount)
+	db.Model(&models.User{}).Count(&userCount)
 	if userCount == 0 {
 		if err := SeedTestData(db); err != nil {
 			log.Fatal("填充测试数据失败:", err)
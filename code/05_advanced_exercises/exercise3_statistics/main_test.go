@@ -0,0 +1,611 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm-advanced-exercises/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var testDBSeq int64
+
+// newTestDB 创建独立的内存SQLite数据库并迁移 models.All()，测试结束时关闭
+func newTestDB(t testing.TB) *gorm.DB {
+	t.Helper()
+	dsn := fmt.Sprintf("file:exercise3_%d?mode=memory&cache=shared&_pragma=busy_timeout(5000)", atomic.AddInt64(&testDBSeq, 1))
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("打开测试数据库失败: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("获取数据库连接失败: %v", err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	if err := db.AutoMigrate(models.All()...); err != nil {
+		t.Fatalf("迁移测试数据库失败: %v", err)
+	}
+	return db
+}
+
+// countRows 统计模型对应表（含软删除）的行数
+func countRows(t testing.TB, db *gorm.DB, model interface{}) int64 {
+	t.Helper()
+	var n int64
+	if err := db.Unscoped().Model(model).Count(&n).Error; err != nil {
+		t.Fatalf("统计 %T 失败: %v", model, err)
+	}
+	return n
+}
+
+// TestMigrateAndSeed 在SQLite上迁移共用模型并填充演示数据
+func TestMigrateAndSeed(t *testing.T) {
+	db := newTestDB(t)
+	if err := SeedTestData(db); err != nil {
+		t.Fatalf("填充数据失败: %v", err)
+	}
+	counts := map[interface{}]int64{
+		&models.User{}: 3, &models.Category{}: 3, &models.Brand{}: 3,
+		&models.Product{}: 5, &models.Order{}: 30, &models.OrderItem{}: 30,
+	}
+	for model, want := range counts {
+		if got := countRows(t, db, model); got != want {
+			t.Errorf("%T 有 %d 条，期望 %d", model, got, want)
+		}
+	}
+}
+
+// utc 2024年的UTC时间，统计数据按UTC存储
+func utc(month time.Month, day, hour, min int) time.Time {
+	return time.Date(2024, month, day, hour, min, 0, 0, time.UTC)
+}
+
+// testRowSeq 生成测试数据中唯一的邮箱、手机号、SKU和订单号
+var testRowSeq int64
+
+func createUser(t testing.TB, db *gorm.DB, username string, createdAt time.Time) models.User {
+	t.Helper()
+	n := atomic.AddInt64(&testRowSeq, 1)
+	user := models.User{
+		BaseModel: models.BaseModel{CreatedAt: createdAt},
+		Username:  username,
+		Email:     fmt.Sprintf("user%d@example.com", n),
+		Phone:     fmt.Sprintf("1380000%04d", n),
+		Password:  "password",
+	}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatalf("创建用户失败: %v", err)
+	}
+	return user
+}
+
+func createCategory(t testing.TB, db *gorm.DB, name string) models.Category {
+	t.Helper()
+	category := models.Category{Name: name, Slug: fmt.Sprintf("category-%d", atomic.AddInt64(&testRowSeq, 1))}
+	if err := db.Create(&category).Error; err != nil {
+		t.Fatalf("创建分类失败: %v", err)
+	}
+	return category
+}
+
+func createBrand(t testing.TB, db *gorm.DB, name string) models.Brand {
+	t.Helper()
+	brand := models.Brand{Name: name, Slug: fmt.Sprintf("brand-%d", atomic.AddInt64(&testRowSeq, 1))}
+	if err := db.Create(&brand).Error; err != nil {
+		t.Fatalf("创建品牌失败: %v", err)
+	}
+	return brand
+}
+
+func createProduct(t testing.TB, db *gorm.DB, name string, categoryID uint, brandID *uint, price int64) models.Product {
+	t.Helper()
+	product := models.Product{
+		Name:       name,
+		SKU:        fmt.Sprintf("SKU%d", atomic.AddInt64(&testRowSeq, 1)),
+		CategoryID: categoryID,
+		BrandID:    brandID,
+		Price:      price,
+	}
+	if err := db.Create(&product).Error; err != nil {
+		t.Fatalf("创建商品失败: %v", err)
+	}
+	return product
+}
+
+// orderLine 订单项，金额为商品单价乘数量
+type orderLine struct {
+	product  models.Product
+	quantity int
+}
+
+// createOrder 创建指定时间和实付金额的订单，status>=2为已支付
+func createOrder(t testing.TB, db *gorm.DB, userID uint, status int8, createdAt time.Time, payAmount int64, lines ...orderLine) models.Order {
+	t.Helper()
+	order := models.Order{
+		BaseModel:   models.BaseModel{CreatedAt: createdAt},
+		OrderNo:     fmt.Sprintf("ORD%d", atomic.AddInt64(&testRowSeq, 1)),
+		UserID:      userID,
+		Status:      status,
+		TotalAmount: payAmount,
+		PayAmount:   payAmount,
+	}
+	if err := db.Create(&order).Error; err != nil {
+		t.Fatalf("创建订单失败: %v", err)
+	}
+	for _, line := range lines {
+		item := models.OrderItem{
+			OrderID:     order.ID,
+			ProductID:   line.product.ID,
+			Quantity:    line.quantity,
+			Price:       line.product.Price,
+			TotalPrice:  line.product.Price * int64(line.quantity),
+			ProductName: line.product.Name,
+		}
+		if err := db.Create(&item).Error; err != nil {
+			t.Fatalf("创建订单项失败: %v", err)
+		}
+	}
+	return order
+}
+
+// softDelete 软删除value，value为带ID的模型指针
+func softDelete(t testing.TB, db *gorm.DB, value interface{}) {
+	t.Helper()
+	if err := db.Delete(value).Error; err != nil {
+		t.Fatalf("删除 %T 失败: %v", value, err)
+	}
+}
+
+// seedCrossMidnightOrders 上海时间6月1日23:30和6月2日00:30各有一单，UTC下两单都在6月1日；
+// 另有未支付、已软删除的订单和一单上海时间5月31日23:59（统计区间之前）的订单
+func seedCrossMidnightOrders(t *testing.T, db *gorm.DB) {
+	t.Helper()
+	alice := createUser(t, db, "alice", utc(5, 1, 0, 0))
+	bob := createUser(t, db, "bob", utc(5, 1, 0, 0))
+
+	createOrder(t, db, alice.ID, 4, utc(5, 31, 15, 59), 500) // 上海 05-31 23:59
+	createOrder(t, db, alice.ID, 4, utc(6, 1, 1, 0), 1000)   // 上海 06-01 09:00
+	createOrder(t, db, bob.ID, 2, utc(6, 1, 15, 30), 2000)   // 上海 06-01 23:30
+	createOrder(t, db, alice.ID, 4, utc(6, 1, 16, 30), 3000) // 上海 06-02 00:30
+	createOrder(t, db, bob.ID, 1, utc(6, 1, 16, 45), 9999)   // 待付款
+	deleted := createOrder(t, db, alice.ID, 4, utc(6, 1, 17, 0), 8888)
+	softDelete(t, db, &deleted)
+}
+
+// TestSalesStatisticsReportTimeZone 按报表时区的自然日分组，跨零点的订单归入本地日期，未支付和软删除的订单不计入
+func TestSalesStatisticsReportTimeZone(t *testing.T) {
+	db := newTestDB(t)
+	seedCrossMidnightOrders(t, db)
+	svc := NewStatisticsService(db)
+
+	newYork := time.FixedZone("UTC-5", -5*3600)
+	cases := []struct {
+		name string
+		tz   *time.Location
+		want []SalesStatistics
+	}{
+		{"上海", DefaultReportTZ, []SalesStatistics{
+			{Date: "2024-06-01", OrderCount: 2, SalesAmount: 3000, UserCount: 2, AvgOrderValue: 1500},
+			{Date: "2024-06-02", OrderCount: 1, SalesAmount: 3000, UserCount: 1, AvgOrderValue: 3000},
+		}},
+		{"UTC", time.UTC, []SalesStatistics{
+			{Date: "2024-06-01", OrderCount: 3, SalesAmount: 6000, UserCount: 2, AvgOrderValue: 2000},
+		}},
+		// 当地6月1日从UTC 05:00开始，UTC 01:00的订单属于前一天
+		{"UTC-5", newYork, []SalesStatistics{
+			{Date: "2024-06-01", OrderCount: 2, SalesAmount: 5000, UserCount: 2, AvgOrderValue: 2500},
+		}},
+	}
+	for _, tc := range cases {
+		start := time.Date(2024, 6, 1, 12, 0, 0, 0, tc.tz)
+		end := time.Date(2024, 6, 2, 12, 0, 0, 0, tc.tz)
+		got, err := svc.GetSalesStatistics(start, end, tc.tz)
+		if err != nil {
+			t.Fatalf("%s: 查询销售统计失败: %v", tc.name, err)
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: 销售统计为 %+v，期望 %+v", tc.name, got, tc.want)
+		}
+	}
+}
+
+// TestHourlyOrderStatisticsReportTimeZone 小时统计只包含报表时区当天的订单，小时为本地时间
+func TestHourlyOrderStatisticsReportTimeZone(t *testing.T) {
+	db := newTestDB(t)
+	seedCrossMidnightOrders(t, db)
+	svc := NewStatisticsService(db)
+
+	cases := []struct {
+		name string
+		date time.Time
+		tz   *time.Location
+		want []string
+	}{
+		{"上海6月1日", utc(6, 1, 4, 0), DefaultReportTZ, []string{"9时 1单 1000分 1人", "23时 1单 2000分 1人"}},
+		{"上海6月2日", utc(6, 1, 18, 0), DefaultReportTZ, []string{"0时 1单 3000分 1人"}},
+		{"UTC6月1日", utc(6, 1, 18, 0), time.UTC, []string{"1时 1单 1000分 1人", "15时 1单 2000分 1人", "16时 1单 3000分 1人"}},
+	}
+	for _, tc := range cases {
+		rows, err := svc.GetHourlyOrderStatistics(tc.date, tc.tz)
+		if err != nil {
+			t.Fatalf("%s: 查询小时统计失败: %v", tc.name, err)
+		}
+		var got []string
+		for _, row := range rows {
+			got = append(got, fmt.Sprintf("%v时 %v单 %v分 %v人", row["hour"], row["order_count"], row["sales_amount"], row["user_count"]))
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: 小时统计为 %q，期望 %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+// TestProductSalesRankSkipsSoftDeleted 软删除的订单项、商品不计入排行；分类、品牌已软删除时名称为空，商品仍然计入
+func TestProductSalesRankSkipsSoftDeleted(t *testing.T) {
+	db := newTestDB(t)
+	user := createUser(t, db, "alice", utc(5, 1, 0, 0))
+	phones, books := createCategory(t, db, "手机"), createCategory(t, db, "图书")
+	apple, huawei := createBrand(t, db, "苹果"), createBrand(t, db, "华为")
+	iphone := createProduct(t, db, "iPhone", phones.ID, &apple.ID, 1000)
+	novel := createProduct(t, db, "小说", books.ID, &huawei.ID, 300)
+	removed := createProduct(t, db, "已下架", phones.ID, nil, 50)
+	softDelete(t, db, &apple)
+	softDelete(t, db, &books)
+	softDelete(t, db, &removed)
+
+	createOrder(t, db, user.ID, 4, utc(6, 1, 2, 0), 4100, orderLine{iphone, 3}, orderLine{novel, 2}, orderLine{removed, 9})
+	order := createOrder(t, db, user.ID, 4, utc(6, 1, 3, 0), 1200, orderLine{novel, 4})
+	if err := db.Where("order_id = ?", order.ID).Delete(&models.OrderItem{}).Error; err != nil {
+		t.Fatalf("删除订单项失败: %v", err)
+	}
+
+	got, err := NewStatisticsService(db).GetProductSalesRank(utc(6, 1, 0, 0), utc(6, 2, 0, 0), 10)
+	if err != nil {
+		t.Fatalf("查询商品排行失败: %v", err)
+	}
+	want := []ProductSalesRank{
+		{ProductID: iphone.ID, ProductName: "iPhone", SalesCount: 3, SalesAmount: 3000, CategoryName: "手机"},
+		{ProductID: novel.ID, ProductName: "小说", SalesCount: 2, SalesAmount: 600, BrandName: "华为"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("商品排行为 %+v，期望 %+v", got, want)
+	}
+}
+
+// TestTopSpendersTieBreak 金额相同时订单数多的在前，订单数也相同时用户ID小的在前；软删除的用户和订单不计入
+func TestTopSpendersTieBreak(t *testing.T) {
+	db := newTestDB(t)
+	first := createUser(t, db, "first", utc(5, 1, 0, 0))
+	twice := createUser(t, db, "twice", utc(5, 1, 0, 0))
+	third := createUser(t, db, "third", utc(5, 1, 0, 0))
+	top := createUser(t, db, "top", utc(5, 1, 0, 0))
+	gone := createUser(t, db, "gone", utc(5, 1, 0, 0))
+
+	createOrder(t, db, first.ID, 4, utc(6, 1, 1, 0), 5000)
+	createOrder(t, db, twice.ID, 2, utc(6, 1, 2, 0), 2000)
+	createOrder(t, db, twice.ID, 4, utc(6, 1, 5, 0), 3000)
+	createOrder(t, db, third.ID, 4, utc(6, 1, 3, 0), 5000)
+	createOrder(t, db, top.ID, 4, utc(6, 1, 4, 0), 6000)
+	createOrder(t, db, gone.ID, 4, utc(6, 1, 4, 0), 9000)
+	createOrder(t, db, first.ID, 1, utc(6, 1, 6, 0), 7000) // 待付款
+	deleted := createOrder(t, db, third.ID, 4, utc(6, 1, 6, 0), 7000)
+	softDelete(t, db, &deleted)
+	softDelete(t, db, &gone)
+
+	svc := NewStatisticsService(db)
+	got, err := svc.GetTopSpenders(utc(6, 1, 0, 0), utc(6, 2, 0, 0), 10)
+	if err != nil {
+		t.Fatalf("查询消费排行失败: %v", err)
+	}
+	want := []Spender{
+		{UserID: top.ID, Username: "top", TotalCents: 6000, OrderCount: 1, LastOrderAt: utc(6, 1, 4, 0)},
+		{UserID: twice.ID, Username: "twice", TotalCents: 5000, OrderCount: 2, LastOrderAt: utc(6, 1, 5, 0)},
+		{UserID: first.ID, Username: "first", TotalCents: 5000, OrderCount: 1, LastOrderAt: utc(6, 1, 1, 0)},
+		{UserID: third.ID, Username: "third", TotalCents: 5000, OrderCount: 1, LastOrderAt: utc(6, 1, 3, 0)},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("消费排行为 %+v，期望 %+v", got, want)
+	}
+
+	// limit截断发生在排序之后，结果是完整排行的前缀
+	got, err = svc.GetTopSpenders(utc(6, 1, 0, 0), utc(6, 2, 0, 0), 2)
+	if err != nil {
+		t.Fatalf("查询消费排行失败: %v", err)
+	}
+	if !reflect.DeepEqual(got, want[:2]) {
+		t.Errorf("前2名为 %+v，期望 %+v", got, want[:2])
+	}
+}
+
+// seedUserBehavior 当前时间为上海6月10日00:30，bob在上海时间6月1日零点注册，carol在前一天23:30注册
+func seedUserBehavior(t *testing.T, db *gorm.DB) (*StatisticsService, []models.User) {
+	t.Helper()
+	alice := createUser(t, db, "alice", utc(5, 1, 0, 0))
+	bob := createUser(t, db, "bob,jr", utc(5, 31, 16, 0))
+	carol := createUser(t, db, "carol", utc(6, 9, 15, 30))
+	createUser(t, db, "idle", utc(5, 1, 0, 0))
+
+	createOrder(t, db, alice.ID, 4, utc(6, 2, 8, 0), 1000)
+	createOrder(t, db, alice.ID, 4, utc(6, 9, 10, 0), 2001)
+	createOrder(t, db, bob.ID, 2, utc(6, 5, 9, 30), 4500)
+	createOrder(t, db, carol.ID, 4, utc(6, 9, 16, 0), 99)
+	deleted := createOrder(t, db, carol.ID, 4, utc(6, 9, 16, 10), 100000)
+	softDelete(t, db, &deleted)
+
+	svc := NewStatisticsService(db)
+	svc.now = func() time.Time { return time.Date(2024, 6, 10, 0, 30, 0, 0, DefaultReportTZ) }
+	return svc, []models.User{alice, bob, carol}
+}
+
+// TestUserBehaviorRegisterDays 注册天数在Go中按当前时间所在时区的自然日计算，跨零点注册算1天
+func TestUserBehaviorRegisterDays(t *testing.T) {
+	db := newTestDB(t)
+	svc, users := seedUserBehavior(t, db)
+	alice, bob, carol := users[0], users[1], users[2]
+
+	got, err := svc.GetUserBehaviorAnalysis(utc(6, 1, 0, 0), svc.now(), 10)
+	if err != nil {
+		t.Fatalf("查询用户行为失败: %v", err)
+	}
+	want := []UserBehaviorAnalysis{
+		{UserID: bob.ID, Username: "bob,jr", OrderCount: 1, TotalAmount: 4500, AvgAmount: 4500, LastOrderAt: utc(6, 5, 9, 30), RegisterDays: 9},
+		{UserID: alice.ID, Username: "alice", OrderCount: 2, TotalAmount: 3001, AvgAmount: 1500.5, LastOrderAt: utc(6, 9, 10, 0), RegisterDays: 40},
+		{UserID: carol.ID, Username: "carol", OrderCount: 1, TotalAmount: 99, AvgAmount: 99, LastOrderAt: utc(6, 9, 16, 0), RegisterDays: 1},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("用户行为为 %+v，期望 %+v", got, want)
+	}
+}
+
+// TestDaysBetween 按to所在时区的自然日计算，与时刻差无关
+func TestDaysBetween(t *testing.T) {
+	cases := []struct {
+		from, to time.Time
+		want     int
+	}{
+		{utc(6, 1, 23, 59), utc(6, 2, 0, 1), 1},
+		{utc(6, 1, 0, 0), utc(6, 1, 23, 59), 0},
+		{utc(6, 1, 15, 30), utc(6, 1, 16, 30).In(DefaultReportTZ), 1},
+		{utc(6, 1, 15, 30), utc(6, 1, 16, 30), 0},
+		{utc(2, 28, 12, 0), utc(3, 1, 0, 0), 2},
+		{utc(6, 2, 0, 0), utc(6, 1, 0, 0), -1},
+	}
+	for _, tc := range cases {
+		if got := daysBetween(tc.from, tc.to); got != tc.want {
+			t.Errorf("daysBetween(%v, %v) = %d，期望 %d", tc.from, tc.to, got, tc.want)
+		}
+	}
+}
+
+// TestSalesStatisticsByCategory 订单数按订单去重，一单中同分类的多个订单项只算一单；
+// 没有销售的分类也返回，已软删除的分类、订单不计入
+func TestSalesStatisticsByCategory(t *testing.T) {
+	db := newTestDB(t)
+	user := createUser(t, db, "alice", utc(5, 1, 0, 0))
+	phones, books, toys, archived := createCategory(t, db, "手机"), createCategory(t, db, "图书"), createCategory(t, db, "玩具"), createCategory(t, db, "停用")
+	phone := createProduct(t, db, "手机", phones.ID, nil, 1000)
+	charger := createProduct(t, db, "充电器", phones.ID, nil, 500)
+	novel := createProduct(t, db, "小说", books.ID, nil, 300)
+	old := createProduct(t, db, "旧货", archived.ID, nil, 100)
+	softDelete(t, db, &archived)
+
+	createOrder(t, db, user.ID, 4, utc(6, 1, 1, 0), 2000, orderLine{phone, 1}, orderLine{charger, 2})
+	createOrder(t, db, user.ID, 2, utc(6, 1, 2, 0), 2300, orderLine{phone, 2}, orderLine{novel, 1})
+	createOrder(t, db, user.ID, 1, utc(6, 1, 3, 0), 5000, orderLine{phone, 5}) // 待付款
+	createOrder(t, db, user.ID, 4, utc(6, 1, 4, 0), 100, orderLine{old, 1})
+	deleted := createOrder(t, db, user.ID, 4, utc(6, 1, 5, 0), 900, orderLine{novel, 3})
+	softDelete(t, db, &deleted)
+
+	got, err := NewStatisticsService(db).GetSalesStatisticsByCategory(utc(6, 1, 0, 0), utc(6, 2, 0, 0))
+	if err != nil {
+		t.Fatalf("查询分类统计失败: %v", err)
+	}
+	want := []CategorySalesStat{
+		{CategoryID: phones.ID, CategoryName: "手机", OrderCount: 2, SalesCount: 5, SalesAmount: 4000, AvgOrderValue: 2000, ItemsPerOrder: 2.5},
+		{CategoryID: books.ID, CategoryName: "图书", OrderCount: 1, SalesCount: 1, SalesAmount: 300, AvgOrderValue: 300, ItemsPerOrder: 1},
+		{CategoryID: toys.ID, CategoryName: "玩具"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("分类统计为 %+v，期望 %+v", got, want)
+	}
+}
+
+// userBehaviorExportRows seedUserBehavior 的导出内容，金额为元，平均金额四舍五入到分
+func userBehaviorExportRows(users []models.User) [][]string {
+	return [][]string{
+		{"用户ID", "用户名", "订单数", "总金额(元)", "平均金额(元)", "最近下单时间", "注册天数"},
+		{fmt.Sprint(users[1].ID), "bob,jr", "1", "45.00", "45.00", "2024-06-05 09:30:00", "9"},
+		{fmt.Sprint(users[0].ID), "alice", "2", "30.01", "15.01", "2024-06-09 10:00:00", "40"},
+		{fmt.Sprint(users[2].ID), "carol", "1", "0.99", "0.99", "2024-06-09 16:00:00", "1"},
+	}
+}
+
+// TestExportUserBehaviorCSV CSV以UTF-8 BOM开头，含逗号的字段加引号
+func TestExportUserBehaviorCSV(t *testing.T) {
+	db := newTestDB(t)
+	svc, users := seedUserBehavior(t, db)
+
+	var buf bytes.Buffer
+	if err := svc.ExportUserBehavior(&buf, "csv", utc(6, 1, 0, 0), svc.now(), 10); err != nil {
+		t.Fatalf("导出CSV失败: %v", err)
+	}
+	var want strings.Builder
+	want.WriteString("\xEF\xBB\xBF")
+	for _, row := range userBehaviorExportRows(users) {
+		for i, cell := range row {
+			if strings.Contains(cell, ",") {
+				cell = `"` + cell + `"`
+			}
+			if i > 0 {
+				want.WriteString(",")
+			}
+			want.WriteString(cell)
+		}
+		want.WriteString("\n")
+	}
+	if buf.String() != want.String() {
+		t.Errorf("CSV内容为\n%s\n期望\n%s", buf.String(), want.String())
+	}
+}
+
+// xlsxSheet 工作表XML中测试关心的部分
+type xlsxSheet struct {
+	Rows []struct {
+		R     int `xml:"r,attr"`
+		Cells []struct {
+			Ref   string `xml:"r,attr"`
+			Type  string `xml:"t,attr"`
+			Value string `xml:"v"`
+			Text  string `xml:"is>t"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+// TestExportUserBehaviorXLSX 工作表逐行写入，数字为数值单元格，其他为内联文本
+func TestExportUserBehaviorXLSX(t *testing.T) {
+	db := newTestDB(t)
+	svc, users := seedUserBehavior(t, db)
+
+	var buf bytes.Buffer
+	if err := svc.ExportUserBehavior(&buf, "xlsx", utc(6, 1, 0, 0), svc.now(), 10); err != nil {
+		t.Fatalf("导出XLSX失败: %v", err)
+	}
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("读取xlsx失败: %v", err)
+	}
+	var names []string
+	var sheet xlsxSheet
+	for _, file := range archive.File {
+		names = append(names, file.Name)
+		if file.Name != "xl/worksheets/sheet1.xml" {
+			continue
+		}
+		r, err := file.Open()
+		if err != nil {
+			t.Fatalf("打开工作表失败: %v", err)
+		}
+		content, _ := io.ReadAll(r)
+		r.Close()
+		if err := xml.Unmarshal(content, &sheet); err != nil {
+			t.Fatalf("解析工作表失败: %v", err)
+		}
+	}
+	wantNames := []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/worksheets/sheet1.xml"}
+	if !reflect.DeepEqual(names, wantNames) {
+		t.Errorf("xlsx包含 %v，期望 %v", names, wantNames)
+	}
+
+	numeric := map[int]bool{0: true, 2: true, 3: true, 4: true, 6: true}
+	var got [][]string
+	for i, row := range sheet.Rows {
+		if row.R != i+1 {
+			t.Errorf("第 %d 行的行号为 %d", i+1, row.R)
+		}
+		var values []string
+		for col, cell := range row.Cells {
+			if ref := xlsxCellRef(col, row.R); cell.Ref != ref {
+				t.Errorf("单元格坐标为 %s，期望 %s", cell.Ref, ref)
+			}
+			switch {
+			case i > 0 && numeric[col] && cell.Type == "":
+				values = append(values, cell.Value)
+			case (i == 0 || !numeric[col]) && cell.Type == "inlineStr":
+				values = append(values, cell.Text)
+			default:
+				t.Errorf("单元格 %s 的类型为 %q", cell.Ref, cell.Type)
+			}
+		}
+		got = append(got, values)
+	}
+	if want := userBehaviorExportRows(users); !reflect.DeepEqual(got, want) {
+		t.Errorf("工作表内容为 %q，期望 %q", got, want)
+	}
+}
+
+// TestExportUserBehaviorUnsupportedFormat 不支持的格式直接返回错误，不写入任何内容
+func TestExportUserBehaviorUnsupportedFormat(t *testing.T) {
+	db := newTestDB(t)
+	var buf bytes.Buffer
+	err := NewStatisticsService(db).ExportUserBehavior(&buf, "pdf", utc(6, 1, 0, 0), utc(6, 2, 0, 0), 10)
+	if !errors.Is(err, ErrUnsupportedExportFormat) || buf.Len() != 0 {
+		t.Errorf("导出pdf返回 %v，写入 %d 字节，期望 ErrUnsupportedExportFormat 且不写入", err, buf.Len())
+	}
+}
+
+// TestDashboardData 今日、昨日按报表时区的自然日划分，软删除、未支付的订单和已删除的用户、下架的商品不计入
+func TestDashboardData(t *testing.T) {
+	db := newTestDB(t)
+	alice := createUser(t, db, "alice", utc(5, 1, 0, 0))
+	bob := createUser(t, db, "bob", utc(5, 1, 0, 0))
+	createUser(t, db, "carol", utc(6, 1, 17, 0)) // 上海 06-02 01:00
+	gone := createUser(t, db, "gone", utc(6, 1, 17, 5))
+	softDelete(t, db, &gone)
+
+	category := createCategory(t, db, "手机")
+	createProduct(t, db, "在售", category.ID, nil, 100)
+	offShelf := createProduct(t, db, "下架", category.ID, nil, 100)
+	if err := db.Model(&offShelf).Update("status", 2).Error; err != nil {
+		t.Fatalf("下架商品失败: %v", err)
+	}
+	removed := createProduct(t, db, "删除", category.ID, nil, 100)
+	softDelete(t, db, &removed)
+
+	createOrder(t, db, alice.ID, 4, utc(5, 20, 8, 0), 4000)
+	createOrder(t, db, bob.ID, 4, utc(6, 1, 15, 30), 2000)   // 上海 06-01 23:30
+	createOrder(t, db, alice.ID, 2, utc(6, 1, 16, 30), 3000) // 上海 06-02 00:30
+	createOrder(t, db, bob.ID, 4, utc(6, 1, 17, 0), 2000)
+	createOrder(t, db, bob.ID, 1, utc(6, 1, 17, 30), 500) // 待付款
+	deleted := createOrder(t, db, alice.ID, 4, utc(6, 1, 17, 40), 9000)
+	softDelete(t, db, &deleted)
+
+	svc := NewStatisticsService(db)
+	svc.now = func() time.Time { return utc(6, 1, 18, 0) } // 上海 06-02 02:00
+
+	cases := []struct {
+		name string
+		tz   *time.Location
+		want DashboardData
+	}{
+		{"上海", DefaultReportTZ, DashboardData{
+			TodayOrders: 2, TodaySales: 5000, TodayUsers: 1,
+			TotalOrders: 4, TotalSales: 11000, TotalUsers: 3, TotalProducts: 1,
+			AvgOrderValue: 2750, OrderGrowthRate: 100, SalesGrowthRate: 150,
+		}},
+		// UTC下四单都是今天，昨天没有订单，增长率为0
+		{"UTC", time.UTC, DashboardData{
+			TodayOrders: 3, TodaySales: 7000, TodayUsers: 1,
+			TotalOrders: 4, TotalSales: 11000, TotalUsers: 3, TotalProducts: 1,
+			AvgOrderValue: 2750,
+		}},
+	}
+	for _, tc := range cases {
+		got, err := svc.GetDashboardData(context.Background(), tc.tz)
+		if err != nil {
+			t.Fatalf("%s: 查询数据大屏失败: %v", tc.name, err)
+		}
+		if *got != tc.want {
+			t.Errorf("%s: 数据大屏为 %+v，期望 %+v", tc.name, *got, tc.want)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := svc.GetDashboardData(ctx, nil); !errors.Is(err, context.Canceled) {
+		t.Errorf("ctx取消后返回 %v，期望 context.Canceled", err)
+	}
+}
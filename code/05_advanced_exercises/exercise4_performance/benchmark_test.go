@@ -0,0 +1,118 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"gorm-advanced-exercises/models"
+	"gorm.io/gorm"
+)
+
+// stmtQuery 参与对比的参数化查询，i用于选择本次的查询参数
+type stmtQuery func(db *gorm.DB, i int) (interface{}, error)
+
+// stmtQueries 准备对比用的查询集合，参数从现有数据中选取
+func stmtQueries(t testing.TB, db *gorm.DB) []stmtQuery {
+	t.Helper()
+	var productIDs, categoryIDs, userIDs []uint
+	if err := db.Model(&models.Product{}).Order("id").Limit(100).Pluck("id", &productIDs).Error; err != nil {
+		t.Fatalf("查询商品ID失败: %v", err)
+	}
+	if err := db.Model(&models.Category{}).Order("id").Limit(100).Pluck("id", &categoryIDs).Error; err != nil {
+		t.Fatalf("查询分类ID失败: %v", err)
+	}
+	if err := db.Model(&models.User{}).Order("id").Limit(100).Pluck("id", &userIDs).Error; err != nil {
+		t.Fatalf("查询用户ID失败: %v", err)
+	}
+	if len(productIDs) == 0 || len(categoryIDs) == 0 || len(userIDs) == 0 {
+		t.Fatal("缺少测试数据，请先填充用户、分类和商品")
+	}
+
+	return []stmtQuery{
+		// 按主键查询商品
+		func(db *gorm.DB, i int) (interface{}, error) {
+			var product models.Product
+			err := db.Where("id = ?", productIDs[i%len(productIDs)]).Take(&product).Error
+			return []interface{}{product.ID, product.Name, product.Price, product.Stock}, err
+		},
+		// 按分类查询上架商品
+		func(db *gorm.DB, i int) (interface{}, error) {
+			var ids []uint
+			err := db.Model(&models.Product{}).
+				Where("category_id = ? AND status = ?", categoryIDs[i%len(categoryIDs)], 1).
+				Order("id DESC").Limit(10).Pluck("id", &ids).Error
+			return ids, err
+		},
+		// 查询用户已付款的订单
+		func(db *gorm.DB, i int) (interface{}, error) {
+			var ids []uint
+			err := db.Model(&models.Order{}).
+				Where("user_id = ? AND status >= ?", userIDs[i%len(userIDs)], 2).
+				Order("id DESC").Limit(5).Pluck("id", &ids).Error
+			return ids, err
+		},
+		// 统计用户数
+		func(db *gorm.DB, i int) (interface{}, error) {
+			var count int64
+			err := db.Model(&models.User{}).Where("status = ?", i%2+1).Count(&count).Error
+			return count, err
+		},
+	}
+}
+
+// TestStmtCachingSameResults 开启PrepareStmt的会话与普通会话共用连接池，同一组查询的结果一致
+func TestStmtCachingSameResults(t *testing.T) {
+	db := newSeededDB(t)
+	cached := db.Session(&gorm.Session{PrepareStmt: true})
+	queries := stmtQueries(t, db)
+
+	for i := 0; i < 20; i++ {
+		for n, query := range queries {
+			want, err := query(db, i)
+			if err != nil {
+				t.Fatalf("第 %d 个查询失败: %v", n+1, err)
+			}
+			got, err := query(cached, i)
+			if err != nil {
+				t.Fatalf("第 %d 个查询（预编译）失败: %v", n+1, err)
+			}
+			if !reflect.DeepEqual(want, got) {
+				t.Fatalf("第 %d 个查询的结果不一致: %v != %v", n+1, want, got)
+			}
+		}
+	}
+}
+
+// BenchmarkStmtCaching 对比PrepareStmt关闭和开启时同一组参数化查询的耗时，每次迭代依次执行全部查询
+// go test -run '^$' -bench StmtCaching ./exercise4_performance
+func BenchmarkStmtCaching(b *testing.B) {
+	db := newSeededDB(b)
+	queries := stmtQueries(b, db)
+
+	sessions := []struct {
+		name string
+		db   *gorm.DB
+	}{
+		{"without_cache", db},
+		{"with_cache", db.Session(&gorm.Session{PrepareStmt: true})},
+	}
+	for _, session := range sessions {
+		b.Run(session.name, func(b *testing.B) {
+			// 预热：建立连接、填充语句缓存
+			for _, query := range queries {
+				if _, err := query(session.db, 0); err != nil {
+					b.Fatalf("预热失败: %v", err)
+				}
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				for _, query := range queries {
+					if _, err := query(session.db, i); err != nil {
+						b.Fatal(err)
+					}
+				}
+			}
+			b.ReportMetric(float64(b.Elapsed().Nanoseconds())/float64(b.N*len(queries)), "ns/query")
+		})
+	}
+}
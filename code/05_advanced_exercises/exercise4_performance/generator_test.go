@@ -0,0 +1,221 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm-advanced-exercises/models"
+	"gorm.io/gorm"
+)
+
+// testGeneratorOptions 小规模的生成参数，订单分多批插入
+func testGeneratorOptions(seed int64) GeneratorOptions {
+	return GeneratorOptions{
+		Seed: seed, Users: 20, Categories: 3, SubCategories: 2, Brands: 5,
+		Products: 30, Orders: 60, BatchSize: 25,
+	}
+}
+
+// generate 在新的测试数据库中按opts生成数据
+func generate(t *testing.T, opts GeneratorOptions) *gorm.DB {
+	t.Helper()
+	db := newTestDB(t)
+	if _, err := NewDataGenerator(db, opts).Generate(); err != nil {
+		t.Fatalf("生成数据失败: %v", err)
+	}
+	return db
+}
+
+// dumpGenerated 按主键顺序导出生成的数据，不含插入时才确定的时间戳
+func dumpGenerated(t *testing.T, db *gorm.DB) []string {
+	t.Helper()
+	var users []models.User
+	var categories []models.Category
+	var products []models.Product
+	var orders []models.Order
+	var items []models.OrderItem
+	for _, dest := range []interface{}{&users, &categories, &products, &orders, &items} {
+		if err := db.Order("id").Find(dest).Error; err != nil {
+			t.Fatalf("查询生成的数据失败: %v", err)
+		}
+	}
+
+	var rows []string
+	for _, u := range users {
+		rows = append(rows, fmt.Sprintf("user %s %s %s %d", u.Username, u.Phone, u.Nickname, u.Status))
+	}
+	for _, c := range categories {
+		rows = append(rows, fmt.Sprintf("category %s %s %v", c.Name, c.Slug, c.ParentID != nil))
+	}
+	for _, p := range products {
+		rows = append(rows, fmt.Sprintf("product %s %s %d %v %d %d %d %d", p.SKU, p.Name, p.CategoryID, p.BrandID != nil, p.Price, p.Stock, p.Views, p.Status))
+	}
+	for _, o := range orders {
+		rows = append(rows, fmt.Sprintf("order %s %d %d %d %d %d %s", o.OrderNo, o.UserID, o.Status, o.TotalAmount, o.FreightAmount, o.PayAmount, o.CreatedAt.UTC()))
+	}
+	for _, i := range items {
+		rows = append(rows, fmt.Sprintf("item %d %d %d %d", i.OrderID, i.ProductID, i.Quantity, i.TotalPrice))
+	}
+	return rows
+}
+
+// TestGeneratorSameSeedSameData 相同种子和规模生成的数据完全相同，与批大小无关；种子不同时数据不同
+func TestGeneratorSameSeedSameData(t *testing.T) {
+	want := dumpGenerated(t, generate(t, testGeneratorOptions(7)))
+
+	again := dumpGenerated(t, generate(t, testGeneratorOptions(7)))
+	if !reflect.DeepEqual(again, want) {
+		t.Error("相同种子两次生成的数据不同")
+	}
+
+	oneBatch := testGeneratorOptions(7)
+	oneBatch.BatchSize = 500
+	if got := dumpGenerated(t, generate(t, oneBatch)); !reflect.DeepEqual(got, want) {
+		t.Error("批大小不同时生成的数据不同")
+	}
+
+	if got := dumpGenerated(t, generate(t, testGeneratorOptions(8))); reflect.DeepEqual(got, want) {
+		t.Error("不同种子生成的数据相同")
+	}
+}
+
+// TestGeneratorOrderInvariants 订单金额等于订单项之和加运费，满99元包邮；已付款的订单有付款时间，已完成的有完成时间
+func TestGeneratorOrderInvariants(t *testing.T) {
+	db := generate(t, testGeneratorOptions(7))
+
+	var orders []models.Order
+	if err := db.Order("id").Find(&orders).Error; err != nil {
+		t.Fatalf("查询订单失败: %v", err)
+	}
+	if len(orders) != 60 {
+		t.Fatalf("生成 %d 个订单，期望 60", len(orders))
+	}
+	for _, o := range orders {
+		var sum struct{ Total, Items int64 }
+		db.Model(&models.OrderItem{}).Select("SUM(total_price) as total, COUNT(*) as items").
+			Where("order_id = ?", o.ID).Scan(&sum)
+		if sum.Items < 1 || sum.Items > 3 || sum.Total != o.TotalAmount {
+			t.Errorf("订单 %s 有 %d 个订单项、合计 %d，订单金额 %d", o.OrderNo, sum.Items, sum.Total, o.TotalAmount)
+		}
+
+		wantFreight := int64(0)
+		if o.TotalAmount < 9900 {
+			wantFreight = 1000
+		}
+		if o.FreightAmount != wantFreight || o.PayAmount != o.TotalAmount+o.FreightAmount {
+			t.Errorf("订单 %s 金额 %d、运费 %d、实付 %d", o.OrderNo, o.TotalAmount, o.FreightAmount, o.PayAmount)
+		}
+
+		paid := o.Status >= 2 && o.Status <= 4
+		if (o.PaidAt != nil) != paid || (o.FinishedAt != nil) != (o.Status == 4) {
+			t.Errorf("订单 %s 状态 %d，付款时间 %v，完成时间 %v", o.OrderNo, o.Status, o.PaidAt, o.FinishedAt)
+		}
+		if paid && !o.PaidAt.After(o.CreatedAt) {
+			t.Errorf("订单 %s 付款时间 %v 早于下单时间 %v", o.OrderNo, o.PaidAt, o.CreatedAt)
+		}
+	}
+}
+
+// TestProfileOptions 三种规模的数据量，未知规模返回错误
+func TestProfileOptions(t *testing.T) {
+	cases := []struct {
+		profile                                                    GeneratorProfile
+		users, categories, subCategories, brands, products, orders int
+		batchSize                                                  int
+	}{
+		{GeneratorProfileSmall, 200, 8, 4, 20, 500, 1000, 0},
+		{GeneratorProfileMedium, 5000, 12, 6, 50, 5000, 50000, 1000},
+		{GeneratorProfileLarge, 50000, 12, 8, 100, 20000, 500000, 1000},
+	}
+	for _, tc := range cases {
+		opts, err := ProfileOptions(tc.profile, 3)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.profile, err)
+		}
+		got := []int{opts.Users, opts.Categories, opts.SubCategories, opts.Brands, opts.Products, opts.Orders, opts.BatchSize}
+		want := []int{tc.users, tc.categories, tc.subCategories, tc.brands, tc.products, tc.orders, tc.batchSize}
+		if !reflect.DeepEqual(got, want) || opts.Seed != 3 {
+			t.Errorf("%s: 参数为 %v（种子 %d），期望 %v（种子 3）", tc.profile, got, opts.Seed, want)
+		}
+	}
+
+	if _, err := ProfileOptions("huge", 1); err == nil || !strings.Contains(err.Error(), "huge") {
+		t.Errorf("未知规模返回 %v，期望报错", err)
+	}
+}
+
+// TestGeneratorCategoryTree 分级时每个一级分类下有SubCategories个二级分类，商品只挂在二级分类下
+func TestGeneratorCategoryTree(t *testing.T) {
+	db := generate(t, testGeneratorOptions(7))
+
+	var parents, children int64
+	db.Model(&models.Category{}).Where("parent_id IS NULL").Count(&parents)
+	db.Model(&models.Category{}).Where("parent_id IS NOT NULL").Count(&children)
+	if parents != 3 || children != 6 {
+		t.Errorf("一级分类 %d 个、二级分类 %d 个，期望 3、6", parents, children)
+	}
+
+	var onParents int64
+	db.Model(&models.Product{}).Joins("JOIN categories c ON c.id = products.category_id").
+		Where("c.parent_id IS NULL").Count(&onParents)
+	if onParents != 0 {
+		t.Errorf("%d 个商品挂在一级分类下", onParents)
+	}
+}
+
+// TestGeneratorOrderTimeCurve 订单时间按月份系数和24小时权重分布，权重为0的月份和小时没有订单
+func TestGeneratorOrderTimeCurve(t *testing.T) {
+	opts := testGeneratorOptions(7)
+	opts.BaseTime = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC) // 时间窗口为2023年全年
+	opts.DailyCurve = make([]float64, 24)
+	opts.DailyCurve[20] = 1
+	opts.MonthlyFactors = make([]float64, 12)
+	opts.MonthlyFactors[5], opts.MonthlyFactors[10] = 1, 1
+	db := generate(t, opts)
+
+	var orders []models.Order
+	if err := db.Find(&orders).Error; err != nil {
+		t.Fatalf("查询订单失败: %v", err)
+	}
+	months := make(map[time.Month]int)
+	for _, o := range orders {
+		at := o.CreatedAt.UTC()
+		months[at.Month()]++
+		if at.Year() != 2023 || at.Hour() != 20 {
+			t.Errorf("订单 %s 的下单时间为 %v，期望2023年的20点", o.OrderNo, at)
+		}
+	}
+	if len(months) != 2 || months[time.June] == 0 || months[time.November] == 0 {
+		t.Errorf("各月订单数为 %v，期望只有6月和11月", months)
+	}
+}
+
+// TestGeneratorRejectsInvalidCurve 小时权重、月份系数的个数不对或全为0时生成失败，不写入数据
+func TestGeneratorRejectsInvalidCurve(t *testing.T) {
+	cases := []struct {
+		name  string
+		apply func(opts *GeneratorOptions)
+	}{
+		{"小时权重23个", func(opts *GeneratorOptions) { opts.DailyCurve = make([]float64, 23) }},
+		{"小时权重全为0", func(opts *GeneratorOptions) { opts.DailyCurve = make([]float64, 24) }},
+		{"月份系数11个", func(opts *GeneratorOptions) { opts.MonthlyFactors = make([]float64, 11) }},
+		{"月份系数为负", func(opts *GeneratorOptions) {
+			opts.MonthlyFactors = append([]float64(nil), defaultMonthlyFactors...)
+			opts.MonthlyFactors[0] = -1
+		}},
+	}
+	for _, tc := range cases {
+		db := newTestDB(t)
+		opts := testGeneratorOptions(7)
+		tc.apply(&opts)
+		if _, err := NewDataGenerator(db, opts).Generate(); err == nil {
+			t.Errorf("%s: 生成成功，期望报错", tc.name)
+		}
+		if n := countRows(t, db, &models.User{}); n != 0 {
+			t.Errorf("%s: 生成失败后写入了 %d 个用户", tc.name, n)
+		}
+	}
+}
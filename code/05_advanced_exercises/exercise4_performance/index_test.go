@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"gorm-advanced-exercises/models"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// mysqlDSNEnv 索引检查使用的MySQL连接串，未设置时跳过这些测试（SQLite的执行计划与MySQL不同）
+// 如 EXERCISE4_MYSQL_DSN="root:123456@tcp(localhost:3306)/gorm_advanced_exercise4_test?charset=utf8mb4&parseTime=True&loc=Local"
+const mysqlDSNEnv = "EXERCISE4_MYSQL_DSN"
+
+// AssertIndexUsed 对查询执行EXPLAIN，执行计划中没有使用index时测试失败
+func AssertIndexUsed(t *testing.T, db *gorm.DB, index, sql string, args ...interface{}) {
+	t.Helper()
+	var plan []map[string]interface{}
+	if err := db.Raw("EXPLAIN "+sql, args...).Scan(&plan).Error; err != nil {
+		t.Fatalf("执行EXPLAIN失败: %v", err)
+	}
+
+	var used []string
+	for _, row := range plan {
+		key := row["key"]
+		if b, ok := key.([]byte); ok {
+			key = string(b)
+		}
+		if name, ok := key.(string); ok && name != "" {
+			if name == index {
+				return
+			}
+			used = append(used, name)
+		}
+	}
+	t.Fatalf("查询未使用索引 %s，实际使用: %v\nSQL: %s", index, used, strings.TrimSpace(sql))
+}
+
+// TestSalesStatisticsUsesStatusCreatedIndex 销售统计按状态和下单时间过滤，应命中 idx_orders_status_created
+func TestSalesStatisticsUsesStatusCreatedIndex(t *testing.T) {
+	db := newMySQLIndexDB(t)
+
+	now := time.Now()
+	sqls := captureSQL(t, db, func(service *OptimizedQueryService) error {
+		_, err := service.GetSalesStatisticsOptimized(context.Background(), now.AddDate(0, 0, -30), now)
+		return err
+	})
+	AssertIndexUsed(t, db, "idx_orders_status_created", findSQL(t, sqls, "GROUP BY DATE(created_at)"))
+}
+
+// TestProductListUsesCategoryStatusIndex 按分类分页查询上架商品，应命中 idx_products_category_status
+func TestProductListUsesCategoryStatusIndex(t *testing.T) {
+	db := newMySQLIndexDB(t)
+
+	var categoryID uint
+	if err := db.Model(&models.Product{}).Select("category_id").Order("id").Limit(1).Scan(&categoryID).Error; err != nil {
+		t.Fatalf("查询商品分类失败: %v", err)
+	}
+	sqls := captureSQL(t, db, func(service *OptimizedQueryService) error {
+		_, _, err := service.GetProductsWithPagination(context.Background(), 1, 20, &categoryID)
+		return err
+	})
+	// 总数查询和分页查询都应该命中
+	AssertIndexUsed(t, db, "idx_products_category_status", findSQL(t, sqls, "SELECT count(*) FROM `products`"))
+	AssertIndexUsed(t, db, "idx_products_category_status", findSQL(t, sqls, "ORDER BY id DESC"))
+}
+
+// newMySQLIndexDB 连接 EXERCISE4_MYSQL_DSN 指定的数据库，迁移模型、创建优化索引，
+// 库中没有数据时按small规模填充，让优化器按真实的数据分布选择索引
+func newMySQLIndexDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := os.Getenv(mysqlDSNEnv)
+	if dsn == "" {
+		t.Skipf("未设置 %s，跳过MySQL索引检查", mysqlDSNEnv)
+	}
+	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{
+		Logger:                                   logger.Default.LogMode(logger.Silent),
+		DisableForeignKeyConstraintWhenMigrating: true,
+	})
+	if err != nil {
+		t.Fatalf("连接MySQL失败: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("获取数据库连接失败: %v", err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	if err := db.AutoMigrate(models.All()...); err != nil {
+		t.Fatalf("迁移失败: %v", err)
+	}
+	if err := CreateOptimizedIndexes(db); err != nil {
+		t.Fatalf("创建索引失败: %v", err)
+	}
+	if countRows(t, db, &models.Order{}) == 0 {
+		if err := SeedTestData(db, GeneratorProfileSmall, 1); err != nil {
+			t.Fatalf("填充数据失败: %v", err)
+		}
+		if err := db.Exec("ANALYZE TABLE orders, products").Error; err != nil {
+			t.Fatalf("更新统计信息失败: %v", err)
+		}
+	}
+	return db
+}
+
+// sqlRecorder 记录执行过的SQL（参数已代入），用于对服务实际执行的查询做EXPLAIN
+type sqlRecorder struct {
+	logger.Interface
+	mu   sync.Mutex
+	sqls []string
+}
+
+func (r *sqlRecorder) LogMode(logger.LogLevel) logger.Interface { return r }
+
+func (r *sqlRecorder) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	sql, _ := fc()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sqls = append(r.sqls, sql)
+}
+
+// captureSQL 用记录SQL的连接创建 OptimizedQueryService 并执行fn，返回执行过的全部SQL
+func captureSQL(t *testing.T, db *gorm.DB, fn func(service *OptimizedQueryService) error) []string {
+	t.Helper()
+	recorder := &sqlRecorder{Interface: logger.Discard}
+	recorded := db.Session(&gorm.Session{Logger: recorder})
+	if err := fn(NewOptimizedQueryService(recorded, NewPerformanceMonitor(recorded))); err != nil {
+		t.Fatalf("执行查询失败: %v", err)
+	}
+	return recorder.sqls
+}
+
+// findSQL 返回第一条包含substr的SQL
+func findSQL(t *testing.T, sqls []string, substr string) string {
+	t.Helper()
+	for _, sql := range sqls {
+		if strings.Contains(sql, substr) {
+			return sql
+		}
+	}
+	t.Fatalf("没有执行包含 %q 的SQL，执行过: %v", substr, sqls)
+	return ""
+}
@@ -2,87 +2,33 @@ package main
 
 import (
 	"context"
+	"crypto/subtle"
 	"database/sql"
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"log"
+	"math"
+	"math/rand"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/gin-gonic/gin"
+	"gorm-advanced-exercises/models"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"gorm.io/gorm/logger"
 )
 
-// 使用exercise2的模型
-type BaseModel struct {
-	ID        uint           `gorm:"primarykey" json:"id"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at"`
-}
-
-type User struct {
-	BaseModel
-	Username    string     `gorm:"uniqueIndex;size:50;not null" json:"username"`
-	Email       string     `gorm:"uniqueIndex;size:100;not null" json:"email"`
-	Phone       string     `gorm:"uniqueIndex;size:20" json:"phone"`
-	Password    string     `gorm:"size:255;not null" json:"-"`
-	Nickname    string     `gorm:"size:50" json:"nickname"`
-	Status      int8       `gorm:"default:1;comment:1-正常,2-禁用" json:"status"`
-	LastLoginAt *time.Time `json:"last_login_at"`
-}
-
-type Category struct {
-	BaseModel
-	Name     string `gorm:"size:50;not null" json:"name"`
-	Slug     string `gorm:"uniqueIndex;size:100;not null" json:"slug"`
-	ParentID *uint  `gorm:"index" json:"parent_id"`
-	Status   int8   `gorm:"default:1;comment:1-启用,2-禁用" json:"status"`
-}
-
-type Brand struct {
-	BaseModel
-	Name   string `gorm:"uniqueIndex;size:50;not null" json:"name"`
-	Slug   string `gorm:"uniqueIndex;size:100;not null" json:"slug"`
-	Status int8   `gorm:"default:1;comment:1-启用,2-禁用" json:"status"`
-}
-
-type Product struct {
-	BaseModel
-	Name       string `gorm:"size:255;not null" json:"name"`
-	SKU        string `gorm:"uniqueIndex;size:100;not null" json:"sku"`
-	CategoryID uint   `gorm:"index;not null" json:"category_id"`
-	BrandID    *uint  `gorm:"index" json:"brand_id"`
-	Price      int64  `gorm:"not null;comment:价格(分)" json:"price"`
-	Stock      int    `gorm:"default:0" json:"stock"`
-	Sales      int    `gorm:"default:0" json:"sales"`
-	Views      int    `gorm:"default:0" json:"views"`
-	Status     int8   `gorm:"default:1;comment:1-上架,2-下架" json:"status"`
-}
-
-type Order struct {
-	BaseModel
-	OrderNo        string     `gorm:"uniqueIndex;size:50;not null" json:"order_no"`
-	UserID         uint       `gorm:"index;not null" json:"user_id"`
-	Status         int8       `gorm:"index;default:1;comment:1-待付款,2-待发货,3-待收货,4-已完成,5-已取消" json:"status"`
-	TotalAmount    int64      `gorm:"not null;comment:商品总金额(分)" json:"total_amount"`
-	PayAmount      int64      `gorm:"not null;comment:实付金额(分)" json:"pay_amount"`
-	FreightAmount  int64      `gorm:"default:0;comment:运费(分)" json:"freight_amount"`
-	DiscountAmount int64      `gorm:"default:0;comment:优惠金额(分)" json:"discount_amount"`
-	PaidAt         *time.Time `json:"paid_at"`
-	FinishedAt     *time.Time `json:"finished_at"`
-}
-
-type OrderItem struct {
-	BaseModel
-	OrderID     uint   `gorm:"index;not null" json:"order_id"`
-	ProductID   uint   `gorm:"index;not null" json:"product_id"`
-	Quantity    int    `gorm:"not null" json:"quantity"`
-	Price       int64  `gorm:"not null;comment:单价(分)" json:"price"`
-	TotalPrice  int64  `gorm:"not null;comment:总价(分)" json:"total_price"`
-	ProductName string `gorm:"size:255;not null" json:"product_name"`
-}
-
 // DatabaseConfig 数据库配置
 type DatabaseConfig struct {
 	Host            string
@@ -95,6 +41,7 @@ type DatabaseConfig struct {
 	MaxOpenConns    int
 	ConnMaxLifetime time.Duration
 	ConnMaxIdleTime time.Duration
+	SlowThreshold   time.Duration // 慢查询阈值，超过的SQL以警告输出并记录到性能监控器，为0时使用 slowQueryThreshold
 }
 
 // ConnectDatabase 连接数据库（优化版）
@@ -103,7 +50,8 @@ func ConnectDatabase(config DatabaseConfig) (*gorm.DB, error) {
 		config.User, config.Password, config.Host, config.Port, config.DBName, config.Charset)
 
 	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Info),
+		// 只输出慢查询和错误，不再逐条打印SQL
+		Logger: NewSlowQueryLogger(config.SlowThreshold),
 		// 禁用外键约束检查以提高性能
 		DisableForeignKeyConstraintWhenMigrating: true,
 		// 预编译语句缓存
@@ -129,11 +77,66 @@ func ConnectDatabase(config DatabaseConfig) (*gorm.DB, error) {
 	return db, nil
 }
 
+// SlowQueryLogger GORM日志：只输出慢查询、警告和错误，不输出"记录不存在"错误（多数是预期的查询结果）
+// 慢查询同时记录到性能监控器；NewPerformanceMonitor 传入使用该日志的连接时自动关联
+type SlowQueryLogger struct {
+	logger.Interface
+	threshold time.Duration
+	monitor   *atomic.Pointer[PerformanceMonitor] // LogMode返回的副本共用同一个监控器
+}
+
+// NewSlowQueryLogger 创建慢查询日志，threshold为0时使用 slowQueryThreshold
+func NewSlowQueryLogger(threshold time.Duration) *SlowQueryLogger {
+	if threshold <= 0 {
+		threshold = slowQueryThreshold
+	}
+	return &SlowQueryLogger{
+		Interface: logger.New(log.New(os.Stdout, "\r\n", log.LstdFlags), logger.Config{
+			SlowThreshold:             threshold,
+			LogLevel:                  logger.Warn,
+			IgnoreRecordNotFoundError: true,
+			Colorful:                  false,
+		}),
+		threshold: threshold,
+		monitor:   new(atomic.Pointer[PerformanceMonitor]),
+	}
+}
+
+// SetMonitor 设置接收慢查询的性能监控器，为nil时只输出日志
+func (l *SlowQueryLogger) SetMonitor(pm *PerformanceMonitor) {
+	l.monitor.Store(pm)
+}
+
+// LogMode 修改日志级别，返回的日志仍把慢查询记录到同一个监控器
+func (l *SlowQueryLogger) LogMode(level logger.LogLevel) logger.Interface {
+	copied := *l
+	copied.Interface = l.Interface.LogMode(level)
+	return &copied
+}
+
+// Trace 输出SQL日志，耗时超过阈值的查询记录到性能监控器（日志级别为Silent时同样记录）
+func (l *SlowQueryLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	l.Interface.Trace(ctx, begin, fc, err)
+
+	elapsed := time.Since(begin)
+	if elapsed <= l.threshold {
+		return
+	}
+	if pm := l.monitor.Load(); pm != nil {
+		sql, rows := fc()
+		pm.RecordSlowQuery(sql, elapsed, rows)
+	}
+}
+
 // PerformanceMonitor 性能监控器
 type PerformanceMonitor struct {
-	db        *gorm.DB
-	queryLogs []QueryLog
-	mu        sync.RWMutex
+	db           *gorm.DB
+	queryLogs    []QueryLog
+	slowQueries  []QueryLog   // SlowQueryLogger 记录的慢SQL，保留最近 maxSlowQueryLogs 条
+	window       *StatsWindow // 当前小时的累计统计，由 DailyDbReportJob 定期取出写入快照
+	mu           sync.RWMutex
+	poolTimeouts int64         // 等待连接超时次数
+	routes       *RouteMetrics // 按路由统计的接口耗时直方图
 }
 
 // QueryLog 查询日志
@@ -144,12 +147,49 @@ type QueryLog struct {
 	Time     time.Time     `json:"time"`
 }
 
-// NewPerformanceMonitor 创建性能监控器
+// maxSlowQueryLogs 性能监控器保留的慢SQL条数
+const maxSlowQueryLogs = 200
+
+// NewPerformanceMonitor 创建性能监控器，db使用 SlowQueryLogger 时慢查询自动记录到该监控器
 func NewPerformanceMonitor(db *gorm.DB) *PerformanceMonitor {
-	return &PerformanceMonitor{
+	pm := &PerformanceMonitor{
 		db:        db,
 		queryLogs: make([]QueryLog, 0),
+		window:    newStatsWindow(time.Now()),
+		routes:    NewRouteMetrics(nil, slowRequestThreshold),
+	}
+	if db != nil {
+		if slowLogger, ok := db.Logger.(*SlowQueryLogger); ok {
+			slowLogger.SetMonitor(pm)
+		}
+	}
+	return pm
+}
+
+// SetRouteBuckets 设置接口耗时直方图的桶上界（秒），已有的接口耗时统计清零，应在开始处理请求前调用
+func (pm *PerformanceMonitor) SetRouteBuckets(buckets []float64) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	pm.routes = NewRouteMetrics(buckets, pm.routes.slowThreshold)
+}
+
+// Routes 按路由统计的接口耗时直方图
+func (pm *PerformanceMonitor) Routes() *RouteMetrics {
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+
+	return pm.routes
+}
+
+// WritePrometheus 以Prometheus文本格式输出接口耗时直方图和连接池等待超时次数
+func (pm *PerformanceMonitor) WritePrometheus(w io.Writer) error {
+	if err := pm.Routes().WritePrometheus(w); err != nil {
+		return err
 	}
+	_, err := fmt.Fprintf(w, "# HELP db_pool_timeouts_total 等待数据库连接超时次数\n"+
+		"# TYPE db_pool_timeouts_total counter\ndb_pool_timeouts_total %d\n", pm.PoolTimeouts())
+	return err
 }
 
 // LogQuery 记录查询
@@ -163,6 +203,7 @@ func (pm *PerformanceMonitor) LogQuery(sql string, duration time.Duration, rows
 		Rows:     rows,
 		Time:     time.Now(),
 	})
+	pm.window.addQuery(sql, duration)
 
 	// 保持最近1000条记录
 	if len(pm.queryLogs) > 1000 {
@@ -170,6 +211,67 @@ func (pm *PerformanceMonitor) LogQuery(sql string, duration time.Duration, rows
 	}
 }
 
+// RecordSlowQuery 记录一条慢SQL，由 SlowQueryLogger 调用
+func (pm *PerformanceMonitor) RecordSlowQuery(sql string, duration time.Duration, rows int64) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	pm.slowQueries = append(pm.slowQueries, QueryLog{
+		SQL:      sql,
+		Duration: duration,
+		Rows:     rows,
+		Time:     time.Now(),
+	})
+	if len(pm.slowQueries) > maxSlowQueryLogs {
+		pm.slowQueries = pm.slowQueries[len(pm.slowQueries)-maxSlowQueryLogs:]
+	}
+}
+
+// SlowSQL 获取 SlowQueryLogger 记录的慢SQL，按时间先后排列
+func (pm *PerformanceMonitor) SlowSQL() []QueryLog {
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+
+	return append([]QueryLog(nil), pm.slowQueries...)
+}
+
+// RecordRequest 记录一次接口请求的响应状态码
+func (pm *PerformanceMonitor) RecordRequest(status int) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	pm.window.addRequest(status)
+}
+
+// TakeWindow 取出截至now的累计统计，并从now开始新的统计窗口
+func (pm *PerformanceMonitor) TakeWindow(now time.Time) *StatsWindow {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	window := pm.window
+	window.End = now
+	pm.window = newStatsWindow(now)
+	return window
+}
+
+// restoreWindow 把未能写入快照的统计合并回当前窗口
+func (pm *PerformanceMonitor) restoreWindow(window *StatsWindow) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	pm.window.merge(window)
+}
+
+// RecordPoolTimeout 记录一次等待连接超时
+func (pm *PerformanceMonitor) RecordPoolTimeout() {
+	atomic.AddInt64(&pm.poolTimeouts, 1)
+}
+
+// PoolTimeouts 获取等待连接超时次数
+func (pm *PerformanceMonitor) PoolTimeouts() int64 {
+	return atomic.LoadInt64(&pm.poolTimeouts)
+}
+
 // GetSlowQueries 获取慢查询
 func (pm *PerformanceMonitor) GetSlowQueries(threshold time.Duration) []QueryLog {
 	pm.mu.RLock()
@@ -195,6 +297,7 @@ func (pm *PerformanceMonitor) GetQueryStats() map[string]interface{} {
 			"avg_duration":  0,
 			"max_duration":  0,
 			"min_duration":  0,
+			"pool_timeouts": pm.PoolTimeouts(),
 		}
 	}
 
@@ -217,52 +320,96 @@ func (pm *PerformanceMonitor) GetQueryStats() map[string]interface{} {
 		"avg_duration":  totalDuration / time.Duration(len(pm.queryLogs)),
 		"max_duration":  maxDuration,
 		"min_duration":  minDuration,
+		"pool_timeouts": pm.PoolTimeouts(),
 	}
 }
 
+// ErrPoolTimeout 在限定时间内没有获取到数据库连接
+var ErrPoolTimeout = errors.New("等待数据库连接超时")
+
+// defaultAcquireTimeout 默认的获取连接超时时间
+const defaultAcquireTimeout = 2 * time.Second
+
 // OptimizedQueryService 优化查询服务
 type OptimizedQueryService struct {
-	db      *gorm.DB
-	monitor *PerformanceMonitor
+	db             *gorm.DB
+	monitor        *PerformanceMonitor
+	acquireTimeout time.Duration // 读查询获取连接的最长等待时间
 }
 
 // NewOptimizedQueryService 创建优化查询服务
 func NewOptimizedQueryService(db *gorm.DB, monitor *PerformanceMonitor) *OptimizedQueryService {
 	return &OptimizedQueryService{
-		db:      db,
-		monitor: monitor,
+		db:             db,
+		monitor:        monitor,
+		acquireTimeout: defaultAcquireTimeout,
+	}
+}
+
+// WithAcquireTimeout 设置读查询获取连接的超时时间
+func (s *OptimizedQueryService) WithAcquireTimeout(timeout time.Duration) *OptimizedQueryService {
+	s.acquireTimeout = timeout
+	return s
+}
+
+// withConn 在acquireTimeout内从连接池取得一个连接，在该连接上执行fn
+// 连接池耗尽时调用方得到ErrPoolTimeout而不是无限等待；获取连接后的查询只受ctx控制
+// 注意：在单个连接上执行的查询不经过PrepareStmt的语句缓存
+func (s *OptimizedQueryService) withConn(ctx context.Context, fn func(tx *gorm.DB) error) error {
+	sqlDB, err := s.db.DB()
+	if err != nil {
+		return err
 	}
+
+	acquireCtx, cancel := context.WithTimeout(ctx, s.acquireTimeout)
+	conn, err := sqlDB.Conn(acquireCtx)
+	cancel()
+	if err != nil {
+		// 只有获取连接的期限到了才算连接池超时，调用方自己的ctx取消原样返回
+		if errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil {
+			s.monitor.RecordPoolTimeout()
+			return fmt.Errorf("%w（%v）", ErrPoolTimeout, s.acquireTimeout)
+		}
+		return err
+	}
+	defer conn.Close()
+
+	tx := s.db.WithContext(ctx)
+	tx.Statement.ConnPool = conn
+	return fn(tx)
 }
 
 // GetProductsWithPagination 分页查询商品（优化版）
-func (s *OptimizedQueryService) GetProductsWithPagination(page, pageSize int, categoryID *uint) ([]Product, int64, error) {
+func (s *OptimizedQueryService) GetProductsWithPagination(ctx context.Context, page, pageSize int, categoryID *uint) ([]models.Product, int64, error) {
 	start := time.Now()
 	defer func() {
 		s.monitor.LogQuery("GetProductsWithPagination", time.Since(start), 0)
 	}()
 
-	var products []Product
+	var products []models.Product
 	var total int64
 
-	query := s.db.Model(&Product{}).Where("status = ?", 1)
-	if categoryID != nil {
-		query = query.Where("category_id = ?", *categoryID)
-	}
+	err := s.withConn(ctx, func(tx *gorm.DB) error {
+		query := tx.Model(&models.Product{}).Where("status = ?", 1)
+		if categoryID != nil {
+			query = query.Where("category_id = ?", *categoryID)
+		}
 
-	// 先获取总数
-	if err := query.Count(&total).Error; err != nil {
-		return nil, 0, err
-	}
+		// 先获取总数
+		if err := query.Count(&total).Error; err != nil {
+			return err
+		}
 
-	// 分页查询，使用索引优化
-	offset := (page - 1) * pageSize
-	err := query.Order("id DESC").Limit(pageSize).Offset(offset).Find(&products).Error
+		// 分页查询，使用索引优化
+		offset := (page - 1) * pageSize
+		return query.Order("id DESC").Limit(pageSize).Offset(offset).Find(&products).Error
+	})
 
 	return products, total, err
 }
 
 // GetOrdersWithJoin 关联查询订单（优化版）
-func (s *OptimizedQueryService) GetOrdersWithJoin(userID uint, limit int) ([]map[string]interface{}, error) {
+func (s *OptimizedQueryService) GetOrdersWithJoin(ctx context.Context, userID uint, limit int) ([]map[string]interface{}, error) {
 	start := time.Now()
 	defer func() {
 		s.monitor.LogQuery("GetOrdersWithJoin", time.Since(start), 0)
@@ -290,12 +437,14 @@ func (s *OptimizedQueryService) GetOrdersWithJoin(userID uint, limit int) ([]map
 		LIMIT ?
 	`
 
-	err := s.db.Raw(sql, userID, limit).Scan(&results).Error
+	err := s.withConn(ctx, func(tx *gorm.DB) error {
+		return tx.Raw(sql, userID, limit).Scan(&results).Error
+	})
 	return results, err
 }
 
 // GetSalesStatisticsOptimized 优化的销售统计
-func (s *OptimizedQueryService) GetSalesStatisticsOptimized(startDate, endDate time.Time) ([]map[string]interface{}, error) {
+func (s *OptimizedQueryService) GetSalesStatisticsOptimized(ctx context.Context, startDate, endDate time.Time) ([]map[string]interface{}, error) {
 	start := time.Now()
 	defer func() {
 		s.monitor.LogQuery("GetSalesStatisticsOptimized", time.Since(start), 0)
@@ -303,8 +452,15 @@ func (s *OptimizedQueryService) GetSalesStatisticsOptimized(startDate, endDate t
 
 	var results []map[string]interface{}
 
-	// 使用索引优化的查询
-	sql := `
+	err := s.withConn(ctx, func(tx *gorm.DB) error {
+		return tx.Raw(salesStatisticsSQL, startDate, endDate).Scan(&results).Error
+	})
+	return results, err
+}
+
+// salesStatisticsSQL 销售统计查询，依赖idx_orders_status_created索引
+// 状态写成IN列表而不是 status >= 2：范围条件之后的列用不上索引，IN列表可以对每个状态按created_at做范围扫描
+const salesStatisticsSQL = `
 		SELECT 
 			DATE(created_at) as date,
 			COUNT(*) as order_count,
@@ -313,18 +469,14 @@ func (s *OptimizedQueryService) GetSalesStatisticsOptimized(startDate, endDate t
 			AVG(pay_amount) as avg_order_value
 		FROM orders 
 		WHERE created_at >= ? AND created_at <= ? 
-			AND status >= 2 
+			AND status IN (2, 3, 4, 5) 
 			AND deleted_at IS NULL
 		GROUP BY DATE(created_at)
 		ORDER BY date
 	`
 
-	err := s.db.Raw(sql, startDate, endDate).Scan(&results).Error
-	return results, err
-}
-
 // BatchInsertProducts 批量插入商品
-func (s *OptimizedQueryService) BatchInsertProducts(products []Product, batchSize int) error {
+func (s *OptimizedQueryService) BatchInsertProducts(products []models.Product, batchSize int) error {
 	start := time.Now()
 	defer func() {
 		s.monitor.LogQuery("BatchInsertProducts", time.Since(start), int64(len(products)))
@@ -354,7 +506,7 @@ func (s *OptimizedQueryService) UpdateProductStockOptimized(productID uint, quan
 	}()
 
 	// 使用原子操作更新库存
-	result := s.db.Model(&Product{}).Where("id = ? AND stock >= ?", productID, quantity).
+	result := s.db.Model(&models.Product{}).Where("id = ? AND stock >= ?", productID, quantity).
 		Update("stock", gorm.Expr("stock - ?", quantity))
 
 	if result.Error != nil {
@@ -368,6 +520,114 @@ func (s *OptimizedQueryService) UpdateProductStockOptimized(productID uint, quan
 	return nil
 }
 
+// ProductDetail 商品详情页数据，预加载分类和品牌
+type ProductDetail struct {
+	models.Product
+	Category *models.Category `gorm:"foreignKey:CategoryID" json:"category,omitempty"`
+	Brand    *models.Brand    `gorm:"foreignKey:BrandID" json:"brand,omitempty"`
+}
+
+// TableName 指定表名
+func (ProductDetail) TableName() string {
+	return "products"
+}
+
+// GetProductDetail 查询商品详情页数据
+func (s *OptimizedQueryService) GetProductDetail(ctx context.Context, productID uint) (*ProductDetail, error) {
+	start := time.Now()
+	defer func() {
+		s.monitor.LogQuery("GetProductDetail", time.Since(start), 1)
+	}()
+
+	var detail ProductDetail
+	err := s.withConn(ctx, func(tx *gorm.DB) error {
+		return tx.Preload("Category").Preload("Brand").
+			Where("status = ?", 1).First(&detail, productID).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &detail, nil
+}
+
+// OrderItemInput 下单的商品和数量
+type OrderItemInput struct {
+	ProductID uint
+	Quantity  int
+}
+
+// orderSeq 订单号序列，避免并发下单时订单号重复
+var orderSeq int64
+
+// CreateOrder 创建订单并扣减库存，任一商品库存不足时整单回滚
+func (s *OptimizedQueryService) CreateOrder(ctx context.Context, userID uint, items []OrderItemInput) (*models.Order, error) {
+	start := time.Now()
+	defer func() {
+		s.monitor.LogQuery("CreateOrder", time.Since(start), int64(len(items)))
+	}()
+
+	if len(items) == 0 {
+		return nil, errors.New("订单商品不能为空")
+	}
+
+	// 按商品ID顺序扣减库存，并发下单时加锁顺序一致，避免死锁
+	items = append([]OrderItemInput(nil), items...)
+	sort.Slice(items, func(i, j int) bool { return items[i].ProductID < items[j].ProductID })
+
+	order := &models.Order{
+		OrderNo: fmt.Sprintf("ORD%d%04d", time.Now().UnixNano(), atomic.AddInt64(&orderSeq, 1)%10000),
+		UserID:  userID,
+		Status:  1, // 待付款
+	}
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		orderItems := make([]models.OrderItem, 0, len(items))
+		for _, item := range items {
+			var product models.Product
+			if err := tx.Select("id", "name", "price").Where("status = ?", 1).
+				First(&product, item.ProductID).Error; err != nil {
+				return fmt.Errorf("商品%d不存在或已下架: %w", item.ProductID, err)
+			}
+
+			result := tx.Model(&models.Product{}).
+				Where("id = ? AND stock >= ?", item.ProductID, item.Quantity).
+				UpdateColumns(map[string]interface{}{
+					"stock": gorm.Expr("stock - ?", item.Quantity),
+					"sales": gorm.Expr("sales + ?", item.Quantity),
+				})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return fmt.Errorf("商品%d库存不足", item.ProductID)
+			}
+
+			totalPrice := product.Price * int64(item.Quantity)
+			order.TotalAmount += totalPrice
+			orderItems = append(orderItems, models.OrderItem{
+				ProductID:   product.ID,
+				Quantity:    item.Quantity,
+				Price:       product.Price,
+				TotalPrice:  totalPrice,
+				ProductName: product.Name,
+			})
+		}
+
+		order.PayAmount = order.TotalAmount
+		if err := tx.Create(order).Error; err != nil {
+			return err
+		}
+		for i := range orderItems {
+			orderItems[i].OrderID = order.ID
+		}
+		return tx.Create(&orderItems).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
 // GetConnectionStats 获取连接池统计
 func GetConnectionStats(db *gorm.DB) (map[string]interface{}, error) {
 	sqlDB, err := db.DB()
@@ -389,132 +649,1263 @@ func GetConnectionStats(db *gorm.DB) (map[string]interface{}, error) {
 	}, nil
 }
 
-// CreateOptimizedIndexes 创建优化索引
-func CreateOptimizedIndexes(db *gorm.DB) error {
-	fmt.Println("创建优化索引...")
+// PoolStats 连接池采样结果
+type PoolStats struct {
+	sql.DBStats
+	Usage             float64       `json:"usage"`               // InUse / MaxOpenConnections，未限制最大连接数时为0
+	WaitCountDelta    int64         `json:"wait_count_delta"`    // 与上次采样相比新增的等待次数
+	WaitDurationDelta time.Duration `json:"wait_duration_delta"` // 与上次采样相比新增的等待时长
+	Alerts            []string      `json:"alerts"`              // 触发的告警原因
+	SampledAt         time.Time     `json:"sampled_at"`
+}
+
+// PoolMonitor 连接池监控器，定期采样 sqlDB.Stats()，
+// 使用率超过阈值或等待次数增长时触发告警回调
+type PoolMonitor struct {
+	sqlDB     *sql.DB
+	threshold float64
+	onAlert   func(PoolStats)
+
+	mu   sync.Mutex
+	prev sql.DBStats
+}
 
-	// 复合索引
-	indexes := []string{
-		"CREATE INDEX IF NOT EXISTS idx_orders_user_status_created ON orders(user_id, status, created_at)",
-		"CREATE INDEX IF NOT EXISTS idx_orders_status_created ON orders(status, created_at)",
-		"CREATE INDEX IF NOT EXISTS idx_products_category_status ON products(category_id, status)",
-		"CREATE INDEX IF NOT EXISTS idx_products_brand_status ON products(brand_id, status)",
-		"CREATE INDEX IF NOT EXISTS idx_order_items_order_product ON order_items(order_id, product_id)",
-		"CREATE INDEX IF NOT EXISTS idx_users_status_created ON users(status, created_at)",
+// NewPoolMonitor 创建连接池监控器，threshold为使用率告警阈值（0~1）
+func NewPoolMonitor(db *gorm.DB, threshold float64, onAlert func(PoolStats)) (*PoolMonitor, error) {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("获取sql.DB失败: %w", err)
 	}
 
-	for _, indexSQL := range indexes {
-		if err := db.Exec(indexSQL).Error; err != nil {
-			fmt.Printf("创建索引失败: %s, 错误: %v\n", indexSQL, err)
-		} else {
-			fmt.Printf("索引创建成功: %s\n", indexSQL)
-		}
+	return &PoolMonitor{
+		sqlDB:     sqlDB,
+		threshold: threshold,
+		onAlert:   onAlert,
+		prev:      sqlDB.Stats(),
+	}, nil
+}
+
+// Sample 采样一次连接池状态，计算与上次采样的差值，需要告警时调用回调
+func (m *PoolMonitor) Sample() PoolStats {
+	m.mu.Lock()
+	current := m.sqlDB.Stats()
+	prev := m.prev
+	m.prev = current
+	m.mu.Unlock()
+
+	stats := PoolStats{
+		DBStats:           current,
+		WaitCountDelta:    current.WaitCount - prev.WaitCount,
+		WaitDurationDelta: current.WaitDuration - prev.WaitDuration,
+		SampledAt:         time.Now(),
+	}
+	if current.MaxOpenConnections > 0 {
+		stats.Usage = float64(current.InUse) / float64(current.MaxOpenConnections)
 	}
 
-	return nil
+	if current.MaxOpenConnections > 0 && stats.Usage >= m.threshold {
+		stats.Alerts = append(stats.Alerts, fmt.Sprintf("连接池使用率 %.0f%% 超过阈值 %.0f%%", stats.Usage*100, m.threshold*100))
+	}
+	if stats.WaitCountDelta > 0 {
+		stats.Alerts = append(stats.Alerts, fmt.Sprintf("新增等待连接 %d 次，等待时长 %v", stats.WaitCountDelta, stats.WaitDurationDelta))
+	}
+
+	if len(stats.Alerts) > 0 && m.onAlert != nil {
+		m.onAlert(stats)
+	}
+	return stats
 }
 
-// BenchmarkTest 性能基准测试
-type BenchmarkTest struct {
-	db      *gorm.DB
-	monitor *PerformanceMonitor
+// Run 按interval定期采样，ctx取消时返回
+func (m *PoolMonitor) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.Sample()
+		}
+	}
 }
 
-// NewBenchmarkTest 创建基准测试
-func NewBenchmarkTest(db *gorm.DB, monitor *PerformanceMonitor) *BenchmarkTest {
-	return &BenchmarkTest{
-		db:      db,
-		monitor: monitor,
+// StartPoolMonitor 在后台启动连接池监控，ctx取消时停止
+func StartPoolMonitor(ctx context.Context, db *gorm.DB, interval time.Duration, threshold float64, onAlert func(PoolStats)) (*PoolMonitor, error) {
+	monitor, err := NewPoolMonitor(db, threshold, onAlert)
+	if err != nil {
+		return nil, err
 	}
+	go monitor.Run(ctx, interval)
+	return monitor, nil
 }
 
-// RunConcurrentQueries 并发查询测试
-func (bt *BenchmarkTest) RunConcurrentQueries(concurrency int, iterations int) {
-	fmt.Printf("\n开始并发查询测试: %d个并发, 每个执行%d次查询\n", concurrency, iterations)
+// slowQueryThreshold 慢查询阈值，日报中的慢查询数按此统计
+const slowQueryThreshold = 100 * time.Millisecond
 
-	start := time.Now()
-	var wg sync.WaitGroup
+// maxWindowStatements 一个统计窗口内分别统计的语句数上限，超出的语句合并到 otherStatements
+const maxWindowStatements = 500
 
-	for i := 0; i < concurrency; i++ {
-		wg.Add(1)
-		go func(workerID int) {
-			defer wg.Done()
+// otherStatements 超出语句数上限后合并统计使用的语句名
+const otherStatements = "(其他语句)"
 
-			for j := 0; j < iterations; j++ {
-				// 查询商品
-				var products []Product
-				bt.db.Where("status = ?", 1).Limit(10).Find(&products)
+// latencyBuckets 查询耗时直方图的桶上界，小时快照只保存直方图，按直方图合并后计算全天的P95
+var latencyBuckets = []time.Duration{
+	time.Millisecond, 2 * time.Millisecond, 5 * time.Millisecond, 10 * time.Millisecond,
+	20 * time.Millisecond, 50 * time.Millisecond, 100 * time.Millisecond, 200 * time.Millisecond,
+	500 * time.Millisecond, time.Second, 2 * time.Second, 5 * time.Second, 10 * time.Second,
+}
 
-				// 查询订单
-				var orders []Order
-				bt.db.Where("status >= ?", 2).Limit(5).Find(&orders)
+// StatementTotal 单条语句在统计窗口内的执行次数和总耗时
+type StatementTotal struct {
+	Count   int64   `json:"count"`
+	TotalMs float64 `json:"total_ms"`
+}
 
-				// 统计查询
-				var count int64
-				bt.db.Model(&User{}).Where("status = ?", 1).Count(&count)
-			}
-		}(i)
+// StatsWindow 监控器在一个统计窗口（通常为一小时）内的累计数据，即小时快照的内容
+type StatsWindow struct {
+	Start           time.Time                  `json:"start"`
+	End             time.Time                  `json:"end"`
+	Queries         int64                      `json:"queries"`
+	SlowQueries     int64                      `json:"slow_queries"`
+	MaxMs           float64                    `json:"max_ms"`
+	Histogram       []int64                    `json:"histogram"` // 按latencyBuckets分桶的查询数，多出的最后一桶为超过最大上界的查询
+	Statements      map[string]*StatementTotal `json:"statements"`
+	APIRequests     int64                      `json:"api_requests"`
+	APIErrors       int64                      `json:"api_errors"`        // 5xx响应数
+	APIClientErrors int64                      `json:"api_client_errors"` // 4xx响应数
+}
+
+// newStatsWindow 创建从start开始的空统计窗口
+func newStatsWindow(start time.Time) *StatsWindow {
+	return &StatsWindow{
+		Start:      start,
+		Histogram:  make([]int64, len(latencyBuckets)+1),
+		Statements: make(map[string]*StatementTotal),
 	}
+}
 
-	wg.Wait()
-	duration := time.Since(start)
+// addQuery 累计一次查询，语句文本去掉多余空白后作为统计的键
+func (w *StatsWindow) addQuery(sql string, duration time.Duration) {
+	ms := float64(duration) / float64(time.Millisecond)
+	w.Queries++
+	if duration > slowQueryThreshold {
+		w.SlowQueries++
+	}
+	if ms > w.MaxMs {
+		w.MaxMs = ms
+	}
+	w.Histogram[sort.Search(len(latencyBuckets), func(i int) bool { return duration <= latencyBuckets[i] })]++
+	w.addStatement(strings.Join(strings.Fields(sql), " "), StatementTotal{Count: 1, TotalMs: ms})
+}
 
-	totalQueries := concurrency * iterations * 3 // 每次循环3个查询
-	qps := float64(totalQueries) / duration.Seconds()
+// addStatement 累计单条语句的统计，语句数达到上限后新语句合并到 otherStatements
+func (w *StatsWindow) addStatement(sql string, total StatementTotal) {
+	stmt, ok := w.Statements[sql]
+	if !ok {
+		if len(w.Statements) >= maxWindowStatements {
+			sql = otherStatements
+			stmt, ok = w.Statements[sql]
+		}
+		if !ok {
+			stmt = &StatementTotal{}
+			w.Statements[sql] = stmt
+		}
+	}
+	stmt.Count += total.Count
+	stmt.TotalMs += total.TotalMs
+}
 
-	fmt.Printf("并发测试完成: 总耗时 %v, 总查询数 %d, QPS: %.2f\n", duration, totalQueries, qps)
+// addRequest 累计一次接口请求
+func (w *StatsWindow) addRequest(status int) {
+	w.APIRequests++
+	switch {
+	case status >= http.StatusInternalServerError:
+		w.APIErrors++
+	case status >= http.StatusBadRequest:
+		w.APIClientErrors++
+	}
 }
 
-// RunBatchInsertTest 批量插入测试
-func (bt *BenchmarkTest) RunBatchInsertTest(totalRecords int, batchSize int) {
-	fmt.Printf("\n开始批量插入测试: 总记录数 %d, 批次大小 %d\n", totalRecords, batchSize)
+// merge 把另一个窗口的数据合并进来，时间范围取两者的并集
+func (w *StatsWindow) merge(other *StatsWindow) {
+	if w.Start.IsZero() || (!other.Start.IsZero() && other.Start.Before(w.Start)) {
+		w.Start = other.Start
+	}
+	if other.End.After(w.End) {
+		w.End = other.End
+	}
+	w.Queries += other.Queries
+	w.SlowQueries += other.SlowQueries
+	if other.MaxMs > w.MaxMs {
+		w.MaxMs = other.MaxMs
+	}
+	for i := 0; i < len(w.Histogram) && i < len(other.Histogram); i++ {
+		w.Histogram[i] += other.Histogram[i]
+	}
+	for sql, total := range other.Statements {
+		w.addStatement(sql, *total)
+	}
+	w.APIRequests += other.APIRequests
+	w.APIErrors += other.APIErrors
+	w.APIClientErrors += other.APIClientErrors
+}
 
-	// 生成测试数据
-	products := make([]Product, totalRecords)
-	for i := 0; i < totalRecords; i++ {
-		products[i] = Product{
-			Name:       fmt.Sprintf("测试商品%d", i),
-			SKU:        fmt.Sprintf("TEST%d", i),
-			CategoryID: 1,
-			Price:      int64(1000 + i),
-			Stock:      100,
-			Status:     1,
+// percentileMs 按直方图估算分位数（毫秒），取所在桶的上界，不超过窗口内的最大耗时
+func (w *StatsWindow) percentileMs(q float64) float64 {
+	if w.Queries == 0 {
+		return 0
+	}
+	rank := int64(math.Ceil(q * float64(w.Queries)))
+	var seen int64
+	for i, n := range w.Histogram {
+		seen += n
+		if seen < rank {
+			continue
+		}
+		if i < len(latencyBuckets) {
+			return math.Min(float64(latencyBuckets[i])/float64(time.Millisecond), w.MaxMs)
 		}
+		break
 	}
+	return w.MaxMs
+}
 
-	start := time.Now()
+// empty 窗口内没有任何查询和请求
+func (w *StatsWindow) empty() bool {
+	return w.Queries == 0 && w.APIRequests == 0
+}
 
-	// 批量插入
-	service := NewOptimizedQueryService(bt.db, bt.monitor)
-	err := service.BatchInsertProducts(products, batchSize)
+// DbStatsSnapshot 监控数据的小时快照。监控器只在内存中统计，进程重启即清零，
+// 快照落库后日报按快照汇总，不受重启影响
+type DbStatsSnapshot struct {
+	ID          uint      `gorm:"primarykey"`
+	PeriodStart time.Time `gorm:"index;not null"` // 快照按开始时间归属到某一天
+	PeriodEnd   time.Time `gorm:"not null"`
+	Payload     string    `gorm:"type:json;not null"` // StatsWindow 的JSON
+	CreatedAt   time.Time
+}
 
-	duration := time.Since(start)
+// DbDailyReport 每日数据库报告，每天一行
+type DbDailyReport struct {
+	ID        uint   `gorm:"primarykey"`
+	Date      string `gorm:"type:char(10);uniqueIndex;not null"` // 报告日期，格式 2006-01-02
+	Payload   string `gorm:"type:json;not null"`                 // DailyReportData 的JSON
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
 
-	if err != nil {
-		fmt.Printf("批量插入失败: %v\n", err)
-	} else {
-		rps := float64(totalRecords) / duration.Seconds()
-		fmt.Printf("批量插入完成: 总耗时 %v, 插入速度: %.2f records/s\n", duration, rps)
-	}
+// StatementStats 日报中按总耗时排名的语句
+type StatementStats struct {
+	SQL     string  `json:"sql"`
+	Count   int64   `json:"count"`
+	TotalMs float64 `json:"total_ms"`
+	AvgMs   float64 `json:"avg_ms"`
+}
 
-	// 清理测试数据
-	bt.db.Where("sku LIKE 'TEST%'").Delete(&Product{})
+// DailyReportData 日报内容
+type DailyReportData struct {
+	Date            string           `json:"date"`
+	Snapshots       int              `json:"snapshots"` // 参与汇总的小时快照数
+	Queries         int64            `json:"queries"`
+	SlowQueries     int64            `json:"slow_queries"`
+	SlowThresholdMs float64          `json:"slow_threshold_ms"`
+	P95Ms           float64          `json:"p95_ms"`
+	MaxMs           float64          `json:"max_ms"`
+	TopStatements   []StatementStats `json:"top_statements"` // 总耗时前10的语句
+	APIRequests     int64            `json:"api_requests"`
+	APIErrors       int64            `json:"api_errors"`
+	APIClientErrors int64            `json:"api_client_errors"`
+	APIErrorRate    float64          `json:"api_error_rate"` // 5xx响应占比
 }
 
-// SeedTestData 填充测试数据
-func SeedTestData(db *gorm.DB) error {
-	fmt.Println("开始填充测试数据...")
+// topStatementLimit 日报保留的语句数
+const topStatementLimit = 10
 
-	// 创建用户
-	users := []User{
-		{Username: "user1", Email: "user1@example.com", Phone: "13800138001", Password: "password", Nickname: "用户1"},
-		{Username: "user2", Email: "user2@example.com", Phone: "13800138002", Password: "password", Nickname: "用户2"},
-		{Username: "user3", Email: "user3@example.com", Phone: "13800138003", Password: "password", Nickname: "用户3"},
+// maxReportDays 日报查询接口一次最多返回的天数
+const maxReportDays = 90
+
+// DailyDbReportJob 数据库日报任务：每个整点把监控器的统计写入小时快照，
+// 每天01:00把前一天的快照汇总为 db_daily_reports 中的一行
+type DailyDbReportJob struct {
+	db      *gorm.DB
+	monitor *PerformanceMonitor
+	loc     *time.Location   // 按该时区划分日期
+	now     func() time.Time // 当前时间，测试时可替换
+}
+
+// NewDailyDbReportJob 创建数据库日报任务，按本地时区划分日期
+func NewDailyDbReportJob(db *gorm.DB, monitor *PerformanceMonitor) *DailyDbReportJob {
+	return &DailyDbReportJob{
+		db:      db,
+		monitor: monitor,
+		loc:     time.Local,
+		now:     time.Now,
 	}
-	db.Create(&users)
+}
 
-	// 创建分类
-	categories := []Category{
+// Migrate 创建快照表和日报表
+func (j *DailyDbReportJob) Migrate() error {
+	return j.db.AutoMigrate(&DbStatsSnapshot{}, &DbDailyReport{})
+}
+
+// WriteSnapshot 取出监控器当前窗口的统计写入快照表，窗口内没有数据时不写；
+// 写入失败时把统计放回监控器，下次快照一并写入
+func (j *DailyDbReportJob) WriteSnapshot() (*DbStatsSnapshot, error) {
+	window := j.monitor.TakeWindow(j.now())
+	if window.empty() {
+		return nil, nil
+	}
+
+	payload, err := json.Marshal(window)
+	if err != nil {
+		j.monitor.restoreWindow(window)
+		return nil, fmt.Errorf("序列化快照失败: %w", err)
+	}
+	snapshot := &DbStatsSnapshot{
+		PeriodStart: window.Start,
+		PeriodEnd:   window.End,
+		Payload:     string(payload),
+	}
+	if err := j.db.Create(snapshot).Error; err != nil {
+		j.monitor.restoreWindow(window)
+		return nil, fmt.Errorf("写入快照失败: %w", err)
+	}
+	return snapshot, nil
+}
+
+// Rollup 汇总某一天（按开始时间归属）的全部快照，写入或覆盖当天的日报，可重复执行
+func (j *DailyDbReportJob) Rollup(day time.Time) (*DailyReportData, error) {
+	day = day.In(j.loc)
+	start := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, j.loc)
+	end := start.AddDate(0, 0, 1)
+
+	var snapshots []DbStatsSnapshot
+	err := j.db.Where("period_start >= ? AND period_start < ?", start, end).
+		Order("period_start").Find(&snapshots).Error
+	if err != nil {
+		return nil, fmt.Errorf("查询快照失败: %w", err)
+	}
+
+	total := newStatsWindow(time.Time{})
+	for _, snapshot := range snapshots {
+		var window StatsWindow
+		if err := json.Unmarshal([]byte(snapshot.Payload), &window); err != nil {
+			return nil, fmt.Errorf("解析快照 %d 失败: %w", snapshot.ID, err)
+		}
+		total.merge(&window)
+	}
+
+	data := buildDailyReport(start.Format("2006-01-02"), len(snapshots), total)
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("序列化日报失败: %w", err)
+	}
+	report := DbDailyReport{Date: data.Date, Payload: string(payload)}
+	err = j.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "date"}},
+		DoUpdates: clause.AssignmentColumns([]string{"payload", "updated_at"}),
+	}).Create(&report).Error
+	if err != nil {
+		return nil, fmt.Errorf("写入日报失败: %w", err)
+	}
+	return data, nil
+}
+
+// buildDailyReport 由合并后的窗口计算日报：总耗时前10的语句、慢查询数、P95和接口错误数
+func buildDailyReport(date string, snapshots int, total *StatsWindow) *DailyReportData {
+	data := &DailyReportData{
+		Date:            date,
+		Snapshots:       snapshots,
+		Queries:         total.Queries,
+		SlowQueries:     total.SlowQueries,
+		SlowThresholdMs: float64(slowQueryThreshold) / float64(time.Millisecond),
+		P95Ms:           total.percentileMs(0.95),
+		MaxMs:           total.MaxMs,
+		TopStatements:   make([]StatementStats, 0, len(total.Statements)),
+		APIRequests:     total.APIRequests,
+		APIErrors:       total.APIErrors,
+		APIClientErrors: total.APIClientErrors,
+	}
+	if total.APIRequests > 0 {
+		data.APIErrorRate = float64(total.APIErrors) / float64(total.APIRequests)
+	}
+
+	for sql, stmt := range total.Statements {
+		data.TopStatements = append(data.TopStatements, StatementStats{
+			SQL:     sql,
+			Count:   stmt.Count,
+			TotalMs: stmt.TotalMs,
+			AvgMs:   stmt.TotalMs / float64(stmt.Count),
+		})
+	}
+	sort.Slice(data.TopStatements, func(a, b int) bool {
+		if data.TopStatements[a].TotalMs != data.TopStatements[b].TotalMs {
+			return data.TopStatements[a].TotalMs > data.TopStatements[b].TotalMs
+		}
+		return data.TopStatements[a].SQL < data.TopStatements[b].SQL
+	})
+	if len(data.TopStatements) > topStatementLimit {
+		data.TopStatements = data.TopStatements[:topStatementLimit]
+	}
+	return data
+}
+
+// Reports 最近days天（不含今天）的日报，按日期正序，便于直接画图；没有日报的日期不出现
+func (j *DailyDbReportJob) Reports(days int) ([]DailyReportData, error) {
+	today := j.now().In(j.loc)
+	from := time.Date(today.Year(), today.Month(), today.Day()-days, 0, 0, 0, 0, j.loc)
+
+	var rows []DbDailyReport
+	err := j.db.Where("date >= ?", from.Format("2006-01-02")).Order("date").Find(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("查询日报失败: %w", err)
+	}
+
+	reports := make([]DailyReportData, len(rows))
+	for i, row := range rows {
+		if err := json.Unmarshal([]byte(row.Payload), &reports[i]); err != nil {
+			return nil, fmt.Errorf("解析日报 %s 失败: %w", row.Date, err)
+		}
+	}
+	return reports, nil
+}
+
+// Run 每个整点写一次快照，01:00的快照写完后汇总前一天的日报；ctx取消时写入最后一次快照后返回
+func (j *DailyDbReportJob) Run(ctx context.Context) {
+	for {
+		now := j.now().In(j.loc)
+		next := time.Date(now.Year(), now.Month(), now.Day(), now.Hour()+1, 0, 0, 0, j.loc)
+		timer := time.NewTimer(next.Sub(now))
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			if _, err := j.WriteSnapshot(); err != nil {
+				log.Printf("写入数据库统计快照失败: %v", err)
+			}
+			return
+		case <-timer.C:
+		}
+
+		if _, err := j.WriteSnapshot(); err != nil {
+			log.Printf("写入数据库统计快照失败: %v", err)
+		}
+		if next.Hour() == 1 {
+			if _, err := j.Rollup(next.AddDate(0, 0, -1)); err != nil {
+				log.Printf("汇总数据库日报失败: %v", err)
+			}
+		}
+	}
+}
+
+// StartDailyDbReportJob 创建表并在后台启动数据库日报任务，ctx取消时停止
+func StartDailyDbReportJob(ctx context.Context, db *gorm.DB, monitor *PerformanceMonitor) (*DailyDbReportJob, error) {
+	job := NewDailyDbReportJob(db, monitor)
+	if err := job.Migrate(); err != nil {
+		return nil, fmt.Errorf("创建数据库日报表失败: %w", err)
+	}
+	go job.Run(ctx)
+	return job, nil
+}
+
+// RequestLogMiddleware 请求日志中间件，记录每个请求的状态码和耗时，状态码计入监控器供日报统计接口错误，
+// 耗时按路由模板计入接口耗时直方图；订单接口的慢请求记录订单ID，见 SetExemplarOrderID
+func RequestLogMiddleware(monitor *PerformanceMonitor) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		status := c.Writer.Status()
+		duration := time.Since(start)
+		monitor.RecordRequest(status)
+
+		route := routeLabel(c)
+		routes := monitor.Routes()
+		routes.Observe(c.Request.Method, route, status, duration)
+		if isOrderRoute(route) {
+			routes.RecordExemplar(c.Request.Method, route, exemplarOrderID(c), status, duration)
+		}
+		log.Printf("%s %s %d %v", c.Request.Method, c.Request.URL.Path, status, duration)
+	}
+}
+
+// AdminTokenAuth 管理接口鉴权，请求头 X-Admin-Token 须与token一致
+func AdminTokenAuth(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		given := c.GetHeader("X-Admin-Token")
+		if token == "" || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "无权访问管理接口"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// NewAdminRouter 创建带请求日志中间件的路由，注册 GET /api/v1/admin/db-reports?days=14
+func NewAdminRouter(job *DailyDbReportJob, adminToken string) *gin.Engine {
+	r := gin.New()
+	r.Use(gin.Recovery(), RequestLogMiddleware(job.monitor))
+
+	admin := r.Group("/api/v1/admin", AdminTokenAuth(adminToken))
+	admin.GET("/db-reports", job.handleReports)
+	admin.GET("/metrics", job.handleMetrics)
+	admin.GET("/debug/route-exemplars", job.handleRouteExemplars)
+	return r
+}
+
+// handleMetrics 以Prometheus文本格式输出监控指标
+func (j *DailyDbReportJob) handleMetrics(c *gin.Context) {
+	c.Header("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	c.Status(http.StatusOK)
+	if err := j.monitor.WritePrometheus(c.Writer); err != nil {
+		log.Printf("输出监控指标失败: %v", err)
+	}
+}
+
+// handleRouteExemplars 返回订单接口最近一次慢请求的订单ID，从某个路由的耗时尖刺定位到具体订单
+func (j *DailyDbReportJob) handleRouteExemplars(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"slow_threshold_ms": float64(j.monitor.Routes().slowThreshold) / float64(time.Millisecond),
+		"exemplars":         j.monitor.Routes().Exemplars(),
+	})
+}
+
+// handleReports 返回最近days天（默认14，最多90）的日报序列
+func (j *DailyDbReportJob) handleReports(c *gin.Context) {
+	days, err := strconv.Atoi(c.DefaultQuery("days", "14"))
+	if err != nil || days < 1 || days > maxReportDays {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("days须为1到%d之间的整数", maxReportDays)})
+		return
+	}
+
+	reports, err := j.Reports(days)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"days": days, "reports": reports})
+}
+
+// ==================== 接口耗时指标 ====================
+// 按 方法 + 路由模板 + 状态类别 统计接口耗时直方图，以Prometheus文本格式导出。
+// 路由模板取 gin 的 FullPath()（如 /api/v1/orders/:id），没有匹配到路由的请求（404）统一记为 unmatchedRoute，
+// 序列数只取决于注册的路由数，不会随URL中的ID或扫描请求增长
+
+// defaultRouteBuckets 接口耗时直方图默认的桶上界（秒）
+var defaultRouteBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// slowRequestThreshold 慢请求阈值，订单接口超过该耗时的请求记录订单ID
+const slowRequestThreshold = 500 * time.Millisecond
+
+// unmatchedRoute 没有匹配到路由的请求使用的路由标签
+const unmatchedRoute = "unmatched"
+
+// exemplarOrderIDKey 订单接口处理函数保存订单ID的上下文键
+const exemplarOrderIDKey = "metrics.order_id"
+
+// routeSeries 直方图的一条序列
+type routeSeries struct {
+	method string
+	route  string
+	class  string // 状态类别，如 2xx
+}
+
+// routeHistogram 一条序列的直方图，counts 按桶分别计数（不累加），多出的最后一桶为超过最大上界的请求
+type routeHistogram struct {
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// RouteExemplar 某个订单接口最近一次慢请求
+type RouteExemplar struct {
+	Method     string    `json:"method"`
+	Route      string    `json:"route"`
+	OrderID    string    `json:"order_id"`
+	Status     int       `json:"status"`
+	DurationMs float64   `json:"duration_ms"`
+	Time       time.Time `json:"time"`
+}
+
+// RouteMetrics 接口耗时直方图及订单接口的慢请求记录
+type RouteMetrics struct {
+	buckets       []float64
+	slowThreshold time.Duration
+
+	mu        sync.Mutex
+	series    map[routeSeries]*routeHistogram
+	exemplars map[string]RouteExemplar // 键为 方法 + 空格 + 路由模板
+}
+
+// NewRouteMetrics 创建接口耗时统计，buckets为桶上界（秒），为空时使用 defaultRouteBuckets；
+// 订单接口耗时不低于slowThreshold的请求记录订单ID
+func NewRouteMetrics(buckets []float64, slowThreshold time.Duration) *RouteMetrics {
+	if len(buckets) == 0 {
+		buckets = defaultRouteBuckets
+	}
+	sorted := append([]float64(nil), buckets...)
+	sort.Float64s(sorted)
+	unique := sorted[:0]
+	for _, b := range sorted {
+		if len(unique) == 0 || b != unique[len(unique)-1] {
+			unique = append(unique, b)
+		}
+	}
+	return &RouteMetrics{
+		buckets:       unique,
+		slowThreshold: slowThreshold,
+		series:        make(map[routeSeries]*routeHistogram),
+		exemplars:     make(map[string]RouteExemplar),
+	}
+}
+
+// Observe 记录一次请求的耗时
+func (m *RouteMetrics) Observe(method, route string, status int, duration time.Duration) {
+	key := routeSeries{method: method, route: route, class: statusClass(status)}
+	seconds := duration.Seconds()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	h, ok := m.series[key]
+	if !ok {
+		h = &routeHistogram{counts: make([]uint64, len(m.buckets)+1)}
+		m.series[key] = h
+	}
+	h.counts[sort.SearchFloat64s(m.buckets, seconds)]++
+	h.sum += seconds
+	h.count++
+}
+
+// RecordExemplar 耗时达到慢请求阈值且有订单ID时，记为该路由最近一次慢请求
+func (m *RouteMetrics) RecordExemplar(method, route, orderID string, status int, duration time.Duration) {
+	if orderID == "" || duration < m.slowThreshold {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.exemplars[method+" "+route] = RouteExemplar{
+		Method:     method,
+		Route:      route,
+		OrderID:    orderID,
+		Status:     status,
+		DurationMs: float64(duration) / float64(time.Millisecond),
+		Time:       time.Now(),
+	}
+}
+
+// Exemplars 各订单接口最近一次慢请求，按路由和方法排序
+func (m *RouteMetrics) Exemplars() []RouteExemplar {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	exemplars := make([]RouteExemplar, 0, len(m.exemplars))
+	for _, e := range m.exemplars {
+		exemplars = append(exemplars, e)
+	}
+	sort.Slice(exemplars, func(a, b int) bool {
+		if exemplars[a].Route != exemplars[b].Route {
+			return exemplars[a].Route < exemplars[b].Route
+		}
+		return exemplars[a].Method < exemplars[b].Method
+	})
+	return exemplars
+}
+
+// WritePrometheus 以Prometheus文本格式输出直方图 http_request_duration_seconds，序列按标签排序
+func (m *RouteMetrics) WritePrometheus(w io.Writer) error {
+	m.mu.Lock()
+	keys := make([]routeSeries, 0, len(m.series))
+	for key := range m.series {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(a, b int) bool {
+		if keys[a].route != keys[b].route {
+			return keys[a].route < keys[b].route
+		}
+		if keys[a].method != keys[b].method {
+			return keys[a].method < keys[b].method
+		}
+		return keys[a].class < keys[b].class
+	})
+
+	var sb strings.Builder
+	sb.WriteString("# HELP http_request_duration_seconds 接口请求耗时（秒），按方法、路由模板和状态类别统计\n")
+	sb.WriteString("# TYPE http_request_duration_seconds histogram\n")
+	for _, key := range keys {
+		h := m.series[key]
+		labels := fmt.Sprintf(`method="%s",route="%s",status="%s"`,
+			escapeLabel(key.method), escapeLabel(key.route), key.class)
+		var cumulative uint64
+		for i, upper := range m.buckets {
+			cumulative += h.counts[i]
+			fmt.Fprintf(&sb, "http_request_duration_seconds_bucket{%s,le=\"%s\"} %d\n",
+				labels, strconv.FormatFloat(upper, 'g', -1, 64), cumulative)
+		}
+		fmt.Fprintf(&sb, "http_request_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", labels, h.count)
+		fmt.Fprintf(&sb, "http_request_duration_seconds_sum{%s} %s\n", labels, strconv.FormatFloat(h.sum, 'g', -1, 64))
+		fmt.Fprintf(&sb, "http_request_duration_seconds_count{%s} %d\n", labels, h.count)
+	}
+	m.mu.Unlock()
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// SetExemplarOrderID 订单接口的处理函数在知道订单ID后调用（如创建订单成功后），慢请求时记录该订单ID；
+// 路由中有 :id 参数的订单接口不需要调用
+func SetExemplarOrderID(c *gin.Context, orderID string) {
+	c.Set(exemplarOrderIDKey, orderID)
+}
+
+// exemplarOrderID 请求对应的订单ID：处理函数设置的优先，其次取路由参数 :id
+func exemplarOrderID(c *gin.Context) string {
+	if id := c.GetString(exemplarOrderIDKey); id != "" {
+		return id
+	}
+	return c.Param("id")
+}
+
+// routeLabel 请求的路由模板，没有匹配到路由时为 unmatchedRoute
+func routeLabel(c *gin.Context) string {
+	if route := c.FullPath(); route != "" {
+		return route
+	}
+	return unmatchedRoute
+}
+
+// isOrderRoute 是否为订单接口（路由模板中有 orders 这一段），只有订单接口记录慢请求的订单ID
+func isOrderRoute(route string) bool {
+	for _, segment := range strings.Split(route, "/") {
+		if segment == "orders" {
+			return true
+		}
+	}
+	return false
+}
+
+// statusClass 状态码类别，如 200 -> 2xx
+func statusClass(status int) string {
+	return strconv.Itoa(status/100) + "xx"
+}
+
+// labelEscaper 按Prometheus文本格式转义标签值中的反斜杠、双引号和换行
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(value string) string {
+	return labelEscaper.Replace(value)
+}
+
+// optimizedIndexes 核心查询依赖的复合索引，索引名 -> 表和列
+// 查询是否命中这些索引由测试中的 AssertIndexUsed 检查（index_test.go）
+var optimizedIndexes = []struct {
+	name, table, columns string
+}{
+	{"idx_orders_user_status_created", "orders", "user_id, status, created_at"},
+	{"idx_orders_status_created", "orders", "status, created_at"},
+	{"idx_products_category_status", "products", "category_id, status"},
+	{"idx_products_brand_status", "products", "brand_id, status"},
+	{"idx_order_items_order_product", "order_items", "order_id, product_id"},
+	{"idx_users_status_created", "users", "status, created_at"},
+}
+
+// CreateOptimizedIndexes 创建优化索引，已存在的跳过
+// MySQL不支持 CREATE INDEX IF NOT EXISTS，先通过 Migrator 检查索引是否存在
+func CreateOptimizedIndexes(db *gorm.DB) error {
+	fmt.Println("创建优化索引...")
+
+	for _, idx := range optimizedIndexes {
+		if db.Migrator().HasIndex(idx.table, idx.name) {
+			continue
+		}
+		indexSQL := fmt.Sprintf("CREATE INDEX %s ON %s(%s)", idx.name, idx.table, idx.columns)
+		if err := db.Exec(indexSQL).Error; err != nil {
+			fmt.Printf("创建索引失败: %s, 错误: %v\n", indexSQL, err)
+		} else {
+			fmt.Printf("索引创建成功: %s\n", indexSQL)
+		}
+	}
+
+	return nil
+}
+
+// BenchmarkTest 性能基准测试
+type BenchmarkTest struct {
+	db             *gorm.DB
+	monitor        *PerformanceMonitor
+	acquireTimeout time.Duration // 并发查询时获取连接的超时时间
+}
+
+// NewBenchmarkTest 创建基准测试
+func NewBenchmarkTest(db *gorm.DB, monitor *PerformanceMonitor) *BenchmarkTest {
+	return &BenchmarkTest{
+		db:             db,
+		monitor:        monitor,
+		acquireTimeout: defaultAcquireTimeout,
+	}
+}
+
+// SetAcquireTimeout 设置并发查询时获取连接的超时时间
+func (bt *BenchmarkTest) SetAcquireTimeout(timeout time.Duration) {
+	bt.acquireTimeout = timeout
+}
+
+// RunConcurrentQueries 并发查询测试
+// 每轮查询先在acquireTimeout内获取连接，并发数超过MaxOpenConns时部分请求会得到ErrPoolTimeout
+func (bt *BenchmarkTest) RunConcurrentQueries(concurrency int, iterations int) {
+	fmt.Printf("\n开始并发查询测试: %d个并发, 每个执行%d次查询, 获取连接超时 %v\n",
+		concurrency, iterations, bt.acquireTimeout)
+
+	service := NewOptimizedQueryService(bt.db, bt.monitor).WithAcquireTimeout(bt.acquireTimeout)
+	ctx := context.Background()
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	var timeouts, failures int64
+
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func(workerID int) {
+			defer wg.Done()
+
+			for j := 0; j < iterations; j++ {
+				err := service.withConn(ctx, func(tx *gorm.DB) error {
+					// 查询商品
+					var products []models.Product
+					if err := tx.Where("status = ?", 1).Limit(10).Find(&products).Error; err != nil {
+						return err
+					}
+
+					// 查询订单
+					var orders []models.Order
+					if err := tx.Where("status >= ?", 2).Limit(5).Find(&orders).Error; err != nil {
+						return err
+					}
+
+					// 统计查询
+					var count int64
+					return tx.Model(&models.User{}).Where("status = ?", 1).Count(&count).Error
+				})
+				if errors.Is(err, ErrPoolTimeout) {
+					atomic.AddInt64(&timeouts, 1)
+				} else if err != nil {
+					atomic.AddInt64(&failures, 1)
+				}
+			}
+		}(i)
+	}
+
+	wg.Wait()
+	duration := time.Since(start)
+
+	totalQueries := (int64(concurrency*iterations) - timeouts - failures) * 3 // 每次循环3个查询
+	qps := float64(totalQueries) / duration.Seconds()
+
+	fmt.Printf("并发测试完成: 总耗时 %v, 成功查询数 %d, QPS: %.2f, 连接超时 %d 次, 其他错误 %d 次\n",
+		duration, totalQueries, qps, timeouts, failures)
+}
+
+// RunBatchInsertTest 批量插入测试
+func (bt *BenchmarkTest) RunBatchInsertTest(totalRecords int, batchSize int) {
+	fmt.Printf("\n开始批量插入测试: 总记录数 %d, 批次大小 %d\n", totalRecords, batchSize)
+
+	// 生成测试数据
+	products := make([]models.Product, totalRecords)
+	for i := 0; i < totalRecords; i++ {
+		products[i] = models.Product{
+			Name:       fmt.Sprintf("测试商品%d", i),
+			SKU:        fmt.Sprintf("TEST%d", i),
+			CategoryID: 1,
+			Price:      int64(1000 + i),
+			Stock:      100,
+			Status:     1,
+		}
+	}
+
+	start := time.Now()
+
+	// 批量插入
+	service := NewOptimizedQueryService(bt.db, bt.monitor)
+	err := service.BatchInsertProducts(products, batchSize)
+
+	duration := time.Since(start)
+
+	if err != nil {
+		fmt.Printf("批量插入失败: %v\n", err)
+	} else {
+		rps := float64(totalRecords) / duration.Seconds()
+		fmt.Printf("批量插入完成: 总耗时 %v, 插入速度: %.2f records/s\n", duration, rps)
+	}
+
+	// 清理测试数据
+	bt.db.Where("sku LIKE 'TEST%'").Delete(&models.Product{})
+}
+
+// WorkloadOp 压测场景中的操作类型
+type WorkloadOp string
+
+const (
+	OpProductPage WorkloadOp = "product_page" // 商品详情页（预加载分类、品牌）
+	OpListing     WorkloadOp = "listing"      // 分页商品列表
+	OpCreateOrder WorkloadOp = "create_order" // 下单并扣减库存
+	OpStatistics  WorkloadOp = "statistics"   // 销售统计
+)
+
+// WeightedOp 带权重的操作
+type WeightedOp struct {
+	Op     WorkloadOp
+	Weight int
+}
+
+// WorkloadSpec 压测场景定义
+type WorkloadSpec struct {
+	Name       string
+	Ops        []WeightedOp  // 各操作按权重随机选择
+	Steps      []int         // 并发阶梯，依次提升并发数
+	Duration   time.Duration // 总时长，平均分给每个阶梯
+	Seed       int64         // 随机种子
+	JSONOutput string        // 非空时把报告写成JSON文件，便于CI对比
+}
+
+// ReadHeavyWorkload 读多写少的场景
+func ReadHeavyWorkload(duration time.Duration) WorkloadSpec {
+	return WorkloadSpec{
+		Name: "read-heavy",
+		Ops: []WeightedOp{
+			{OpProductPage, 70},
+			{OpListing, 15},
+			{OpCreateOrder, 10},
+			{OpStatistics, 5},
+		},
+		Steps:    []int{2, 4, 8},
+		Duration: duration,
+		Seed:     1,
+	}
+}
+
+// WriteHeavyWorkload 下单集中的场景（如促销），库存行的锁竞争明显
+func WriteHeavyWorkload(duration time.Duration) WorkloadSpec {
+	return WorkloadSpec{
+		Name: "write-heavy",
+		Ops: []WeightedOp{
+			{OpProductPage, 30},
+			{OpListing, 10},
+			{OpCreateOrder, 55},
+			{OpStatistics, 5},
+		},
+		Steps:    []int{2, 4, 8},
+		Duration: duration,
+		Seed:     2,
+	}
+}
+
+// OpReport 单个操作的统计
+type OpReport struct {
+	Op     WorkloadOp    `json:"op"`
+	Count  int64         `json:"count"`
+	Errors int64         `json:"errors"`
+	P50    time.Duration `json:"p50_ns"`
+	P95    time.Duration `json:"p95_ns"`
+	P99    time.Duration `json:"p99_ns"`
+	Max    time.Duration `json:"max_ns"`
+}
+
+// ScenarioReport 压测场景报告
+type ScenarioReport struct {
+	Name     string        `json:"name"`
+	Duration time.Duration `json:"duration_ns"`
+	Total    int64         `json:"total"`
+	Errors   int64         `json:"errors"`
+	QPS      float64       `json:"qps"`
+	Ops      []OpReport    `json:"ops"`
+}
+
+// Print 打印报告表格
+func (r *ScenarioReport) Print() {
+	fmt.Printf("\n场景 %s: 耗时 %v, 操作数 %d, 错误 %d, QPS %.2f\n", r.Name, r.Duration.Round(time.Millisecond), r.Total, r.Errors, r.QPS)
+	fmt.Printf("%-14s %8s %6s %12s %12s %12s %12s\n", "操作", "次数", "错误", "P50", "P95", "P99", "最大")
+	for _, op := range r.Ops {
+		fmt.Printf("%-14s %8d %6d %12v %12v %12v %12v\n", op.Op, op.Count, op.Errors,
+			op.P50.Round(time.Microsecond), op.P95.Round(time.Microsecond),
+			op.P99.Round(time.Microsecond), op.Max.Round(time.Microsecond))
+	}
+}
+
+// WriteJSON 把报告写成JSON文件
+func (r *ScenarioReport) WriteJSON(path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// opSamples 单个worker记录的延迟样本，结束后合并，避免压测过程中争用锁
+type opSamples struct {
+	latencies map[WorkloadOp][]time.Duration
+	errors    map[WorkloadOp]int64
+	orderIDs  []uint
+}
+
+// workloadFixtures 压测用到的已有数据
+type workloadFixtures struct {
+	productIDs  []uint
+	userIDs     []uint
+	categoryIDs []uint
+}
+
+// RunScenario 按场景定义进行混合负载压测
+// 各操作通过OptimizedQueryService执行，下单走CreateOrder的真实扣库存路径；
+// 结束后删除压测生成的订单并把扣减的库存加回去
+func (bt *BenchmarkTest) RunScenario(spec WorkloadSpec) (*ScenarioReport, error) {
+	if len(spec.Ops) == 0 || len(spec.Steps) == 0 || spec.Duration <= 0 {
+		return nil, errors.New("压测场景缺少操作、并发阶梯或时长")
+	}
+	totalWeight := 0
+	for _, op := range spec.Ops {
+		totalWeight += op.Weight
+	}
+	if totalWeight <= 0 {
+		return nil, errors.New("压测场景的权重之和必须大于0")
+	}
+
+	fixtures, err := bt.loadWorkloadFixtures()
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Printf("\n开始场景压测 %s: 并发阶梯 %v, 总时长 %v\n", spec.Name, spec.Steps, spec.Duration)
+
+	service := NewOptimizedQueryService(bt.db, bt.monitor).WithAcquireTimeout(bt.acquireTimeout)
+	stepDuration := spec.Duration / time.Duration(len(spec.Steps))
+	var all []*opSamples
+
+	start := time.Now()
+	for step, concurrency := range spec.Steps {
+		ctx, cancel := context.WithTimeout(context.Background(), stepDuration)
+		var wg sync.WaitGroup
+		samples := make([]*opSamples, concurrency)
+
+		for w := 0; w < concurrency; w++ {
+			samples[w] = &opSamples{
+				latencies: make(map[WorkloadOp][]time.Duration),
+				errors:    make(map[WorkloadOp]int64),
+			}
+			wg.Add(1)
+			go func(rng *rand.Rand, out *opSamples) {
+				defer wg.Done()
+				for ctx.Err() == nil {
+					op := pickOp(rng, spec.Ops, totalWeight)
+					opStart := time.Now()
+					orderID, err := bt.runOp(ctx, service, rng, fixtures, op)
+					if orderID != 0 {
+						out.orderIDs = append(out.orderIDs, orderID)
+					}
+					if ctx.Err() != nil {
+						return // 阶梯结束时被中断的操作不计入统计
+					}
+					out.latencies[op] = append(out.latencies[op], time.Since(opStart))
+					if err != nil {
+						out.errors[op]++
+					}
+				}
+			}(rand.New(rand.NewSource(spec.Seed+int64(step*1000+w))), samples[w])
+		}
+
+		wg.Wait()
+		cancel()
+		all = append(all, samples...)
+	}
+	report := buildScenarioReport(spec, all, time.Since(start))
+
+	var orderIDs []uint
+	for _, samples := range all {
+		orderIDs = append(orderIDs, samples.orderIDs...)
+	}
+	if err := bt.cleanupScenarioOrders(orderIDs); err != nil {
+		return report, fmt.Errorf("清理压测订单失败: %w", err)
+	}
+
+	if spec.JSONOutput != "" {
+		if err := report.WriteJSON(spec.JSONOutput); err != nil {
+			return report, fmt.Errorf("写入压测报告失败: %w", err)
+		}
+	}
+	return report, nil
+}
+
+// runOp 执行一次操作，下单成功时返回订单ID用于清理
+func (bt *BenchmarkTest) runOp(ctx context.Context, service *OptimizedQueryService, rng *rand.Rand, f *workloadFixtures, op WorkloadOp) (uint, error) {
+	switch op {
+	case OpProductPage:
+		_, err := service.GetProductDetail(ctx, f.productIDs[rng.Intn(len(f.productIDs))])
+		return 0, err
+	case OpListing:
+		categoryID := f.categoryIDs[rng.Intn(len(f.categoryIDs))]
+		_, _, err := service.GetProductsWithPagination(ctx, 1+rng.Intn(5), 20, &categoryID)
+		return 0, err
+	case OpCreateOrder:
+		items := make([]OrderItemInput, 1+rng.Intn(3))
+		for i := range items {
+			items[i] = OrderItemInput{ProductID: f.productIDs[rng.Intn(len(f.productIDs))], Quantity: 1}
+		}
+		items = mergeOrderItems(items)
+		order, err := service.CreateOrder(ctx, f.userIDs[rng.Intn(len(f.userIDs))], items)
+		if err != nil {
+			return 0, err
+		}
+		return order.ID, nil
+	case OpStatistics:
+		now := time.Now()
+		_, err := service.GetSalesStatisticsOptimized(ctx, now.AddDate(0, 0, -30), now)
+		return 0, err
+	}
+	return 0, fmt.Errorf("未知的压测操作: %s", op)
+}
+
+// loadWorkloadFixtures 读取压测需要的商品、用户和分类ID
+func (bt *BenchmarkTest) loadWorkloadFixtures() (*workloadFixtures, error) {
+	f := &workloadFixtures{}
+	if err := bt.db.Model(&models.Product{}).Where("status = ?", 1).Order("id").Limit(1000).Pluck("id", &f.productIDs).Error; err != nil {
+		return nil, err
+	}
+	if err := bt.db.Model(&models.User{}).Order("id").Limit(1000).Pluck("id", &f.userIDs).Error; err != nil {
+		return nil, err
+	}
+	if err := bt.db.Model(&models.Category{}).Order("id").Pluck("id", &f.categoryIDs).Error; err != nil {
+		return nil, err
+	}
+	if len(f.productIDs) == 0 || len(f.userIDs) == 0 || len(f.categoryIDs) == 0 {
+		return nil, errors.New("压测需要先填充商品、用户和分类数据")
+	}
+	return f, nil
+}
+
+// cleanupScenarioOrders 删除压测生成的订单和订单项，并恢复扣减的库存和销量
+func (bt *BenchmarkTest) cleanupScenarioOrders(orderIDs []uint) error {
+	if len(orderIDs) == 0 {
+		return nil
+	}
+
+	return bt.db.Transaction(func(tx *gorm.DB) error {
+		for i := 0; i < len(orderIDs); i += 500 {
+			end := i + 500
+			if end > len(orderIDs) {
+				end = len(orderIDs)
+			}
+			batch := orderIDs[i:end]
+
+			var restock []struct {
+				ProductID uint
+				Quantity  int
+			}
+			if err := tx.Model(&models.OrderItem{}).Select("product_id, SUM(quantity) as quantity").
+				Where("order_id IN ?", batch).Group("product_id").Scan(&restock).Error; err != nil {
+				return err
+			}
+			for _, r := range restock {
+				if err := tx.Model(&models.Product{}).Where("id = ?", r.ProductID).UpdateColumns(map[string]interface{}{
+					"stock": gorm.Expr("stock + ?", r.Quantity),
+					"sales": gorm.Expr("sales - ?", r.Quantity),
+				}).Error; err != nil {
+					return err
+				}
+			}
+
+			if err := tx.Unscoped().Where("order_id IN ?", batch).Delete(&models.OrderItem{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Unscoped().Where("id IN ?", batch).Delete(&models.Order{}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// pickOp 按权重随机选择操作
+func pickOp(rng *rand.Rand, ops []WeightedOp, totalWeight int) WorkloadOp {
+	n := rng.Intn(totalWeight)
+	for _, op := range ops {
+		if n < op.Weight {
+			return op.Op
+		}
+		n -= op.Weight
+	}
+	return ops[len(ops)-1].Op
+}
+
+// mergeOrderItems 合并同一商品的多个下单项
+func mergeOrderItems(items []OrderItemInput) []OrderItemInput {
+	merged := items[:0]
+	index := make(map[uint]int)
+	for _, item := range items {
+		if i, ok := index[item.ProductID]; ok {
+			merged[i].Quantity += item.Quantity
+			continue
+		}
+		index[item.ProductID] = len(merged)
+		merged = append(merged, item)
+	}
+	return merged
+}
+
+// buildScenarioReport 合并各worker的样本，计算分位数和QPS
+func buildScenarioReport(spec WorkloadSpec, all []*opSamples, duration time.Duration) *ScenarioReport {
+	report := &ScenarioReport{Name: spec.Name, Duration: duration}
+	for _, weighted := range spec.Ops {
+		var latencies []time.Duration
+		var errs int64
+		for _, samples := range all {
+			latencies = append(latencies, samples.latencies[weighted.Op]...)
+			errs += samples.errors[weighted.Op]
+		}
+		sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+
+		op := OpReport{Op: weighted.Op, Count: int64(len(latencies)), Errors: errs}
+		if len(latencies) > 0 {
+			op.P50 = percentile(latencies, 0.50)
+			op.P95 = percentile(latencies, 0.95)
+			op.P99 = percentile(latencies, 0.99)
+			op.Max = latencies[len(latencies)-1]
+		}
+		report.Ops = append(report.Ops, op)
+		report.Total += op.Count
+		report.Errors += op.Errors
+	}
+	if duration > 0 {
+		report.QPS = float64(report.Total) / duration.Seconds()
+	}
+	return report
+}
+
+// percentile 计算已排序样本的分位数
+func percentile(sorted []time.Duration, q float64) time.Duration {
+	i := int(math.Ceil(q*float64(len(sorted)))) - 1
+	if i < 0 {
+		i = 0
+	}
+	return sorted[i]
+}
+
+// SeedTestData 填充测试数据：固定的演示数据，加上按profile规模生成的压测数据（seed相同则数据相同）
+func SeedTestData(db *gorm.DB, profile GeneratorProfile, seed int64) error {
+	fmt.Println("开始填充测试数据...")
+
+	// 创建用户
+	users := []models.User{
+		{Username: "user1", Email: "user1@example.com", Phone: "13800138001", Password: "password", Nickname: "用户1"},
+		{Username: "user2", Email: "user2@example.com", Phone: "13800138002", Password: "password", Nickname: "用户2"},
+		{Username: "user3", Email: "user3@example.com", Phone: "13800138003", Password: "password", Nickname: "用户3"},
+	}
+	db.Create(&users)
+
+	// 创建分类
+	categories := []models.Category{
 		{Name: "电子产品", Slug: "electronics"},
 		{Name: "服装", Slug: "clothing"},
 		{Name: "图书", Slug: "books"},
@@ -522,7 +1913,7 @@ func SeedTestData(db *gorm.DB) error {
 	db.Create(&categories)
 
 	// 创建品牌
-	brands := []Brand{
+	brands := []models.Brand{
 		{Name: "苹果", Slug: "apple"},
 		{Name: "华为", Slug: "huawei"},
 		{Name: "小米", Slug: "xiaomi"},
@@ -530,7 +1921,7 @@ func SeedTestData(db *gorm.DB) error {
 	db.Create(&brands)
 
 	// 创建商品
-	products := []Product{
+	products := []models.Product{
 		{Name: "iPhone 15", SKU: "IPHONE15", CategoryID: categories[0].ID, BrandID: &brands[0].ID, Price: 599900, Stock: 100, Sales: 50},
 		{Name: "华为P60", SKU: "HUAWEIP60", CategoryID: categories[0].ID, BrandID: &brands[1].ID, Price: 499900, Stock: 80, Sales: 30},
 		{Name: "小米14", SKU: "XIAOMI14", CategoryID: categories[0].ID, BrandID: &brands[2].ID, Price: 399900, Stock: 120, Sales: 80},
@@ -539,39 +1930,507 @@ func SeedTestData(db *gorm.DB) error {
 	}
 	db.Create(&products)
 
-	// 创建大量订单用于性能测试
-	for i := 0; i < 1000; i++ {
-		userID := uint(i%3 + 1)
-		productID := uint(i%5 + 1)
-		quantity := i%3 + 1
-		price := products[productID-1].Price
-		totalPrice := price * int64(quantity)
-
-		order := Order{
-			OrderNo:     fmt.Sprintf("ORD%d", time.Now().UnixNano()+int64(i)),
-			UserID:      userID,
-			Status:      int8(i%5 + 1), // 随机状态
-			TotalAmount: totalPrice,
-			PayAmount:   totalPrice,
-			CreatedAt:   time.Now().AddDate(0, 0, -i%365), // 随机日期
-		}
-		db.Create(&order)
-
-		orderItem := OrderItem{
-			OrderID:     order.ID,
-			ProductID:   productID,
-			Quantity:    quantity,
-			Price:       price,
-			TotalPrice:  totalPrice,
-			ProductName: products[productID-1].Name,
-		}
-		db.Create(&orderItem)
+	// 生成大量订单用于性能测试
+	opts, err := ProfileOptions(profile, seed)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("按 %s 规模生成压测数据（种子 %d）...\n", profile, seed)
+	report, err := NewDataGenerator(db, opts).Generate()
+	if err != nil {
+		return err
 	}
+	report.Print()
 
 	fmt.Println("测试数据填充完成")
 	return nil
 }
 
+// GeneratorOptions 测试数据生成参数
+type GeneratorOptions struct {
+	Seed           int64     // 随机种子，0表示使用当前时间（结果不可复现）
+	Prefix         string    // 用户名、SKU、订单号等唯一字段的前缀，默认gen
+	Users          int       // 用户数
+	Categories     int       // 一级分类数，默认10
+	SubCategories  int       // 每个一级分类下的二级分类数，0表示不分级；分级时商品只挂在二级分类下
+	Brands         int       // 品牌数，默认20
+	Products       int       // 商品数
+	Orders         int       // 订单数
+	MaxItems       int       // 每个订单最多的商品种类，默认3
+	BatchSize      int       // CreateInBatches的批大小，默认500
+	BaseTime       time.Time // 订单时间基准，订单分布在此前一年内；指定Seed时默认为固定日期
+	DailyCurve     []float64 // 0~23点各小时的下单权重，共24个，默认 defaultDailyCurve（晚间高峰）
+	MonthlyFactors []float64 // 1~12月的订单量系数，共12个，默认 defaultMonthlyFactors（618、双11、年末促销）
+	Growth         float64   // 一年内订单量的增长比例，如0.5表示最后一天的订单量约为第一天的1.5倍
+}
+
+// GeneratorProfile 测试数据规模
+type GeneratorProfile string
+
+const (
+	GeneratorProfileSmall  GeneratorProfile = "small"  // 1千订单，用于功能演示和冒烟测试
+	GeneratorProfileMedium GeneratorProfile = "medium" // 5万订单
+	GeneratorProfileLarge  GeneratorProfile = "large"  // 50万订单，用于基准测试
+)
+
+// ProfileOptions 返回数据规模对应的生成参数，相同的规模和种子生成的数据完全相同
+func ProfileOptions(profile GeneratorProfile, seed int64) (GeneratorOptions, error) {
+	opts := GeneratorOptions{Seed: seed, MaxItems: 3}
+	switch profile {
+	case GeneratorProfileSmall:
+		opts.Users, opts.Categories, opts.SubCategories, opts.Brands = 200, 8, 4, 20
+		opts.Products, opts.Orders, opts.Growth = 500, 1000, 0.5
+	case GeneratorProfileMedium:
+		opts.Users, opts.Categories, opts.SubCategories, opts.Brands = 5000, 12, 6, 50
+		opts.Products, opts.Orders, opts.Growth = 5000, 50000, 0.8
+		opts.BatchSize = 1000
+	case GeneratorProfileLarge:
+		opts.Users, opts.Categories, opts.SubCategories, opts.Brands = 50000, 12, 8, 100
+		opts.Products, opts.Orders, opts.Growth = 20000, 500000, 1
+		opts.BatchSize = 1000
+	default:
+		return opts, fmt.Errorf("未知的数据规模 %q，可选 small、medium、large", profile)
+	}
+	return opts, nil
+}
+
+// GenerateReport 数据生成结果
+type GenerateReport struct {
+	Stages   []GenerateStage `json:"stages"`
+	Duration time.Duration   `json:"duration"`
+}
+
+// GenerateStage 单张表的生成耗时
+type GenerateStage struct {
+	Table    string        `json:"table"`
+	Rows     int           `json:"rows"`
+	Duration time.Duration `json:"duration"`
+}
+
+// Print 打印各表的插入速度
+func (r *GenerateReport) Print() {
+	fmt.Println("\n数据生成结果:")
+	total := 0
+	for _, stage := range r.Stages {
+		fmt.Printf("  %-12s %8d 行, 耗时 %v, %.2f rows/s\n",
+			stage.Table, stage.Rows, stage.Duration, float64(stage.Rows)/stage.Duration.Seconds())
+		total += stage.Rows
+	}
+	fmt.Printf("  共 %d 行, 总耗时 %v, %.2f rows/s\n", total, r.Duration, float64(total)/r.Duration.Seconds())
+}
+
+// generatorEpoch 指定Seed时默认的时间基准，保证同一种子生成的数据完全一致
+var generatorEpoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local)
+
+// defaultDailyCurve 默认的24小时下单权重：凌晨最少，午休有小高峰，20~22点最多
+var defaultDailyCurve = []float64{
+	3, 2, 1, 1, 1, 1, 2, 4, 6, 7, 8, 9, // 0~11点
+	11, 10, 8, 8, 8, 9, 10, 12, 15, 16, 13, 7, // 12~23点
+}
+
+// defaultMonthlyFactors 默认的月份系数：春节所在的2月偏低，6月（618）、11月（双11）和12月偏高
+var defaultMonthlyFactors = []float64{1, 0.8, 1, 1, 1.1, 1.4, 1, 1, 1, 1.1, 1.8, 1.3}
+
+var (
+	genSurnames      = []string{"王", "李", "张", "刘", "陈", "杨", "赵", "黄", "周", "吴", "徐", "孙", "马", "朱", "胡"}
+	genGivenNames    = []string{"伟", "芳", "娜", "敏", "静", "磊", "洋", "勇", "艳", "杰", "军", "涛", "超", "明", "丽"}
+	genCategories    = []string{"手机", "电脑", "家电", "服装", "鞋靴", "图书", "食品", "美妆", "运动", "家居", "母婴", "玩具"}
+	genSubCategories = []string{"配件", "精选", "新品", "特惠", "进口", "国货", "周边", "套装"}
+	genBrands        = []string{"星辰", "远航", "青山", "蓝海", "优选", "极客", "森林", "晨光", "云端", "磐石"}
+	genAdjectives    = []string{"经典", "轻薄", "旗舰", "入门", "专业", "便携", "智能", "限量", "升级", "简约"}
+	genNouns         = []string{"款", "版", "套装", "系列", "礼盒"}
+)
+
+// DataGenerator 可复现的测试数据生成器，用于压测和性能对比
+// 相同的Seed和参数会生成完全相同的数据，保证基准测试可复现
+type DataGenerator struct {
+	db   *gorm.DB
+	opts GeneratorOptions
+	rng  *rand.Rand
+
+	windowStart time.Time // 订单时间窗口的第一天零点（BaseTime所在日期往前365天）
+	dayCDF      []float64 // 时间窗口内每天订单权重的累计值，按增长比例和月份系数计算
+	hourCDF     []float64 // 每小时订单权重的累计值
+}
+
+// NewDataGenerator 创建测试数据生成器
+func NewDataGenerator(db *gorm.DB, opts GeneratorOptions) *DataGenerator {
+	if opts.Prefix == "" {
+		opts.Prefix = "gen"
+	}
+	if opts.Categories <= 0 {
+		opts.Categories = 10
+	}
+	if opts.Brands <= 0 {
+		opts.Brands = 20
+	}
+	if opts.MaxItems <= 0 {
+		opts.MaxItems = 3
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 500
+	}
+
+	seed := opts.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+		if opts.BaseTime.IsZero() {
+			opts.BaseTime = time.Now()
+		}
+	}
+	if opts.BaseTime.IsZero() {
+		opts.BaseTime = generatorEpoch
+	}
+	if opts.DailyCurve == nil {
+		opts.DailyCurve = defaultDailyCurve
+	}
+	if opts.MonthlyFactors == nil {
+		opts.MonthlyFactors = defaultMonthlyFactors
+	}
+
+	return &DataGenerator{
+		db:   db,
+		opts: opts,
+		rng:  rand.New(rand.NewSource(seed)),
+	}
+}
+
+// Generate 按参数生成用户、分类、品牌、商品和订单
+func (g *DataGenerator) Generate() (*GenerateReport, error) {
+	report := &GenerateReport{}
+	start := time.Now()
+
+	if err := g.buildTimeCurve(); err != nil {
+		return report, err
+	}
+
+	userIDs, err := g.generateUsers(report)
+	if err != nil {
+		return report, err
+	}
+	categories, err := g.generateCategories(report)
+	if err != nil {
+		return report, err
+	}
+	brandIDs, err := g.generateBrands(report)
+	if err != nil {
+		return report, err
+	}
+	products, err := g.generateProducts(report, categories, brandIDs)
+	if err != nil {
+		return report, err
+	}
+	if err := g.generateOrders(report, userIDs, products); err != nil {
+		return report, err
+	}
+
+	report.Duration = time.Since(start)
+	return report, nil
+}
+
+// stage 执行一个生成阶段并记录耗时
+func (g *DataGenerator) stage(report *GenerateReport, table string, rows int, fn func() error) error {
+	start := time.Now()
+	if err := fn(); err != nil {
+		return fmt.Errorf("生成%s失败: %w", table, err)
+	}
+	report.Stages = append(report.Stages, GenerateStage{Table: table, Rows: rows, Duration: time.Since(start)})
+	return nil
+}
+
+func (g *DataGenerator) generateUsers(report *GenerateReport) ([]uint, error) {
+	users := make([]models.User, g.opts.Users)
+	for i := range users {
+		users[i] = models.User{
+			Username: fmt.Sprintf("%s_user%d", g.opts.Prefix, i+1),
+			Email:    fmt.Sprintf("%s_user%d@example.com", g.opts.Prefix, i+1),
+			Phone:    fmt.Sprintf("139%08d", i+1),
+			Password: "password",
+			Nickname: genSurnames[g.rng.Intn(len(genSurnames))] + genGivenNames[g.rng.Intn(len(genGivenNames))],
+			Status:   g.weighted(1, 95, 2), // 约5%的用户被禁用
+		}
+	}
+	if len(users) == 0 {
+		return nil, nil
+	}
+
+	err := g.stage(report, "users", len(users), func() error {
+		return g.db.CreateInBatches(users, g.opts.BatchSize).Error
+	})
+
+	ids := make([]uint, len(users))
+	for i := range users {
+		ids[i] = users[i].ID
+	}
+	return ids, err
+}
+
+// generateCategories 生成分类树，先插入一级分类取得ID，再插入二级分类；返回可以挂商品的叶子分类
+func (g *DataGenerator) generateCategories(report *GenerateReport) ([]models.Category, error) {
+	categories := make([]models.Category, g.opts.Categories)
+	for i := range categories {
+		categories[i] = models.Category{
+			Name:   genCategories[i%len(genCategories)],
+			Slug:   fmt.Sprintf("%s-category-%d", g.opts.Prefix, i+1),
+			Status: 1,
+		}
+	}
+	var children []models.Category
+
+	err := g.stage(report, "categories", len(categories)*(1+g.opts.SubCategories), func() error {
+		if err := g.db.CreateInBatches(categories, g.opts.BatchSize).Error; err != nil {
+			return err
+		}
+		if g.opts.SubCategories <= 0 {
+			return nil
+		}
+
+		children = make([]models.Category, 0, len(categories)*g.opts.SubCategories)
+		for i := range categories {
+			for j := 0; j < g.opts.SubCategories; j++ {
+				parentID := categories[i].ID
+				children = append(children, models.Category{
+					Name:     categories[i].Name + genSubCategories[j%len(genSubCategories)],
+					Slug:     fmt.Sprintf("%s-category-%d-%d", g.opts.Prefix, i+1, j+1),
+					ParentID: &parentID,
+					Status:   1,
+				})
+			}
+		}
+		return g.db.CreateInBatches(children, g.opts.BatchSize).Error
+	})
+
+	if g.opts.SubCategories > 0 {
+		return children, err
+	}
+	return categories, err
+}
+
+func (g *DataGenerator) generateBrands(report *GenerateReport) ([]uint, error) {
+	brands := make([]models.Brand, g.opts.Brands)
+	for i := range brands {
+		brands[i] = models.Brand{
+			Name:   fmt.Sprintf("%s%s%d", g.opts.Prefix, genBrands[i%len(genBrands)], i+1),
+			Slug:   fmt.Sprintf("%s-brand-%d", g.opts.Prefix, i+1),
+			Status: 1,
+		}
+	}
+
+	err := g.stage(report, "brands", len(brands), func() error {
+		return g.db.CreateInBatches(brands, g.opts.BatchSize).Error
+	})
+
+	ids := make([]uint, len(brands))
+	for i := range brands {
+		ids[i] = brands[i].ID
+	}
+	return ids, err
+}
+
+func (g *DataGenerator) generateProducts(report *GenerateReport, categories []models.Category, brandIDs []uint) ([]models.Product, error) {
+	if g.opts.Products > 0 && len(categories) == 0 {
+		return nil, fmt.Errorf("生成商品需要至少一个分类")
+	}
+
+	products := make([]models.Product, g.opts.Products)
+	for i := range products {
+		category := categories[g.rng.Intn(len(categories))]
+		product := models.Product{
+			Name: fmt.Sprintf("%s%s%s", genAdjectives[g.rng.Intn(len(genAdjectives))],
+				category.Name, genNouns[g.rng.Intn(len(genNouns))]),
+			SKU:        fmt.Sprintf("%s-SKU-%06d", strings.ToUpper(g.opts.Prefix), i+1),
+			CategoryID: category.ID,
+			Price:      int64(g.rng.Intn(5000)+1) * 100, // 1元~5000元
+			Stock:      g.rng.Intn(1000),
+			Views:      g.rng.Intn(10000),
+			Status:     g.weighted(1, 90, 2), // 约10%的商品下架
+		}
+		// 约80%的商品有品牌
+		if len(brandIDs) > 0 && g.rng.Intn(100) < 80 {
+			brandID := brandIDs[g.rng.Intn(len(brandIDs))]
+			product.BrandID = &brandID
+		}
+		products[i] = product
+	}
+	if len(products) == 0 {
+		return nil, nil
+	}
+
+	err := g.stage(report, "products", len(products), func() error {
+		return g.db.CreateInBatches(products, g.opts.BatchSize).Error
+	})
+	return products, err
+}
+
+// generateOrders 分批生成订单和订单项，每批先插入订单取得ID，再插入订单项，控制内存占用
+func (g *DataGenerator) generateOrders(report *GenerateReport, userIDs []uint, products []models.Product) error {
+	if g.opts.Orders == 0 {
+		return nil
+	}
+	if len(userIDs) == 0 || len(products) == 0 {
+		return fmt.Errorf("生成订单需要至少一个用户和一个商品")
+	}
+
+	var orderDuration, itemDuration time.Duration
+	itemCount := 0
+
+	for offset := 0; offset < g.opts.Orders; offset += g.opts.BatchSize {
+		size := g.opts.BatchSize
+		if offset+size > g.opts.Orders {
+			size = g.opts.Orders - offset
+		}
+
+		orders := make([]models.Order, size)
+		items := make([][]models.OrderItem, size)
+		for i := range orders {
+			orders[i], items[i] = g.newOrder(offset+i, userIDs, products)
+		}
+
+		start := time.Now()
+		if err := g.db.CreateInBatches(orders, g.opts.BatchSize).Error; err != nil {
+			return fmt.Errorf("生成orders失败: %w", err)
+		}
+		orderDuration += time.Since(start)
+
+		var batch []models.OrderItem
+		for i := range orders {
+			for _, item := range items[i] {
+				item.OrderID = orders[i].ID
+				batch = append(batch, item)
+			}
+		}
+
+		start = time.Now()
+		if err := g.db.CreateInBatches(batch, g.opts.BatchSize).Error; err != nil {
+			return fmt.Errorf("生成order_items失败: %w", err)
+		}
+		itemDuration += time.Since(start)
+		itemCount += len(batch)
+	}
+
+	report.Stages = append(report.Stages,
+		GenerateStage{Table: "orders", Rows: g.opts.Orders, Duration: orderDuration},
+		GenerateStage{Table: "order_items", Rows: itemCount, Duration: itemDuration},
+	)
+	return nil
+}
+
+// newOrder 生成第seq个订单及其订单项（订单ID在插入后回填）
+func (g *DataGenerator) newOrder(seq int, userIDs []uint, products []models.Product) (models.Order, []models.OrderItem) {
+	createdAt := g.orderTime()
+	order := models.Order{
+		BaseModel: models.BaseModel{CreatedAt: createdAt, UpdatedAt: createdAt},
+		OrderNo:   fmt.Sprintf("%s%010d", strings.ToUpper(g.opts.Prefix), seq+1),
+		UserID:    userIDs[g.rng.Intn(len(userIDs))],
+		// 状态分布：待付款10%，待发货15%，待收货15%，已完成50%，已取消10%
+		Status: g.weighted(1, 10, 2, 15, 3, 15, 4, 50, 5),
+	}
+
+	count := g.rng.Intn(g.opts.MaxItems) + 1
+	items := make([]models.OrderItem, 0, count)
+	for i := 0; i < count; i++ {
+		product := products[g.rng.Intn(len(products))]
+		quantity := g.rng.Intn(3) + 1
+		items = append(items, models.OrderItem{
+			ProductID:   product.ID,
+			Quantity:    quantity,
+			Price:       product.Price,
+			TotalPrice:  product.Price * int64(quantity),
+			ProductName: product.Name,
+		})
+		order.TotalAmount += product.Price * int64(quantity)
+	}
+
+	// 满99元包邮，否则运费10元
+	if order.TotalAmount < 9900 {
+		order.FreightAmount = 1000
+	}
+	order.PayAmount = order.TotalAmount + order.FreightAmount
+
+	if order.Status >= 2 && order.Status <= 4 {
+		paidAt := createdAt.Add(time.Duration(g.rng.Intn(30)+1) * time.Minute)
+		order.PaidAt = &paidAt
+	}
+	if order.Status == 4 {
+		finishedAt := order.PaidAt.Add(time.Duration(g.rng.Intn(7*24)+24) * time.Hour)
+		order.FinishedAt = &finishedAt
+	}
+	return order, items
+}
+
+// buildTimeCurve 根据增长比例、月份系数和24小时权重计算订单时间的累计分布
+func (g *DataGenerator) buildTimeCurve() error {
+	if len(g.opts.DailyCurve) != 24 {
+		return fmt.Errorf("DailyCurve 需要24个小时权重，实际 %d 个", len(g.opts.DailyCurve))
+	}
+	if len(g.opts.MonthlyFactors) != 12 {
+		return fmt.Errorf("MonthlyFactors 需要12个月份系数，实际 %d 个", len(g.opts.MonthlyFactors))
+	}
+
+	hourCDF, err := cumulative(g.opts.DailyCurve)
+	if err != nil {
+		return fmt.Errorf("DailyCurve %w", err)
+	}
+
+	y, m, d := g.opts.BaseTime.Date()
+	g.windowStart = time.Date(y, m, d, 0, 0, 0, 0, g.opts.BaseTime.Location()).AddDate(0, 0, -365)
+	weights := make([]float64, 365)
+	for i := range weights {
+		month := g.windowStart.AddDate(0, 0, i).Month()
+		weights[i] = (1 + g.opts.Growth*float64(i)/364) * g.opts.MonthlyFactors[month-1]
+	}
+	dayCDF, err := cumulative(weights)
+	if err != nil {
+		return fmt.Errorf("MonthlyFactors/Growth %w", err)
+	}
+
+	g.hourCDF, g.dayCDF = hourCDF, dayCDF
+	return nil
+}
+
+// orderTime 按累计分布随机选择日期和小时，小时内的时间均匀分布
+func (g *DataGenerator) orderTime() time.Time {
+	day := pickCDF(g.rng, g.dayCDF)
+	hour := pickCDF(g.rng, g.hourCDF)
+	return g.windowStart.AddDate(0, 0, day).
+		Add(time.Duration(hour)*time.Hour + time.Duration(g.rng.Intn(3600))*time.Second)
+}
+
+// cumulative 计算权重的累计值，权重不能为负数且总和必须大于0
+func cumulative(weights []float64) ([]float64, error) {
+	cdf := make([]float64, len(weights))
+	sum := 0.0
+	for i, w := range weights {
+		if w < 0 {
+			return nil, fmt.Errorf("权重不能为负数: 第%d个为%v", i+1, w)
+		}
+		sum += w
+		cdf[i] = sum
+	}
+	if sum <= 0 {
+		return nil, fmt.Errorf("权重总和必须大于0")
+	}
+	return cdf, nil
+}
+
+// pickCDF 按累计分布随机选择下标，权重为0的下标不会被选中
+func pickCDF(rng *rand.Rand, cdf []float64) int {
+	x := rng.Float64() * cdf[len(cdf)-1]
+	return sort.Search(len(cdf), func(i int) bool { return cdf[i] > x })
+}
+
+// weighted 按权重随机选择值，参数依次为 值1, 权重1, 值2, 权重2, ..., 最后一个值（使用剩余权重）
+func (g *DataGenerator) weighted(pairs ...int8) int8 {
+	n := int8(g.rng.Intn(100))
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if n < pairs[i+1] {
+			return pairs[i]
+		}
+		n -= pairs[i+1]
+	}
+	return pairs[len(pairs)-1]
+}
+
 // demonstratePerformanceOptimization 演示性能优化
 func demonstratePerformanceOptimization(db *gorm.DB) {
 	fmt.Println("\n=== 演示性能优化功能 ===")
@@ -583,7 +2442,7 @@ func demonstratePerformanceOptimization(db *gorm.DB) {
 	// 1. 分页查询测试
 	fmt.Println("\n1. 分页查询测试:")
 	categoryID := uint(1)
-	products, total, err := service.GetProductsWithPagination(1, 10, &categoryID)
+	products, total, err := service.GetProductsWithPagination(context.Background(), 1, 10, &categoryID)
 	if err != nil {
 		fmt.Printf("分页查询失败: %v\n", err)
 	} else {
@@ -592,7 +2451,7 @@ func demonstratePerformanceOptimization(db *gorm.DB) {
 
 	// 2. 关联查询测试
 	fmt.Println("\n2. 关联查询测试:")
-	orders, err := service.GetOrdersWithJoin(1, 5)
+	orders, err := service.GetOrdersWithJoin(context.Background(), 1, 5)
 	if err != nil {
 		fmt.Printf("关联查询失败: %v\n", err)
 	} else {
@@ -607,7 +2466,7 @@ func demonstratePerformanceOptimization(db *gorm.DB) {
 	fmt.Println("\n3. 销售统计查询测试:")
 	startDate := time.Now().AddDate(0, 0, -30)
 	endDate := time.Now()
-	stats, err := service.GetSalesStatisticsOptimized(startDate, endDate)
+	stats, err := service.GetSalesStatisticsOptimized(context.Background(), startDate, endDate)
 	if err != nil {
 		fmt.Printf("统计查询失败: %v\n", err)
 	} else {
@@ -642,7 +2501,7 @@ func demonstratePerformanceOptimization(db *gorm.DB) {
 		queryStats["max_duration"], queryStats["min_duration"])
 
 	// 7. 慢查询分析
-	slowQueries := monitor.GetSlowQueries(100 * time.Millisecond)
+	slowQueries := monitor.GetSlowQueries(slowQueryThreshold)
 	if len(slowQueries) > 0 {
 		fmt.Printf("\n发现 %d 个慢查询:\n", len(slowQueries))
 		for _, query := range slowQueries {
@@ -652,13 +2511,73 @@ func demonstratePerformanceOptimization(db *gorm.DB) {
 		fmt.Println("\n未发现慢查询")
 	}
 
-	// 8. 基准测试
+	// 8. 基准测试（同时监控连接池，使用率超过80%或出现等待时告警）
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	_, err = StartPoolMonitor(ctx, db, 100*time.Millisecond, 0.8, func(stats PoolStats) {
+		fmt.Printf("连接池告警: 使用中 %d/%d, %v\n", stats.InUse, stats.MaxOpenConnections, stats.Alerts)
+	})
+	if err != nil {
+		fmt.Printf("启动连接池监控失败: %v\n", err)
+	}
+
 	benchmark := NewBenchmarkTest(db, monitor)
 	benchmark.RunConcurrentQueries(10, 100)
+
+	// 并发数远超最大连接数且获取连接的期限很短时，部分请求返回ErrPoolTimeout而不是一直等待
+	if sqlDB, err := db.DB(); err == nil {
+		maxOpen := sqlDB.Stats().MaxOpenConnections
+		sqlDB.SetMaxOpenConns(5)
+		benchmark.SetAcquireTimeout(5 * time.Millisecond)
+		benchmark.RunConcurrentQueries(50, 20)
+		benchmark.SetAcquireTimeout(defaultAcquireTimeout)
+		sqlDB.SetMaxOpenConns(maxOpen)
+		fmt.Printf("累计连接超时次数: %d\n", monitor.PoolTimeouts())
+	}
 	benchmark.RunBatchInsertTest(1000, 100)
+	fmt.Println("预编译语句缓存对比: go test -run '^$' -bench StmtCaching （见 benchmark_test.go）")
+
+	// 9. 混合负载场景压测
+	for _, spec := range []WorkloadSpec{ReadHeavyWorkload(3 * time.Second), WriteHeavyWorkload(3 * time.Second)} {
+		report, err := benchmark.RunScenario(spec)
+		if err != nil {
+			fmt.Printf("场景压测失败: %v\n", err)
+		}
+		if report != nil {
+			report.Print()
+		}
+	}
+
+	// 10. 数据库日报：把本次演示的统计写入快照并汇总当天日报（正常运行时由 StartDailyDbReportJob 每小时/每天执行）
+	fmt.Println("\n10. 数据库日报:")
+	job := NewDailyDbReportJob(db, monitor)
+	if err := job.Migrate(); err != nil {
+		fmt.Printf("创建数据库日报表失败: %v\n", err)
+		return
+	}
+	if _, err := job.WriteSnapshot(); err != nil {
+		fmt.Printf("写入快照失败: %v\n", err)
+	}
+	daily, err := job.Rollup(time.Now())
+	if err != nil {
+		fmt.Printf("汇总日报失败: %v\n", err)
+		return
+	}
+	fmt.Printf("%s: 查询 %d 次, 慢查询 %d 次, P95 %.1fms, 接口错误 %d 次\n",
+		daily.Date, daily.Queries, daily.SlowQueries, daily.P95Ms, daily.APIErrors)
+	for i, stmt := range daily.TopStatements {
+		fmt.Printf("  %d. %s 共 %d 次, 总耗时 %.1fms\n", i+1, stmt.SQL, stmt.Count, stmt.TotalMs)
+	}
+	fmt.Println("管理接口: GET /api/v1/admin/db-reports?days=14 （见 NewAdminRouter）")
+	fmt.Println("接口耗时指标: GET /api/v1/admin/metrics （Prometheus文本格式），订单接口慢请求: GET /api/v1/admin/debug/route-exemplars")
 }
 
 func main() {
+	// 压测数据规模和随机种子，如 go run . -profile=large -seed=7
+	profile := flag.String("profile", string(GeneratorProfileSmall), "压测数据规模: small(1千订单)、medium(5万订单)、large(50万订单)")
+	seed := flag.Int64("seed", 42, "压测数据的随机种子，相同种子生成的数据相同")
+	flag.Parse()
+
 	// 数据库配置（优化版）
 	config := DatabaseConfig{
 		Host:            "localhost",
@@ -671,6 +2590,8 @@ func main() {
 		MaxOpenConns:    100,               // 最大打开连接数
 		ConnMaxLifetime: time.Hour,         // 连接最大生存时间
 		ConnMaxIdleTime: 10 * time.Minute,  // 连接最大空闲时间
+		// 超过200ms的SQL记为慢查询，输出警告并记录到性能监控器
+		SlowThreshold: 200 * time.Millisecond,
 	}
 
 	// 连接数据库
@@ -681,16 +2602,16 @@ func main() {
 	}
 
 	// 迁移数据库
-	db.AutoMigrate(&User{}, &Category{}, &Brand{}, &Product{}, &Order{}, &OrderItem{})
+	db.AutoMigrate(models.All()...)
 
 	// 创建优化索引
 	CreateOptimizedIndexes(db)
 
 	// 检查是否需要填充测试数据
 	var userCount int64
-	db.Model(&User{}).Count(&userCount)
+	db.Model(&models.User{}).Count(&userCount)
 	if userCount == 0 {
-		if err := SeedTestData(db); err != nil {
+		if err := SeedTestData(db, GeneratorProfile(*profile), *seed); err != nil {
 			log.Fatal("填充测试数据失败:", err)
 		}
 	}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm-advanced-exercises/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var testDBSeq int64
+
+// newTestDB 创建独立的内存SQLite数据库并迁移 models.All()，测试结束时关闭
+func newTestDB(t testing.TB) *gorm.DB {
+	t.Helper()
+	dsn := fmt.Sprintf("file:exercise4_%d?mode=memory&cache=shared&_pragma=busy_timeout(5000)", atomic.AddInt64(&testDBSeq, 1))
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("打开测试数据库失败: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("获取数据库连接失败: %v", err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	if err := db.AutoMigrate(models.All()...); err != nil {
+		t.Fatalf("迁移测试数据库失败: %v", err)
+	}
+	return db
+}
+
+// countRows 统计模型对应表（含软删除）的行数
+func countRows(t testing.TB, db *gorm.DB, model interface{}) int64 {
+	t.Helper()
+	var n int64
+	if err := db.Unscoped().Model(model).Count(&n).Error; err != nil {
+		t.Fatalf("统计 %T 失败: %v", model, err)
+	}
+	return n
+}
+
+// newSeededDB 按small规模（种子1）填充数据的测试数据库
+func newSeededDB(t testing.TB) *gorm.DB {
+	t.Helper()
+	db := newTestDB(t)
+	if err := SeedTestData(db, GeneratorProfileSmall, 1); err != nil {
+		t.Fatalf("填充数据失败: %v", err)
+	}
+	return db
+}
+
+// TestMigrateAndSeed 在SQLite上迁移共用模型，按small规模填充演示和压测数据
+func TestMigrateAndSeed(t *testing.T) {
+	db := newSeededDB(t)
+	opts, err := ProfileOptions(GeneratorProfileSmall, 1)
+	if err != nil {
+		t.Fatalf("获取生成参数失败: %v", err)
+	}
+	// 固定的演示数据之外，按profile生成的数据
+	counts := map[interface{}]int64{
+		&models.User{}:    3 + int64(opts.Users),
+		&models.Brand{}:   3 + int64(opts.Brands),
+		&models.Product{}: 5 + int64(opts.Products),
+		&models.Order{}:   int64(opts.Orders),
+	}
+	for model, want := range counts {
+		if got := countRows(t, db, model); got != want {
+			t.Errorf("%T 有 %d 条，期望 %d", model, got, want)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestRouteMetricsPrometheus 桶上界去重排序，桶计数在输出时累加；序列按路由、方法、状态类别排序，标签值转义
+func TestRouteMetricsPrometheus(t *testing.T) {
+	m := NewRouteMetrics([]float64{0.1, 0.05, 0.1}, slowRequestThreshold)
+	ms := func(f float64) time.Duration { return time.Duration(f * float64(time.Millisecond)) }
+	m.Observe("GET", "/api/v1/orders/:id", 200, ms(31.25))
+	m.Observe("GET", "/api/v1/orders/:id", 204, ms(62.5))
+	m.Observe("GET", "/api/v1/orders/:id", 200, 2*time.Second)
+	m.Observe("POST", "/api/v1/orders", 503, 250*time.Millisecond)
+	m.Observe("GET", `/a"b`, 404, 50*time.Millisecond)
+
+	var out strings.Builder
+	if err := m.WritePrometheus(&out); err != nil {
+		t.Fatalf("输出指标失败: %v", err)
+	}
+	want := `# HELP http_request_duration_seconds 接口请求耗时（秒），按方法、路由模板和状态类别统计
+# TYPE http_request_duration_seconds histogram
+http_request_duration_seconds_bucket{method="GET",route="/a\"b",status="4xx",le="0.05"} 1
+http_request_duration_seconds_bucket{method="GET",route="/a\"b",status="4xx",le="0.1"} 1
+http_request_duration_seconds_bucket{method="GET",route="/a\"b",status="4xx",le="+Inf"} 1
+http_request_duration_seconds_sum{method="GET",route="/a\"b",status="4xx"} 0.05
+http_request_duration_seconds_count{method="GET",route="/a\"b",status="4xx"} 1
+http_request_duration_seconds_bucket{method="POST",route="/api/v1/orders",status="5xx",le="0.05"} 0
+http_request_duration_seconds_bucket{method="POST",route="/api/v1/orders",status="5xx",le="0.1"} 0
+http_request_duration_seconds_bucket{method="POST",route="/api/v1/orders",status="5xx",le="+Inf"} 1
+http_request_duration_seconds_sum{method="POST",route="/api/v1/orders",status="5xx"} 0.25
+http_request_duration_seconds_count{method="POST",route="/api/v1/orders",status="5xx"} 1
+http_request_duration_seconds_bucket{method="GET",route="/api/v1/orders/:id",status="2xx",le="0.05"} 1
+http_request_duration_seconds_bucket{method="GET",route="/api/v1/orders/:id",status="2xx",le="0.1"} 2
+http_request_duration_seconds_bucket{method="GET",route="/api/v1/orders/:id",status="2xx",le="+Inf"} 3
+http_request_duration_seconds_sum{method="GET",route="/api/v1/orders/:id",status="2xx"} 2.09375
+http_request_duration_seconds_count{method="GET",route="/api/v1/orders/:id",status="2xx"} 3
+`
+	if out.String() != want {
+		t.Errorf("指标输出为\n%s\n期望\n%s", out.String(), want)
+	}
+}
+
+// TestRouteExemplars 只有耗时达到阈值且有订单ID的请求才记录，同一路由保留最近一次
+func TestRouteExemplars(t *testing.T) {
+	m := NewRouteMetrics(nil, 100*time.Millisecond)
+	m.RecordExemplar("GET", "/api/v1/orders/:id", "", 200, time.Second)
+	m.RecordExemplar("GET", "/api/v1/orders/:id", "1", 200, 99*time.Millisecond)
+	m.RecordExemplar("GET", "/api/v1/orders/:id", "2", 200, 100*time.Millisecond)
+	m.RecordExemplar("GET", "/api/v1/orders/:id", "3", 500, 300*time.Millisecond)
+	m.RecordExemplar("POST", "/api/v1/orders", "4", 201, 150*time.Millisecond)
+
+	var got []string
+	for _, e := range m.Exemplars() {
+		got = append(got, e.Method+" "+e.Route+" "+e.OrderID)
+	}
+	want := []string{"POST /api/v1/orders 4", "GET /api/v1/orders/:id 3"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("慢请求记录为 %v，期望 %v", got, want)
+	}
+	if e := m.Exemplars()[1]; e.Status != 500 || e.DurationMs != 300 {
+		t.Errorf("最近一次慢请求为 %+v", e)
+	}
+}
+
+// TestRequestLogMiddlewareRoutes 按路由模板统计，未匹配的请求记为unmatched；
+// 订单接口的慢请求记录路由参数或处理函数设置的订单ID
+func TestRequestLogMiddlewareRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	monitor := NewPerformanceMonitor(nil)
+	monitor.routes = NewRouteMetrics(nil, 0) // 所有订单请求都算慢请求
+
+	r := gin.New()
+	r.Use(RequestLogMiddleware(monitor))
+	r.GET("/api/v1/orders/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.POST("/api/v1/orders", func(c *gin.Context) {
+		SetExemplarOrderID(c, "1001")
+		c.Status(http.StatusCreated)
+	})
+	r.GET("/api/v1/products/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	for _, req := range []struct{ method, url string }{
+		{"GET", "/api/v1/orders/42"}, {"GET", "/api/v1/orders/43"}, {"POST", "/api/v1/orders"},
+		{"GET", "/api/v1/products/7"}, {"GET", "/wp-login.php"},
+	} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(req.method, req.url, nil))
+	}
+
+	var got []string
+	for _, e := range monitor.Routes().Exemplars() {
+		got = append(got, e.Method+" "+e.Route+" "+e.OrderID)
+	}
+	if want := []string{"POST /api/v1/orders 1001", "GET /api/v1/orders/:id 43"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("慢请求记录为 %v，期望 %v", got, want)
+	}
+
+	var out strings.Builder
+	if err := monitor.WritePrometheus(&out); err != nil {
+		t.Fatalf("输出指标失败: %v", err)
+	}
+	for _, series := range []string{
+		`http_request_duration_seconds_count{method="GET",route="/api/v1/orders/:id",status="2xx"} 2`,
+		`http_request_duration_seconds_count{method="GET",route="/api/v1/products/:id",status="2xx"} 1`,
+		`http_request_duration_seconds_count{method="GET",route="unmatched",status="4xx"} 1`,
+		"db_pool_timeouts_total 0",
+	} {
+		if !strings.Contains(out.String(), series) {
+			t.Errorf("指标中没有 %s", series)
+		}
+	}
+	if strings.Contains(out.String(), "wp-login") || strings.Contains(out.String(), "/42") {
+		t.Errorf("指标中出现了请求路径:\n%s", out.String())
+	}
+}
+
+// TestIsOrderRoute 路由模板中有 orders 这一段才是订单接口
+func TestIsOrderRoute(t *testing.T) {
+	for route, want := range map[string]bool{
+		"/api/v1/orders":           true,
+		"/api/v1/orders/:id/items": true,
+		"/api/v1/preorders":        false,
+		"/api/v1/orders-export":    false,
+		unmatchedRoute:             false,
+	} {
+		if got := isOrderRoute(route); got != want {
+			t.Errorf("isOrderRoute(%q) = %v，期望 %v", route, got, want)
+		}
+	}
+}
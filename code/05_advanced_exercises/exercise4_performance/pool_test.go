@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// limitPool 把测试数据库的最大连接数设为n，返回底层连接池
+func limitPool(t *testing.T, db *gorm.DB, n int) *sql.DB {
+	t.Helper()
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("获取数据库连接失败: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(n)
+	return sqlDB
+}
+
+// holdConns 占用n个连接，测试结束时释放
+func holdConns(t *testing.T, sqlDB *sql.DB, n int) []*sql.Conn {
+	t.Helper()
+	conns := make([]*sql.Conn, n)
+	for i := range conns {
+		conn, err := sqlDB.Conn(context.Background())
+		if err != nil {
+			t.Fatalf("获取连接失败: %v", err)
+		}
+		conns[i] = conn
+		t.Cleanup(func() { conn.Close() })
+	}
+	return conns
+}
+
+// TestPoolMonitorAlerts 使用率达到阈值、出现新的等待时告警，等待次数按两次采样的差值计算
+func TestPoolMonitorAlerts(t *testing.T) {
+	db := newTestDB(t)
+	sqlDB := limitPool(t, db, 2)
+
+	var alerts []PoolStats
+	monitor, err := NewPoolMonitor(db, 0.8, func(stats PoolStats) { alerts = append(alerts, stats) })
+	if err != nil {
+		t.Fatalf("创建连接池监控失败: %v", err)
+	}
+	if stats := monitor.Sample(); len(stats.Alerts) != 0 || stats.Usage != 0 {
+		t.Errorf("空闲时采样为 %+v，期望不告警", stats)
+	}
+
+	conns := holdConns(t, sqlDB, 2)
+	stats := monitor.Sample()
+	if stats.Usage != 1 || len(stats.Alerts) != 1 || !strings.Contains(stats.Alerts[0], "100%") {
+		t.Errorf("连接占满时使用率 %v、告警 %v，期望1条使用率告警", stats.Usage, stats.Alerts)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := sqlDB.Conn(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("连接池已满时获取连接返回 %v，期望超时", err)
+	}
+	stats = monitor.Sample()
+	if stats.WaitCountDelta != 1 || stats.WaitDurationDelta <= 0 || len(stats.Alerts) != 2 {
+		t.Errorf("等待后采样为 新增等待 %d 次、%v，告警 %v，期望1次等待和2条告警",
+			stats.WaitCountDelta, stats.WaitDurationDelta, stats.Alerts)
+	}
+
+	for _, conn := range conns {
+		conn.Close()
+	}
+	// 等待次数是累计值，没有新的等待时不再告警
+	if stats := monitor.Sample(); len(stats.Alerts) != 0 || stats.WaitCountDelta != 0 {
+		t.Errorf("释放连接后采样为 %+v，期望不告警", stats)
+	}
+	if len(alerts) != 2 {
+		t.Errorf("告警回调调用了 %d 次，期望 2", len(alerts))
+	}
+}
+
+// TestStartPoolMonitor 后台定期采样，连接占满时调用告警回调
+func TestStartPoolMonitor(t *testing.T) {
+	db := newTestDB(t)
+	holdConns(t, limitPool(t, db, 1), 1)
+
+	alerts := make(chan PoolStats, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if _, err := StartPoolMonitor(ctx, db, 10*time.Millisecond, 0.5, func(stats PoolStats) {
+		select {
+		case alerts <- stats:
+		default:
+		}
+	}); err != nil {
+		t.Fatalf("启动连接池监控失败: %v", err)
+	}
+
+	select {
+	case stats := <-alerts:
+		if stats.InUse != 1 || stats.MaxOpenConnections != 1 {
+			t.Errorf("告警时使用中 %d/%d，期望 1/1", stats.InUse, stats.MaxOpenConnections)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("连接占满1秒内没有告警")
+	}
+}
+
+// TestAcquireTimeoutReturnsErrPoolTimeout 连接池占满时读查询在获取连接的期限后返回ErrPoolTimeout并计数，
+// 调用方自己取消的ctx原样返回，不计为连接池超时
+func TestAcquireTimeoutReturnsErrPoolTimeout(t *testing.T) {
+	db := newTestDB(t)
+	conns := holdConns(t, limitPool(t, db, 1), 1)
+	monitor := NewPerformanceMonitor(db)
+	service := NewOptimizedQueryService(db, monitor).WithAcquireTimeout(20 * time.Millisecond)
+
+	start := time.Now()
+	_, _, err := service.GetProductsWithPagination(context.Background(), 1, 10, nil)
+	if !errors.Is(err, ErrPoolTimeout) {
+		t.Fatalf("连接池已满时返回 %v，期望 ErrPoolTimeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("等待了 %v 才返回", elapsed)
+	}
+	if _, err := service.GetProductDetail(context.Background(), 1); !errors.Is(err, ErrPoolTimeout) {
+		t.Errorf("商品详情返回 %v，期望 ErrPoolTimeout", err)
+	}
+	if n := monitor.PoolTimeouts(); n != 2 {
+		t.Errorf("连接超时 %d 次，期望 2", n)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := service.GetOrdersWithJoin(ctx, 1, 5); !errors.Is(err, context.Canceled) || errors.Is(err, ErrPoolTimeout) {
+		t.Errorf("ctx取消后返回 %v，期望 context.Canceled", err)
+	}
+	if n := monitor.PoolTimeouts(); n != 2 {
+		t.Errorf("ctx取消后连接超时计数为 %d，期望仍为 2", n)
+	}
+
+	var metrics strings.Builder
+	if err := monitor.WritePrometheus(&metrics); err != nil || !strings.Contains(metrics.String(), "db_pool_timeouts_total 2\n") {
+		t.Errorf("监控指标为 %q（%v），期望 db_pool_timeouts_total 2", metrics.String(), err)
+	}
+
+	conns[0].Close()
+	if _, total, err := service.GetProductsWithPagination(context.Background(), 1, 10, nil); err != nil || total != 0 {
+		t.Errorf("释放连接后查询返回 %d 条（%v）", total, err)
+	}
+}
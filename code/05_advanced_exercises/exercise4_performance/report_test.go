@@ -0,0 +1,204 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newReportJob 按UTC划分日期、当前时间可设置的日报任务
+func newReportJob(t *testing.T) (*DailyDbReportJob, *PerformanceMonitor, func(time.Time)) {
+	t.Helper()
+	monitor := NewPerformanceMonitor(nil)
+	job := NewDailyDbReportJob(newTestDB(t), monitor)
+	job.loc = time.UTC
+	if err := job.Migrate(); err != nil {
+		t.Fatalf("创建日报表失败: %v", err)
+	}
+	setNow := func(now time.Time) { job.now = func() time.Time { return now } }
+	return job, monitor, setNow
+}
+
+// writeSnapshot 在now写入快照，want为false时期望窗口为空、不写入
+func writeSnapshot(t *testing.T, job *DailyDbReportJob, want bool) *DbStatsSnapshot {
+	t.Helper()
+	snapshot, err := job.WriteSnapshot()
+	if err != nil {
+		t.Fatalf("写入快照失败: %v", err)
+	}
+	if (snapshot != nil) != want {
+		t.Fatalf("写入的快照为 %+v，期望写入: %v", snapshot, want)
+	}
+	return snapshot
+}
+
+// TestSnapshotRollup 小时快照按开始时间归属到某一天，日报合并当天的快照：
+// 语句按去掉多余空白后的文本累计，按总耗时排序，P95按直方图估算，不超过最大耗时
+func TestSnapshotRollup(t *testing.T) {
+	job, monitor, setNow := newReportJob(t)
+	day := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	// 第一个窗口从监控器创建时开始，先取出空窗口，之后的窗口从整点开始
+	setNow(day)
+	writeSnapshot(t, job, false)
+
+	monitor.LogQuery("SELECT a", 3*time.Millisecond, 0)
+	monitor.LogQuery("SELECT a", 3*time.Millisecond, 0)
+	monitor.LogQuery("SELECT  b\n\tWHERE x", 150*time.Millisecond, 0)
+	monitor.RecordRequest(http.StatusOK)
+	monitor.RecordRequest(http.StatusInternalServerError)
+	monitor.RecordRequest(http.StatusNotFound)
+	setNow(day.Add(time.Hour))
+	if snapshot := writeSnapshot(t, job, true); !snapshot.PeriodStart.Equal(day) || !snapshot.PeriodEnd.Equal(day.Add(time.Hour)) {
+		t.Errorf("快照时间为 %v ~ %v", snapshot.PeriodStart, snapshot.PeriodEnd)
+	}
+
+	monitor.LogQuery("SELECT b WHERE x", 40*time.Millisecond, 0)
+	monitor.LogQuery("SELECT c", time.Millisecond, 0)
+	setNow(day.Add(2 * time.Hour))
+	writeSnapshot(t, job, true)
+
+	// 第二天的快照不计入6月1日
+	setNow(day.AddDate(0, 0, 1))
+	writeSnapshot(t, job, false)
+	monitor.LogQuery("SELECT z", time.Second, 0)
+	setNow(day.AddDate(0, 0, 1).Add(time.Hour))
+	writeSnapshot(t, job, true)
+
+	want := &DailyReportData{
+		Date: "2024-06-01", Snapshots: 2, Queries: 5, SlowQueries: 1,
+		SlowThresholdMs: 100, P95Ms: 150, MaxMs: 150,
+		TopStatements: []StatementStats{
+			{SQL: "SELECT b WHERE x", Count: 2, TotalMs: 190, AvgMs: 95},
+			{SQL: "SELECT a", Count: 2, TotalMs: 6, AvgMs: 3},
+			{SQL: "SELECT c", Count: 1, TotalMs: 1, AvgMs: 1},
+		},
+		APIRequests: 3, APIErrors: 1, APIClientErrors: 1, APIErrorRate: 1.0 / 3,
+	}
+	for i := 0; i < 2; i++ {
+		got, err := job.Rollup(day.Add(12 * time.Hour))
+		if err != nil {
+			t.Fatalf("汇总日报失败: %v", err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("日报为 %+v，期望 %+v", got, want)
+		}
+	}
+	if n := countRows(t, job.db, &DbDailyReport{}); n != 1 {
+		t.Errorf("重复汇总后有 %d 行日报，期望 1", n)
+	}
+
+	next, err := job.Rollup(day.AddDate(0, 0, 1))
+	if err != nil {
+		t.Fatalf("汇总日报失败: %v", err)
+	}
+	if next.Snapshots != 1 || next.Queries != 1 || next.SlowQueries != 1 || next.P95Ms != 1000 {
+		t.Errorf("6月2日的日报为 %+v", next)
+	}
+
+	setNow(day.AddDate(0, 0, 2).Add(3 * time.Hour))
+	for days, dates := range map[int][]string{7: {"2024-06-01", "2024-06-02"}, 1: {"2024-06-02"}} {
+		reports, err := job.Reports(days)
+		if err != nil {
+			t.Fatalf("查询日报失败: %v", err)
+		}
+		var got []string
+		for _, r := range reports {
+			got = append(got, r.Date)
+		}
+		if !reflect.DeepEqual(got, dates) {
+			t.Errorf("最近 %d 天的日报为 %v，期望 %v", days, got, dates)
+		}
+	}
+}
+
+// TestWriteSnapshotFailureKeepsWindow 快照写入失败时统计放回监控器，下次快照一并写入
+func TestWriteSnapshotFailureKeepsWindow(t *testing.T) {
+	job, monitor, setNow := newReportJob(t)
+	day := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	setNow(day)
+	writeSnapshot(t, job, false)
+
+	monitor.LogQuery("SELECT a", time.Millisecond, 0)
+	if err := job.db.Migrator().DropTable(&DbStatsSnapshot{}); err != nil {
+		t.Fatalf("删除快照表失败: %v", err)
+	}
+	setNow(day.Add(time.Hour))
+	if _, err := job.WriteSnapshot(); err == nil {
+		t.Fatal("快照表不存在时写入成功")
+	}
+
+	if err := job.Migrate(); err != nil {
+		t.Fatalf("创建日报表失败: %v", err)
+	}
+	monitor.LogQuery("SELECT b", time.Millisecond, 0)
+	setNow(day.Add(2 * time.Hour))
+	snapshot := writeSnapshot(t, job, true)
+
+	var window StatsWindow
+	if err := json.Unmarshal([]byte(snapshot.Payload), &window); err != nil {
+		t.Fatalf("解析快照失败: %v", err)
+	}
+	if window.Queries != 2 || len(window.Statements) != 2 || !snapshot.PeriodStart.Equal(day) {
+		t.Errorf("快照从 %v 开始，包含 %d 次查询、%d 条语句，期望从 %v 开始的2次查询", snapshot.PeriodStart, window.Queries, len(window.Statements), day)
+	}
+}
+
+// TestAdminReportsEndpoint 日报接口需要管理令牌，days须在1到90之间
+func TestAdminReportsEndpoint(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	job, monitor, setNow := newReportJob(t)
+	day := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	setNow(day)
+	writeSnapshot(t, job, false)
+	monitor.LogQuery("SELECT a", time.Millisecond, 0)
+	setNow(day.Add(time.Hour))
+	writeSnapshot(t, job, true)
+	if _, err := job.Rollup(day); err != nil {
+		t.Fatalf("汇总日报失败: %v", err)
+	}
+	setNow(day.AddDate(0, 0, 1))
+
+	router := NewAdminRouter(job, "secret")
+	get := func(url, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		if token != "" {
+			req.Header.Set("X-Admin-Token", token)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	for _, tc := range []struct {
+		url, token string
+		status     int
+	}{
+		{"/api/v1/admin/db-reports", "", http.StatusForbidden},
+		{"/api/v1/admin/db-reports", "wrong", http.StatusForbidden},
+		{"/api/v1/admin/db-reports?days=0", "secret", http.StatusBadRequest},
+		{"/api/v1/admin/db-reports?days=91", "secret", http.StatusBadRequest},
+		{"/api/v1/admin/db-reports?days=x", "secret", http.StatusBadRequest},
+	} {
+		if w := get(tc.url, tc.token); w.Code != tc.status {
+			t.Errorf("GET %s（令牌 %q）返回 %d，期望 %d", tc.url, tc.token, w.Code, tc.status)
+		}
+	}
+
+	w := get("/api/v1/admin/db-reports", "secret")
+	var body struct {
+		Days    int               `json:"days"`
+		Reports []DailyReportData `json:"reports"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("默认天数返回 %d: %s", w.Code, w.Body.String())
+	}
+	if body.Days != 14 || len(body.Reports) != 1 || body.Reports[0].Date != "2024-06-01" || body.Reports[0].Queries != 1 {
+		t.Errorf("日报接口返回 %+v", body)
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm-advanced-exercises/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// TestSlowQueryLoggerRecordsCrossing 耗时超过阈值的SQL记录到自动关联的监控器，日志级别为Silent时同样记录；未超过阈值的不记录
+func TestSlowQueryLoggerRecordsCrossing(t *testing.T) {
+	slowLogger := NewSlowQueryLogger(50 * time.Millisecond)
+	db := newTestDB(t).Session(&gorm.Session{Logger: slowLogger.LogMode(logger.Silent)})
+	monitor := NewPerformanceMonitor(db)
+
+	// 商品查询前等待60毫秒，使其超过阈值
+	err := db.Callback().Query().Before("gorm:query").Register("test:slow_products", func(tx *gorm.DB) {
+		if tx.Statement.Table == "products" {
+			time.Sleep(60 * time.Millisecond)
+		}
+	})
+	if err != nil {
+		t.Fatalf("注册回调失败: %v", err)
+	}
+
+	var users []models.User
+	var products []models.Product
+	if err := db.Find(&users).Error; err != nil {
+		t.Fatalf("查询用户失败: %v", err)
+	}
+	if err := db.Where("stock > ?", 5).Find(&products).Error; err != nil {
+		t.Fatalf("查询商品失败: %v", err)
+	}
+
+	slow := monitor.SlowSQL()
+	if len(slow) != 1 {
+		t.Fatalf("记录了 %d 条慢SQL，期望 1: %+v", len(slow), slow)
+	}
+	if !strings.Contains(slow[0].SQL, "products") || !strings.Contains(slow[0].SQL, "stock > 5") || slow[0].Duration < 60*time.Millisecond {
+		t.Errorf("慢SQL记录为 %+v", slow[0])
+	}
+}
+
+// TestSlowQueryLoggerThreshold 阈值为0时使用默认阈值；恰好等于阈值的不算慢查询；未关联监控器时只输出日志
+func TestSlowQueryLoggerThreshold(t *testing.T) {
+	if l := NewSlowQueryLogger(0); l.threshold != slowQueryThreshold {
+		t.Errorf("默认阈值为 %v，期望 %v", l.threshold, slowQueryThreshold)
+	}
+
+	slowLogger := NewSlowQueryLogger(time.Hour)
+	quiet := slowLogger.LogMode(logger.Silent)
+	fc := func() (string, int64) { return "SELECT 1", 1 }
+	quiet.Trace(context.Background(), time.Now().Add(-2*time.Hour), fc, nil) // 尚未关联监控器
+
+	monitor := NewPerformanceMonitor(nil)
+	slowLogger.SetMonitor(monitor) // LogMode返回的副本共用同一个监控器
+	quiet.Trace(context.Background(), time.Now(), fc, nil)
+	quiet.Trace(context.Background(), time.Now().Add(-2*time.Hour), fc, nil)
+
+	slow := monitor.SlowSQL()
+	if len(slow) != 1 || slow[0].SQL != "SELECT 1" || slow[0].Rows != 1 {
+		t.Errorf("慢SQL记录为 %+v，期望只有关联监控器后超过阈值的一条", slow)
+	}
+}
+
+// TestRecordSlowQueryKeepsLatest 监控器只保留最近 maxSlowQueryLogs 条慢SQL
+func TestRecordSlowQueryKeepsLatest(t *testing.T) {
+	monitor := NewPerformanceMonitor(nil)
+	for i := 0; i < maxSlowQueryLogs+5; i++ {
+		monitor.RecordSlowQuery(fmt.Sprintf("SELECT %d", i), time.Second, 0)
+	}
+	slow := monitor.SlowSQL()
+	if len(slow) != maxSlowQueryLogs || slow[0].SQL != "SELECT 5" || slow[len(slow)-1].SQL != fmt.Sprintf("SELECT %d", maxSlowQueryLogs+4) {
+		t.Errorf("保留 %d 条慢SQL，首条 %q", len(slow), slow[0].SQL)
+	}
+}
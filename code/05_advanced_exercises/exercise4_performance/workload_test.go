@@ -0,0 +1,143 @@
+package main
+
+import (
+	"encoding/json"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"gorm-advanced-exercises/models"
+)
+
+// TestPickOpFollowsWeights 操作按权重的比例被选中，权重为0的操作不会被选中
+func TestPickOpFollowsWeights(t *testing.T) {
+	ops := []WeightedOp{{OpProductPage, 1}, {OpListing, 0}, {OpCreateOrder, 3}}
+	rng := rand.New(rand.NewSource(1))
+
+	const draws = 40000
+	counts := make(map[WorkloadOp]int)
+	for i := 0; i < draws; i++ {
+		counts[pickOp(rng, ops, 4)]++
+	}
+	if counts[OpListing] != 0 {
+		t.Errorf("权重为0的操作被选中 %d 次", counts[OpListing])
+	}
+	if share := float64(counts[OpProductPage]) / draws; math.Abs(share-0.25) > 0.02 {
+		t.Errorf("权重1/4的操作占 %.3f", share)
+	}
+	if counts[OpProductPage]+counts[OpCreateOrder] != draws {
+		t.Errorf("选中次数为 %v，合计应为 %d", counts, draws)
+	}
+}
+
+// TestBuiltinWorkloads 内置场景的权重合计为100，写多场景的下单占比高于读多场景
+func TestBuiltinWorkloads(t *testing.T) {
+	weights := func(spec WorkloadSpec) map[WorkloadOp]int {
+		m := make(map[WorkloadOp]int)
+		total := 0
+		for _, op := range spec.Ops {
+			m[op.Op] += op.Weight
+			total += op.Weight
+		}
+		if total != 100 {
+			t.Errorf("%s 的权重合计为 %d", spec.Name, total)
+		}
+		return m
+	}
+	read, write := weights(ReadHeavyWorkload(time.Second)), weights(WriteHeavyWorkload(time.Second))
+	if read[OpCreateOrder] >= write[OpCreateOrder] || read[OpProductPage] <= write[OpProductPage] {
+		t.Errorf("读多场景权重 %v，写多场景权重 %v", read, write)
+	}
+}
+
+// TestMergeOrderItems 同一商品的下单项合并数量，保持首次出现的顺序
+func TestMergeOrderItems(t *testing.T) {
+	got := mergeOrderItems([]OrderItemInput{{3, 1}, {1, 1}, {3, 2}, {2, 1}, {1, 1}})
+	want := []OrderItemInput{{3, 3}, {1, 2}, {2, 1}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("合并结果为 %v，期望 %v", got, want)
+	}
+}
+
+// TestRunScenarioValidation 缺少操作、阶梯、时长，权重和为0或没有数据时不开始压测
+func TestRunScenarioValidation(t *testing.T) {
+	bt := NewBenchmarkTest(newTestDB(t), NewPerformanceMonitor(nil))
+	ops := []WeightedOp{{OpListing, 1}}
+	cases := []struct {
+		name string
+		spec WorkloadSpec
+	}{
+		{"没有操作", WorkloadSpec{Steps: []int{1}, Duration: time.Second}},
+		{"没有阶梯", WorkloadSpec{Ops: ops, Duration: time.Second}},
+		{"没有时长", WorkloadSpec{Ops: ops, Steps: []int{1}}},
+		{"权重和为0", WorkloadSpec{Ops: []WeightedOp{{OpListing, 0}}, Steps: []int{1}, Duration: time.Second}},
+		{"没有数据", WorkloadSpec{Ops: ops, Steps: []int{1}, Duration: time.Second}},
+	}
+	for _, tc := range cases {
+		if report, err := bt.RunScenario(tc.spec); err == nil || report != nil {
+			t.Errorf("%s: 返回 %v、%v，期望报错", tc.name, report, err)
+		}
+	}
+}
+
+// TestRunScenarioRestoresData 混合负载按阶梯执行，报告按场景中的操作顺序汇总并写成JSON；
+// 结束后压测生成的订单被删除，库存和销量恢复原值
+func TestRunScenarioRestoresData(t *testing.T) {
+	db := newSeededDB(t)
+	type productTotals struct{ Stock, Sales int64 }
+	totals := func() productTotals {
+		var pt productTotals
+		db.Model(&models.Product{}).Select("SUM(stock) as stock, SUM(sales) as sales").Scan(&pt)
+		return pt
+	}
+	wantTotals := totals()
+	wantOrders := countRows(t, db, &models.Order{})
+
+	spec := WorkloadSpec{
+		Name:       "mixed",
+		Ops:        []WeightedOp{{OpProductPage, 1}, {OpListing, 1}, {OpCreateOrder, 2}, {OpStatistics, 1}},
+		Steps:      []int{1, 2},
+		Duration:   400 * time.Millisecond,
+		Seed:       3,
+		JSONOutput: filepath.Join(t.TempDir(), "report.json"),
+	}
+	report, err := NewBenchmarkTest(db, NewPerformanceMonitor(db)).RunScenario(spec)
+	if err != nil {
+		t.Fatalf("压测失败: %v", err)
+	}
+
+	var total, errs int64
+	for i, op := range report.Ops {
+		if op.Op != spec.Ops[i].Op {
+			t.Errorf("第 %d 个操作为 %s，期望 %s", i+1, op.Op, spec.Ops[i].Op)
+		}
+		if op.Count == 0 || op.P50 > op.P95 || op.P95 > op.P99 || op.P99 > op.Max {
+			t.Errorf("%s: 次数 %d，P50 %v、P95 %v、P99 %v、最大 %v", op.Op, op.Count, op.P50, op.P95, op.P99, op.Max)
+		}
+		total += op.Count
+		errs += op.Errors
+	}
+	if len(report.Ops) != len(spec.Ops) || report.Total != total || report.Errors != errs || report.QPS <= 0 {
+		t.Errorf("报告为 %+v", report)
+	}
+
+	data, err := os.ReadFile(spec.JSONOutput)
+	if err != nil {
+		t.Fatalf("读取JSON报告失败: %v", err)
+	}
+	var written ScenarioReport
+	if err := json.Unmarshal(data, &written); err != nil || !reflect.DeepEqual(&written, report) {
+		t.Errorf("JSON报告为 %+v（%v），期望与返回的报告一致", written, err)
+	}
+
+	if got := countRows(t, db, &models.Order{}); got != wantOrders {
+		t.Errorf("压测后有 %d 个订单，期望 %d", got, wantOrders)
+	}
+	if got := totals(); got != wantTotals {
+		t.Errorf("压测后库存、销量合计为 %+v，期望 %+v", got, wantTotals)
+	}
+}
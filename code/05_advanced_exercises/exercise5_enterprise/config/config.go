@@ -1,15 +1,19 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/spf13/viper"
+	"gorm.io/gorm/schema"
 )
 
 // Config 应用配置结构
 type Config struct {
 	Server   ServerConfig   `mapstructure:"server"`
+	CORS     CORSConfig     `mapstructure:"cors"`
+	Paging   PagingConfig   `mapstructure:"paging"`
 	Database DatabaseConfig `mapstructure:"database"`
 	Redis    RedisConfig    `mapstructure:"redis"`
 	JWT      JWTConfig      `mapstructure:"jwt"`
@@ -27,6 +31,40 @@ type ServerConfig struct {
 	ReadTimeout  time.Duration `mapstructure:"read_timeout"`
 	WriteTimeout time.Duration `mapstructure:"write_timeout"`
 	MaxHeaderMB  int           `mapstructure:"max_header_mb"`
+
+	// DebugSQLToken 非管理员开启请求SQL调试（X-Debug-SQL）时需携带的令牌，为空时只允许管理员
+	DebugSQLToken string `mapstructure:"debug_sql_token"`
+}
+
+// CORSConfig 跨域配置，开发环境允许前端开发服务器访问，生产环境只列出正式域名
+type CORSConfig struct {
+	// AllowedOrigins 允许的来源，完全匹配；* 表示任意来源，也可以用一个 * 代替子域名或端口，
+	// 如 https://*.example.com、http://localhost:*；为空时不允许跨域
+	AllowedOrigins   []string      `mapstructure:"allowed_origins"`
+	AllowedMethods   []string      `mapstructure:"allowed_methods"`
+	AllowedHeaders   []string      `mapstructure:"allowed_headers"`
+	ExposedHeaders   []string      `mapstructure:"exposed_headers"`   // 允许前端读取的响应头，如分页头
+	AllowCredentials bool          `mapstructure:"allow_credentials"` // 是否允许携带Cookie等凭证，开启时不能允许任意来源
+	MaxAge           time.Duration `mapstructure:"max_age"`           // 预检结果的缓存时间（Access-Control-Max-Age）
+}
+
+// Validate 检查跨域配置：允许携带凭证时不能使用 * 允许任意来源
+func (c *CORSConfig) Validate() error {
+	if !c.AllowCredentials {
+		return nil
+	}
+	for _, origin := range c.AllowedOrigins {
+		if origin == "*" {
+			return errors.New("cors.allow_credentials 开启时 allowed_origins 不能包含 *")
+		}
+	}
+	return nil
+}
+
+// PagingConfig 列表分页限制，防止过大的page_size或过深的OFFSET拖垮数据库
+type PagingConfig struct {
+	MaxPageSize int `mapstructure:"max_page_size"` // page_size上限，超出时按上限返回；接口可以指定更小的上限
+	MaxDepth    int `mapstructure:"max_depth"`     // page*page_size的上限，超出时返回400，提示改用游标分页
 }
 
 // DatabaseConfig 数据库配置
@@ -44,10 +82,17 @@ type DatabaseConfig struct {
 	MaxOpenConns    int           `mapstructure:"max_open_conns"`
 	ConnMaxLifetime time.Duration `mapstructure:"conn_max_lifetime"`
 	LogLevel        string        `mapstructure:"log_level"`
+
+	// TablePrefix 表名前缀，部署到共享数据库时使用，如 edu_
+	TablePrefix string `mapstructure:"table_prefix"`
+	// SingularTable 未指定表名的表（如多对多连接表）使用单数表名
+	SingularTable bool `mapstructure:"singular_table"`
 }
 
 // RedisConfig Redis配置
 type RedisConfig struct {
+	// Enabled 是否使用Redis缓存，未启用时使用进程内缓存（单实例部署）
+	Enabled      bool          `mapstructure:"enabled"`
 	Host         string        `mapstructure:"host"`
 	Port         int           `mapstructure:"port"`
 	Password     string        `mapstructure:"password"`
@@ -148,6 +193,9 @@ func LoadConfig(configPath string) (*Config, error) {
 	if err := viper.Unmarshal(&config); err != nil {
 		return nil, fmt.Errorf("解析配置文件失败: %w", err)
 	}
+	if err := config.CORS.Validate(); err != nil {
+		return nil, fmt.Errorf("配置错误: %w", err)
+	}
 
 	return &config, nil
 }
@@ -161,6 +209,19 @@ func setDefaults() {
 	viper.SetDefault("server.read_timeout", "30s")
 	viper.SetDefault("server.write_timeout", "30s")
 	viper.SetDefault("server.max_header_mb", 1)
+	viper.SetDefault("server.debug_sql_token", "")
+
+	// 跨域默认配置：不允许跨域，开发环境在配置文件中列出前端开发服务器的地址
+	viper.SetDefault("cors.allowed_origins", []string{})
+	viper.SetDefault("cors.allowed_methods", []string{"GET", "POST", "PUT", "PATCH", "DELETE"})
+	viper.SetDefault("cors.allowed_headers", []string{"Authorization", "Content-Type", "Accept-Language", "X-Debug-SQL", "X-Debug-Token"})
+	viper.SetDefault("cors.exposed_headers", []string{"X-Total-Count", "X-Page", "X-Page-Size", "Link", "X-Request-ID"})
+	viper.SetDefault("cors.allow_credentials", false)
+	viper.SetDefault("cors.max_age", "12h")
+
+	// 分页默认配置
+	viper.SetDefault("paging.max_page_size", 100)
+	viper.SetDefault("paging.max_depth", 10000)
 
 	// 数据库默认配置
 	viper.SetDefault("database.driver", "mysql")
@@ -176,8 +237,11 @@ func setDefaults() {
 	viper.SetDefault("database.max_open_conns", 100)
 	viper.SetDefault("database.conn_max_lifetime", "1h")
 	viper.SetDefault("database.log_level", "info")
+	viper.SetDefault("database.table_prefix", "")
+	viper.SetDefault("database.singular_table", false)
 
 	// Redis默认配置
+	viper.SetDefault("redis.enabled", false)
 	viper.SetDefault("redis.host", "localhost")
 	viper.SetDefault("redis.port", 6379)
 	viper.SetDefault("redis.password", "")
@@ -232,6 +296,11 @@ func (c *DatabaseConfig) GetDSN() string {
 		c.Username, c.Password, c.Host, c.Port, c.DBName, c.Charset, c.ParseTime, c.Loc)
 }
 
+// GetNamingStrategy 获取GORM命名策略，包含表名前缀和单复数设置
+func (c *DatabaseConfig) GetNamingStrategy() schema.NamingStrategy {
+	return schema.NamingStrategy{TablePrefix: c.TablePrefix, SingularTable: c.SingularTable}
+}
+
 // GetRedisAddr 获取Redis地址
 func (c *RedisConfig) GetRedisAddr() string {
 	return fmt.Sprintf("%s:%d", c.Host, c.Port)
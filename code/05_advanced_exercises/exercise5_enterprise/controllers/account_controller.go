@@ -0,0 +1,38 @@
+package controllers
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"edu-platform/services"
+)
+
+// AccountController 账户控制器
+type AccountController struct {
+	deletionService *services.AccountDeletionService
+}
+
+// NewAccountController 创建账户控制器
+func NewAccountController(deletionService *services.AccountDeletionService) *AccountController {
+	return &AccountController{deletionService: deletionService}
+}
+
+// RequestDeletion 申请注销账户，申请后立即禁止登录，宽限期内可撤销
+func (ctrl *AccountController) RequestDeletion(c *gin.Context) {
+	request, err := ctrl.deletionService.RequestDeletion(c.GetUint("user_id"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	Success(c, request)
+}
+
+// CancelDeletion 撤销注销申请
+func (ctrl *AccountController) CancelDeletion(c *gin.Context) {
+	if err := ctrl.deletionService.CancelDeletion(c.GetUint("user_id")); err != nil {
+		c.Error(err)
+		return
+	}
+
+	Success(c, nil)
+}
@@ -0,0 +1,89 @@
+package controllers
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"edu-platform/services"
+)
+
+// AdminController 管理后台控制器
+type AdminController struct {
+	retentionService  *services.RetentionService
+	dataHealthService *services.DataHealthService
+}
+
+// NewAdminController 创建管理后台控制器
+func NewAdminController(retentionService *services.RetentionService, dataHealthService *services.DataHealthService) *AdminController {
+	return &AdminController{retentionService: retentionService, dataHealthService: dataHealthService}
+}
+
+// PurgeData 手动清理过期数据
+// dry_run=true时只统计将被删除的行数，不做修改
+func (ctrl *AdminController) PurgeData(c *gin.Context) {
+	var req struct {
+		Table         string `json:"table" binding:"required"`
+		OlderThanDays int    `json:"older_than_days" binding:"omitempty,min=1"`
+		BatchSize     int    `json:"batch_size" binding:"omitempty,min=1,max=10000"`
+		DryRun        bool   `json:"dry_run"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(services.ErrValidation.Wrap(err))
+		return
+	}
+
+	policy, err := ctrl.retentionService.Policy(req.Table)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	days := req.OlderThanDays
+	if days == 0 {
+		days = ctrl.retentionService.RetentionDays(policy)
+	}
+	olderThan := time.Duration(days) * 24 * time.Hour
+
+	var result services.PurgeResult
+	if req.DryRun {
+		result, err = ctrl.retentionService.Count(req.Table, olderThan)
+	} else {
+		result, err = ctrl.retentionService.Purge(c.Request.Context(), req.Table, olderThan, req.BatchSize)
+	}
+	if err != nil {
+		c.Error(services.ErrInternal.WithMsg("retention.purge_failed").Wrap(err))
+		return
+	}
+
+	Success(c, result)
+}
+
+// GetDataHealth 各数据表的未删除行数和软删除行数
+func (ctrl *AdminController) GetDataHealth(c *gin.Context) {
+	tables, err := ctrl.dataHealthService.TableCounts(c.Request.Context())
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	Success(c, tables)
+}
+
+// GetDanglingReferences 查找外键指向的记录已被删除的行，如 ?table=order_items&fk=course_id
+func (ctrl *AdminController) GetDanglingReferences(c *gin.Context) {
+	table, fk := c.Query("table"), c.Query("fk")
+	if table == "" || fk == "" {
+		c.Error(services.ErrValidation)
+		return
+	}
+
+	report, err := ctrl.dataHealthService.CheckDanglingReferencesByTable(c.Request.Context(), table, fk)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	Success(c, report)
+}
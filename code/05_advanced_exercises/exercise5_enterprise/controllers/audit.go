@@ -0,0 +1,70 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"edu-platform/models"
+	"edu-platform/services"
+)
+
+// AuditLog 操作日志中间件，登录用户的写请求（POST、PUT、PATCH、DELETE）处理完成后写入 system_logs
+// user_id 为请求身份的用户，actor_id 为实际操作人：管理员模拟登录时为管理员ID，否则与 user_id 相同；
+// 请求体和响应体可能包含密码等敏感信息，不记录。须注册在 ErrorHandler 之前，才能取到错误响应的状态码
+func AuditLog(auditService *services.AuditService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		switch c.Request.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		default:
+			return
+		}
+		userID := c.GetUint("user_id")
+		if userID == 0 {
+			return
+		}
+		actorID := userID
+		if adminID := c.GetUint("impersonator_id"); adminID != 0 {
+			actorID = adminID
+		}
+
+		action := c.FullPath()
+		if action == "" {
+			action = c.Request.URL.Path
+		}
+		entry := &models.SystemLog{
+			UserID:    &userID,
+			ActorID:   &actorID,
+			Action:    c.Request.Method + " " + action,
+			Module:    auditModule(c.Request.URL.Path),
+			Method:    c.Request.Method,
+			URL:       c.Request.URL.Path,
+			IP:        c.ClientIP(),
+			UserAgent: c.Request.UserAgent(),
+			Status:    c.Writer.Status(),
+			Duration:  time.Since(start).Milliseconds(),
+		}
+		if err := auditService.Record(entry); err != nil {
+			log.Printf("写入操作日志失败: %s %s: %v", c.Request.Method, c.Request.URL.Path, err)
+		}
+	}
+}
+
+// auditModule 操作日志的模块名，取 /api/v1/ 之后的第一段路径，如 courses、orders、admin
+func auditModule(path string) string {
+	path = strings.TrimPrefix(path, "/api/v1/")
+	module, _, _ := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+	if module == "" {
+		return "api"
+	}
+	if len(module) > 50 {
+		module = module[:50]
+	}
+	return module
+}
@@ -0,0 +1,56 @@
+package controllers
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"edu-platform/services"
+)
+
+// BundleController 课程包控制器
+type BundleController struct {
+	bundleService *services.BundleService
+}
+
+// NewBundleController 创建课程包控制器
+func NewBundleController(bundleService *services.BundleService) *BundleController {
+	return &BundleController{bundleService: bundleService}
+}
+
+// GetBundles 获取课程包列表
+func (ctrl *BundleController) GetBundles(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	bundles, total, err := ctrl.bundleService.GetBundles(page, pageSize)
+	if err != nil {
+		c.Error(services.ErrInternal.WithMsg("error.query_failed").Wrap(err))
+		return
+	}
+
+	SetPaginationHeaders(c, page, pageSize, total)
+	Success(c, PageResponse{
+		List:     bundles,
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+	})
+}
+
+// GetBundle 获取课程包详情
+func (ctrl *BundleController) GetBundle(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(services.ErrValidation)
+		return
+	}
+
+	bundle, err := ctrl.bundleService.GetBundleByID(uint(id))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	Success(c, bundle)
+}
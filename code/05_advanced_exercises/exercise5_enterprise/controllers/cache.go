@@ -0,0 +1,27 @@
+package controllers
+
+import (
+	"github.com/redis/go-redis/v9"
+
+	"edu-platform/config"
+	"edu-platform/services"
+)
+
+// newCache 按配置创建缓存：启用Redis时使用Redis，连接失败时降级为不缓存；未启用时使用进程内缓存
+func newCache(redisCfg config.RedisConfig) services.Cache {
+	if !redisCfg.Enabled {
+		return services.NewMemoryCache()
+	}
+	client := redis.NewClient(&redis.Options{
+		Addr:            redisCfg.GetRedisAddr(),
+		Password:        redisCfg.Password,
+		DB:              redisCfg.DB,
+		PoolSize:        redisCfg.PoolSize,
+		MinIdleConns:    redisCfg.MinIdleConns,
+		DialTimeout:     redisCfg.DialTimeout,
+		ReadTimeout:     redisCfg.ReadTimeout,
+		WriteTimeout:    redisCfg.WriteTimeout,
+		ConnMaxIdleTime: redisCfg.IdleTimeout,
+	})
+	return services.NewDegradingCache(services.NewRedisCache(client))
+}
@@ -0,0 +1,39 @@
+package controllers
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"edu-platform/services"
+)
+
+// CertificateController 结业证书控制器
+type CertificateController struct {
+	certificateService *services.CertificateService
+}
+
+// NewCertificateController 创建结业证书控制器
+func NewCertificateController(certificateService *services.CertificateService) *CertificateController {
+	return &CertificateController{certificateService: certificateService}
+}
+
+// GetMyCertificates 当前用户的结业证书
+func (ctrl *CertificateController) GetMyCertificates(c *gin.Context) {
+	certificates, err := ctrl.certificateService.GetUserCertificates(c.GetUint("user_id"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	Success(c, certificates)
+}
+
+// VerifyCertificate 按编号验证证书，不需要登录
+func (ctrl *CertificateController) VerifyCertificate(c *gin.Context) {
+	certificate, err := ctrl.certificateService.VerifyCertificate(c.Param("serial"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	Success(c, certificate)
+}
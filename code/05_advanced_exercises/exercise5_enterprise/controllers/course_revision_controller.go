@@ -0,0 +1,93 @@
+package controllers
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"edu-platform/services"
+)
+
+// CourseRevisionController 课程大纲修订控制器
+type CourseRevisionController struct {
+	revisionService *services.CourseRevisionService
+}
+
+// NewCourseRevisionController 创建课程大纲修订控制器
+func NewCourseRevisionController(revisionService *services.CourseRevisionService) *CourseRevisionController {
+	return &CourseRevisionController{revisionService: revisionService}
+}
+
+// GetOutline 获取课程线上大纲
+func (ctrl *CourseRevisionController) GetOutline(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(services.ErrValidation)
+		return
+	}
+
+	outline, err := ctrl.revisionService.GetOutline(uint(id))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	Success(c, outline)
+}
+
+// SaveOutline 保存课程大纲，已发布的课程保存为草稿修订
+func (ctrl *CourseRevisionController) SaveOutline(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(services.ErrValidation)
+		return
+	}
+
+	var outline services.CourseOutline
+	if err := c.ShouldBindJSON(&outline); err != nil {
+		c.Error(services.ErrValidation.Wrap(err))
+		return
+	}
+
+	revision, err := ctrl.revisionService.SaveOutline(uint(id), c.GetUint("user_id"), &outline)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	Success(c, gin.H{"revision": revision})
+}
+
+// DiffDraft 查看草稿修订与线上大纲的差异
+func (ctrl *CourseRevisionController) DiffDraft(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(services.ErrValidation)
+		return
+	}
+
+	changes, err := ctrl.revisionService.DiffDraft(uint(id), c.GetUint("user_id"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	Success(c, changes)
+}
+
+// PublishRevision 发布草稿修订
+func (ctrl *CourseRevisionController) PublishRevision(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(services.ErrValidation)
+		return
+	}
+
+	revision, err := ctrl.revisionService.PublishRevision(uint(id), c.GetUint("user_id"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	Success(c, revision)
+}
@@ -0,0 +1,157 @@
+package controllers
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"edu-platform/services"
+)
+
+// DiscussionController 课程讨论控制器
+type DiscussionController struct {
+	discussionService *services.DiscussionService
+}
+
+// NewDiscussionController 创建课程讨论控制器
+func NewDiscussionController(discussionService *services.DiscussionService) *DiscussionController {
+	return &DiscussionController{discussionService: discussionService}
+}
+
+// GetThreads 获取课程的讨论主题列表
+func (ctrl *DiscussionController) GetThreads(c *gin.Context) {
+	courseID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(services.ErrValidation)
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	threads, total, err := ctrl.discussionService.GetThreads(uint(courseID), page, pageSize)
+	if err != nil {
+		c.Error(services.ErrInternal.WithMsg("error.query_failed").Wrap(err))
+		return
+	}
+
+	SetPaginationHeaders(c, page, pageSize, total)
+	Success(c, PageResponse{
+		List:     threads,
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+	})
+}
+
+// CreateThread 发布讨论主题
+func (ctrl *DiscussionController) CreateThread(c *gin.Context) {
+	courseID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(services.ErrValidation)
+		return
+	}
+
+	var req struct {
+		Title string `json:"title" binding:"required,max=255"`
+		Body  string `json:"body" binding:"required,max=5000"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(services.ErrValidation.Wrap(err))
+		return
+	}
+
+	thread, err := ctrl.discussionService.CreateThread(uint(courseID), c.GetUint("user_id"), req.Title, req.Body)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	Success(c, thread)
+}
+
+// GetReplies 获取主题的回复列表
+func (ctrl *DiscussionController) GetReplies(c *gin.Context) {
+	courseID, threadID, ok := threadParams(c)
+	if !ok {
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	replies, total, err := ctrl.discussionService.GetReplies(courseID, threadID, page, pageSize)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	SetPaginationHeaders(c, page, pageSize, total)
+	Success(c, PageResponse{
+		List:     replies,
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+	})
+}
+
+// Reply 回复讨论主题，讲师回复时可传 resolve=true 将主题标记为已解决
+func (ctrl *DiscussionController) Reply(c *gin.Context) {
+	courseID, threadID, ok := threadParams(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Body    string `json:"body" binding:"required,max=5000"`
+		Resolve bool   `json:"resolve"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(services.ErrValidation.Wrap(err))
+		return
+	}
+
+	reply, err := ctrl.discussionService.Reply(courseID, threadID, c.GetUint("user_id"), req.Body, req.Resolve)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	Success(c, reply)
+}
+
+// DeleteReply 删除回复
+func (ctrl *DiscussionController) DeleteReply(c *gin.Context) {
+	courseID, threadID, ok := threadParams(c)
+	if !ok {
+		return
+	}
+
+	replyID, err := strconv.ParseUint(c.Param("reply_id"), 10, 32)
+	if err != nil {
+		c.Error(services.ErrValidation)
+		return
+	}
+
+	if err := ctrl.discussionService.DeleteReply(courseID, threadID, uint(replyID), c.GetUint("user_id")); err != nil {
+		c.Error(err)
+		return
+	}
+
+	Success(c, nil)
+}
+
+// threadParams 解析路径中的课程ID和主题ID，解析失败时已写入错误
+func threadParams(c *gin.Context) (courseID, threadID uint, ok bool) {
+	cid, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(services.ErrValidation)
+		return 0, 0, false
+	}
+	tid, err := strconv.ParseUint(c.Param("thread_id"), 10, 32)
+	if err != nil {
+		c.Error(services.ErrValidation)
+		return 0, 0, false
+	}
+	return uint(cid), uint(tid), true
+}
@@ -0,0 +1,68 @@
+package controllers
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"edu-platform/services"
+)
+
+// EmailController 邮件模板和邮件记录控制器（管理员）
+type EmailController struct {
+	emailService *services.EmailService
+}
+
+// NewEmailController 创建邮件控制器
+func NewEmailController(emailService *services.EmailService) *EmailController {
+	return &EmailController{emailService: emailService}
+}
+
+// GetTemplates 获取所有邮件模板
+func (ctrl *EmailController) GetTemplates(c *gin.Context) {
+	templates, err := ctrl.emailService.GetTemplates()
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	Success(c, templates)
+}
+
+// SaveTemplate 创建或修改邮件模板，模板引用不存在的字段时返回参数错误
+func (ctrl *EmailController) SaveTemplate(c *gin.Context) {
+	var req services.EmailTemplateInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(services.ErrValidation.Wrap(err))
+		return
+	}
+
+	tmpl, err := ctrl.emailService.SaveTemplate(c.Param("key"), req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	Success(c, tmpl)
+}
+
+// GetEmailLogs 获取邮件记录，可按状态筛选（1-待发送,2-已发送,3-发送失败）
+func (ctrl *EmailController) GetEmailLogs(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	status, _ := strconv.Atoi(c.Query("status"))
+
+	emails, total, err := ctrl.emailService.GetEmailLogs(int8(status), page, pageSize)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	SetPaginationHeaders(c, page, pageSize, total)
+	Success(c, PageResponse{
+		List:     emails,
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+	})
+}
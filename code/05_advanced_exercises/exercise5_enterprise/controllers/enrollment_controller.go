@@ -0,0 +1,46 @@
+package controllers
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"edu-platform/services"
+)
+
+// EnrollmentController 选课控制器
+type EnrollmentController struct {
+	enrollmentService *services.EnrollmentService
+}
+
+// NewEnrollmentController 创建选课控制器
+func NewEnrollmentController(enrollmentService *services.EnrollmentService) *EnrollmentController {
+	return &EnrollmentController{enrollmentService: enrollmentService}
+}
+
+// BulkEnroll 按邮箱为企业员工批量开通课程（管理员）
+// strict=true时有未知邮箱则整批不开通
+func (ctrl *EnrollmentController) BulkEnroll(c *gin.Context) {
+	courseID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(services.ErrValidation)
+		return
+	}
+
+	var req struct {
+		Emails []string `json:"emails" binding:"required"`
+		Strict bool     `json:"strict"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(services.ErrValidation.Wrap(err))
+		return
+	}
+
+	result, err := ctrl.enrollmentService.BulkEnroll(uint(courseID), req.Emails, req.Strict)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	Success(c, result)
+}
@@ -0,0 +1,51 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+
+	"github.com/gin-gonic/gin"
+
+	"edu-platform/i18n"
+	"edu-platform/services"
+)
+
+// ErrorHandler 统一错误处理中间件
+// 处理器通过c.Error()返回错误，这里将AppError转换为对应的HTTP状态码和响应体；
+// 非AppError的错误一律按服务器内部错误处理，避免泄露底层错误信息。
+// 响应信息按请求语言从消息目录翻译，错误码保持不变
+func ErrorHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+
+		err := c.Errors.Last().Err
+		var appErr *services.AppError
+		if !errors.As(err, &appErr) {
+			appErr = services.ErrInternal.Wrap(err)
+		}
+
+		if appErr.HTTPStatus >= 500 {
+			log.Printf("[ERROR] %s %s: %v", c.Request.Method, c.Request.URL.Path, appErr)
+		}
+
+		message := appErr.Message
+		if appErr.MsgID != "" {
+			message = i18n.T(c, appErr.MsgID, appErr.Args...)
+		}
+		// 参数校验错误附加逐个字段的提示
+		if detail := i18n.TranslateValidation(c, appErr.Err); detail != "" {
+			message += ": " + detail
+		}
+
+		c.JSON(appErr.HTTPStatus, Response{
+			Code:    appErr.Code,
+			Message: message,
+			Data:    appErr.Details,
+			Debug:   takeDebugInfo(c),
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"edu-platform/services"
+)
+
+// TestErrorHandlerEnvelope 处理器返回的错误转换为HTTP状态码和统一响应：AppError按自身的状态码和错误码返回，
+// 被包装的AppError同样识别；其他错误一律按500返回，不泄露底层错误信息
+func TestErrorHandlerEnvelope(t *testing.T) {
+	t.Parallel()
+	gin.SetMode(gin.TestMode)
+
+	cases := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantCode   int
+		wantMsg    string
+		wantData   interface{}
+	}{
+		{"预定义错误", services.ErrNotFound, http.StatusNotFound, services.CodeNotFound, "资源不存在", nil},
+		{"替换消息", services.ErrConflict.WithMsg("error.invalid_param", "status"), http.StatusConflict, services.CodeConflict, "参数status的值无效", nil},
+		{"固定提示", services.ErrForbidden.WithMessage("只有讲师可以操作"), http.StatusForbidden, services.CodeForbidden, "只有讲师可以操作", nil},
+		{
+			"附加数据", services.ErrCourseFull.WithDetails(map[string]interface{}{"course_id": 3, "waitlist": true}),
+			http.StatusConflict, services.CodeCourseFull, "",
+			map[string]interface{}{"course_id": float64(3), "waitlist": true},
+		},
+		{"被包装的AppError", fmt.Errorf("购买失败: %w", services.ErrTooManyRequests), http.StatusTooManyRequests, services.CodeTooManyRequests, "请求过于频繁", nil},
+		{"带底层错误", services.ErrInternal.Wrap(errors.New("connection refused")), http.StatusInternalServerError, services.CodeInternal, "服务器内部错误", nil},
+		{"普通错误", errors.New("connection refused"), http.StatusInternalServerError, services.CodeInternal, "服务器内部错误", nil},
+	}
+	for _, tc := range cases {
+		r := gin.New()
+		r.Use(ErrorHandler())
+		err := tc.err
+		r.GET("/", func(c *gin.Context) { c.Error(err) })
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		var body struct {
+			Code    int         `json:"code"`
+			Message string      `json:"message"`
+			Data    interface{} `json:"data"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatalf("%s: 解析响应失败: %v\n%s", tc.name, err, w.Body.String())
+		}
+		if w.Code != tc.wantStatus || body.Code != tc.wantCode {
+			t.Errorf("%s: 返回 %d、code=%d，期望 %d、code=%d", tc.name, w.Code, body.Code, tc.wantStatus, tc.wantCode)
+		}
+		if tc.wantMsg != "" && body.Message != tc.wantMsg {
+			t.Errorf("%s: message为 %q，期望 %q", tc.name, body.Message, tc.wantMsg)
+		}
+		if strings.Contains(w.Body.String(), "connection refused") {
+			t.Errorf("%s: 响应泄露了底层错误: %s", tc.name, w.Body.String())
+		}
+		if !reflect.DeepEqual(body.Data, tc.wantData) {
+			t.Errorf("%s: data为 %v，期望 %v", tc.name, body.Data, tc.wantData)
+		}
+	}
+}
+
+// TestErrorHandlerKeepsWrittenResponse 处理器已经写出响应时不再覆盖
+func TestErrorHandlerKeepsWrittenResponse(t *testing.T) {
+	t.Parallel()
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.Use(ErrorHandler())
+	r.GET("/", func(c *gin.Context) {
+		c.String(http.StatusAccepted, "queued")
+		c.Error(services.ErrInternal)
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusAccepted || w.Body.String() != "queued" {
+		t.Errorf("返回 %d %q，期望保持处理器写出的 202 queued", w.Code, w.Body.String())
+	}
+}
+
+// TestAppErrorIs 派生的错误与预定义错误按错误码比较，Wrap后仍能取到底层错误
+func TestAppErrorIs(t *testing.T) {
+	t.Parallel()
+	cause := errors.New("record not found")
+	err := error(services.ErrNotFound.WithMsg("error.invalid_param", "id").Wrap(cause))
+
+	if !errors.Is(err, services.ErrNotFound) {
+		t.Error("派生的错误应与 ErrNotFound 相同")
+	}
+	if errors.Is(err, services.ErrConflict) {
+		t.Error("错误码不同时不应相同")
+	}
+	if !errors.Is(err, cause) {
+		t.Error("应能通过 errors.Is 取到底层错误")
+	}
+	if services.ErrNotFound.Err != nil || services.ErrNotFound.MsgID != "error.not_found" {
+		t.Error("WithMsg/Wrap 不应修改预定义错误")
+	}
+}
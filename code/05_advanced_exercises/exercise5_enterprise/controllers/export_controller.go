@@ -0,0 +1,54 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"edu-platform/services"
+)
+
+// ExportController 用户数据导出控制器
+type ExportController struct {
+	jobs *services.ExportJobManager
+}
+
+// NewExportController 创建用户数据导出控制器
+func NewExportController(jobs *services.ExportJobManager) *ExportController {
+	return &ExportController{jobs: jobs}
+}
+
+// RequestExport 发起个人数据导出，返回任务ID供轮询
+func (ctrl *ExportController) RequestExport(c *gin.Context) {
+	job, err := ctrl.jobs.Enqueue(c.GetUint("user_id"))
+	if err != nil {
+		c.Error(services.ErrInternal.WithMsg("export.failed").Wrap(err))
+		return
+	}
+
+	c.JSON(http.StatusAccepted, Response{
+		Code:    200,
+		Message: "success",
+		Data:    job,
+	})
+}
+
+// GetExport 查询导出任务，任务完成时直接下载导出文件
+func (ctrl *ExportController) GetExport(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	job, err := ctrl.jobs.Get(c.Param("job_id"), userID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	switch job.Status {
+	case services.ExportJobCompleted:
+		c.FileAttachment(job.FilePath, fmt.Sprintf("user-%d-export.json", userID))
+	case services.ExportJobFailed:
+		c.Error(services.ErrInternal.WithMsg("export.failed"))
+	default:
+		Success(c, job)
+	}
+}
@@ -0,0 +1,47 @@
+package controllers
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"edu-platform/services"
+)
+
+// FinanceController 财务控制器
+type FinanceController struct {
+	financeService *services.FinanceService
+}
+
+// NewFinanceController 创建财务控制器
+func NewFinanceController(financeService *services.FinanceService) *FinanceController {
+	return &FinanceController{financeService: financeService}
+}
+
+// ExportPayoutStatement 下载讲师某月的结算单CSV（管理员），默认上个月
+func (ctrl *FinanceController) ExportPayoutStatement(c *gin.Context) {
+	instructorID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(services.ErrValidation)
+		return
+	}
+	month := c.DefaultQuery("month", time.Now().AddDate(0, -1, 0).Format("2006-01"))
+	start, err := time.ParseInLocation("2006-01", month, time.Local)
+	if err != nil {
+		c.Error(services.ErrValidation.WithMsg("finance.invalid_month", month))
+		return
+	}
+
+	// 先在内存中生成，出错时仍能返回统一的错误响应
+	var buf bytes.Buffer
+	if err := ctrl.financeService.ExportPayoutStatement(uint(instructorID), start.Month(), start.Year(), &buf); err != nil {
+		c.Error(err)
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="payout-%d-%s.csv"`, instructorID, start.Format("200601")))
+	c.Data(http.StatusOK, "text/csv; charset=utf-8", buf.Bytes())
+}
@@ -0,0 +1,78 @@
+package controllers
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"edu-platform/models"
+	"edu-platform/services"
+)
+
+// ImpersonationController 管理员模拟登录控制器，客服排查问题时以用户的身份查看页面
+type ImpersonationController struct {
+	userService *services.UserService
+	ttl         time.Duration
+	jwtSecret   string
+}
+
+// NewImpersonationController 创建模拟登录控制器，ttl为模拟登录token的有效期，最长30分钟；jwtSecret为token签名密钥
+func NewImpersonationController(userService *services.UserService, ttl time.Duration, jwtSecret string) *ImpersonationController {
+	return &ImpersonationController{userService: userService, ttl: services.ImpersonationTTL(ttl), jwtSecret: jwtSecret}
+}
+
+// Impersonate 管理员模拟登录用户，返回以该用户身份访问的短期token
+// 模拟期间的写操作在操作日志中记录管理员为实际操作人，注销账户、修改密码等接口不可用
+func (ctrl *ImpersonationController) Impersonate(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("user_id"), 10, 32)
+	if err != nil {
+		c.Error(services.ErrValidation)
+		return
+	}
+
+	imp, err := ctrl.userService.WithContext(c.Request.Context()).StartImpersonation(c.GetUint("user_id"), uint(userID), ctrl.ttl, time.Now())
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	token, err := issueImpersonationToken(imp, ctrl.jwtSecret)
+	if err != nil {
+		c.Error(services.ErrInternal.Wrap(err))
+		return
+	}
+
+	Success(c, gin.H{
+		"token":           token,
+		"expires_at":      imp.ExpiresAt,
+		"user":            imp.User,
+		"impersonated_by": imp.AdminID,
+	})
+}
+
+// meResponse 当前用户信息，impersonated_by 为模拟登录的管理员，普通登录时为null
+type meResponse struct {
+	*models.User
+	ImpersonatedBy *services.Impersonator `json:"impersonated_by"`
+}
+
+// GetMe 当前用户信息，模拟登录时返回 impersonated_by，前端据此显示模拟登录提示条
+func (ctrl *UserController) GetMe(c *gin.Context) {
+	user, err := ctrl.userService.WithContext(c.Request.Context()).GetUserByID(c.GetUint("user_id"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	user.Password = ""
+
+	resp := meResponse{User: user}
+	if adminID := c.GetUint("impersonator_id"); adminID != 0 {
+		if resp.ImpersonatedBy, err = ctrl.userService.WithContext(c.Request.Context()).GetImpersonator(adminID); err != nil {
+			c.Error(err)
+			return
+		}
+	}
+
+	Success(c, resp)
+}
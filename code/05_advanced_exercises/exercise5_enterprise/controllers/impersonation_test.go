@@ -0,0 +1,122 @@
+package controllers
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+
+	"edu-platform/models"
+	"edu-platform/services"
+	"edu-platform/testhelpers/testdb"
+)
+
+const testSecret = "test-secret"
+
+// TestParseImpersonationTokenSignature 签名覆盖token的全部字段：改动任一字段、换密钥签名或未配置密钥时都不能解析
+func TestParseImpersonationTokenSignature(t *testing.T) {
+	t.Parallel()
+	token := mustIssue(t, 2, 1, 3, time.Now().Add(10*time.Minute))
+
+	claims, ok := parseImpersonationToken(token, testSecret)
+	if !ok || claims.UserID != 2 || claims.ImpersonatorID != 1 || claims.Version != 3 {
+		t.Fatalf("解析有效token得到 %+v, %v，期望 user=2 impersonator=1 version=3", claims, ok)
+	}
+
+	sep := strings.LastIndex(token, "_")
+	unsigned, signature := token[:sep], token[sep+1:]
+	cases := map[string]struct {
+		token  string
+		secret string
+	}{
+		// 保留原签名，把被模拟的用户换成管理员自己
+		"tampered_user":      {strings.Replace(unsigned, "imp_token_2_", "imp_token_1_", 1) + "_" + signature, testSecret},
+		"tampered_version":   {strings.Replace(unsigned, "_1_3_", "_1_4_", 1) + "_" + signature, testSecret},
+		"tampered_expiry":    {unsigned[:strings.LastIndex(unsigned, "_")+1] + "9999999999_" + signature, testSecret},
+		"tampered_signature": {unsigned + "_" + strings.Repeat("0", len(signature)), testSecret},
+		"missing_signature":  {unsigned, testSecret},
+		"wrong_secret":       {token, "other-secret"},
+		"no_secret":          {token, ""},
+	}
+	for name, tc := range cases {
+		if claims, ok := parseImpersonationToken(tc.token, tc.secret); ok {
+			t.Errorf("%s: 解析得到 %+v，期望无效", name, claims)
+		}
+	}
+}
+
+// TestVerifyImpersonationTokenExpiry 签名正确的模拟登录token，过期或过期时间超过30分钟后都无效
+func TestVerifyImpersonationTokenExpiry(t *testing.T) {
+	t.Parallel()
+	db := testdb.New(t)
+	admin := createUser(t, db, "admin")
+	student := createUser(t, db, "student")
+	users := services.NewUserService(db)
+
+	now := time.Now()
+	cases := []struct {
+		name      string
+		expiresAt time.Time
+		valid     bool
+	}{
+		{"valid", now.Add(10 * time.Minute), true},
+		{"max_ttl", now.Add(services.MaxImpersonationTTL - time.Minute), true},
+		{"expired", now.Add(-time.Second), false},
+		{"ttl_too_long", now.Add(services.MaxImpersonationTTL + time.Minute), false},
+		{"ttl_far_future", now.Add(24 * time.Hour), false},
+	}
+	for _, tc := range cases {
+		token := mustIssue(t, student.ID, admin.ID, admin.TokenVersion, tc.expiresAt)
+		claims, ok := verifyAuthToken(users, testSecret, "Bearer "+token)
+		if ok != tc.valid {
+			t.Errorf("%s: 校验结果为 %v，期望 %v", tc.name, ok, tc.valid)
+		}
+		if ok && (claims.UserID != student.ID || claims.ImpersonatorID != admin.ID) {
+			t.Errorf("%s: 解析得到 %+v，期望 user=%d impersonator=%d", tc.name, claims, student.ID, admin.ID)
+		}
+	}
+
+	// 签发者不是管理员时，签名和有效期正确也无效
+	token := mustIssue(t, admin.ID, student.ID, student.TokenVersion, now.Add(10*time.Minute))
+	if _, ok := verifyAuthToken(users, testSecret, token); ok {
+		t.Error("非管理员签发的模拟登录token校验通过，期望无效")
+	}
+}
+
+// mustIssue 签发模拟登录token
+func mustIssue(t *testing.T, userID, adminID, adminVersion uint, expiresAt time.Time) string {
+	t.Helper()
+	token, err := issueImpersonationToken(&services.Impersonation{
+		User:         &models.User{BaseModel: models.BaseModel{ID: userID}},
+		AdminID:      adminID,
+		AdminVersion: adminVersion,
+		ExpiresAt:    expiresAt,
+	}, testSecret)
+	if err != nil {
+		t.Fatalf("签发模拟登录token失败: %v", err)
+	}
+	return token
+}
+
+// createUser 创建指定角色的用户
+func createUser(t *testing.T, db *gorm.DB, role string) *models.User {
+	t.Helper()
+	r := models.Role{Name: role}
+	if err := db.Where("name = ?", role).FirstOrCreate(&r).Error; err != nil {
+		t.Fatalf("创建角色失败: %v", err)
+	}
+	user := &models.User{
+		Username: role,
+		Email:    role + "@example.test",
+		Phone:    fmt.Sprintf("139%08d", r.ID),
+		Password: "secret123",
+		Status:   models.UserStatusActive,
+		RoleID:   r.ID,
+	}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatalf("创建用户失败: %v", err)
+	}
+	return user
+}
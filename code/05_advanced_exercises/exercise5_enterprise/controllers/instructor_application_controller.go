@@ -0,0 +1,112 @@
+package controllers
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"edu-platform/services"
+)
+
+// InstructorApplicationController 讲师申请控制器
+type InstructorApplicationController struct {
+	applicationService *services.InstructorApplicationService
+}
+
+// NewInstructorApplicationController 创建讲师申请控制器
+func NewInstructorApplicationController(applicationService *services.InstructorApplicationService) *InstructorApplicationController {
+	return &InstructorApplicationController{applicationService: applicationService}
+}
+
+// Submit 提交讲师申请
+func (ctrl *InstructorApplicationController) Submit(c *gin.Context) {
+	var req struct {
+		Bio            string `json:"bio" binding:"required,max=500"`
+		Qualifications string `json:"qualifications" binding:"required,max=2000"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(services.ErrValidation.Wrap(err))
+		return
+	}
+
+	application, err := ctrl.applicationService.Submit(c.GetUint("user_id"), req.Bio, req.Qualifications)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	Success(c, application)
+}
+
+// GetMine 查看自己最近一次申请的审核状态
+func (ctrl *InstructorApplicationController) GetMine(c *gin.Context) {
+	application, err := ctrl.applicationService.GetLatest(c.GetUint("user_id"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	Success(c, application)
+}
+
+// GetApplications 获取讲师申请列表（管理员），默认只返回待审核的申请
+func (ctrl *InstructorApplicationController) GetApplications(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	status, _ := strconv.Atoi(c.DefaultQuery("status", "1"))
+
+	applications, total, err := ctrl.applicationService.GetApplications(int8(status), page, pageSize)
+	if err != nil {
+		c.Error(services.ErrInternal.WithMsg("error.query_failed").Wrap(err))
+		return
+	}
+
+	SetPaginationHeaders(c, page, pageSize, total)
+	Success(c, PageResponse{
+		List:     applications,
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+	})
+}
+
+// Approve 通过讲师申请
+func (ctrl *InstructorApplicationController) Approve(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(services.ErrValidation)
+		return
+	}
+
+	if err := ctrl.applicationService.Approve(uint(id), c.GetUint("user_id")); err != nil {
+		c.Error(err)
+		return
+	}
+
+	Success(c, nil)
+}
+
+// Reject 拒绝讲师申请
+func (ctrl *InstructorApplicationController) Reject(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(services.ErrValidation)
+		return
+	}
+
+	var req struct {
+		Reason string `json:"reason" binding:"required,max=500"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(services.ErrValidation.Wrap(err))
+		return
+	}
+
+	if err := ctrl.applicationService.Reject(uint(id), c.GetUint("user_id"), req.Reason); err != nil {
+		c.Error(err)
+		return
+	}
+
+	Success(c, nil)
+}
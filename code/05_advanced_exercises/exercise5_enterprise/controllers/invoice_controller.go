@@ -0,0 +1,52 @@
+package controllers
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"edu-platform/services"
+)
+
+// InvoiceController 发票控制器
+type InvoiceController struct {
+	invoiceService *services.InvoiceService
+}
+
+// NewInvoiceController 创建发票控制器
+func NewInvoiceController(invoiceService *services.InvoiceService) *InvoiceController {
+	return &InvoiceController{invoiceService: invoiceService}
+}
+
+// GetOrderInvoice 获取订单的发票
+func (ctrl *InvoiceController) GetOrderInvoice(c *gin.Context) {
+	invoice, err := ctrl.invoiceService.GetOrderInvoice(c.Param("order_no"), c.GetUint("user_id"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	Success(c, invoice)
+}
+
+// GetInvoices 按月份获取发票列表（管理员），默认当月
+func (ctrl *InvoiceController) GetInvoices(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	month := c.DefaultQuery("month", time.Now().Format("2006-01"))
+
+	invoices, total, err := ctrl.invoiceService.GetInvoices(month, page, pageSize)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	SetPaginationHeaders(c, page, pageSize, total)
+	Success(c, PageResponse{
+		List:     invoices,
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+	})
+}
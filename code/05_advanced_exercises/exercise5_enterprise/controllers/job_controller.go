@@ -0,0 +1,39 @@
+package controllers
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"edu-platform/services"
+)
+
+// JobController 后台任务管理控制器（管理员）
+type JobController struct {
+	jobRunner *services.JobRunner
+}
+
+// NewJobController 创建后台任务管理控制器
+func NewJobController(jobRunner *services.JobRunner) *JobController {
+	return &JobController{jobRunner: jobRunner}
+}
+
+// GetJobs 所有后台任务的执行计划和最近一次执行记录
+func (ctrl *JobController) GetJobs(c *gin.Context) {
+	statuses, err := ctrl.jobRunner.Statuses()
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	Success(c, statuses)
+}
+
+// RunJob 手动触发后台任务，任务在后台执行，返回本次执行记录
+func (ctrl *JobController) RunJob(c *gin.Context) {
+	run, err := ctrl.jobRunner.RunNow(c.Param("name"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	Success(c, run)
+}
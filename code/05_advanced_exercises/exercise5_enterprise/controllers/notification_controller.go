@@ -0,0 +1,121 @@
+package controllers
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"edu-platform/services"
+)
+
+// unreadPushInterval 推送未读数时读取缓存的间隔
+const unreadPushInterval = 5 * time.Second
+
+// NotificationController 站内通知控制器
+type NotificationController struct {
+	notificationService *services.NotificationService
+}
+
+// NewNotificationController 创建站内通知控制器
+func NewNotificationController(notificationService *services.NotificationService) *NotificationController {
+	return &NotificationController{notificationService: notificationService}
+}
+
+// notificationIDsRequest 批量操作的通知ID
+type notificationIDsRequest struct {
+	IDs []uint `json:"ids" binding:"required"`
+}
+
+// MarkRead 将指定通知标记为已读，返回实际标记的数量和最新的未读数
+func (ctrl *NotificationController) MarkRead(c *gin.Context) {
+	var req notificationIDsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(services.ErrValidation.Wrap(err))
+		return
+	}
+
+	userID := c.GetUint("user_id")
+	marked, err := ctrl.notificationService.MarkRead(userID, req.IDs)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	ctrl.respondUnread(c, userID, gin.H{"marked": marked})
+}
+
+// MarkAllAsRead 将全部未读通知标记为已读
+func (ctrl *NotificationController) MarkAllAsRead(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	marked, err := ctrl.notificationService.MarkAllAsRead(userID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	ctrl.respondUnread(c, userID, gin.H{"marked": marked})
+}
+
+// DeleteNotifications 删除指定通知
+func (ctrl *NotificationController) DeleteNotifications(c *gin.Context) {
+	var req notificationIDsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(services.ErrValidation.Wrap(err))
+		return
+	}
+
+	userID := c.GetUint("user_id")
+	deleted, err := ctrl.notificationService.DeleteNotifications(userID, req.IDs)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	ctrl.respondUnread(c, userID, gin.H{"deleted": deleted})
+}
+
+// GetUnreadCount 获取未读通知数（角标）
+func (ctrl *NotificationController) GetUnreadCount(c *gin.Context) {
+	ctrl.respondUnread(c, c.GetUint("user_id"), gin.H{})
+}
+
+// StreamUnreadCount 以SSE推送未读通知数，连接建立时推送一次，之后有变化时推送 unread 事件
+func (ctrl *NotificationController) StreamUnreadCount(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+
+	err := ctrl.notificationService.WatchUnreadCount(c.Request.Context(), userID, unreadPushInterval, func(count int64) bool {
+		c.SSEvent("unread", gin.H{"unread": count})
+		c.Writer.Flush()
+		return true
+	})
+	if err != nil && !c.Writer.Written() {
+		c.Error(err)
+	}
+}
+
+// ReconcileUnreadCount 按通知表重新计算用户的未读数（管理员）
+func (ctrl *NotificationController) ReconcileUnreadCount(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(services.ErrValidation)
+		return
+	}
+
+	unread, err := ctrl.notificationService.ReconcileUnreadCount(uint(userID))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	Success(c, gin.H{"unread": unread})
+}
+
+// respondUnread 在响应中附带最新的未读数
+func (ctrl *NotificationController) respondUnread(c *gin.Context, userID uint, data gin.H) {
+	unread, err := ctrl.notificationService.GetUnreadCount(userID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	data["unread"] = unread
+	Success(c, data)
+}
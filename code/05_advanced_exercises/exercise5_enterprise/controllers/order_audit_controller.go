@@ -0,0 +1,64 @@
+package controllers
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"edu-platform/models"
+	"edu-platform/services"
+)
+
+// OrderAuditController 订单金额一致性检查控制器（管理员）
+type OrderAuditController struct {
+	orderAuditService *services.OrderAuditService
+}
+
+// NewOrderAuditController 创建订单金额一致性检查控制器
+func NewOrderAuditController(orderAuditService *services.OrderAuditService) *OrderAuditController {
+	return &OrderAuditController{orderAuditService: orderAuditService}
+}
+
+// GetMismatchedOrders 总额与订单项合计不一致的订单
+func (ctrl *OrderAuditController) GetMismatchedOrders(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "100"))
+
+	mismatches, err := ctrl.orderAuditService.FindMismatchedOrders(limit)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	Success(c, mismatches)
+}
+
+// RepairOrder 修复订单金额，已付款的订单须传 confirm: true
+func (ctrl *OrderAuditController) RepairOrder(c *gin.Context) {
+	orderID, err := models.ParseOrderID(c.Param("id"))
+	if err != nil {
+		c.Error(services.ErrValidation)
+		return
+	}
+
+	var req struct {
+		Strategy services.OrderRepairStrategy `json:"strategy" binding:"required"`
+		Confirm  bool                         `json:"confirm"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(services.ErrValidation.Wrap(err))
+		return
+	}
+
+	userID := c.GetUint("user_id")
+	actorID := userID
+	if adminID := c.GetUint("impersonator_id"); adminID != 0 {
+		actorID = adminID
+	}
+	repair, err := ctrl.orderAuditService.RepairOrder(orderID, req.Strategy, req.Confirm, userID, actorID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	Success(c, repair)
+}
@@ -0,0 +1,83 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"edu-platform/models"
+	"edu-platform/services"
+)
+
+// TestParseOrderSearchParams 订单列表筛选参数的解析：状态接受名称和数字，只有日期的上限取次日零点，
+// 取值无效时返回422，Details中的field为参数名
+func TestParseOrderSearchParams(t *testing.T) {
+	t.Parallel()
+	gin.SetMode(gin.TestMode)
+
+	day := func(y int, m time.Month, d int) *time.Time {
+		v := time.Date(y, m, d, 0, 0, 0, 0, time.Local)
+		return &v
+	}
+	amount := func(v int64) *int64 { return &v }
+	rfc3339 := time.Date(2024, 6, 1, 8, 30, 0, 0, time.UTC)
+
+	cases := []struct {
+		query     string
+		want      services.OrderSearchParams
+		wantField string // 非空表示期望该参数无效
+	}{
+		{query: "", want: services.OrderSearchParams{}},
+		{query: "keyword=Go%E8%AF%AD%E8%A8%80", want: services.OrderSearchParams{Keyword: "Go语言"}},
+		{
+			query: "status=paid&status=1",
+			want:  services.OrderSearchParams{Statuses: []models.OrderStatus{models.OrderStatusPaid, models.OrderStatusPending}},
+		},
+		{query: "status=shipped", wantField: "status"},
+		{query: "status=9", wantField: "status"},
+		{
+			query: "created_from=2024-06-01&created_to=2024-06-30",
+			want:  services.OrderSearchParams{CreatedFrom: day(2024, 6, 1), CreatedTo: day(2024, 7, 1)},
+		},
+		// RFC3339时间原样作为上限，不加一天
+		{query: "created_to=2024-06-01T08:30:00Z", want: services.OrderSearchParams{CreatedTo: &rfc3339}},
+		{query: "created_from=2024/06/01", wantField: "created_from"},
+		{query: "created_to=yesterday", wantField: "created_to"},
+		{
+			query: "min_amount=0&max_amount=19900",
+			want:  services.OrderSearchParams{MinAmount: amount(0), MaxAmount: amount(19900)},
+		},
+		{query: "min_amount=-1", wantField: "min_amount"},
+		{query: "max_amount=99.5", wantField: "max_amount"},
+	}
+
+	for _, tc := range cases {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/orders?"+tc.query, nil)
+
+		got, err := parseOrderSearchParams(c)
+		if tc.wantField != "" {
+			var appErr *services.AppError
+			if !errors.As(err, &appErr) || appErr.HTTPStatus != http.StatusUnprocessableEntity {
+				t.Errorf("%q: 错误为 %v，期望422", tc.query, err)
+				continue
+			}
+			if details, _ := appErr.Details.(gin.H); details["field"] != tc.wantField {
+				t.Errorf("%q: 错误详情为 %v，期望 field=%s", tc.query, appErr.Details, tc.wantField)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: 解析失败: %v", tc.query, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%q: 解析结果为 %+v，期望 %+v", tc.query, got, tc.want)
+		}
+	}
+}
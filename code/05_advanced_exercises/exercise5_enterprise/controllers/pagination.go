@@ -0,0 +1,146 @@
+package controllers
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"edu-platform/config"
+	"edu-platform/services"
+)
+
+const (
+	defaultPageSize    = 20
+	defaultMaxPageSize = 100
+	defaultMaxDepth    = 10000
+
+	paginationKey = "pagination"
+)
+
+// PageGuard 列表分页参数的解析和限制
+// page_size超出上限时按上限返回；page*page_size超出深度上限时返回400，提示改用 after_id 游标分页，不执行查询
+type PageGuard struct {
+	maxPageSize int
+	maxDepth    int
+}
+
+// NewPageGuard 创建分页限制，配置为0时使用默认值（page_size最多100，深度最多10000条）
+func NewPageGuard(cfg config.PagingConfig) *PageGuard {
+	g := &PageGuard{maxPageSize: cfg.MaxPageSize, maxDepth: cfg.MaxDepth}
+	if g.maxPageSize <= 0 {
+		g.maxPageSize = defaultMaxPageSize
+	}
+	if g.maxDepth <= 0 {
+		g.maxDepth = defaultMaxDepth
+	}
+	return g
+}
+
+// Limit 解析 page、page_size、after_id 的中间件，结果通过 pageParams 取得
+// maxPageSize为该接口的page_size上限，为0或大于全局上限时使用全局上限；
+// allowDeep为true时（管理员接口）可以传 deep_paging=true 越过深度限制，改为按id倒序的游标方式取该页
+func (g *PageGuard) Limit(maxPageSize int, allowDeep bool) gin.HandlerFunc {
+	if maxPageSize <= 0 || maxPageSize > g.maxPageSize {
+		maxPageSize = g.maxPageSize
+	}
+	return func(c *gin.Context) {
+		p := services.Pagination{}
+		p.Page, _ = strconv.Atoi(c.DefaultQuery("page", "1"))
+		p.PageSize, _ = strconv.Atoi(c.DefaultQuery("page_size", strconv.Itoa(defaultPageSize)))
+		if p.Page < 1 {
+			p.Page = 1
+		}
+		if p.PageSize < 1 {
+			p.PageSize = defaultPageSize
+		}
+		if p.PageSize > maxPageSize {
+			p.PageSize = maxPageSize
+		}
+
+		if s := c.Query("after_id"); s != "" {
+			id, err := strconv.ParseUint(s, 10, 64)
+			if err != nil || id == 0 {
+				c.Error(invalidParam("after_id"))
+				c.Abort()
+				return
+			}
+			p.AfterID = uint(id)
+		}
+		if allowDeep {
+			p.Deep, _ = strconv.ParseBool(c.Query("deep_paging"))
+		}
+
+		// 游标分页不受深度限制；page*page_size > 深度上限 改写为除法比较，超大页码相乘也不会溢出
+		if !p.Keyset() && p.Page > g.maxDepth/p.PageSize {
+			c.Error(services.ErrValidation.WithMsg("error.page_too_deep", g.maxDepth).
+				WithDetails(gin.H{"max_depth": g.maxDepth, "cursor_param": "after_id"}))
+			c.Abort()
+			return
+		}
+
+		c.Set(paginationKey, p)
+		c.Next()
+	}
+}
+
+// pageParams 取得 PageGuard.Limit 解析的分页参数，路由没有注册该中间件时使用默认值
+func pageParams(c *gin.Context) services.Pagination {
+	if v, ok := c.Get(paginationKey); ok {
+		return v.(services.Pagination)
+	}
+	return services.Pagination{Page: 1, PageSize: defaultPageSize}
+}
+
+// SetPaginationHeaders 设置分页响应头
+// X-Total-Count / X-Page / X-Page-Size 以及 RFC 5988 Link 头（first/prev/next/last），
+// Link中的URL基于当前请求生成，保留除page以外的所有查询参数
+func SetPaginationHeaders(c *gin.Context, page, pageSize int, total int64) {
+	c.Header("X-Total-Count", strconv.FormatInt(total, 10))
+	c.Header("X-Page", strconv.Itoa(page))
+	c.Header("X-Page-Size", strconv.Itoa(pageSize))
+
+	if pageSize <= 0 {
+		return
+	}
+
+	lastPage := int((total + int64(pageSize) - 1) / int64(pageSize))
+	if lastPage < 1 {
+		lastPage = 1
+	}
+
+	links := []string{pageLink(c, 1, "first")}
+	if page > 1 {
+		prev := page - 1
+		if prev > lastPage {
+			prev = lastPage
+		}
+		links = append(links, pageLink(c, prev, "prev"))
+	}
+	if page < lastPage {
+		links = append(links, pageLink(c, page+1, "next"))
+	}
+	links = append(links, pageLink(c, lastPage, "last"))
+
+	c.Header("Link", strings.Join(links, ", "))
+}
+
+// pageLink 生成指定页码的Link条目
+func pageLink(c *gin.Context, page int, rel string) string {
+	u := url.URL{
+		Scheme: "http",
+		Host:   c.Request.Host,
+		Path:   c.Request.URL.Path,
+	}
+	if c.Request.TLS != nil {
+		u.Scheme = "https"
+	}
+
+	query := c.Request.URL.Query()
+	query.Set("page", strconv.Itoa(page))
+	u.RawQuery = query.Encode()
+
+	return fmt.Sprintf(`<%s>; rel="%s"`, u.String(), rel)
+}
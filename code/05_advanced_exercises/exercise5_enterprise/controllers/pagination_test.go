@@ -0,0 +1,147 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"edu-platform/config"
+	"edu-platform/services"
+)
+
+// TestSetPaginationHeaders 第一页没有prev、最后一页没有next，页码超过最后一页时prev指向最后一页；
+// Link中保留原有查询参数，只替换page
+func TestSetPaginationHeaders(t *testing.T) {
+	t.Parallel()
+	gin.SetMode(gin.TestMode)
+
+	link := func(page, rel string) string {
+		return `<http://example.com/api/v1/courses?page=` + page + `&page_size=20&status=published>; rel="` + rel + `"`
+	}
+	cases := []struct {
+		name  string
+		page  int
+		total int64
+		want  []string
+	}{
+		{"第一页", 1, 45, []string{link("1", "first"), link("2", "next"), link("3", "last")}},
+		{"中间页", 2, 45, []string{link("1", "first"), link("1", "prev"), link("3", "next"), link("3", "last")}},
+		{"最后一页", 3, 45, []string{link("1", "first"), link("2", "prev"), link("3", "last")}},
+		{"超过最后一页", 7, 45, []string{link("1", "first"), link("3", "prev"), link("3", "last")}},
+		{"整页", 2, 40, []string{link("1", "first"), link("1", "prev"), link("2", "last")}},
+		{"没有数据", 1, 0, []string{link("1", "first"), link("1", "last")}},
+	}
+	for _, tc := range cases {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/courses?status=published&page=9&page_size=20", nil)
+
+		SetPaginationHeaders(c, tc.page, 20, tc.total)
+
+		if got, want := w.Header().Get("Link"), strings.Join(tc.want, ", "); got != want {
+			t.Errorf("%s: Link为\n%s\n期望\n%s", tc.name, got, want)
+		}
+		if w.Header().Get("X-Page") != strconv.Itoa(tc.page) || w.Header().Get("X-Page-Size") != "20" {
+			t.Errorf("%s: X-Page为 %s、X-Page-Size为 %s，期望 %d、20", tc.name, w.Header().Get("X-Page"), w.Header().Get("X-Page-Size"), tc.page)
+		}
+		if got := w.Header().Get("X-Total-Count"); got != strconv.FormatInt(tc.total, 10) {
+			t.Errorf("%s: X-Total-Count为 %s，期望 %d", tc.name, got, tc.total)
+		}
+	}
+}
+
+// TestPageGuardLimit page_size按接口上限和全局上限截断，无效值使用默认值；
+// page*page_size超出深度上限时返回400，游标分页和管理员的 deep_paging 不受限制
+func TestPageGuardLimit(t *testing.T) {
+	t.Parallel()
+	gin.SetMode(gin.TestMode)
+
+	guard := NewPageGuard(config.PagingConfig{MaxPageSize: 100, MaxDepth: 1000})
+	r := gin.New()
+	r.Use(ErrorHandler())
+	echo := func(c *gin.Context) { c.JSON(http.StatusOK, pageParams(c)) }
+	r.GET("/list", guard.Limit(50, false), echo)
+	r.GET("/admin", guard.Limit(0, true), echo)
+
+	cases := []struct {
+		url        string
+		want       *services.Pagination // 返回错误时为nil
+		wantStatus int
+	}{
+		{"/list", &services.Pagination{Page: 1, PageSize: 20}, http.StatusOK},
+		{"/list?page=0&page_size=-5", &services.Pagination{Page: 1, PageSize: 20}, http.StatusOK},
+		{"/list?page=abc&page_size=abc", &services.Pagination{Page: 1, PageSize: 20}, http.StatusOK},
+		{"/list?page_size=80", &services.Pagination{Page: 1, PageSize: 50}, http.StatusOK},
+		{"/admin?page_size=500", &services.Pagination{Page: 1, PageSize: 100}, http.StatusOK},
+		{"/list?page=20&page_size=50", &services.Pagination{Page: 20, PageSize: 50}, http.StatusOK},
+		{"/list?page=21&page_size=50", nil, http.StatusBadRequest},
+		{"/list?page=9223372036854775807&page_size=50", nil, http.StatusBadRequest}, // 深度计算不能溢出
+		{"/list?page=21&page_size=50&after_id=7", &services.Pagination{Page: 21, PageSize: 50, AfterID: 7}, http.StatusOK},
+		{"/list?after_id=0", nil, http.StatusUnprocessableEntity},
+		{"/list?after_id=abc", nil, http.StatusUnprocessableEntity},
+		{"/list?page=21&page_size=50&deep_paging=true", nil, http.StatusBadRequest},
+		{"/admin?page=21&page_size=50&deep_paging=true", &services.Pagination{Page: 21, PageSize: 50, Deep: true}, http.StatusOK},
+	}
+	for _, tc := range cases {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tc.url, nil))
+		if w.Code != tc.wantStatus {
+			t.Errorf("%s: 返回 %d %s，期望 %d", tc.url, w.Code, w.Body.String(), tc.wantStatus)
+			continue
+		}
+		if tc.want == nil {
+			continue
+		}
+		var got services.Pagination
+		if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+			t.Errorf("%s: 解析响应失败: %v", tc.url, err)
+			continue
+		}
+		if got != *tc.want {
+			t.Errorf("%s: 分页参数为 %+v，期望 %+v", tc.url, got, *tc.want)
+		}
+	}
+}
+
+// TestPageGuardTooDeep 超出深度上限的响应提示深度上限和游标参数；未配置时使用默认上限
+func TestPageGuardTooDeep(t *testing.T) {
+	t.Parallel()
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.Use(ErrorHandler())
+	r.GET("/list", NewPageGuard(config.PagingConfig{}).Limit(0, false), func(c *gin.Context) {
+		c.JSON(http.StatusOK, pageParams(c))
+	})
+
+	for url, wantStatus := range map[string]int{
+		"/list?page=100&page_size=500": http.StatusOK, // page_size按默认上限100计算，深度恰好10000
+		"/list?page=101&page_size=100": http.StatusBadRequest,
+	} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, url, nil))
+		if w.Code != wantStatus {
+			t.Fatalf("%s: 返回 %d，期望 %d", url, w.Code, wantStatus)
+		}
+		if wantStatus != http.StatusBadRequest {
+			continue
+		}
+		var body struct {
+			Data struct {
+				MaxDepth    int    `json:"max_depth"`
+				CursorParam string `json:"cursor_param"`
+			} `json:"data"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatalf("解析响应失败: %v", err)
+		}
+		if body.Data.MaxDepth != 10000 || body.Data.CursorParam != "after_id" {
+			t.Errorf("%s: data为 %+v，期望 max_depth=10000、cursor_param=after_id", url, body.Data)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package controllers
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"edu-platform/services"
+)
+
+// ChangePassword 修改当前用户的密码，须提供当前密码；成功后之前签发的token失效，需要重新登录
+func (ctrl *UserController) ChangePassword(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	var req struct {
+		CurrentPassword string `json:"current_password" binding:"required"`
+		NewPassword     string `json:"new_password" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(services.ErrValidation.Wrap(err))
+		return
+	}
+
+	if err := ctrl.passwordService.WithContext(c.Request.Context()).ChangePassword(userID, req.CurrentPassword, req.NewPassword); err != nil {
+		c.Error(err)
+		return
+	}
+
+	Success(c, nil)
+}
+
+// RequestPasswordReset 向邮箱或手机号发送重置密码的验证码，账户不存在时同样返回成功
+func (ctrl *UserController) RequestPasswordReset(c *gin.Context) {
+	var req struct {
+		Target string `json:"target" binding:"required,max=100"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(services.ErrValidation.Wrap(err))
+		return
+	}
+
+	if err := ctrl.passwordService.WithContext(c.Request.Context()).RequestReset(req.Target); err != nil {
+		c.Error(err)
+		return
+	}
+
+	Success(c, nil)
+}
+
+// ConfirmPasswordReset 校验验证码并设置新密码，成功后之前签发的token失效
+func (ctrl *UserController) ConfirmPasswordReset(c *gin.Context) {
+	var req struct {
+		Target      string `json:"target" binding:"required,max=100"`
+		Code        string `json:"code" binding:"required,len=6,numeric"`
+		NewPassword string `json:"new_password" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(services.ErrValidation.Wrap(err))
+		return
+	}
+
+	if err := ctrl.passwordService.WithContext(c.Request.Context()).ConfirmReset(req.Target, req.Code, req.NewPassword); err != nil {
+		c.Error(err)
+		return
+	}
+
+	Success(c, nil)
+}
@@ -0,0 +1,75 @@
+package controllers
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"edu-platform/services"
+)
+
+// PromotionController 课程限时促销控制器（管理员）
+type PromotionController struct {
+	promotionService *services.PromotionService
+}
+
+// NewPromotionController 创建限时促销控制器
+func NewPromotionController(promotionService *services.PromotionService) *PromotionController {
+	return &PromotionController{promotionService: promotionService}
+}
+
+// CreatePromotion 为课程创建限时促销
+func (ctrl *PromotionController) CreatePromotion(c *gin.Context) {
+	courseID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(services.ErrValidation)
+		return
+	}
+
+	var req services.PromotionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(services.ErrValidation.Wrap(err))
+		return
+	}
+
+	promotion, err := ctrl.promotionService.CreatePromotion(uint(courseID), c.GetUint("user_id"), req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	Success(c, promotion)
+}
+
+// GetPromotions 课程的全部限时促销
+func (ctrl *PromotionController) GetPromotions(c *gin.Context) {
+	courseID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(services.ErrValidation)
+		return
+	}
+
+	promotions, err := ctrl.promotionService.GetPromotions(uint(courseID))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	Success(c, promotions)
+}
+
+// DeletePromotion 删除限时促销
+func (ctrl *PromotionController) DeletePromotion(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(services.ErrValidation)
+		return
+	}
+
+	if err := ctrl.promotionService.DeletePromotion(uint(id)); err != nil {
+		c.Error(err)
+		return
+	}
+
+	Success(c, nil)
+}
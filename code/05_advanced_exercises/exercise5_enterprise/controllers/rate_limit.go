@@ -0,0 +1,27 @@
+package controllers
+
+import (
+	"log"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"edu-platform/services"
+)
+
+// RateLimit 按客户端IP限流：同一场景下每个window内最多limit次请求，超过时返回429
+// 限流计数失败（缓存不可用）时放行
+func RateLimit(limiter *services.RateLimiter, scope string, limit int, window time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		allowed, err := limiter.Allow(c.Request.Context(), scope, c.ClientIP(), limit, window)
+		if err != nil {
+			log.Printf("[WARN] 限流计数失败，放行请求: %v", err)
+		}
+		if !allowed {
+			c.Error(services.ErrTooManyRequests)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,37 @@
+package controllers
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"edu-platform/services"
+)
+
+// RecommendationController 课程推荐控制器
+type RecommendationController struct {
+	recommendationService *services.RecommendationService
+}
+
+// NewRecommendationController 创建课程推荐控制器
+func NewRecommendationController(recommendationService *services.RecommendationService) *RecommendationController {
+	return &RecommendationController{recommendationService: recommendationService}
+}
+
+// GetRelatedCourses 购买了该课程的学员还购买了哪些课程，登录时排除自己已拥有的课程
+func (ctrl *RecommendationController) GetRelatedCourses(c *gin.Context) {
+	courseID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(services.ErrValidation)
+		return
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "6"))
+
+	related, err := ctrl.recommendationService.GetRelatedCourses(uint(courseID), limit, c.GetUint("user_id"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	Success(c, related)
+}
@@ -0,0 +1,39 @@
+package controllers
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"edu-platform/services"
+)
+
+// ReportController 自定义报表控制器
+type ReportController struct {
+	reportBuilder *services.ReportQueryBuilder
+}
+
+// NewReportController 创建自定义报表控制器
+func NewReportController(reportBuilder *services.ReportQueryBuilder) *ReportController {
+	return &ReportController{reportBuilder: reportBuilder}
+}
+
+// GetSchema 获取各报表对象可用的字段（管理员）
+func (ctrl *ReportController) GetSchema(c *gin.Context) {
+	Success(c, ctrl.reportBuilder.Schema())
+}
+
+// RunReport 执行自定义报表（管理员）
+func (ctrl *ReportController) RunReport(c *gin.Context) {
+	var spec services.ReportSpec
+	if err := c.ShouldBindJSON(&spec); err != nil {
+		c.Error(services.ErrValidation.Wrap(err))
+		return
+	}
+
+	result, err := ctrl.reportBuilder.Run(c.Request.Context(), spec)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	Success(c, result)
+}
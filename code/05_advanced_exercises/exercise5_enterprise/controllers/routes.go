@@ -0,0 +1,298 @@
+package controllers
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"edu-platform/config"
+	"edu-platform/i18n"
+	"edu-platform/middleware"
+	"edu-platform/services"
+)
+
+// RouteOption SetupRoutes 的可选参数
+type RouteOption func(*routeOptions)
+
+type routeOptions struct {
+	codeSender services.CodeSender
+}
+
+// WithCodeSender 指定注册、重置密码验证码的发送器，默认写入日志（LogCodeSender）；测试中用于取得验证码
+func WithCodeSender(sender services.CodeSender) RouteOption {
+	return func(o *routeOptions) {
+		o.codeSender = sender
+	}
+}
+
+// SetupRoutes 设置路由，cfg为nil时使用默认配置
+func SetupRoutes(db *gorm.DB, cfg *config.Config, opts ...RouteOption) *gin.Engine {
+	var options routeOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	var serverCfg config.ServerConfig
+	var corsCfg config.CORSConfig
+	var pagingCfg config.PagingConfig
+	var jwtCfg config.JWTConfig
+	var redisCfg config.RedisConfig
+	if cfg != nil {
+		serverCfg = cfg.Server
+		corsCfg = cfg.CORS
+		pagingCfg = cfg.Paging
+		jwtCfg = cfg.JWT
+		redisCfg = cfg.Redis
+	}
+
+	// 包装全局日志，支持按请求记录SQL（X-Debug-SQL），全局日志级别不变
+	db = db.Session(&gorm.Session{NewDB: true, Logger: services.NewSQLDebugLogger(db.Logger)})
+
+	// 创建服务实例
+	cache := newCache(redisCfg)
+	userService := services.NewUserService(db)
+
+	r := gin.Default()
+	// 安全响应头和跨域处理在业务中间件之前，预检请求直接返回，不进入后续中间件和处理函数
+	r.Use(middleware.SecurityHeaders(), middleware.CORS(corsCfg))
+	// 操作日志在 ErrorHandler 之前注册，记录的状态码包含错误响应
+	r.Use(i18n.Middleware(), DebugSQL(userService, serverCfg.DebugSQLToken, jwtCfg.Secret),
+		AuditLog(services.NewAuditService(db)), ErrorHandler())
+
+	suggestIndex := services.NewCourseSuggestIndex(db)
+	if err := suggestIndex.Rebuild(); err != nil {
+		log.Printf("构建课程搜索建议索引失败: %v", err)
+	}
+	courseService := services.NewCourseService(db, suggestIndex)
+	orderService := services.NewOrderService(db)
+	learningService := services.NewLearningService(db)
+	bundleService := services.NewBundleService(db)
+	settingsService := services.NewSettingsService(db)
+	retentionService := services.NewRetentionService(db, settingsService)
+	deletionService := services.NewAccountDeletionService(db)
+	verificationService := services.NewVerificationService(db, options.codeSender)
+	passwordService := services.NewPasswordService(db, verificationService)
+	applicationService := services.NewInstructorApplicationService(db)
+	discussionService := services.NewDiscussionService(db)
+	timelineService := services.NewTimelineService(db)
+	revisionService := services.NewCourseRevisionService(db)
+	invoiceService := services.NewInvoiceService(db)
+	emailService := services.NewEmailService(db, nil)
+	financeService := services.NewFinanceService(db)
+	enrollmentService := services.NewEnrollmentService(db)
+	waitlistService := services.NewWaitlistService(db)
+	recommendationService := services.NewRecommendationService(db, services.NewCourseCache(cache))
+	notificationService := services.NewNotificationService(db)
+	dataHealthService := services.NewDataHealthService(db)
+	reportBuilder := services.NewReportQueryBuilder(db)
+	promotionService := services.NewPromotionService(db)
+	searchService := services.NewSearchService(db)
+	certificateService := services.NewCertificateService(db)
+	orderAuditService := services.NewOrderAuditService(db)
+	jobRunner := services.NewDefaultJobRunner(db)
+	rateLimiter := services.NewRateLimiter(cache)
+	exportJobs := services.NewExportJobManager(services.NewUserDataExporter(db), services.DefaultExportDir())
+
+	// 创建控制器实例
+	userController := NewUserController(userService, deletionService, verificationService, passwordService)
+	courseController := NewCourseController(courseService)
+	bundleController := NewBundleController(bundleService)
+	orderController := NewOrderController(orderService, learningService)
+	adminController := NewAdminController(retentionService, dataHealthService)
+	timelineController := NewTimelineController(timelineService)
+	exportController := NewExportController(exportJobs)
+	accountController := NewAccountController(deletionService)
+	applicationController := NewInstructorApplicationController(applicationService)
+	discussionController := NewDiscussionController(discussionService)
+	revisionController := NewCourseRevisionController(revisionService)
+	invoiceController := NewInvoiceController(invoiceService)
+	emailController := NewEmailController(emailService)
+	financeController := NewFinanceController(financeService)
+	enrollmentController := NewEnrollmentController(enrollmentService)
+	waitlistController := NewWaitlistController(waitlistService)
+	recommendationController := NewRecommendationController(recommendationService)
+	notificationController := NewNotificationController(notificationService)
+	reportController := NewReportController(reportBuilder)
+	promotionController := NewPromotionController(promotionService)
+	searchController := NewSearchController(searchService)
+	certificateController := NewCertificateController(certificateService)
+	orderAuditController := NewOrderAuditController(orderAuditService)
+	jobController := NewJobController(jobRunner)
+	impersonationController := NewImpersonationController(userService, jwtCfg.ExpireDuration, jwtCfg.Secret)
+
+	// 认证中间件校验token版本，修改或重置密码后旧token失效
+	requireAuth := AuthMiddleware(userService, jwtCfg.Secret)
+	optionalAuth := OptionalAuthMiddleware(userService, jwtCfg.Secret)
+	// 管理员模拟登录时禁止的操作
+	noImpersonation := ForbidImpersonation()
+
+	// 列表分页限制：page_size超出上限时按上限返回，翻页过深时返回400，提示改用游标分页
+	pageGuard := NewPageGuard(pagingCfg)
+
+	api := r.Group("/api/v1")
+	{
+		// 全站搜索：课程、帖子、用户
+		api.GET("/search", searchController.Search)
+		// 结业证书公开验证，不需要登录
+		api.GET("/certificates/verify/:serial", certificateController.VerifyCertificate)
+
+		// 用户相关路由
+		users := api.Group("/users")
+		{
+			users.POST("/verification-code", userController.RequestVerificationCode)
+			users.POST("/verification-code/verify", userController.VerifyCode)
+			users.POST("/register", userController.Register)
+			// 同一IP每分钟最多尝试登录10次
+			users.POST("/login", RateLimit(rateLimiter, "login", 10, time.Minute), userController.Login)
+			users.GET("/profile", requireAuth, userController.GetProfile)
+			users.PUT("/profile", requireAuth, userController.UpdateProfile)
+		}
+
+		// 重置密码
+		auth := api.Group("/auth")
+		{
+			auth.POST("/password-reset", userController.RequestPasswordReset)
+			auth.POST("/password-reset/confirm", userController.ConfirmPasswordReset)
+		}
+
+		// 当前用户相关路由
+		me := api.Group("/me", requireAuth)
+		{
+			me.GET("", userController.GetMe)
+			me.GET("/export", exportController.RequestExport)
+			me.GET("/export/:job_id", exportController.GetExport)
+			me.DELETE("", noImpersonation, accountController.RequestDeletion)
+			me.POST("/deletion/cancel", accountController.CancelDeletion)
+			me.POST("/instructor-application", applicationController.Submit)
+			me.GET("/instructor-application", applicationController.GetMine)
+			me.GET("/recently-viewed", courseController.GetRecentlyViewed)
+			me.PUT("/password", noImpersonation, userController.ChangePassword)
+			me.GET("/certificates", certificateController.GetMyCertificates)
+
+			// 站内通知，未读数读取用户表中的缓存
+			me.GET("/notifications/unread-count", notificationController.GetUnreadCount)
+			me.GET("/notifications/unread-count/stream", notificationController.StreamUnreadCount)
+			me.POST("/notifications/read", notificationController.MarkRead)
+			me.POST("/notifications/read-all", notificationController.MarkAllAsRead)
+			me.POST("/notifications/delete", notificationController.DeleteNotifications)
+		}
+
+		// 课程相关路由
+		courses := api.Group("/courses")
+		{
+			courses.GET("", pageGuard.Limit(0, false), courseController.GetCourses)
+			courses.GET("/suggest", courseController.SuggestCourses)
+			courses.GET("/autocomplete", courseController.AutocompleteCourses)
+			courses.GET("/catalog", courseController.GetCatalog)
+			courses.GET("/:id", optionalAuth, courseController.GetCourse)
+			courses.GET("/:id/related", optionalAuth, recommendationController.GetRelatedCourses)
+			courses.POST("", requireAuth, courseController.CreateCourse)
+			courses.PUT("/:id", requireAuth, courseController.UpdateCourse)
+			courses.POST("/:id/publish", requireAuth, courseController.PublishCourse)
+			courses.POST("/:id/unpublish", requireAuth, courseController.UnpublishCourse)
+			courses.PUT("/:id/prerequisites", requireAuth, courseController.SetPrerequisites)
+			courses.PUT("/:id/tags", requireAuth, courseController.SetTags)
+
+			// 课程大纲及修订
+			courses.GET("/:id/outline", revisionController.GetOutline)
+			courses.PUT("/:id/outline", requireAuth, revisionController.SaveOutline)
+			courses.GET("/:id/outline/draft/diff", requireAuth, revisionController.DiffDraft)
+			courses.POST("/:id/outline/draft/publish", requireAuth, revisionController.PublishRevision)
+
+			// 课程讨论
+			courses.GET("/:id/threads", discussionController.GetThreads)
+			courses.POST("/:id/threads", requireAuth, discussionController.CreateThread)
+			courses.GET("/:id/threads/:thread_id/replies", discussionController.GetReplies)
+			courses.POST("/:id/threads/:thread_id/replies", requireAuth, discussionController.Reply)
+			courses.DELETE("/:id/threads/:thread_id/replies/:reply_id", requireAuth, discussionController.DeleteReply)
+
+			// 限额课程候补
+			courses.POST("/:id/waitlist", requireAuth, waitlistController.JoinWaitlist)
+			courses.GET("/:id/waitlist", requireAuth, waitlistController.GetWaitlistPosition)
+		}
+
+		// 课程包相关路由
+		bundles := api.Group("/bundles")
+		{
+			bundles.GET("", bundleController.GetBundles)
+			bundles.GET("/:id", bundleController.GetBundle)
+		}
+
+		// 订单相关路由
+		orders := api.Group("/orders", requireAuth)
+		{
+			orders.POST("", orderController.CreateOrder)
+			orders.GET("", pageGuard.Limit(0, false), orderController.GetOrders)
+			orders.GET("/:order_no", orderController.GetOrder)
+			orders.POST("/:order_no/pay", orderController.PayOrder)
+			orders.DELETE("/:order_no", orderController.CancelOrder)
+			orders.POST("/:order_no/confirm-receipt", orderController.ConfirmReceipt)
+			orders.GET("/:order_no/invoice", invoiceController.GetOrderInvoice)
+		}
+
+		// 学习相关路由
+		learning := api.Group("/learning", requireAuth)
+		{
+			learning.GET("/courses", orderController.GetLearningCourses)
+			learning.POST("/progress", orderController.UpdateProgress)
+			learning.GET("/courses/:course_id/progress", orderController.GetCourseProgress)
+			learning.GET("/streak", orderController.GetStreak)
+		}
+
+		// 管理员路由
+		admin := api.Group("/admin", requireAuth, AdminMiddleware(userService))
+		{
+			admin.GET("/users", pageGuard.Limit(0, true), userController.GetUsers)
+			admin.POST("/impersonate/:user_id", noImpersonation, impersonationController.Impersonate)
+			admin.POST("/users/:id/unread-count/reconcile", notificationController.ReconcileUnreadCount)
+			admin.POST("/retention/purge", adminController.PurgeData)
+			admin.GET("/data-health", adminController.GetDataHealth)
+			admin.GET("/data-health/dangling", adminController.GetDanglingReferences)
+			admin.GET("/timeline", timelineController.GetTimeline)
+			admin.GET("/instructor-applications", applicationController.GetApplications)
+			admin.POST("/instructor-applications/:id/approve", applicationController.Approve)
+			admin.POST("/instructor-applications/:id/reject", applicationController.Reject)
+			admin.GET("/invoices", invoiceController.GetInvoices)
+			admin.GET("/email-templates", emailController.GetTemplates)
+			admin.PUT("/email-templates/:key", emailController.SaveTemplate)
+			admin.GET("/email-logs", emailController.GetEmailLogs)
+			admin.GET("/instructors/:id/payout-statement", financeController.ExportPayoutStatement)
+			admin.POST("/courses/:id/enrollments/bulk", enrollmentController.BulkEnroll)
+			admin.GET("/courses/:id/promotions", promotionController.GetPromotions)
+			admin.POST("/courses/:id/promotions", promotionController.CreatePromotion)
+			admin.DELETE("/promotions/:id", promotionController.DeletePromotion)
+			admin.GET("/orders/mismatches", orderAuditController.GetMismatchedOrders)
+			admin.POST("/orders/:id/repair", orderAuditController.RepairOrder)
+			admin.GET("/jobs", jobController.GetJobs)
+			admin.POST("/jobs/:name/run", jobController.RunJob)
+			admin.GET("/reports/schema", reportController.GetSchema)
+			admin.POST("/reports/run", reportController.RunReport)
+		}
+	}
+
+	return r
+}
+
+// StartBackgroundJobs 启动后台定时任务，ctx取消时停止
+func StartBackgroundJobs(ctx context.Context, db *gorm.DB) {
+	// 候补通知过期、账户注销、数据清理由任务调度器执行，执行记录写入 job_runs，可在管理接口查看和手动触发
+	services.NewDefaultJobRunner(db).Start(ctx)
+
+	// 以下任务执行频繁，为避免 job_runs 快速增长不写执行记录，仍使用各自的定时器
+
+	// 每分钟按时间更新限时促销的状态（只用于展示和报表，售价按时间窗口实时判断）
+	services.NewPromotionService(db).StartStatusSync(ctx, time.Minute)
+
+	// 投递发件箱事件：订单支付后开具发票，退款后开具红字发票；下单、支付、退款后按模板生成通知邮件
+	emailService := services.NewEmailService(db, nil)
+	services.NewOutboxService(db).StartOutboxRelay(ctx, services.MergeOutboxMux(
+		services.NewInvoiceService(db).OutboxHandlers(),
+		emailService.OutboxHandlers(),
+	))
+
+	// 每分钟发送一轮待发送邮件，接入SMTP前使用 LogEmailSender 只写日志
+	emailService.StartSender(ctx, time.Minute)
+}
@@ -0,0 +1,42 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"edu-platform/services"
+)
+
+// SearchController 全站搜索控制器
+type SearchController struct {
+	searchService *services.SearchService
+}
+
+// NewSearchController 创建全站搜索控制器
+func NewSearchController(searchService *services.SearchService) *SearchController {
+	return &SearchController{searchService: searchService}
+}
+
+// Search 全站搜索，同时返回课程、帖子、用户三类结果
+// 部分分区查询失败时仍返回其他分区的结果，失败的分区列在 failed 中；全部失败时返回500
+func (ctrl *SearchController) Search(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "5"))
+
+	results, err := ctrl.searchService.GlobalSearch(c.Request.Context(), c.Query("q"), limit)
+	var searchErr *services.SearchError
+	if errors.As(err, &searchErr) {
+		if searchErr.AllFailed() {
+			c.Error(services.ErrInternal.Wrap(err))
+			return
+		}
+		log.Printf("[WARN] %s %s: %v", c.Request.Method, c.Request.URL.Path, err)
+	} else if err != nil {
+		c.Error(err)
+		return
+	}
+
+	Success(c, results)
+}
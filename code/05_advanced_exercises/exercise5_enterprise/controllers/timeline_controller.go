@@ -0,0 +1,49 @@
+package controllers
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"edu-platform/services"
+)
+
+// TimelineController 管理后台时间线控制器
+type TimelineController struct {
+	timelineService *services.TimelineService
+}
+
+// NewTimelineController 创建时间线控制器
+func NewTimelineController(timelineService *services.TimelineService) *TimelineController {
+	return &TimelineController{timelineService: timelineService}
+}
+
+// GetTimeline 获取某一天的事件流（管理员）
+// date格式为2006-01-02，默认当天；types为逗号分隔的事件类型，默认全部；cursor为上一页返回的next_cursor
+func (ctrl *TimelineController) GetTimeline(c *gin.Context) {
+	date := time.Now()
+	if s := c.Query("date"); s != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", s, time.Local)
+		if err != nil {
+			c.Error(services.ErrValidation.WithMsg("timeline.invalid_date"))
+			return
+		}
+		date = parsed
+	}
+
+	var types []string
+	if s := c.Query("types"); s != "" {
+		types = strings.Split(s, ",")
+	}
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	page, err := ctrl.timelineService.GetTimeline(date, types, c.Query("cursor"), pageSize)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	Success(c, page)
+}
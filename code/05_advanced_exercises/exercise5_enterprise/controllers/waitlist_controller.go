@@ -0,0 +1,53 @@
+package controllers
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"edu-platform/services"
+)
+
+// WaitlistController 课程候补控制器
+type WaitlistController struct {
+	waitlistService *services.WaitlistService
+}
+
+// NewWaitlistController 创建课程候补控制器
+func NewWaitlistController(waitlistService *services.WaitlistService) *WaitlistController {
+	return &WaitlistController{waitlistService: waitlistService}
+}
+
+// JoinWaitlist 加入满员课程的候补名单，返回当前排名
+func (ctrl *WaitlistController) JoinWaitlist(c *gin.Context) {
+	courseID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(services.ErrValidation)
+		return
+	}
+
+	position, err := ctrl.waitlistService.Join(c.GetUint("user_id"), uint(courseID))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	Success(c, position)
+}
+
+// GetWaitlistPosition 查询自己在课程候补名单中的排名，已通知时返回购买截止时间
+func (ctrl *WaitlistController) GetWaitlistPosition(c *gin.Context) {
+	courseID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(services.ErrValidation)
+		return
+	}
+
+	position, err := ctrl.waitlistService.Position(c.GetUint("user_id"), uint(courseID))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	Success(c, position)
+}
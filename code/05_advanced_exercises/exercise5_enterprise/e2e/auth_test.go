@@ -0,0 +1,197 @@
+package e2e
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+
+	"edu-platform/models"
+	"edu-platform/testhelpers"
+	"edu-platform/testhelpers/factory"
+)
+
+// authUsers 认证测试用到的用户
+type authUsers struct {
+	admin   *models.User
+	student *models.User
+}
+
+// TestAuthFailures 认证失败矩阵：每种无效凭证都被拒绝，错误响应与golden文件一致
+func TestAuthFailures(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name   string
+		method string
+		path   string
+		status int
+		// token 返回请求使用的token，可以修改数据库构造场景
+		token func(t *testing.T, srv *testhelpers.Server, u authUsers) string
+		body  func(u authUsers) interface{} // 请求体，可选
+	}{
+		{
+			name: "missing_token", method: http.MethodGet, path: "/api/v1/users/profile", status: http.StatusUnauthorized,
+			token: func(*testing.T, *testhelpers.Server, authUsers) string { return "" },
+		},
+		{
+			name: "malformed_token", method: http.MethodGet, path: "/api/v1/users/profile", status: http.StatusUnauthorized,
+			token: func(*testing.T, *testhelpers.Server, authUsers) string { return "not-a-token" },
+		},
+		{
+			name: "unknown_user", method: http.MethodGet, path: "/api/v1/users/profile", status: http.StatusUnauthorized,
+			token: func(*testing.T, *testhelpers.Server, authUsers) string { return "jwt_token_999999_0" },
+		},
+		{
+			// 修改密码后token版本递增，之前签发的token失效
+			name: "stale_token_version", method: http.MethodGet, path: "/api/v1/users/profile", status: http.StatusUnauthorized,
+			token: func(t *testing.T, srv *testhelpers.Server, u authUsers) string {
+				token := srv.Login(u.student.Email, factory.Password)
+				bumpTokenVersion(t, srv.DB, u.student.ID)
+				return token
+			},
+		},
+		{
+			name: "wrong_password", method: http.MethodPost, path: "/api/v1/users/login", status: http.StatusUnauthorized,
+			token: func(*testing.T, *testhelpers.Server, authUsers) string { return "" },
+			body: func(u authUsers) interface{} {
+				return map[string]string{"email": u.student.Email, "password": "wrong-password"}
+			},
+		},
+		{
+			name: "disabled_user_login", method: http.MethodPost, path: "/api/v1/users/login", status: http.StatusForbidden,
+			token: func(t *testing.T, srv *testhelpers.Server, u authUsers) string {
+				if err := srv.DB.Model(u.student).Update("status", models.UserStatusDisabled).Error; err != nil {
+					t.Fatalf("禁用用户失败: %v", err)
+				}
+				return ""
+			},
+			body: func(u authUsers) interface{} {
+				return map[string]string{"email": u.student.Email, "password": factory.Password}
+			},
+		},
+		{
+			name: "non_admin_on_admin_route", method: http.MethodGet, path: "/api/v1/admin/users", status: http.StatusForbidden,
+			token: func(t *testing.T, srv *testhelpers.Server, u authUsers) string {
+				return srv.Login(u.student.Email, factory.Password)
+			},
+		},
+		{
+			name: "unsigned_impersonation_token", method: http.MethodGet, path: "/api/v1/me", status: http.StatusUnauthorized,
+			token: func(_ *testing.T, _ *testhelpers.Server, u authUsers) string {
+				return unsignedImpersonationToken(u, time.Now().Add(10*time.Minute))
+			},
+		},
+		{
+			name: "forged_impersonation_token", method: http.MethodGet, path: "/api/v1/me", status: http.StatusUnauthorized,
+			token: func(_ *testing.T, _ *testhelpers.Server, u authUsers) string {
+				return signImpersonationToken("wrong-secret", unsignedImpersonationToken(u, time.Now().Add(10*time.Minute)))
+			},
+		},
+		{
+			// 签名正确但过期时间超过30分钟上限
+			name: "impersonation_ttl_too_long", method: http.MethodGet, path: "/api/v1/me", status: http.StatusUnauthorized,
+			token: func(_ *testing.T, _ *testhelpers.Server, u authUsers) string {
+				return signImpersonationToken(testhelpers.JWTSecret, unsignedImpersonationToken(u, time.Now().Add(24*time.Hour)))
+			},
+		},
+		{
+			name: "expired_impersonation_token", method: http.MethodGet, path: "/api/v1/me", status: http.StatusUnauthorized,
+			token: func(_ *testing.T, _ *testhelpers.Server, u authUsers) string {
+				return signImpersonationToken(testhelpers.JWTSecret, unsignedImpersonationToken(u, time.Now().Add(-time.Minute)))
+			},
+		},
+		{
+			// 管理员修改密码后，之前签发的模拟登录token失效
+			name: "impersonation_admin_version_changed", method: http.MethodGet, path: "/api/v1/me", status: http.StatusUnauthorized,
+			token: func(t *testing.T, srv *testhelpers.Server, u authUsers) string {
+				token := impersonate(t, srv, u)
+				bumpTokenVersion(t, srv.DB, u.admin.ID)
+				return token
+			},
+		},
+		{
+			name: "impersonation_forbidden_endpoint", method: http.MethodPut, path: "/api/v1/me/password", status: http.StatusForbidden,
+			token: func(t *testing.T, srv *testhelpers.Server, u authUsers) string {
+				return impersonate(t, srv, u)
+			},
+			body: func(authUsers) interface{} {
+				return map[string]string{"current_password": factory.Password, "new_password": "newsecret123"}
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			db := testhelpers.NewDB(t)
+			srv := testhelpers.NewServer(t, db)
+			f := factory.New(t, db)
+			u := authUsers{admin: f.User("admin"), student: f.User("student")}
+
+			token := tc.token(t, srv, u)
+			var body interface{}
+			if tc.body != nil {
+				body = tc.body(u)
+			}
+
+			resp := srv.Do(tc.method, tc.path, token, body)
+			if resp.Status != tc.status {
+				t.Errorf("状态码 = %d，期望 %d: %s", resp.Status, tc.status, resp.Body)
+			}
+			testhelpers.AssertGolden(t, "auth/"+tc.name, resp.Body)
+		})
+	}
+}
+
+// TestImpersonationToken 管理员签发的模拟登录token可以访问被模拟用户的接口，/me 返回模拟者
+func TestImpersonationToken(t *testing.T) {
+	t.Parallel()
+	db := testhelpers.NewDB(t)
+	srv := testhelpers.NewServer(t, db)
+	f := factory.New(t, db)
+	u := authUsers{admin: f.User("admin"), student: f.User("student")}
+
+	resp := srv.MustOK(http.MethodGet, "/api/v1/me", impersonate(t, srv, u), nil)
+	testhelpers.AssertGolden(t, "auth/impersonation_me", resp.Body)
+}
+
+// impersonate 管理员登录后通过接口模拟登录学生，返回模拟登录token
+func impersonate(t *testing.T, srv *testhelpers.Server, u authUsers) string {
+	t.Helper()
+	adminToken := srv.Login(u.admin.Email, factory.Password)
+	resp := srv.MustOK(http.MethodPost, fmt.Sprintf("/api/v1/admin/impersonate/%d", u.student.ID), adminToken, nil)
+	var data struct {
+		Token string `json:"token"`
+	}
+	resp.Data(t, &data)
+	return data.Token
+}
+
+// unsignedImpersonationToken 未签名的模拟登录token，格式与 controllers.issueImpersonationToken 一致
+func unsignedImpersonationToken(u authUsers, expiresAt time.Time) string {
+	return fmt.Sprintf("imp_token_%d_%d_%d_%d", u.student.ID, u.admin.ID, u.admin.TokenVersion, expiresAt.Unix())
+}
+
+// signImpersonationToken 用secret签名，算法与 controllers.impersonationSignature 一致
+func signImpersonationToken(secret, unsigned string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(unsigned))
+	return unsigned + "_" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// bumpTokenVersion 递增用户的token版本，与修改或重置密码的效果相同
+func bumpTokenVersion(t *testing.T, db *gorm.DB, userID uint) {
+	t.Helper()
+	err := db.Model(&models.User{}).Where("id = ?", userID).
+		UpdateColumn("token_version", gorm.Expr("token_version + 1")).Error
+	if err != nil {
+		t.Fatalf("更新token版本失败: %v", err)
+	}
+}
@@ -0,0 +1,109 @@
+package e2e
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"gorm.io/gorm"
+
+	"edu-platform/models"
+	"edu-platform/services"
+	"edu-platform/testhelpers"
+	"edu-platform/testhelpers/factory"
+)
+
+// TestCourseOwnerOnlyEndpoints 修改课程设置的接口只允许课程讲师调用：
+// 其他讲师调用返回403且数据不变，课程讲师调用成功
+func TestCourseOwnerOnlyEndpoints(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name   string
+		method string
+		path   string // %d 替换为课程ID
+		body   func(other *models.Course) interface{}
+		// count 统计课程的相关记录数，用于确认非讲师的请求没有写入
+		count func(t *testing.T, db *gorm.DB, courseID uint) int64
+	}{
+		{
+			name: "prerequisites", method: http.MethodPut, path: "/api/v1/courses/%d/prerequisites",
+			body: func(other *models.Course) interface{} {
+				return map[string][]uint{"course_ids": {other.ID}}
+			},
+			count: func(t *testing.T, db *gorm.DB, courseID uint) int64 {
+				return countRows(t, db.Model(&models.CoursePrerequisite{}).Where("course_id = ?", courseID))
+			},
+		},
+		{
+			name: "tags", method: http.MethodPut, path: "/api/v1/courses/%d/tags",
+			body: func(*models.Course) interface{} {
+				return map[string][]string{"tags": {"项目实战"}}
+			},
+			count: countCourseTags,
+		},
+		{
+			// 更新课程时传入标签
+			name: "update_course_tags", method: http.MethodPut, path: "/api/v1/courses/%d",
+			body: func(*models.Course) interface{} {
+				return map[string][]string{"tags": {"项目实战"}}
+			},
+			count: countCourseTags,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			db := testhelpers.NewDB(t)
+			srv := testhelpers.NewServer(t, db)
+			f := factory.New(t, db)
+
+			course, other := f.Course(9900), f.Course(4900)
+			path := fmt.Sprintf(tc.path, course.ID)
+
+			// other 的讲师不是 course 的讲师
+			intruder := srv.Login(instructorOf(t, db, other).Email, factory.Password)
+			resp := srv.Do(tc.method, path, intruder, tc.body(other))
+			if resp.Status != http.StatusForbidden || resp.Code(t) != services.CodeForbidden {
+				t.Fatalf("其他讲师请求返回 %d: %s，期望403", resp.Status, resp.Body)
+			}
+			if n := tc.count(t, db, course.ID); n != 0 {
+				t.Errorf("其他讲师请求后写入了 %d 条记录，期望 0", n)
+			}
+
+			owner := srv.Login(instructorOf(t, db, course).Email, factory.Password)
+			srv.MustOK(tc.method, path, owner, tc.body(other))
+			if n := tc.count(t, db, course.ID); n != 1 {
+				t.Errorf("课程讲师请求后有 %d 条记录，期望 1", n)
+			}
+		})
+	}
+}
+
+// instructorOf 查询课程的讲师
+func instructorOf(t *testing.T, db *gorm.DB, course *models.Course) *models.User {
+	t.Helper()
+	var user models.User
+	if err := db.First(&user, course.InstructorID).Error; err != nil {
+		t.Fatalf("查询讲师失败: %v", err)
+	}
+	return &user
+}
+
+// countRows 统计查询的记录数
+func countRows(t *testing.T, query *gorm.DB) int64 {
+	t.Helper()
+	var n int64
+	if err := query.Count(&n).Error; err != nil {
+		t.Fatalf("统计记录失败: %v", err)
+	}
+	return n
+}
+
+// countCourseTags 课程的标签数
+func countCourseTags(t *testing.T, db *gorm.DB, courseID uint) int64 {
+	t.Helper()
+	return countRows(t, db.Table(models.Table(db, "course_tags")).Where("course_id = ?", courseID))
+}
@@ -0,0 +1,91 @@
+package e2e
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"edu-platform/testhelpers"
+	"edu-platform/testhelpers/factory"
+)
+
+// TestDebugSQLRecordsServiceQueries 管理员带 X-Debug-SQL 请求订单、学习进度和用户接口时，响应的debug字段包含服务执行的SQL
+func TestDebugSQLRecordsServiceQueries(t *testing.T) {
+	t.Parallel()
+	db := testhelpers.NewDB(t)
+	srv := testhelpers.NewServer(t, db)
+	f := factory.New(t, db)
+
+	admin := f.User("admin")
+	course := f.Course(9900)
+	token := srv.Login(admin.Email, factory.Password)
+	debug := map[string]string{"X-Debug-SQL": "true"}
+
+	resp := srv.DoWithHeaders(http.MethodPost, "/api/v1/orders", token, map[string]interface{}{
+		"course_ids": []uint{course.ID},
+	}, debug)
+	assertDebugSQL(t, resp, "INSERT INTO `orders`")
+	var order struct {
+		OrderNo string `json:"order_no"`
+	}
+	resp.Data(t, &order)
+
+	resp = srv.DoWithHeaders(http.MethodPost, "/api/v1/orders/"+order.OrderNo+"/pay", token, map[string]string{
+		"payment_method": "alipay", "payment_no": "PAY-DEBUG-0001",
+	}, debug)
+	assertDebugSQL(t, resp, "UPDATE `orders`")
+
+	resp = srv.DoWithHeaders(http.MethodGet, "/api/v1/orders/"+order.OrderNo, token, nil, debug)
+	assertDebugSQL(t, resp, "FROM `orders`")
+
+	resp = srv.DoWithHeaders(http.MethodPost, "/api/v1/learning/progress", token, progressRequest(f, course.ID, 1, 30), debug)
+	assertDebugSQL(t, resp, "`learning_progress`")
+
+	resp = srv.DoWithHeaders(http.MethodGet, "/api/v1/me", token, nil, debug)
+	assertDebugSQL(t, resp, "FROM `users`")
+
+	// 未带请求头时不返回调试信息
+	resp = srv.MustOK(http.MethodGet, "/api/v1/orders/"+order.OrderNo, token, nil)
+	if statements := debugSQL(t, resp); statements != nil {
+		t.Errorf("未开启调试时不应返回SQL，实际 %d 条", len(statements))
+	}
+}
+
+// debugSQL 响应debug字段中的SQL，没有debug字段时返回nil
+func debugSQL(t *testing.T, resp *testhelpers.Response) []string {
+	t.Helper()
+	var body struct {
+		Debug *struct {
+			SQL []struct {
+				SQL string `json:"sql"`
+			} `json:"sql"`
+		} `json:"debug"`
+	}
+	if err := json.Unmarshal(resp.Body, &body); err != nil {
+		t.Fatalf("解析响应失败: %v\n%s", err, resp.Body)
+	}
+	if body.Debug == nil {
+		return nil
+	}
+	statements := make([]string, 0, len(body.Debug.SQL))
+	for _, stmt := range body.Debug.SQL {
+		statements = append(statements, stmt.SQL)
+	}
+	return statements
+}
+
+// assertDebugSQL 请求成功且记录的SQL中有包含want的语句
+func assertDebugSQL(t *testing.T, resp *testhelpers.Response, want string) {
+	t.Helper()
+	if resp.Status != http.StatusOK {
+		t.Fatalf("请求失败 %d: %s", resp.Status, resp.Body)
+	}
+	statements := debugSQL(t, resp)
+	for _, sql := range statements {
+		if strings.Contains(sql, want) {
+			return
+		}
+	}
+	t.Errorf("记录的SQL中没有 %q，共 %d 条:\n%s", want, len(statements), strings.Join(statements, "\n"))
+}
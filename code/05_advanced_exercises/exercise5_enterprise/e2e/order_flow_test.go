@@ -0,0 +1,160 @@
+// Package e2e 端到端接口测试：请求经过完整的路由和中间件，数据写入每个测试独立的内存SQLite数据库，
+// 响应去掉ID、时间、token等易变字段后与 testdata 下的golden文件比较。
+// 接口响应有意变更时运行 go test ./e2e/ -update 重新生成golden文件，并在提交中一起审阅
+package e2e
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"edu-platform/models"
+	"edu-platform/services"
+	"edu-platform/testhelpers"
+	"edu-platform/testhelpers/factory"
+)
+
+// TestOrderLifecycle 注册 → 登录 → 浏览课程 → 下单 → 支付 → 学习进度 → 发票 → 确认收货
+func TestOrderLifecycle(t *testing.T) {
+	t.Parallel()
+	db := testhelpers.NewSeededDB(t)
+	srv := testhelpers.NewServer(t, db)
+	f := factory.New(t, db)
+
+	// 注册：发送验证码 → 校验验证码取得 verification_token → 注册
+	const email = "newstudent@example.com"
+	srv.MustOK(http.MethodPost, "/api/v1/users/verification-code", "", map[string]string{
+		"target": email, "purpose": services.PurposeRegister,
+	})
+	code := srv.Codes.Code(email, services.PurposeRegister)
+	if code == "" {
+		t.Fatal("没有发送注册验证码")
+	}
+	resp := srv.MustOK(http.MethodPost, "/api/v1/users/verification-code/verify", "", map[string]string{
+		"target": email, "purpose": services.PurposeRegister, "code": code,
+	})
+	var verified struct {
+		VerificationToken string `json:"verification_token"`
+	}
+	resp.Data(t, &verified)
+
+	resp = srv.MustOK(http.MethodPost, "/api/v1/users/register", "", map[string]string{
+		"username":           "newstudent",
+		"email":              email,
+		"password":           "secret123",
+		"nickname":           "新同学",
+		"verification_token": verified.VerificationToken,
+	})
+	testhelpers.AssertGolden(t, "lifecycle/01_register", resp.Body)
+
+	// 登录
+	resp = srv.MustOK(http.MethodPost, "/api/v1/users/login", "", map[string]string{
+		"email": email, "password": "secret123",
+	})
+	testhelpers.AssertGolden(t, "lifecycle/02_login", resp.Body)
+	var login struct {
+		Token string `json:"token"`
+	}
+	resp.Data(t, &login)
+	token := login.Token
+
+	// 浏览课程
+	resp = srv.MustOK(http.MethodGet, "/api/v1/courses?sort=price&order=asc", "", nil)
+	testhelpers.AssertGolden(t, "lifecycle/03_courses", resp.Body)
+	var course models.Course
+	if err := db.Where("slug = ?", "golang-tutorial").First(&course).Error; err != nil {
+		t.Fatalf("查询课程失败: %v", err)
+	}
+	courseID := course.ID
+	resp = srv.MustOK(http.MethodGet, fmt.Sprintf("/api/v1/courses/%d", courseID), token, nil)
+	testhelpers.AssertGolden(t, "lifecycle/04_course_detail", resp.Body)
+
+	// 下单
+	resp = srv.MustOK(http.MethodPost, "/api/v1/orders", token, map[string]interface{}{
+		"course_ids": []uint{courseID},
+	})
+	testhelpers.AssertGolden(t, "lifecycle/05_create_order", resp.Body)
+	var order struct {
+		OrderNo string `json:"order_no"`
+	}
+	resp.Data(t, &order)
+
+	// 未支付时只能学习免费课时
+	resp = srv.Do(http.MethodPost, "/api/v1/learning/progress", token, progressRequest(f, courseID, 1, 50))
+	if resp.Status != http.StatusForbidden {
+		t.Fatalf("未支付的课程学习收费课时应返回403: %d %s", resp.Status, resp.Body)
+	}
+	testhelpers.AssertGolden(t, "lifecycle/06_progress_unpaid", resp.Body)
+
+	// 支付
+	resp = srv.MustOK(http.MethodPost, "/api/v1/orders/"+order.OrderNo+"/pay", token, map[string]string{
+		"payment_method": "alipay", "payment_no": "PAY-E2E-0001",
+	})
+	testhelpers.AssertGolden(t, "lifecycle/07_pay", resp.Body)
+
+	// 重复支付
+	resp = srv.Do(http.MethodPost, "/api/v1/orders/"+order.OrderNo+"/pay", token, map[string]string{
+		"payment_method": "alipay", "payment_no": "PAY-E2E-0001",
+	})
+	testhelpers.AssertGolden(t, "lifecycle/08_pay_again", resp.Body)
+
+	resp = srv.MustOK(http.MethodGet, "/api/v1/orders/"+order.OrderNo, token, nil)
+	testhelpers.AssertGolden(t, "lifecycle/09_order_paid", resp.Body)
+
+	// 学习进度
+	srv.MustOK(http.MethodPost, "/api/v1/learning/progress", token, progressRequest(f, courseID, 1, 100))
+	resp = srv.MustOK(http.MethodGet, fmt.Sprintf("/api/v1/learning/courses/%d/progress", courseID), token, nil)
+	testhelpers.AssertGolden(t, "lifecycle/10_course_progress", resp.Body)
+	resp = srv.MustOK(http.MethodGet, "/api/v1/learning/courses", token, nil)
+	testhelpers.AssertGolden(t, "lifecycle/11_learning_courses", resp.Body)
+
+	// 发票由支付时写入的outbox事件异步开具，测试中直接投递一次
+	relayOutbox(t, srv)
+	resp = srv.MustOK(http.MethodGet, "/api/v1/orders/"+order.OrderNo+"/invoice", token, nil)
+	testhelpers.AssertGolden(t, "lifecycle/12_invoice", resp.Body)
+
+	// 确认收货
+	resp = srv.MustOK(http.MethodPost, "/api/v1/orders/"+order.OrderNo+"/confirm-receipt", token, nil)
+	testhelpers.AssertGolden(t, "lifecycle/13_confirm_receipt", resp.Body)
+}
+
+// TestOrderNotVisibleToOtherUser 其他用户不能查看订单和获取发票
+func TestOrderNotVisibleToOtherUser(t *testing.T) {
+	t.Parallel()
+	db := testhelpers.NewDB(t)
+	srv := testhelpers.NewServer(t, db)
+	f := factory.New(t, db)
+
+	owner := f.User("student")
+	other := f.User("student")
+	course := f.Course(9900)
+	order := f.PaidOrder(owner.ID, course.ID)
+	relayOutbox(t, srv)
+
+	token := srv.Login(other.Email, factory.Password)
+	resp := srv.Do(http.MethodGet, "/api/v1/orders/"+order.OrderNo, token, nil)
+	testhelpers.AssertGolden(t, "lifecycle/other_user_order", resp.Body)
+	resp = srv.Do(http.MethodGet, "/api/v1/orders/"+order.OrderNo+"/invoice", token, nil)
+	testhelpers.AssertGolden(t, "lifecycle/other_user_invoice", resp.Body)
+}
+
+// progressRequest 课程第index个课时（从0开始）的学习进度请求
+func progressRequest(f *factory.Factory, courseID uint, index, progress int) map[string]interface{} {
+	lessons := f.Lessons(courseID)
+	return map[string]interface{}{
+		"course_id":  courseID,
+		"lesson_id":  lessons[index].ID,
+		"progress":   progress,
+		"watch_time": progress * 6,
+	}
+}
+
+// relayOutbox 投递一次outbox中的待处理事件（开具发票等）
+func relayOutbox(t *testing.T, srv *testhelpers.Server) {
+	t.Helper()
+	mux := services.MergeOutboxMux(services.NewInvoiceService(srv.DB).OutboxHandlers())
+	if _, err := services.NewOutboxService(srv.DB).RelayOutbox(context.Background(), mux); err != nil {
+		t.Fatalf("投递outbox事件失败: %v", err)
+	}
+}
@@ -0,0 +1,53 @@
+package e2e
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"edu-platform/models"
+	"edu-platform/services"
+	"edu-platform/testhelpers"
+	"edu-platform/testhelpers/factory"
+)
+
+// TestRetentionPurgeRequiresAdmin 数据清理接口只允许管理员调用：学生和讲师调用返回403且不删除数据，管理员调用删除过期数据
+func TestRetentionPurgeRequiresAdmin(t *testing.T) {
+	t.Parallel()
+	db := testhelpers.NewDB(t)
+	srv := testhelpers.NewServer(t, db)
+	f := factory.New(t, db)
+
+	expired := &models.VerificationCode{
+		Target:    "someone@example.test",
+		Purpose:   services.PurposeRegister,
+		CodeHash:  "hash",
+		ExpiresAt: time.Now().AddDate(0, 0, -10),
+	}
+	if err := db.Create(expired).Error; err != nil {
+		t.Fatalf("创建过期验证码失败: %v", err)
+	}
+	const path = "/api/v1/admin/retention/purge"
+	body := map[string]string{"table": "verification_codes"}
+
+	for _, role := range []string{"student", "instructor"} {
+		token := srv.Login(f.User(role).Email, factory.Password)
+		resp := srv.Do(http.MethodPost, path, token, body)
+		if resp.Status != http.StatusForbidden || resp.Code(t) != services.CodeForbidden {
+			t.Errorf("%s 调用清理接口返回 %d: %s，期望403", role, resp.Status, resp.Body)
+		}
+	}
+	if n := countRows(t, db.Model(&models.VerificationCode{})); n != 1 {
+		t.Fatalf("非管理员调用后剩余 %d 条验证码，期望 1", n)
+	}
+
+	token := srv.Login(f.User("admin").Email, factory.Password)
+	var result services.PurgeResult
+	srv.MustOK(http.MethodPost, path, token, body).Data(t, &result)
+	if result.Rows != 1 {
+		t.Errorf("管理员清理了 %d 行，期望 1", result.Rows)
+	}
+	if n := countRows(t, db.Model(&models.VerificationCode{})); n != 0 {
+		t.Errorf("管理员清理后剩余 %d 条验证码，期望 0", n)
+	}
+}
@@ -0,0 +1,431 @@
+// Package fixtures 从JSON文件加载示例数据
+// 数据文件按依赖顺序加载：roles.json → users.json → categories.json → courses.json，
+// 记录之间通过业务键引用（用户引用角色名，课程引用分类slug和讲师用户名），加载时解析为ID。
+package fixtures
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+
+	"edu-platform/models"
+	"edu-platform/services"
+)
+
+//go:embed data/*.json
+var embedded embed.FS
+
+var validate = validator.New()
+
+// Error 夹具加载错误，记录出错的文件和记录序号
+type Error struct {
+	File  string
+	Index int // 记录序号（从1开始），0表示文件级错误
+	Err   error
+}
+
+// Error 实现error接口
+func (e *Error) Error() string {
+	if e.Index == 0 {
+		return fmt.Sprintf("fixtures: %s: %v", e.File, e.Err)
+	}
+	return fmt.Sprintf("fixtures: %s 第%d条记录: %v", e.File, e.Index, e.Err)
+}
+
+// Unwrap 返回底层错误
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// 夹具记录格式，外键使用业务键表示
+type roleFixture struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type userFixture struct {
+	Username string          `json:"username"`
+	Email    string          `json:"email"`
+	Phone    string          `json:"phone"`
+	Password string          `json:"password"`
+	Nickname string          `json:"nickname"`
+	Role     string          `json:"role"` // 角色名
+	Profile  *profileFixture `json:"profile"`
+}
+
+type profileFixture struct {
+	RealName string `json:"real_name"`
+	Gender   int8   `json:"gender"`
+	Bio      string `json:"bio"`
+	Company  string `json:"company"`
+	Position string `json:"position"`
+}
+
+type categoryFixture struct {
+	Name        string `json:"name"`
+	Slug        string `json:"slug"`
+	Description string `json:"description"`
+	Parent      string `json:"parent"` // 父分类slug，可选
+	Sort        int    `json:"sort"`
+}
+
+type courseFixture struct {
+	Title         string           `json:"title"`
+	Slug          string           `json:"slug"`
+	Subtitle      string           `json:"subtitle"`
+	Description   string           `json:"description"`
+	Category      string           `json:"category"`   // 分类slug
+	Instructor    string           `json:"instructor"` // 讲师用户名
+	Price         int64            `json:"price"`
+	OriginalPrice int64            `json:"original_price"`
+	Level         int8             `json:"level"`
+	Duration      int              `json:"duration"`
+	Status        int8             `json:"status"`
+	IsFree        bool             `json:"is_free"`
+	Tags          []string         `json:"tags"`
+	Chapters      []chapterFixture `json:"chapters"`
+}
+
+type chapterFixture struct {
+	Title       string          `json:"title"`
+	Description string          `json:"description"`
+	Sort        int             `json:"sort"`
+	Lessons     []lessonFixture `json:"lessons"`
+}
+
+type lessonFixture struct {
+	Title    string `json:"title"`
+	Duration int    `json:"duration"`
+	Sort     int    `json:"sort"`
+	IsFree   bool   `json:"is_free"`
+}
+
+// Load 加载内置的示例数据
+func Load(db *gorm.DB) error {
+	data, err := fs.Sub(embedded, "data")
+	if err != nil {
+		return err
+	}
+	return LoadFS(db, data)
+}
+
+// LoadFS 从fsys根目录加载夹具文件，不存在的文件跳过
+// 所有数据在同一个事务中写入，任一记录失败则全部回滚
+func LoadFS(db *gorm.DB, fsys fs.FS) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		l := &loader{
+			tx:         tx,
+			fsys:       fsys,
+			roles:      make(map[string]uint),
+			users:      make(map[string]uint),
+			categories: make(map[string]uint),
+		}
+
+		steps := []struct {
+			file string
+			load func(records []json.RawMessage) error
+		}{
+			{"roles.json", l.loadRoles},
+			{"users.json", l.loadUsers},
+			{"categories.json", l.loadCategories},
+			{"courses.json", l.loadCourses},
+		}
+		for _, step := range steps {
+			records, err := l.read(step.file)
+			if err != nil {
+				return err
+			}
+			if err := step.load(records); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// loader 加载过程的状态，缓存已插入记录的业务键到ID的映射
+type loader struct {
+	tx   *gorm.DB
+	fsys fs.FS
+	file string // 当前加载的文件
+
+	roles      map[string]uint // 角色名 → ID
+	users      map[string]uint // 用户名 → ID
+	categories map[string]uint // 分类slug → ID
+}
+
+// read 读取文件中的记录数组，文件不存在时返回nil
+func (l *loader) read(file string) ([]json.RawMessage, error) {
+	l.file = file
+	content, err := fs.ReadFile(l.fsys, file)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, &Error{File: file, Err: err}
+	}
+
+	var records []json.RawMessage
+	if err := json.Unmarshal(content, &records); err != nil {
+		return nil, &Error{File: file, Err: err}
+	}
+	return records, nil
+}
+
+// fail 生成当前文件第i条记录（从0开始）的错误
+func (l *loader) fail(i int, err error) error {
+	return &Error{File: l.file, Index: i + 1, Err: err}
+}
+
+// decode 严格解析单条记录，未知字段视为错误
+func decode(raw json.RawMessage, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.DisallowUnknownFields()
+	return dec.Decode(v)
+}
+
+// validateModel 按模型的validate标签校验，只校验模型自身字段，不进入关联结构体
+func validateModel(model interface{}) error {
+	return validate.StructFiltered(model, func(ns []byte) bool {
+		return bytes.Count(ns, []byte(".")) > 1
+	})
+}
+
+// resolve 解析业务键：优先使用本次已加载的记录，其次查询数据库中已有的数据
+func (l *loader) resolve(cache map[string]uint, model interface{}, column, key, kind string) (uint, error) {
+	if id, ok := cache[key]; ok {
+		return id, nil
+	}
+
+	var id uint
+	err := l.tx.Model(model).Select("id").Where(column+" = ?", key).Limit(1).Scan(&id).Error
+	if err != nil {
+		return 0, err
+	}
+	if id == 0 {
+		return 0, fmt.Errorf("引用的%s %q 不存在", kind, key)
+	}
+	cache[key] = id
+	return id, nil
+}
+
+func (l *loader) loadRoles(records []json.RawMessage) error {
+	for i, raw := range records {
+		var f roleFixture
+		if err := decode(raw, &f); err != nil {
+			return l.fail(i, err)
+		}
+
+		role := models.Role{Name: f.Name, Description: f.Description, Status: 1}
+		if err := validateModel(&role); err != nil {
+			return l.fail(i, err)
+		}
+		if err := l.tx.Create(&role).Error; err != nil {
+			return l.fail(i, err)
+		}
+		l.roles[role.Name] = role.ID
+	}
+	return nil
+}
+
+func (l *loader) loadUsers(records []json.RawMessage) error {
+	for i, raw := range records {
+		var f userFixture
+		if err := decode(raw, &f); err != nil {
+			return l.fail(i, err)
+		}
+
+		roleID, err := l.resolve(l.roles, &models.Role{}, "name", f.Role, "角色")
+		if err != nil {
+			return l.fail(i, err)
+		}
+
+		user := models.User{
+			Username: f.Username,
+			Email:    f.Email,
+			Phone:    f.Phone,
+			Password: f.Password,
+			Nickname: f.Nickname,
+			Status:   models.UserStatusActive,
+			RoleID:   roleID,
+		}
+		if err := validateModel(&user); err != nil {
+			return l.fail(i, err)
+		}
+
+		var profile models.UserProfile
+		if f.Profile != nil {
+			profile = models.UserProfile{
+				RealName: f.Profile.RealName,
+				Gender:   f.Profile.Gender,
+				Bio:      f.Profile.Bio,
+				Company:  f.Profile.Company,
+				Position: f.Profile.Position,
+			}
+			if err := validateModel(&profile); err != nil {
+				return l.fail(i, err)
+			}
+		}
+
+		// 用户的AfterCreate钩子会创建空资料，这里再补充资料内容
+		if err := l.tx.Create(&user).Error; err != nil {
+			return l.fail(i, err)
+		}
+		if f.Profile != nil {
+			if err := l.tx.Model(&models.UserProfile{}).Where("user_id = ?", user.ID).
+				Updates(&profile).Error; err != nil {
+				return l.fail(i, err)
+			}
+		}
+		l.users[user.Username] = user.ID
+	}
+	return nil
+}
+
+func (l *loader) loadCategories(records []json.RawMessage) error {
+	for i, raw := range records {
+		var f categoryFixture
+		if err := decode(raw, &f); err != nil {
+			return l.fail(i, err)
+		}
+
+		category := models.Category{
+			Name:        f.Name,
+			Slug:        f.Slug,
+			Description: f.Description,
+			Sort:        f.Sort,
+			Status:      1,
+		}
+		// 父分类需在同一文件的前面定义或已存在于数据库
+		if f.Parent != "" {
+			parentID, err := l.resolve(l.categories, &models.Category{}, "slug", f.Parent, "分类")
+			if err != nil {
+				return l.fail(i, err)
+			}
+			category.ParentID = &parentID
+		}
+		if err := validateModel(&category); err != nil {
+			return l.fail(i, err)
+		}
+		if err := l.tx.Create(&category).Error; err != nil {
+			return l.fail(i, err)
+		}
+		l.categories[category.Slug] = category.ID
+	}
+	return nil
+}
+
+func (l *loader) loadCourses(records []json.RawMessage) error {
+	for i, raw := range records {
+		var f courseFixture
+		if err := decode(raw, &f); err != nil {
+			return l.fail(i, err)
+		}
+		if err := l.loadCourse(f); err != nil {
+			return l.fail(i, err)
+		}
+	}
+	return nil
+}
+
+// loadCourse 创建课程及其章节、课时
+func (l *loader) loadCourse(f courseFixture) error {
+	categoryID, err := l.resolve(l.categories, &models.Category{}, "slug", f.Category, "分类")
+	if err != nil {
+		return err
+	}
+	instructorID, err := l.resolve(l.users, &models.User{}, "username", f.Instructor, "讲师")
+	if err != nil {
+		return err
+	}
+
+	lessonCount := 0
+	for _, chapter := range f.Chapters {
+		lessonCount += len(chapter.Lessons)
+	}
+
+	course := models.Course{
+		Title:         f.Title,
+		Slug:          f.Slug,
+		Subtitle:      f.Subtitle,
+		Description:   f.Description,
+		CategoryID:    categoryID,
+		InstructorID:  instructorID,
+		Price:         f.Price,
+		OriginalPrice: f.OriginalPrice,
+		Level:         defaultInt8(f.Level, 1),
+		Duration:      f.Duration,
+		Status:        models.CourseStatus(defaultInt8(f.Status, int8(models.CourseStatusDraft))),
+		IsFree:        f.IsFree,
+		LessonCount:   lessonCount,
+	}
+	if err := validateModel(&course); err != nil {
+		return err
+	}
+	if err := l.tx.Create(&course).Error; err != nil {
+		return err
+	}
+	if len(f.Tags) > 0 {
+		if _, err := services.ReplaceCourseTags(l.tx, course.ID, f.Tags); err != nil {
+			return err
+		}
+	}
+	if err := l.tx.Model(&models.Category{}).Where("id = ?", categoryID).
+		UpdateColumn("course_count", gorm.Expr("course_count + ?", 1)).Error; err != nil {
+		return err
+	}
+
+	for ci, cf := range f.Chapters {
+		chapter := models.Chapter{
+			CourseID:    course.ID,
+			Title:       cf.Title,
+			Description: cf.Description,
+			Sort:        cf.Sort,
+			Status:      1,
+			LessonCount: len(cf.Lessons),
+		}
+		seconds := 0
+		for _, lf := range cf.Lessons {
+			seconds += lf.Duration
+		}
+		chapter.Duration = seconds / 60 // 课时时长为秒，章节时长为分钟
+		if err := validateModel(&chapter); err != nil {
+			return fmt.Errorf("第%d个章节: %w", ci+1, err)
+		}
+		if err := l.tx.Create(&chapter).Error; err != nil {
+			return fmt.Errorf("第%d个章节: %w", ci+1, err)
+		}
+
+		for li, lf := range cf.Lessons {
+			lesson := models.Lesson{
+				ChapterID: chapter.ID,
+				Title:     lf.Title,
+				Duration:  lf.Duration,
+				Sort:      lf.Sort,
+				IsFree:    lf.IsFree,
+				Status:    1,
+			}
+			if err := validateModel(&lesson); err != nil {
+				return fmt.Errorf("第%d个章节第%d个课时: %w", ci+1, li+1, err)
+			}
+			if err := l.tx.Create(&lesson).Error; err != nil {
+				return fmt.Errorf("第%d个章节第%d个课时: %w", ci+1, li+1, err)
+			}
+		}
+	}
+	return nil
+}
+
+// defaultInt8 值为0时使用默认值（与模型字段的数据库默认值一致）
+func defaultInt8(v, def int8) int8 {
+	if v == 0 {
+		return def
+	}
+	return v
+}
@@ -0,0 +1,123 @@
+package fixtures_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"gorm.io/gorm"
+
+	"edu-platform/fixtures"
+	"edu-platform/models"
+	"edu-platform/testhelpers"
+	"edu-platform/testhelpers/factory"
+)
+
+// idBy 按业务键查询记录ID，不存在时返回0
+func idBy(t *testing.T, db *gorm.DB, model interface{}, column, key string) uint {
+	t.Helper()
+	var id uint
+	if err := db.Model(model).Select("id").Where(column+" = ?", key).Scan(&id).Error; err != nil {
+		t.Fatalf("查询 %T %s=%s 失败: %v", model, column, key, err)
+	}
+	return id
+}
+
+// TestLoadResolvesReferences 内置示例数据中的业务键解析为对应记录的ID
+func TestLoadResolvesReferences(t *testing.T) {
+	t.Parallel()
+	db := testhelpers.NewSeededDB(t)
+
+	var instructor models.User
+	if err := db.Preload("Profile").Where("username = ?", "instructor1").First(&instructor).Error; err != nil {
+		t.Fatalf("查询讲师失败: %v", err)
+	}
+	if want := idBy(t, db, &models.Role{}, "name", "instructor"); instructor.RoleID != want {
+		t.Errorf("讲师的角色为 %d，期望 instructor 角色 %d", instructor.RoleID, want)
+	}
+	if instructor.Profile.RealName != "张老师" {
+		t.Errorf("讲师资料为 %+v，期望补充 real_name", instructor.Profile)
+	}
+
+	var course models.Course
+	if err := db.Where("slug = ?", "golang-tutorial").First(&course).Error; err != nil {
+		t.Fatalf("查询课程失败: %v", err)
+	}
+	if want := idBy(t, db, &models.Category{}, "slug", "programming"); course.CategoryID != want {
+		t.Errorf("课程分类为 %d，期望 programming 分类 %d", course.CategoryID, want)
+	}
+	if course.InstructorID != instructor.ID {
+		t.Errorf("课程讲师为 %d，期望 instructor1 %d", course.InstructorID, instructor.ID)
+	}
+}
+
+// TestLoadFSExistingReferences 引用可以指向同一文件中前面的记录，也可以指向数据库中已有的数据
+func TestLoadFSExistingReferences(t *testing.T) {
+	t.Parallel()
+	db := testhelpers.NewDB(t)
+	f := factory.New(t, db)
+	teacher := f.User("instructor")
+
+	fsys := fstest.MapFS{
+		"users.json": {Data: []byte(`[
+			{"username": "loaded_teacher", "email": "loaded@example.test", "password": "password", "role": "instructor"}
+		]`)},
+		"categories.json": {Data: []byte(`[
+			{"name": "后端", "slug": "backend"},
+			{"name": "Go", "slug": "go", "parent": "backend"}
+		]`)},
+		"courses.json": {Data: []byte(`[
+			{"title": "Go并发", "slug": "go-concurrency", "category": "go", "instructor": "` + teacher.Username + `", "price": 100}
+		]`)},
+	}
+	if err := fixtures.LoadFS(db, fsys); err != nil {
+		t.Fatalf("加载失败: %v", err)
+	}
+
+	var user models.User
+	db.Where("username = ?", "loaded_teacher").First(&user)
+	if user.RoleID != teacher.RoleID {
+		t.Errorf("用户角色为 %d，期望数据库中已有的 instructor 角色 %d", user.RoleID, teacher.RoleID)
+	}
+
+	var child models.Category
+	db.Where("slug = ?", "go").First(&child)
+	if parent := idBy(t, db, &models.Category{}, "slug", "backend"); child.ParentID == nil || *child.ParentID != parent {
+		t.Errorf("子分类的父分类为 %v，期望 %d", child.ParentID, parent)
+	}
+
+	var course models.Course
+	db.Where("slug = ?", "go-concurrency").First(&course)
+	if course.CategoryID != child.ID || course.InstructorID != teacher.ID {
+		t.Errorf("课程分类 %d、讲师 %d，期望 %d、%d", course.CategoryID, course.InstructorID, child.ID, teacher.ID)
+	}
+}
+
+// TestLoadFSUnknownReference 引用不存在时返回带文件名和记录序号的错误，已写入的数据全部回滚
+func TestLoadFSUnknownReference(t *testing.T) {
+	t.Parallel()
+	db := testhelpers.NewDB(t)
+
+	fsys := fstest.MapFS{
+		"roles.json": {Data: []byte(`[{"name": "reviewer"}]`)},
+		"categories.json": {Data: []byte(`[
+			{"name": "后端", "slug": "backend"},
+			{"name": "Go", "slug": "go", "parent": "frontend"}
+		]`)},
+	}
+	err := fixtures.LoadFS(db, fsys)
+	var fixtureErr *fixtures.Error
+	if !errors.As(err, &fixtureErr) {
+		t.Fatalf("返回 %v，期望 *fixtures.Error", err)
+	}
+	if fixtureErr.File != "categories.json" || fixtureErr.Index != 2 || !strings.Contains(err.Error(), `"frontend"`) {
+		t.Errorf("错误为 %v，期望指出 categories.json 第2条记录引用的 frontend", err)
+	}
+	if id := idBy(t, db, &models.Role{}, "name", "reviewer"); id != 0 {
+		t.Error("加载失败后角色未回滚")
+	}
+	if id := idBy(t, db, &models.Category{}, "slug", "backend"); id != 0 {
+		t.Error("加载失败后分类未回滚")
+	}
+}
@@ -0,0 +1,108 @@
+package i18n
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 支持的语言
+const (
+	LocaleZhCN = "zh-CN"
+	LocaleEn   = "en"
+
+	DefaultLocale = LocaleZhCN
+)
+
+// localeKey gin上下文中保存语言的键
+const localeKey = "locale"
+
+// warnedIDs 已经告警过的未知消息ID，避免同一ID重复刷日志
+var warnedIDs sync.Map
+
+// Translate 按语言翻译消息，args用于格式化消息中的占位符
+// 当前语言缺少翻译时回退到默认语言，消息ID不存在时返回ID本身并记录告警
+func Translate(locale, msgID string, args ...interface{}) string {
+	texts, ok := catalog[msgID]
+	if !ok {
+		if _, warned := warnedIDs.LoadOrStore(msgID, true); !warned {
+			log.Printf("[WARN] i18n: 未知的消息ID %q", msgID)
+		}
+		return msgID
+	}
+
+	text, ok := texts[locale]
+	if !ok {
+		text = texts[DefaultLocale]
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(text, args...)
+	}
+	return text
+}
+
+// T 按当前请求的语言翻译消息
+func T(c *gin.Context, msgID string, args ...interface{}) string {
+	return Translate(FromContext(c), msgID, args...)
+}
+
+// FromContext 获取当前请求的语言
+func FromContext(c *gin.Context) string {
+	if locale := c.GetString(localeKey); locale != "" {
+		return locale
+	}
+	return DefaultLocale
+}
+
+// Middleware 根据Accept-Language解析请求语言，默认zh-CN
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(localeKey, ParseAcceptLanguage(c.GetHeader("Accept-Language")))
+		c.Next()
+	}
+}
+
+// ParseAcceptLanguage 从Accept-Language中选出权重最高的受支持语言
+// 例如 "en-US,en;q=0.9,zh;q=0.8" 解析为 en
+func ParseAcceptLanguage(header string) string {
+	best, bestQ := DefaultLocale, -1.0
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		tag := strings.ToLower(strings.TrimSpace(fields[0]))
+		if tag == "" {
+			continue
+		}
+
+		q := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if v, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					q = v
+				}
+			}
+		}
+
+		locale := matchLocale(tag)
+		if locale != "" && q > bestQ {
+			best, bestQ = locale, q
+		}
+	}
+	return best
+}
+
+// matchLocale 将语言标签映射到受支持的语言
+func matchLocale(tag string) string {
+	switch {
+	case tag == "zh" || strings.HasPrefix(tag, "zh-"):
+		return LocaleZhCN
+	case tag == "en" || strings.HasPrefix(tag, "en-"):
+		return LocaleEn
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,89 @@
+package i18n
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestParseAcceptLanguage 选出权重最高的受支持语言，没有受支持的语言时回退到zh-CN
+func TestParseAcceptLanguage(t *testing.T) {
+	t.Parallel()
+	cases := map[string]string{
+		"":                           LocaleZhCN,
+		"en":                         LocaleEn,
+		"en-US,en;q=0.9,zh;q=0.8":    LocaleEn,
+		"zh-TW":                      LocaleZhCN,
+		"fr-FR,zh;q=0.5,en;q=0.7":    LocaleEn,
+		"fr-FR,de;q=0.9":             LocaleZhCN, // 都不支持
+		"ja, EN-GB;q=0.3":            LocaleEn,   // 不区分大小写，q前允许空格
+		"zh;q=0.2,en;q=abc":          LocaleEn,   // q无法解析时按1处理
+		" , ;q=0.5":                  LocaleZhCN,
+		"en;q=0.5,zh-CN;q=0.5":       LocaleEn, // 权重相同时取先出现的
+		"zh-CN;q=0.5,en-US;q=0.5000": LocaleZhCN,
+	}
+	for header, want := range cases {
+		if got := ParseAcceptLanguage(header); got != want {
+			t.Errorf("ParseAcceptLanguage(%q) = %s，期望 %s", header, got, want)
+		}
+	}
+}
+
+// TestTranslateFallback 不支持的语言回退到zh-CN，未知的消息ID返回ID本身
+func TestTranslateFallback(t *testing.T) {
+	t.Parallel()
+	if got := Translate(LocaleEn, "error.not_found"); got != "Resource not found" {
+		t.Errorf("en: %q", got)
+	}
+	if got := Translate("fr", "error.not_found"); got != "资源不存在" {
+		t.Errorf("不支持的语言返回 %q，期望回退到zh-CN", got)
+	}
+	if got := Translate(LocaleEn, "error.invalid_param", "page"); got != "Invalid value for parameter page" {
+		t.Errorf("带参数的消息返回 %q", got)
+	}
+	if got := Translate(LocaleEn, "no.such.message"); got != "no.such.message" {
+		t.Errorf("未知的消息ID返回 %q，期望返回ID本身", got)
+	}
+}
+
+// TestCatalogComplete 每条消息都同时提供zh-CN和en翻译
+func TestCatalogComplete(t *testing.T) {
+	t.Parallel()
+	for msgID, texts := range catalog {
+		for _, locale := range []string{LocaleZhCN, LocaleEn} {
+			if texts[locale] == "" {
+				t.Errorf("消息 %s 缺少 %s 翻译", msgID, locale)
+			}
+		}
+	}
+}
+
+// TestMiddlewareLocale 中间件按Accept-Language设置请求语言，T按该语言翻译
+func TestMiddlewareLocale(t *testing.T) {
+	t.Parallel()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(Middleware())
+	r.GET("/", func(c *gin.Context) { c.String(http.StatusOK, T(c, "error.forbidden")) })
+
+	cases := map[string]string{
+		"":                "没有权限",
+		"en-US,en;q=0.9":  "Permission denied",
+		"de-DE":           "没有权限",
+		"de-DE,en;q=0.1":  "Permission denied",
+		"zh-CN,en;q=0.99": "没有权限",
+	}
+	for header, want := range cases {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if header != "" {
+			req.Header.Set("Accept-Language", header)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Body.String() != want {
+			t.Errorf("Accept-Language=%q 返回 %q，期望 %q", header, w.Body.String(), want)
+		}
+	}
+}
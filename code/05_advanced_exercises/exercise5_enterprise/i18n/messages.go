@@ -0,0 +1,203 @@
+package i18n
+
+// catalog 消息目录：消息ID -> 语言 -> 文本
+// 消息ID一经对外使用不要修改，新增消息时需同时提供zh-CN和en翻译
+var catalog = map[string]map[string]string{
+	// 通用错误
+	"error.validation":        {LocaleZhCN: "参数错误", LocaleEn: "Invalid parameters"},
+	"error.unauthorized":      {LocaleZhCN: "未登录", LocaleEn: "Not logged in"},
+	"error.forbidden":         {LocaleZhCN: "没有权限", LocaleEn: "Permission denied"},
+	"error.not_found":         {LocaleZhCN: "资源不存在", LocaleEn: "Resource not found"},
+	"error.conflict":          {LocaleZhCN: "资源冲突", LocaleEn: "Resource conflict"},
+	"error.invalid_include":   {LocaleZhCN: "不支持加载关联%s，可选：%s", LocaleEn: "Cannot include %s; valid options: %s"},
+	"error.invalid_param":     {LocaleZhCN: "参数%s的值无效", LocaleEn: "Invalid value for parameter %s"},
+	"error.too_many_requests": {LocaleZhCN: "请求过于频繁", LocaleEn: "Too many requests"},
+	"error.page_too_deep":     {LocaleZhCN: "分页过深（超过%d条），请使用 after_id 游标分页", LocaleEn: "Page is too deep (beyond %d rows); use after_id cursor pagination instead"},
+	"error.internal":          {LocaleZhCN: "服务器内部错误", LocaleEn: "Internal server error"},
+	"error.query_failed":      {LocaleZhCN: "查询失败", LocaleEn: "Query failed"},
+	"error.update_failed":     {LocaleZhCN: "更新失败", LocaleEn: "Update failed"},
+
+	// 认证
+	"auth.invalid_credentials":     {LocaleZhCN: "邮箱或密码错误", LocaleEn: "Incorrect email or password"},
+	"auth.account_disabled":        {LocaleZhCN: "账户已被禁用", LocaleEn: "Account is disabled"},
+	"auth.invalid_token":           {LocaleZhCN: "token无效", LocaleEn: "Invalid token"},
+	"auth.impersonate_self":        {LocaleZhCN: "不能模拟登录自己", LocaleEn: "You cannot impersonate yourself"},
+	"auth.impersonate_admin":       {LocaleZhCN: "不能模拟登录管理员", LocaleEn: "Administrators cannot be impersonated"},
+	"auth.admin_required":          {LocaleZhCN: "需要管理员权限", LocaleEn: "Administrator access required"},
+	"auth.impersonation_forbidden": {LocaleZhCN: "模拟登录时不能执行此操作", LocaleEn: "This action is not allowed while impersonating a user"},
+
+	// 密码
+	"password.incorrect":       {LocaleZhCN: "当前密码错误", LocaleEn: "Current password is incorrect"},
+	"password.too_short":       {LocaleZhCN: "密码至少需要%d位", LocaleEn: "Password must be at least %d characters"},
+	"password.same_as_account": {LocaleZhCN: "密码不能与用户名或邮箱相同", LocaleEn: "Password must not be the same as your username or email"},
+
+	// 用户
+	"user.username_exists": {LocaleZhCN: "用户名已存在", LocaleEn: "Username already exists"},
+	"user.email_exists":    {LocaleZhCN: "邮箱已存在", LocaleEn: "Email already exists"},
+	"user.phone_exists":    {LocaleZhCN: "手机号已存在", LocaleEn: "Phone number already exists"},
+	"user.not_found":       {LocaleZhCN: "用户不存在", LocaleEn: "User not found"},
+
+	// 通知
+	"notification.ids_required": {LocaleZhCN: "请选择通知", LocaleEn: "No notifications selected"},
+	"notification.too_many_ids": {LocaleZhCN: "一次最多操作%d条通知", LocaleEn: "At most %d notifications per request"},
+
+	// 验证码
+	"verification.invalid_purpose": {LocaleZhCN: "验证码用途无效", LocaleEn: "Invalid verification purpose"},
+	"verification.too_frequent":    {LocaleZhCN: "验证码发送过于频繁，请稍后再试", LocaleEn: "Verification codes are requested too often, please try again later"},
+	"verification.code_invalid":    {LocaleZhCN: "验证码不存在或已失效，请重新获取", LocaleEn: "Verification code is invalid or has expired, please request a new one"},
+	"verification.code_mismatch":   {LocaleZhCN: "验证码错误，还可尝试%d次", LocaleEn: "Incorrect verification code, %d attempts left"},
+	"verification.token_invalid":   {LocaleZhCN: "请先完成邮箱或手机号验证", LocaleEn: "Please verify your email or phone number first"},
+
+	// 账户注销
+	"account.deletion_pending":       {LocaleZhCN: "账户已申请注销", LocaleEn: "Account deletion has been requested"},
+	"account.deletion_not_found":     {LocaleZhCN: "注销申请不存在", LocaleEn: "Deletion request not found"},
+	"account.deletion_grace_expired": {LocaleZhCN: "宽限期已过，无法撤销注销申请", LocaleEn: "The grace period has ended and the deletion can no longer be cancelled"},
+	"account.deletion_cancelled":     {LocaleZhCN: "注销申请已撤销", LocaleEn: "Deletion request has been cancelled"},
+
+	// 讲师申请
+	"instructor.already_instructor":     {LocaleZhCN: "您已经是讲师", LocaleEn: "You are already an instructor"},
+	"instructor.application_pending":    {LocaleZhCN: "您已有待审核的讲师申请", LocaleEn: "You already have a pending instructor application"},
+	"instructor.application_not_found":  {LocaleZhCN: "讲师申请不存在", LocaleEn: "Instructor application not found"},
+	"instructor.application_reviewed":   {LocaleZhCN: "该申请已审核，不能重复处理", LocaleEn: "This application has already been reviewed"},
+	"instructor.reject_reason_required": {LocaleZhCN: "请填写拒绝原因", LocaleEn: "A reason is required to reject an application"},
+	"instructor.role_missing":           {LocaleZhCN: "讲师角色不存在", LocaleEn: "Instructor role is not configured"},
+
+	// 课程
+	"course.slug_exists":             {LocaleZhCN: "课程标识已存在", LocaleEn: "Course slug already exists"},
+	"course.slug_invalid":            {LocaleZhCN: "课程标识只能包含小写字母、数字和连字符，不能全是数字，最长%d个字符", LocaleEn: "Course slug may contain only lowercase letters, digits and hyphens, must not be all digits, and is at most %d characters"},
+	"course.not_found":               {LocaleZhCN: "课程不存在", LocaleEn: "Course not found"},
+	"course.unavailable":             {LocaleZhCN: "部分课程不存在或已下架", LocaleEn: "Some courses do not exist or are no longer available"},
+	"course.publish_failed":          {LocaleZhCN: "发布失败", LocaleEn: "Publish failed"},
+	"course.not_owner":               {LocaleZhCN: "只能修改自己的课程", LocaleEn: "You can only edit your own courses"},
+	"course.draft_not_found":         {LocaleZhCN: "课程没有待发布的大纲草稿", LocaleEn: "Course has no outline draft to publish"},
+	"course.outline_title_required":  {LocaleZhCN: "章节和课时标题不能为空", LocaleEn: "Chapter and lesson titles are required"},
+	"course.outline_duplicate_id":    {LocaleZhCN: "大纲中ID %d 重复", LocaleEn: "ID %d appears more than once in the outline"},
+	"course.outline_unknown_chapter": {LocaleZhCN: "章节 %d 不属于该课程", LocaleEn: "Chapter %d does not belong to this course"},
+	"course.outline_unknown_lesson":  {LocaleZhCN: "课时 %d 不属于该课程", LocaleEn: "Lesson %d does not belong to this course"},
+	"course.prerequisite_self":       {LocaleZhCN: "课程不能以自身为先修课程", LocaleEn: "A course cannot be its own prerequisite"},
+	"course.prerequisite_not_found":  {LocaleZhCN: "部分先修课程不存在", LocaleEn: "Some prerequisite courses do not exist"},
+	"course.prerequisite_cycle":      {LocaleZhCN: "设置《%s》为先修课程会形成循环依赖", LocaleEn: "Making \"%s\" a prerequisite would create a cycle"},
+	"course.prerequisite_not_met":    {LocaleZhCN: "请先学完先修课程：%s", LocaleEn: "Please complete the prerequisite courses first: %s"},
+	"course.full":                    {LocaleZhCN: "课程《%s》已满员，可以加入候补名单", LocaleEn: "\"%s\" is full; you can join the waitlist"},
+	"course.tag_invalid":             {LocaleZhCN: "标签不能为空，最长%d个字符", LocaleEn: "Tags must not be empty and are at most %d characters"},
+	"course.too_many_tags":           {LocaleZhCN: "一门课程最多%d个标签", LocaleEn: "A course can have at most %d tags"},
+
+	// 限时促销
+	"promotion.invalid_window": {LocaleZhCN: "促销结束时间必须晚于开始时间", LocaleEn: "Promotion must end after it starts"},
+	"promotion.invalid_price":  {LocaleZhCN: "促销价必须低于课程价格", LocaleEn: "Promotion price must be lower than the course price"},
+	"promotion.overlap":        {LocaleZhCN: "与该课程已有的促销时间重叠", LocaleEn: "Promotion overlaps an existing promotion for this course"},
+	"promotion.not_found":      {LocaleZhCN: "促销不存在", LocaleEn: "Promotion not found"},
+
+	// 订单金额一致性检查
+	"order_audit.invalid_strategy": {LocaleZhCN: "修复方式只能是 %s 或 %s", LocaleEn: "Strategy must be %s or %s"},
+	"order_audit.confirm_required": {LocaleZhCN: "已付款的订单须确认后才能修复（confirm: true）", LocaleEn: "Repairing a paid order requires confirmation (confirm: true)"},
+	"order_audit.consistent":       {LocaleZhCN: "订单金额一致，无需修复", LocaleEn: "Order amounts are consistent; nothing to repair"},
+	"order_audit.items_below_paid": {LocaleZhCN: "订单项合计低于实付金额，无法以订单项为准修复，请改用 trust-order", LocaleEn: "Items total is below the paid amount; use trust-order instead"},
+
+	// 后台任务
+	"job.not_found": {LocaleZhCN: "后台任务 %s 不存在", LocaleEn: "Job %s not found"},
+	"job.running":   {LocaleZhCN: "后台任务 %s 正在执行，请稍后再试", LocaleEn: "Job %s is already running; try again later"},
+
+	// 全站搜索
+	"search.keyword_required": {LocaleZhCN: "请输入搜索关键词", LocaleEn: "Search keyword is required"},
+	"search.keyword_too_long": {LocaleZhCN: "搜索关键词不能超过%d个字符", LocaleEn: "Search keyword must be at most %d characters"},
+
+	// 结业证书
+	"certificate.not_found": {LocaleZhCN: "证书不存在，请核对证书编号", LocaleEn: "Certificate not found; please check the serial number"},
+
+	// 候补
+	"waitlist.not_limited":     {LocaleZhCN: "该课程不限人数，无需候补", LocaleEn: "This course has no enrollment limit"},
+	"waitlist.seats_available": {LocaleZhCN: "课程还有名额，可以直接购买", LocaleEn: "Seats are still available; you can enroll directly"},
+	"waitlist.already_joined":  {LocaleZhCN: "您已在候补名单中", LocaleEn: "You are already on the waitlist"},
+	"waitlist.not_joined":      {LocaleZhCN: "您不在该课程的候补名单中", LocaleEn: "You are not on the waitlist for this course"},
+
+	// 选课
+	"enrollment.emails_required": {LocaleZhCN: "请提供要开通的邮箱", LocaleEn: "Please provide the emails to enroll"},
+	"enrollment.too_many_emails": {LocaleZhCN: "每次最多开通%d个邮箱", LocaleEn: "At most %d emails can be enrolled at a time"},
+	"enrollment.unknown_emails":  {LocaleZhCN: "有%d个邮箱没有对应的用户，本次未开通", LocaleEn: "%d emails do not belong to any user, nothing was enrolled"},
+
+	// 课程讨论
+	"discussion.forbidden":         {LocaleZhCN: "只有已购买课程的学员和讲师可以参与讨论", LocaleEn: "Only enrolled students and the instructor can join the discussion"},
+	"discussion.thread_not_found":  {LocaleZhCN: "讨论主题不存在", LocaleEn: "Discussion thread not found"},
+	"discussion.reply_not_found":   {LocaleZhCN: "回复不存在", LocaleEn: "Reply not found"},
+	"discussion.resolve_forbidden": {LocaleZhCN: "只有讲师可以将问题标记为已解决", LocaleEn: "Only the instructor can mark a thread as resolved"},
+	"discussion.delete_forbidden":  {LocaleZhCN: "只能删除自己的回复", LocaleEn: "You can only delete your own replies"},
+
+	// 课程包
+	"bundle.not_found":    {LocaleZhCN: "课程包不存在", LocaleEn: "Bundle not found"},
+	"bundle.unavailable":  {LocaleZhCN: "部分课程包不存在或已下架", LocaleEn: "Some bundles do not exist or are no longer available"},
+	"bundle.owned_course": {LocaleZhCN: "您已拥有课程包中的部分课程", LocaleEn: "You already own some courses in this bundle"},
+
+	// 订单
+	"order.empty":                 {LocaleZhCN: "请选择要购买的课程或课程包", LocaleEn: "Please choose courses or bundles to purchase"},
+	"order.duplicate_course":      {LocaleZhCN: "课程《%s》在订单中重复", LocaleEn: "Course \"%s\" appears more than once in the order"},
+	"order.already_purchased":     {LocaleZhCN: "您已购买过部分课程", LocaleEn: "You have already purchased some of these courses"},
+	"order.coupon_invalid":        {LocaleZhCN: "优惠券不存在或已失效", LocaleEn: "Coupon does not exist or has expired"},
+	"order.coupon_min_amount":     {LocaleZhCN: "订单金额不满足优惠券使用条件，最低消费%.2f元", LocaleEn: "Order amount does not meet the coupon minimum of %.2f yuan"},
+	"order.not_payable":           {LocaleZhCN: "订单不存在或状态异常", LocaleEn: "Order does not exist or cannot be paid"},
+	"order.expired":               {LocaleZhCN: "订单已过期", LocaleEn: "Order has expired"},
+	"order.not_cancelable":        {LocaleZhCN: "订单不存在或无法取消", LocaleEn: "Order does not exist or cannot be cancelled"},
+	"order.not_found":             {LocaleZhCN: "订单不存在", LocaleEn: "Order not found"},
+	"order.not_paid":              {LocaleZhCN: "订单未支付，无法退款", LocaleEn: "Order has not been paid and cannot be refunded"},
+	"order.fully_refunded":        {LocaleZhCN: "订单已全部退款", LocaleEn: "Order has already been fully refunded"},
+	"order.refund_items_required": {LocaleZhCN: "请选择需要退款的订单项", LocaleEn: "Please select the order items to refund"},
+	"order.refund_items_invalid":  {LocaleZhCN: "部分订单项不存在或已退款", LocaleEn: "Some order items do not exist or have already been refunded"},
+	"order.forbidden":             {LocaleZhCN: "无权操作该订单", LocaleEn: "You are not allowed to operate on this order"},
+	"order.invalid_date":          {LocaleZhCN: "参数%s的日期格式错误，应为 2006-01-02 或 RFC3339 格式", LocaleEn: "Parameter %s must be a date like 2006-01-02 or an RFC3339 time"},
+	"order.not_receivable":        {LocaleZhCN: "订单未支付或已取消，无法确认收货", LocaleEn: "Order is unpaid or cancelled and cannot be confirmed as received"},
+
+	// 发票
+	"invoice.not_found":     {LocaleZhCN: "发票尚未开具", LocaleEn: "Invoice has not been issued yet"},
+	"invoice.invalid_month": {LocaleZhCN: "月份格式错误: %s，应为YYYY-MM", LocaleEn: "Invalid month: %s, expected YYYY-MM"},
+
+	// 邮件模板
+	"email.unknown_template": {LocaleZhCN: "不支持的邮件模板: %s", LocaleEn: "Unsupported email template: %s"},
+	"email.invalid_template": {LocaleZhCN: "邮件模板无效: %s", LocaleEn: "Invalid email template: %s"},
+	"email.empty_subject":    {LocaleZhCN: "邮件主题不能为空", LocaleEn: "Email subject must not be empty"},
+
+	// 财务
+	"finance.invalid_month": {LocaleZhCN: "结算月份无效: %s，应为YYYY-MM", LocaleEn: "Invalid payout month: %s, expected YYYY-MM"},
+
+	// 学习
+	"learning.forbidden": {LocaleZhCN: "您没有权限学习该课程", LocaleEn: "You do not have access to this course"},
+
+	// 数据导出
+	"export.job_not_found": {LocaleZhCN: "导出任务不存在或已过期", LocaleEn: "Export job not found or expired"},
+	"export.failed":        {LocaleZhCN: "数据导出失败", LocaleEn: "Data export failed"},
+
+	// 数据保留
+	"retention.table_not_allowed": {LocaleZhCN: "表 %s 不允许清理", LocaleEn: "Table %s cannot be purged"},
+	"retention.purge_failed":      {LocaleZhCN: "清理失败", LocaleEn: "Purge failed"},
+
+	// 数据健康检查
+	"data_health.unknown_table":       {LocaleZhCN: "表 %s 不存在", LocaleEn: "Table %s does not exist"},
+	"data_health.unknown_foreign_key": {LocaleZhCN: "表 %s 没有外键 %s", LocaleEn: "Table %s has no foreign key %s"},
+
+	// 管理后台时间线
+	"timeline.unknown_type":   {LocaleZhCN: "不支持的事件类型: %s", LocaleEn: "Unsupported event type: %s"},
+	"timeline.invalid_date":   {LocaleZhCN: "日期格式不正确，应为YYYY-MM-DD", LocaleEn: "Invalid date, expected YYYY-MM-DD"},
+	"timeline.invalid_cursor": {LocaleZhCN: "分页游标无效", LocaleEn: "Invalid pagination cursor"},
+
+	// 自定义报表
+	"report.unknown_entity":       {LocaleZhCN: "不支持的报表对象: %s", LocaleEn: "Unsupported report entity: %s"},
+	"report.unknown_field":        {LocaleZhCN: "报表对象 %s 不支持字段: %s", LocaleEn: "Report entity %s has no field: %s"},
+	"report.invalid_operator":     {LocaleZhCN: "字段 %s 不支持操作符: %s", LocaleEn: "Field %s does not support operator: %s"},
+	"report.invalid_value":        {LocaleZhCN: "字段 %s 的 %s 条件值无效", LocaleEn: "Invalid value for field %s with operator %s"},
+	"report.not_groupable":        {LocaleZhCN: "字段 %s 不能用于分组", LocaleEn: "Field %s cannot be used for grouping"},
+	"report.invalid_aggregation":  {LocaleZhCN: "不支持的聚合: %s(%s)", LocaleEn: "Unsupported aggregation: %s(%s)"},
+	"report.aggregation_required": {LocaleZhCN: "至少需要一个聚合", LocaleEn: "At least one aggregation is required"},
+	"report.too_complex":          {LocaleZhCN: "报表条件过多，筛选最多%d个、分组最多%d个、聚合最多%d个", LocaleEn: "Report is too complex: at most %d filters, %d group-by fields and %d aggregations"},
+	"report.invalid_sort":         {LocaleZhCN: "不能按 %s 排序，只能使用分组字段或聚合列", LocaleEn: "Cannot sort by %s: only group-by fields and aggregation columns are allowed"},
+	"report.timeout":              {LocaleZhCN: "报表查询超时，请缩小查询范围", LocaleEn: "Report query timed out, please narrow the filters"},
+
+	// 参数校验，第一个参数为字段名，第二个参数为校验参数
+	"validation.required": {LocaleZhCN: "%s为必填项", LocaleEn: "%s is required"},
+	"validation.email":    {LocaleZhCN: "%s必须是有效的邮箱地址", LocaleEn: "%s must be a valid email address"},
+	"validation.min":      {LocaleZhCN: "%s不能小于%s", LocaleEn: "%s must be at least %s"},
+	"validation.max":      {LocaleZhCN: "%s不能大于%s", LocaleEn: "%s must be at most %s"},
+	"validation.len":      {LocaleZhCN: "%s的长度必须为%s", LocaleEn: "%s must have length %s"},
+	"validation.oneof":    {LocaleZhCN: "%s必须是[%s]之一", LocaleEn: "%s must be one of [%s]"},
+	"validation.gt":       {LocaleZhCN: "%s必须大于%s", LocaleEn: "%s must be greater than %s"},
+	"validation.gte":      {LocaleZhCN: "%s必须大于或等于%s", LocaleEn: "%s must be greater than or equal to %s"},
+	"validation.invalid":  {LocaleZhCN: "%s格式不正确", LocaleEn: "%s is invalid"},
+}
@@ -0,0 +1,34 @@
+package i18n
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+// TranslateValidation 翻译参数校验错误
+// 每个字段错误按校验标签查找 validation.<tag> 消息，未收录的标签使用 validation.invalid；
+// 不是校验错误（如JSON格式错误）时返回空字符串
+func TranslateValidation(c *gin.Context, err error) string {
+	var verrs validator.ValidationErrors
+	if !errors.As(err, &verrs) {
+		return ""
+	}
+
+	messages := make([]string, 0, len(verrs))
+	for _, fe := range verrs {
+		msgID := "validation." + fe.Tag()
+		if _, ok := catalog[msgID]; !ok {
+			msgID = "validation.invalid"
+		}
+
+		if fe.Param() != "" {
+			messages = append(messages, T(c, msgID, fe.Field(), fe.Param()))
+		} else {
+			messages = append(messages, T(c, msgID, fe.Field()))
+		}
+	}
+	return strings.Join(messages, "; ")
+}
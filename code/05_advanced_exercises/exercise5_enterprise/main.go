@@ -1,249 +1,24 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
 	"strconv"
+	"syscall"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
-)
+	"gorm.io/gorm/schema"
 
-// ========== 数据模型定义 ==========
-
-// BaseModel 基础模型
-type BaseModel struct {
-	ID        uint           `gorm:"primarykey" json:"id"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at"`
-}
-
-// User 用户模型
-type User struct {
-	BaseModel
-	Username    string       `gorm:"uniqueIndex;size:50;not null" json:"username"`
-	Email       string       `gorm:"uniqueIndex;size:100;not null" json:"email"`
-	Phone       string       `gorm:"uniqueIndex;size:20" json:"phone"`
-	Password    string       `gorm:"size:255;not null" json:"-"`
-	Nickname    string       `gorm:"size:50" json:"nickname"`
-	Avatar      string       `gorm:"size:255" json:"avatar"`
-	Status      int8         `gorm:"default:1;comment:1-正常,2-禁用" json:"status"`
-	RoleID      uint         `gorm:"index;not null" json:"role_id"`
-	LastLoginAt *time.Time   `json:"last_login_at"`
-	
-	// 关联
-	Role            Role             `gorm:"foreignKey:RoleID" json:"role,omitempty"`
-	Profile         UserProfile      `gorm:"foreignKey:UserID" json:"profile,omitempty"`
-	Orders          []Order          `gorm:"foreignKey:UserID" json:"orders,omitempty"`
-	LearningProgress []LearningProgress `gorm:"foreignKey:UserID" json:"learning_progress,omitempty"`
-}
-
-// TableName 指定表名
-func (User) TableName() string {
-	return "users"
-}
-
-// Role 角色模型
-type Role struct {
-	BaseModel
-	Name        string `gorm:"uniqueIndex;size:50;not null" json:"name"`
-	Description string `gorm:"size:255" json:"description"`
-	Status      int8   `gorm:"default:1;comment:1-启用,2-禁用" json:"status"`
-	
-	// 关联
-	Users []User `gorm:"foreignKey:RoleID" json:"users,omitempty"`
-}
-
-// TableName 指定表名
-func (Role) TableName() string {
-	return "roles"
-}
-
-// UserProfile 用户资料模型
-type UserProfile struct {
-	BaseModel
-	UserID   uint   `gorm:"uniqueIndex;not null" json:"user_id"`
-	RealName string `gorm:"size:50" json:"real_name"`
-	Gender   int8   `gorm:"default:0;comment:0-未知,1-男,2-女" json:"gender"`
-	Birthday *time.Time `json:"birthday"`
-	Bio      string `gorm:"type:text" json:"bio"`
-	Location string `gorm:"size:100" json:"location"`
-	Website  string `gorm:"size:255" json:"website"`
-	
-	// 关联
-	User User `gorm:"foreignKey:UserID" json:"user,omitempty"`
-}
-
-// TableName 指定表名
-func (UserProfile) TableName() string {
-	return "user_profiles"
-}
-
-// Category 课程分类模型
-type Category struct {
-	BaseModel
-	Name        string `gorm:"size:50;not null" json:"name"`
-	Slug        string `gorm:"uniqueIndex;size:100;not null" json:"slug"`
-	Description string `gorm:"type:text" json:"description"`
-	Icon        string `gorm:"size:255" json:"icon"`
-	ParentID    *uint  `gorm:"index" json:"parent_id"`
-	Sort        int    `gorm:"default:0" json:"sort"`
-	Status      int8   `gorm:"default:1;comment:1-启用,2-禁用" json:"status"`
-	
-	// 关联
-	Parent   *Category `gorm:"foreignKey:ParentID" json:"parent,omitempty"`
-	Children []Category `gorm:"foreignKey:ParentID" json:"children,omitempty"`
-	Courses  []Course   `gorm:"foreignKey:CategoryID" json:"courses,omitempty"`
-}
-
-// TableName 指定表名
-func (Category) TableName() string {
-	return "categories"
-}
-
-// Course 课程模型
-type Course struct {
-	BaseModel
-	Title       string `gorm:"size:255;not null" json:"title"`
-	Slug        string `gorm:"uniqueIndex;size:255;not null" json:"slug"`
-	Description string `gorm:"type:text" json:"description"`
-	Cover       string `gorm:"size:255" json:"cover"`
-	CategoryID  uint   `gorm:"index;not null" json:"category_id"`
-	InstructorID uint  `gorm:"index;not null" json:"instructor_id"`
-	Price       int64  `gorm:"not null;comment:价格(分)" json:"price"`
-	OriginalPrice int64 `gorm:"default:0;comment:原价(分)" json:"original_price"`
-	Level       int8   `gorm:"default:1;comment:1-初级,2-中级,3-高级" json:"level"`
-	Duration    int    `gorm:"default:0;comment:课程时长(分钟)" json:"duration"`
-	StudentCount int   `gorm:"default:0;comment:学生数量" json:"student_count"`
-	LessonCount  int   `gorm:"default:0;comment:课时数量" json:"lesson_count"`
-	Rating      float32 `gorm:"default:0;comment:评分" json:"rating"`
-	Status      int8   `gorm:"default:1;comment:1-草稿,2-发布,3-下架" json:"status"`
-	PublishedAt *time.Time `json:"published_at"`
-	
-	// 关联
-	Category    Category  `gorm:"foreignKey:CategoryID" json:"category,omitempty"`
-	Instructor  User      `gorm:"foreignKey:InstructorID" json:"instructor,omitempty"`
-	Chapters    []Chapter `gorm:"foreignKey:CourseID" json:"chapters,omitempty"`
-	Orders      []Order   `gorm:"many2many:order_items;" json:"orders,omitempty"`
-}
-
-// TableName 指定表名
-func (Course) TableName() string {
-	return "courses"
-}
-
-// Chapter 章节模型
-type Chapter struct {
-	BaseModel
-	CourseID    uint   `gorm:"index;not null" json:"course_id"`
-	Title       string `gorm:"size:255;not null" json:"title"`
-	Description string `gorm:"type:text" json:"description"`
-	Sort        int    `gorm:"default:0" json:"sort"`
-	Status      int8   `gorm:"default:1;comment:1-启用,2-禁用" json:"status"`
-	
-	// 关联
-	Course  Course   `gorm:"foreignKey:CourseID" json:"course,omitempty"`
-	Lessons []Lesson `gorm:"foreignKey:ChapterID" json:"lessons,omitempty"`
-}
-
-// TableName 指定表名
-func (Chapter) TableName() string {
-	return "chapters"
-}
-
-// Lesson 课时模型
-type Lesson struct {
-	BaseModel
-	ChapterID   uint   `gorm:"index;not null" json:"chapter_id"`
-	Title       string `gorm:"size:255;not null" json:"title"`
-	Description string `gorm:"type:text" json:"description"`
-	VideoURL    string `gorm:"size:500" json:"video_url"`
-	Duration    int    `gorm:"default:0;comment:时长(秒)" json:"duration"`
-	Sort        int    `gorm:"default:0" json:"sort"`
-	IsFree      bool   `gorm:"default:false;comment:是否免费" json:"is_free"`
-	Status      int8   `gorm:"default:1;comment:1-启用,2-禁用" json:"status"`
-	
-	// 关联
-	Chapter          Chapter            `gorm:"foreignKey:ChapterID" json:"chapter,omitempty"`
-	LearningProgress []LearningProgress `gorm:"foreignKey:LessonID" json:"learning_progress,omitempty"`
-}
-
-// TableName 指定表名
-func (Lesson) TableName() string {
-	return "lessons"
-}
-
-// Order 订单模型
-type Order struct {
-	BaseModel
-	OrderNo        string     `gorm:"uniqueIndex;size:50;not null" json:"order_no"`
-	UserID         uint       `gorm:"index;not null" json:"user_id"`
-	TotalAmount    int64      `gorm:"not null;comment:总金额(分)" json:"total_amount"`
-	PayAmount      int64      `gorm:"not null;comment:实付金额(分)" json:"pay_amount"`
-	DiscountAmount int64      `gorm:"default:0;comment:优惠金额(分)" json:"discount_amount"`
-	Status         int8       `gorm:"index;default:1;comment:1-待付款,2-已付款,3-已完成,4-已取消" json:"status"`
-	PaymentMethod  string     `gorm:"size:50" json:"payment_method"`
-	PaymentNo      string     `gorm:"size:100" json:"payment_no"`
-	PaidAt         *time.Time `json:"paid_at"`
-	ExpiredAt      *time.Time `json:"expired_at"`
-	Remark         string     `gorm:"type:text" json:"remark"`
-	
-	// 关联
-	User    User      `gorm:"foreignKey:UserID" json:"user,omitempty"`
-	Items   []OrderItem `gorm:"foreignKey:OrderID" json:"items,omitempty"`
-	Courses []Course  `gorm:"many2many:order_items;" json:"courses,omitempty"`
-}
-
-// TableName 指定表名
-func (Order) TableName() string {
-	return "orders"
-}
-
-// OrderItem 订单项模型
-type OrderItem struct {
-	BaseModel
-	OrderID     uint   `gorm:"index;not null" json:"order_id"`
-	CourseID    uint   `gorm:"index;not null" json:"course_id"`
-	CourseName  string `gorm:"size:255;not null" json:"course_name"`
-	Price       int64  `gorm:"not null;comment:价格(分)" json:"price"`
-	OriginalPrice int64 `gorm:"default:0;comment:原价(分)" json:"original_price"`
-	
-	// 关联
-	Order  Order  `gorm:"foreignKey:OrderID" json:"order,omitempty"`
-	Course Course `gorm:"foreignKey:CourseID" json:"course,omitempty"`
-}
-
-// TableName 指定表名
-func (OrderItem) TableName() string {
-	return "order_items"
-}
-
-// LearningProgress 学习进度模型
-type LearningProgress struct {
-	BaseModel
-	UserID     uint `gorm:"index;not null" json:"user_id"`
-	CourseID   uint `gorm:"index;not null" json:"course_id"`
-	LessonID   uint `gorm:"index;not null" json:"lesson_id"`
-	Progress   int  `gorm:"default:0;comment:进度百分比" json:"progress"`
-	WatchTime  int  `gorm:"default:0;comment:观看时长(秒)" json:"watch_time"`
-	IsCompleted bool `gorm:"default:false;comment:是否完成" json:"is_completed"`
-	CompletedAt *time.Time `json:"completed_at"`
-	
-	// 关联
-	User   User   `gorm:"foreignKey:UserID" json:"user,omitempty"`
-	Course Course `gorm:"foreignKey:CourseID" json:"course,omitempty"`
-	Lesson Lesson `gorm:"foreignKey:LessonID" json:"lesson,omitempty"`
-}
-
-// TableName 指定表名
-func (LearningProgress) TableName() string {
-	return "learning_progress"
-}
+	"edu-platform/models"
+)
 
 // ========== 数据库配置 ==========
 
@@ -255,19 +30,111 @@ type DatabaseConfig struct {
 	Password string
 	DBName   string
 	Charset  string
+
+	MaxAttempts int  // 最大连接尝试次数，<=0时使用默认值10
+	FailFast    bool // 快速失败（测试模式），只尝试连接一次
+
+	TablePrefix   string // 表名前缀，部署到共享数据库时使用，如 edu_
+	SingularTable bool   // 未指定表名的表（如多对多连接表）使用单数表名
+
+	RetryBackoff time.Duration // 首次重试等待时间，之后每次加倍，<=0时使用默认值500ms
+	Dial         DialFunc      // 打开并检查连接，为nil时连接MySQL（openDatabase）；测试中替换为模拟实现
 }
 
+// DialFunc 打开数据库并确认连接可用，失败时返回错误由 ConnectDatabase 重试
+type DialFunc func(ctx context.Context, dsn string, naming schema.NamingStrategy) (*gorm.DB, error)
+
+const (
+	defaultConnectAttempts = 10                     // 默认最大连接尝试次数
+	connectBaseBackoff     = 500 * time.Millisecond // 首次重试等待时间
+	connectMaxBackoff      = 10 * time.Second       // 重试等待时间上限
+	connectPingTimeout     = 5 * time.Second        // 单次Ping超时时间
+)
+
 // ConnectDatabase 连接数据库
-func ConnectDatabase(config DatabaseConfig) (*gorm.DB, error) {
+// 数据库尚未就绪时（如docker-compose中应用先于MySQL启动）按指数退避重试，
+// 每次尝试都通过PingContext确认连接可用；ctx被取消时立即停止重试
+func ConnectDatabase(ctx context.Context, config DatabaseConfig) (*gorm.DB, error) {
 	dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=%s&parseTime=True&loc=Local",
 		config.User, config.Password, config.Host, config.Port, config.DBName, config.Charset)
 
+	maxAttempts := config.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = defaultConnectAttempts
+	}
+	if config.FailFast {
+		maxAttempts = 1
+	}
+
+	dial := config.Dial
+	if dial == nil {
+		dial = openDatabase
+	}
+	backoff := config.RetryBackoff
+	if backoff <= 0 {
+		backoff = connectBaseBackoff
+	}
+	var lastErr error
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("连接数据库已取消: %w", err)
+		}
+
+		db, err := dial(ctx, dsn, schema.NamingStrategy{
+			TablePrefix:   config.TablePrefix,
+			SingularTable: config.SingularTable,
+		})
+		if err == nil {
+			if attempt > 1 {
+				log.Printf("第%d次尝试连接数据库成功", attempt)
+			}
+			return db, nil
+		}
+
+		lastErr = err
+		log.Printf("连接数据库失败（第%d/%d次）: %v", attempt, maxAttempts, err)
+		if attempt == maxAttempts {
+			break
+		}
+
+		log.Printf("%v后重试...", backoff)
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("连接数据库已取消（最后一次错误: %v）: %w", lastErr, ctx.Err())
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > connectMaxBackoff {
+			backoff = connectMaxBackoff
+		}
+	}
+
+	return nil, fmt.Errorf("连接数据库失败，已尝试%d次: %w", maxAttempts, lastErr)
+}
+
+// openDatabase 打开数据库并Ping确认连接可用
+func openDatabase(ctx context.Context, dsn string, naming schema.NamingStrategy) (*gorm.DB, error) {
 	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Info),
+		Logger:         logger.Default.LogMode(logger.Info),
+		NamingStrategy: naming,
+		// 由下方的PingContext负责连接检查，以便支持超时和取消
+		DisableAutomaticPing: true,
 	})
+	if err != nil {
+		return nil, err
+	}
 
+	sqlDB, err := db.DB()
 	if err != nil {
-		return nil, fmt.Errorf("连接数据库失败: %w", err)
+		return nil, err
+	}
+
+	pingCtx, cancel := context.WithTimeout(ctx, connectPingTimeout)
+	defer cancel()
+	if err := sqlDB.PingContext(pingCtx); err != nil {
+		sqlDB.Close()
+		return nil, err
 	}
 
 	return db, nil
@@ -286,14 +153,14 @@ func NewUserService(db *gorm.DB) *UserService {
 }
 
 // GetUsers 获取用户列表
-func (s *UserService) GetUsers(page, pageSize int) ([]User, int64, error) {
-	var users []User
+func (s *UserService) GetUsers(page, pageSize int) ([]models.User, int64, error) {
+	var users []models.User
 	var total int64
 
 	offset := (page - 1) * pageSize
 
 	// 获取总数
-	if err := s.db.Model(&User{}).Count(&total).Error; err != nil {
+	if err := s.db.Model(&models.User{}).Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
@@ -305,14 +172,14 @@ func (s *UserService) GetUsers(page, pageSize int) ([]User, int64, error) {
 }
 
 // GetUserByID 根据ID获取用户
-func (s *UserService) GetUserByID(id uint) (*User, error) {
-	var user User
+func (s *UserService) GetUserByID(id uint) (*models.User, error) {
+	var user models.User
 	err := s.db.Preload("Role").Preload("Profile").First(&user, id).Error
 	return &user, err
 }
 
 // CreateUser 创建用户
-func (s *UserService) CreateUser(user *User) error {
+func (s *UserService) CreateUser(user *models.User) error {
 	return s.db.Create(user).Error
 }
 
@@ -327,11 +194,11 @@ func NewCourseService(db *gorm.DB) *CourseService {
 }
 
 // GetCourses 获取课程列表
-func (s *CourseService) GetCourses(page, pageSize int, categoryID *uint) ([]Course, int64, error) {
-	var courses []Course
+func (s *CourseService) GetCourses(page, pageSize int, categoryID *uint) ([]models.Course, int64, error) {
+	var courses []models.Course
 	var total int64
 
-	query := s.db.Model(&Course{}).Where("status = ?", 2) // 只查询已发布的课程
+	query := s.db.Model(&models.Course{}).Where("status = ?", 2) // 只查询已发布的课程
 	if categoryID != nil {
 		query = query.Where("category_id = ?", *categoryID)
 	}
@@ -351,15 +218,15 @@ func (s *CourseService) GetCourses(page, pageSize int, categoryID *uint) ([]Cour
 }
 
 // GetCourseByID 根据ID获取课程详情
-func (s *CourseService) GetCourseByID(id uint) (*Course, error) {
-	var course Course
+func (s *CourseService) GetCourseByID(id uint) (*models.Course, error) {
+	var course models.Course
 	err := s.db.Preload("Category").Preload("Instructor").
 		Preload("Chapters.Lessons").First(&course, id).Error
 	return &course, err
 }
 
 // CreateCourse 创建课程
-func (s *CourseService) CreateCourse(course *Course) error {
+func (s *CourseService) CreateCourse(course *models.Course) error {
 	return s.db.Create(course).Error
 }
 
@@ -374,7 +241,7 @@ func NewOrderService(db *gorm.DB) *OrderService {
 }
 
 // CreateOrder 创建订单
-func (s *OrderService) CreateOrder(userID uint, courseIDs []uint) (*Order, error) {
+func (s *OrderService) CreateOrder(userID uint, courseIDs []uint) (*models.Order, error) {
 	// 开启事务
 	tx := s.db.Begin()
 	defer func() {
@@ -384,7 +251,7 @@ func (s *OrderService) CreateOrder(userID uint, courseIDs []uint) (*Order, error
 	}()
 
 	// 查询课程信息
-	var courses []Course
+	var courses []models.Course
 	if err := tx.Where("id IN ? AND status = ?", courseIDs, 2).Find(&courses).Error; err != nil {
 		tx.Rollback()
 		return nil, err
@@ -402,12 +269,12 @@ func (s *OrderService) CreateOrder(userID uint, courseIDs []uint) (*Order, error
 	}
 
 	// 创建订单
-	order := &Order{
+	order := &models.Order{
 		OrderNo:     fmt.Sprintf("EDU%d", time.Now().UnixNano()),
 		UserID:      userID,
 		TotalAmount: totalAmount,
 		PayAmount:   totalAmount,
-		Status:      1, // 待付款
+		Status:      models.OrderStatusPending,
 		ExpiredAt:   &[]time.Time{time.Now().Add(30 * time.Minute)}[0], // 30分钟后过期
 	}
 
@@ -418,7 +285,7 @@ func (s *OrderService) CreateOrder(userID uint, courseIDs []uint) (*Order, error
 
 	// 创建订单项
 	for _, course := range courses {
-		orderItem := OrderItem{
+		orderItem := models.OrderItem{
 			OrderID:       order.ID,
 			CourseID:      course.ID,
 			CourseName:    course.Title,
@@ -436,14 +303,14 @@ func (s *OrderService) CreateOrder(userID uint, courseIDs []uint) (*Order, error
 }
 
 // GetOrdersByUserID 获取用户订单列表
-func (s *OrderService) GetOrdersByUserID(userID uint, page, pageSize int) ([]Order, int64, error) {
-	var orders []Order
+func (s *OrderService) GetOrdersByUserID(userID uint, page, pageSize int) ([]models.Order, int64, error) {
+	var orders []models.Order
 	var total int64
 
 	offset := (page - 1) * pageSize
 
 	// 获取总数
-	if err := s.db.Model(&Order{}).Where("user_id = ?", userID).Count(&total).Error; err != nil {
+	if err := s.db.Model(&models.Order{}).Where("user_id = ?", userID).Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
@@ -467,17 +334,17 @@ func NewLearningService(db *gorm.DB) *LearningService {
 // UpdateProgress 更新学习进度
 func (s *LearningService) UpdateProgress(userID, courseID, lessonID uint, progress, watchTime int) error {
 	// 查找或创建学习进度记录
-	var learningProgress LearningProgress
+	var learningProgress models.LearningProgress
 	err := s.db.Where("user_id = ? AND course_id = ? AND lesson_id = ?", userID, courseID, lessonID).
 		First(&learningProgress).Error
 
 	if err == gorm.ErrRecordNotFound {
 		// 创建新记录
-		learningProgress = LearningProgress{
-			UserID:   userID,
-			CourseID: courseID,
-			LessonID: lessonID,
-			Progress: progress,
+		learningProgress = models.LearningProgress{
+			UserID:    userID,
+			CourseID:  courseID,
+			LessonID:  lessonID,
+			Progress:  progress,
 			WatchTime: watchTime,
 		}
 		if progress >= 100 {
@@ -503,8 +370,8 @@ func (s *LearningService) UpdateProgress(userID, courseID, lessonID uint, progre
 }
 
 // GetUserCourseProgress 获取用户课程学习进度
-func (s *LearningService) GetUserCourseProgress(userID, courseID uint) ([]LearningProgress, error) {
-	var progress []LearningProgress
+func (s *LearningService) GetUserCourseProgress(userID, courseID uint) ([]models.LearningProgress, error) {
+	var progress []models.LearningProgress
 	err := s.db.Preload("Lesson").Where("user_id = ? AND course_id = ?", userID, courseID).
 		Order("lesson_id").Find(&progress).Error
 	return progress, err
@@ -602,7 +469,8 @@ func (c *CourseController) GetCourses(ctx *gin.Context) {
 	var categoryID *uint
 	if categoryIDStr != "" {
 		id, _ := strconv.ParseUint(categoryIDStr, 10, 32)
-		categoryID = (*uint)(&id)
+		cid := uint(id)
+		categoryID = &cid
 	}
 
 	courses, total, err := c.courseService.GetCourses(page, pageSize, categoryID)
@@ -727,7 +595,7 @@ func SeedData(db *gorm.DB) error {
 	fmt.Println("开始填充测试数据...")
 
 	// 创建角色
-	roles := []Role{
+	roles := []models.Role{
 		{Name: "admin", Description: "管理员"},
 		{Name: "instructor", Description: "讲师"},
 		{Name: "student", Description: "学生"},
@@ -735,23 +603,26 @@ func SeedData(db *gorm.DB) error {
 	db.Create(&roles)
 
 	// 创建用户
-	users := []User{
+	users := []models.User{
 		{Username: "admin", Email: "admin@example.com", Phone: "13800138001", Password: "password", Nickname: "管理员", RoleID: roles[0].ID},
 		{Username: "instructor1", Email: "instructor1@example.com", Phone: "13800138002", Password: "password", Nickname: "讲师1", RoleID: roles[1].ID},
 		{Username: "student1", Email: "student1@example.com", Phone: "13800138003", Password: "password", Nickname: "学生1", RoleID: roles[2].ID},
 	}
 	db.Create(&users)
 
-	// 创建用户资料
-	profiles := []UserProfile{
+	// 填写用户资料（空的资料记录由 User 的 AfterCreate 钩子创建）
+	profiles := []models.UserProfile{
 		{UserID: users[0].ID, RealName: "管理员", Gender: 1},
 		{UserID: users[1].ID, RealName: "张老师", Gender: 1},
 		{UserID: users[2].ID, RealName: "李同学", Gender: 2},
 	}
-	db.Create(&profiles)
+	for _, profile := range profiles {
+		db.Model(&models.UserProfile{}).Where("user_id = ?", profile.UserID).
+			Updates(models.UserProfile{RealName: profile.RealName, Gender: profile.Gender})
+	}
 
 	// 创建分类
-	categories := []Category{
+	categories := []models.Category{
 		{Name: "编程开发", Slug: "programming", Description: "编程开发相关课程"},
 		{Name: "设计创意", Slug: "design", Description: "设计创意相关课程"},
 		{Name: "产品运营", Slug: "product", Description: "产品运营相关课程"},
@@ -759,36 +630,36 @@ func SeedData(db *gorm.DB) error {
 	db.Create(&categories)
 
 	// 创建课程
-	courses := []Course{
+	courses := []models.Course{
 		{
-			Title:        "Go语言入门到精通",
-			Slug:         "golang-tutorial",
-			Description:  "从零开始学习Go语言，掌握现代编程技能",
-			CategoryID:   categories[0].ID,
-			InstructorID: users[1].ID,
-			Price:        19900, // 199元
+			Title:         "Go语言入门到精通",
+			Slug:          "golang-tutorial",
+			Description:   "从零开始学习Go语言，掌握现代编程技能",
+			CategoryID:    categories[0].ID,
+			InstructorID:  users[1].ID,
+			Price:         19900, // 199元
 			OriginalPrice: 29900, // 原价299元
-			Level:        1,
-			Duration:     1200, // 20小时
-			Status:       2,    // 已发布
+			Level:         1,
+			Duration:      1200, // 20小时
+			Status:        models.CourseStatusPublished,
 		},
 		{
-			Title:        "React前端开发实战",
-			Slug:         "react-tutorial",
-			Description:  "学习React框架，构建现代化前端应用",
-			CategoryID:   categories[0].ID,
-			InstructorID: users[1].ID,
-			Price:        24900, // 249元
+			Title:         "React前端开发实战",
+			Slug:          "react-tutorial",
+			Description:   "学习React框架，构建现代化前端应用",
+			CategoryID:    categories[0].ID,
+			InstructorID:  users[1].ID,
+			Price:         24900, // 249元
 			OriginalPrice: 34900, // 原价349元
-			Level:        2,
-			Duration:     1800, // 30小时
-			Status:       2,    // 已发布
+			Level:         2,
+			Duration:      1800, // 30小时
+			Status:        models.CourseStatusPublished,
 		},
 	}
 	db.Create(&courses)
 
 	// 创建章节
-	chapters := []Chapter{
+	chapters := []models.Chapter{
 		{CourseID: courses[0].ID, Title: "Go语言基础", Sort: 1},
 		{CourseID: courses[0].ID, Title: "Go语言进阶", Sort: 2},
 		{CourseID: courses[1].ID, Title: "React基础", Sort: 1},
@@ -797,7 +668,7 @@ func SeedData(db *gorm.DB) error {
 	db.Create(&chapters)
 
 	// 创建课时
-	lessons := []Lesson{
+	lessons := []models.Lesson{
 		{ChapterID: chapters[0].ID, Title: "Go语言介绍", Duration: 600, Sort: 1, IsFree: true},
 		{ChapterID: chapters[0].ID, Title: "变量和数据类型", Duration: 900, Sort: 2},
 		{ChapterID: chapters[1].ID, Title: "并发编程", Duration: 1200, Sort: 1},
@@ -864,25 +735,29 @@ func main() {
 		Password: "123456",
 		DBName:   "gorm_advanced_exercise5",
 		Charset:  "utf8mb4",
+
+		MaxAttempts: defaultConnectAttempts,
+		FailFast:    gin.Mode() == gin.TestMode,
 	}
 
+	// 启动阶段收到退出信号时停止重试
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+
 	// 连接数据库
 	fmt.Println("连接数据库...")
-	db, err := ConnectDatabase(config)
+	db, err := ConnectDatabase(ctx, config)
+	stop()
 	if err != nil {
 		log.Fatal("连接数据库失败:", err)
 	}
 
 	// 迁移数据库
 	fmt.Println("迁移数据库...")
-	db.AutoMigrate(
-		&Role{}, &User{}, &UserProfile{}, &Category{}, &Course{},
-		&Chapter{}, &Lesson{}, &Order{}, &OrderItem{}, &LearningProgress{},
-	)
+	db.AutoMigrate(models.All()...)
 
 	// 检查是否需要填充测试数据
 	var userCount int64
-	db.Model(&User{}).Count(&userCount)
+	db.Model(&models.User{}).Count(&userCount)
 	if userCount == 0 {
 		if err := SeedData(db); err != nil {
 			log.Fatal("填充测试数据失败:", err)
@@ -915,4 +790,4 @@ func main() {
 	if err := r.Run(":8080"); err != nil {
 		log.Fatal("启动服务器失败:", err)
 	}
-}
\ No newline at end of file
+}
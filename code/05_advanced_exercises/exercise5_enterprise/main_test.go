@@ -0,0 +1,217 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/schema"
+
+	"edu-platform/models"
+)
+
+var errNotReady = errors.New("dial tcp 127.0.0.1:3306: connect: connection refused")
+
+// fakeDial 前failures次返回errNotReady，之后返回db；记录调用次数
+type fakeDial struct {
+	failures int32
+	db       *gorm.DB
+	calls    int32
+	onCall   func(attempt int32)
+}
+
+func (f *fakeDial) dial(ctx context.Context, dsn string, naming schema.NamingStrategy) (*gorm.DB, error) {
+	attempt := atomic.AddInt32(&f.calls, 1)
+	if f.onCall != nil {
+		f.onCall(attempt)
+	}
+	if attempt <= f.failures {
+		return nil, errNotReady
+	}
+	return f.db, nil
+}
+
+func testDatabaseConfig(dial DialFunc) DatabaseConfig {
+	return DatabaseConfig{
+		Host: "127.0.0.1", Port: 3306, User: "root", Password: "secret", DBName: "edu", Charset: "utf8mb4",
+		RetryBackoff: time.Millisecond,
+		Dial:         dial,
+	}
+}
+
+func TestConnectDatabaseRetriesUntilSuccess(t *testing.T) {
+	want := &gorm.DB{}
+	fake := &fakeDial{failures: 3, db: want}
+	config := testDatabaseConfig(fake.dial)
+	config.MaxAttempts = 5
+
+	db, err := ConnectDatabase(context.Background(), config)
+	if err != nil {
+		t.Fatalf("ConnectDatabase() error = %v", err)
+	}
+	if db != want {
+		t.Errorf("ConnectDatabase() 返回的不是dial成功时的连接")
+	}
+	if fake.calls != 4 {
+		t.Errorf("尝试次数 = %d，期望 4", fake.calls)
+	}
+}
+
+func TestConnectDatabaseGivesUpAfterMaxAttempts(t *testing.T) {
+	fake := &fakeDial{failures: 100}
+	config := testDatabaseConfig(fake.dial)
+	config.MaxAttempts = 4
+
+	_, err := ConnectDatabase(context.Background(), config)
+	if !errors.Is(err, errNotReady) {
+		t.Fatalf("ConnectDatabase() error = %v，期望包含最后一次连接错误", err)
+	}
+	if fake.calls != 4 {
+		t.Errorf("尝试次数 = %d，期望 4", fake.calls)
+	}
+}
+
+func TestConnectDatabaseDefaultAttempts(t *testing.T) {
+	fake := &fakeDial{failures: 100}
+
+	if _, err := ConnectDatabase(context.Background(), testDatabaseConfig(fake.dial)); err == nil {
+		t.Fatal("ConnectDatabase() 应返回错误")
+	}
+	if fake.calls != defaultConnectAttempts {
+		t.Errorf("尝试次数 = %d，期望默认的 %d", fake.calls, defaultConnectAttempts)
+	}
+}
+
+func TestConnectDatabaseFailFast(t *testing.T) {
+	fake := &fakeDial{failures: 1, db: &gorm.DB{}}
+	config := testDatabaseConfig(fake.dial)
+	config.MaxAttempts = 5
+	config.FailFast = true
+
+	if _, err := ConnectDatabase(context.Background(), config); !errors.Is(err, errNotReady) {
+		t.Fatalf("ConnectDatabase() error = %v，期望第一次失败后直接返回", err)
+	}
+	if fake.calls != 1 {
+		t.Errorf("尝试次数 = %d，期望 1", fake.calls)
+	}
+}
+
+// TestConnectDatabaseCancelledDuringBackoff 退避等待期间取消ctx，立即返回而不等到下一次重试
+func TestConnectDatabaseCancelledDuringBackoff(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	fake := &fakeDial{failures: 100}
+	fake.onCall = func(int32) {
+		// 第一次尝试失败后进入退避等待，此时取消
+		time.AfterFunc(20*time.Millisecond, cancel)
+	}
+	config := testDatabaseConfig(fake.dial)
+	config.RetryBackoff = time.Hour
+
+	start := time.Now()
+	_, err := ConnectDatabase(ctx, config)
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("取消后 %v 才返回", elapsed)
+	}
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("ConnectDatabase() error = %v，期望 context.Canceled", err)
+	}
+	if !strings.Contains(err.Error(), errNotReady.Error()) {
+		t.Errorf("错误信息中没有最后一次连接错误: %v", err)
+	}
+	if fake.calls != 1 {
+		t.Errorf("尝试次数 = %d，期望 1", fake.calls)
+	}
+}
+
+func TestConnectDatabaseCancelledBeforeStart(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	fake := &fakeDial{db: &gorm.DB{}}
+	if _, err := ConnectDatabase(ctx, testDatabaseConfig(fake.dial)); !errors.Is(err, context.Canceled) {
+		t.Fatalf("ConnectDatabase() error = %v，期望 context.Canceled", err)
+	}
+	if fake.calls != 0 {
+		t.Errorf("ctx已取消时不应尝试连接，实际尝试 %d 次", fake.calls)
+	}
+}
+
+func TestConnectDatabasePassesDSNAndNaming(t *testing.T) {
+	var gotDSN string
+	var gotNaming schema.NamingStrategy
+	config := testDatabaseConfig(func(ctx context.Context, dsn string, naming schema.NamingStrategy) (*gorm.DB, error) {
+		gotDSN, gotNaming = dsn, naming
+		return &gorm.DB{}, nil
+	})
+	config.TablePrefix = "edu_"
+	config.SingularTable = true
+
+	if _, err := ConnectDatabase(context.Background(), config); err != nil {
+		t.Fatalf("ConnectDatabase() error = %v", err)
+	}
+	wantDSN := "root:secret@tcp(127.0.0.1:3306)/edu?charset=utf8mb4&parseTime=True&loc=Local"
+	if gotDSN != wantDSN {
+		t.Errorf("dsn = %q，期望 %q", gotDSN, wantDSN)
+	}
+	if gotNaming.TablePrefix != "edu_" || !gotNaming.SingularTable {
+		t.Errorf("命名策略 = %+v", gotNaming)
+	}
+}
+
+// TestMigrateAndSeedSQLite 在SQLite上迁移 models.All() 并填充演示数据，表名带上配置的前缀；
+// 共用的 Category 同样按前缀建表
+func TestMigrateAndSeedSQLite(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file:exercise5?mode=memory&cache=shared"), &gorm.Config{
+		Logger:         logger.Default.LogMode(logger.Silent),
+		NamingStrategy: schema.NamingStrategy{TablePrefix: "edu_"},
+	})
+	if err != nil {
+		t.Fatalf("打开数据库失败: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("获取数据库连接失败: %v", err)
+	}
+	defer sqlDB.Close()
+
+	if err := db.AutoMigrate(models.All()...); err != nil {
+		t.Fatalf("迁移失败: %v", err)
+	}
+	for _, table := range []string{"edu_users", "edu_categories", "edu_courses", "edu_orders", "edu_order_items"} {
+		if !db.Migrator().HasTable(table) {
+			t.Errorf("迁移后没有表 %s", table)
+		}
+	}
+
+	if err := SeedData(db); err != nil {
+		t.Fatalf("填充数据失败: %v", err)
+	}
+	counts := map[interface{}]int64{
+		&models.Role{}: 3, &models.User{}: 3, &models.UserProfile{}: 3,
+		&models.Category{}: 3, &models.Course{}: 2, &models.Chapter{}: 4, &models.Lesson{}: 6,
+	}
+	for model, want := range counts {
+		var got int64
+		if err := db.Model(model).Count(&got).Error; err != nil {
+			t.Fatalf("统计 %T 失败: %v", model, err)
+		}
+		if got != want {
+			t.Errorf("%T 有 %d 条，期望 %d", model, got, want)
+		}
+	}
+	var profile models.UserProfile
+	if err := db.Joins("User").Where("User.username = ?", "instructor1").First(&profile).Error; err != nil {
+		t.Fatalf("查询用户资料失败: %v", err)
+	}
+	if profile.RealName != "张老师" {
+		t.Errorf("用户资料的姓名为 %q，期望 %q", profile.RealName, "张老师")
+	}
+}
@@ -0,0 +1,98 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"edu-platform/config"
+)
+
+// CORS 跨域中间件，须在其他中间件之前注册
+// 来源按配置完全匹配或通配符匹配，响应中只回显匹配到的来源；允许携带凭证时 * 不生效，不会回显任意来源。
+// 预检请求（OPTIONS + Access-Control-Request-Method）直接返回204，不进入后续中间件和处理函数
+func CORS(cfg config.CORSConfig) gin.HandlerFunc {
+	allowMethods := strings.Join(cfg.AllowedMethods, ", ")
+	allowHeaders := strings.Join(cfg.AllowedHeaders, ", ")
+	exposeHeaders := strings.Join(cfg.ExposedHeaders, ", ")
+	maxAge := strconv.Itoa(int(cfg.MaxAge.Seconds()))
+
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		preflight := c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != ""
+		if origin == "" {
+			if preflight {
+				c.AbortWithStatus(http.StatusNoContent)
+				return
+			}
+			c.Next()
+			return
+		}
+
+		// 响应内容随Origin变化，缓存须按Origin区分
+		c.Writer.Header().Add("Vary", "Origin")
+		allowed := originAllowed(cfg, origin)
+		if allowed {
+			c.Header("Access-Control-Allow-Origin", origin)
+			if cfg.AllowCredentials {
+				c.Header("Access-Control-Allow-Credentials", "true")
+			}
+		}
+
+		if !preflight {
+			if allowed && exposeHeaders != "" {
+				c.Header("Access-Control-Expose-Headers", exposeHeaders)
+			}
+			c.Next()
+			return
+		}
+
+		// 来源不允许时预检同样返回204，但不带允许的头，浏览器会拦截后续请求
+		if allowed {
+			c.Header("Access-Control-Allow-Methods", allowMethods)
+			c.Header("Access-Control-Allow-Headers", allowHeaders)
+			if cfg.MaxAge > 0 {
+				c.Header("Access-Control-Max-Age", maxAge)
+			}
+		}
+		c.AbortWithStatus(http.StatusNoContent)
+	}
+}
+
+// originAllowed 来源是否在允许列表中
+func originAllowed(cfg config.CORSConfig, origin string) bool {
+	for _, pattern := range cfg.AllowedOrigins {
+		if pattern == "*" {
+			// 允许携带凭证时不接受任意来源，配置校验也会拒绝这种组合
+			if !cfg.AllowCredentials {
+				return true
+			}
+			continue
+		}
+		if matchOrigin(pattern, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchOrigin 完全匹配，或按模式中唯一的 * 匹配子域名或端口
+// * 只能匹配字母、数字、点和连字符，https://*.example.com 不匹配 https://evil.com/.example.com 之类的值
+func matchOrigin(pattern, origin string) bool {
+	if !strings.Contains(pattern, "*") {
+		return strings.EqualFold(pattern, origin)
+	}
+	prefix, suffix, _ := strings.Cut(strings.ToLower(pattern), "*")
+	origin = strings.ToLower(origin)
+	if len(origin) <= len(prefix)+len(suffix) || !strings.HasPrefix(origin, prefix) || !strings.HasSuffix(origin, suffix) {
+		return false
+	}
+	for _, r := range origin[len(prefix) : len(origin)-len(suffix)] {
+		if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '.' || r == '-') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"crypto/rand"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	shared "gorm-advanced-exercises/models"
+)
+
+// BaseModel 基础模型，自增主键，用于内部数据表；与电商练习共用同一定义
+type BaseModel = shared.BaseModel
+
+// UUIDModel UUID主键的基础模型，用于对外暴露ID的数据表
+// 自增ID会暴露业务量（如两天的订单ID之差就是订单数），UUID没有这个问题
+type UUIDModel struct {
+	ID        string         `gorm:"type:char(36);primarykey" json:"id"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
+}
+
+// BeforeCreate 创建前生成UUID，已指定ID时保留
+func (m *UUIDModel) BeforeCreate(tx *gorm.DB) error {
+	if m.ID == "" {
+		m.ID = NewUUID()
+	}
+	return nil
+}
+
+// NewUUID 生成随机UUID（版本4），格式为 xxxxxxxx-xxxx-4xxx-yxxx-xxxxxxxxxxxx
+func NewUUID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(fmt.Sprintf("生成UUID失败: %v", err))
+	}
+	b[6] = b[6]&0x0f | 0x40 // 版本4
+	b[8] = b[8]&0x3f | 0x80 // RFC 4122 变体
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// IsUUID 判断字符串是否为小写的标准UUID格式
+func IsUUID(s string) bool {
+	if len(s) != 36 {
+		return false
+	}
+	for i, c := range s {
+		switch i {
+		case 8, 13, 18, 23:
+			if c != '-' {
+				return false
+			}
+		default:
+			if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f') {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,39 @@
+package models
+
+import "gorm.io/gorm/schema"
+
+// Bundle 课程包模型（多门课程打包优惠销售）
+type Bundle struct {
+	BaseModel
+	Title         string `gorm:"size:255;not null" json:"title" validate:"required,max=255"`
+	Slug          string `gorm:"uniqueIndex;size:255;not null" json:"slug" validate:"required,max=255"`
+	Description   string `gorm:"type:text" json:"description" validate:"omitempty,max=2000"`
+	Cover         string `gorm:"size:255" json:"cover"`
+	Price         int64  `gorm:"not null;comment:课程包价格(分)" json:"price" validate:"min=0"`
+	OriginalPrice int64  `gorm:"default:0;comment:各课程原价合计(分)" json:"original_price" validate:"min=0"`
+	Status        int8   `gorm:"index;default:1;comment:1-草稿,2-发布,3-下架" json:"status"`
+
+	// 关联
+	Items []BundleCourse `gorm:"foreignKey:BundleID" json:"items,omitempty"`
+}
+
+// TableName 指定表名
+func (Bundle) TableName(namer schema.Namer) string {
+	return prefixedTable(namer, "bundles")
+}
+
+// BundleCourse 课程包与课程的关联
+type BundleCourse struct {
+	BaseModel
+	BundleID uint `gorm:"uniqueIndex:idx_bundle_course;not null" json:"bundle_id"`
+	CourseID uint `gorm:"uniqueIndex:idx_bundle_course;index;not null" json:"course_id"`
+	Sort     int  `gorm:"default:0" json:"sort"`
+
+	// 关联
+	Course Course `gorm:"foreignKey:CourseID" json:"course,omitempty"`
+}
+
+// TableName 指定表名
+func (BundleCourse) TableName(namer schema.Namer) string {
+	return prefixedTable(namer, "bundle_courses")
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm/schema"
+)
+
+// Certificate 结业证书，用户学完课程全部课时时签发，每个用户每门课程最多一张
+// Serial 对外公开，任何人可以凭编号验证；课程退款后证书标记为已撤销，不删除，验证时显示已撤销
+type Certificate struct {
+	BaseModel
+	UserID      uint              `gorm:"uniqueIndex:idx_certificate_user_course;not null" json:"user_id"`
+	CourseID    uint              `gorm:"uniqueIndex:idx_certificate_user_course;index;not null" json:"course_id"`
+	Serial      string            `gorm:"uniqueIndex;size:20;not null;comment:证书编号，如 CERT-2024-7KQ2MX" json:"serial"`
+	IssuedAt    time.Time         `gorm:"not null" json:"issued_at"`
+	ContentHash string            `gorm:"size:64;not null;comment:证书内容的SHA-256" json:"content_hash"`
+	Status      CertificateStatus `gorm:"index;default:1;comment:1-有效,2-已撤销" json:"status"`
+	RevokedAt   *time.Time        `json:"revoked_at,omitempty"`
+}
+
+// TableName 指定表名
+func (Certificate) TableName(namer schema.Namer) string {
+	return prefixedTable(namer, "certificates")
+}
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm/schema"
+)
+
+// CoursePrerequisite 课程的先修课程，学完先修课程才能购买该课程
+// (course_id, prerequisite_course_id) 唯一，不能引用自身，也不能形成环，由 CourseService.SetPrerequisites 校验
+type CoursePrerequisite struct {
+	ID                   uint      `gorm:"primarykey" json:"id"`
+	CourseID             uint      `gorm:"uniqueIndex:idx_course_prerequisite_pair;not null" json:"course_id"`
+	PrerequisiteCourseID uint      `gorm:"uniqueIndex:idx_course_prerequisite_pair;index;not null" json:"prerequisite_course_id"`
+	CreatedAt            time.Time `json:"created_at"`
+
+	// 关联
+	PrerequisiteCourse Course `gorm:"foreignKey:PrerequisiteCourseID" json:"prerequisite_course,omitempty"`
+}
+
+// TableName 指定表名
+func (CoursePrerequisite) TableName(namer schema.Namer) string {
+	return prefixedTable(namer, "course_prerequisites")
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm/schema"
+)
+
+// CourseRevision 课程大纲修订版本
+// 已发布课程的大纲修改先保存为草稿修订，发布修订时才同步到章节、课时表，学员看到的内容不受影响
+type CourseRevision struct {
+	BaseModel
+	CourseID    uint       `gorm:"uniqueIndex:idx_course_revision;not null" json:"course_id"`
+	RevisionNo  int        `gorm:"uniqueIndex:idx_course_revision;not null;comment:修订号，从1递增" json:"revision_no"`
+	AuthorID    uint       `gorm:"index;not null" json:"author_id"`
+	Outline     string     `gorm:"type:longtext;not null" json:"outline"` // 大纲，JSON格式
+	Status      int8       `gorm:"index;default:1;comment:1-草稿,2-已发布" json:"status"`
+	PublishedAt *time.Time `json:"published_at"`
+
+	// 关联
+	Course Course `gorm:"foreignKey:CourseID" json:"course,omitempty"`
+	Author User   `gorm:"foreignKey:AuthorID" json:"author,omitempty"`
+}
+
+// TableName 指定表名
+func (CourseRevision) TableName(namer schema.Namer) string {
+	return prefixedTable(namer, "course_revisions")
+}
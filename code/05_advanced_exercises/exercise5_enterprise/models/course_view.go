@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm/schema"
+)
+
+// CourseView 课程浏览记录，每个用户每门课程一条，ViewedAt为最近一次浏览时间
+// 每个用户只保留最近浏览的若干门课程，更早的记录在写入时清理
+type CourseView struct {
+	ID       uint      `gorm:"primarykey" json:"id"`
+	UserID   uint      `gorm:"uniqueIndex:idx_course_views_user_course;index:idx_course_views_user_time,priority:1;not null" json:"user_id"`
+	CourseID uint      `gorm:"uniqueIndex:idx_course_views_user_course;not null" json:"course_id"`
+	ViewedAt time.Time `gorm:"index:idx_course_views_user_time,priority:2;not null" json:"viewed_at"`
+}
+
+// TableName 指定表名
+func (CourseView) TableName(namer schema.Namer) string {
+	return prefixedTable(namer, "course_views")
+}
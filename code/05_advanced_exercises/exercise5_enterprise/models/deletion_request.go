@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm/schema"
+)
+
+// DeletionRequest 账户注销申请模型
+// 申请后进入宽限期，到期后由后台任务匿名化用户数据；订单等财务记录保留
+type DeletionRequest struct {
+	BaseModel
+	UserID       uint       `gorm:"index;not null" json:"user_id"`
+	RequestedAt  time.Time  `gorm:"not null" json:"requested_at"`
+	ExecuteAfter time.Time  `gorm:"index;not null;comment:宽限期结束时间" json:"execute_after"`
+	Status       int8       `gorm:"index;default:1;comment:1-待执行,2-已取消,3-已执行" json:"status"`
+	CancelledAt  *time.Time `json:"cancelled_at"`
+	ExecutedAt   *time.Time `json:"executed_at"`
+
+	// 关联
+	User User `gorm:"foreignKey:UserID" json:"user,omitempty"`
+}
+
+// TableName 指定表名
+func (DeletionRequest) TableName(namer schema.Namer) string {
+	return prefixedTable(namer, "deletion_requests")
+}
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// CourseThread 课程讨论主题模型
+type CourseThread struct {
+	BaseModel
+	CourseID    uint       `gorm:"index;not null" json:"course_id"`
+	AuthorID    uint       `gorm:"index;not null" json:"author_id"`
+	Title       string     `gorm:"size:255;not null" json:"title"`
+	Body        string     `gorm:"type:text" json:"body"`
+	Status      int8       `gorm:"index;default:1;comment:1-待解决,2-已解决" json:"status"`
+	ReplyCount  int        `gorm:"default:0;comment:回复数量" json:"reply_count"`
+	LastReplyAt *time.Time `json:"last_reply_at"`
+
+	// 关联
+	Author  User          `gorm:"foreignKey:AuthorID" json:"author,omitempty"`
+	Replies []ThreadReply `gorm:"foreignKey:ThreadID" json:"replies,omitempty"`
+}
+
+// TableName 指定表名
+func (CourseThread) TableName(namer schema.Namer) string {
+	return prefixedTable(namer, "course_threads")
+}
+
+// ThreadReply 讨论回复模型
+type ThreadReply struct {
+	BaseModel
+	ThreadID           uint   `gorm:"index;not null" json:"thread_id"`
+	AuthorID           uint   `gorm:"index;not null" json:"author_id"`
+	Body               string `gorm:"type:text;not null" json:"body"`
+	IsInstructorAnswer bool   `gorm:"default:false;comment:是否讲师回答" json:"is_instructor_answer"`
+
+	// 关联
+	Author User `gorm:"foreignKey:AuthorID" json:"author,omitempty"`
+}
+
+// TableName 指定表名
+func (ThreadReply) TableName(namer schema.Namer) string {
+	return prefixedTable(namer, "thread_replies")
+}
+
+// AfterCreate GORM钩子：创建回复后增加主题的回复数量
+func (r *ThreadReply) AfterCreate(tx *gorm.DB) error {
+	return tx.Model(&CourseThread{}).Where("id = ?", r.ThreadID).Updates(map[string]interface{}{
+		"reply_count":   gorm.Expr("reply_count + ?", 1),
+		"last_reply_at": r.CreatedAt,
+	}).Error
+}
+
+// AfterDelete GORM钩子：删除回复后减少主题的回复数量
+// 删除时需传入已加载的回复（ThreadID有值），按条件批量删除不会正确更新计数
+func (r *ThreadReply) AfterDelete(tx *gorm.DB) error {
+	return tx.Model(&CourseThread{}).Where("id = ? AND reply_count > 0", r.ThreadID).
+		Update("reply_count", gorm.Expr("reply_count - ?", 1)).Error
+}
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm/schema"
+)
+
+// EmailTemplate 邮件模板模型
+// 每个订单事件一个模板，Key与发件箱事件类型相同（如 order.paid）；主题和正文使用 text/template 语法
+type EmailTemplate struct {
+	BaseModel
+	Key         string `gorm:"uniqueIndex;size:50;not null" json:"key"`
+	SubjectTmpl string `gorm:"size:255;not null" json:"subject_tmpl"`
+	BodyTmpl    string `gorm:"type:text;not null" json:"body_tmpl"`
+	Enabled     bool   `gorm:"not null;comment:停用后对应事件不再生成邮件" json:"enabled"`
+}
+
+// TableName 指定表名
+func (EmailTemplate) TableName(namer schema.Namer) string {
+	return prefixedTable(namer, "email_templates")
+}
+
+// EmailLog 邮件记录模型
+// 订单事件按模板渲染后写入，状态为待发送，由发送任务发出后更新状态；主题和正文保存渲染结果，之后修改模板不影响已生成的邮件
+type EmailLog struct {
+	BaseModel
+	UserID          uint       `gorm:"index;not null" json:"user_id"`
+	TemplateKey     string     `gorm:"index;size:50;not null" json:"template_key"`
+	OrderID         OrderID    `gorm:"index;size:36;not null" json:"order_id"`
+	OutboxEventID   uint       `gorm:"uniqueIndex;not null;comment:触发邮件的发件箱事件，事件重复投递时不重复生成" json:"outbox_event_id"`
+	ToEmail         string     `gorm:"size:100;not null" json:"to_email"`
+	RenderedSubject string     `gorm:"size:255;not null" json:"rendered_subject"`
+	RenderedBody    string     `gorm:"type:text" json:"rendered_body"`
+	Status          int8       `gorm:"index;default:1;comment:1-待发送,2-已发送,3-发送失败" json:"status"`
+	Attempts        int        `gorm:"default:0;comment:发送次数" json:"attempts"`
+	LastError       string     `gorm:"size:500;comment:最近一次发送失败原因" json:"last_error"`
+	SentAt          *time.Time `json:"sent_at"`
+}
+
+// TableName 指定表名
+func (EmailLog) TableName(namer schema.Namer) string {
+	return prefixedTable(namer, "email_logs")
+}
@@ -0,0 +1,74 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm/schema"
+)
+
+// EnrollmentStatus 选课记录状态
+type EnrollmentStatus int8
+
+const (
+	EnrollmentStatusActive  EnrollmentStatus = 1 // 有效
+	EnrollmentStatusRevoked EnrollmentStatus = 2 // 已撤销（退款）
+)
+
+// EnrollmentSource 选课记录来源
+type EnrollmentSource int8
+
+const (
+	EnrollmentSourcePurchase EnrollmentSource = 1 // 购买
+	EnrollmentSourceBulk     EnrollmentSource = 2 // 企业批量开通
+)
+
+// RefundType 退款类型
+type RefundType int8
+
+const (
+	RefundTypeFull    RefundType = 1 // 全额退款，含先部分退款、再退余下全部订单项的情况
+	RefundTypePartial RefundType = 2 // 部分退款
+)
+
+// Enrollment 选课记录模型（订单支付后开通的学习权限）
+// 企业批量开通的选课记录没有对应的订单，OrderID、OrderItemID为空
+type Enrollment struct {
+	BaseModel
+	UserID      uint             `gorm:"index:idx_enrollment_user_course;not null" json:"user_id"`
+	CourseID    uint             `gorm:"index:idx_enrollment_user_course;index;not null" json:"course_id"`
+	OrderID     *OrderID         `gorm:"index;size:36" json:"order_id"`
+	OrderItemID *uint            `gorm:"uniqueIndex" json:"order_item_id"`
+	Source      EnrollmentSource `gorm:"default:1;comment:1-购买,2-企业批量开通" json:"source"`
+	Status      EnrollmentStatus `gorm:"index;default:1;comment:1-有效,2-已撤销" json:"status"`
+	EnrolledAt  time.Time        `gorm:"not null" json:"enrolled_at"`
+	RevokedAt   *time.Time       `json:"revoked_at"`
+
+	// 关联
+	User   User   `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	Course Course `gorm:"foreignKey:CourseID" json:"course,omitempty"`
+}
+
+// TableName 指定表名
+func (Enrollment) TableName(namer schema.Namer) string {
+	return prefixedTable(namer, "enrollments")
+}
+
+// Refund 退款记录模型
+type Refund struct {
+	BaseModel
+	RefundNo string     `gorm:"uniqueIndex;size:50;not null" json:"refund_no"`
+	OrderID  OrderID    `gorm:"index;size:36;not null" json:"order_id"`
+	UserID   uint       `gorm:"index;not null" json:"user_id"`
+	Amount   int64      `gorm:"not null;comment:退款金额(分)" json:"amount"`
+	Type     RefundType `gorm:"not null;comment:1-全额退款,2-部分退款" json:"type"`
+	Reason   string     `gorm:"type:text" json:"reason"`
+
+	// 关联
+	Order Order       `gorm:"foreignKey:OrderID" json:"order,omitempty"`
+	Items []OrderItem `gorm:"foreignKey:RefundID" json:"items,omitempty"`
+}
+
+// TableName 指定表名
+func (Refund) TableName(namer schema.Namer) string {
+	return prefixedTable(namer, "refunds")
+}
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm/schema"
+)
+
+// InstructorApplication 讲师申请模型
+type InstructorApplication struct {
+	BaseModel
+	UserID         uint       `gorm:"index;not null" json:"user_id"`
+	Bio            string     `gorm:"type:text" json:"bio"`
+	Qualifications string     `gorm:"type:text" json:"qualifications"` // 资质说明
+	Status         int8       `gorm:"index;default:1;comment:1-待审核,2-已通过,3-已拒绝" json:"status"`
+	ReviewerID     *uint      `gorm:"index" json:"reviewer_id"`
+	ReviewedAt     *time.Time `json:"reviewed_at"`
+	RejectReason   string     `gorm:"size:500" json:"reject_reason"`
+	// ActiveUserID 待审核时等于UserID，审核后置为NULL
+	// 唯一索引允许多个NULL，保证每个用户同时只有一个待审核的申请
+	ActiveUserID *uint `gorm:"uniqueIndex" json:"-"`
+
+	// 关联
+	User     User  `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	Reviewer *User `gorm:"foreignKey:ReviewerID" json:"reviewer,omitempty"`
+}
+
+// TableName 指定表名
+func (InstructorApplication) TableName(namer schema.Namer) string {
+	return prefixedTable(namer, "instructor_applications")
+}
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm/schema"
+)
+
+// Invoice 发票模型
+// 订单支付后自动开具，每个订单只开一张，发票号按月连续编号，如 INV-202406-000123
+type Invoice struct {
+	BaseModel
+	InvoiceNo string    `gorm:"uniqueIndex;size:30;not null" json:"invoice_no"`
+	OrderID   OrderID   `gorm:"uniqueIndex;size:36;not null" json:"order_id"`
+	UserID    uint      `gorm:"index;not null" json:"user_id"`
+	Amount    int64     `gorm:"not null;comment:含税金额(分)" json:"amount"`
+	TaxRate   float64   `gorm:"type:decimal(5,4);not null;comment:税率，如0.06" json:"tax_rate"`
+	TaxAmount int64     `gorm:"not null;comment:税额(分)" json:"tax_amount"`
+	IssuedAt  time.Time `gorm:"index;not null" json:"issued_at"`
+
+	// 购买方信息快照，用户之后修改资料不影响已开发票
+	BuyerName  string `gorm:"size:50" json:"buyer_name"`
+	BuyerEmail string `gorm:"size:100" json:"buyer_email"`
+	BuyerPhone string `gorm:"size:20" json:"buyer_phone"`
+}
+
+// TableName 指定表名
+func (Invoice) TableName(namer schema.Namer) string {
+	return prefixedTable(namer, "invoices")
+}
+
+// CreditNote 红字发票（冲销凭证）模型
+// 发票开具后不能重开，订单退款时为退款金额开具红字发票，编号独立按月连续，如 CN-202406-000001
+type CreditNote struct {
+	BaseModel
+	CreditNoteNo string    `gorm:"uniqueIndex;size:30;not null" json:"credit_note_no"`
+	InvoiceID    uint      `gorm:"index;not null" json:"invoice_id"`
+	RefundID     uint      `gorm:"uniqueIndex;not null" json:"refund_id"`
+	OrderID      OrderID   `gorm:"index;size:36;not null" json:"order_id"`
+	Amount       int64     `gorm:"not null;comment:冲销金额(分)" json:"amount"`
+	TaxAmount    int64     `gorm:"not null;comment:冲销税额(分)" json:"tax_amount"`
+	IssuedAt     time.Time `gorm:"index;not null" json:"issued_at"`
+
+	// 关联
+	Invoice Invoice `gorm:"foreignKey:InvoiceID" json:"invoice,omitempty"`
+}
+
+// TableName 指定表名
+func (CreditNote) TableName(namer schema.Namer) string {
+	return prefixedTable(namer, "credit_notes")
+}
+
+// DocumentCounter 单据编号计数器，每个名称（如 invoice:202406、waitlist:12）一行，在事务中原子递增
+type DocumentCounter struct {
+	Name      string    `gorm:"primaryKey;size:50" json:"name"`
+	Value     int64     `gorm:"not null;default:0" json:"value"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (DocumentCounter) TableName(namer schema.Namer) string {
+	return prefixedTable(namer, "document_counters")
+}
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm/schema"
+)
+
+// JobRunStatus 后台任务执行状态
+type JobRunStatus string
+
+const (
+	JobRunStatusRunning   JobRunStatus = "running"
+	JobRunStatusSucceeded JobRunStatus = "succeeded"
+	JobRunStatusFailed    JobRunStatus = "failed"
+)
+
+// JobRun 后台任务执行记录，每次执行（定时或手动触发）一行
+type JobRun struct {
+	ID           uint         `gorm:"primarykey" json:"id"`
+	Job          string       `gorm:"index:idx_job_run_job;size:50;not null" json:"job"`
+	Trigger      string       `gorm:"size:20;not null;comment:schedule-定时,manual-手动" json:"trigger"`
+	Status       JobRunStatus `gorm:"size:20;not null" json:"status"`
+	StartedAt    time.Time    `gorm:"not null" json:"started_at"`
+	FinishedAt   *time.Time   `json:"finished_at"`
+	Error        string       `gorm:"type:text" json:"error,omitempty"`
+	RowsAffected int64        `gorm:"default:0" json:"rows_affected"`
+}
+
+// TableName 指定表名
+func (JobRun) TableName(namer schema.Namer) string {
+	return prefixedTable(namer, "job_runs")
+}
+
+// JobLock 后台任务锁，每个任务一行；LockedUntil 晚于当前时间表示任务正在执行
+// 多个实例通过条件更新抢锁，同一任务同一时间只有一个实例执行；实例崩溃后锁在 LockedUntil 后自动失效
+type JobLock struct {
+	Name        string    `gorm:"primaryKey;size:50" json:"name"`
+	Owner       string    `gorm:"size:64;not null;default:''" json:"owner"`
+	LockedUntil time.Time `gorm:"not null" json:"locked_until"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (JobLock) TableName(namer schema.Namer) string {
+	return prefixedTable(namer, "job_locks")
+}
@@ -0,0 +1,18 @@
+package models
+
+import "gorm.io/gorm/schema"
+
+// LearningActivity 学习行为日志模型（每次上报学习进度追加一条，按保留策略定期清理）
+type LearningActivity struct {
+	BaseModel
+	UserID    uint `gorm:"index;not null" json:"user_id"`
+	CourseID  uint `gorm:"index;not null" json:"course_id"`
+	LessonID  uint `gorm:"not null" json:"lesson_id"`
+	Progress  int  `gorm:"default:0;comment:上报时的进度百分比" json:"progress"`
+	WatchTime int  `gorm:"default:0;comment:上报时的观看时长(秒)" json:"watch_time"`
+}
+
+// TableName 指定表名
+func (LearningActivity) TableName(namer schema.Namer) string {
+	return prefixedTable(namer, "learning_activities")
+}
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm/schema"
+)
+
+// LoginHistory 登录历史模型（每次登录成功追加一条）
+type LoginHistory struct {
+	BaseModel
+	UserID     uint      `gorm:"index;not null" json:"user_id"`
+	IP         string    `gorm:"size:45" json:"ip"`
+	UserAgent  string    `gorm:"size:500" json:"user_agent"`
+	LoggedInAt time.Time `gorm:"index;not null" json:"logged_in_at"`
+}
+
+// TableName 指定表名
+func (LoginHistory) TableName(namer schema.Namer) string {
+	return prefixedTable(namer, "login_histories")
+}
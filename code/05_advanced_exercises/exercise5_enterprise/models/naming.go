@@ -0,0 +1,32 @@
+package models
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+
+	shared "gorm-advanced-exercises/models"
+)
+
+// 表名前缀
+// 部署到客户的共享数据库时通过 DatabaseConfig.TablePrefix（如 edu_）给所有表加前缀，前缀配置在GORM的命名策略中。
+// 模型的表名是固定的，TableName 通过 prefixedTable 加上前缀；原生SQL和 Table("...") 中的表名通过 Table、TableAs 取得，
+// 不能直接写死表名，否则有前缀时会查错表
+
+// prefixedTable 固定表名加上命名策略中配置的前缀
+func prefixedTable(namer schema.Namer, name string) string {
+	return shared.PrefixedTable(namer, name)
+}
+
+// Table 表在数据库中的实际名称（加上db命名策略中的前缀），name为不带前缀的表名，如 Table(db, "orders")
+func Table(db *gorm.DB, name string) string {
+	return prefixedTable(db.NamingStrategy, name)
+}
+
+// TableAs 带前缀的表名，并以不带前缀的表名作别名，如 "edu_orders AS orders"，没有前缀时就是表名本身
+// 用于 JOIN 和 Table(...)，SQL中 orders.id 这样的限定列名有没有前缀都能使用
+func TableAs(db *gorm.DB, name string) string {
+	if table := Table(db, name); table != name {
+		return table + " AS " + name
+	}
+	return name
+}
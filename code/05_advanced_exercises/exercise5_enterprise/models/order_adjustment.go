@@ -0,0 +1,19 @@
+package models
+
+import "gorm.io/gorm/schema"
+
+// OrderAdjustment 订单金额调整行，订单总额与订单项合计不一致、按订单总额修复时写入
+// 订单项对应购买的课程（支付时据此开通选课，退款和讲师结算也按订单项计算），调整金额不是课程，单独记录；
+// 订单总额 = 订单项价格合计 + 调整金额合计，Amount 可以为负
+type OrderAdjustment struct {
+	BaseModel
+	OrderID   OrderID `gorm:"index;size:36;not null" json:"order_id"`
+	Amount    int64   `gorm:"not null;comment:调整金额(分)，可为负" json:"amount"`
+	Reason    string  `gorm:"size:255" json:"reason"`
+	CreatedBy uint    `gorm:"not null" json:"created_by"`
+}
+
+// TableName 指定表名
+func (OrderAdjustment) TableName(namer schema.Namer) string {
+	return prefixedTable(namer, "order_adjustments")
+}
@@ -0,0 +1,26 @@
+//go:build !orderuuid
+
+package models
+
+import "strconv"
+
+// 订单主键类型，默认为自增ID；使用 -tags orderuuid 编译时改为UUID（见order_id_uuid.go）
+// 订单项、选课记录、退款、发票等表的order_id字段都使用OrderID，随订单主键一起切换
+
+// OrderModel 订单使用的基础模型
+type OrderModel = BaseModel
+
+// OrderID 订单主键类型
+type OrderID = uint
+
+// OrderUUID 订单是否使用UUID主键
+const OrderUUID = false
+
+// ParseOrderID 解析字符串形式的订单ID，如发件箱事件的AggregateID
+func ParseOrderID(s string) (OrderID, error) {
+	id, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return OrderID(id), nil
+}
@@ -0,0 +1,157 @@
+//go:build orderuuid
+
+package models
+
+import (
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+const (
+	legacyOrderTable    = "orders_legacy" // 迁移时保留的旧订单表（不带前缀）
+	orderMigrationBatch = 500             // 每批分配UUID的订单数
+)
+
+// orderReferences 通过order_id引用订单的模型
+var orderReferences = []interface{}{&OrderItem{}, &Enrollment{}, &Refund{}, &Invoice{}, &CreditNote{}, &EmailLog{}}
+
+// MigrateOrderIDsToUUID 把自增主键的订单表迁移为UUID主键
+// 需要在 AutoMigrate 之前执行，订单主键已是UUID时直接返回。迁移包含表结构修改，MySQL下无法整体回滚，
+// 应停服并备份后执行。旧订单表改名为 orders_legacy 保留（多一列uuid记录新旧ID的对应关系），确认无误后手动删除
+//
+//  1. 旧订单表改名为 orders_legacy，为每个订单分配UUID
+//  2. 删除引用订单的外键，订单项、选课记录、退款、发票、红字发票、邮件记录的order_id改为字符串并替换成UUID
+//  3. 订单相关的发件箱事件的aggregate_id替换成UUID
+//  4. 按新结构建订单表，从旧表复制数据，重建外键
+func MigrateOrderIDsToUUID(db *gorm.DB) error {
+	m := db.Migrator()
+	if !m.HasTable(&Order{}) {
+		return nil
+	}
+	migrated, err := orderIDIsUUID(db)
+	if err != nil || migrated {
+		return err
+	}
+
+	legacy := Table(db, legacyOrderTable)
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(&Order{}); err != nil {
+		return err
+	}
+
+	// 1. 保留旧表并分配UUID，旧表上的索引先删除，避免与新表的索引重名（SQLite的索引名全库唯一）
+	if err := m.RenameTable(stmt.Schema.Table, legacy); err != nil {
+		return err
+	}
+	for _, idx := range stmt.Schema.ParseIndexes() {
+		if m.HasIndex(legacy, idx.Name) {
+			if err := m.DropIndex(legacy, idx.Name); err != nil {
+				return err
+			}
+		}
+	}
+	if err := db.Exec("ALTER TABLE " + legacy + " ADD COLUMN uuid VARCHAR(36)").Error; err != nil {
+		return err
+	}
+	for {
+		var ids []uint
+		if err := db.Table(legacy).Where("uuid IS NULL").Limit(orderMigrationBatch).Pluck("id", &ids).Error; err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			break
+		}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			for _, id := range ids {
+				if err := tx.Table(legacy).Where("id = ?", id).Update("uuid", NewUUID()).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	// 2. 引用订单的外键阻止修改列类型，先删除，第4步由AutoMigrate重建
+	for _, c := range []struct {
+		model interface{}
+		name  string
+	}{{&Order{}, "Items"}, {&OrderItem{}, "Order"}, {&Refund{}, "Order"}} {
+		if m.HasConstraint(c.model, c.name) {
+			if err := m.DropConstraint(c.model, c.name); err != nil {
+				return err
+			}
+		}
+	}
+	for _, model := range orderReferences {
+		if !m.HasTable(model) {
+			continue
+		}
+		if err := m.AlterColumn(model, "OrderID"); err != nil {
+			return err
+		}
+		table, err := tableName(db, model)
+		if err != nil {
+			return err
+		}
+		if err := db.Exec("UPDATE " + table + " SET order_id = (SELECT uuid FROM " + legacy +
+			" WHERE " + legacy + ".id = " + table + ".order_id)").Error; err != nil {
+			return err
+		}
+	}
+
+	// 3. 发件箱事件
+	if m.HasTable(&OutboxEvent{}) {
+		if err := m.AlterColumn(&OutboxEvent{}, "AggregateID"); err != nil {
+			return err
+		}
+		outbox := Table(db, "outbox_events")
+		if err := db.Exec("UPDATE "+outbox+" SET aggregate_id = (SELECT uuid FROM "+legacy+
+			" WHERE "+legacy+".id = "+outbox+".aggregate_id) WHERE event_type LIKE ?", "order.%").Error; err != nil {
+			return err
+		}
+	}
+
+	// 4. 新建订单表并复制数据
+	if err := m.CreateTable(&Order{}); err != nil {
+		return err
+	}
+	var columns []string
+	for _, name := range stmt.Schema.DBNames {
+		if name != "id" {
+			columns = append(columns, name)
+		}
+	}
+	list := strings.Join(columns, ", ")
+	if err := db.Exec("INSERT INTO " + stmt.Schema.Table + " (id, " + list + ") SELECT uuid, " + list +
+		" FROM " + legacy).Error; err != nil {
+		return err
+	}
+	return db.AutoMigrate(append([]interface{}{&Order{}}, orderReferences...)...)
+}
+
+// orderIDIsUUID 订单表的主键是否已是字符串类型
+func orderIDIsUUID(db *gorm.DB) (bool, error) {
+	columns, err := db.Migrator().ColumnTypes(&Order{})
+	if err != nil {
+		return false, err
+	}
+	for _, column := range columns {
+		if column.Name() == "id" {
+			return !strings.Contains(strings.ToLower(column.DatabaseTypeName()), "int"), nil
+		}
+	}
+	return false, nil
+}
+
+// tableName 模型对应的表名
+func tableName(db *gorm.DB, model interface{}) (string, error) {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(model); err != nil {
+		return "", err
+	}
+	return stmt.Schema.Table, nil
+}
@@ -0,0 +1,22 @@
+//go:build orderuuid
+
+package models
+
+import "fmt"
+
+// OrderModel 订单使用的基础模型，主键为UUID
+type OrderModel = UUIDModel
+
+// OrderID 订单主键类型
+type OrderID = string
+
+// OrderUUID 订单是否使用UUID主键
+const OrderUUID = true
+
+// ParseOrderID 解析字符串形式的订单ID，如发件箱事件的AggregateID
+func ParseOrderID(s string) (OrderID, error) {
+	if !IsUUID(s) {
+		return "", fmt.Errorf("无效的订单ID: %q", s)
+	}
+	return s, nil
+}
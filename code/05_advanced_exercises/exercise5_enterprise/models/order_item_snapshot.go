@@ -0,0 +1,47 @@
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+// snapshotBackfillBatch 每批回填快照的订单项数
+const snapshotBackfillBatch = 500
+
+// BackfillOrderItemSnapshots 为加入快照列之前的订单项回填课程封面、讲师和分类名称
+// 在 AutoMigrate 之后执行，只填写为空的快照列，已有的快照不会被当前课程信息覆盖；
+// 取的是执行时的课程信息（软删除的课程也可以），课程已被物理删除的订单项保持为空。可重复执行
+// 返回处理的订单项数
+func BackfillOrderItemSnapshots(db *gorm.DB) (int64, error) {
+	items, courses, categories, users := Table(db, "order_items"), Table(db, "courses"), Table(db, "categories"), Table(db, "users")
+	missing := "(course_image IS NULL OR course_image = '' OR instructor_name IS NULL OR instructor_name = '' OR category_name IS NULL OR category_name = '')"
+
+	// 关联子查询在MySQL和SQLite的UPDATE中都可以使用，课程不存在时子查询为NULL，保留原值
+	updates := map[string]interface{}{
+		"course_image":    gorm.Expr("COALESCE(NULLIF(course_image, ''), (SELECT c.cover FROM " + courses + " c WHERE c.id = " + items + ".course_id), '')"),
+		"instructor_name": gorm.Expr("COALESCE(NULLIF(instructor_name, ''), (SELECT COALESCE(NULLIF(u.nickname, ''), u.username) FROM " + courses + " c JOIN " + users + " u ON u.id = c.instructor_id WHERE c.id = " + items + ".course_id), '')"),
+		"category_name":   gorm.Expr("COALESCE(NULLIF(category_name, ''), (SELECT cat.name FROM " + courses + " c JOIN " + categories + " cat ON cat.id = c.category_id WHERE c.id = " + items + ".course_id), '')"),
+	}
+
+	// 只处理课程还在的订单项；按ID游标分批，回填后仍为空（如课程没有封面）的订单项不会被重复选中
+	exists := "EXISTS (SELECT 1 FROM " + courses + " c WHERE c.id = " + items + ".course_id)"
+	var total int64
+	var lastID uint
+	for {
+		var ids []uint
+		err := db.Model(&OrderItem{}).Unscoped().Where("id > ? AND "+missing+" AND "+exists, lastID).
+			Order("id").Limit(snapshotBackfillBatch).Pluck("id", &ids).Error
+		if err != nil {
+			return total, err
+		}
+		if len(ids) == 0 {
+			return total, nil
+		}
+		lastID = ids[len(ids)-1]
+
+		result := db.Model(&OrderItem{}).Unscoped().Where("id IN ?", ids).UpdateColumns(updates)
+		if result.Error != nil {
+			return total, result.Error
+		}
+		total += result.RowsAffected
+	}
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm/schema"
+)
+
+// OutboxEvent 发件箱事件模型
+// 业务变更和事件在同一事务中写入，由投递任务读取后交给事件处理器，处理成功后标记为已投递
+type OutboxEvent struct {
+	BaseModel
+	EventType   string     `gorm:"index;size:50;not null" json:"event_type"`
+	AggregateID string     `gorm:"index;size:36;not null;comment:关联的业务ID，如订单ID" json:"aggregate_id"`
+	Payload     string     `gorm:"type:text" json:"payload"` // 事件内容，JSON格式
+	Status      int8       `gorm:"index;default:1;comment:1-待投递,2-已投递" json:"status"`
+	Attempts    int        `gorm:"default:0;comment:投递次数" json:"attempts"`
+	LastError   string     `gorm:"size:500;comment:最近一次投递失败原因" json:"last_error"`
+	DeliveredAt *time.Time `json:"delivered_at"`
+}
+
+// TableName 指定表名
+func (OutboxEvent) TableName(namer schema.Namer) string {
+	return prefixedTable(namer, "outbox_events")
+}
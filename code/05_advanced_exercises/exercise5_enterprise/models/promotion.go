@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm/schema"
+)
+
+// Promotion 课程限时促销，时间窗口为 [StartsAt, EndsAt)，同一课程的促销时间不能重叠
+// 促销期间按 PromoPrice 售卖，课程的 Price 不被修改；是否生效只按时间窗口判断，
+// Status 由定时任务按时间更新，只用于列表展示和报表，未及时更新也不影响售价
+type Promotion struct {
+	BaseModel
+	CourseID   uint            `gorm:"index:idx_promotion_course_window,priority:1;not null" json:"course_id"`
+	PromoPrice int64           `gorm:"not null;comment:促销价(分)" json:"promo_price"`
+	StartsAt   time.Time       `gorm:"index:idx_promotion_course_window,priority:2;not null" json:"starts_at"`
+	EndsAt     time.Time       `gorm:"index:idx_promotion_course_window,priority:3;index;not null" json:"ends_at"`
+	Status     PromotionStatus `gorm:"size:8;index;default:1;comment:1-未开始,2-进行中,3-已结束" json:"status"`
+	CreatedBy  uint            `gorm:"not null" json:"created_by"`
+}
+
+// TableName 指定表名
+func (Promotion) TableName(namer schema.Namer) string {
+	return prefixedTable(namer, "promotions")
+}
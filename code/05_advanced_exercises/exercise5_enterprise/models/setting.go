@@ -0,0 +1,17 @@
+package models
+
+import "gorm.io/gorm/schema"
+
+// Setting 系统设置模型（键值对配置，运行时可修改）
+type Setting struct {
+	BaseModel
+	Key         string `gorm:"uniqueIndex;size:100;not null" json:"key"`
+	Value       string `gorm:"type:text" json:"value"`
+	Type        string `gorm:"size:20;default:'string';comment:string,int,bool,json" json:"type"`
+	Description string `gorm:"size:255" json:"description"`
+}
+
+// TableName 指定表名
+func (Setting) TableName(namer schema.Namer) string {
+	return prefixedTable(namer, "settings")
+}
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm/schema"
+)
+
+// SlugRedirect 课程改名重新生成标识后保留的旧标识，按旧标识访问时跳转到课程的当前标识
+// 旧标识不会再分配给其他课程，生成标识时和 courses.slug 一起查重
+type SlugRedirect struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	OldSlug   string    `gorm:"uniqueIndex;size:255;not null" json:"old_slug"`
+	CourseID  uint      `gorm:"index;not null" json:"course_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName 指定表名
+func (SlugRedirect) TableName(namer schema.Namer) string {
+	return prefixedTable(namer, "slug_redirects")
+}
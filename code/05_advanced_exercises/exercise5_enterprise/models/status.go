@@ -0,0 +1,434 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// 订单、课程、用户、候补的状态类型
+// 数据库中仍存为 int8（Valuer 返回 int64 时 GORM 会推断为64位，模型字段上用 size:8 保持原列类型），
+// JSON 输出为可读名称，输入同时接受名称和旧的数字写法
+
+// OrderStatus 订单状态
+type OrderStatus int8
+
+const (
+	OrderStatusPending   OrderStatus = 1 // 待付款
+	OrderStatusPaid      OrderStatus = 2 // 已付款
+	OrderStatusCompleted OrderStatus = 3 // 已完成
+	OrderStatusCancelled OrderStatus = 4 // 已取消
+	OrderStatusRefunded  OrderStatus = 5 // 已退款
+)
+
+var orderStatusNames = statusNames{
+	"订单状态",
+	map[int8]string{1: "pending", 2: "paid", 3: "completed", 4: "cancelled", 5: "refunded"},
+}
+
+// orderTransitions 订单状态机：待付款可支付或取消（含过期自动取消），已付款可确认收货，
+// 已付款和已完成的订单全额退款后为已退款，已取消和已退款为终态
+var orderTransitions = map[OrderStatus][]OrderStatus{
+	OrderStatusPending:   {OrderStatusPaid, OrderStatusCancelled},
+	OrderStatusPaid:      {OrderStatusCompleted, OrderStatusRefunded},
+	OrderStatusCompleted: {OrderStatusRefunded},
+}
+
+// ParseOrderStatus 按名称或数字解析订单状态，如 "paid" 或 "2"
+func ParseOrderStatus(s string) (OrderStatus, error) {
+	v, err := orderStatusNames.parse(s)
+	return OrderStatus(v), err
+}
+
+// IsValid 是否为已定义的订单状态
+func (s OrderStatus) IsValid() bool { return orderStatusNames.has(int8(s)) }
+
+// IsPaid 订单是否已付款且未退款（已付款或已完成）
+func (s OrderStatus) IsPaid() bool {
+	return s == OrderStatusPaid || s == OrderStatusCompleted
+}
+
+// CanTransitionTo 订单能否从当前状态变为next
+func (s OrderStatus) CanTransitionTo(next OrderStatus) bool {
+	for _, to := range orderTransitions[s] {
+		if to == next {
+			return true
+		}
+	}
+	return false
+}
+
+func (s OrderStatus) String() string { return orderStatusNames.name(int8(s)) }
+
+// SQL 用于手写SQL的数字字面量，带名称注释，如 2 /* paid */
+func (s OrderStatus) SQL() string { return orderStatusNames.sql(int8(s)) }
+
+func (s OrderStatus) MarshalJSON() ([]byte, error) { return json.Marshal(s.String()) }
+
+func (s *OrderStatus) UnmarshalJSON(data []byte) error {
+	v, err := orderStatusNames.unmarshal(data)
+	*s = OrderStatus(v)
+	return err
+}
+
+func (s OrderStatus) Value() (driver.Value, error) { return int64(s), nil }
+
+func (s *OrderStatus) Scan(src interface{}) error {
+	v, err := scanInt8(src)
+	*s = OrderStatus(v)
+	return err
+}
+
+// CourseStatus 课程状态
+type CourseStatus int8
+
+const (
+	CourseStatusDraft       CourseStatus = 1 // 草稿
+	CourseStatusPublished   CourseStatus = 2 // 已发布
+	CourseStatusUnpublished CourseStatus = 3 // 已下架
+)
+
+var courseStatusNames = statusNames{
+	"课程状态",
+	map[int8]string{1: "draft", 2: "published", 3: "unpublished"},
+}
+
+// courseTransitions 课程状态机：草稿和已下架的课程可以发布，已发布的课程可以下架
+var courseTransitions = map[CourseStatus][]CourseStatus{
+	CourseStatusDraft:       {CourseStatusPublished},
+	CourseStatusPublished:   {CourseStatusUnpublished},
+	CourseStatusUnpublished: {CourseStatusPublished},
+}
+
+// ParseCourseStatus 按名称或数字解析课程状态，如 "published" 或 "2"
+func ParseCourseStatus(s string) (CourseStatus, error) {
+	v, err := courseStatusNames.parse(s)
+	return CourseStatus(v), err
+}
+
+// IsValid 是否为已定义的课程状态
+func (s CourseStatus) IsValid() bool { return courseStatusNames.has(int8(s)) }
+
+// IsPublished 课程是否已发布，只有已发布的课程可以购买和出现在列表中
+func (s CourseStatus) IsPublished() bool { return s == CourseStatusPublished }
+
+// CanTransitionTo 课程能否从当前状态变为next
+func (s CourseStatus) CanTransitionTo(next CourseStatus) bool {
+	for _, to := range courseTransitions[s] {
+		if to == next {
+			return true
+		}
+	}
+	return false
+}
+
+func (s CourseStatus) String() string { return courseStatusNames.name(int8(s)) }
+
+// SQL 用于手写SQL的数字字面量，带名称注释，如 2 /* published */
+func (s CourseStatus) SQL() string { return courseStatusNames.sql(int8(s)) }
+
+func (s CourseStatus) MarshalJSON() ([]byte, error) { return json.Marshal(s.String()) }
+
+func (s *CourseStatus) UnmarshalJSON(data []byte) error {
+	v, err := courseStatusNames.unmarshal(data)
+	*s = CourseStatus(v)
+	return err
+}
+
+func (s CourseStatus) Value() (driver.Value, error) { return int64(s), nil }
+
+func (s *CourseStatus) Scan(src interface{}) error {
+	v, err := scanInt8(src)
+	*s = CourseStatus(v)
+	return err
+}
+
+// UserStatus 用户状态
+type UserStatus int8
+
+const (
+	UserStatusActive   UserStatus = 1 // 正常
+	UserStatusDisabled UserStatus = 2 // 禁用
+)
+
+var userStatusNames = statusNames{
+	"用户状态",
+	map[int8]string{1: "active", 2: "disabled"},
+}
+
+// ParseUserStatus 按名称或数字解析用户状态，如 "active" 或 "1"
+func ParseUserStatus(s string) (UserStatus, error) {
+	v, err := userStatusNames.parse(s)
+	return UserStatus(v), err
+}
+
+// IsValid 是否为已定义的用户状态
+func (s UserStatus) IsValid() bool { return userStatusNames.has(int8(s)) }
+
+// IsActive 用户是否为正常状态，禁用的用户不能登录
+func (s UserStatus) IsActive() bool { return s == UserStatusActive }
+
+// CanTransitionTo 用户能否从当前状态变为next，正常和禁用之间可以互相切换
+func (s UserStatus) CanTransitionTo(next UserStatus) bool {
+	return s != next && s.IsValid() && next.IsValid()
+}
+
+func (s UserStatus) String() string { return userStatusNames.name(int8(s)) }
+
+// SQL 用于手写SQL的数字字面量，带名称注释，如 1 /* active */
+func (s UserStatus) SQL() string { return userStatusNames.sql(int8(s)) }
+
+func (s UserStatus) MarshalJSON() ([]byte, error) { return json.Marshal(s.String()) }
+
+func (s *UserStatus) UnmarshalJSON(data []byte) error {
+	v, err := userStatusNames.unmarshal(data)
+	*s = UserStatus(v)
+	return err
+}
+
+func (s UserStatus) Value() (driver.Value, error) { return int64(s), nil }
+
+func (s *UserStatus) Scan(src interface{}) error {
+	v, err := scanInt8(src)
+	*s = UserStatus(v)
+	return err
+}
+
+// WaitlistStatus 候补状态
+type WaitlistStatus int8
+
+const (
+	WaitlistStatusWaiting   WaitlistStatus = 1 // 等待中
+	WaitlistStatusOffered   WaitlistStatus = 2 // 已通知，限期内可以购买
+	WaitlistStatusExpired   WaitlistStatus = 3 // 通知已过期
+	WaitlistStatusConverted WaitlistStatus = 4 // 已购买
+)
+
+var waitlistStatusNames = statusNames{
+	"候补状态",
+	map[int8]string{1: "waiting", 2: "offered", 3: "expired", 4: "converted"},
+}
+
+// waitlistTransitions 候补状态机：等待中的用户在有名额时收到通知，也可以直接购买；
+// 通知过期或购买后再次候补时回到等待中，重新排在队尾
+var waitlistTransitions = map[WaitlistStatus][]WaitlistStatus{
+	WaitlistStatusWaiting:   {WaitlistStatusOffered, WaitlistStatusConverted},
+	WaitlistStatusOffered:   {WaitlistStatusExpired, WaitlistStatusConverted},
+	WaitlistStatusExpired:   {WaitlistStatusWaiting},
+	WaitlistStatusConverted: {WaitlistStatusWaiting},
+}
+
+// ParseWaitlistStatus 按名称或数字解析候补状态，如 "offered" 或 "2"
+func ParseWaitlistStatus(s string) (WaitlistStatus, error) {
+	v, err := waitlistStatusNames.parse(s)
+	return WaitlistStatus(v), err
+}
+
+// IsValid 是否为已定义的候补状态
+func (s WaitlistStatus) IsValid() bool { return waitlistStatusNames.has(int8(s)) }
+
+// IsActive 是否仍在候补中（等待中或已通知），这时不能重复加入
+func (s WaitlistStatus) IsActive() bool {
+	return s == WaitlistStatusWaiting || s == WaitlistStatusOffered
+}
+
+// CanTransitionTo 候补能否从当前状态变为next
+func (s WaitlistStatus) CanTransitionTo(next WaitlistStatus) bool {
+	for _, to := range waitlistTransitions[s] {
+		if to == next {
+			return true
+		}
+	}
+	return false
+}
+
+func (s WaitlistStatus) String() string { return waitlistStatusNames.name(int8(s)) }
+
+// SQL 用于手写SQL的数字字面量，带名称注释，如 2 /* offered */
+func (s WaitlistStatus) SQL() string { return waitlistStatusNames.sql(int8(s)) }
+
+func (s WaitlistStatus) MarshalJSON() ([]byte, error) { return json.Marshal(s.String()) }
+
+func (s *WaitlistStatus) UnmarshalJSON(data []byte) error {
+	v, err := waitlistStatusNames.unmarshal(data)
+	*s = WaitlistStatus(v)
+	return err
+}
+
+func (s WaitlistStatus) Value() (driver.Value, error) { return int64(s), nil }
+
+func (s *WaitlistStatus) Scan(src interface{}) error {
+	v, err := scanInt8(src)
+	*s = WaitlistStatus(v)
+	return err
+}
+
+// PromotionStatus 限时促销状态，只用于列表展示和报表，促销是否生效始终按时间窗口判断
+type PromotionStatus int8
+
+const (
+	PromotionStatusScheduled PromotionStatus = 1 // 未开始
+	PromotionStatusActive    PromotionStatus = 2 // 进行中
+	PromotionStatusEnded     PromotionStatus = 3 // 已结束
+)
+
+var promotionStatusNames = statusNames{
+	"促销状态",
+	map[int8]string{1: "scheduled", 2: "active", 3: "ended"},
+}
+
+// PromotionStatusAt 时间窗口 [startsAt, endsAt) 在now时对应的促销状态
+func PromotionStatusAt(startsAt, endsAt, now time.Time) PromotionStatus {
+	switch {
+	case now.Before(startsAt):
+		return PromotionStatusScheduled
+	case now.Before(endsAt):
+		return PromotionStatusActive
+	default:
+		return PromotionStatusEnded
+	}
+}
+
+// ParsePromotionStatus 按名称或数字解析促销状态，如 "active" 或 "2"
+func ParsePromotionStatus(s string) (PromotionStatus, error) {
+	v, err := promotionStatusNames.parse(s)
+	return PromotionStatus(v), err
+}
+
+// IsValid 是否为已定义的促销状态
+func (s PromotionStatus) IsValid() bool { return promotionStatusNames.has(int8(s)) }
+
+func (s PromotionStatus) String() string { return promotionStatusNames.name(int8(s)) }
+
+// SQL 用于手写SQL的数字字面量，带名称注释，如 2 /* active */
+func (s PromotionStatus) SQL() string { return promotionStatusNames.sql(int8(s)) }
+
+func (s PromotionStatus) MarshalJSON() ([]byte, error) { return json.Marshal(s.String()) }
+
+func (s *PromotionStatus) UnmarshalJSON(data []byte) error {
+	v, err := promotionStatusNames.unmarshal(data)
+	*s = PromotionStatus(v)
+	return err
+}
+
+func (s PromotionStatus) Value() (driver.Value, error) { return int64(s), nil }
+
+func (s *PromotionStatus) Scan(src interface{}) error {
+	v, err := scanInt8(src)
+	*s = PromotionStatus(v)
+	return err
+}
+
+// CertificateStatus 结业证书状态
+type CertificateStatus int8
+
+const (
+	CertificateStatusValid   CertificateStatus = 1 // 有效
+	CertificateStatusRevoked CertificateStatus = 2 // 已撤销（课程退款）
+)
+
+var certificateStatusNames = statusNames{
+	"证书状态",
+	map[int8]string{1: "valid", 2: "revoked"},
+}
+
+// IsValid 是否为已定义的证书状态
+func (s CertificateStatus) IsValid() bool { return certificateStatusNames.has(int8(s)) }
+
+func (s CertificateStatus) String() string { return certificateStatusNames.name(int8(s)) }
+
+// SQL 用于手写SQL的数字字面量，带名称注释，如 2 /* revoked */
+func (s CertificateStatus) SQL() string { return certificateStatusNames.sql(int8(s)) }
+
+func (s CertificateStatus) MarshalJSON() ([]byte, error) { return json.Marshal(s.String()) }
+
+func (s *CertificateStatus) UnmarshalJSON(data []byte) error {
+	v, err := certificateStatusNames.unmarshal(data)
+	*s = CertificateStatus(v)
+	return err
+}
+
+func (s CertificateStatus) Value() (driver.Value, error) { return int64(s), nil }
+
+func (s *CertificateStatus) Scan(src interface{}) error {
+	v, err := scanInt8(src)
+	*s = CertificateStatus(v)
+	return err
+}
+
+// statusNames 状态值与名称的对应关系，供各状态类型共用
+type statusNames struct {
+	kind  string
+	names map[int8]string
+}
+
+func (n statusNames) has(v int8) bool {
+	_, ok := n.names[v]
+	return ok
+}
+
+// name 状态名称，未定义的值（如零值）输出数字
+func (n statusNames) name(v int8) string {
+	if name, ok := n.names[v]; ok {
+		return name
+	}
+	return strconv.Itoa(int(v))
+}
+
+func (n statusNames) sql(v int8) string {
+	return fmt.Sprintf("%d /* %s */", v, n.name(v))
+}
+
+// parse 按名称或数字解析，只接受已定义的状态
+func (n statusNames) parse(s string) (int8, error) {
+	for v, name := range n.names {
+		if name == s {
+			return v, nil
+		}
+	}
+	if v, err := strconv.ParseInt(s, 10, 8); err == nil && n.has(int8(v)) {
+		return int8(v), nil
+	}
+	return 0, fmt.Errorf("无效的%s: %q", n.kind, s)
+}
+
+// unmarshal 解析JSON中的状态，兼容旧客户端：名称字符串或数字都可以；
+// 数字不校验取值，与改为类型前一致，由 validate 标签等负责校验
+func (n statusNames) unmarshal(data []byte) (int8, error) {
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return 0, err
+		}
+		for v, name := range n.names {
+			if name == s {
+				return v, nil
+			}
+		}
+		data = []byte(s)
+	}
+	v, err := strconv.ParseInt(string(data), 10, 8)
+	if err != nil {
+		return 0, fmt.Errorf("无效的%s: %s", n.kind, data)
+	}
+	return int8(v), nil
+}
+
+// scanInt8 从数据库值读取状态，各驱动可能返回整数、字节或字符串
+func scanInt8(src interface{}) (int8, error) {
+	switch v := src.(type) {
+	case nil:
+		return 0, nil
+	case int64:
+		return int8(v), nil
+	case []byte:
+		n, err := strconv.ParseInt(string(v), 10, 8)
+		return int8(n), err
+	case string:
+		n, err := strconv.ParseInt(v, 10, 8)
+		return int8(n), err
+	}
+	return 0, fmt.Errorf("无法将 %T 读取为状态", src)
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm/schema"
+)
+
+// Tag 课程标签（如"零基础"、"项目实战"），与课程多对多，关联表为 course_tags
+// 按规范化后的名称查找或创建，"Go"、" go " 是同一个标签，Name 保留首次创建时的写法用于展示
+type Tag struct {
+	ID             uint      `gorm:"primarykey" json:"id"`
+	Name           string    `gorm:"size:50;not null" json:"name"`
+	NormalizedName string    `gorm:"uniqueIndex;size:50;not null" json:"-"`
+	UsageCount     int       `gorm:"default:0;comment:关联的课程数" json:"usage_count"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (Tag) TableName(namer schema.Namer) string {
+	return prefixedTable(namer, "tags")
+}
+
+// NormalizeTagName 标签名规范化：去掉首尾空白，连续空白合并为一个空格，转为小写
+func NormalizeTagName(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm/schema"
+)
+
+// VerificationCode 验证码模型（注册、重置密码时验证邮箱或手机号）
+// 只保存验证码的哈希；验证通过后生成一次性凭证，同样只保存哈希，注册时凭凭证证明已完成验证
+type VerificationCode struct {
+	ID          uint       `gorm:"primarykey" json:"id"`
+	CreatedAt   time.Time  `gorm:"index:idx_verification_target_time,priority:2" json:"created_at"`
+	Target      string     `gorm:"index:idx_verification_target_time,priority:1;size:100;not null;comment:邮箱或手机号" json:"target"`
+	Purpose     string     `gorm:"size:20;not null;comment:register-注册,reset-重置密码" json:"purpose"`
+	CodeHash    string     `gorm:"size:64;not null" json:"-"`
+	ExpiresAt   time.Time  `gorm:"index;not null" json:"expires_at"`
+	Attempts    int        `gorm:"default:0;comment:已尝试次数" json:"attempts"`
+	ConsumedAt  *time.Time `gorm:"comment:验证通过时间" json:"consumed_at"`
+	TokenHash   string     `gorm:"index;size:64" json:"-"`
+	TokenUsedAt *time.Time `gorm:"comment:凭证使用时间" json:"token_used_at"`
+}
+
+// TableName 指定表名
+func (VerificationCode) TableName(namer schema.Namer) string {
+	return prefixedTable(namer, "verification_codes")
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm/schema"
+)
+
+// Waitlist 限额课程的候补名单，每个用户在每门课程下只有一条记录
+// 课程满员后用户加入候补；有名额空出时按 Position 从小到大通知，被通知的用户在 OfferExpiresAt 前可以购买，
+// 过期未购买则通知下一位。Position 只增不减，前端展示的排名按等待中的记录实时统计，不受他人购买或过期影响
+type Waitlist struct {
+	BaseModel
+	CourseID       uint           `gorm:"uniqueIndex:idx_waitlist_course_user;index:idx_waitlist_course_position,priority:1;not null" json:"course_id"`
+	UserID         uint           `gorm:"uniqueIndex:idx_waitlist_course_user;index;not null" json:"user_id"`
+	Position       int64          `gorm:"index:idx_waitlist_course_position,priority:2;not null;comment:加入顺序，同一课程内递增" json:"-"`
+	Status         WaitlistStatus `gorm:"size:8;index;default:1;comment:1-等待中,2-已通知,3-已过期,4-已购买" json:"status"`
+	OfferedAt      *time.Time     `json:"offered_at"`
+	OfferExpiresAt *time.Time     `gorm:"index" json:"offer_expires_at"`
+
+	// 关联
+	User   User   `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	Course Course `gorm:"foreignKey:CourseID" json:"course,omitempty"`
+}
+
+// TableName 指定表名
+func (Waitlist) TableName(namer schema.Namer) string {
+	return prefixedTable(namer, "waitlists")
+}
@@ -0,0 +1,184 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"edu-platform/models"
+)
+
+// deletionGracePeriod 注销宽限期，期间用户可以撤销申请
+const deletionGracePeriod = 14 * 24 * time.Hour
+
+// AccountDeletionService 账户注销服务
+type AccountDeletionService struct {
+	db *gorm.DB
+}
+
+// NewAccountDeletionService 创建账户注销服务
+func NewAccountDeletionService(db *gorm.DB) *AccountDeletionService {
+	return &AccountDeletionService{db: db}
+}
+
+// RequestDeletion 申请注销账户，宽限期结束后执行匿名化
+func (s *AccountDeletionService) RequestDeletion(userID uint) (*models.DeletionRequest, error) {
+	pending, err := s.IsPending(userID)
+	if err != nil {
+		return nil, err
+	}
+	if pending {
+		return nil, ErrConflict.WithMsg("account.deletion_pending")
+	}
+
+	now := time.Now()
+	request := models.DeletionRequest{
+		UserID:       userID,
+		RequestedAt:  now,
+		ExecuteAfter: now.Add(deletionGracePeriod),
+		Status:       1, // 待执行
+	}
+	if err := s.db.Create(&request).Error; err != nil {
+		return nil, err
+	}
+	return &request, nil
+}
+
+// CancelDeletion 在宽限期内撤销注销申请
+func (s *AccountDeletionService) CancelDeletion(userID uint) error {
+	var request models.DeletionRequest
+	err := s.db.Where("user_id = ? AND status = ?", userID, 1).First(&request).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrNotFound.WithMsg("account.deletion_not_found")
+		}
+		return err
+	}
+
+	if time.Now().After(request.ExecuteAfter) {
+		return ErrConflict.WithMsg("account.deletion_grace_expired")
+	}
+
+	now := time.Now()
+	// 带状态条件更新，避免与后台执行任务并发时撤销已执行的申请
+	result := s.db.Model(&request).Where("status = ?", 1).Updates(map[string]interface{}{
+		"status":       2, // 已取消
+		"cancelled_at": &now,
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrConflict.WithMsg("account.deletion_grace_expired")
+	}
+	return nil
+}
+
+// IsPending 用户是否有待执行的注销申请（有则禁止登录）
+func (s *AccountDeletionService) IsPending(userID uint) (bool, error) {
+	var count int64
+	err := s.db.Model(&models.DeletionRequest{}).
+		Where("user_id = ? AND status = ?", userID, 1).Count(&count).Error
+	return count > 0, err
+}
+
+// Execute 执行注销申请：在一个事务中匿名化用户并删除个人数据
+// 订单、选课和学习记录保留并继续关联到匿名化后的用户；重复执行已完成的申请直接返回
+func (s *AccountDeletionService) Execute(requestID uint) error {
+	tx := s.db.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	var request models.DeletionRequest
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&request, requestID).Error; err != nil {
+		tx.Rollback()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrNotFound.WithMsg("account.deletion_not_found")
+		}
+		return err
+	}
+
+	switch request.Status {
+	case 3: // 已执行
+		tx.Rollback()
+		return nil
+	case 2: // 已取消
+		tx.Rollback()
+		return ErrConflict.WithMsg("account.deletion_cancelled")
+	}
+
+	// 匿名化用户：邮箱为非空唯一列，使用唯一占位值；手机号置空
+	userID := request.UserID
+	if err := tx.Model(&models.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+		"username":                  fmt.Sprintf("deleted_user_%d", userID),
+		"email":                     fmt.Sprintf("deleted_user_%d@deleted.invalid", userID),
+		"phone":                     gorm.Expr("NULL"),
+		"password":                  "",
+		"nickname":                  "",
+		"avatar":                    "",
+		"login_ip":                  "",
+		"last_login_at":             gorm.Expr("NULL"),
+		"email_verified_at":         gorm.Expr("NULL"),
+		"phone_verified_at":         gorm.Expr("NULL"),
+		"status":                    models.UserStatusDisabled,
+		"unread_notification_count": 0, // 通知随个人数据一起删除
+	}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	// 物理删除个人数据
+	personalData := []interface{}{
+		&models.UserProfile{},
+		&models.CourseFavorite{},
+		&models.Notification{},
+		&models.LoginHistory{},
+	}
+	for _, model := range personalData {
+		if err := tx.Unscoped().Where("user_id = ?", userID).Delete(model).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	now := time.Now()
+	if err := tx.Model(&request).Updates(map[string]interface{}{
+		"status":      3, // 已执行
+		"executed_at": &now,
+	}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit().Error
+}
+
+// ExecuteDue 执行所有宽限期已结束的注销申请，返回成功执行的数量
+func (s *AccountDeletionService) ExecuteDue(ctx context.Context) (int, error) {
+	var requests []models.DeletionRequest
+	if err := s.db.WithContext(ctx).Where("status = ? AND execute_after <= ?", 1, time.Now()).
+		Find(&requests).Error; err != nil {
+		return 0, err
+	}
+
+	executed := 0
+	for _, request := range requests {
+		if err := ctx.Err(); err != nil {
+			return executed, err
+		}
+		if err := s.Execute(request.ID); err != nil {
+			log.Printf("执行注销申请失败: request=%d user=%d: %v", request.ID, request.UserID, err)
+			continue
+		}
+		executed++
+	}
+	return executed, nil
+}
@@ -0,0 +1,155 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+
+	"edu-platform/models"
+	"edu-platform/services"
+	"edu-platform/testhelpers"
+	"edu-platform/testhelpers/factory"
+)
+
+// expireGracePeriod 把注销申请的宽限期结束时间改为一分钟前
+func expireGracePeriod(t *testing.T, db *gorm.DB, requestID uint) {
+	t.Helper()
+	err := db.Model(&models.DeletionRequest{}).Where("id = ?", requestID).
+		Update("execute_after", time.Now().Add(-time.Minute)).Error
+	if err != nil {
+		t.Fatalf("修改宽限期失败: %v", err)
+	}
+}
+
+// countByUser 统计用户的个人数据条数（包括软删除的）
+func countByUser(t *testing.T, db *gorm.DB, model interface{}, userID uint) int64 {
+	t.Helper()
+	var n int64
+	if err := db.Unscoped().Model(model).Where("user_id = ?", userID).Count(&n).Error; err != nil {
+		t.Fatalf("统计 %T 失败: %v", model, err)
+	}
+	return n
+}
+
+// TestExecuteDueAnonymizes 宽限期结束的申请被执行：用户被匿名化、个人数据被删除，订单保留；
+// 宽限期内的申请不执行，重复执行已完成的申请不报错
+func TestExecuteDueAnonymizes(t *testing.T) {
+	t.Parallel()
+	db := testhelpers.NewDB(t)
+	f := factory.New(t, db)
+	deletions := services.NewAccountDeletionService(db)
+
+	leaving, waiting := f.User("student"), f.User("student")
+	course := f.Course(9900)
+	order := f.PaidOrder(leaving.ID, course.ID)
+	// 用户资料在创建用户时自动创建
+	if n := countByUser(t, db, &models.UserProfile{}, leaving.ID); n != 1 {
+		t.Fatalf("用户资料有 %d 条，期望 1", n)
+	}
+	for _, record := range []interface{}{
+		&models.CourseFavorite{UserID: leaving.ID, CourseID: course.ID},
+		&models.Notification{UserID: leaving.ID, Title: "欢迎", Type: 1},
+	} {
+		if err := db.Create(record).Error; err != nil {
+			t.Fatalf("创建 %T 失败: %v", record, err)
+		}
+	}
+
+	due, err := deletions.RequestDeletion(leaving.ID)
+	if err != nil {
+		t.Fatalf("申请注销失败: %v", err)
+	}
+	if _, err := deletions.RequestDeletion(waiting.ID); err != nil {
+		t.Fatalf("申请注销失败: %v", err)
+	}
+	expireGracePeriod(t, db, due.ID)
+
+	executed, err := deletions.ExecuteDue(context.Background())
+	if err != nil || executed != 1 {
+		t.Fatalf("ExecuteDue 返回 %d, %v，期望执行 1 个申请", executed, err)
+	}
+
+	var user models.User
+	if err := db.First(&user, leaving.ID).Error; err != nil {
+		t.Fatalf("查询用户失败: %v", err)
+	}
+	wantName := fmt.Sprintf("deleted_user_%d", leaving.ID)
+	if user.Username != wantName || user.Email != wantName+"@deleted.invalid" || user.Phone != "" ||
+		user.Password != "" || user.Nickname != "" || user.Status != models.UserStatusDisabled {
+		t.Errorf("匿名化后的用户为 username=%s email=%s phone=%q nickname=%q status=%v",
+			user.Username, user.Email, user.Phone, user.Nickname, user.Status)
+	}
+	for _, model := range []interface{}{&models.UserProfile{}, &models.CourseFavorite{}, &models.Notification{}} {
+		if n := countByUser(t, db, model, leaving.ID); n != 0 {
+			t.Errorf("%T 还有 %d 条记录，期望物理删除", model, n)
+		}
+	}
+	if n := countByUser(t, db, &models.Order{}, leaving.ID); n != 1 {
+		t.Errorf("订单 %d 应保留并关联到匿名用户，实际有 %d 条", order.ID, n)
+	}
+
+	// 宽限期内的申请仍待执行
+	if pending, _ := deletions.IsPending(waiting.ID); !pending {
+		t.Error("宽限期内的申请不应被执行")
+	}
+	var other models.User
+	db.First(&other, waiting.ID)
+	if other.Username != waiting.Username {
+		t.Errorf("宽限期内的用户被修改为 %s", other.Username)
+	}
+
+	// 已执行的申请重复执行直接返回
+	if err := deletions.Execute(due.ID); err != nil {
+		t.Errorf("重复执行返回 %v，期望 nil", err)
+	}
+	if executed, err := deletions.ExecuteDue(context.Background()); err != nil || executed != 0 {
+		t.Errorf("再次 ExecuteDue 返回 %d, %v，期望 0", executed, err)
+	}
+}
+
+// TestCancelDeletion 宽限期内可以撤销，撤销后不能执行；宽限期结束后不能撤销；同一用户不能重复申请
+func TestCancelDeletion(t *testing.T) {
+	t.Parallel()
+	db := testhelpers.NewDB(t)
+	f := factory.New(t, db)
+	deletions := services.NewAccountDeletionService(db)
+
+	user := f.User("student")
+	request, err := deletions.RequestDeletion(user.ID)
+	if err != nil {
+		t.Fatalf("申请注销失败: %v", err)
+	}
+	if got := request.ExecuteAfter.Sub(request.RequestedAt); got != 14*24*time.Hour {
+		t.Errorf("宽限期为 %v，期望14天", got)
+	}
+	if _, err := deletions.RequestDeletion(user.ID); !errors.Is(err, services.ErrConflict) {
+		t.Errorf("重复申请返回 %v，期望 ErrConflict", err)
+	}
+
+	if err := deletions.CancelDeletion(user.ID); err != nil {
+		t.Fatalf("撤销失败: %v", err)
+	}
+	if pending, _ := deletions.IsPending(user.ID); pending {
+		t.Error("撤销后仍有待执行的申请")
+	}
+	if err := deletions.Execute(request.ID); !errors.Is(err, services.ErrConflict) {
+		t.Errorf("执行已撤销的申请返回 %v，期望 ErrConflict", err)
+	}
+	if err := deletions.CancelDeletion(user.ID); !errors.Is(err, services.ErrNotFound) {
+		t.Errorf("没有待执行申请时撤销返回 %v，期望 ErrNotFound", err)
+	}
+
+	// 宽限期结束后不能撤销
+	again, err := deletions.RequestDeletion(user.ID)
+	if err != nil {
+		t.Fatalf("再次申请注销失败: %v", err)
+	}
+	expireGracePeriod(t, db, again.ID)
+	if err := deletions.CancelDeletion(user.ID); !errors.Is(err, services.ErrConflict) {
+		t.Errorf("宽限期结束后撤销返回 %v，期望 ErrConflict", err)
+	}
+}
@@ -0,0 +1,33 @@
+package services
+
+import (
+	"gorm.io/gorm"
+
+	"edu-platform/models"
+)
+
+// AuditService 操作日志，记录登录用户的写操作，写入 system_logs
+type AuditService struct {
+	db *gorm.DB
+}
+
+// NewAuditService 创建操作日志服务
+func NewAuditService(db *gorm.DB) *AuditService {
+	return &AuditService{db: db}
+}
+
+// Record 写入一条操作日志，超出列长度的URL和User-Agent截断后保存
+func (s *AuditService) Record(entry *models.SystemLog) error {
+	entry.URL = truncateRunes(entry.URL, 500)
+	entry.UserAgent = truncateRunes(entry.UserAgent, 500)
+	entry.Action = truncateRunes(entry.Action, 100)
+	return s.db.Create(entry).Error
+}
+
+// truncateRunes 按字符截断字符串
+func truncateRunes(s string, max int) string {
+	if runes := []rune(s); len(runes) > max {
+		return string(runes[:max])
+	}
+	return s
+}
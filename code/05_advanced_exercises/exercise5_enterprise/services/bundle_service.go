@@ -0,0 +1,167 @@
+package services
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+
+	"edu-platform/models"
+)
+
+// settingRejectOwnedBundle 购买课程包时已拥有其中部分课程的处理方式：
+// false（默认）排除已拥有的课程并按比例降低课程包价格，true 直接拒绝下单
+const settingRejectOwnedBundle = "order.bundle_reject_owned"
+
+// BundleService 课程包服务
+type BundleService struct {
+	db *gorm.DB
+}
+
+// NewBundleService 创建课程包服务
+func NewBundleService(db *gorm.DB) *BundleService {
+	return &BundleService{db: db}
+}
+
+// GetBundles 获取已发布的课程包列表
+func (s *BundleService) GetBundles(page, pageSize int) ([]models.Bundle, int64, error) {
+	var bundles []models.Bundle
+	var total int64
+
+	query := s.db.Model(&models.Bundle{}).Where("status = ?", 2)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	err := query.Preload("Items", func(db *gorm.DB) *gorm.DB {
+		return db.Order("sort ASC")
+	}).Preload("Items.Course").
+		Order("created_at DESC").Limit(pageSize).Offset(offset).Find(&bundles).Error
+
+	return bundles, total, err
+}
+
+// GetBundleByID 获取课程包详情
+func (s *BundleService) GetBundleByID(id uint) (*models.Bundle, error) {
+	var bundle models.Bundle
+	err := s.db.Where("status = ?", 2).Preload("Items", func(db *gorm.DB) *gorm.DB {
+		return db.Order("sort ASC")
+	}).Preload("Items.Course").First(&bundle, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound.WithMsg("bundle.not_found")
+		}
+		return nil, err
+	}
+	return &bundle, nil
+}
+
+// bundleLine 课程包展开后的一个订单项
+type bundleLine struct {
+	BundleID uint
+	Course   models.Course
+	Price    int64 // 分摊后的价格
+}
+
+// expandBundles 将课程包展开为订单项
+// 用户已拥有的课程按设置排除（课程包价格按原价比例降低）或拒绝下单
+func expandBundles(tx *gorm.DB, userID uint, bundleIDs []uint) ([]bundleLine, error) {
+	if len(bundleIDs) == 0 {
+		return nil, nil
+	}
+
+	var bundles []models.Bundle
+	if err := tx.Where("id IN ? AND status = ?", bundleIDs, 2).
+		Preload("Items.Course", withSnapshotJoins).Find(&bundles).Error; err != nil {
+		return nil, err
+	}
+	if len(bundles) != len(bundleIDs) {
+		return nil, ErrNotFound.WithMsg("bundle.unavailable")
+	}
+
+	rejectOwned := NewSettingsService(tx).GetBool(settingRejectOwnedBundle, false)
+
+	var lines []bundleLine
+	for _, bundle := range bundles {
+		courseIDs := make([]uint, 0, len(bundle.Items))
+		for _, item := range bundle.Items {
+			if !item.Course.Status.IsPublished() {
+				return nil, ErrNotFound.WithMsg("course.unavailable")
+			}
+			courseIDs = append(courseIDs, item.CourseID)
+		}
+
+		// 查询用户已拥有的课程
+		var ownedIDs []uint
+		if err := tx.Model(&models.Enrollment{}).
+			Where("user_id = ? AND course_id IN ? AND status = ?", userID, courseIDs, 1).
+			Pluck("course_id", &ownedIDs).Error; err != nil {
+			return nil, err
+		}
+		owned := make(map[uint]bool, len(ownedIDs))
+		for _, id := range ownedIDs {
+			owned[id] = true
+		}
+		if len(owned) > 0 && rejectOwned {
+			return nil, ErrConflict.WithMsg("bundle.owned_course")
+		}
+
+		var fullWeight, remainingWeight int64
+		var remaining []models.Course
+		for _, item := range bundle.Items {
+			fullWeight += item.Course.Price
+			if !owned[item.CourseID] {
+				remainingWeight += item.Course.Price
+				remaining = append(remaining, item.Course)
+			}
+		}
+		if len(remaining) == 0 {
+			return nil, ErrConflict.WithMsg("order.already_purchased")
+		}
+
+		// 排除已拥有的课程后，按剩余课程的原价占比降低课程包价格
+		price := bundle.Price
+		if len(remaining) < len(bundle.Items) {
+			if fullWeight > 0 {
+				price = bundle.Price * remainingWeight / fullWeight
+			} else {
+				price = bundle.Price * int64(len(remaining)) / int64(len(bundle.Items))
+			}
+		}
+
+		weights := make([]int64, len(remaining))
+		for i, course := range remaining {
+			weights[i] = course.Price
+		}
+		for i, allocated := range allocateByWeight(price, weights) {
+			lines = append(lines, bundleLine{BundleID: bundle.ID, Course: remaining[i], Price: allocated})
+		}
+	}
+	return lines, nil
+}
+
+// allocateByWeight 按权重分摊金额，舍入误差计入最后一项，保证分摊结果之和等于amount
+// 权重全为0时平均分摊
+func allocateByWeight(amount int64, weights []int64) []int64 {
+	result := make([]int64, len(weights))
+	if len(weights) == 0 {
+		return result
+	}
+
+	var totalWeight int64
+	for _, w := range weights {
+		totalWeight += w
+	}
+
+	var allocated int64
+	for i := 0; i < len(weights)-1; i++ {
+		if totalWeight > 0 {
+			result[i] = amount * weights[i] / totalWeight
+		} else {
+			result[i] = amount / int64(len(weights))
+		}
+		allocated += result[i]
+	}
+	result[len(weights)-1] = amount - allocated
+	return result
+}
@@ -0,0 +1,128 @@
+package services_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"gorm.io/gorm"
+
+	"edu-platform/models"
+	"edu-platform/services"
+	"edu-platform/testhelpers"
+	"edu-platform/testhelpers/factory"
+)
+
+// newBundle 创建已发布的课程包，课程按参数顺序排列
+func newBundle(t *testing.T, db *gorm.DB, price int64, courses ...*models.Course) *models.Bundle {
+	t.Helper()
+	bundle := models.Bundle{Title: "课程包", Slug: fmt.Sprintf("bundle-%d", price), Price: price, Status: 2}
+	for i, course := range courses {
+		bundle.OriginalPrice += course.Price
+		bundle.Items = append(bundle.Items, models.BundleCourse{CourseID: course.ID, Sort: i})
+	}
+	if err := db.Create(&bundle).Error; err != nil {
+		t.Fatalf("创建课程包失败: %v", err)
+	}
+	return &bundle
+}
+
+// bundleItems 查询订单中的课程包订单项，返回课程ID到分摊价格的映射
+func bundleItems(t *testing.T, db *gorm.DB, order *models.Order, bundleID uint) map[uint]int64 {
+	t.Helper()
+	var items []models.OrderItem
+	if err := db.Where("order_id = ?", order.ID).Find(&items).Error; err != nil {
+		t.Fatalf("查询订单项失败: %v", err)
+	}
+	prices := make(map[uint]int64, len(items))
+	for _, item := range items {
+		if item.BundleID == nil || *item.BundleID != bundleID {
+			t.Errorf("订单项 %d 的课程包为 %v，期望 %d", item.ID, item.BundleID, bundleID)
+		}
+		prices[item.CourseID] = item.Price
+	}
+	return prices
+}
+
+// TestBundleAllocation 课程包价格按课程原价比例分摊到订单项，舍入误差计入最后一项，合计恰好等于课程包价格
+func TestBundleAllocation(t *testing.T) {
+	t.Parallel()
+	db := testhelpers.NewDB(t)
+	f := factory.New(t, db)
+
+	student := f.User("student")
+	a, b, c := f.Course(1000), f.Course(3333), f.Course(777)
+	bundle := newBundle(t, db, 2999, a, b, c)
+
+	order, err := services.NewOrderService(db).CreateOrder(student.ID, nil, []uint{bundle.ID}, "")
+	if err != nil {
+		t.Fatalf("购买课程包失败: %v", err)
+	}
+	if order.TotalAmount != 2999 || order.PayAmount != 2999 {
+		t.Errorf("订单总金额 %d、实付 %d，期望均为 2999", order.TotalAmount, order.PayAmount)
+	}
+
+	// 2999*1000/5110=586，2999*3333/5110=1956，最后一项补足余数
+	want := map[uint]int64{a.ID: 586, b.ID: 1956, c.ID: 457}
+	got := bundleItems(t, db, order, bundle.ID)
+	var sum int64
+	for courseID, price := range got {
+		sum += price
+		if price != want[courseID] {
+			t.Errorf("课程 %d 分摊 %d，期望 %d", courseID, price, want[courseID])
+		}
+	}
+	if len(got) != 3 || sum != bundle.Price {
+		t.Errorf("%d 个订单项合计 %d，期望 3 个合计 %d", len(got), sum, bundle.Price)
+	}
+}
+
+// TestBundleOwnedCourses 已拥有的课程从课程包中排除，价格按剩余课程的原价占比降低；
+// 开启拒绝设置后直接拒绝，全部拥有时不能购买
+func TestBundleOwnedCourses(t *testing.T) {
+	t.Parallel()
+	db := testhelpers.NewDB(t)
+	f := factory.New(t, db)
+	orders := services.NewOrderService(db)
+
+	student := f.User("student")
+	a, b, c := f.Course(1000), f.Course(3333), f.Course(777)
+	bundle := newBundle(t, db, 2999, a, b, c)
+	f.PaidOrder(student.ID, a.ID)
+
+	order, err := orders.CreateOrder(student.ID, nil, []uint{bundle.ID}, "")
+	if err != nil {
+		t.Fatalf("购买课程包失败: %v", err)
+	}
+	// 剩余课程原价 4110/5110，课程包价格降为 2999*4110/5110=2412
+	got := bundleItems(t, db, order, bundle.ID)
+	var sum int64
+	for _, price := range got {
+		sum += price
+	}
+	if _, ok := got[a.ID]; ok || len(got) != 2 {
+		t.Errorf("订单项为 %v，期望排除已拥有的课程 %d", got, a.ID)
+	}
+	if sum != 2412 || order.TotalAmount != 2412 {
+		t.Errorf("订单项合计 %d、订单总金额 %d，期望均为 2412", sum, order.TotalAmount)
+	}
+	if err := orders.CancelOrder(order.OrderNo, student.ID); err != nil {
+		t.Fatalf("取消订单失败: %v", err)
+	}
+
+	if err := services.NewSettingsService(db).Set("order.bundle_reject_owned", "true", "bool"); err != nil {
+		t.Fatalf("写入设置失败: %v", err)
+	}
+	if _, err := orders.CreateOrder(student.ID, nil, []uint{bundle.ID}, ""); !errors.Is(err, services.ErrConflict) {
+		t.Errorf("开启拒绝后购买返回 %v，期望 ErrConflict", err)
+	}
+
+	owner := f.User("student")
+	f.PaidOrder(owner.ID, a.ID, b.ID, c.ID)
+	if err := services.NewSettingsService(db).Set("order.bundle_reject_owned", "false", "bool"); err != nil {
+		t.Fatalf("写入设置失败: %v", err)
+	}
+	if _, err := orders.CreateOrder(owner.ID, nil, []uint{bundle.ID}, ""); !errors.Is(err, services.ErrConflict) {
+		t.Errorf("已拥有全部课程时购买返回 %v，期望 ErrConflict", err)
+	}
+}
@@ -0,0 +1,139 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+)
+
+// Cache 缓存接口，值为字节串，结构体通过 GetJSON/SetJSON 序列化
+// 实现：MemoryCache（进程内，单实例部署和开发环境）、RedisCache（多实例共享）；
+// RedisCache 外层包一层 NewDegradingCache，连接失败时降级为不缓存，请求照常查询数据库
+type Cache interface {
+	// Get 读取缓存，不存在或已过期时返回false
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	// Set 写入缓存，ttl<=0表示不过期
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Delete 删除缓存，键不存在时不报错
+	Delete(ctx context.Context, keys ...string) error
+	// Incr 计数加1并返回加1后的值；键不存在时从0开始计数，并设置ttl后过期
+	Incr(ctx context.Context, key string, ttl time.Duration) (int64, error)
+	// SetNX 键不存在时写入并返回true，已存在时不修改并返回false
+	SetNX(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error)
+}
+
+// GetJSON 读取缓存并按JSON解析到dest，不存在时返回false
+func GetJSON(ctx context.Context, cache Cache, key string, dest interface{}) (bool, error) {
+	data, ok, err := cache.Get(ctx, key)
+	if err != nil || !ok {
+		return false, err
+	}
+	if err := json.Unmarshal(data, dest); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// SetJSON 把value序列化为JSON后写入缓存
+func SetJSON(ctx context.Context, cache Cache, key string, value interface{}, ttl time.Duration) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return cache.Set(ctx, key, data, ttl)
+}
+
+// degradedRetryInterval 缓存不可用时，隔多久再尝试访问一次
+const degradedRetryInterval = 30 * time.Second
+
+// degradingCache 缓存访问失败时降级为不缓存：读取视为未命中，写入和删除忽略，
+// Incr 返回0（限流等依赖计数的功能放行），SetNX 返回true（视为抢到）。
+// 降级期间不再访问底层缓存，每隔 degradedRetryInterval 试一次，避免每个请求都等待连接超时；
+// 不可用和恢复时各记录一条日志
+type degradingCache struct {
+	next Cache
+
+	mu      sync.Mutex
+	down    bool
+	retryAt time.Time
+}
+
+// NewDegradingCache 包装缓存，访问失败时降级为不缓存，不向调用方返回错误
+func NewDegradingCache(next Cache) Cache {
+	return &degradingCache{next: next}
+}
+
+// available 缓存是否可以访问：正常，或降级后已到重试时间
+func (c *degradingCache) available() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return !c.down || !time.Now().Before(c.retryAt)
+}
+
+// observe 记录一次访问结果，返回是否成功
+func (c *degradingCache) observe(err error) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err != nil {
+		if !c.down {
+			log.Printf("[WARN] 缓存不可用，降级为不缓存: %v", err)
+		}
+		c.down = true
+		c.retryAt = time.Now().Add(degradedRetryInterval)
+		return false
+	}
+	if c.down {
+		log.Printf("缓存已恢复")
+		c.down = false
+	}
+	return true
+}
+
+func (c *degradingCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	if !c.available() {
+		return nil, false, nil
+	}
+	value, ok, err := c.next.Get(ctx, key)
+	if !c.observe(err) {
+		return nil, false, nil
+	}
+	return value, ok, nil
+}
+
+func (c *degradingCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if c.available() {
+		c.observe(c.next.Set(ctx, key, value, ttl))
+	}
+	return nil
+}
+
+func (c *degradingCache) Delete(ctx context.Context, keys ...string) error {
+	if c.available() {
+		c.observe(c.next.Delete(ctx, keys...))
+	}
+	return nil
+}
+
+func (c *degradingCache) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	if !c.available() {
+		return 0, nil
+	}
+	n, err := c.next.Incr(ctx, key, ttl)
+	if !c.observe(err) {
+		return 0, nil
+	}
+	return n, nil
+}
+
+func (c *degradingCache) SetNX(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+	if !c.available() {
+		return true, nil
+	}
+	ok, err := c.next.SetNX(ctx, key, value, ttl)
+	if !c.observe(err) {
+		return true, nil
+	}
+	return ok, nil
+}
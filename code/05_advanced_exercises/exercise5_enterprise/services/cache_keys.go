@@ -0,0 +1,31 @@
+package services
+
+import (
+	"strconv"
+	"strings"
+)
+
+// 缓存键统一在这里定义，格式为 edu:<版本>:<功能>:...，不同功能的键不会冲突
+// 缓存的数据结构变化时把 CacheKeyVersion 加1，部署后新版本不再读取旧键，旧键等待过期即可，不需要手动清理
+const (
+	cacheKeyPrefix  = "edu"
+	CacheKeyVersion = "v1"
+)
+
+// cacheKey 拼接缓存键
+func cacheKey(parts ...string) string {
+	return cacheKeyPrefix + ":" + CacheKeyVersion + ":" + strings.Join(parts, ":")
+}
+
+// courseCacheKinds 按课程缓存的数据种类，课程缓存失效时逐个删除
+var courseCacheKinds = []string{relatedCacheKind}
+
+// courseCacheKey 按课程缓存的数据：edu:v1:course:<课程ID>:<种类>
+func courseCacheKey(courseID uint, kind string) string {
+	return cacheKey("course", strconv.FormatUint(uint64(courseID), 10), kind)
+}
+
+// rateLimitCacheKey 限流计数：edu:v1:ratelimit:<场景>:<对象>:<窗口序号>
+func rateLimitCacheKey(scope, subject string, window int64) string {
+	return cacheKey("ratelimit", scope, subject, strconv.FormatInt(window, 10))
+}
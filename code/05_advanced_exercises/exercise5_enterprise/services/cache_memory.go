@@ -0,0 +1,125 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// memoryCacheSweepSize 缓存条目超过该数量时，写入前先清理已过期的条目
+const memoryCacheSweepSize = 1024
+
+// memoryCacheEntry 缓存条目，expiresAt为零值表示不过期
+type memoryCacheEntry struct {
+	value     []byte
+	expiresAt time.Time
+}
+
+func (e memoryCacheEntry) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && !now.Before(e.expiresAt)
+}
+
+// MemoryCache 进程内缓存，多实例部署时各实例分别缓存、分别计数
+type MemoryCache struct {
+	mu      sync.Mutex
+	entries map[string]memoryCacheEntry
+	now     func() time.Time
+}
+
+// NewMemoryCache 创建进程内缓存
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{entries: make(map[string]memoryCacheEntry), now: time.Now}
+}
+
+// lookup 读取未过期的条目，已过期的顺便删除；调用方须持有锁
+func (c *MemoryCache) lookup(key string, now time.Time) (memoryCacheEntry, bool) {
+	entry, ok := c.entries[key]
+	if !ok {
+		return entry, false
+	}
+	if entry.expired(now) {
+		delete(c.entries, key)
+		return entry, false
+	}
+	return entry, true
+}
+
+// store 写入条目，条目较多时先清理已过期的；调用方须持有锁
+func (c *MemoryCache) store(key string, value []byte, ttl time.Duration, now time.Time) {
+	if len(c.entries) >= memoryCacheSweepSize {
+		for k, entry := range c.entries {
+			if entry.expired(now) {
+				delete(c.entries, k)
+			}
+		}
+	}
+	entry := memoryCacheEntry{value: append([]byte(nil), value...)}
+	if ttl > 0 {
+		entry.expiresAt = now.Add(ttl)
+	}
+	c.entries[key] = entry
+}
+
+func (c *MemoryCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.lookup(key, c.now())
+	if !ok {
+		return nil, false, nil
+	}
+	return append([]byte(nil), entry.value...), true, nil
+}
+
+func (c *MemoryCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.store(key, value, ttl, c.now())
+	return nil
+}
+
+func (c *MemoryCache) Delete(ctx context.Context, keys ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, key := range keys {
+		delete(c.entries, key)
+	}
+	return nil
+}
+
+// Incr 与Redis一致，计数按十进制文本保存；已有的值不是整数时返回错误，过期时间保持不变
+func (c *MemoryCache) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	entry, ok := c.lookup(key, now)
+	if !ok {
+		c.store(key, []byte("1"), ttl, now)
+		return 1, nil
+	}
+	n, err := strconv.ParseInt(string(entry.value), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("缓存 %s 的值不是整数", key)
+	}
+	n++
+	entry.value = []byte(strconv.FormatInt(n, 10))
+	c.entries[key] = entry
+	return n, nil
+}
+
+func (c *MemoryCache) SetNX(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	if _, ok := c.lookup(key, now); ok {
+		return false, nil
+	}
+	c.store(key, value, ttl, now)
+	return true, nil
+}
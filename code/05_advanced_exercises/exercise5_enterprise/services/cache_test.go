@@ -0,0 +1,133 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"edu-platform/services"
+)
+
+// flakyCache 在 MemoryCache 外层模拟缓存故障，记录实际访问底层缓存的次数
+type flakyCache struct {
+	*services.MemoryCache
+	down  bool
+	calls int
+}
+
+func (c *flakyCache) fail() error {
+	c.calls++
+	if c.down {
+		return errors.New("dial tcp: connection refused")
+	}
+	return nil
+}
+
+func (c *flakyCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	if err := c.fail(); err != nil {
+		return nil, false, err
+	}
+	return c.MemoryCache.Get(ctx, key)
+}
+
+func (c *flakyCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if err := c.fail(); err != nil {
+		return err
+	}
+	return c.MemoryCache.Set(ctx, key, value, ttl)
+}
+
+func (c *flakyCache) Delete(ctx context.Context, keys ...string) error {
+	if err := c.fail(); err != nil {
+		return err
+	}
+	return c.MemoryCache.Delete(ctx, keys...)
+}
+
+func (c *flakyCache) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	if err := c.fail(); err != nil {
+		return 0, err
+	}
+	return c.MemoryCache.Incr(ctx, key, ttl)
+}
+
+func (c *flakyCache) SetNX(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+	if err := c.fail(); err != nil {
+		return false, err
+	}
+	return c.MemoryCache.SetNX(ctx, key, value, ttl)
+}
+
+// TestDegradingCache 缓存正常时透传；访问失败后降级为不缓存且不返回错误：读取未命中、Incr返回0、SetNX返回true，
+// 降级期间不再访问底层缓存
+func TestDegradingCache(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	next := &flakyCache{MemoryCache: services.NewMemoryCache()}
+	cache := services.NewDegradingCache(next)
+
+	if err := services.SetJSON(ctx, cache, "course:1", map[string]int{"id": 1}, time.Minute); err != nil {
+		t.Fatalf("写入缓存失败: %v", err)
+	}
+	var cached map[string]int
+	if ok, err := services.GetJSON(ctx, cache, "course:1", &cached); !ok || err != nil || cached["id"] != 1 {
+		t.Errorf("读取缓存返回 %v, %v, %v，期望命中", cached, ok, err)
+	}
+	if n, err := cache.Incr(ctx, "hits", time.Minute); n != 1 || err != nil {
+		t.Errorf("Incr返回 %d, %v，期望 1", n, err)
+	}
+	if ok, err := cache.SetNX(ctx, "course:1", []byte("x"), time.Minute); ok || err != nil {
+		t.Errorf("已存在的键SetNX返回 %v, %v，期望 false", ok, err)
+	}
+
+	next.down = true
+	if _, ok, err := cache.Get(ctx, "course:1"); ok || err != nil {
+		t.Errorf("故障时读取返回 %v, %v，期望未命中且不报错", ok, err)
+	}
+	calls := next.calls
+	if err := cache.Set(ctx, "course:2", []byte("{}"), time.Minute); err != nil {
+		t.Errorf("降级后写入返回 %v，期望忽略", err)
+	}
+	if err := cache.Delete(ctx, "course:1"); err != nil {
+		t.Errorf("降级后删除返回 %v，期望忽略", err)
+	}
+	if n, err := cache.Incr(ctx, "hits", time.Minute); n != 0 || err != nil {
+		t.Errorf("降级后Incr返回 %d, %v，期望 0（放行）", n, err)
+	}
+	if ok, err := cache.SetNX(ctx, "course:1", []byte("x"), time.Minute); !ok || err != nil {
+		t.Errorf("降级后SetNX返回 %v, %v，期望 true（视为抢到）", ok, err)
+	}
+	if ok, err := services.GetJSON(ctx, cache, "course:1", &cached); ok || err != nil {
+		t.Errorf("降级后GetJSON返回 %v, %v，期望未命中", ok, err)
+	}
+	if next.calls != calls {
+		t.Errorf("降级期间访问了底层缓存 %d 次，期望等到重试时间", next.calls-calls)
+	}
+}
+
+// TestDegradingRedisUnreachable Redis连接不上时，包装后的 RedisCache 不返回错误，请求照常进行
+func TestDegradingRedisUnreachable(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	client := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", DialTimeout: 200 * time.Millisecond, MaxRetries: -1})
+	t.Cleanup(func() { client.Close() })
+
+	redisCache := services.NewRedisCache(client)
+	if _, _, err := redisCache.Get(ctx, "course:1"); err == nil {
+		t.Fatal("未包装的 RedisCache 连接失败时应返回错误")
+	}
+
+	cache := services.NewDegradingCache(redisCache)
+	if _, ok, err := cache.Get(ctx, "course:1"); ok || err != nil {
+		t.Errorf("读取返回 %v, %v，期望未命中且不报错", ok, err)
+	}
+	if n, err := cache.Incr(ctx, "rate:1", time.Minute); n != 0 || err != nil {
+		t.Errorf("Incr返回 %d, %v，期望 0", n, err)
+	}
+	if err := cache.Set(ctx, "course:1", []byte("{}"), time.Minute); err != nil {
+		t.Errorf("写入返回 %v，期望忽略", err)
+	}
+}
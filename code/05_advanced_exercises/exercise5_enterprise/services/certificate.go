@@ -0,0 +1,181 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"edu-platform/models"
+)
+
+const (
+	certificateSerialChars    = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789" // 去掉容易看错的 0/O、1/I
+	certificateSerialLength   = 6                                  // 编号中随机部分的长度
+	certificateSerialAttempts = 5                                  // 编号冲突时最多重试的次数
+)
+
+// CertificateService 结业证书服务
+type CertificateService struct {
+	db *gorm.DB
+}
+
+// NewCertificateService 创建结业证书服务
+func NewCertificateService(db *gorm.DB) *CertificateService {
+	return &CertificateService{db: db}
+}
+
+// CertificateInfo 证书信息，我的证书列表和公开验证接口共用
+type CertificateInfo struct {
+	Serial      string                   `json:"serial"`
+	CourseID    uint                     `json:"course_id"`
+	CourseTitle string                   `json:"course_title"`
+	HolderName  string                   `json:"holder_name"` // 持有人昵称，未设置昵称时为用户名
+	IssuedAt    time.Time                `json:"issued_at"`
+	Status      models.CertificateStatus `json:"status"`
+	Valid       bool                     `json:"valid"`
+	RevokedAt   *time.Time               `json:"revoked_at,omitempty"`
+	ContentHash string                   `json:"content_hash"`
+}
+
+// certificateInfoQuery 证书信息查询，课程和用户被删除后证书仍可验证，关联时不过滤软删除
+func (s *CertificateService) certificateInfoQuery() *gorm.DB {
+	return s.db.Table(models.TableAs(s.db, "certificates")).
+		Select("certificates.serial, certificates.course_id, courses.title AS course_title, " +
+			"COALESCE(NULLIF(users.nickname, ''), users.username) AS holder_name, " +
+			"certificates.issued_at, certificates.status, certificates.revoked_at, certificates.content_hash").
+		Joins("JOIN " + models.TableAs(s.db, "courses") + " ON courses.id = certificates.course_id").
+		Joins("JOIN " + models.TableAs(s.db, "users") + " ON users.id = certificates.user_id").
+		Where("certificates.deleted_at IS NULL")
+}
+
+// GetUserCertificates 用户的全部证书（包括已撤销的），新签发的在前
+func (s *CertificateService) GetUserCertificates(userID uint) ([]CertificateInfo, error) {
+	certificates := []CertificateInfo{}
+	if err := s.certificateInfoQuery().Where("certificates.user_id = ?", userID).
+		Order("certificates.issued_at DESC, certificates.id DESC").
+		Scan(&certificates).Error; err != nil {
+		return nil, err
+	}
+	for i := range certificates {
+		certificates[i].Valid = certificates[i].Status == models.CertificateStatusValid
+	}
+	return certificates, nil
+}
+
+// VerifyCertificate 按编号验证证书，不需要登录；编号不区分大小写，已撤销的证书同样返回，Valid 为false
+func (s *CertificateService) VerifyCertificate(serial string) (*CertificateInfo, error) {
+	serial = strings.ToUpper(strings.TrimSpace(serial))
+	var certificates []CertificateInfo
+	if err := s.certificateInfoQuery().Where("certificates.serial = ?", serial).Limit(1).
+		Scan(&certificates).Error; err != nil {
+		return nil, err
+	}
+	if len(certificates) == 0 {
+		return nil, ErrNotFound.WithMsg("certificate.not_found")
+	}
+	info := &certificates[0]
+	info.Valid = info.Status == models.CertificateStatusValid
+	return info, nil
+}
+
+// issueCertificateIfCompleted 用户学完课程全部启用的课时时签发证书，在更新学习进度的事务中调用
+// 已有证书（包括已撤销的）时不重复签发；并发完成最后两个课时时由 (user_id, course_id) 唯一索引保证只签发一张
+func issueCertificateIfCompleted(tx *gorm.DB, userID, courseID uint, now time.Time) (*models.Certificate, error) {
+	completed, err := completedCourses(tx, userID, []uint{courseID})
+	if err != nil || !completed[courseID] {
+		return nil, err
+	}
+	issued, err := hasCertificate(tx, userID, courseID)
+	if err != nil || issued {
+		return nil, err
+	}
+
+	certificate := &models.Certificate{
+		UserID:   userID,
+		CourseID: courseID,
+		IssuedAt: now,
+		Status:   models.CertificateStatusValid,
+	}
+	for attempt := 0; attempt < certificateSerialAttempts; attempt++ {
+		if certificate.Serial, err = newCertificateSerial(now); err != nil {
+			return nil, err
+		}
+		certificate.ContentHash = certificateContentHash(certificate)
+
+		// 在保存点中插入，插入失败只回滚这一步，不影响外层事务
+		insertErr := tx.Transaction(func(tx *gorm.DB) error {
+			return tx.Create(certificate).Error
+		})
+		if insertErr == nil {
+			return certificate, nil
+		}
+
+		// 插入失败：编号已被占用时换一个编号重试，否则是同一用户同一课程已签发（并发完成）
+		taken, err := certificateSerialTaken(tx, certificate.Serial)
+		if err != nil {
+			return nil, err
+		}
+		if !taken {
+			if issued, err := hasCertificate(tx, userID, courseID); err != nil || issued {
+				return nil, err
+			}
+			return nil, insertErr
+		}
+		certificate.ID = 0
+	}
+	return nil, fmt.Errorf("生成证书编号失败：连续%d次与已有编号重复", certificateSerialAttempts)
+}
+
+// hasCertificate 用户是否已有该课程的证书，加锁读取最新提交的数据，不受事务快照影响
+func hasCertificate(tx *gorm.DB, userID, courseID uint) (bool, error) {
+	var count int64
+	err := tx.Model(&models.Certificate{}).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("user_id = ? AND course_id = ?", userID, courseID).Count(&count).Error
+	return count > 0, err
+}
+
+// certificateSerialTaken 证书编号是否已被占用
+func certificateSerialTaken(tx *gorm.DB, serial string) (bool, error) {
+	var count int64
+	err := tx.Model(&models.Certificate{}).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("serial = ?", serial).Count(&count).Error
+	return count > 0, err
+}
+
+// revokeCertificates 撤销用户在这些课程下的证书，在退款的事务中调用
+func revokeCertificates(tx *gorm.DB, userID uint, courseIDs []uint, now time.Time) error {
+	if len(courseIDs) == 0 {
+		return nil
+	}
+	return tx.Model(&models.Certificate{}).
+		Where("user_id = ? AND course_id IN ? AND status = ?", userID, courseIDs, models.CertificateStatusValid).
+		Updates(map[string]interface{}{"status": models.CertificateStatusRevoked, "revoked_at": &now}).Error
+}
+
+// newCertificateSerial 生成证书编号：CERT-<签发年份>-<6位随机字符>，随机部分约10亿种组合，冲突时由调用方重试
+func newCertificateSerial(now time.Time) (string, error) {
+	max := big.NewInt(int64(len(certificateSerialChars)))
+	buf := make([]byte, certificateSerialLength)
+	for i := range buf {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", fmt.Errorf("生成证书编号失败: %w", err)
+		}
+		buf[i] = certificateSerialChars[n.Int64()]
+	}
+	return fmt.Sprintf("CERT-%d-%s", now.Year(), buf), nil
+}
+
+// certificateContentHash 证书内容的SHA-256：编号、用户、课程和签发时间，打印的证书可以凭此核对内容未被修改
+func certificateContentHash(c *models.Certificate) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s|%d|%d|%s",
+		c.Serial, c.UserID, c.CourseID, c.IssuedAt.UTC().Format(time.RFC3339))))
+	return hex.EncodeToString(sum[:])
+}
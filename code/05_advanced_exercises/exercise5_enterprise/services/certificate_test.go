@@ -0,0 +1,91 @@
+package services_test
+
+import (
+	"strings"
+	"testing"
+
+	"edu-platform/models"
+	"edu-platform/services"
+	"edu-platform/testhelpers"
+	"edu-platform/testhelpers/factory"
+)
+
+// completeCourse 学完课程的全部课时
+func completeCourse(t *testing.T, f *factory.Factory, learning *services.LearningService, userID, courseID uint) {
+	t.Helper()
+	for _, lesson := range f.Lessons(courseID) {
+		if err := learning.UpdateProgress(userID, courseID, lesson.ID, 100, lesson.Duration); err != nil {
+			t.Fatalf("更新课时 %d 的进度失败: %v", lesson.ID, err)
+		}
+	}
+}
+
+// certificatesByCourse 用户的证书按课程ID索引
+func certificatesByCourse(t *testing.T, certificates *services.CertificateService, userID uint) map[uint]services.CertificateInfo {
+	t.Helper()
+	list, err := certificates.GetUserCertificates(userID)
+	if err != nil {
+		t.Fatalf("查询证书失败: %v", err)
+	}
+	byCourse := make(map[uint]services.CertificateInfo, len(list))
+	for _, info := range list {
+		byCourse[info.CourseID] = info
+	}
+	return byCourse
+}
+
+// TestRefundRevokesCertificate 退款的课程的证书被撤销，验证时仍能查到但无效；同一订单中未退款课程的证书不受影响；
+// 重新购买并学完后不再签发新证书
+func TestRefundRevokesCertificate(t *testing.T) {
+	t.Parallel()
+	db := testhelpers.NewDB(t)
+	f := factory.New(t, db)
+	learning := services.NewLearningService(db)
+	certificates := services.NewCertificateService(db)
+
+	student := f.User("student")
+	refunded, kept := f.Course(9900), f.Course(19900)
+	order := f.PaidOrder(student.ID, refunded.ID, kept.ID)
+	completeCourse(t, f, learning, student.ID, refunded.ID)
+	completeCourse(t, f, learning, student.ID, kept.ID)
+
+	issued := certificatesByCourse(t, certificates, student.ID)
+	if len(issued) != 2 || !issued[refunded.ID].Valid || !issued[kept.ID].Valid {
+		t.Fatalf("学完后的证书为 %+v，期望两门课程各一张有效证书", issued)
+	}
+
+	var itemID uint
+	for _, item := range order.Items {
+		if item.CourseID == refunded.ID {
+			itemID = item.ID
+		}
+	}
+	if err := services.NewOrderService(db).RefundItems(order.ID, []uint{itemID}); err != nil {
+		t.Fatalf("部分退款失败: %v", err)
+	}
+
+	after := certificatesByCourse(t, certificates, student.ID)
+	if revoked := after[refunded.ID]; revoked.Valid || revoked.Status != models.CertificateStatusRevoked || revoked.RevokedAt == nil {
+		t.Errorf("退款课程的证书为 %+v，期望已撤销", revoked)
+	}
+	if !after[kept.ID].Valid {
+		t.Errorf("未退款课程的证书为 %+v，期望仍然有效", after[kept.ID])
+	}
+
+	info, err := certificates.VerifyCertificate(strings.ToLower(issued[refunded.ID].Serial))
+	if err != nil {
+		t.Fatalf("验证证书失败: %v", err)
+	}
+	if info.Valid || info.RevokedAt == nil || info.ContentHash != issued[refunded.ID].ContentHash {
+		t.Errorf("验证结果为 %+v，期望显示已撤销且内容哈希不变", info)
+	}
+
+	// 已有（已撤销的）证书时不重复签发
+	f.PaidOrder(student.ID, refunded.ID)
+	completeCourse(t, f, learning, student.ID, refunded.ID)
+	var count int64
+	db.Model(&models.Certificate{}).Where("user_id = ? AND course_id = ?", student.ID, refunded.ID).Count(&count)
+	if again := certificatesByCourse(t, certificates, student.ID)[refunded.ID]; count != 1 || again.Valid {
+		t.Errorf("重新购买学完后有 %d 张证书、状态 %v，期望保持原来那张已撤销的证书", count, again.Status)
+	}
+}
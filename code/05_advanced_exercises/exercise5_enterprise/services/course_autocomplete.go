@@ -0,0 +1,57 @@
+package services
+
+import (
+	"strings"
+
+	"gorm.io/gorm/clause"
+
+	"edu-platform/models"
+)
+
+const maxAutocompleteLimit = 20 // 自动补全最多返回的条数
+
+// Suggestion 自动补全候选项
+type Suggestion struct {
+	Text     string `json:"text"`
+	CourseID uint   `json:"course_id"`
+}
+
+// Suggest 输入框自动补全，返回标题以prefix开头的已发布课程
+// 直接查询数据库，title上有索引，前缀 LIKE 'x%' 可以走索引范围扫描；
+// 相同标题只返回一条（取ID最小的课程），完全匹配的排在最前，其余按学生数倒序、标题短的优先
+func (s *CourseService) Suggest(prefix string, limit int) ([]Suggestion, error) {
+	prefix = strings.TrimSpace(prefix)
+	if runes := []rune(prefix); len(runes) > maxSuggestQueryLength {
+		prefix = string(runes[:maxSuggestQueryLength])
+	}
+	if prefix == "" {
+		return []Suggestion{}, nil
+	}
+	if limit <= 0 {
+		limit = defaultSuggestLimit
+	}
+	if limit > maxAutocompleteLimit {
+		limit = maxAutocompleteLimit
+	}
+
+	// 转义LIKE通配符，用户输入只作为普通文本匹配
+	escaped := strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(prefix)
+
+	suggestions := make([]Suggestion, 0, limit)
+	err := s.db.Model(&models.Course{}).
+		Select("title AS text, MIN(id) AS course_id").
+		Scopes(AvailableCourses()).
+		Where("title LIKE ? ESCAPE '!'", escaped+"%").
+		Group("title").
+		Clauses(clause.OrderBy{Expression: clause.Expr{
+			SQL:                "CASE WHEN title = ? THEN 0 ELSE 1 END, MAX(student_count) DESC, LENGTH(title) ASC, title ASC",
+			Vars:               []interface{}{prefix},
+			WithoutParentheses: true,
+		}}).
+		Limit(limit).
+		Scan(&suggestions).Error
+	if err != nil {
+		return nil, err
+	}
+	return suggestions, nil
+}
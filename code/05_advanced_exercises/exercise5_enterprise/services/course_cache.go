@@ -0,0 +1,48 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// CourseCache 按课程缓存计算代价较高的查询结果（带过期时间），值按JSON序列化
+// 只适合允许短时间不一致的数据，如推荐列表；底层为Redis时多个实例共享，为 MemoryCache 时各实例分别缓存
+type CourseCache struct {
+	cache Cache
+}
+
+// NewCourseCache 创建课程缓存
+func NewCourseCache(cache Cache) *CourseCache {
+	return &CourseCache{cache: cache}
+}
+
+// Get 读取缓存并解析到dest，不存在、已过期或解析失败时返回false
+func (c *CourseCache) Get(courseID uint, kind string, dest interface{}) bool {
+	key := courseCacheKey(courseID, kind)
+	ok, err := GetJSON(context.Background(), c.cache, key, dest)
+	if err != nil {
+		log.Printf("读取课程缓存 %s 失败: %v", key, err)
+		return false
+	}
+	return ok
+}
+
+// Set 写入缓存，ttl后过期
+func (c *CourseCache) Set(courseID uint, kind string, value interface{}, ttl time.Duration) {
+	key := courseCacheKey(courseID, kind)
+	if err := SetJSON(context.Background(), c.cache, key, value, ttl); err != nil {
+		log.Printf("写入课程缓存 %s 失败: %v", key, err)
+	}
+}
+
+// Invalidate 删除课程的所有缓存
+func (c *CourseCache) Invalidate(courseID uint) {
+	keys := make([]string, 0, len(courseCacheKinds))
+	for _, kind := range courseCacheKinds {
+		keys = append(keys, courseCacheKey(courseID, kind))
+	}
+	if err := c.cache.Delete(context.Background(), keys...); err != nil {
+		log.Printf("删除课程 %d 的缓存失败: %v", courseID, err)
+	}
+}
@@ -0,0 +1,112 @@
+package services
+
+import (
+	"edu-platform/models"
+)
+
+const catalogCoursesPerCategory = 8 // 首页每个分类展示的课程数量
+
+// CatalogCourse 首页课程目录中的课程
+type CatalogCourse struct {
+	ID            uint    `json:"id"`
+	CategoryID    uint    `json:"-"`
+	Title         string  `json:"title"`
+	Slug          string  `json:"slug"`
+	Subtitle      string  `json:"subtitle"`
+	Cover         string  `json:"cover"`
+	Price         int64   `json:"price"`
+	OriginalPrice int64   `json:"original_price"`
+	Level         int8    `json:"level"`
+	StudentCount  int     `json:"student_count"`
+	Rating        float32 `json:"rating"`
+	IsFree        bool    `json:"is_free"`
+}
+
+// CategoryWithCourses 首页课程目录中的分类，包含该分类的热门课程和子分类
+type CategoryWithCourses struct {
+	ID       uint                  `json:"id"`
+	Name     string                `json:"name"`
+	Slug     string                `json:"slug"`
+	Icon     string                `json:"icon"`
+	Cover    string                `json:"cover"`
+	Courses  []CatalogCourse       `json:"courses"`
+	Children []CategoryWithCourses `json:"children"`
+}
+
+// GetCatalog 获取首页课程目录
+// 返回启用的分类树，每个分类带学生数最多的若干门已发布课程。
+// 分类和课程各查询一次，课程用窗口函数按分类分别取前N条，不会产生N+1查询；
+// 父分类被禁用时其子分类也不展示
+func (s *CourseService) GetCatalog() ([]CategoryWithCourses, error) {
+	var categories []models.Category
+	if err := s.db.Where("status = ?", 1).Order("sort ASC, id ASC").Find(&categories).Error; err != nil {
+		return nil, err
+	}
+	if len(categories) == 0 {
+		return []CategoryWithCourses{}, nil
+	}
+
+	ids := make([]uint, 0, len(categories))
+	for _, category := range categories {
+		ids = append(ids, category.ID)
+	}
+
+	// 按分类分区编号，学生数相同时按ID保证顺序稳定
+	ranked := s.db.Model(&models.Course{}).Table(models.TableAs(s.db, "courses")).
+		Select("courses.*, ROW_NUMBER() OVER (PARTITION BY category_id ORDER BY student_count DESC, id ASC) AS rn").
+		Scopes(AvailableCourses()).
+		Where("category_id IN ?", ids)
+
+	var courses []CatalogCourse
+	if err := s.db.Table("(?) AS ranked", ranked).
+		Where("rn <= ?", catalogCoursesPerCategory).
+		Order("category_id ASC, rn ASC").
+		Scan(&courses).Error; err != nil {
+		return nil, err
+	}
+
+	coursesByCategory := make(map[uint][]CatalogCourse)
+	for _, course := range courses {
+		coursesByCategory[course.CategoryID] = append(coursesByCategory[course.CategoryID], course)
+	}
+
+	childrenByParent := make(map[uint][]models.Category)
+	var roots []models.Category
+	for _, category := range categories {
+		if category.ParentID == nil {
+			roots = append(roots, category)
+		} else {
+			childrenByParent[*category.ParentID] = append(childrenByParent[*category.ParentID], category)
+		}
+	}
+
+	// 从顶级分类开始组装，父分类不在启用列表中的子分类不会被访问到
+	var build func(category models.Category, depth int) CategoryWithCourses
+	build = func(category models.Category, depth int) CategoryWithCourses {
+		node := CategoryWithCourses{
+			ID:       category.ID,
+			Name:     category.Name,
+			Slug:     category.Slug,
+			Icon:     category.Icon,
+			Cover:    category.Cover,
+			Courses:  coursesByCategory[category.ID],
+			Children: []CategoryWithCourses{},
+		}
+		if node.Courses == nil {
+			node.Courses = []CatalogCourse{}
+		}
+		// 防止错误数据形成环导致无限递归
+		if depth < len(categories) {
+			for _, child := range childrenByParent[category.ID] {
+				node.Children = append(node.Children, build(child, depth+1))
+			}
+		}
+		return node
+	}
+
+	catalog := make([]CategoryWithCourses, 0, len(roots))
+	for _, root := range roots {
+		catalog = append(catalog, build(root, 0))
+	}
+	return catalog, nil
+}
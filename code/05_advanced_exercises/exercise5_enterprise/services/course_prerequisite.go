@@ -0,0 +1,221 @@
+package services
+
+import (
+	"context"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"edu-platform/models"
+)
+
+// PrerequisiteStatus 先修课程及用户的完成情况
+type PrerequisiteStatus struct {
+	CourseID  uint   `json:"course_id"`
+	Title     string `json:"title"`
+	Slug      string `json:"slug"`
+	Completed bool   `json:"completed"`
+}
+
+// CourseDetail 课程详情，附带先修课程及当前用户的完成情况
+// 分类、讲师和章节按 include 加载，未加载或记录已被删除的不出现在响应中（指针字段覆盖 Course 中的同名字段）
+type CourseDetail struct {
+	*models.Course
+	Category      *models.Category     `json:"category,omitempty"`
+	Instructor    *models.User         `json:"instructor,omitempty"`
+	Chapters      *[]models.Chapter    `json:"chapters,omitempty"`
+	Prerequisites []PrerequisiteStatus `json:"prerequisites"`
+}
+
+// NewCourseDetail 按已加载的关联构造课程详情，包含章节但章节为空时返回空数组
+func NewCourseDetail(course *models.Course, includes Includes, prerequisites []PrerequisiteStatus) CourseDetail {
+	detail := CourseDetail{Course: course, Prerequisites: prerequisites}
+	if includes.Has("category") && course.Category.ID != 0 {
+		detail.Category = &course.Category
+	}
+	if includes.Has("instructor") && course.Instructor.ID != 0 {
+		detail.Instructor = &course.Instructor
+	}
+	if includes.Has("chapters") {
+		chapters := course.Chapters
+		if chapters == nil {
+			chapters = []models.Chapter{}
+		}
+		detail.Chapters = &chapters
+	}
+	return detail
+}
+
+// SetPrerequisites 设置课程的先修课程（覆盖原有设置），传空列表表示取消全部先修课程，只有课程讲师可以设置
+// 沿先修关系逐层向上查找，如果从新的先修课程能走回当前课程则会形成环（如A→B→C→A），拒绝保存
+func (s *CourseService) SetPrerequisites(courseID, userID uint, prereqIDs []uint) error {
+	ids := make([]uint, 0, len(prereqIDs))
+	seen := make(map[uint]bool)
+	for _, id := range prereqIDs {
+		if id == courseID {
+			return ErrValidation.WithMsg("course.prerequisite_self")
+		}
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if _, err := ownedCourse(tx, courseID, userID); err != nil {
+			return err
+		}
+
+		var titles map[uint]string
+		if len(ids) > 0 {
+			var courses []models.Course
+			if err := tx.Select("id", "title").Where("id IN ?", ids).Find(&courses).Error; err != nil {
+				return err
+			}
+			if len(courses) != len(ids) {
+				return ErrValidation.WithMsg("course.prerequisite_not_found")
+			}
+			titles = make(map[uint]string, len(courses))
+			for _, c := range courses {
+				titles[c.ID] = c.Title
+			}
+		}
+
+		for _, id := range ids {
+			cyclic, err := reachesCourse(tx, id, courseID)
+			if err != nil {
+				return err
+			}
+			if cyclic {
+				return ErrValidation.WithMsg("course.prerequisite_cycle", titles[id])
+			}
+		}
+
+		if err := tx.Where("course_id = ?", courseID).Delete(&models.CoursePrerequisite{}).Error; err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+		rows := make([]models.CoursePrerequisite, len(ids))
+		for i, id := range ids {
+			rows[i] = models.CoursePrerequisite{CourseID: courseID, PrerequisiteCourseID: id}
+		}
+		return tx.Create(&rows).Error
+	})
+}
+
+// reachesCourse 从from出发沿先修关系（课程→它的先修课程）能否到达target，按层批量查询
+func reachesCourse(db *gorm.DB, from, target uint) (bool, error) {
+	visited := map[uint]bool{from: true}
+	frontier := []uint{from}
+	for len(frontier) > 0 {
+		var next []uint
+		if err := db.Model(&models.CoursePrerequisite{}).
+			Where("course_id IN ?", frontier).
+			Pluck("prerequisite_course_id", &next).Error; err != nil {
+			return false, err
+		}
+		frontier = frontier[:0]
+		for _, id := range next {
+			if id == target {
+				return true, nil
+			}
+			if !visited[id] {
+				visited[id] = true
+				frontier = append(frontier, id)
+			}
+		}
+	}
+	return false, nil
+}
+
+// GetPrerequisites 课程的先修课程及用户的完成情况，userID为0（未登录）时均为未完成
+func (s *CourseService) GetPrerequisites(ctx context.Context, courseID, userID uint) ([]PrerequisiteStatus, error) {
+	statuses, err := prerequisiteStatuses(s.db.WithContext(ctx), userID, []uint{courseID})
+	if err != nil {
+		return nil, err
+	}
+	if statuses == nil {
+		statuses = []PrerequisiteStatus{}
+	}
+	return statuses, nil
+}
+
+// prerequisiteStatuses 查询若干课程的全部先修课程（去重）及用户的完成情况
+func prerequisiteStatuses(db *gorm.DB, userID uint, courseIDs []uint) ([]PrerequisiteStatus, error) {
+	var statuses []PrerequisiteStatus
+	err := db.Model(&models.CoursePrerequisite{}).Table(models.TableAs(db, "course_prerequisites")).
+		Select("DISTINCT courses.id AS course_id, courses.title, courses.slug").
+		Joins("JOIN "+models.TableAs(db, "courses")+" ON courses.id = course_prerequisites.prerequisite_course_id AND courses.deleted_at IS NULL").
+		Where("course_prerequisites.course_id IN ?", courseIDs).
+		Order("courses.id").
+		Scan(&statuses).Error
+	if err != nil || len(statuses) == 0 || userID == 0 {
+		return statuses, err
+	}
+
+	ids := make([]uint, len(statuses))
+	for i, st := range statuses {
+		ids[i] = st.CourseID
+	}
+	completed, err := completedCourses(db, userID, ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range statuses {
+		statuses[i].Completed = completed[statuses[i].CourseID]
+	}
+	return statuses, nil
+}
+
+// completedCourses 用户已学完的课程：课程中启用的课时都已完成（learning_progress.is_completed），没有课时的课程不算学完
+func completedCourses(db *gorm.DB, userID uint, courseIDs []uint) (map[uint]bool, error) {
+	var rows []struct {
+		CourseID uint
+		Total    int64
+		Done     int64
+	}
+	err := db.Table(models.TableAs(db, "lessons")).
+		Select("chapters.course_id, COUNT(DISTINCT lessons.id) AS total, COUNT(DISTINCT learning_progress.lesson_id) AS done").
+		Joins("JOIN "+models.TableAs(db, "chapters")+" ON chapters.id = lessons.chapter_id AND chapters.deleted_at IS NULL").
+		Joins("LEFT JOIN "+models.TableAs(db, "learning_progress")+" ON learning_progress.lesson_id = lessons.id AND learning_progress.user_id = ? "+
+			"AND learning_progress.is_completed = ? AND learning_progress.deleted_at IS NULL", userID, true).
+		Where("chapters.course_id IN ? AND lessons.status = ? AND lessons.deleted_at IS NULL", courseIDs, 1).
+		Group("chapters.course_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	completed := make(map[uint]bool, len(rows))
+	for _, r := range rows {
+		completed[r.CourseID] = r.Total > 0 && r.Done >= r.Total
+	}
+	return completed, nil
+}
+
+// checkPrerequisites 用户是否学完了这些课程的全部先修课程，未学完时返回 ErrPrerequisiteNotMet，
+// 消息中列出课程名，Details为未学完的先修课程
+func checkPrerequisites(db *gorm.DB, userID uint, courseIDs []uint) error {
+	if len(courseIDs) == 0 {
+		return nil
+	}
+	statuses, err := prerequisiteStatuses(db, userID, courseIDs)
+	if err != nil {
+		return err
+	}
+
+	var missing []PrerequisiteStatus
+	var titles []string
+	for _, st := range statuses {
+		if !st.Completed {
+			missing = append(missing, st)
+			titles = append(titles, st.Title)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	return ErrPrerequisiteNotMet.WithMsg("course.prerequisite_not_met", strings.Join(titles, ", ")).WithDetails(missing)
+}
@@ -0,0 +1,492 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"edu-platform/models"
+)
+
+// CourseOutline 课程大纲（章节和课时），修订版本以JSON格式保存
+type CourseOutline struct {
+	Chapters []OutlineChapter `json:"chapters"`
+}
+
+// OutlineChapter 大纲中的章节，按数组顺序排序
+type OutlineChapter struct {
+	ID          uint            `json:"id,omitempty"` // 已有章节的ID，新章节为0
+	Title       string          `json:"title"`
+	Description string          `json:"description"`
+	Lessons     []OutlineLesson `json:"lessons"`
+}
+
+// OutlineLesson 大纲中的课时，按数组顺序排序
+// 课时以ID为准，可以改名、调整顺序或移动到其他章节，学习进度不受影响
+type OutlineLesson struct {
+	ID          uint   `json:"id,omitempty"` // 已有课时的ID，新课时为0
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Content     string `json:"content"`
+	VideoURL    string `json:"video_url"`
+	Duration    int    `json:"duration"` // 时长(秒)
+	IsFree      bool   `json:"is_free"`
+}
+
+// OutlineChange 草稿与线上大纲的一处差异
+type OutlineChange struct {
+	Type        string `json:"type"`   // added-新增,removed-删除,retitled-改名,moved-调整位置,updated-内容修改
+	Target      string `json:"target"` // chapter-章节,lesson-课时
+	ID          uint   `json:"id,omitempty"`
+	Title       string `json:"title"`
+	OldTitle    string `json:"old_title,omitempty"`
+	Position    string `json:"position,omitempty"`     // 草稿中的位置，章节为“2”，课时为“2.3”
+	OldPosition string `json:"old_position,omitempty"` // 线上的位置
+}
+
+// CourseRevisionService 课程大纲修订服务
+// 草稿课程直接修改章节、课时；已发布或已下架的课程修改保存为草稿修订，发布修订后才生效
+type CourseRevisionService struct {
+	db *gorm.DB
+}
+
+// NewCourseRevisionService 创建课程大纲修订服务
+func NewCourseRevisionService(db *gorm.DB) *CourseRevisionService {
+	return &CourseRevisionService{db: db}
+}
+
+// GetOutline 获取线上大纲
+func (s *CourseRevisionService) GetOutline(courseID uint) (*CourseOutline, error) {
+	if err := s.db.Select("id").First(&models.Course{}, courseID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound.WithMsg("course.not_found")
+		}
+		return nil, err
+	}
+	return liveOutline(s.db, courseID)
+}
+
+// SaveOutline 保存讲师编辑的大纲
+// 草稿课程直接同步到章节、课时表，返回的修订为nil；其他课程写入草稿修订，已有草稿时覆盖
+func (s *CourseRevisionService) SaveOutline(courseID, authorID uint, outline *CourseOutline) (*models.CourseRevision, error) {
+	if err := validateOutline(outline); err != nil {
+		return nil, err
+	}
+
+	course, err := ownedCourse(s.db, courseID, authorID)
+	if err != nil {
+		return nil, err
+	}
+	if course.Status == models.CourseStatusDraft {
+		return nil, s.db.Transaction(func(tx *gorm.DB) error {
+			return applyOutline(tx, courseID, outline)
+		})
+	}
+
+	data, err := json.Marshal(outline)
+	if err != nil {
+		return nil, err
+	}
+
+	var revision models.CourseRevision
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		// 锁定课程，避免并发保存生成两个草稿
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&models.Course{}, courseID).Error; err != nil {
+			return err
+		}
+
+		err := tx.Where("course_id = ? AND status = ?", courseID, 1).First(&revision).Error
+		if err == nil {
+			revision.AuthorID = authorID
+			revision.Outline = string(data)
+			return tx.Save(&revision).Error
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		var lastNo int
+		if err := tx.Model(&models.CourseRevision{}).Where("course_id = ?", courseID).
+			Select("COALESCE(MAX(revision_no), 0)").Scan(&lastNo).Error; err != nil {
+			return err
+		}
+		revision = models.CourseRevision{
+			CourseID:   courseID,
+			RevisionNo: lastNo + 1,
+			AuthorID:   authorID,
+			Outline:    string(data),
+			Status:     1, // 草稿
+		}
+		return tx.Create(&revision).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &revision, nil
+}
+
+// DiffDraft 比较草稿修订与线上大纲
+func (s *CourseRevisionService) DiffDraft(courseID, userID uint) ([]OutlineChange, error) {
+	if _, err := ownedCourse(s.db, courseID, userID); err != nil {
+		return nil, err
+	}
+
+	_, draft, err := findDraft(s.db, courseID)
+	if err != nil {
+		return nil, err
+	}
+	live, err := liveOutline(s.db, courseID)
+	if err != nil {
+		return nil, err
+	}
+	return DiffOutlines(live, draft), nil
+}
+
+// PublishRevision 发布草稿修订
+// 在一个事务中把草稿同步到章节、课时表，并把课程的当前修订号指向该修订
+func (s *CourseRevisionService) PublishRevision(courseID, userID uint) (*models.CourseRevision, error) {
+	if _, err := ownedCourse(s.db, courseID, userID); err != nil {
+		return nil, err
+	}
+
+	var revision *models.CourseRevision
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&models.Course{}, courseID).Error; err != nil {
+			return err
+		}
+
+		var draft *CourseOutline
+		var err error
+		if revision, draft, err = findDraft(tx, courseID); err != nil {
+			return err
+		}
+		if err := applyOutline(tx, courseID, draft); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		revision.Status = 2 // 已发布
+		revision.PublishedAt = &now
+		if err := tx.Save(revision).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Course{}).Where("id = ?", courseID).
+			Update("revision_no", revision.RevisionNo).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return revision, nil
+}
+
+// ownedCourse 查询课程并检查是否为该讲师的课程
+func ownedCourse(db *gorm.DB, courseID, userID uint) (*models.Course, error) {
+	var course models.Course
+	if err := db.Select("id", "instructor_id", "status").First(&course, courseID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound.WithMsg("course.not_found")
+		}
+		return nil, err
+	}
+	if course.InstructorID != userID {
+		return nil, ErrForbidden.WithMsg("course.not_owner")
+	}
+	return &course, nil
+}
+
+// findDraft 查询课程的草稿修订并解析大纲
+func findDraft(db *gorm.DB, courseID uint) (*models.CourseRevision, *CourseOutline, error) {
+	var revision models.CourseRevision
+	if err := db.Where("course_id = ? AND status = ?", courseID, 1).First(&revision).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrNotFound.WithMsg("course.draft_not_found")
+		}
+		return nil, nil, err
+	}
+
+	var outline CourseOutline
+	if err := json.Unmarshal([]byte(revision.Outline), &outline); err != nil {
+		return nil, nil, ErrInternal.Wrap(err)
+	}
+	return &revision, &outline, nil
+}
+
+// validateOutline 校验大纲：标题必填，章节和课时ID不能重复
+func validateOutline(outline *CourseOutline) error {
+	if outline == nil {
+		return ErrValidation.WithMsg("course.outline_title_required")
+	}
+
+	chapterIDs := make(map[uint]bool)
+	lessonIDs := make(map[uint]bool)
+	for _, chapter := range outline.Chapters {
+		if strings.TrimSpace(chapter.Title) == "" {
+			return ErrValidation.WithMsg("course.outline_title_required")
+		}
+		if chapter.ID != 0 {
+			if chapterIDs[chapter.ID] {
+				return ErrValidation.WithMsg("course.outline_duplicate_id", chapter.ID)
+			}
+			chapterIDs[chapter.ID] = true
+		}
+
+		for _, lesson := range chapter.Lessons {
+			if strings.TrimSpace(lesson.Title) == "" {
+				return ErrValidation.WithMsg("course.outline_title_required")
+			}
+			if lesson.ID != 0 {
+				if lessonIDs[lesson.ID] {
+					return ErrValidation.WithMsg("course.outline_duplicate_id", lesson.ID)
+				}
+				lessonIDs[lesson.ID] = true
+			}
+		}
+	}
+	return nil
+}
+
+// liveOutline 读取章节、课时表中的线上大纲
+func liveOutline(db *gorm.DB, courseID uint) (*CourseOutline, error) {
+	var chapters []models.Chapter
+	err := db.Where("course_id = ?", courseID).Preload("Lessons", func(db *gorm.DB) *gorm.DB {
+		return db.Order("sort ASC, id ASC")
+	}).Order("sort ASC, id ASC").Find(&chapters).Error
+	if err != nil {
+		return nil, err
+	}
+
+	outline := &CourseOutline{Chapters: make([]OutlineChapter, 0, len(chapters))}
+	for _, chapter := range chapters {
+		oc := OutlineChapter{
+			ID:          chapter.ID,
+			Title:       chapter.Title,
+			Description: chapter.Description,
+			Lessons:     make([]OutlineLesson, 0, len(chapter.Lessons)),
+		}
+		for _, lesson := range chapter.Lessons {
+			oc.Lessons = append(oc.Lessons, OutlineLesson{
+				ID:          lesson.ID,
+				Title:       lesson.Title,
+				Description: lesson.Description,
+				Content:     lesson.Content,
+				VideoURL:    lesson.VideoURL,
+				Duration:    lesson.Duration,
+				IsFree:      lesson.IsFree,
+			})
+		}
+		outline.Chapters = append(outline.Chapters, oc)
+	}
+	return outline, nil
+}
+
+// applyOutline 把大纲同步到章节、课时表
+// 以ID对应已有的章节和课时：有ID的更新，没有ID的新增，大纲中不存在的删除；
+// 同时重新计算章节和课程的课时数、时长
+func applyOutline(tx *gorm.DB, courseID uint, outline *CourseOutline) error {
+	var chapters []models.Chapter
+	if err := tx.Where("course_id = ?", courseID).Find(&chapters).Error; err != nil {
+		return err
+	}
+	existingChapters := make(map[uint]bool, len(chapters))
+	chapterIDs := make([]uint, 0, len(chapters))
+	for _, chapter := range chapters {
+		existingChapters[chapter.ID] = true
+		chapterIDs = append(chapterIDs, chapter.ID)
+	}
+
+	existingLessons := make(map[uint]bool)
+	if len(chapterIDs) > 0 {
+		var lessonIDs []uint
+		if err := tx.Model(&models.Lesson{}).Where("chapter_id IN ?", chapterIDs).Pluck("id", &lessonIDs).Error; err != nil {
+			return err
+		}
+		for _, id := range lessonIDs {
+			existingLessons[id] = true
+		}
+	}
+
+	keptChapters := make(map[uint]bool)
+	keptLessons := make(map[uint]bool)
+	courseLessons, courseSeconds := 0, 0
+
+	for ci, oc := range outline.Chapters {
+		chapterID := oc.ID
+		if chapterID != 0 {
+			if !existingChapters[chapterID] {
+				return ErrValidation.WithMsg("course.outline_unknown_chapter", chapterID)
+			}
+			if err := tx.Model(&models.Chapter{}).Where("id = ?", chapterID).Updates(map[string]interface{}{
+				"title":       oc.Title,
+				"description": oc.Description,
+				"sort":        ci + 1,
+			}).Error; err != nil {
+				return err
+			}
+		} else {
+			chapter := models.Chapter{CourseID: courseID, Title: oc.Title, Description: oc.Description, Sort: ci + 1, Status: 1}
+			if err := tx.Create(&chapter).Error; err != nil {
+				return err
+			}
+			chapterID = chapter.ID
+		}
+		keptChapters[chapterID] = true
+
+		chapterSeconds := 0
+		for li, ol := range oc.Lessons {
+			if ol.ID != 0 {
+				if !existingLessons[ol.ID] {
+					return ErrValidation.WithMsg("course.outline_unknown_lesson", ol.ID)
+				}
+				if err := tx.Model(&models.Lesson{}).Where("id = ?", ol.ID).Updates(map[string]interface{}{
+					"chapter_id":  chapterID,
+					"title":       ol.Title,
+					"description": ol.Description,
+					"content":     ol.Content,
+					"video_url":   ol.VideoURL,
+					"duration":    ol.Duration,
+					"is_free":     ol.IsFree,
+					"sort":        li + 1,
+				}).Error; err != nil {
+					return err
+				}
+				keptLessons[ol.ID] = true
+			} else {
+				lesson := models.Lesson{
+					ChapterID:   chapterID,
+					Title:       ol.Title,
+					Description: ol.Description,
+					Content:     ol.Content,
+					VideoURL:    ol.VideoURL,
+					Duration:    ol.Duration,
+					IsFree:      ol.IsFree,
+					Sort:        li + 1,
+					Status:      1,
+				}
+				if err := tx.Create(&lesson).Error; err != nil {
+					return err
+				}
+				keptLessons[lesson.ID] = true
+			}
+			chapterSeconds += ol.Duration
+		}
+
+		if err := tx.Model(&models.Chapter{}).Where("id = ?", chapterID).Updates(map[string]interface{}{
+			"lesson_count": len(oc.Lessons),
+			"duration":     (chapterSeconds + 59) / 60,
+		}).Error; err != nil {
+			return err
+		}
+		courseLessons += len(oc.Lessons)
+		courseSeconds += chapterSeconds
+	}
+
+	// 删除大纲中已经不存在的课时和章节
+	var removedLessons, removedChapters []uint
+	for id := range existingLessons {
+		if !keptLessons[id] {
+			removedLessons = append(removedLessons, id)
+		}
+	}
+	for id := range existingChapters {
+		if !keptChapters[id] {
+			removedChapters = append(removedChapters, id)
+		}
+	}
+	if len(removedLessons) > 0 {
+		if err := tx.Where("id IN ?", removedLessons).Delete(&models.Lesson{}).Error; err != nil {
+			return err
+		}
+	}
+	if len(removedChapters) > 0 {
+		if err := tx.Where("id IN ?", removedChapters).Delete(&models.Chapter{}).Error; err != nil {
+			return err
+		}
+	}
+
+	return tx.Model(&models.Course{}).Where("id = ?", courseID).Updates(map[string]interface{}{
+		"lesson_count": courseLessons,
+		"duration":     (courseSeconds + 59) / 60,
+	}).Error
+}
+
+// DiffOutlines 比较两个大纲，列出新增、删除、改名、调整位置和内容修改的章节和课时
+func DiffOutlines(live, draft *CourseOutline) []OutlineChange {
+	type placed struct {
+		position string
+		chapter  OutlineChapter
+		lesson   OutlineLesson
+	}
+	liveChapters := make(map[uint]placed)
+	liveLessons := make(map[uint]placed)
+	for ci, chapter := range live.Chapters {
+		liveChapters[chapter.ID] = placed{position: fmt.Sprint(ci + 1), chapter: chapter}
+		for li, lesson := range chapter.Lessons {
+			liveLessons[lesson.ID] = placed{position: fmt.Sprintf("%d.%d", ci+1, li+1), lesson: lesson}
+		}
+	}
+
+	changes := []OutlineChange{}
+	seenChapters := make(map[uint]bool)
+	seenLessons := make(map[uint]bool)
+	for ci, chapter := range draft.Chapters {
+		position := fmt.Sprint(ci + 1)
+		old, ok := liveChapters[chapter.ID]
+		switch {
+		case chapter.ID == 0 || !ok:
+			changes = append(changes, OutlineChange{Type: "added", Target: "chapter", Title: chapter.Title, Position: position})
+		default:
+			seenChapters[chapter.ID] = true
+			if old.chapter.Title != chapter.Title {
+				changes = append(changes, OutlineChange{Type: "retitled", Target: "chapter", ID: chapter.ID,
+					Title: chapter.Title, OldTitle: old.chapter.Title, Position: position})
+			}
+			if old.position != position {
+				changes = append(changes, OutlineChange{Type: "moved", Target: "chapter", ID: chapter.ID,
+					Title: chapter.Title, Position: position, OldPosition: old.position})
+			}
+		}
+
+		for li, lesson := range chapter.Lessons {
+			position := fmt.Sprintf("%d.%d", ci+1, li+1)
+			old, ok := liveLessons[lesson.ID]
+			if lesson.ID == 0 || !ok {
+				changes = append(changes, OutlineChange{Type: "added", Target: "lesson", Title: lesson.Title, Position: position})
+				continue
+			}
+			seenLessons[lesson.ID] = true
+			if old.lesson.Title != lesson.Title {
+				changes = append(changes, OutlineChange{Type: "retitled", Target: "lesson", ID: lesson.ID,
+					Title: lesson.Title, OldTitle: old.lesson.Title, Position: position})
+			}
+			if old.position != position {
+				changes = append(changes, OutlineChange{Type: "moved", Target: "lesson", ID: lesson.ID,
+					Title: lesson.Title, Position: position, OldPosition: old.position})
+			}
+			renamed := old.lesson
+			renamed.Title = lesson.Title
+			if renamed != lesson {
+				changes = append(changes, OutlineChange{Type: "updated", Target: "lesson", ID: lesson.ID,
+					Title: lesson.Title, Position: position})
+			}
+		}
+	}
+
+	for ci, chapter := range live.Chapters {
+		if !seenChapters[chapter.ID] {
+			changes = append(changes, OutlineChange{Type: "removed", Target: "chapter", ID: chapter.ID,
+				Title: chapter.Title, OldPosition: fmt.Sprint(ci + 1)})
+		}
+		for li, lesson := range chapter.Lessons {
+			if !seenLessons[lesson.ID] {
+				changes = append(changes, OutlineChange{Type: "removed", Target: "lesson", ID: lesson.ID,
+					Title: lesson.Title, OldPosition: fmt.Sprintf("%d.%d", ci+1, li+1)})
+			}
+		}
+	}
+	return changes
+}
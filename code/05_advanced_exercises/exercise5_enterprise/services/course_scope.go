@@ -0,0 +1,34 @@
+package services
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"edu-platform/models"
+)
+
+// AvailableCourses 学员可浏览、可购买的课程（GORM作用域），用于课程表是主表的查询
+// "课程对学员可见"的条件只在这里定义，列表、搜索、推荐、下单都组合这个作用域，不要再直接写 status = 已发布
+// 条件加在主表上（用 TableAs 起了别名时为别名），与其他表JOIN时不会有列名歧义
+func AvailableCourses() func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where(clause.Eq{
+			Column: clause.Column{Table: clause.CurrentTable, Name: "status"},
+			Value:  models.CourseStatusPublished,
+		})
+	}
+}
+
+// AvailableCoursesIn 同 AvailableCourses，条件加在名称或别名为 table 的课程表上
+// 用于课程表是JOIN进来的表，或只用 Table 指定表名的查询；这些查询GORM不会自动排除软删除的课程，这里一并加上
+func AvailableCoursesIn(table string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where(clause.Eq{
+			Column: clause.Column{Table: table, Name: "status"},
+			Value:  models.CourseStatusPublished,
+		}).Where(clause.Eq{
+			Column: clause.Column{Table: table, Name: "deleted_at"},
+			Value:  nil,
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package services_test
+
+import (
+	"reflect"
+	"testing"
+
+	"edu-platform/models"
+	"edu-platform/services"
+	"edu-platform/testhelpers"
+	"edu-platform/testhelpers/factory"
+)
+
+// TestAvailableCourses 只有已发布的课程可见；草稿、已下架不可见；
+// 课程表是主表时GORM自动排除软删除，JOIN进来时由 AvailableCoursesIn 排除
+func TestAvailableCourses(t *testing.T) {
+	t.Parallel()
+	db := testhelpers.NewDB(t)
+	f := factory.New(t, db)
+
+	published := f.Course(9900)
+	draft, unpublished, deleted := f.Course(9900), f.Course(9900), f.Course(9900)
+	db.Model(draft).Update("status", models.CourseStatusDraft)
+	db.Model(unpublished).Update("status", models.CourseStatusUnpublished)
+	if err := db.Delete(deleted).Error; err != nil {
+		t.Fatalf("删除课程失败: %v", err)
+	}
+	want := []uint{published.ID}
+
+	// 与同样有 status 列的分类表JOIN，条件带表名，不会有列名歧义
+	var ids []uint
+	err := db.Model(&models.Course{}).
+		Joins("JOIN "+models.TableAs(db, "categories")+" ON categories.id = courses.category_id").
+		Scopes(services.AvailableCourses()).
+		Order("courses.id").Pluck("courses.id", &ids).Error
+	if err != nil || !reflect.DeepEqual(ids, want) {
+		t.Errorf("AvailableCourses 返回 %v（%v），期望 %v", ids, err, want)
+	}
+
+	// 课程表以别名JOIN进来，软删除的课程同样被排除
+	ids = nil
+	err = db.Table(models.TableAs(db, "chapters")).
+		Joins("JOIN "+models.Table(db, "courses")+" c ON c.id = chapters.course_id").
+		Scopes(services.AvailableCoursesIn("c")).
+		Order("c.id").Pluck("c.id", &ids).Error
+	if err != nil || !reflect.DeepEqual(ids, want) {
+		t.Errorf("AvailableCoursesIn 返回 %v（%v），期望 %v", ids, err, want)
+	}
+}
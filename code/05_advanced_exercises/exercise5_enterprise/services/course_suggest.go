@@ -0,0 +1,207 @@
+package services
+
+import (
+	"log"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+
+	"edu-platform/models"
+)
+
+const (
+	defaultSuggestLimit   = 10              // 默认返回的建议数量
+	maxSuggestQueryLength = 50              // 查询词最大长度（字符）
+	suggestRefreshAfter   = 5 * time.Minute // 索引超过该时间后在下次查询时后台重建
+)
+
+// CourseSuggestion 课程搜索建议
+type CourseSuggestion struct {
+	ID           uint   `json:"id"`
+	Title        string `json:"title"`
+	Category     string `json:"category"`
+	StudentCount int    `json:"-"` // 排序依据
+}
+
+// SuggestResult 搜索建议结果
+type SuggestResult struct {
+	Courses    []CourseSuggestion `json:"courses"`
+	Categories []string           `json:"categories"`
+}
+
+// suggestEntry 索引项，key为小写的课程标题或slug
+type suggestEntry struct {
+	key    string
+	course *CourseSuggestion
+}
+
+// suggestIndex 建好后只读的索引快照
+type suggestIndex struct {
+	entries    []suggestEntry // 按key排序，用于前缀二分查找
+	categories []string       // 启用的分类名
+	builtAt    time.Time
+}
+
+// CourseSuggestIndex 课程搜索建议的内存索引
+// 只保存已发布课程的id、标题、分类名和学生数；重建时先构建新索引再整体替换，
+// 查询不会看到构建了一半的索引
+type CourseSuggestIndex struct {
+	db         *gorm.DB
+	current    atomic.Pointer[suggestIndex]
+	rebuilding atomic.Bool
+}
+
+// NewCourseSuggestIndex 创建课程搜索建议索引，需调用Rebuild完成首次构建
+func NewCourseSuggestIndex(db *gorm.DB) *CourseSuggestIndex {
+	idx := &CourseSuggestIndex{db: db}
+	idx.current.Store(&suggestIndex{builtAt: time.Now()})
+	return idx
+}
+
+// Rebuild 从数据库重建索引
+func (idx *CourseSuggestIndex) Rebuild() error {
+	rows, err := idx.db.Table(models.TableAs(idx.db, "courses")).
+		Select("courses.id, courses.title, courses.slug, courses.student_count, categories.name").
+		Joins("LEFT JOIN " + models.TableAs(idx.db, "categories") + " ON categories.id = courses.category_id").
+		Scopes(AvailableCoursesIn("courses")).
+		Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	next := &suggestIndex{builtAt: time.Now()}
+	for rows.Next() {
+		var slug string
+		var category *string
+		course := &CourseSuggestion{}
+		if err := rows.Scan(&course.ID, &course.Title, &slug, &course.StudentCount, &category); err != nil {
+			return err
+		}
+		if category != nil {
+			course.Category = *category
+		}
+
+		next.entries = append(next.entries, suggestEntry{key: strings.ToLower(course.Title), course: course})
+		if slug != "" {
+			next.entries = append(next.entries, suggestEntry{key: strings.ToLower(slug), course: course})
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	sort.Slice(next.entries, func(i, j int) bool {
+		return next.entries[i].key < next.entries[j].key
+	})
+
+	if err := idx.db.Table(models.Table(idx.db, "categories")).
+		Where("status = ? AND deleted_at IS NULL", 1).
+		Order("sort ASC, id ASC").
+		Pluck("name", &next.categories).Error; err != nil {
+		return err
+	}
+
+	idx.current.Store(next)
+	return nil
+}
+
+// Suggest 查询搜索建议：先按前缀匹配，不足limit条时再按包含匹配补足，
+// 两组结果各自按学生数倒序
+func (idx *CourseSuggestIndex) Suggest(q string, limit int) SuggestResult {
+	if limit <= 0 {
+		limit = defaultSuggestLimit
+	}
+
+	current := idx.current.Load()
+	if time.Since(current.builtAt) > suggestRefreshAfter {
+		idx.refreshAsync()
+	}
+
+	q = normalizeSuggestQuery(q)
+	result := SuggestResult{Courses: []CourseSuggestion{}, Categories: []string{}}
+	if q == "" {
+		return result
+	}
+
+	seen := make(map[uint]bool)
+
+	// 前缀匹配：二分查找第一个>=q的key，向后扫描直到前缀不匹配
+	var prefix []CourseSuggestion
+	start := sort.Search(len(current.entries), func(i int) bool {
+		return current.entries[i].key >= q
+	})
+	for i := start; i < len(current.entries) && strings.HasPrefix(current.entries[i].key, q); i++ {
+		course := current.entries[i].course
+		if !seen[course.ID] {
+			seen[course.ID] = true
+			prefix = append(prefix, *course)
+		}
+	}
+	rankSuggestions(prefix)
+	result.Courses = appendSuggestions(result.Courses, prefix, limit)
+
+	// 包含匹配兜底
+	if len(result.Courses) < limit {
+		var contains []CourseSuggestion
+		for _, entry := range current.entries {
+			if !seen[entry.course.ID] && strings.Contains(entry.key, q) {
+				seen[entry.course.ID] = true
+				contains = append(contains, *entry.course)
+			}
+		}
+		rankSuggestions(contains)
+		result.Courses = appendSuggestions(result.Courses, contains, limit)
+	}
+
+	for _, name := range current.categories {
+		if strings.HasPrefix(strings.ToLower(name), q) {
+			result.Categories = append(result.Categories, name)
+		}
+	}
+	return result
+}
+
+// refreshAsync 在后台重建索引，同一时间只有一个重建任务
+func (idx *CourseSuggestIndex) refreshAsync() {
+	if !idx.rebuilding.CompareAndSwap(false, true) {
+		return
+	}
+	go func() {
+		defer idx.rebuilding.Store(false)
+		if err := idx.Rebuild(); err != nil {
+			log.Printf("重建课程搜索建议索引失败: %v", err)
+		}
+	}()
+}
+
+// normalizeSuggestQuery 去除空白、转小写并限制长度
+func normalizeSuggestQuery(q string) string {
+	q = strings.ToLower(strings.TrimSpace(q))
+	if runes := []rune(q); len(runes) > maxSuggestQueryLength {
+		q = string(runes[:maxSuggestQueryLength])
+	}
+	return q
+}
+
+// rankSuggestions 按学生数倒序，学生数相同时按ID正序，保证结果稳定
+func rankSuggestions(courses []CourseSuggestion) {
+	sort.Slice(courses, func(i, j int) bool {
+		if courses[i].StudentCount != courses[j].StudentCount {
+			return courses[i].StudentCount > courses[j].StudentCount
+		}
+		return courses[i].ID < courses[j].ID
+	})
+}
+
+func appendSuggestions(dst, src []CourseSuggestion, limit int) []CourseSuggestion {
+	for _, course := range src {
+		if len(dst) >= limit {
+			break
+		}
+		dst = append(dst, course)
+	}
+	return dst
+}
@@ -0,0 +1,142 @@
+package services_test
+
+import (
+	"reflect"
+	"testing"
+
+	"gorm.io/gorm"
+
+	"edu-platform/models"
+	"edu-platform/services"
+	"edu-platform/testhelpers"
+	"edu-platform/testhelpers/factory"
+)
+
+// TestCourseSuggestRanking 标题或slug前缀匹配的课程在前，不足时用包含匹配补足，两组各自按学生数倒序；
+// 分类名按前缀匹配
+func TestCourseSuggestRanking(t *testing.T) {
+	t.Parallel()
+	db := testhelpers.NewDB(t)
+	f := factory.New(t, db)
+
+	intro := suggestCourse(t, f, db, "Go 入门", "intro-1", 10)
+	advanced := suggestCourse(t, f, db, "Go 进阶", "advanced-1", 30)
+	concurrency := suggestCourse(t, f, db, "并发编程", "golang-concurrency", 20)
+	learn := suggestCourse(t, f, db, "跟我学Go", "learn-1", 100)
+	suggestCourse(t, f, db, "React 入门", "react-1", 50)
+	draft := suggestCourse(t, f, db, "Go 草稿", "draft-1", 200)
+	if err := db.Model(draft).Update("status", models.CourseStatusDraft).Error; err != nil {
+		t.Fatalf("修改课程状态失败: %v", err)
+	}
+	if err := db.Model(&models.Category{}).Where("id = ?", intro.CategoryID).Update("name", "Go开发").Error; err != nil {
+		t.Fatalf("修改分类名称失败: %v", err)
+	}
+
+	idx := services.NewCourseSuggestIndex(db)
+	if err := idx.Rebuild(); err != nil {
+		t.Fatalf("构建索引失败: %v", err)
+	}
+
+	cases := []struct {
+		q          string
+		limit      int
+		want       []uint
+		categories []string
+	}{
+		// 前缀（含slug前缀）按学生数倒序，再补上包含匹配的课程；草稿课程不出现
+		{"go", 10, []uint{advanced.ID, concurrency.ID, intro.ID, learn.ID}, []string{"Go开发"}},
+		{"  GO ", 10, []uint{advanced.ID, concurrency.ID, intro.ID, learn.ID}, []string{"Go开发"}},
+		// 前缀匹配已够limit条时不补包含匹配
+		{"go", 2, []uint{advanced.ID, concurrency.ID}, []string{"Go开发"}},
+		{"go 入", 10, []uint{intro.ID}, []string{}},
+		{"golang", 10, []uint{concurrency.ID}, []string{}},
+		{"学go", 10, []uint{learn.ID}, []string{}},
+		{"python", 10, nil, []string{}},
+		{"", 10, nil, []string{}},
+	}
+	for _, tc := range cases {
+		result := idx.Suggest(tc.q, tc.limit)
+		if got := suggestionIDs(result.Courses); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%q limit=%d: 课程为 %v，期望 %v", tc.q, tc.limit, got, tc.want)
+		}
+		if !reflect.DeepEqual(result.Categories, tc.categories) {
+			t.Errorf("%q: 分类为 %v，期望 %v", tc.q, result.Categories, tc.categories)
+		}
+	}
+}
+
+// TestCourseSuggestRebuildOnChange 通过 CourseService 修改标题、下架和重新发布课程后，搜索建议随之更新；
+// 直接改数据库不会影响已建好的索引，直到下次重建
+func TestCourseSuggestRebuildOnChange(t *testing.T) {
+	t.Parallel()
+	db := testhelpers.NewDB(t)
+	f := factory.New(t, db)
+
+	course := suggestCourse(t, f, db, "Go 入门", "go-intro", 10)
+	idx := services.NewCourseSuggestIndex(db)
+	if err := idx.Rebuild(); err != nil {
+		t.Fatalf("构建索引失败: %v", err)
+	}
+	courses := services.NewCourseService(db, idx)
+
+	assertSuggested(t, idx, "go", course.ID, true)
+
+	if err := courses.UpdateCourse(course.ID, map[string]interface{}{"title": "Rust 入门"}, false); err != nil {
+		t.Fatalf("修改课程标题失败: %v", err)
+	}
+	assertSuggested(t, idx, "rust", course.ID, true)
+	// 标题不再匹配，但slug仍以go开头
+	assertSuggested(t, idx, "go 入门", course.ID, false)
+	assertSuggested(t, idx, "go-intro", course.ID, true)
+
+	if err := courses.UnpublishCourse(course.ID); err != nil {
+		t.Fatalf("下架课程失败: %v", err)
+	}
+	assertSuggested(t, idx, "rust", course.ID, false)
+
+	if err := courses.PublishCourse(course.ID); err != nil {
+		t.Fatalf("发布课程失败: %v", err)
+	}
+	assertSuggested(t, idx, "rust", course.ID, true)
+
+	// 绕过服务直接修改的数据，重建前查询仍使用旧索引
+	if err := db.Model(course).Update("title", "Vue 入门").Error; err != nil {
+		t.Fatalf("修改课程标题失败: %v", err)
+	}
+	assertSuggested(t, idx, "vue", course.ID, false)
+	if err := idx.Rebuild(); err != nil {
+		t.Fatalf("重建索引失败: %v", err)
+	}
+	assertSuggested(t, idx, "vue", course.ID, true)
+}
+
+// suggestCourse 创建指定标题、slug和学生数的已发布课程
+func suggestCourse(t *testing.T, f *factory.Factory, db *gorm.DB, title, slug string, students int) *models.Course {
+	t.Helper()
+	course := f.Course(9900)
+	if err := db.Model(course).Updates(map[string]interface{}{
+		"title": title, "slug": slug, "student_count": students,
+	}).Error; err != nil {
+		t.Fatalf("修改课程失败: %v", err)
+	}
+	return course
+}
+
+func assertSuggested(t *testing.T, idx *services.CourseSuggestIndex, q string, courseID uint, want bool) {
+	t.Helper()
+	found := false
+	for _, id := range suggestionIDs(idx.Suggest(q, 10).Courses) {
+		found = found || id == courseID
+	}
+	if found != want {
+		t.Errorf("%q 的建议中包含课程 %d: %v，期望 %v", q, courseID, found, want)
+	}
+}
+
+func suggestionIDs(courses []services.CourseSuggestion) []uint {
+	var ids []uint
+	for _, course := range courses {
+		ids = append(ids, course.ID)
+	}
+	return ids
+}
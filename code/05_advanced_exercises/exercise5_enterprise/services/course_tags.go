@@ -0,0 +1,236 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"edu-platform/models"
+)
+
+const (
+	maxCourseTags    = 10 // 一门课程最多的标签数
+	maxTagNameLength = 50 // 标签名最大长度（字符）
+)
+
+// SetTags 设置课程的标签（覆盖原有设置），传空列表表示清除全部标签，返回设置后的标签（按传入顺序），只有课程讲师可以设置
+// 标签名按规范化后的名称（去首尾空白、合并空白、小写）查找或创建，大小写不同的写法是同一个标签
+func (s *CourseService) SetTags(courseID, userID uint, tagNames []string) ([]models.Tag, error) {
+	var tags []models.Tag
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if _, err := ownedCourse(tx, courseID, userID); err != nil {
+			return err
+		}
+		var err error
+		tags, err = ReplaceCourseTags(tx, courseID, tagNames)
+		return err
+	})
+	return tags, err
+}
+
+// ReplaceCourseTags 在事务中替换课程的标签，并重新统计替换前后涉及的标签的课程数
+// 锁住课程行，同一课程的并发设置依次执行；课程数按 course_tags 重新计算，而不是增减，替换多少次都不会偏差
+func ReplaceCourseTags(tx *gorm.DB, courseID uint, tagNames []string) ([]models.Tag, error) {
+	names, normalized, err := normalizeTagNames(tagNames)
+	if err != nil {
+		return nil, err
+	}
+
+	var course models.Course
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&course, courseID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound.WithMsg("course.not_found")
+		}
+		return nil, err
+	}
+
+	var affected []uint
+	if err := tx.Table(models.Table(tx, "course_tags")).Where("course_id = ?", courseID).
+		Pluck("tag_id", &affected).Error; err != nil {
+		return nil, err
+	}
+
+	tags, err := findOrCreateTags(tx, names, normalized)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Model(&course).Association("Tags").Replace(tags); err != nil {
+		return nil, err
+	}
+
+	for _, tag := range tags {
+		affected = append(affected, tag.ID)
+	}
+	if err := recountTagUsage(tx, affected); err != nil {
+		return nil, err
+	}
+	if len(tags) > 0 {
+		// 返回重新统计后的课程数
+		var counted []models.Tag
+		if err := tx.Select("id", "usage_count").Where("id IN ?", affected).Find(&counted).Error; err != nil {
+			return nil, err
+		}
+		usage := make(map[uint]int, len(counted))
+		for _, tag := range counted {
+			usage[tag.ID] = tag.UsageCount
+		}
+		for i := range tags {
+			tags[i].UsageCount = usage[tags[i].ID]
+		}
+	}
+	return tags, nil
+}
+
+// normalizeTagNames 校验并去重标签名，返回展示名（保留首次出现的写法）和对应的规范化名称，顺序与传入一致
+func normalizeTagNames(tagNames []string) ([]string, []string, error) {
+	names := make([]string, 0, len(tagNames))
+	normalized := make([]string, 0, len(tagNames))
+	seen := make(map[string]bool)
+	for _, raw := range tagNames {
+		name := strings.Join(strings.Fields(raw), " ")
+		if name == "" || utf8.RuneCountInString(name) > maxTagNameLength {
+			return nil, nil, ErrValidation.WithMsg("course.tag_invalid", maxTagNameLength)
+		}
+		key := models.NormalizeTagName(name)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		names = append(names, name)
+		normalized = append(normalized, key)
+	}
+	if len(names) > maxCourseTags {
+		return nil, nil, ErrValidation.WithMsg("course.too_many_tags", maxCourseTags)
+	}
+	return names, normalized, nil
+}
+
+// findOrCreateTags 按规范化名称查找标签，不存在的创建，返回的标签顺序与传入一致
+// 创建时唯一索引冲突则忽略（并发请求已创建），之后重新查询，不会因并发创建同名标签而失败
+func findOrCreateTags(tx *gorm.DB, names, normalized []string) ([]models.Tag, error) {
+	if len(normalized) == 0 {
+		return []models.Tag{}, nil
+	}
+
+	var existing []models.Tag
+	if err := tx.Where("normalized_name IN ?", normalized).Find(&existing).Error; err != nil {
+		return nil, err
+	}
+	if len(existing) < len(normalized) {
+		found := make(map[string]bool, len(existing))
+		for _, tag := range existing {
+			found[tag.NormalizedName] = true
+		}
+		var missing []models.Tag
+		for i, key := range normalized {
+			if !found[key] {
+				missing = append(missing, models.Tag{Name: names[i], NormalizedName: key})
+			}
+		}
+		if err := Upsert(tx, &missing, []string{"normalized_name"}, nil); err != nil {
+			return nil, err
+		}
+		existing = nil
+		if err := tx.Where("normalized_name IN ?", normalized).Find(&existing).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	byName := make(map[string]models.Tag, len(existing))
+	for _, tag := range existing {
+		byName[tag.NormalizedName] = tag
+	}
+	tags := make([]models.Tag, 0, len(normalized))
+	for _, key := range normalized {
+		tags = append(tags, byName[key])
+	}
+	return tags, nil
+}
+
+// recountTagUsage 按 course_tags 重新计算标签的课程数，计数和写入在同一条UPDATE中完成
+func recountTagUsage(tx *gorm.DB, tagIDs []uint) error {
+	if len(tagIDs) == 0 {
+		return nil
+	}
+	usage := tx.Session(&gorm.Session{NewDB: true}).Table(models.Table(tx, "course_tags")).
+		Select("COUNT(*)").Where("tag_id = " + models.Table(tx, "tags") + ".id")
+	return tx.Model(&models.Tag{}).Where("id IN ?", tagIDs).UpdateColumn("usage_count", usage).Error
+}
+
+// filterTagNames 规范化并去重筛选用的标签名，忽略空值
+func filterTagNames(tagNames []string) []string {
+	normalized := make([]string, 0, len(tagNames))
+	seen := make(map[string]bool)
+	for _, raw := range tagNames {
+		key := models.NormalizeTagName(raw)
+		if key != "" && !seen[key] {
+			seen[key] = true
+			normalized = append(normalized, key)
+		}
+	}
+	return normalized
+}
+
+// coursesWithTags 带有指定标签的课程ID子查询
+// all为true时课程须带有全部标签（AND）：JOIN标签后按课程分组，HAVING COUNT(DISTINCT 标签) 等于标签数；
+// 为false时带有任一标签即可（OR）。不存在的标签名在AND下使结果为空，在OR下被忽略
+func coursesWithTags(db *gorm.DB, normalized []string, all bool) *gorm.DB {
+	sub := db.Session(&gorm.Session{NewDB: true}).Table(models.TableAs(db, "course_tags")).
+		Select("course_tags.course_id").
+		Joins("JOIN "+models.TableAs(db, "tags")+" ON tags.id = course_tags.tag_id").
+		Where("tags.normalized_name IN ?", normalized)
+	if all {
+		sub = sub.Group("course_tags.course_id").Having("COUNT(DISTINCT tags.id) = ?", len(normalized))
+	}
+	return sub
+}
+
+// BackfillCourseTags 把改用标签表之前 courses.tags 列中逗号分隔的标签迁移到 course_tags
+// 在 AutoMigrate 之后执行，只处理还没有标签的课程，可重复执行；标签不合法（过长、过多）的课程记录日志后跳过
+// 返回迁移的课程数
+func BackfillCourseTags(db *gorm.DB) (int64, error) {
+	if !db.Migrator().HasColumn(&models.Course{}, "tags") {
+		return 0, nil
+	}
+
+	courses, courseTags := models.Table(db, "courses"), models.Table(db, "course_tags")
+	var rows []struct {
+		ID   uint
+		Tags string
+	}
+	err := db.Table(courses).Select("id, tags").
+		Where("tags IS NOT NULL AND tags <> '' AND deleted_at IS NULL").
+		Where("NOT EXISTS (SELECT 1 FROM " + courseTags + " ct WHERE ct.course_id = " + courses + ".id)").
+		Order("id").Scan(&rows).Error
+	if err != nil {
+		return 0, err
+	}
+
+	var total int64
+	for _, row := range rows {
+		var names []string
+		for _, name := range strings.Split(row.Tags, ",") {
+			if strings.TrimSpace(name) != "" {
+				names = append(names, name)
+			}
+		}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			_, err := ReplaceCourseTags(tx, row.ID, names)
+			return err
+		})
+		var appErr *AppError
+		if errors.As(err, &appErr) {
+			log.Printf("迁移课程标签失败，已跳过: course=%d tags=%q: %v", row.ID, row.Tags, err)
+			continue
+		}
+		if err != nil {
+			return total, err
+		}
+		total++
+	}
+	return total, nil
+}
@@ -0,0 +1,63 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"edu-platform/models"
+)
+
+const (
+	recentlyViewedKeep         = 50 // 每个用户保留的浏览记录数
+	defaultRecentlyViewedLimit = 10
+)
+
+// RecordView 记录用户浏览课程，同一门课程只保留一条并更新为最近的浏览时间，超出保留数的旧记录随即删除
+func (s *CourseService) RecordView(db *gorm.DB, userID, courseID uint) error {
+	view := models.CourseView{UserID: userID, CourseID: courseID, ViewedAt: time.Now()}
+	err := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "course_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"viewed_at"}),
+	}).Create(&view).Error
+	if err != nil {
+		return err
+	}
+
+	// 找到第 recentlyViewedKeep+1 新的记录，它及更早的记录都删除
+	// MySQL不支持 IN 子查询中使用LIMIT，所以先查出分界再删除
+	var boundary models.CourseView
+	err = db.Where("user_id = ?", userID).Order("viewed_at DESC, id DESC").
+		Offset(recentlyViewedKeep).Limit(1).Take(&boundary).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return db.Where("user_id = ? AND (viewed_at < ? OR (viewed_at = ? AND id <= ?))",
+		userID, boundary.ViewedAt, boundary.ViewedAt, boundary.ID).
+		Delete(&models.CourseView{}).Error
+}
+
+// GetRecentlyViewed 用户最近浏览的课程，按浏览时间倒序，只返回仍在发布中的课程
+func (s *CourseService) GetRecentlyViewed(userID uint, limit int) ([]models.Course, error) {
+	if limit <= 0 {
+		limit = defaultRecentlyViewedLimit
+	}
+	if limit > recentlyViewedKeep {
+		limit = recentlyViewedKeep
+	}
+
+	var courses []models.Course
+	err := s.db.Table(models.TableAs(s.db, "courses")).
+		Joins("JOIN "+models.TableAs(s.db, "course_views")+" ON course_views.course_id = courses.id").
+		Scopes(AvailableCoursesIn("courses")).
+		Where("course_views.user_id = ?", userID).
+		Order("course_views.viewed_at DESC").Order("course_views.id DESC").
+		Limit(limit).Preload("Category").Preload("Instructor").
+		Find(&courses).Error
+	return courses, err
+}
@@ -0,0 +1,74 @@
+package services
+
+import (
+	"gorm.io/gorm"
+
+	"edu-platform/models"
+)
+
+// 关联记录缺失时的处理方式
+// 外键指向的记录被物理删除或软删除后，预加载得到零值结构体，直接返回会出现 id 为0的空对象
+const (
+	DanglingOmit        = "omit"        // 不返回该关联（默认）
+	DanglingPlaceholder = "placeholder" // 返回只有ID和名称的占位记录，名称标明已删除
+)
+
+// settingDanglingMode 关联记录缺失时的处理方式，omit 或 placeholder
+const settingDanglingMode = "association.dangling_mode"
+
+// 占位记录的名称
+const (
+	deletedUserName     = "已注销用户"
+	deletedCourseTitle  = "课程已删除"
+	deletedCategoryName = "分类已删除"
+)
+
+// danglingMode 读取关联记录缺失时的处理方式，未配置或配置无效时为 DanglingOmit
+func danglingMode(db *gorm.DB) string {
+	value, ok, err := NewSettingsService(db).Get(settingDanglingMode)
+	if err != nil || !ok || value != DanglingPlaceholder {
+		return DanglingOmit
+	}
+	return DanglingPlaceholder
+}
+
+// fillCoursePlaceholders 处理方式为 DanglingPlaceholder 时，为已预加载但记录缺失的分类和讲师填入占位记录
+// 没有缺失的关联时不读取设置
+func fillCoursePlaceholders(db *gorm.DB, course *models.Course, includes Includes) {
+	danglingCategory := includes.Has("category") && course.CategoryID != 0 && course.Category.ID == 0
+	danglingInstructor := includes.Has("instructor") && course.InstructorID != 0 && course.Instructor.ID == 0
+	if !danglingCategory && !danglingInstructor || danglingMode(db) != DanglingPlaceholder {
+		return
+	}
+
+	if danglingCategory {
+		course.Category = models.Category{BaseModel: models.BaseModel{ID: course.CategoryID}, Name: deletedCategoryName}
+	}
+	if danglingInstructor {
+		course.Instructor = models.User{
+			BaseModel: models.BaseModel{ID: course.InstructorID},
+			Username:  deletedUserName,
+			Nickname:  deletedUserName,
+		}
+	}
+}
+
+// fillOrderItemPlaceholders 处理方式为 DanglingPlaceholder 时，为已预加载但课程缺失的订单项填入占位课程，
+// 订单项上的快照不受影响；没有缺失的课程时不读取设置
+func fillOrderItemPlaceholders(db *gorm.DB, orders ...*models.Order) {
+	var dangling []*models.OrderItem
+	for _, order := range orders {
+		for i := range order.Items {
+			if order.Items[i].CourseID != 0 && order.Items[i].Course.ID == 0 {
+				dangling = append(dangling, &order.Items[i])
+			}
+		}
+	}
+	if len(dangling) == 0 || danglingMode(db) != DanglingPlaceholder {
+		return
+	}
+
+	for _, item := range dangling {
+		item.Course = models.Course{BaseModel: models.BaseModel{ID: item.CourseID}, Title: deletedCourseTitle}
+	}
+}
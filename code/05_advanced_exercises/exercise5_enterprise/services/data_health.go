@@ -0,0 +1,194 @@
+package services
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+
+	"edu-platform/models"
+)
+
+// maxDanglingRows 外键检查最多返回的缺失行数，总数不受限制
+const maxDanglingRows = 100
+
+// TableHealth 数据表的行数统计
+type TableHealth struct {
+	Table      string `json:"table"`
+	SoftDelete bool   `json:"soft_delete"` // 是否有软删除字段，没有时Deleted始终为0
+	Active     int64  `json:"active"`
+	Deleted    int64  `json:"deleted"`
+	Total      int64  `json:"total"`
+}
+
+// DataHealthService 数据健康检查服务
+type DataHealthService struct {
+	db *gorm.DB
+}
+
+// NewDataHealthService 创建数据健康检查服务
+func NewDataHealthService(db *gorm.DB) *DataHealthService {
+	return &DataHealthService{db: db}
+}
+
+// TableCounts 按迁移顺序统计每张表的未删除行数和软删除行数
+// 同一张表的三个计数在一个事务中查询，并发写入时同样满足 Active + Deleted = Total（取决于数据库的隔离级别，MySQL默认的可重复读满足）
+func (s *DataHealthService) TableCounts(ctx context.Context) ([]TableHealth, error) {
+	db := s.db.WithContext(ctx)
+	all := models.All()
+	result := make([]TableHealth, 0, len(all))
+	for _, model := range all {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(model); err != nil {
+			return nil, err
+		}
+		field, err := softDeleteField(db, model)
+		if err != nil {
+			return nil, err
+		}
+
+		health := TableHealth{Table: stmt.Schema.Table, SoftDelete: field != nil}
+		err = db.Transaction(func(tx *gorm.DB) error {
+			var err error
+			if health.Total, err = CountWithDeleted(tx, model); err != nil {
+				return err
+			}
+			if health.Active, err = CountActive(tx, model); err != nil {
+				return err
+			}
+			health.Deleted, err = CountDeleted(tx, model)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, health)
+	}
+	return result, nil
+}
+
+// DanglingReference 外键指向的记录缺失的行
+type DanglingReference struct {
+	ID         string `json:"id"`          // 行主键
+	ForeignKey string `json:"foreign_key"` // 外键值
+	Deleted    bool   `json:"deleted"`     // 被引用的记录仍在但已软删除；为false时已被物理删除
+}
+
+// DanglingReport 一个外键的检查结果
+type DanglingReport struct {
+	Table       string              `json:"table"`
+	ForeignKey  string              `json:"foreign_key"`
+	References  string              `json:"references"`   // 被引用的表
+	Total       int64               `json:"total"`        // 外键指向的记录缺失的行数（含软删除）
+	SoftDeleted int64               `json:"soft_deleted"` // 其中被引用记录已软删除的行数
+	Rows        []DanglingReference `json:"rows"`         // 按主键排序的前 maxDanglingRows 行
+}
+
+// CheckDanglingReferencesByTable 按表名检查外键，表名可带或不带前缀，不是迁移的模型时返回 ErrValidation
+func (s *DataHealthService) CheckDanglingReferencesByTable(ctx context.Context, table, fk string) (*DanglingReport, error) {
+	prefixed := models.Table(s.db, table)
+	for _, model := range models.All() {
+		stmt := &gorm.Statement{DB: s.db}
+		if err := stmt.Parse(model); err != nil {
+			return nil, err
+		}
+		if stmt.Schema.Table == table || stmt.Schema.Table == prefixed {
+			return s.CheckDanglingReferences(ctx, model, fk)
+		}
+	}
+	return nil, ErrValidation.WithMsg("data_health.unknown_table", table)
+}
+
+// CheckDanglingReferences 查找外键指向的记录已不存在（物理删除）或已软删除的行，已软删除的行本身不检查
+// fk为模型上 belongs-to 关联的外键，字段名或列名均可，如 CourseID、course_id；外键为空或0的行视为没有关联
+func (s *DataHealthService) CheckDanglingReferences(ctx context.Context, model interface{}, fk string) (*DanglingReport, error) {
+	db := s.db.WithContext(ctx)
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(model); err != nil {
+		return nil, err
+	}
+	rel := belongsToByForeignKey(stmt.Schema, fk)
+	if rel == nil {
+		return nil, ErrValidation.WithMsg("data_health.unknown_foreign_key", stmt.Schema.Table, fk)
+	}
+	foreignKey, primaryKey := rel.References[0].ForeignKey, rel.References[0].PrimaryKey
+	pk := stmt.Schema.PrioritizedPrimaryField
+	if pk == nil {
+		return nil, ErrValidation.WithMsg("data_health.unknown_foreign_key", stmt.Schema.Table, fk)
+	}
+
+	// t为检查的表，r为被引用的表；被引用的表没有软删除字段时只查物理删除
+	deleted := "0"
+	missing := "r." + primaryKey.DBName + " IS NULL"
+	if field := schemaSoftDeleteField(rel.FieldSchema); field != nil {
+		deleted = "CASE WHEN r." + primaryKey.DBName + " IS NULL THEN 0 ELSE 1 END"
+		missing = "(" + missing + " OR r." + field.DBName + " IS NOT NULL)"
+	}
+	query := db.Table(stmt.Schema.Table + " t").
+		Joins("LEFT JOIN " + rel.FieldSchema.Table + " r ON r." + primaryKey.DBName + " = t." + foreignKey.DBName).
+		Where("t." + foreignKey.DBName + " IS NOT NULL").
+		Where(missing)
+	if foreignKey.DataType == schema.Uint || foreignKey.DataType == schema.Int {
+		query = query.Where("t." + foreignKey.DBName + " <> 0")
+	} else {
+		query = query.Where("t." + foreignKey.DBName + " <> ''")
+	}
+	if field := schemaSoftDeleteField(stmt.Schema); field != nil {
+		query = query.Where("t." + field.DBName + " IS NULL")
+	}
+
+	report := &DanglingReport{
+		Table:      stmt.Schema.Table,
+		ForeignKey: foreignKey.DBName,
+		References: rel.FieldSchema.Table,
+		Rows:       []DanglingReference{},
+	}
+	var counts struct {
+		Total       int64
+		SoftDeleted *int64
+	}
+	if err := query.Session(&gorm.Session{}).
+		Select("COUNT(*) AS total, SUM(" + deleted + ") AS soft_deleted").
+		Scan(&counts).Error; err != nil {
+		return nil, err
+	}
+	report.Total = counts.Total
+	if counts.SoftDeleted != nil {
+		report.SoftDeleted = *counts.SoftDeleted
+	}
+	if report.Total == 0 {
+		return report, nil
+	}
+
+	err := query.Select("t." + pk.DBName + " AS id, t." + foreignKey.DBName + " AS foreign_key, " + deleted + " AS deleted").
+		Order("t." + pk.DBName).Limit(maxDanglingRows).
+		Scan(&report.Rows).Error
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// belongsToByForeignKey 查找外键为fk（字段名或列名）的 belongs-to 关联，只支持单列外键
+func belongsToByForeignKey(sch *schema.Schema, fk string) *schema.Relationship {
+	for _, rel := range sch.Relationships.BelongsTo {
+		if len(rel.References) != 1 {
+			continue
+		}
+		field := rel.References[0].ForeignKey
+		if field.Name == fk || field.DBName == fk {
+			return rel
+		}
+	}
+	return nil
+}
+
+// schemaSoftDeleteField 已解析模型的软删除字段，没有时返回nil
+func schemaSoftDeleteField(sch *schema.Schema) *schema.Field {
+	for _, field := range sch.Fields {
+		if field.FieldType == deletedAtType && field.DBName != "" {
+			return field
+		}
+	}
+	return nil
+}
@@ -0,0 +1,189 @@
+package services
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+
+	"edu-platform/models"
+)
+
+// DiscussionService 课程讨论服务
+// 只有已选课的学员和课程讲师可以发帖和回复
+type DiscussionService struct {
+	db *gorm.DB
+}
+
+// NewDiscussionService 创建课程讨论服务
+func NewDiscussionService(db *gorm.DB) *DiscussionService {
+	return &DiscussionService{db: db}
+}
+
+// CreateThread 在课程下发布讨论主题
+func (s *DiscussionService) CreateThread(courseID, userID uint, title, body string) (*models.CourseThread, error) {
+	if _, err := s.authorize(courseID, userID); err != nil {
+		return nil, err
+	}
+
+	thread := models.CourseThread{
+		CourseID: courseID,
+		AuthorID: userID,
+		Title:    title,
+		Body:     body,
+		Status:   1, // 待解决
+	}
+	if err := s.db.Create(&thread).Error; err != nil {
+		return nil, err
+	}
+	return &thread, nil
+}
+
+// Reply 回复讨论主题
+// 讲师的回复自动标记为讲师回答，讲师可以同时将主题标记为已解决
+func (s *DiscussionService) Reply(courseID, threadID, userID uint, body string, resolve bool) (*models.ThreadReply, error) {
+	thread, err := s.getThread(courseID, threadID)
+	if err != nil {
+		return nil, err
+	}
+
+	isInstructor, err := s.authorize(courseID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if resolve && !isInstructor {
+		return nil, ErrForbidden.WithMsg("discussion.resolve_forbidden")
+	}
+
+	reply := models.ThreadReply{
+		ThreadID:           thread.ID,
+		AuthorID:           userID,
+		Body:               body,
+		IsInstructorAnswer: isInstructor,
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		// 回复数量由ThreadReply的AfterCreate钩子维护
+		if err := tx.Create(&reply).Error; err != nil {
+			return err
+		}
+		if resolve {
+			return tx.Model(thread).Update("status", 2).Error // 已解决
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &reply, nil
+}
+
+// DeleteReply 删除回复，回复作者或课程讲师可以删除
+func (s *DiscussionService) DeleteReply(courseID, threadID, replyID, userID uint) error {
+	thread, err := s.getThread(courseID, threadID)
+	if err != nil {
+		return err
+	}
+
+	var reply models.ThreadReply
+	if err := s.db.Where("id = ? AND thread_id = ?", replyID, thread.ID).First(&reply).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrNotFound.WithMsg("discussion.reply_not_found")
+		}
+		return err
+	}
+
+	if reply.AuthorID != userID {
+		var course models.Course
+		if err := s.db.Select("id", "instructor_id").First(&course, courseID).Error; err != nil {
+			return err
+		}
+		if course.InstructorID != userID {
+			return ErrForbidden.WithMsg("discussion.delete_forbidden")
+		}
+	}
+
+	// 传入已加载的回复，AfterDelete钩子据此减少回复数量
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		return tx.Delete(&reply).Error
+	})
+}
+
+// GetThreads 分页获取课程的讨论主题，待解决的排在前面，其次按最近活跃时间倒序
+func (s *DiscussionService) GetThreads(courseID uint, page, pageSize int) ([]models.CourseThread, int64, error) {
+	var threads []models.CourseThread
+	var total int64
+
+	query := s.db.Model(&models.CourseThread{}).Where("course_id = ?", courseID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	err := query.Preload("Author").
+		Order("status ASC").
+		Order("COALESCE(last_reply_at, created_at) DESC").
+		Order("id DESC").
+		Offset(offset).Limit(pageSize).
+		Find(&threads).Error
+
+	return threads, total, err
+}
+
+// GetReplies 分页获取主题的回复，按时间正序
+func (s *DiscussionService) GetReplies(courseID, threadID uint, page, pageSize int) ([]models.ThreadReply, int64, error) {
+	thread, err := s.getThread(courseID, threadID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var replies []models.ThreadReply
+	var total int64
+
+	query := s.db.Model(&models.ThreadReply{}).Where("thread_id = ?", thread.ID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	err = query.Preload("Author").Order("id ASC").
+		Offset(offset).Limit(pageSize).
+		Find(&replies).Error
+
+	return replies, total, err
+}
+
+// authorize 检查用户能否参与课程讨论，返回用户是否为课程讲师
+func (s *DiscussionService) authorize(courseID, userID uint) (bool, error) {
+	var course models.Course
+	if err := s.db.Select("id", "instructor_id").First(&course, courseID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, ErrNotFound.WithMsg("course.not_found")
+		}
+		return false, err
+	}
+	if course.InstructorID == userID {
+		return true, nil
+	}
+
+	enrolled, err := isEnrolled(s.db, userID, courseID)
+	if err != nil {
+		return false, err
+	}
+	if !enrolled {
+		return false, ErrForbidden.WithMsg("discussion.forbidden")
+	}
+	return false, nil
+}
+
+// getThread 获取课程下的讨论主题
+func (s *DiscussionService) getThread(courseID, threadID uint) (*models.CourseThread, error) {
+	var thread models.CourseThread
+	err := s.db.Where("id = ? AND course_id = ?", threadID, courseID).First(&thread).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound.WithMsg("discussion.thread_not_found")
+		}
+		return nil, err
+	}
+	return &thread, nil
+}
@@ -0,0 +1,410 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"text/template"
+	"time"
+
+	"gorm.io/gorm"
+
+	"edu-platform/models"
+)
+
+// 邮件状态
+const (
+	EmailQueued int8 = 1 // 待发送
+	EmailSent   int8 = 2 // 已发送
+	EmailFailed int8 = 3 // 发送失败（重试次数已用完）
+)
+
+const (
+	emailSendBatchSize = 100 // 发送任务每批读取的邮件数
+	emailMaxAttempts   = 5   // 每封邮件最多发送次数，用完后标记为发送失败
+)
+
+// EmailTemplateKeys 可配置的邮件模板，模板Key与触发它的发件箱事件类型相同
+var EmailTemplateKeys = []string{EventOrderCreated, EventOrderPaid, EventOrderRefunded}
+
+// EmailSender 邮件发送器
+type EmailSender interface {
+	SendEmail(ctx context.Context, to, subject, body string) error
+}
+
+// LogEmailSender 把邮件写入日志，只用于开发环境（接入SMTP之前）
+type LogEmailSender struct{}
+
+// SendEmail 实现 EmailSender
+func (LogEmailSender) SendEmail(ctx context.Context, to, subject, body string) error {
+	log.Printf("[邮件] to=%s subject=%s\n%s", to, subject, body)
+	return nil
+}
+
+// EmailUserData 模板中的用户信息
+type EmailUserData struct {
+	ID       uint
+	Username string
+	Name     string // 昵称，未设置时为用户名
+	Email    string
+}
+
+// EmailOrderItemData 模板中的订单项
+type EmailOrderItemData struct {
+	CourseName string
+	Price      int64
+}
+
+// EmailOrderData 模板中的订单信息，金额单位为分，模板中用 yuan 函数转为元
+type EmailOrderData struct {
+	OrderNo        string
+	Status         string
+	TotalAmount    int64
+	PayAmount      int64
+	DiscountAmount int64
+	RefundAmount   int64
+	CreatedAt      time.Time
+	PaidAt         *time.Time
+	Items          []EmailOrderItemData
+}
+
+// EmailRefundData 模板中的退款信息，只有 order.refunded 事件有值
+type EmailRefundData struct {
+	RefundNo string
+	Amount   int64
+	Reason   string
+}
+
+// EmailContext 渲染邮件模板的数据，模板只能引用这里的字段，如 {{.User.Name}}、{{yuan .Order.PayAmount}}
+type EmailContext struct {
+	User   EmailUserData
+	Order  EmailOrderData
+	Refund EmailRefundData
+}
+
+// emailTemplateFuncs 模板可用的函数
+var emailTemplateFuncs = template.FuncMap{
+	// yuan 金额由分转为元，保留两位小数
+	"yuan": func(cents int64) string {
+		return fmt.Sprintf("%.2f", float64(cents)/100)
+	},
+}
+
+// newEmailContext 由订单生成模板数据，order须预加载User和Items
+func newEmailContext(order *models.Order) EmailContext {
+	data := EmailContext{
+		User: EmailUserData{
+			ID:       order.User.ID,
+			Username: order.User.Username,
+			Name:     order.User.DisplayName(),
+			Email:    order.User.Email,
+		},
+		Order: EmailOrderData{
+			OrderNo:        order.OrderNo,
+			Status:         order.Status.String(),
+			TotalAmount:    order.TotalAmount,
+			PayAmount:      order.PayAmount,
+			DiscountAmount: order.DiscountAmount,
+			RefundAmount:   order.RefundAmount,
+			CreatedAt:      order.CreatedAt,
+			PaidAt:         order.PaidAt,
+			Items:          make([]EmailOrderItemData, len(order.Items)),
+		},
+	}
+	for i, item := range order.Items {
+		data.Order.Items[i] = EmailOrderItemData{CourseName: item.CourseName, Price: item.Price}
+	}
+	return data
+}
+
+// sampleEmailContext 保存模板时试渲染使用的示例数据，所有字段都有值
+func sampleEmailContext() EmailContext {
+	now := time.Now()
+	return EmailContext{
+		User: EmailUserData{ID: 1, Username: "student", Name: "张三", Email: "student@example.com"},
+		Order: EmailOrderData{
+			OrderNo:        "EDU1700000000000000000",
+			Status:         models.OrderStatusPaid.String(),
+			TotalAmount:    29900,
+			PayAmount:      24900,
+			DiscountAmount: 5000,
+			RefundAmount:   9900,
+			CreatedAt:      now,
+			PaidAt:         &now,
+			Items: []EmailOrderItemData{
+				{CourseName: "Go语言入门", Price: 9900},
+				{CourseName: "GORM实战", Price: 20000},
+			},
+		},
+		Refund: EmailRefundData{RefundNo: "RF1700000000000000000", Amount: 9900, Reason: "课程内容与描述不符"},
+	}
+}
+
+// renderEmailTemplate 用data渲染模板，语法错误或引用不存在的字段、函数时返回错误
+func renderEmailTemplate(name, text string, data EmailContext) (string, error) {
+	tmpl, err := template.New(name).Funcs(emailTemplateFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// renderEmail 渲染邮件主题和正文，主题去掉首尾空白
+func renderEmail(tmpl *models.EmailTemplate, data EmailContext) (subject, body string, err error) {
+	if subject, err = renderEmailTemplate("subject", tmpl.SubjectTmpl, data); err != nil {
+		return "", "", err
+	}
+	if body, err = renderEmailTemplate("body", tmpl.BodyTmpl, data); err != nil {
+		return "", "", err
+	}
+	return strings.TrimSpace(subject), body, nil
+}
+
+// EmailService 邮件服务
+// 订单事件由发件箱投递时按模板渲染邮件并写入待发送记录，发送任务再通过 EmailSender 发出
+type EmailService struct {
+	db     *gorm.DB
+	sender EmailSender
+}
+
+// NewEmailService 创建邮件服务，sender为nil时使用 LogEmailSender
+func NewEmailService(db *gorm.DB, sender EmailSender) *EmailService {
+	if sender == nil {
+		sender = LogEmailSender{}
+	}
+	return &EmailService{db: db, sender: sender}
+}
+
+// EmailTemplateInput 保存邮件模板的参数
+type EmailTemplateInput struct {
+	SubjectTmpl string `json:"subject_tmpl" binding:"required,max=255"`
+	BodyTmpl    string `json:"body_tmpl" binding:"required"`
+	Enabled     *bool  `json:"enabled"` // 为nil时新模板启用、已有模板保持不变
+}
+
+// GetTemplates 获取所有邮件模板（管理员）
+func (s *EmailService) GetTemplates() ([]models.EmailTemplate, error) {
+	var templates []models.EmailTemplate
+	err := s.db.Order("id ASC").Find(&templates).Error
+	return templates, err
+}
+
+// SaveTemplate 创建或修改邮件模板（管理员）
+// 保存前用示例数据试渲染，语法错误或引用不存在的字段时返回 ErrValidation，不保存
+func (s *EmailService) SaveTemplate(key string, input EmailTemplateInput) (*models.EmailTemplate, error) {
+	if !containsString(EmailTemplateKeys, key) {
+		return nil, ErrValidation.WithMsg("email.unknown_template", key)
+	}
+
+	tmpl := models.EmailTemplate{Key: key, SubjectTmpl: input.SubjectTmpl, BodyTmpl: input.BodyTmpl, Enabled: true}
+	subject, _, err := renderEmail(&tmpl, sampleEmailContext())
+	if err != nil {
+		return nil, ErrValidation.WithMsg("email.invalid_template", err.Error())
+	}
+	if subject == "" {
+		return nil, ErrValidation.WithMsg("email.empty_subject")
+	}
+
+	var existing models.EmailTemplate
+	err = s.db.Where("`key` = ?", key).First(&existing).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		if input.Enabled != nil {
+			tmpl.Enabled = *input.Enabled
+		}
+		if err := s.db.Create(&tmpl).Error; err != nil {
+			return nil, err
+		}
+		return &tmpl, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	existing.SubjectTmpl = input.SubjectTmpl
+	existing.BodyTmpl = input.BodyTmpl
+	if input.Enabled != nil {
+		existing.Enabled = *input.Enabled
+	}
+	if err := s.db.Model(&existing).Select("subject_tmpl", "body_tmpl", "enabled").Updates(&existing).Error; err != nil {
+		return nil, err
+	}
+	return &existing, nil
+}
+
+// GetEmailLogs 获取邮件记录（管理员），status为0时返回所有状态，按时间倒序
+func (s *EmailService) GetEmailLogs(status int8, page, pageSize int) ([]models.EmailLog, int64, error) {
+	var emails []models.EmailLog
+	var total int64
+
+	query := s.db.Model(&models.EmailLog{})
+	if status != 0 {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	err := query.Order("id DESC").Offset(offset).Limit(pageSize).Find(&emails).Error
+	return emails, total, err
+}
+
+// OutboxHandlers 邮件相关的发件箱事件处理器：下单、支付、退款后按对应模板生成邮件
+func (s *EmailService) OutboxHandlers() OutboxMux {
+	handler := func(ctx context.Context, event models.OutboxEvent) error {
+		return s.queueOrderEmail(s.db.WithContext(ctx), event)
+	}
+	return OutboxMux{
+		EventOrderCreated:  handler,
+		EventOrderPaid:     handler,
+		EventOrderRefunded: handler,
+	}
+}
+
+// queueOrderEmail 按事件类型对应的模板渲染订单邮件，写入待发送记录
+// 模板不存在或已停用、用户已注销（没有邮箱）时跳过；每个事件只生成一封邮件，重复投递时跳过
+func (s *EmailService) queueOrderEmail(db *gorm.DB, event models.OutboxEvent) error {
+	var tmpl models.EmailTemplate
+	err := db.Where("`key` = ?", event.EventType).First(&tmpl).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !tmpl.Enabled {
+		return nil
+	}
+
+	orderID, err := models.ParseOrderID(event.AggregateID)
+	if err != nil {
+		return err
+	}
+	var order models.Order
+	if err := db.Preload("User").Preload("Items").First(&order, "id = ?", orderID).Error; err != nil {
+		return err
+	}
+	if order.User.Email == "" {
+		return nil
+	}
+
+	data := newEmailContext(&order)
+	if event.EventType == EventOrderRefunded {
+		var payload struct {
+			RefundID uint `json:"refund_id"`
+		}
+		if err := json.Unmarshal([]byte(event.Payload), &payload); err != nil {
+			return err
+		}
+		var refund models.Refund
+		if err := db.First(&refund, payload.RefundID).Error; err != nil {
+			return err
+		}
+		data.Refund = EmailRefundData{RefundNo: refund.RefundNo, Amount: refund.Amount, Reason: refund.Reason}
+	}
+
+	subject, body, err := renderEmail(&tmpl, data)
+	if err != nil {
+		return fmt.Errorf("渲染邮件模板%s失败: %w", tmpl.Key, err)
+	}
+
+	email := models.EmailLog{
+		UserID:          order.UserID,
+		TemplateKey:     tmpl.Key,
+		OrderID:         order.ID,
+		OutboxEventID:   event.ID,
+		ToEmail:         order.User.Email,
+		RenderedSubject: subject,
+		RenderedBody:    body,
+		Status:          EmailQueued,
+	}
+	return Upsert(db, &email, []string{"outbox_event_id"}, nil)
+}
+
+// SendQueued 发送一轮待发送邮件，返回发送成功的数量
+// 按ID顺序逐封发送；失败的邮件记录发送次数和原因，留到下一轮重试，用完 emailMaxAttempts 次后标记为发送失败
+func (s *EmailService) SendQueued(ctx context.Context) (int, error) {
+	sent := 0
+	var lastID uint
+	for {
+		var emails []models.EmailLog
+		err := s.db.WithContext(ctx).
+			Where("status = ? AND id > ?", EmailQueued, lastID).
+			Order("id").Limit(emailSendBatchSize).
+			Find(&emails).Error
+		if err != nil {
+			return sent, err
+		}
+		if len(emails) == 0 {
+			return sent, nil
+		}
+		lastID = emails[len(emails)-1].ID
+
+		for _, email := range emails {
+			if err := ctx.Err(); err != nil {
+				return sent, err
+			}
+
+			if err := s.sender.SendEmail(ctx, email.ToEmail, email.RenderedSubject, email.RenderedBody); err != nil {
+				log.Printf("发送邮件%d(%s)失败: %v", email.ID, email.TemplateKey, err)
+				message := []rune(err.Error())
+				if len(message) > 500 {
+					message = message[:500]
+				}
+				updates := map[string]interface{}{
+					"attempts":   gorm.Expr("attempts + 1"),
+					"last_error": string(message),
+				}
+				if email.Attempts+1 >= emailMaxAttempts {
+					updates["status"] = EmailFailed
+				}
+				if err := s.db.Model(&models.EmailLog{}).
+					Where("id = ? AND status = ?", email.ID, EmailQueued).
+					Updates(updates).Error; err != nil {
+					return sent, err
+				}
+				continue
+			}
+
+			now := time.Now()
+			if err := s.db.Model(&models.EmailLog{}).
+				Where("id = ? AND status = ?", email.ID, EmailQueued).
+				Updates(map[string]interface{}{
+					"status":   EmailSent,
+					"attempts": gorm.Expr("attempts + 1"),
+					"sent_at":  &now,
+				}).Error; err != nil {
+				return sent, err
+			}
+			sent++
+		}
+	}
+}
+
+// StartSender 启动邮件发送任务，每隔interval发送一轮待发送邮件，ctx取消时退出
+func (s *EmailService) StartSender(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			if _, err := s.SendQueued(ctx); err != nil && ctx.Err() == nil {
+				log.Printf("发送邮件失败: %v", err)
+			}
+		}
+	}()
+}
@@ -0,0 +1,139 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"edu-platform/models"
+)
+
+const (
+	bulkEnrollMaxEmails = 5000 // 每次批量开通最多提交的邮箱数
+	bulkEnrollBatchSize = 500  // 按邮箱查询用户、写入选课记录的批大小
+)
+
+// BulkEnrollResult 批量开通结果，邮箱去重后按提交顺序归入各类
+type BulkEnrollResult struct {
+	Enrolled        []string `json:"enrolled"`         // 本次开通
+	AlreadyEnrolled []string `json:"already_enrolled"` // 已有有效的选课记录，未重复开通
+	Unknown         []string `json:"unknown"`          // 没有使用该邮箱的用户
+}
+
+// EnrollmentService 选课服务
+type EnrollmentService struct {
+	db *gorm.DB
+}
+
+// NewEnrollmentService 创建选课服务
+func NewEnrollmentService(db *gorm.DB) *EnrollmentService {
+	return &EnrollmentService{db: db}
+}
+
+// BulkEnroll 企业客户按员工邮箱批量开通课程，选课记录不关联订单（Source为2）
+// 在一个事务中按批查询用户、写入选课记录，并锁定课程，同一课程的批量开通串行执行，重复提交不会重复开通。
+// 没有对应用户的邮箱默认跳过并在结果中列出；strict为true时有未知邮箱则整批不开通，返回的错误Details为未知邮箱
+func (s *EnrollmentService) BulkEnroll(courseID uint, userEmails []string, strict bool) (BulkEnrollResult, error) {
+	var emails []string
+	seen := make(map[string]bool, len(userEmails))
+	for _, email := range userEmails {
+		email = strings.TrimSpace(email)
+		key := strings.ToLower(email)
+		if email == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		emails = append(emails, email)
+	}
+	if len(emails) == 0 {
+		return BulkEnrollResult{}, ErrValidation.WithMsg("enrollment.emails_required")
+	}
+	if len(emails) > bulkEnrollMaxEmails {
+		return BulkEnrollResult{}, ErrValidation.WithMsg("enrollment.too_many_emails", bulkEnrollMaxEmails)
+	}
+
+	result := BulkEnrollResult{Enrolled: []string{}, AlreadyEnrolled: []string{}, Unknown: []string{}}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var course models.Course
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").
+			Scopes(AvailableCourses()).
+			First(&course, courseID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrNotFound.WithMsg("course.not_found")
+			}
+			return err
+		}
+
+		now := time.Now()
+		var enrollments []models.Enrollment
+		for start := 0; start < len(emails); start += bulkEnrollBatchSize {
+			end := start + bulkEnrollBatchSize
+			if end > len(emails) {
+				end = len(emails)
+			}
+			chunk := emails[start:end]
+
+			var users []models.User
+			if err := tx.Select("id", "email").Where("email IN ?", chunk).Find(&users).Error; err != nil {
+				return err
+			}
+			userIDs := make(map[string]uint, len(users))
+			ids := make([]uint, 0, len(users))
+			for _, user := range users {
+				userIDs[strings.ToLower(user.Email)] = user.ID
+				ids = append(ids, user.ID)
+			}
+
+			enrolled := make(map[uint]bool)
+			if len(ids) > 0 {
+				var enrolledIDs []uint
+				if err := tx.Model(&models.Enrollment{}).
+					Where("course_id = ? AND user_id IN ? AND status = ?", courseID, ids, 1).
+					Pluck("user_id", &enrolledIDs).Error; err != nil {
+					return err
+				}
+				for _, id := range enrolledIDs {
+					enrolled[id] = true
+				}
+			}
+
+			for _, email := range chunk {
+				userID, ok := userIDs[strings.ToLower(email)]
+				switch {
+				case !ok:
+					result.Unknown = append(result.Unknown, email)
+				case enrolled[userID]:
+					result.AlreadyEnrolled = append(result.AlreadyEnrolled, email)
+				default:
+					result.Enrolled = append(result.Enrolled, email)
+					enrollments = append(enrollments, models.Enrollment{
+						UserID:     userID,
+						CourseID:   courseID,
+						Source:     models.EnrollmentSourceBulk,
+						Status:     models.EnrollmentStatusActive,
+						EnrolledAt: now,
+					})
+				}
+			}
+		}
+
+		if strict && len(result.Unknown) > 0 {
+			return ErrValidation.WithMsg("enrollment.unknown_emails", len(result.Unknown)).WithDetails(result.Unknown)
+		}
+		if len(enrollments) == 0 {
+			return nil
+		}
+		if err := tx.CreateInBatches(&enrollments, bulkEnrollBatchSize).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Course{}).Where("id = ?", courseID).
+			Update("student_count", gorm.Expr("student_count + ?", len(enrollments))).Error
+	})
+	if err != nil {
+		return BulkEnrollResult{}, err
+	}
+	return result, nil
+}
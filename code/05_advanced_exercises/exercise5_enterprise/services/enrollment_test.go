@@ -0,0 +1,81 @@
+package services_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"edu-platform/models"
+	"edu-platform/services"
+	"edu-platform/testhelpers"
+	"edu-platform/testhelpers/factory"
+)
+
+// TestBulkEnrollUnknownEmails 默认跳过未知邮箱并按提交顺序归类，重复的邮箱只处理一次；
+// strict为true时有未知邮箱则整批不开通，错误的Details为未知邮箱
+func TestBulkEnrollUnknownEmails(t *testing.T) {
+	t.Parallel()
+	db := testhelpers.NewDB(t)
+	f := factory.New(t, db)
+	enrollments := services.NewEnrollmentService(db)
+
+	course := f.Course(9900)
+	bought, fresh, late := f.User("student"), f.User("student"), f.User("student")
+	f.PaidOrder(bought.ID, course.ID)
+
+	// 严格模式下不开通任何人
+	_, err := enrollments.BulkEnroll(course.ID, []string{fresh.Email, "ghost@example.test"}, true)
+	var appErr *services.AppError
+	if !errors.As(err, &appErr) || !errors.Is(err, services.ErrValidation) {
+		t.Fatalf("严格模式返回 %v，期望 ErrValidation", err)
+	}
+	if !reflect.DeepEqual(appErr.Details, []string{"ghost@example.test"}) {
+		t.Errorf("错误的Details为 %v，期望未知邮箱列表", appErr.Details)
+	}
+	var count int64
+	db.Model(&models.Enrollment{}).Where("course_id = ? AND user_id = ?", course.ID, fresh.ID).Count(&count)
+	if count != 0 {
+		t.Error("严格模式有未知邮箱时不应开通")
+	}
+
+	result, err := enrollments.BulkEnroll(course.ID, []string{
+		"ghost@example.test", " " + fresh.Email, bought.Email, "", "GHOST@example.test", late.Email, fresh.Email,
+	}, false)
+	if err != nil {
+		t.Fatalf("批量开通失败: %v", err)
+	}
+	want := services.BulkEnrollResult{
+		Enrolled:        []string{fresh.Email, late.Email},
+		AlreadyEnrolled: []string{bought.Email},
+		Unknown:         []string{"ghost@example.test"},
+	}
+	if !reflect.DeepEqual(result, want) {
+		t.Errorf("结果为 %+v，期望 %+v", result, want)
+	}
+
+	var enrollment models.Enrollment
+	if err := db.Where("course_id = ? AND user_id = ?", course.ID, late.ID).First(&enrollment).Error; err != nil {
+		t.Fatalf("查询选课记录失败: %v", err)
+	}
+	if enrollment.Source != models.EnrollmentSourceBulk || enrollment.Status != models.EnrollmentStatusActive {
+		t.Errorf("选课记录来源 %v、状态 %v，期望批量开通的有效记录", enrollment.Source, enrollment.Status)
+	}
+	var updated models.Course
+	db.First(&updated, course.ID)
+	if updated.StudentCount != course.StudentCount+3 {
+		t.Errorf("学生数为 %d，期望购买1人加批量开通2人", updated.StudentCount)
+	}
+
+	// 重复提交不会重复开通
+	again, err := enrollments.BulkEnroll(course.ID, []string{fresh.Email, late.Email}, true)
+	if err != nil || len(again.Enrolled) != 0 || len(again.AlreadyEnrolled) != 2 {
+		t.Errorf("重复提交返回 %+v, %v，期望全部已开通", again, err)
+	}
+
+	if _, err := enrollments.BulkEnroll(course.ID, []string{" ", ""}, false); !errors.Is(err, services.ErrValidation) {
+		t.Errorf("没有邮箱时返回 %v，期望 ErrValidation", err)
+	}
+	if _, err := enrollments.BulkEnroll(course.ID+100, []string{fresh.Email}, false); !errors.Is(err, services.ErrNotFound) {
+		t.Errorf("课程不存在时返回 %v，期望 ErrNotFound", err)
+	}
+}
@@ -0,0 +1,112 @@
+package services
+
+import (
+	"net/http"
+
+	"edu-platform/i18n"
+)
+
+// AppError 业务错误
+// Code为稳定的业务错误码，HTTPStatus为返回给客户端的HTTP状态码
+// MsgID为消息目录中的消息ID，错误处理中间件按请求语言翻译；Message为默认语言的文本，用于日志
+type AppError struct {
+	Code       int           `json:"code"`
+	HTTPStatus int           `json:"-"`
+	MsgID      string        `json:"-"`
+	Args       []interface{} `json:"-"` // 消息参数
+	Message    string        `json:"message"`
+	Err        error         `json:"-"` // 底层错误，只用于日志，不返回给客户端
+	Details    interface{}   `json:"-"` // 附加数据，放在响应的data字段中供前端展示
+}
+
+// 业务错误码
+const (
+	CodeValidation         = 40000 // 参数校验失败
+	CodeUnauthorized       = 40100 // 未登录或认证失败
+	CodeForbidden          = 40300 // 无权限
+	CodeNotFound           = 40400 // 资源不存在
+	CodeConflict           = 40900 // 资源冲突或状态不允许
+	CodePrerequisiteNotMet = 40901 // 未学完先修课程
+	CodeCourseFull         = 40902 // 限额课程已满员，可以加入候补
+	CodeInvalidParam       = 42200 // 参数格式正确但取值无效，如日期格式错误
+	CodeTooManyRequests    = 42900 // 请求过于频繁
+	CodeInternal           = 50000 // 服务器内部错误
+)
+
+// 预定义错误，通过WithMsg/Wrap派生具体错误
+var (
+	ErrValidation      = newAppError(CodeValidation, http.StatusBadRequest, "error.validation")
+	ErrUnauthorized    = newAppError(CodeUnauthorized, http.StatusUnauthorized, "error.unauthorized")
+	ErrForbidden       = newAppError(CodeForbidden, http.StatusForbidden, "error.forbidden")
+	ErrNotFound        = newAppError(CodeNotFound, http.StatusNotFound, "error.not_found")
+	ErrConflict        = newAppError(CodeConflict, http.StatusConflict, "error.conflict")
+	ErrInvalidParam    = newAppError(CodeInvalidParam, http.StatusUnprocessableEntity, "error.invalid_param")
+	ErrTooManyRequests = newAppError(CodeTooManyRequests, http.StatusTooManyRequests, "error.too_many_requests")
+	ErrInternal        = newAppError(CodeInternal, http.StatusInternalServerError, "error.internal")
+
+	// ErrPrerequisiteNotMet 购买课程时有先修课程未学完，Details为 []PrerequisiteStatus
+	ErrPrerequisiteNotMet = newAppError(CodePrerequisiteNotMet, http.StatusConflict, "course.prerequisite_not_met")
+
+	// ErrCourseFull 限额课程已满员，Details为 {"course_id": ..., "waitlist": true}
+	ErrCourseFull = newAppError(CodeCourseFull, http.StatusConflict, "course.full")
+)
+
+func newAppError(code, httpStatus int, msgID string) *AppError {
+	return &AppError{
+		Code:       code,
+		HTTPStatus: httpStatus,
+		MsgID:      msgID,
+		Message:    i18n.Translate(i18n.DefaultLocale, msgID),
+	}
+}
+
+// Error 实现error接口
+func (e *AppError) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+// Unwrap 返回底层错误，支持errors.Is/errors.As
+func (e *AppError) Unwrap() error {
+	return e.Err
+}
+
+// Is 错误码相同即视为同一类错误，使errors.Is(err, ErrNotFound)可用
+func (e *AppError) Is(target error) bool {
+	t, ok := target.(*AppError)
+	return ok && t.Code == e.Code
+}
+
+// WithMsg 复制错误并替换为消息目录中的消息
+func (e *AppError) WithMsg(msgID string, args ...interface{}) *AppError {
+	clone := *e
+	clone.MsgID = msgID
+	clone.Args = args
+	clone.Message = i18n.Translate(i18n.DefaultLocale, msgID, args...)
+	return &clone
+}
+
+// WithMessage 复制错误并替换为不需要翻译的固定提示信息
+func (e *AppError) WithMessage(message string) *AppError {
+	clone := *e
+	clone.MsgID = ""
+	clone.Args = nil
+	clone.Message = message
+	return &clone
+}
+
+// WithDetails 复制错误并附加返回给前端的数据
+func (e *AppError) WithDetails(details interface{}) *AppError {
+	clone := *e
+	clone.Details = details
+	return &clone
+}
+
+// Wrap 复制错误并附加底层错误
+func (e *AppError) Wrap(err error) *AppError {
+	clone := *e
+	clone.Err = err
+	return &clone
+}
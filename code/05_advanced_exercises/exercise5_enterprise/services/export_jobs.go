@@ -0,0 +1,194 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// 导出任务状态
+const (
+	ExportJobPending   = "pending"   // 排队中
+	ExportJobRunning   = "running"   // 生成中
+	ExportJobCompleted = "completed" // 已完成，可下载
+	ExportJobFailed    = "failed"    // 失败
+)
+
+// exportFileTTL 导出文件的保留时长，过期后文件和任务一起删除
+const exportFileTTL = 24 * time.Hour
+
+// ExportJob 用户数据导出任务
+type ExportJob struct {
+	ID          string     `json:"job_id"`
+	UserID      uint       `json:"-"`
+	Status      string     `json:"status"`
+	Error       string     `json:"error,omitempty"`
+	FilePath    string     `json:"-"`
+	CreatedAt   time.Time  `json:"created_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+}
+
+// ExportJobManager 导出任务管理
+// 任务保存在内存中，导出文件写入临时目录，完成24小时后自动删除
+type ExportJobManager struct {
+	exporter *UserDataExporter
+	dir      string
+
+	mu   sync.Mutex
+	jobs map[string]*ExportJob
+}
+
+// NewExportJobManager 创建导出任务管理，dir为导出文件目录
+// 创建时会清理目录中上次运行遗留的过期文件
+func NewExportJobManager(exporter *UserDataExporter, dir string) *ExportJobManager {
+	m := &ExportJobManager{
+		exporter: exporter,
+		dir:      dir,
+		jobs:     make(map[string]*ExportJob),
+	}
+	m.removeExpiredFiles()
+	return m
+}
+
+// DefaultExportDir 默认导出文件目录
+func DefaultExportDir() string {
+	return filepath.Join(os.TempDir(), "edu-platform-exports")
+}
+
+// Enqueue 创建导出任务并在后台执行
+func (m *ExportJobManager) Enqueue(userID uint) (ExportJob, error) {
+	id, err := newExportJobID()
+	if err != nil {
+		return ExportJob{}, err
+	}
+
+	job := &ExportJob{
+		ID:        id,
+		UserID:    userID,
+		Status:    ExportJobPending,
+		CreatedAt: time.Now(),
+	}
+
+	m.mu.Lock()
+	m.jobs[id] = job
+	snapshot := *job
+	m.mu.Unlock()
+
+	go m.run(id)
+	return snapshot, nil
+}
+
+// Get 查询导出任务，只能查询自己的任务
+func (m *ExportJobManager) Get(jobID string, userID uint) (ExportJob, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	job, ok := m.jobs[jobID]
+	if !ok || job.UserID != userID {
+		return ExportJob{}, ErrNotFound.WithMsg("export.job_not_found")
+	}
+	return *job, nil
+}
+
+// run 执行导出任务
+func (m *ExportJobManager) run(jobID string) {
+	m.update(jobID, func(job *ExportJob) { job.Status = ExportJobRunning })
+
+	m.mu.Lock()
+	userID := m.jobs[jobID].UserID
+	m.mu.Unlock()
+
+	path := filepath.Join(m.dir, jobID+".json")
+	err := m.writeFile(userID, path)
+
+	now := time.Now()
+	expiresAt := now.Add(exportFileTTL)
+	m.update(jobID, func(job *ExportJob) {
+		job.CompletedAt = &now
+		job.ExpiresAt = &expiresAt
+		if err != nil {
+			job.Status = ExportJobFailed
+			job.Error = err.Error()
+			return
+		}
+		job.Status = ExportJobCompleted
+		job.FilePath = path
+	})
+	if err != nil {
+		log.Printf("用户数据导出失败: job=%s user=%d: %v", jobID, userID, err)
+	}
+
+	time.AfterFunc(exportFileTTL, func() { m.remove(jobID) })
+}
+
+// writeFile 导出到文件，失败时删除不完整的文件
+func (m *ExportJobManager) writeFile(userID uint, path string) error {
+	if err := os.MkdirAll(m.dir, 0o700); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+
+	if err := m.exporter.Export(userID, file); err != nil {
+		file.Close()
+		os.Remove(path)
+		return err
+	}
+	return file.Close()
+}
+
+func (m *ExportJobManager) update(jobID string, fn func(job *ExportJob)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if job, ok := m.jobs[jobID]; ok {
+		fn(job)
+	}
+}
+
+// remove 删除过期的任务和导出文件
+func (m *ExportJobManager) remove(jobID string) {
+	m.mu.Lock()
+	job, ok := m.jobs[jobID]
+	delete(m.jobs, jobID)
+	m.mu.Unlock()
+
+	if ok && job.FilePath != "" {
+		if err := os.Remove(job.FilePath); err != nil && !os.IsNotExist(err) {
+			log.Printf("删除过期导出文件失败: %v", err)
+		}
+	}
+}
+
+// removeExpiredFiles 删除目录中超过保留时长的导出文件
+func (m *ExportJobManager) removeExpiredFiles() {
+	entries, err := os.ReadDir(m.dir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || entry.IsDir() {
+			continue
+		}
+		if time.Since(info.ModTime()) > exportFileTTL {
+			os.Remove(filepath.Join(m.dir, entry.Name()))
+		}
+	}
+}
+
+func newExportJobID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("生成任务ID失败: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
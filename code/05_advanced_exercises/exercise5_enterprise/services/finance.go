@@ -0,0 +1,169 @@
+package services
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+
+	"edu-platform/models"
+)
+
+// settingPlatformFeeRate 平台服务费率，如0.3表示从讲师的课程收入中抽取30%
+const settingPlatformFeeRate = "finance.platform_fee_rate"
+
+// defaultPlatformFeeRate 未配置费率时使用的默认平台服务费率
+const defaultPlatformFeeRate = 0.3
+
+// PayoutLine 讲师结算明细，对应一个订单项，金额单位为分
+type PayoutLine struct {
+	OrderNo     string    `json:"order_no"`
+	OrderItemID uint      `json:"order_item_id"`
+	CourseID    uint      `json:"course_id"`
+	CourseName  string    `json:"course_name"`
+	PaidAt      time.Time `json:"paid_at"`
+	Gross       int64     `json:"gross"`        // 订单项实收金额
+	PlatformFee int64     `json:"platform_fee"` // 平台服务费
+	Net         int64     `json:"net"`          // 讲师收入
+}
+
+// InstructorPayout 讲师某月的结算汇总，合计由明细逐项相加得到，与明细完全一致
+type InstructorPayout struct {
+	InstructorID uint         `json:"instructor_id"`
+	Lines        []PayoutLine `json:"lines"`
+	Gross        int64        `json:"gross"`
+	PlatformFee  int64        `json:"platform_fee"`
+	Net          int64        `json:"net"`
+}
+
+// FinanceService 财务服务
+type FinanceService struct {
+	db *gorm.DB
+}
+
+// NewFinanceService 创建财务服务
+func NewFinanceService(db *gorm.DB) *FinanceService {
+	return &FinanceService{db: db}
+}
+
+// ComputeInstructorPayouts 计算某月的讲师结算，instructorIDs为空时计算全部讲师，按讲师ID排序
+// 统计当月（服务器本地时区）支付、状态为已付款或已完成的订单中未退款的订单项，实收金额为价格减去订单项优惠；
+// 订单级优惠券由平台承担，不分摊到讲师。平台服务费按订单项分别四舍五入到分，讲师收入为实收金额减去服务费
+func (s *FinanceService) ComputeInstructorPayouts(month time.Month, year int, instructorIDs ...uint) ([]InstructorPayout, error) {
+	if month < time.January || month > time.December {
+		return nil, ErrValidation.WithMsg("finance.invalid_month", fmt.Sprintf("%d-%02d", year, int(month)))
+	}
+	start := time.Date(year, month, 1, 0, 0, 0, 0, time.Local)
+	end := start.AddDate(0, 1, 0)
+
+	var rows []struct {
+		InstructorID   uint
+		OrderNo        string
+		OrderItemID    uint
+		CourseID       uint
+		CourseName     string
+		PaidAt         time.Time
+		Price          int64
+		DiscountAmount int64
+	}
+	query := s.db.Model(&models.OrderItem{}).Table(models.TableAs(s.db, "order_items")).
+		Select("courses.instructor_id, orders.order_no, order_items.id AS order_item_id, order_items.course_id, "+
+			"order_items.course_name, orders.paid_at, order_items.price, order_items.discount_amount").
+		Joins("JOIN "+models.TableAs(s.db, "orders")+" ON orders.id = order_items.order_id AND orders.deleted_at IS NULL").
+		Joins("JOIN "+models.TableAs(s.db, "courses")+" ON courses.id = order_items.course_id").
+		Where("orders.status IN ? AND orders.paid_at >= ? AND orders.paid_at < ?", []models.OrderStatus{models.OrderStatusPaid, models.OrderStatusCompleted}, start, end).
+		Where("order_items.refund_id IS NULL")
+	if len(instructorIDs) > 0 {
+		query = query.Where("courses.instructor_id IN ?", instructorIDs)
+	}
+	if err := query.Order("courses.instructor_id, orders.paid_at, order_items.id").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	rate := NewSettingsService(s.db).GetFloat(settingPlatformFeeRate, defaultPlatformFeeRate)
+	var payouts []InstructorPayout
+	for _, row := range rows {
+		if len(payouts) == 0 || payouts[len(payouts)-1].InstructorID != row.InstructorID {
+			payouts = append(payouts, InstructorPayout{InstructorID: row.InstructorID})
+		}
+		payout := &payouts[len(payouts)-1]
+
+		gross := row.Price - row.DiscountAmount
+		if gross < 0 {
+			gross = 0
+		}
+		fee := int64(math.Round(float64(gross) * rate))
+		line := PayoutLine{
+			OrderNo:     row.OrderNo,
+			OrderItemID: row.OrderItemID,
+			CourseID:    row.CourseID,
+			CourseName:  row.CourseName,
+			PaidAt:      row.PaidAt,
+			Gross:       gross,
+			PlatformFee: fee,
+			Net:         gross - fee,
+		}
+		payout.Lines = append(payout.Lines, line)
+		payout.Gross += line.Gross
+		payout.PlatformFee += line.PlatformFee
+		payout.Net += line.Net
+	}
+	return payouts, nil
+}
+
+// payoutStatementHeader 讲师结算单的表头
+var payoutStatementHeader = []string{"订单号", "订单项ID", "课程ID", "课程名称", "支付时间", "实收金额(元)", "平台服务费(元)", "讲师收入(元)"}
+
+// ExportPayoutStatement 导出讲师某月的结算单（CSV），每个订单项一行，最后一行为合计
+// 合计取自 ComputeInstructorPayouts 的汇总，即各行金额（分）之和，换算成元时不会产生尾差；当月没有收入时只有表头和为0的合计行
+func (s *FinanceService) ExportPayoutStatement(instructorID uint, month time.Month, year int, w io.Writer) error {
+	payouts, err := s.ComputeInstructorPayouts(month, year, instructorID)
+	if err != nil {
+		return err
+	}
+	payout := InstructorPayout{InstructorID: instructorID}
+	if len(payouts) > 0 {
+		payout = payouts[0]
+	}
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write(payoutStatementHeader); err != nil {
+		return err
+	}
+	for _, line := range payout.Lines {
+		if err := writer.Write([]string{
+			line.OrderNo,
+			fmt.Sprint(line.OrderItemID),
+			fmt.Sprint(line.CourseID),
+			line.CourseName,
+			line.PaidAt.In(time.Local).Format("2006-01-02 15:04:05"),
+			formatCents(line.Gross),
+			formatCents(line.PlatformFee),
+			formatCents(line.Net),
+		}); err != nil {
+			return err
+		}
+	}
+	if err := writer.Write([]string{
+		"合计", "", "", fmt.Sprintf("%d笔", len(payout.Lines)), "",
+		formatCents(payout.Gross),
+		formatCents(payout.PlatformFee),
+		formatCents(payout.Net),
+	}); err != nil {
+		return err
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// formatCents 金额从分格式化为元，保留两位小数，用整数运算避免浮点误差
+func formatCents(cents int64) string {
+	sign := ""
+	if cents < 0 {
+		sign, cents = "-", -cents
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, cents/100, cents%100)
+}
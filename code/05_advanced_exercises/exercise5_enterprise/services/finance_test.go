@@ -0,0 +1,101 @@
+package services_test
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"edu-platform/models"
+	"edu-platform/services"
+	"edu-platform/testhelpers"
+	"edu-platform/testhelpers/factory"
+)
+
+// TestExportPayoutStatement 每个未退款的订单项一行，服务费按行四舍五入到分，合计行等于各行之和；
+// 其他讲师的课程和已退款的订单项不计入
+func TestExportPayoutStatement(t *testing.T) {
+	t.Parallel()
+	db := testhelpers.NewDB(t)
+	f := factory.New(t, db)
+
+	big, small, refunded, other := f.Course(9999), f.Course(1001), f.Course(5000), f.Course(3000)
+	instructorID := big.InstructorID
+	if err := db.Model(&models.Course{}).Where("id IN ?", []uint{small.ID, refunded.ID}).
+		Update("instructor_id", instructorID).Error; err != nil {
+		t.Fatalf("修改课程讲师失败: %v", err)
+	}
+
+	student := f.User("student")
+	first := f.PaidOrder(student.ID, big.ID, refunded.ID)
+	second := f.PaidOrder(f.User("student").ID, small.ID, other.ID)
+	for _, item := range first.Items {
+		if item.CourseID == refunded.ID {
+			if err := db.Model(&item).Update("refund_id", 1).Error; err != nil {
+				t.Fatalf("标记退款失败: %v", err)
+			}
+		}
+	}
+
+	now := time.Now()
+	var buf bytes.Buffer
+	if err := services.NewFinanceService(db).ExportPayoutStatement(instructorID, now.Month(), now.Year(), &buf); err != nil {
+		t.Fatalf("导出结算单失败: %v", err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("解析CSV失败: %v\n%s", err, buf.String())
+	}
+	if len(records) != 4 {
+		t.Fatalf("结算单有 %d 行，期望表头、2行明细和合计:\n%v", len(records), records)
+	}
+
+	// 9999×0.3=2999.7 舍入为3000，1001×0.3=300.3 舍入为300
+	wantLines := map[string][]string{
+		first.OrderNo:  {fmt.Sprint(big.ID), "99.99", "30.00", "69.99"},
+		second.OrderNo: {fmt.Sprint(small.ID), "10.01", "3.00", "7.01"},
+	}
+	for _, record := range records[1:3] {
+		want, ok := wantLines[record[0]]
+		got := []string{record[2], record[5], record[6], record[7]}
+		if !ok || !reflect.DeepEqual(got, want) {
+			t.Errorf("明细行为 %v，期望订单 %s 的 %v", record, record[0], want)
+		}
+	}
+	wantTotal := []string{"合计", "", "", "2笔", "", "110.00", "33.00", "77.00"}
+	if !reflect.DeepEqual(records[3], wantTotal) {
+		t.Errorf("合计行为 %v，期望 %v", records[3], wantTotal)
+	}
+}
+
+// TestExportPayoutStatementEmpty 当月没有收入时只有表头和为0的合计行；月份无效时返回 ErrValidation
+func TestExportPayoutStatementEmpty(t *testing.T) {
+	t.Parallel()
+	db := testhelpers.NewDB(t)
+	f := factory.New(t, db)
+	finance := services.NewFinanceService(db)
+
+	course := f.Course(9900)
+	f.PaidOrder(f.User("student").ID, course.ID)
+
+	lastYear := time.Now().Year() - 1
+	var buf bytes.Buffer
+	if err := finance.ExportPayoutStatement(course.InstructorID, time.March, lastYear, &buf); err != nil {
+		t.Fatalf("导出结算单失败: %v", err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("解析CSV失败: %v", err)
+	}
+	wantTotal := []string{"合计", "", "", "0笔", "", "0.00", "0.00", "0.00"}
+	if len(records) != 2 || !reflect.DeepEqual(records[1], wantTotal) {
+		t.Errorf("结算单为 %v，期望只有表头和 %v", records, wantTotal)
+	}
+
+	if err := finance.ExportPayoutStatement(course.InstructorID, 13, lastYear, &buf); !errors.Is(err, services.ErrValidation) {
+		t.Errorf("月份无效时返回 %v，期望 ErrValidation", err)
+	}
+}
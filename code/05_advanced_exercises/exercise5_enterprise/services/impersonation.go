@@ -0,0 +1,106 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+
+	"edu-platform/models"
+)
+
+// MaxImpersonationTTL 模拟登录token的最长有效期，不受 jwt.expire_duration 配置影响
+const MaxImpersonationTTL = 30 * time.Minute
+
+// ImpersonationTTL 模拟登录token的有效期：取配置的token有效期，未配置或超过30分钟时按30分钟
+func ImpersonationTTL(configured time.Duration) time.Duration {
+	if configured <= 0 || configured > MaxImpersonationTTL {
+		return MaxImpersonationTTL
+	}
+	return configured
+}
+
+// Impersonator 模拟登录的管理员，GET /me 中返回给前端显示提示条
+type Impersonator struct {
+	ID       uint   `json:"id"`
+	Username string `json:"username"`
+	Nickname string `json:"nickname"`
+}
+
+// Impersonation 一次模拟登录：管理员以目标用户的身份访问，token签发时记录管理员当前的token版本
+type Impersonation struct {
+	User         *models.User
+	AdminID      uint
+	AdminVersion uint
+	ExpiresAt    time.Time
+}
+
+// StartImpersonation 管理员模拟登录目标用户，用于客服排查问题
+// 只有管理员可以发起，不能模拟自己或其他管理员；有效期按 ttl 计算，最长30分钟
+func (s *UserService) StartImpersonation(adminID, userID uint, ttl time.Duration, now time.Time) (*Impersonation, error) {
+	if adminID == userID {
+		return nil, ErrValidation.WithMsg("auth.impersonate_self")
+	}
+	isAdmin, err := s.IsAdmin(adminID)
+	if err != nil {
+		return nil, err
+	}
+	if !isAdmin {
+		return nil, ErrForbidden
+	}
+	adminVersion, err := s.TokenVersion(adminID)
+	if err != nil {
+		return nil, err
+	}
+
+	var user models.User
+	if err := s.db.Select("id", "username", "nickname", "status").First(&user, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound.WithMsg("user.not_found")
+		}
+		return nil, err
+	}
+	targetIsAdmin, err := s.IsAdmin(userID)
+	if err != nil {
+		return nil, err
+	}
+	if targetIsAdmin {
+		return nil, ErrForbidden.WithMsg("auth.impersonate_admin")
+	}
+
+	return &Impersonation{
+		User:         &user,
+		AdminID:      adminID,
+		AdminVersion: adminVersion,
+		ExpiresAt:    now.Add(ImpersonationTTL(ttl)),
+	}, nil
+}
+
+// VerifyImpersonator 校验模拟登录token中的管理员：token版本与签发时一致（管理员修改或重置密码后失效），且仍是管理员
+func (s *UserService) VerifyImpersonator(adminID, version uint) (bool, error) {
+	current, err := s.TokenVersion(adminID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	if current != version {
+		return false, nil
+	}
+	return s.IsAdmin(adminID)
+}
+
+// GetImpersonator 模拟登录的管理员信息
+func (s *UserService) GetImpersonator(adminID uint) (*Impersonator, error) {
+	var admin Impersonator
+	err := s.db.Model(&models.User{}).Select("id", "username", "nickname").
+		Where("id = ?", adminID).Take(&admin).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound.WithMsg("user.not_found")
+		}
+		return nil, err
+	}
+	return &admin, nil
+}
@@ -0,0 +1,140 @@
+package services
+
+import (
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// maxIncludeDepth include 关联路径的最大层级，如 chapters.lessons 为2层
+const maxIncludeDepth = 2
+
+// includeWhitelist 一个接口允许 include 的关联路径，键为客户端使用的小写路径，值为对应的 GORM 关联路径
+type includeWhitelist struct {
+	paths    map[string]string
+	defaults []string // 未传 include 参数时加载的关联，与加入该参数前的返回内容一致
+}
+
+// courseIncludes 课程详情允许的关联
+var courseIncludes = includeWhitelist{
+	paths: map[string]string{
+		"category":         "Category",
+		"instructor":       "Instructor",
+		"chapters":         "Chapters",
+		"chapters.lessons": "Chapters.Lessons",
+		"tags":             "Tags",
+	},
+	defaults: []string{"category", "instructor", "chapters.lessons", "tags"},
+}
+
+// orderIncludes 订单详情允许的关联
+var orderIncludes = includeWhitelist{
+	paths: map[string]string{
+		"items":        "Items",
+		"items.course": "Items.Course",
+		"coupon":       "Coupon",
+	},
+	defaults: []string{"items.course", "coupon"},
+}
+
+// Includes 校验过的 include 关联路径，包含嵌套路径时其上级路径也视为已包含
+type Includes struct {
+	paths map[string]string
+}
+
+// DefaultCourseIncludes 课程详情默认加载的关联：分类、讲师、章节及课时
+func DefaultCourseIncludes() Includes {
+	includes, _ := courseIncludes.parse(courseIncludes.defaults)
+	return includes
+}
+
+// ParseCourseIncludes 解析课程详情的 include 参数，如 "instructor,chapters.lessons"，为空表示不加载关联
+func ParseCourseIncludes(raw string) (Includes, error) {
+	return courseIncludes.parse(splitIncludes(raw))
+}
+
+// DefaultOrderIncludes 订单详情默认加载的关联：订单项及其课程、优惠券
+func DefaultOrderIncludes() Includes {
+	includes, _ := orderIncludes.parse(orderIncludes.defaults)
+	return includes
+}
+
+// ParseOrderIncludes 解析订单详情的 include 参数，如 "items.course"，为空表示不加载关联
+func ParseOrderIncludes(raw string) (Includes, error) {
+	return orderIncludes.parse(splitIncludes(raw))
+}
+
+// splitIncludes 按逗号拆分 include 参数，去掉空白和空项，路径不区分大小写
+func splitIncludes(raw string) []string {
+	var paths []string
+	for _, path := range strings.Split(raw, ",") {
+		if path = strings.ToLower(strings.TrimSpace(path)); path != "" {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// parse 按白名单校验关联路径，有不允许的路径时返回 ErrValidation，Details为有效选项
+func (w includeWhitelist) parse(paths []string) (Includes, error) {
+	includes := Includes{paths: make(map[string]string, len(paths))}
+	var invalid []string
+	for _, path := range paths {
+		assoc, ok := w.paths[path]
+		if !ok || strings.Count(path, ".")+1 > maxIncludeDepth {
+			invalid = append(invalid, path)
+			continue
+		}
+		includes.paths[path] = assoc
+	}
+	if len(invalid) > 0 {
+		valid := w.options()
+		return Includes{}, ErrValidation.WithMsg("error.invalid_include", strings.Join(invalid, ","), strings.Join(valid, ",")).
+			WithDetails(map[string]interface{}{"invalid": invalid, "valid": valid})
+	}
+	return includes, nil
+}
+
+// options 白名单中的有效选项，按字母排序
+func (w includeWhitelist) options() []string {
+	options := make([]string, 0, len(w.paths))
+	for path := range w.paths {
+		options = append(options, path)
+	}
+	sort.Strings(options)
+	return options
+}
+
+// Has 是否包含该关联路径；包含 chapters.lessons 时 chapters 也视为已包含
+func (inc Includes) Has(path string) bool {
+	_, ok := inc.paths[path]
+	return ok || inc.covered(path)
+}
+
+// Preload 把关联路径转成链式 Preload，已被更深路径覆盖的上级路径不再单独预加载
+func (inc Includes) Preload(db *gorm.DB) *gorm.DB {
+	paths := make([]string, 0, len(inc.paths))
+	for path := range inc.paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		if inc.covered(path) {
+			continue
+		}
+		db = db.Preload(inc.paths[path])
+	}
+	return db
+}
+
+// covered 是否有更深的路径包含了该路径，如 chapters.lessons 包含 chapters
+func (inc Includes) covered(path string) bool {
+	for included := range inc.paths {
+		if strings.HasPrefix(included, path+".") {
+			return true
+		}
+	}
+	return false
+}
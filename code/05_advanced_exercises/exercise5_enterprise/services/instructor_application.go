@@ -0,0 +1,225 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"edu-platform/models"
+)
+
+// instructorRoleName 讲师角色名
+const instructorRoleName = "instructor"
+
+// InstructorApplicationService 讲师申请服务
+type InstructorApplicationService struct {
+	db *gorm.DB
+}
+
+// NewInstructorApplicationService 创建讲师申请服务
+func NewInstructorApplicationService(db *gorm.DB) *InstructorApplicationService {
+	return &InstructorApplicationService{db: db}
+}
+
+// Submit 提交讲师申请，每个用户同时只能有一个待审核的申请
+func (s *InstructorApplicationService) Submit(userID uint, bio, qualifications string) (*models.InstructorApplication, error) {
+	instructorRoleID, err := s.instructorRoleID(s.db)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := s.db.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	// 锁定用户，串行化同一用户的并发提交
+	var user models.User
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&user, userID).Error; err != nil {
+		tx.Rollback()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound.WithMsg("user.not_found")
+		}
+		return nil, err
+	}
+	if user.RoleID == instructorRoleID {
+		tx.Rollback()
+		return nil, ErrConflict.WithMsg("instructor.already_instructor")
+	}
+
+	pending, err := s.hasPending(tx, userID)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if pending {
+		tx.Rollback()
+		return nil, ErrConflict.WithMsg("instructor.application_pending")
+	}
+
+	application := models.InstructorApplication{
+		UserID:         userID,
+		Bio:            bio,
+		Qualifications: qualifications,
+		Status:         1, // 待审核
+		ActiveUserID:   &userID,
+	}
+	if err := tx.Create(&application).Error; err != nil {
+		tx.Rollback()
+		// 唯一索引冲突说明并发请求已经创建了待审核的申请
+		if pending, _ := s.hasPending(s.db, userID); pending {
+			return nil, ErrConflict.WithMsg("instructor.application_pending")
+		}
+		return nil, err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, err
+	}
+	return &application, nil
+}
+
+// GetLatest 获取用户最近一次的讲师申请
+func (s *InstructorApplicationService) GetLatest(userID uint) (*models.InstructorApplication, error) {
+	var application models.InstructorApplication
+	err := s.db.Where("user_id = ?", userID).Order("id DESC").First(&application).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound.WithMsg("instructor.application_not_found")
+		}
+		return nil, err
+	}
+	return &application, nil
+}
+
+// GetApplications 按状态分页获取讲师申请（管理员），预加载申请人及其资料
+func (s *InstructorApplicationService) GetApplications(status int8, page, pageSize int) ([]models.InstructorApplication, int64, error) {
+	var applications []models.InstructorApplication
+	var total int64
+
+	query := s.db.Model(&models.InstructorApplication{}).Where("status = ?", status)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	err := query.Preload("User").Preload("User.Profile").
+		Order("created_at ASC").
+		Offset(offset).Limit(pageSize).
+		Find(&applications).Error
+
+	return applications, total, err
+}
+
+// Approve 通过讲师申请，将申请人的角色改为讲师并发送通知
+func (s *InstructorApplicationService) Approve(applicationID, reviewerID uint) error {
+	instructorRoleID, err := s.instructorRoleID(s.db)
+	if err != nil {
+		return err
+	}
+
+	return s.review(applicationID, reviewerID, 2, "", func(tx *gorm.DB, application *models.InstructorApplication) error {
+		if err := tx.Model(&models.User{}).Where("id = ?", application.UserID).
+			Update("role_id", instructorRoleID).Error; err != nil {
+			return err
+		}
+		return createNotification(tx, &models.Notification{
+			UserID:  application.UserID,
+			Title:   "讲师申请已通过",
+			Content: "恭喜，您的讲师申请已通过审核，现在可以创建课程了。",
+			Type:    1, // 系统通知
+		})
+	})
+}
+
+// Reject 拒绝讲师申请，必须填写拒绝原因
+func (s *InstructorApplicationService) Reject(applicationID, reviewerID uint, reason string) error {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return ErrValidation.WithMsg("instructor.reject_reason_required")
+	}
+
+	return s.review(applicationID, reviewerID, 3, reason, func(tx *gorm.DB, application *models.InstructorApplication) error {
+		return createNotification(tx, &models.Notification{
+			UserID:  application.UserID,
+			Title:   "讲师申请未通过",
+			Content: "您的讲师申请未通过审核，原因：" + reason,
+			Type:    1, // 系统通知
+		})
+	})
+}
+
+// review 审核的公共流程：只有待审核的申请可以通过或拒绝
+func (s *InstructorApplicationService) review(applicationID, reviewerID uint, status int8, reason string,
+	after func(tx *gorm.DB, application *models.InstructorApplication) error) error {
+	tx := s.db.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	var application models.InstructorApplication
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&application, applicationID).Error; err != nil {
+		tx.Rollback()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrNotFound.WithMsg("instructor.application_not_found")
+		}
+		return err
+	}
+	if application.Status != 1 {
+		tx.Rollback()
+		return ErrConflict.WithMsg("instructor.application_reviewed")
+	}
+
+	now := time.Now()
+	// 带状态条件更新，防止并发审核
+	result := tx.Model(&application).Where("status = ?", 1).Updates(map[string]interface{}{
+		"status":         status,
+		"reviewer_id":    reviewerID,
+		"reviewed_at":    &now,
+		"reject_reason":  reason,
+		"active_user_id": nil,
+	})
+	if result.Error != nil {
+		tx.Rollback()
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		tx.Rollback()
+		return ErrConflict.WithMsg("instructor.application_reviewed")
+	}
+
+	if err := after(tx, &application); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit().Error
+}
+
+// hasPending 用户是否有待审核的申请
+func (s *InstructorApplicationService) hasPending(db *gorm.DB, userID uint) (bool, error) {
+	var count int64
+	err := db.Model(&models.InstructorApplication{}).
+		Where("user_id = ? AND status = ?", userID, 1).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// instructorRoleID 查询讲师角色ID
+func (s *InstructorApplicationService) instructorRoleID(db *gorm.DB) (uint, error) {
+	var role models.Role
+	if err := db.Where("name = ?", instructorRoleName).First(&role).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, ErrInternal.WithMsg("instructor.role_missing")
+		}
+		return 0, err
+	}
+	return role.ID, nil
+}
@@ -0,0 +1,237 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"edu-platform/models"
+)
+
+// settingInvoiceTaxRate 发票税率，如0.06表示6%
+const settingInvoiceTaxRate = "invoice.tax_rate"
+
+// defaultInvoiceTaxRate 未配置税率时使用的默认税率
+const defaultInvoiceTaxRate = 0.06
+
+// invoiceMonthLayout 发票编号和按月查询使用的月份格式
+const invoiceMonthLayout = "200601"
+
+// InvoiceService 发票服务
+type InvoiceService struct {
+	db *gorm.DB
+}
+
+// NewInvoiceService 创建发票服务
+func NewInvoiceService(db *gorm.DB) *InvoiceService {
+	return &InvoiceService{db: db}
+}
+
+// OutboxHandlers 发票相关的发件箱事件处理器：订单支付后开具发票，退款后开具红字发票
+// 事件可能重复投递，已开具的单据直接跳过
+func (s *InvoiceService) OutboxHandlers() OutboxMux {
+	return OutboxMux{
+		EventOrderPaid: func(ctx context.Context, event models.OutboxEvent) error {
+			orderID, err := models.ParseOrderID(event.AggregateID)
+			if err != nil {
+				return err
+			}
+			_, err = s.issueInvoice(s.db.WithContext(ctx), orderID)
+			return err
+		},
+		EventOrderRefunded: func(ctx context.Context, event models.OutboxEvent) error {
+			var payload struct {
+				RefundID uint `json:"refund_id"`
+			}
+			if err := json.Unmarshal([]byte(event.Payload), &payload); err != nil {
+				return err
+			}
+			_, err := s.issueCreditNote(s.db.WithContext(ctx), payload.RefundID)
+			return err
+		},
+	}
+}
+
+// issueInvoice 为已支付订单开具发票，订单已有发票时返回已有的发票
+func (s *InvoiceService) issueInvoice(db *gorm.DB, orderID models.OrderID) (*models.Invoice, error) {
+	var invoice models.Invoice
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// 锁定订单，同一订单的重复事件串行处理
+		var order models.Order
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("User").
+			First(&order, "id = ?", orderID).Error; err != nil {
+			return err
+		}
+
+		err := tx.Where("order_id = ?", order.ID).First(&invoice).Error
+		if err == nil {
+			return nil // 已开具，发票不能重开
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if order.PaidAt == nil {
+			return fmt.Errorf("订单%v未支付，不能开具发票", order.ID)
+		}
+
+		now := time.Now()
+		seq, err := nextDocumentNo(tx, "invoice:"+now.Format(invoiceMonthLayout))
+		if err != nil {
+			return err
+		}
+
+		taxRate := NewSettingsService(tx).GetFloat(settingInvoiceTaxRate, defaultInvoiceTaxRate)
+		invoice = models.Invoice{
+			InvoiceNo:  fmt.Sprintf("INV-%s-%06d", now.Format(invoiceMonthLayout), seq),
+			OrderID:    order.ID,
+			UserID:     order.UserID,
+			Amount:     order.PayAmount,
+			TaxRate:    taxRate,
+			TaxAmount:  includedTax(order.PayAmount, taxRate),
+			IssuedAt:   now,
+			BuyerName:  order.User.Nickname,
+			BuyerEmail: order.User.Email,
+			BuyerPhone: order.User.Phone,
+		}
+		if invoice.BuyerName == "" {
+			invoice.BuyerName = order.User.Username
+		}
+		return tx.Create(&invoice).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &invoice, nil
+}
+
+// issueCreditNote 为退款开具红字发票，退款已有红字发票时返回已有的红字发票
+// 订单发票尚未开具时返回错误，由发件箱下一轮重试
+func (s *InvoiceService) issueCreditNote(db *gorm.DB, refundID uint) (*models.CreditNote, error) {
+	var note models.CreditNote
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var refund models.Refund
+		if err := tx.First(&refund, refundID).Error; err != nil {
+			return err
+		}
+
+		err := tx.Where("refund_id = ?", refund.ID).First(&note).Error
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		var invoice models.Invoice
+		if err := tx.Where("order_id = ?", refund.OrderID).First(&invoice).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("订单%v的发票尚未开具", refund.OrderID)
+			}
+			return err
+		}
+
+		now := time.Now()
+		seq, err := nextDocumentNo(tx, "credit_note:"+now.Format(invoiceMonthLayout))
+		if err != nil {
+			return err
+		}
+
+		note = models.CreditNote{
+			CreditNoteNo: fmt.Sprintf("CN-%s-%06d", now.Format(invoiceMonthLayout), seq),
+			InvoiceID:    invoice.ID,
+			RefundID:     refund.ID,
+			OrderID:      refund.OrderID,
+			Amount:       refund.Amount,
+			TaxAmount:    includedTax(refund.Amount, invoice.TaxRate),
+			IssuedAt:     now,
+		}
+		return tx.Create(&note).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &note, nil
+}
+
+// nextDocumentNo 在事务中递增并返回计数器的下一个值
+// UPDATE会锁住计数器行直到事务结束，并发分配不会重复；事务回滚时编号随之回滚
+func nextDocumentNo(tx *gorm.DB, name string) (int64, error) {
+	result := tx.Model(&models.DocumentCounter{}).Where("name = ?", name).
+		Update("value", gorm.Expr("value + 1"))
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	if result.RowsAffected == 0 {
+		// 当月第一张单据，先创建计数器（并发创建时忽略冲突）再递增
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&models.DocumentCounter{Name: name}).Error; err != nil {
+			return 0, err
+		}
+		if err := tx.Model(&models.DocumentCounter{}).Where("name = ?", name).
+			Update("value", gorm.Expr("value + 1")).Error; err != nil {
+			return 0, err
+		}
+	}
+
+	var counter models.DocumentCounter
+	if err := tx.Where("name = ?", name).First(&counter).Error; err != nil {
+		return 0, err
+	}
+	return counter.Value, nil
+}
+
+// includedTax 计算含税金额中的税额（分），四舍五入
+func includedTax(amount int64, taxRate float64) int64 {
+	return int64(math.Round(float64(amount) * taxRate / (1 + taxRate)))
+}
+
+// GetOrderInvoice 获取订单的发票，只能查看自己订单的发票
+// 发票在支付后由后台任务开具，刚支付的订单可能暂时查不到
+func (s *InvoiceService) GetOrderInvoice(orderNo string, userID uint) (*models.Invoice, error) {
+	var order models.Order
+	if err := s.db.Select("id", "user_id").Where("order_no = ?", orderNo).First(&order).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound.WithMsg("order.not_found")
+		}
+		return nil, err
+	}
+	if order.UserID != userID {
+		return nil, ErrForbidden.WithMsg("order.forbidden")
+	}
+
+	var invoice models.Invoice
+	if err := s.db.Where("order_id = ?", order.ID).First(&invoice).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound.WithMsg("invoice.not_found")
+		}
+		return nil, err
+	}
+	return &invoice, nil
+}
+
+// GetInvoices 按开票月份获取发票列表（管理员），month格式为2024-06，按发票号排序
+func (s *InvoiceService) GetInvoices(month string, page, pageSize int) ([]models.Invoice, int64, error) {
+	start, err := time.ParseInLocation("2006-01", month, time.Local)
+	if err != nil {
+		return nil, 0, ErrValidation.WithMsg("invoice.invalid_month", month)
+	}
+
+	var invoices []models.Invoice
+	var total int64
+
+	query := s.db.Model(&models.Invoice{}).
+		Where("issued_at >= ? AND issued_at < ?", start, start.AddDate(0, 1, 0))
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	err = query.Order("invoice_no ASC").Offset(offset).Limit(pageSize).Find(&invoices).Error
+	return invoices, total, err
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+
+	"gorm.io/gorm"
+
+	"edu-platform/models"
+	"edu-platform/testhelpers/testdb"
+)
+
+// TestNextDocumentNoConcurrent 500个并发事务分配同一计数器的编号，编号从1开始连续且不重复
+func TestNextDocumentNoConcurrent(t *testing.T) {
+	db := testdb.New(t)
+	const (
+		workers = 500
+		counter = "invoice:2026-01"
+	)
+
+	numbers := make([]int64, workers)
+	errs := make([]error, workers)
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			errs[i] = db.Transaction(func(tx *gorm.DB) error {
+				n, err := nextDocumentNo(tx, counter)
+				numbers[i] = n
+				return err
+			})
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("第%d个事务分配编号失败: %v", i, err)
+		}
+	}
+	sort.Slice(numbers, func(i, j int) bool { return numbers[i] < numbers[j] })
+	for i, n := range numbers {
+		if n != int64(i+1) {
+			t.Fatalf("排序后第%d个编号为%d，期望%d（编号重复或不连续）", i, n, i+1)
+		}
+	}
+
+	var stored models.DocumentCounter
+	if err := db.Where("name = ?", counter).First(&stored).Error; err != nil {
+		t.Fatalf("查询计数器失败: %v", err)
+	}
+	if stored.Value != workers {
+		t.Errorf("计数器值为%d，期望%d", stored.Value, workers)
+	}
+}
+
+// TestNextDocumentNoRollback 事务回滚时分配的编号随之回滚，下一次分配不跳号
+func TestNextDocumentNoRollback(t *testing.T) {
+	db := testdb.New(t)
+	const counter = "credit_note:2026-01"
+	errRollback := errors.New("rollback")
+
+	next := func(fail bool) int64 {
+		t.Helper()
+		var n int64
+		err := db.Transaction(func(tx *gorm.DB) error {
+			var err error
+			if n, err = nextDocumentNo(tx, counter); err != nil {
+				return err
+			}
+			if fail {
+				return errRollback
+			}
+			return nil
+		})
+		if err != nil && !errors.Is(err, errRollback) {
+			t.Fatalf("分配编号失败: %v", err)
+		}
+		return n
+	}
+
+	if n := next(false); n != 1 {
+		t.Fatalf("第一个编号为%d，期望1", n)
+	}
+	if n := next(true); n != 2 {
+		t.Fatalf("回滚的事务中编号为%d，期望2", n)
+	}
+	if n := next(false); n != 2 {
+		t.Errorf("回滚后的编号为%d，期望2", n)
+	}
+}
@@ -0,0 +1,376 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"edu-platform/models"
+)
+
+const (
+	defaultJobTimeout = 10 * time.Minute // 任务单次执行的默认超时时间
+	jobLockMargin     = time.Minute      // 锁的租期比超时时间多出的部分，超时退出和记录结果期间锁仍有效
+)
+
+// 任务的触发方式，写入 JobRun.Trigger
+const (
+	JobTriggerSchedule = "schedule"
+	JobTriggerManual   = "manual"
+)
+
+// JobSchedule 任务的执行计划
+type JobSchedule interface {
+	// Next 晚于after的下一次执行时间
+	Next(after time.Time) time.Time
+	String() string
+}
+
+// intervalSchedule 每隔固定时间执行一次，从上一次执行结束时开始计时
+type intervalSchedule time.Duration
+
+func (s intervalSchedule) Next(after time.Time) time.Time {
+	return after.Add(time.Duration(s))
+}
+
+func (s intervalSchedule) String() string {
+	return "every " + time.Duration(s).String()
+}
+
+// dailySchedule 每天在本地时间 hour:minute 执行一次
+type dailySchedule struct {
+	hour, minute int
+}
+
+func (s dailySchedule) Next(after time.Time) time.Time {
+	next := time.Date(after.Year(), after.Month(), after.Day(), s.hour, s.minute, 0, 0, after.Location())
+	if !next.After(after) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+func (s dailySchedule) String() string {
+	return fmt.Sprintf("daily at %02d:%02d", s.hour, s.minute)
+}
+
+// ParseSchedule 解析执行计划，支持两种写法：
+//   - "every 10m"：每隔一段时间执行，时长格式同 time.ParseDuration，最短1秒
+//   - "daily at 03:00"：每天在本地时间的指定时刻执行
+func ParseSchedule(spec string) (JobSchedule, error) {
+	spec = strings.TrimSpace(spec)
+	if rest := strings.TrimPrefix(spec, "every "); rest != spec {
+		d, err := time.ParseDuration(strings.TrimSpace(rest))
+		if err != nil || d < time.Second {
+			return nil, fmt.Errorf("无效的执行间隔: %q", spec)
+		}
+		return intervalSchedule(d), nil
+	}
+	if rest := strings.TrimPrefix(spec, "daily at "); rest != spec {
+		parts := strings.Split(strings.TrimSpace(rest), ":")
+		if len(parts) == 2 {
+			hour, herr := strconv.Atoi(parts[0])
+			minute, merr := strconv.Atoi(parts[1])
+			if herr == nil && merr == nil && hour >= 0 && hour < 24 && minute >= 0 && minute < 60 {
+				return dailySchedule{hour: hour, minute: minute}, nil
+			}
+		}
+		return nil, fmt.Errorf("无效的执行时刻: %q", spec)
+	}
+	return nil, fmt.Errorf("无效的执行计划: %q，应为 \"every <时长>\" 或 \"daily at HH:MM\"", spec)
+}
+
+// Job 后台任务
+type Job struct {
+	Name     string        // 任务名，也是手动触发接口中的 :name
+	Schedule string        // 执行计划，见 ParseSchedule
+	Timeout  time.Duration // 单次执行的超时时间，默认10分钟
+	// Run 执行一次任务，返回处理的行数
+	Run func(ctx context.Context) (int64, error)
+}
+
+type registeredJob struct {
+	Job
+	schedule JobSchedule
+}
+
+// JobStatus 任务状态：执行计划、是否正在执行和最近一次执行记录
+type JobStatus struct {
+	Name     string         `json:"name"`
+	Schedule string         `json:"schedule"`
+	Running  bool           `json:"running"`
+	LastRun  *models.JobRun `json:"last_run"`
+}
+
+// JobRunner 后台任务调度器：按执行计划运行已注册的任务，每次执行写入 job_runs
+// 执行前在 job_locks 中抢锁，多个实例同时部署时同一任务不会重复执行，手动触发与定时执行也不会重叠
+type JobRunner struct {
+	db     *gorm.DB
+	jobs   []*registeredJob
+	byName map[string]*registeredJob
+}
+
+// NewJobRunner 创建后台任务调度器
+func NewJobRunner(db *gorm.DB) *JobRunner {
+	return &JobRunner{db: db, byName: make(map[string]*registeredJob)}
+}
+
+// Register 注册任务，任务名重复或执行计划无效时panic（属于代码错误，启动时即可发现）
+func (r *JobRunner) Register(job Job) {
+	if job.Name == "" || job.Run == nil {
+		panic("后台任务缺少名称或执行函数")
+	}
+	if _, exists := r.byName[job.Name]; exists {
+		panic("后台任务重复注册: " + job.Name)
+	}
+	schedule, err := ParseSchedule(job.Schedule)
+	if err != nil {
+		panic("后台任务 " + job.Name + ": " + err.Error())
+	}
+	if job.Timeout <= 0 {
+		job.Timeout = defaultJobTimeout
+	}
+	registered := &registeredJob{Job: job, schedule: schedule}
+	r.jobs = append(r.jobs, registered)
+	r.byName[job.Name] = registered
+}
+
+// Start 按执行计划启动所有已注册的任务，每个任务一个goroutine，ctx取消时退出
+func (r *JobRunner) Start(ctx context.Context) {
+	for _, job := range r.jobs {
+		go r.loop(ctx, job)
+	}
+}
+
+func (r *JobRunner) loop(ctx context.Context, job *registeredJob) {
+	for {
+		timer := time.NewTimer(time.Until(job.schedule.Next(time.Now())))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		run, token, err := r.begin(ctx, job, JobTriggerSchedule)
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Printf("后台任务 %s 启动失败: %v", job.Name, err)
+			}
+			continue
+		}
+		if run == nil {
+			continue // 其他实例或手动触发的执行尚未结束，本轮跳过
+		}
+		r.finish(ctx, job, run, token)
+	}
+}
+
+// RunNow 手动触发任务，立即返回执行记录（状态为 running），任务在后台执行，结果可通过 Statuses 查看
+// 任务不存在返回 ErrNotFound，正在执行返回 ErrConflict
+func (r *JobRunner) RunNow(name string) (*models.JobRun, error) {
+	job, ok := r.byName[name]
+	if !ok {
+		return nil, ErrNotFound.WithMsg("job.not_found", name)
+	}
+	ctx := context.Background()
+	run, token, err := r.begin(ctx, job, JobTriggerManual)
+	if err != nil {
+		return nil, err
+	}
+	if run == nil {
+		return nil, ErrConflict.WithMsg("job.running", name)
+	}
+	started := *run
+	go r.finish(ctx, job, run, token)
+	return &started, nil
+}
+
+// Statuses 所有已注册任务的状态，按注册顺序返回
+func (r *JobRunner) Statuses() ([]JobStatus, error) {
+	names := make([]string, 0, len(r.jobs))
+	for _, job := range r.jobs {
+		names = append(names, job.Name)
+	}
+
+	var runs []models.JobRun
+	latest := r.db.Model(&models.JobRun{}).Select("MAX(id)").Where("job IN ?", names).Group("job")
+	if err := r.db.Where("id IN (?)", latest).Find(&runs).Error; err != nil {
+		return nil, err
+	}
+	lastRuns := make(map[string]*models.JobRun, len(runs))
+	for i := range runs {
+		lastRuns[runs[i].Job] = &runs[i]
+	}
+
+	var locked []string
+	if err := r.db.Model(&models.JobLock{}).Where("name IN ? AND locked_until > ?", names, time.Now()).
+		Pluck("name", &locked).Error; err != nil {
+		return nil, err
+	}
+	running := make(map[string]bool, len(locked))
+	for _, name := range locked {
+		running[name] = true
+	}
+
+	statuses := make([]JobStatus, 0, len(r.jobs))
+	for _, job := range r.jobs {
+		statuses = append(statuses, JobStatus{
+			Name:     job.Name,
+			Schedule: job.schedule.String(),
+			Running:  running[job.Name],
+			LastRun:  lastRuns[job.Name],
+		})
+	}
+	return statuses, nil
+}
+
+// begin 抢锁并写入一条 running 状态的执行记录；锁被占用时返回 nil 记录
+func (r *JobRunner) begin(ctx context.Context, job *registeredJob, trigger string) (*models.JobRun, string, error) {
+	token, acquired, err := r.acquireLock(ctx, job.Name, job.Timeout+jobLockMargin)
+	if err != nil || !acquired {
+		return nil, "", err
+	}
+	run := &models.JobRun{
+		Job:       job.Name,
+		Trigger:   trigger,
+		Status:    models.JobRunStatusRunning,
+		StartedAt: time.Now(),
+	}
+	if err := r.db.WithContext(ctx).Create(run).Error; err != nil {
+		r.releaseLock(job.Name, token)
+		return nil, "", err
+	}
+	return run, token, nil
+}
+
+// finish 执行任务并记录结果，最后释放锁；记录结果时不使用ctx，服务停止导致的中断也会写入
+func (r *JobRunner) finish(ctx context.Context, job *registeredJob, run *models.JobRun, token string) {
+	defer r.releaseLock(job.Name, token)
+
+	runCtx, cancel := context.WithTimeout(ctx, job.Timeout)
+	rows, err := runJob(runCtx, job)
+	cancel()
+
+	updates := map[string]interface{}{
+		"status":        models.JobRunStatusSucceeded,
+		"finished_at":   time.Now(),
+		"rows_affected": rows,
+	}
+	if err != nil {
+		updates["status"] = models.JobRunStatusFailed
+		updates["error"] = err.Error()
+		log.Printf("后台任务 %s 执行失败: %v", job.Name, err)
+	}
+	if err := r.db.Model(run).Updates(updates).Error; err != nil {
+		log.Printf("后台任务 %s 记录执行结果失败: %v", job.Name, err)
+	}
+}
+
+// runJob 执行任务，任务panic时转为错误，不影响调度goroutine
+func runJob(ctx context.Context, job *registeredJob) (rows int64, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("panic: %v", p)
+		}
+	}()
+	return job.Run(ctx)
+}
+
+// acquireLock 抢占任务锁：锁行不存在时先插入一行已过期的锁，再以条件更新抢锁，
+// 只有锁已过期时更新才会命中，多个实例并发抢锁只有一个成功；token用于释放时确认锁仍属于自己
+func (r *JobRunner) acquireLock(ctx context.Context, name string, lease time.Duration) (string, bool, error) {
+	token, err := newJobLockToken()
+	if err != nil {
+		return "", false, err
+	}
+	db := r.db.WithContext(ctx)
+	now := time.Now()
+	if err := db.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.JobLock{Name: name, LockedUntil: now}).Error; err != nil {
+		return "", false, err
+	}
+	result := db.Model(&models.JobLock{}).Where("name = ? AND locked_until <= ?", name, now).
+		Updates(map[string]interface{}{"owner": token, "locked_until": now.Add(lease)})
+	if result.Error != nil {
+		return "", false, result.Error
+	}
+	return token, result.RowsAffected == 1, nil
+}
+
+// releaseLock 释放任务锁，锁已过期并被其他实例抢占时不会误释放
+func (r *JobRunner) releaseLock(name, token string) {
+	if err := r.db.Model(&models.JobLock{}).Where("name = ? AND owner = ?", name, token).
+		Update("locked_until", time.Now()).Error; err != nil {
+		log.Printf("后台任务 %s 释放锁失败: %v", name, err)
+	}
+}
+
+func newJobLockToken() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("生成任务锁标识失败: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// NewDefaultJobRunner 创建注册了全部周期任务的调度器
+// 后台启动时调用 Start 按计划执行，管理接口使用同一组任务定义手动触发
+func NewDefaultJobRunner(db *gorm.DB) *JobRunner {
+	runner := NewJobRunner(db)
+
+	// 每10分钟处理过期的候补通知，名额依次让给下一位候补用户
+	waitlistService := NewWaitlistService(db)
+	runner.Register(Job{
+		Name:     "waitlist-offer-expiry",
+		Schedule: "every 10m",
+		Run: func(ctx context.Context) (int64, error) {
+			n, err := waitlistService.ExpireOffers(ctx, time.Now())
+			if n > 0 {
+				log.Printf("候补: %d 个通知已过期", n)
+			}
+			return int64(n), err
+		},
+	})
+
+	// 每小时执行宽限期已结束的账户注销申请
+	deletionService := NewAccountDeletionService(db)
+	runner.Register(Job{
+		Name:     "account-deletion",
+		Schedule: "every 1h",
+		Run: func(ctx context.Context) (int64, error) {
+			n, err := deletionService.ExecuteDue(ctx)
+			if n > 0 {
+				log.Printf("账户注销: 已执行%d个申请", n)
+			}
+			return int64(n), err
+		},
+	})
+
+	// 每天凌晨3点清理过期的日志数据，数据量大时分批执行，超时时间放宽到1小时
+	retentionService := NewRetentionService(db, NewSettingsService(db))
+	runner.Register(Job{
+		Name:     "retention-purge",
+		Schedule: "daily at 03:00",
+		Timeout:  time.Hour,
+		Run: func(ctx context.Context) (int64, error) {
+			results, err := retentionService.PurgeAll(ctx)
+			var rows int64
+			for _, r := range results {
+				log.Printf("数据清理: 表=%s 删除=%d 批次=%d 耗时=%v", r.Table, r.Rows, r.Batches, r.Duration)
+				rows += r.Rows
+			}
+			return rows, err
+		},
+	})
+
+	return runner
+}
@@ -0,0 +1,176 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+
+	"edu-platform/models"
+	"edu-platform/services"
+	"edu-platform/testhelpers"
+)
+
+// blockingJob 执行到release关闭才结束的任务，每次开始执行时向started发送一次
+func blockingJob(name string, started chan<- struct{}, release <-chan struct{}) services.Job {
+	return services.Job{
+		Name:     name,
+		Schedule: "every 1h",
+		Run: func(ctx context.Context) (int64, error) {
+			started <- struct{}{}
+			select {
+			case <-release:
+				return 3, nil
+			case <-ctx.Done():
+				return 0, ctx.Err()
+			}
+		},
+	}
+}
+
+// waitJobRun 等待执行记录结束，返回最终的记录
+func waitJobRun(t *testing.T, db *gorm.DB, id uint) models.JobRun {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		var run models.JobRun
+		if err := db.First(&run, id).Error; err != nil {
+			t.Fatalf("查询执行记录失败: %v", err)
+		}
+		if run.Status != models.JobRunStatusRunning {
+			return run
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("执行记录 %d 超时未结束", id)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// waitJobUnlocked 等待任务锁释放；finish 在写入执行结果之后才释放锁
+func waitJobUnlocked(t *testing.T, db *gorm.DB, name string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		var held int64
+		if err := db.Model(&models.JobLock{}).Where("name = ? AND locked_until > ?", name, time.Now()).
+			Count(&held).Error; err != nil {
+			t.Fatalf("查询任务锁失败: %v", err)
+		}
+		if held == 0 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("任务 %s 的锁超时未释放", name)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// TestJobRunnerLockExclusive 两个调度器（模拟两个实例）共用任务锁：执行期间任一实例都不能再次触发，
+// 结束释放锁后可以再次执行
+func TestJobRunnerLockExclusive(t *testing.T) {
+	t.Parallel()
+	db := testhelpers.NewDB(t)
+
+	started, release := make(chan struct{}, 2), make(chan struct{})
+	first, second := services.NewJobRunner(db), services.NewJobRunner(db)
+	first.Register(blockingJob("sync", started, release))
+	second.Register(blockingJob("sync", started, release))
+
+	run, err := first.RunNow("sync")
+	if err != nil || run.Status != models.JobRunStatusRunning || run.Trigger != services.JobTriggerManual {
+		t.Fatalf("手动触发返回 %+v, %v，期望 running 的手动执行记录", run, err)
+	}
+	<-started
+	for name, runner := range map[string]*services.JobRunner{"同一实例": first, "另一实例": second} {
+		if _, err := runner.RunNow("sync"); !errors.Is(err, services.ErrConflict) {
+			t.Errorf("%s在执行期间触发返回 %v，期望 ErrConflict", name, err)
+		}
+	}
+	statuses, err := second.Statuses()
+	if err != nil || len(statuses) != 1 || !statuses[0].Running || statuses[0].LastRun.ID != run.ID {
+		t.Errorf("执行期间的状态为 %+v, %v，期望正在执行且最近一次为 %d", statuses, err, run.ID)
+	}
+
+	close(release)
+	if done := waitJobRun(t, db, run.ID); done.Status != models.JobRunStatusSucceeded || done.RowsAffected != 3 || done.FinishedAt == nil {
+		t.Errorf("执行结束后记录为 %+v，期望成功处理 3 行", done)
+	}
+	waitJobUnlocked(t, db, "sync")
+
+	again, err := second.RunNow("sync")
+	if err != nil {
+		t.Fatalf("锁释放后另一实例触发失败: %v", err)
+	}
+	<-started
+	waitJobRun(t, db, again.ID)
+	waitJobUnlocked(t, db, "sync")
+	if _, err := first.RunNow("unknown"); !errors.Is(err, services.ErrNotFound) {
+		t.Errorf("触发未注册的任务返回 %v，期望 ErrNotFound", err)
+	}
+}
+
+// TestJobRunnerExpiredLock 锁的租期过后其他实例可以抢占；原持有者结束时不会释放被抢占的锁
+func TestJobRunnerExpiredLock(t *testing.T) {
+	t.Parallel()
+	db := testhelpers.NewDB(t)
+
+	started := make(chan struct{}, 3)
+	releaseStale, releaseNew := make(chan struct{}), make(chan struct{})
+	stale, fresh := services.NewJobRunner(db), services.NewJobRunner(db)
+	stale.Register(blockingJob("sync", started, releaseStale))
+	fresh.Register(blockingJob("sync", started, releaseNew))
+
+	staleRun, err := stale.RunNow("sync")
+	if err != nil {
+		t.Fatalf("手动触发失败: %v", err)
+	}
+	<-started
+	// 模拟持有者卡住超过租期
+	if err := db.Model(&models.JobLock{}).Where("name = ?", "sync").
+		Update("locked_until", time.Now().Add(-time.Second)).Error; err != nil {
+		t.Fatalf("修改锁的租期失败: %v", err)
+	}
+
+	freshRun, err := fresh.RunNow("sync")
+	if err != nil {
+		t.Fatalf("锁过期后抢占失败: %v", err)
+	}
+	<-started
+	close(releaseStale)
+	waitJobRun(t, db, staleRun.ID)
+
+	// 原持有者结束后锁仍属于新的执行
+	if _, err := stale.RunNow("sync"); !errors.Is(err, services.ErrConflict) {
+		t.Errorf("抢占者执行期间触发返回 %v，期望 ErrConflict", err)
+	}
+	close(releaseNew)
+	waitJobRun(t, db, freshRun.ID)
+	waitJobUnlocked(t, db, "sync")
+}
+
+// TestJobRunnerPanic 任务panic时记录为失败并释放锁
+func TestJobRunnerPanic(t *testing.T) {
+	t.Parallel()
+	db := testhelpers.NewDB(t)
+
+	runner := services.NewJobRunner(db)
+	runner.Register(services.Job{
+		Name:     "broken",
+		Schedule: "daily at 03:00",
+		Run:      func(ctx context.Context) (int64, error) { panic("boom") },
+	})
+
+	run, err := runner.RunNow("broken")
+	if err != nil {
+		t.Fatalf("手动触发失败: %v", err)
+	}
+	if done := waitJobRun(t, db, run.ID); done.Status != models.JobRunStatusFailed || !strings.Contains(done.Error, "boom") {
+		t.Errorf("panic后记录为 %+v，期望失败并记录panic信息", done)
+	}
+	waitJobUnlocked(t, db, "broken")
+}
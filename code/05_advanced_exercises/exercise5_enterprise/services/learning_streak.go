@@ -0,0 +1,92 @@
+package services
+
+import (
+	"log"
+	"sort"
+	"time"
+
+	"edu-platform/models"
+)
+
+// settingStreakTimezone 计算连续学习天数时划分日期使用的时区，如 Asia/Shanghai，未设置时使用服务器本地时区
+const settingStreakTimezone = "learning.streak_timezone"
+
+// GetStreak 用户当前的连续学习天数和历史最长连续天数
+// 有观看时长（watch_time > 0）的学习记录的日期算作学习日，取学习行为日志的上报时间和学习进度的最后观看时间。
+// 今天还没有学习但昨天学习了，当前连续天数仍然有效（截止到昨天）；最近的学习日早于昨天时为0。
+// 学习行为日志按保留策略清理后，更早的学习日只能从学习进度中得到，最长连续天数可能偏小
+func (s *LearningService) GetStreak(userID uint) (current, longest int, err error) {
+	var times []time.Time
+	if err := s.db.Model(&models.LearningActivity{}).
+		Where("user_id = ? AND watch_time > ?", userID, 0).
+		Pluck("created_at", &times).Error; err != nil {
+		return 0, 0, err
+	}
+	var watched []time.Time
+	if err := s.db.Model(&models.LearningProgress{}).
+		Where("user_id = ? AND watch_time > ? AND last_watch_at IS NOT NULL", userID, 0).
+		Pluck("last_watch_at", &watched).Error; err != nil {
+		return 0, 0, err
+	}
+	times = append(times, watched...)
+
+	loc := s.streakLocation()
+	current, longest = computeStreak(times, time.Now(), loc)
+	return current, longest, nil
+}
+
+// streakLocation 读取划分学习日的时区设置
+func (s *LearningService) streakLocation() *time.Location {
+	name, ok, err := NewSettingsService(s.db).Get(settingStreakTimezone)
+	if err != nil || !ok || name == "" {
+		return time.Local
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		log.Printf("学习日时区设置无效 %q: %v", name, err)
+		return time.Local
+	}
+	return loc
+}
+
+// computeStreak 按loc时区把时间划分为日期，计算截止到now的当前连续天数和最长连续天数
+func computeStreak(times []time.Time, now time.Time, loc *time.Location) (current, longest int) {
+	seen := make(map[int64]bool)
+	var days []int64
+	for _, t := range times {
+		day := dayNumber(t, loc)
+		if !seen[day] {
+			seen[day] = true
+			days = append(days, day)
+		}
+	}
+	if len(days) == 0 {
+		return 0, 0
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i] < days[j] })
+
+	run := 0
+	for i, day := range days {
+		if i > 0 && day == days[i-1]+1 {
+			run++
+		} else {
+			run = 1
+		}
+		if run > longest {
+			longest = run
+		}
+	}
+
+	// 循环结束时run为最后一段连续学习的天数
+	today := dayNumber(now, loc)
+	if last := days[len(days)-1]; last == today || last == today-1 {
+		current = run
+	}
+	return current, longest
+}
+
+// dayNumber t在loc时区的日期，以1970-01-01起的天数表示，便于判断相邻
+func dayNumber(t time.Time, loc *time.Location) int64 {
+	y, m, d := t.In(loc).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC).Unix() / 86400
+}
@@ -0,0 +1,93 @@
+package services_test
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata" // 没有系统时区数据库的环境也能加载 Asia/Shanghai
+
+	"gorm.io/gorm"
+
+	"edu-platform/models"
+	"edu-platform/services"
+	"edu-platform/testhelpers"
+	"edu-platform/testhelpers/factory"
+)
+
+// addActivity 在指定时间为用户写入一条学习行为日志
+func addActivity(t *testing.T, db *gorm.DB, userID uint, at time.Time, watchTime int) {
+	t.Helper()
+	activity := models.LearningActivity{UserID: userID, CourseID: 1, LessonID: 1, WatchTime: watchTime}
+	activity.CreatedAt = at
+	if err := db.Create(&activity).Error; err != nil {
+		t.Fatalf("写入学习日志失败: %v", err)
+	}
+}
+
+// TestStreakAcrossGaps 中断一天即重新计数；没有观看时长的记录不算学习日；
+// 今天还没学习时截止到昨天的连续天数仍有效，最近的学习日早于昨天时当前连续天数为0
+func TestStreakAcrossGaps(t *testing.T) {
+	t.Parallel()
+	db := testhelpers.NewDB(t)
+	f := factory.New(t, db)
+	learning := services.NewLearningService(db)
+	if err := services.NewSettingsService(db).Set("learning.streak_timezone", "UTC", "string"); err != nil {
+		t.Fatalf("写入设置失败: %v", err)
+	}
+
+	noon := time.Now().UTC().Truncate(24 * time.Hour).Add(12 * time.Hour)
+	daysAgo := func(n int) time.Time { return noon.AddDate(0, 0, -n) }
+
+	cases := []struct {
+		name        string
+		days        []int // 学习日距今天的天数
+		idle        []int // 只有记录、没有观看时长的日期
+		wantCurrent int
+		wantLongest int
+	}{
+		{"今天仍在连续", []int{0, 1, 2, 5, 6, 7, 8}, []int{3, 4}, 3, 4},
+		{"今天还没学习", []int{1, 2, 4}, []int{0}, 2, 2},
+		{"已经中断", []int{2, 3, 4}, nil, 0, 3},
+		{"同一天多条记录", []int{0, 0, 0}, nil, 1, 1},
+		{"没有学习记录", nil, []int{0, 1}, 0, 0},
+	}
+	for _, tc := range cases {
+		user := f.User("student")
+		for _, n := range tc.days {
+			addActivity(t, db, user.ID, daysAgo(n), 60)
+		}
+		for _, n := range tc.idle {
+			addActivity(t, db, user.ID, daysAgo(n), 0)
+		}
+
+		current, longest, err := learning.GetStreak(user.ID)
+		if err != nil {
+			t.Fatalf("%s: 计算连续天数失败: %v", tc.name, err)
+		}
+		if current != tc.wantCurrent || longest != tc.wantLongest {
+			t.Errorf("%s: 当前 %d 天、最长 %d 天，期望 %d、%d", tc.name, current, longest, tc.wantCurrent, tc.wantLongest)
+		}
+	}
+}
+
+// TestStreakTimezone 学习日按设置的时区划分：UTC 同一天的两条记录在东八区跨过了零点
+func TestStreakTimezone(t *testing.T) {
+	t.Parallel()
+	db := testhelpers.NewDB(t)
+	f := factory.New(t, db)
+	learning := services.NewLearningService(db)
+	settings := services.NewSettingsService(db)
+
+	user := f.User("student")
+	day := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -10)
+	addActivity(t, db, user.ID, day.Add(15*time.Hour+30*time.Minute), 60) // 东八区 23:30
+	addActivity(t, db, user.ID, day.Add(16*time.Hour+30*time.Minute), 60) // 东八区次日 00:30
+
+	for zone, want := range map[string]int{"UTC": 1, "Asia/Shanghai": 2} {
+		if err := settings.Set("learning.streak_timezone", zone, "string"); err != nil {
+			t.Fatalf("写入设置失败: %v", err)
+		}
+		if _, longest, err := learning.GetStreak(user.ID); err != nil || longest != want {
+			t.Errorf("时区 %s: 最长 %d 天（%v），期望 %d", zone, longest, err, want)
+		}
+	}
+}
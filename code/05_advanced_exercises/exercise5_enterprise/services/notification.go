@@ -0,0 +1,179 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+
+	"edu-platform/models"
+)
+
+// maxNotificationBatch 一次批量标记已读或删除的通知数上限
+const maxNotificationBatch = 500
+
+// NotificationService 站内通知服务
+// 用户的未读通知数缓存在 users.unread_notification_count，新建、标记已读、删除通知时在同一事务中增减，
+// 角标和推送直接读这一列，不再对通知表执行COUNT。计数出现偏差时用 ReconcileUnreadCount 按通知表重新计算
+type NotificationService struct {
+	db *gorm.DB
+}
+
+// NewNotificationService 创建通知服务
+func NewNotificationService(db *gorm.DB) *NotificationService {
+	return &NotificationService{db: db}
+}
+
+// Create 新建通知，未读通知同时增加用户的未读数
+func (s *NotificationService) Create(notification *models.Notification) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		return createNotification(tx, notification)
+	})
+}
+
+// createNotification 在事务中新建通知并增加未读数，其他服务发送通知都经过这里，不要直接Create通知
+func createNotification(tx *gorm.DB, notification *models.Notification) error {
+	if err := tx.Create(notification).Error; err != nil {
+		return err
+	}
+	if notification.IsRead {
+		return nil
+	}
+	return tx.Model(&models.User{}).Where("id = ?", notification.UserID).
+		UpdateColumn("unread_notification_count", gorm.Expr("unread_notification_count + 1")).Error
+}
+
+// decrementUnread 减少用户的未读数，不减成负数
+func decrementUnread(tx *gorm.DB, userID uint, n int64) error {
+	if n <= 0 {
+		return nil
+	}
+	return tx.Model(&models.User{}).Where("id = ?", userID).
+		UpdateColumn("unread_notification_count",
+			gorm.Expr("CASE WHEN unread_notification_count > ? THEN unread_notification_count - ? ELSE 0 END", n, n)).Error
+}
+
+// markRead 将用户的未读通知标记为已读，返回实际标记的数量，并按该数量减少未读数
+// 条件中带 user_id，其他用户的通知ID不会被修改，也不计入数量；已读的通知不重复计数
+func markRead(tx *gorm.DB, userID uint, ids []uint) (int64, error) {
+	query := tx.Model(&models.Notification{}).Where("user_id = ? AND is_read = ?", userID, false)
+	if ids != nil {
+		query = query.Where("id IN ?", ids)
+	}
+	result := query.Updates(map[string]interface{}{"is_read": true, "read_at": time.Now()})
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	return result.RowsAffected, decrementUnread(tx, userID, result.RowsAffected)
+}
+
+// checkNotificationBatch 检查批量操作的通知ID
+func checkNotificationBatch(ids []uint) error {
+	if len(ids) == 0 {
+		return ErrValidation.WithMsg("notification.ids_required")
+	}
+	if len(ids) > maxNotificationBatch {
+		return ErrValidation.WithMsg("notification.too_many_ids", maxNotificationBatch)
+	}
+	return nil
+}
+
+// MarkRead 将用户的指定通知标记为已读，返回实际标记的数量；不属于该用户或已读的ID忽略
+func (s *NotificationService) MarkRead(userID uint, ids []uint) (int64, error) {
+	if err := checkNotificationBatch(ids); err != nil {
+		return 0, err
+	}
+	var marked int64
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		marked, err = markRead(tx, userID, ids)
+		return err
+	})
+	return marked, err
+}
+
+// MarkAllAsRead 将用户的全部未读通知标记为已读，返回标记的数量
+// 按实际标记的数量减少未读数，而不是直接置0，不会抵消并发新建的通知
+func (s *NotificationService) MarkAllAsRead(userID uint) (int64, error) {
+	var marked int64
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		marked, err = markRead(tx, userID, nil)
+		return err
+	})
+	return marked, err
+}
+
+// DeleteNotifications 删除用户的指定通知（软删除），返回删除的数量；不属于该用户的ID忽略
+// 先把其中的未读通知标记为已读，得到准确的未读数量后再删除，与并发的标记已读不会重复扣减
+func (s *NotificationService) DeleteNotifications(userID uint, ids []uint) (int64, error) {
+	if err := checkNotificationBatch(ids); err != nil {
+		return 0, err
+	}
+	var deleted int64
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if _, err := markRead(tx, userID, ids); err != nil {
+			return err
+		}
+		result := tx.Where("user_id = ? AND id IN ?", userID, ids).Delete(&models.Notification{})
+		deleted = result.RowsAffected
+		return result.Error
+	})
+	return deleted, err
+}
+
+// GetUnreadCount 读取缓存的未读通知数
+func (s *NotificationService) GetUnreadCount(userID uint) (int64, error) {
+	var user models.User
+	err := s.db.Select("id", "unread_notification_count").First(&user, userID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, ErrNotFound.WithMsg("user.not_found")
+		}
+		return 0, err
+	}
+	return user.UnreadNotificationCount, nil
+}
+
+// ReconcileUnreadCount 按通知表重新计算用户的未读数，用于修复计数偏差，返回修复后的值
+// 计数和写入在同一条UPDATE中完成，不会覆盖计算期间新建的通知
+func (s *NotificationService) ReconcileUnreadCount(userID uint) (int64, error) {
+	unread := s.db.Session(&gorm.Session{NewDB: true}).Model(&models.Notification{}).
+		Select("COUNT(*)").Where("user_id = ? AND is_read = ?", userID, false)
+	result := s.db.Model(&models.User{}).Where("id = ?", userID).UpdateColumn("unread_notification_count", unread)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return 0, ErrNotFound.WithMsg("user.not_found")
+	}
+	return s.GetUnreadCount(userID)
+}
+
+// WatchUnreadCount 每隔interval读取一次缓存的未读数，有变化时调用push，首次立即推送；ctx取消或push返回false时停止
+// 每次只按主键读一列，连接数多时也不会对通知表执行COUNT
+func (s *NotificationService) WatchUnreadCount(ctx context.Context, userID uint, interval time.Duration, push func(count int64) bool) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	last := int64(-1)
+	for {
+		count, err := s.GetUnreadCount(userID)
+		if err != nil {
+			return err
+		}
+		if count != last {
+			if !push(count) {
+				return nil
+			}
+			last = count
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
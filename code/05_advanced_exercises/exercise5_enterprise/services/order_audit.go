@@ -0,0 +1,225 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"edu-platform/models"
+)
+
+const (
+	defaultMismatchLimit = 100  // 不一致订单默认返回的数量
+	maxMismatchLimit     = 1000 // 不一致订单最多返回的数量
+)
+
+// OrderRepairStrategy 订单金额不一致时的修复方式
+type OrderRepairStrategy string
+
+const (
+	// RepairTrustItems 以订单项为准：订单总额改为订单项合计，优惠金额和实付金额随之调整
+	RepairTrustItems OrderRepairStrategy = "trust-items"
+	// RepairTrustOrder 以订单为准：订单金额不变，写入一条调整行补齐差额
+	RepairTrustOrder OrderRepairStrategy = "trust-order"
+)
+
+// OrderAuditService 订单金额一致性检查：订单总额应等于订单项价格合计加调整金额合计
+type OrderAuditService struct {
+	db *gorm.DB
+}
+
+// NewOrderAuditService 创建订单金额一致性检查服务
+func NewOrderAuditService(db *gorm.DB) *OrderAuditService {
+	return &OrderAuditService{db: db}
+}
+
+// OrderMismatch 金额不一致的订单
+type OrderMismatch struct {
+	OrderID         models.OrderID     `json:"order_id"`
+	OrderNo         string             `json:"order_no"`
+	Status          models.OrderStatus `json:"status"`
+	TotalAmount     int64              `json:"total_amount"`     // 订单总额
+	ItemsTotal      int64              `json:"items_total"`      // 订单项价格合计
+	AdjustmentTotal int64              `json:"adjustment_total"` // 调整金额合计
+	Difference      int64              `json:"difference"`       // 订单总额 - 订单项合计 - 调整金额合计
+}
+
+// OrderAmounts 订单的金额，修复前后各记录一份
+type OrderAmounts struct {
+	TotalAmount     int64 `json:"total_amount"`
+	DiscountAmount  int64 `json:"discount_amount"`
+	PayAmount       int64 `json:"pay_amount"`
+	ItemsTotal      int64 `json:"items_total"`
+	AdjustmentTotal int64 `json:"adjustment_total"`
+}
+
+// OrderRepair 修复结果
+type OrderRepair struct {
+	OrderID  models.OrderID      `json:"order_id"`
+	Strategy OrderRepairStrategy `json:"strategy"`
+	Before   OrderAmounts        `json:"before"`
+	After    OrderAmounts        `json:"after"`
+}
+
+// adjustmentTotalSQL 订单调整金额合计的标量子查询，外层查询的订单表别名须为 orders
+func adjustmentTotalSQL(db *gorm.DB) string {
+	return "COALESCE((SELECT SUM(oa.amount) FROM " + models.Table(db, "order_adjustments") +
+		" oa WHERE oa.order_id = orders.id AND oa.deleted_at IS NULL), 0)"
+}
+
+// FindMismatchedOrders 查询总额与订单项合计（加调整金额）不一致的订单，按订单ID排序，最多limit条
+// 一条 GROUP BY ... HAVING 查询完成；每个订单项对应一门课程，没有数量，合计即 SUM(price)
+func (s *OrderAuditService) FindMismatchedOrders(limit int) ([]OrderMismatch, error) {
+	if limit <= 0 {
+		limit = defaultMismatchLimit
+	}
+	if limit > maxMismatchLimit {
+		limit = maxMismatchLimit
+	}
+
+	adjustments := adjustmentTotalSQL(s.db)
+	mismatches := []OrderMismatch{}
+	err := s.db.Table(models.TableAs(s.db, "orders")).
+		Select("orders.id AS order_id, orders.order_no, orders.status, orders.total_amount, " +
+			"COALESCE(SUM(order_items.price), 0) AS items_total, " + adjustments + " AS adjustment_total").
+		Joins("LEFT JOIN " + models.TableAs(s.db, "order_items") +
+			" ON order_items.order_id = orders.id AND order_items.deleted_at IS NULL").
+		Where("orders.deleted_at IS NULL").
+		Group("orders.id, orders.order_no, orders.status, orders.total_amount").
+		Having("orders.total_amount <> COALESCE(SUM(order_items.price), 0) + " + adjustments).
+		Order("orders.id").
+		Limit(limit).
+		Scan(&mismatches).Error
+	if err != nil {
+		return nil, err
+	}
+	for i := range mismatches {
+		m := &mismatches[i]
+		m.Difference = m.TotalAmount - m.ItemsTotal - m.AdjustmentTotal
+	}
+	return mismatches, nil
+}
+
+// RepairOrder 按strategy修复订单金额，修复前后的金额写入操作日志
+//   - trust-items：订单总额改为订单项合计加调整金额；未付款的订单优惠金额不超过总额，实付金额为总额减优惠；
+//     已付款的订单实付金额是实际收到的钱，保持不变，优惠金额改为总额减实付，订单项合计低于实付金额时无法按此方式修复
+//   - trust-order：订单金额不变，写入一条调整行，金额为差额（可为负）
+//
+// 已付款、已完成、已退款的订单须传 confirm=true 确认；userID为操作的管理员，actorID为实际操作人（模拟登录时不同）
+func (s *OrderAuditService) RepairOrder(orderID models.OrderID, strategy OrderRepairStrategy, confirm bool, userID, actorID uint) (*OrderRepair, error) {
+	if strategy != RepairTrustItems && strategy != RepairTrustOrder {
+		return nil, ErrValidation.WithMsg("order_audit.invalid_strategy", string(RepairTrustItems), string(RepairTrustOrder))
+	}
+
+	repair := &OrderRepair{OrderID: orderID, Strategy: strategy}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var order models.Order
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&order, "id = ?", orderID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrNotFound.WithMsg("order.not_found")
+			}
+			return err
+		}
+		if (order.Status.IsPaid() || order.Status == models.OrderStatusRefunded) && !confirm {
+			return ErrValidation.WithMsg("order_audit.confirm_required")
+		}
+
+		before, err := orderAmounts(tx, &order)
+		if err != nil {
+			return err
+		}
+		diff := before.TotalAmount - before.ItemsTotal - before.AdjustmentTotal
+		if diff == 0 {
+			return ErrConflict.WithMsg("order_audit.consistent")
+		}
+
+		after := before
+		switch strategy {
+		case RepairTrustItems:
+			after.TotalAmount = before.ItemsTotal + before.AdjustmentTotal
+			if order.Status == models.OrderStatusPending || order.Status == models.OrderStatusCancelled {
+				if after.DiscountAmount > after.TotalAmount {
+					after.DiscountAmount = after.TotalAmount
+				}
+				after.PayAmount = after.TotalAmount - after.DiscountAmount
+			} else {
+				if after.TotalAmount < after.PayAmount {
+					return ErrConflict.WithMsg("order_audit.items_below_paid").WithDetails(map[string]interface{}{
+						"items_total": after.TotalAmount,
+						"pay_amount":  after.PayAmount,
+					})
+				}
+				after.DiscountAmount = after.TotalAmount - after.PayAmount
+			}
+			if err := tx.Model(&order).Updates(map[string]interface{}{
+				"total_amount":    after.TotalAmount,
+				"discount_amount": after.DiscountAmount,
+				"pay_amount":      after.PayAmount,
+			}).Error; err != nil {
+				return err
+			}
+		case RepairTrustOrder:
+			adjustment := models.OrderAdjustment{
+				OrderID:   order.ID,
+				Amount:    diff,
+				Reason:    fmt.Sprintf("订单总额与订单项合计不一致，按订单总额补齐差额（订单项合计%d，调整前调整金额%d）", before.ItemsTotal, before.AdjustmentTotal),
+				CreatedBy: actorID,
+			}
+			if err := tx.Create(&adjustment).Error; err != nil {
+				return err
+			}
+			after.AdjustmentTotal += diff
+		}
+
+		repair.Before, repair.After = before, after
+		return recordOrderRepair(tx, repair, userID, actorID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return repair, nil
+}
+
+// orderAmounts 订单的金额和订单项、调整行的合计
+func orderAmounts(tx *gorm.DB, order *models.Order) (OrderAmounts, error) {
+	amounts := OrderAmounts{
+		TotalAmount:    order.TotalAmount,
+		DiscountAmount: order.DiscountAmount,
+		PayAmount:      order.PayAmount,
+	}
+	if err := tx.Model(&models.OrderItem{}).Where("order_id = ?", order.ID).
+		Select("COALESCE(SUM(price), 0)").Scan(&amounts.ItemsTotal).Error; err != nil {
+		return amounts, err
+	}
+	if err := tx.Model(&models.OrderAdjustment{}).Where("order_id = ?", order.ID).
+		Select("COALESCE(SUM(amount), 0)").Scan(&amounts.AdjustmentTotal).Error; err != nil {
+		return amounts, err
+	}
+	return amounts, nil
+}
+
+// recordOrderRepair 在修复订单的事务中写入操作日志，Request 为修复方式和修复前的金额，Response 为修复后的金额
+func recordOrderRepair(tx *gorm.DB, repair *OrderRepair, userID, actorID uint) error {
+	request, err := json.Marshal(map[string]interface{}{"strategy": repair.Strategy, "before": repair.Before})
+	if err != nil {
+		return err
+	}
+	response, err := json.Marshal(map[string]interface{}{"after": repair.After})
+	if err != nil {
+		return err
+	}
+	return tx.Create(&models.SystemLog{
+		UserID:   &userID,
+		ActorID:  &actorID,
+		Action:   "order.repair." + string(repair.Strategy),
+		Module:   "order_audit",
+		Method:   "SYSTEM",
+		URL:      fmt.Sprintf("order:%v", repair.OrderID),
+		Request:  string(request),
+		Response: string(response),
+		Status:   200,
+	}).Error
+}
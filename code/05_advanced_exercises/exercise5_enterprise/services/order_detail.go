@@ -0,0 +1,73 @@
+package services
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+
+	"edu-platform/models"
+)
+
+// OrderDetail 订单详情，订单项（及其课程）和优惠券按 include 加载，未加载的不出现在响应中
+type OrderDetail struct {
+	*models.Order
+	Items *[]OrderItemDetail `json:"items,omitempty"`
+}
+
+// OrderItemDetail 订单详情中的订单项
+// 名称、封面、讲师、分类以订单项上的下单时快照为准；course 是课程的当前信息，
+// 未加载或课程已被删除时不返回（设置 association.dangling_mode 为 placeholder 时返回占位课程）
+type OrderItemDetail struct {
+	*models.OrderItem
+	Course *models.Course `json:"course,omitempty"`
+}
+
+// GetUserOrder 按订单号查询用户自己的订单，只预加载includes中的关联
+func (s *OrderService) GetUserOrder(userID uint, orderNo string, includes Includes) (*OrderDetail, error) {
+	var order models.Order
+	err := includes.Preload(s.db).Where("order_no = ? AND user_id = ?", orderNo, userID).First(&order).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound.WithMsg("order.not_found")
+		}
+		return nil, err
+	}
+
+	if includes.Has("items.course") {
+		fillOrderItemPlaceholders(s.db, &order)
+	}
+	return newOrderDetail(&order, includes), nil
+}
+
+// OrderDetails 把订单列表转为订单详情，订单须预加载订单项及其课程（SearchUserOrders 的结果）
+// 课程已被删除的订单项不返回 course，不会出现 id 为0的空课程
+func (s *OrderService) OrderDetails(orders []models.Order) []OrderDetail {
+	includes := DefaultOrderIncludes()
+	loaded := make([]*models.Order, len(orders))
+	for i := range orders {
+		loaded[i] = &orders[i]
+	}
+	fillOrderItemPlaceholders(s.db, loaded...)
+
+	details := make([]OrderDetail, len(orders))
+	for i := range orders {
+		details[i] = *newOrderDetail(&orders[i], includes)
+	}
+	return details
+}
+
+// newOrderDetail 按已加载的关联构造订单详情
+func newOrderDetail(order *models.Order, includes Includes) *OrderDetail {
+	detail := &OrderDetail{Order: order}
+	if includes.Has("items") {
+		items := make([]OrderItemDetail, len(order.Items))
+		for i := range order.Items {
+			items[i] = OrderItemDetail{OrderItem: &order.Items[i]}
+			if includes.Has("items.course") && order.Items[i].Course.ID != 0 {
+				items[i].Course = &order.Items[i].Course
+			}
+		}
+		detail.Items = &items
+	}
+	return detail
+}
@@ -0,0 +1,132 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"edu-platform/models"
+)
+
+// ReviewPromptItem 评价提醒中待评价的课程
+type ReviewPromptItem struct {
+	CourseID   uint   `json:"course_id"`
+	CourseName string `json:"course_name"`
+}
+
+// ConfirmReceipt 确认收货，买家将自己已付款的订单改为已完成
+// 课程订单没有单独的待收货状态，已付款即视为已交付，确认后状态从2（已付款）变为3（已完成）；
+// 订单已完成时直接返回，重复调用不会报错，也不会重复发送评价提醒
+func (s *OrderService) ConfirmReceipt(orderNo string, userID uint) (*models.Order, error) {
+	tx := s.db.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	// 锁定订单，防止与退款或重复确认并发
+	var order models.Order
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("order_no = ?", orderNo).First(&order).Error; err != nil {
+		tx.Rollback()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound.WithMsg("order.not_found")
+		}
+		return nil, err
+	}
+
+	if order.UserID != userID {
+		tx.Rollback()
+		return nil, ErrForbidden.WithMsg("order.forbidden")
+	}
+
+	if order.Status == models.OrderStatusCompleted {
+		tx.Rollback()
+		return s.loadOrder(order.ID)
+	}
+	if !order.Status.CanTransitionTo(models.OrderStatusCompleted) {
+		tx.Rollback()
+		return nil, ErrConflict.WithMsg("order.not_receivable")
+	}
+
+	now := time.Now()
+	result := tx.Model(&models.Order{}).Where("id = ? AND status = ?", order.ID, models.OrderStatusPaid).Updates(map[string]interface{}{
+		"status":      models.OrderStatusCompleted,
+		"finished_at": &now,
+	})
+	if result.Error != nil {
+		tx.Rollback()
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		tx.Rollback()
+		return nil, ErrConflict.WithMsg("order.not_receivable")
+	}
+
+	if err := s.createReviewPrompt(tx, &order); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, err
+	}
+	return s.loadOrder(order.ID)
+}
+
+// reviewPromptItems 查询订单中用户尚未评价的课程（已退款的订单项除外）
+func (s *OrderService) reviewPromptItems(db *gorm.DB, orderID models.OrderID, userID uint) ([]ReviewPromptItem, error) {
+	var items []ReviewPromptItem
+	err := db.Table(models.Table(db, "order_items")+" oi").
+		Select("oi.course_id, oi.course_name").
+		Joins("LEFT JOIN "+models.Table(db, "course_reviews")+" cr ON cr.course_id = oi.course_id AND cr.user_id = ? AND cr.deleted_at IS NULL", userID).
+		Where("oi.order_id = ? AND oi.refund_id IS NULL AND oi.deleted_at IS NULL AND cr.id IS NULL", orderID).
+		Order("oi.id").
+		Scan(&items).Error
+	return items, err
+}
+
+// createReviewPrompt 为尚未评价的课程创建评价提醒通知，全部评价过时不发送
+func (s *OrderService) createReviewPrompt(tx *gorm.DB, order *models.Order) error {
+	items, err := s.reviewPromptItems(tx, order.ID, order.UserID)
+	if err != nil {
+		return err
+	}
+	if len(items) == 0 {
+		return nil
+	}
+
+	names := make([]string, len(items))
+	for i, item := range items {
+		names[i] = "《" + item.CourseName + "》"
+	}
+	data, err := json.Marshal(map[string]interface{}{
+		"order_no": order.OrderNo,
+		"courses":  items,
+	})
+	if err != nil {
+		return err
+	}
+
+	return createNotification(tx, &models.Notification{
+		UserID:  order.UserID,
+		Title:   "请评价您购买的课程",
+		Content: "订单" + order.OrderNo + "已完成，欢迎评价：" + strings.Join(names, "、"),
+		Type:    3, // 订单通知
+		Data:    string(data),
+	})
+}
+
+// loadOrder 查询订单及订单项
+func (s *OrderService) loadOrder(orderID models.OrderID) (*models.Order, error) {
+	var order models.Order
+	if err := s.db.Preload("Items").First(&order, "id = ?", orderID).Error; err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
@@ -0,0 +1,210 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"edu-platform/models"
+)
+
+// RefundOrder 订单全额退款
+// 退还所有尚未退款的订单项，撤销对应的选课记录并扣减课程学生数
+// 收入和销量没有单独的统计表，由订单的 refund_amount 和订单项的 refund_id 计算（见 paidSalesSQL），
+// 二者与退款记录在同一事务中更新
+func (s *OrderService) RefundOrder(orderID models.OrderID, reason string) error {
+	return s.refund(orderID, nil, reason)
+}
+
+// RefundItems 订单部分退款
+// 只退还指定的订单项，全部订单项退完后订单状态变为已退款
+func (s *OrderService) RefundItems(orderID models.OrderID, itemIDs []uint) error {
+	if len(itemIDs) == 0 {
+		return ErrValidation.WithMsg("order.refund_items_required")
+	}
+	return s.refund(orderID, itemIDs, "部分退款")
+}
+
+// refund 退款的公共流程，itemIDs为nil表示全额退款
+func (s *OrderService) refund(orderID models.OrderID, itemIDs []uint, reason string) error {
+	tx := s.db.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	// 锁定订单，防止并发退款
+	var order models.Order
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&order, "id = ?", orderID).Error; err != nil {
+		tx.Rollback()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrNotFound.WithMsg("order.not_found")
+		}
+		return err
+	}
+
+	// 已全额退款的订单不能再次退款；除此之外只有已付款或已完成的订单可以退款
+	if order.Status == models.OrderStatusRefunded {
+		tx.Rollback()
+		return ErrConflict.WithMsg("order.fully_refunded")
+	}
+	if !order.Status.IsPaid() {
+		tx.Rollback()
+		return ErrConflict.WithMsg("order.not_paid")
+	}
+
+	// 查询待退款的订单项（已退款的订单项不能重复退款）
+	var items []models.OrderItem
+	query := tx.Where("order_id = ? AND refund_id IS NULL", order.ID)
+	if itemIDs != nil {
+		query = query.Where("id IN ?", itemIDs)
+	}
+	if err := query.Find(&items).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if len(items) == 0 {
+		tx.Rollback()
+		return ErrConflict.WithMsg("order.fully_refunded")
+	}
+	if itemIDs != nil && len(items) != len(itemIDs) {
+		tx.Rollback()
+		return ErrNotFound.WithMsg("order.refund_items_invalid")
+	}
+
+	// 剩余未退款的订单项数量，用于判断本次是否退完
+	var remaining int64
+	if err := tx.Model(&models.OrderItem{}).
+		Where("order_id = ? AND refund_id IS NULL", order.ID).Count(&remaining).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	fullRefund := int64(len(items)) == remaining
+
+	// 计算退款金额：退完全部订单项时退还剩余实付金额，否则按订单项实付价计算
+	refundable := order.PayAmount - order.RefundAmount
+	var amount int64
+	if fullRefund {
+		amount = refundable
+	} else {
+		for _, item := range items {
+			amount += item.Price - item.DiscountAmount
+		}
+		if amount > refundable {
+			amount = refundable
+		}
+	}
+
+	// 退完剩余全部订单项的退款记为全额退款（含先部分退款、再退余下部分的情况）
+	refundType := models.RefundTypePartial
+	if fullRefund {
+		refundType = models.RefundTypeFull
+	}
+
+	// 退款单号按月顺序编号，与发票号一样由计数器分配，并发退款不会重复
+	now := time.Now()
+	seq, err := nextDocumentNo(tx, "refund:"+now.Format(invoiceMonthLayout))
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	refund := models.Refund{
+		RefundNo: fmt.Sprintf("RF-%s-%06d", now.Format(invoiceMonthLayout), seq),
+		OrderID:  order.ID,
+		UserID:   order.UserID,
+		Amount:   amount,
+		Type:     refundType,
+		Reason:   reason,
+	}
+	if err := tx.Create(&refund).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	itemIDList := make([]uint, 0, len(items))
+	for _, item := range items {
+		itemIDList = append(itemIDList, item.ID)
+	}
+
+	// 标记订单项已退款
+	if err := tx.Model(&models.OrderItem{}).Where("id IN ?", itemIDList).
+		Update("refund_id", refund.ID).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	// 撤销订单项开通的选课记录
+	var enrollments []models.Enrollment
+	if err := tx.Where("order_item_id IN ? AND status = ?", itemIDList, models.EnrollmentStatusActive).Find(&enrollments).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if len(enrollments) > 0 {
+		if err := tx.Model(&models.Enrollment{}).Where("order_item_id IN ? AND status = ?", itemIDList, models.EnrollmentStatusActive).
+			Updates(map[string]interface{}{
+				"status":     models.EnrollmentStatusRevoked,
+				"revoked_at": &now,
+			}).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		// 调整课程学生数量
+		for _, enrollment := range enrollments {
+			if err := tx.Model(&models.Course{}).Where("id = ? AND student_count > 0", enrollment.CourseID).
+				Update("student_count", gorm.Expr("student_count - ?", 1)).Error; err != nil {
+				tx.Rollback()
+				return err
+			}
+		}
+
+		// 退出的名额通知候补用户
+		for _, enrollment := range enrollments {
+			if _, err := offerSeats(tx, enrollment.CourseID, now); err != nil {
+				tx.Rollback()
+				return err
+			}
+		}
+
+		// 撤销已签发的结业证书，验证时显示已撤销
+		courseIDs := make([]uint, 0, len(enrollments))
+		for _, enrollment := range enrollments {
+			courseIDs = append(courseIDs, enrollment.CourseID)
+		}
+		if err := revokeCertificates(tx, order.UserID, courseIDs, now); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	// 更新订单退款金额和状态
+	updates := map[string]interface{}{
+		"refund_amount": gorm.Expr("refund_amount + ?", amount),
+		"refund_reason": reason,
+	}
+	if fullRefund {
+		updates["status"] = models.OrderStatusRefunded
+		updates["refunded_at"] = &now
+	}
+	if err := tx.Model(&order).Updates(updates).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := addOutboxEvent(tx, EventOrderRefunded, fmt.Sprint(order.ID), map[string]interface{}{
+		"refund_id": refund.ID,
+		"refund_no": refund.RefundNo,
+		"amount":    amount,
+	}); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit().Error
+}
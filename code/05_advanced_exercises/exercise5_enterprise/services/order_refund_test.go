@@ -0,0 +1,202 @@
+package services_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+
+	"edu-platform/models"
+	"edu-platform/services"
+	"edu-platform/testhelpers"
+	"edu-platform/testhelpers/factory"
+)
+
+// TestRefundItemsThenRest 先部分退款一个订单项，再全额退款余下部分：
+// 退款记录依次为部分退款、全额退款，选课记录、学生数和订单金额随之调整
+func TestRefundItemsThenRest(t *testing.T) {
+	t.Parallel()
+	db := testhelpers.NewDB(t)
+	f := factory.New(t, db)
+	orders := services.NewOrderService(db)
+
+	student := f.User("student")
+	first, second := f.Course(19900), f.Course(24900)
+	order := f.PaidOrder(student.ID, first.ID, second.ID)
+	itemFor := map[uint]uint{}
+	for _, item := range order.Items {
+		itemFor[item.CourseID] = item.ID
+	}
+
+	if err := orders.RefundItems(order.ID, []uint{itemFor[first.ID]}); err != nil {
+		t.Fatalf("部分退款失败: %v", err)
+	}
+	refunds := loadRefunds(t, db, order.ID)
+	if len(refunds) != 1 || refunds[0].Type != models.RefundTypePartial || refunds[0].Amount != 19900 {
+		t.Fatalf("部分退款后的退款记录为 %s，期望 [type=2 amount=19900]", describeRefunds(refunds))
+	}
+	after := loadOrder(t, db, order.ID)
+	if after.Status != models.OrderStatusPaid || after.RefundAmount != 19900 {
+		t.Errorf("部分退款后订单 status=%s refund_amount=%d，期望 paid 和 19900", after.Status, after.RefundAmount)
+	}
+	assertEnrollmentStatus(t, db, itemFor[first.ID], models.EnrollmentStatusRevoked)
+	assertEnrollmentStatus(t, db, itemFor[second.ID], models.EnrollmentStatusActive)
+	assertStudentCount(t, db, first.ID, 0)
+	assertStudentCount(t, db, second.ID, 1)
+
+	if err := orders.RefundOrder(order.ID, "不想学了"); err != nil {
+		t.Fatalf("退还余下部分失败: %v", err)
+	}
+	refunds = loadRefunds(t, db, order.ID)
+	if len(refunds) != 2 || refunds[1].Type != models.RefundTypeFull || refunds[1].Amount != 24900 {
+		t.Fatalf("退款记录为 %s，期望第二条为 type=1 amount=24900", describeRefunds(refunds))
+	}
+	after = loadOrder(t, db, order.ID)
+	if after.Status != models.OrderStatusRefunded || after.RefundedAt == nil {
+		t.Errorf("全部退款后订单 status=%s refunded_at=%v，期望已退款", after.Status, after.RefundedAt)
+	}
+	// 净收入 = 实付金额 - 已退款金额
+	if after.PayAmount-after.RefundAmount != 0 {
+		t.Errorf("全部退款后净收入为 %d，期望 0", after.PayAmount-after.RefundAmount)
+	}
+	assertEnrollmentStatus(t, db, itemFor[second.ID], models.EnrollmentStatusRevoked)
+	assertStudentCount(t, db, second.ID, 0)
+}
+
+// TestRefundOrderTwice 已全额退款的订单再次退款时返回 order.fully_refunded，不产生新的退款记录
+func TestRefundOrderTwice(t *testing.T) {
+	t.Parallel()
+	db := testhelpers.NewDB(t)
+	f := factory.New(t, db)
+	orders := services.NewOrderService(db)
+
+	order := f.PaidOrder(f.User("student").ID, f.Course(9900).ID)
+	if err := orders.RefundOrder(order.ID, "重复购买"); err != nil {
+		t.Fatalf("全额退款失败: %v", err)
+	}
+	refunds := loadRefunds(t, db, order.ID)
+	if len(refunds) != 1 || refunds[0].Type != models.RefundTypeFull || refunds[0].Amount != order.PayAmount {
+		t.Fatalf("退款记录为 %s，期望 [type=1 amount=%d]", describeRefunds(refunds), order.PayAmount)
+	}
+
+	assertAppError(t, orders.RefundOrder(order.ID, "重复购买"), services.ErrConflict, "order.fully_refunded")
+	assertAppError(t, orders.RefundItems(order.ID, []uint{order.Items[0].ID}), services.ErrConflict, "order.fully_refunded")
+	if refunds := loadRefunds(t, db, order.ID); len(refunds) != 1 {
+		t.Errorf("再次退款后有 %d 条退款记录，期望 1 条", len(refunds))
+	}
+}
+
+// TestRefundUnpaidOrder 未付款的订单不能退款
+func TestRefundUnpaidOrder(t *testing.T) {
+	t.Parallel()
+	db := testhelpers.NewDB(t)
+	f := factory.New(t, db)
+	orders := services.NewOrderService(db)
+
+	order := f.PendingOrder(f.User("student").ID, f.Course(9900).ID)
+	assertAppError(t, orders.RefundOrder(order.ID, "未付款"), services.ErrConflict, "order.not_paid")
+
+	if refunds := loadRefunds(t, db, order.ID); len(refunds) != 0 {
+		t.Errorf("未付款订单产生了 %d 条退款记录", len(refunds))
+	}
+	if after := loadOrder(t, db, order.ID); after.Status != models.OrderStatusPending || after.RefundAmount != 0 {
+		t.Errorf("订单 status=%s refund_amount=%d，期望保持待付款", after.Status, after.RefundAmount)
+	}
+}
+
+// TestRefundNumbersAreSequential 同月的退款单号按顺序分配，同一时刻的多笔退款也不会重复
+func TestRefundNumbersAreSequential(t *testing.T) {
+	t.Parallel()
+	db := testhelpers.NewDB(t)
+	f := factory.New(t, db)
+	orders := services.NewOrderService(db)
+
+	student := f.User("student")
+	first, second := f.Course(19900), f.Course(24900)
+	order := f.PaidOrder(student.ID, first.ID, second.ID)
+	if err := orders.RefundItems(order.ID, []uint{order.Items[0].ID}); err != nil {
+		t.Fatalf("部分退款失败: %v", err)
+	}
+	if err := orders.RefundOrder(order.ID, "不想学了"); err != nil {
+		t.Fatalf("全额退款失败: %v", err)
+	}
+	other := f.PaidOrder(student.ID, f.Course(9900).ID)
+	if err := orders.RefundOrder(other.ID, "重复购买"); err != nil {
+		t.Fatalf("全额退款失败: %v", err)
+	}
+
+	var numbers []string
+	if err := db.Model(&models.Refund{}).Order("id").Pluck("refund_no", &numbers).Error; err != nil {
+		t.Fatalf("查询退款单号失败: %v", err)
+	}
+	month := time.Now().Format("200601")
+	want := []string{"RF-" + month + "-000001", "RF-" + month + "-000002", "RF-" + month + "-000003"}
+	if strings.Join(numbers, ",") != strings.Join(want, ",") {
+		t.Errorf("退款单号为 %v，期望 %v", numbers, want)
+	}
+}
+
+func loadOrder(t *testing.T, db *gorm.DB, id models.OrderID) models.Order {
+	t.Helper()
+	var order models.Order
+	if err := db.First(&order, "id = ?", id).Error; err != nil {
+		t.Fatalf("查询订单失败: %v", err)
+	}
+	return order
+}
+
+// loadRefunds 订单的退款记录，按创建顺序排列
+func loadRefunds(t *testing.T, db *gorm.DB, orderID models.OrderID) []models.Refund {
+	t.Helper()
+	var refunds []models.Refund
+	if err := db.Where("order_id = ?", orderID).Order("id").Find(&refunds).Error; err != nil {
+		t.Fatalf("查询退款记录失败: %v", err)
+	}
+	return refunds
+}
+
+// describeRefunds 退款记录的类型和金额，用于错误信息
+func describeRefunds(refunds []models.Refund) string {
+	parts := make([]string, 0, len(refunds))
+	for _, r := range refunds {
+		parts = append(parts, fmt.Sprintf("type=%d amount=%d", r.Type, r.Amount))
+	}
+	return "[" + strings.Join(parts, ", ") + "]"
+}
+
+func assertEnrollmentStatus(t *testing.T, db *gorm.DB, orderItemID uint, want models.EnrollmentStatus) {
+	t.Helper()
+	var enrollment models.Enrollment
+	if err := db.Where("order_item_id = ?", orderItemID).First(&enrollment).Error; err != nil {
+		t.Fatalf("查询订单项 %d 的选课记录失败: %v", orderItemID, err)
+	}
+	if enrollment.Status != want {
+		t.Errorf("订单项 %d 的选课记录状态为 %d，期望 %d", orderItemID, enrollment.Status, want)
+	}
+}
+
+func assertStudentCount(t *testing.T, db *gorm.DB, courseID uint, want int) {
+	t.Helper()
+	var course models.Course
+	if err := db.Select("id", "student_count").First(&course, courseID).Error; err != nil {
+		t.Fatalf("查询课程失败: %v", err)
+	}
+	if course.StudentCount != want {
+		t.Errorf("课程 %d 的学生数为 %d，期望 %d", courseID, course.StudentCount, want)
+	}
+}
+
+// assertAppError err 为指定类型和消息的业务错误
+func assertAppError(t *testing.T, err error, kind *services.AppError, msgID string) {
+	t.Helper()
+	var appErr *services.AppError
+	if !errors.As(err, &appErr) || !errors.Is(err, kind) {
+		t.Fatalf("期望 %d 错误 %s，实际 %v", kind.Code, msgID, err)
+	}
+	if appErr.MsgID != msgID {
+		t.Errorf("错误为 %s，期望 %s", appErr.MsgID, msgID)
+	}
+}
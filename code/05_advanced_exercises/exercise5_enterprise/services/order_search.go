@@ -0,0 +1,104 @@
+package services
+
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"edu-platform/models"
+)
+
+// OrderSearchParams 用户订单列表的筛选条件，零值的条件不生效
+type OrderSearchParams struct {
+	Statuses    []models.OrderStatus // 订单状态，多个为“或”
+	CreatedFrom *time.Time           // 下单时间 >= CreatedFrom
+	CreatedTo   *time.Time           // 下单时间 < CreatedTo
+	MinAmount   *int64               // 实付金额下限（分，含）
+	MaxAmount   *int64               // 实付金额上限（分，含）
+	Keyword     string               // 匹配订单号，或订单中的课程名称
+	Pagination
+}
+
+// scopes 筛选条件对应的查询作用域，总数查询和列表查询使用同一组作用域，条件不会只加到其中一个
+// 条件中的列都带 orders. 限定，查询须以 models.TableAs(db, "orders") 为表
+func (p OrderSearchParams) scopes(db *gorm.DB, userID uint) []func(*gorm.DB) *gorm.DB {
+	scopes := []func(*gorm.DB) *gorm.DB{
+		func(q *gorm.DB) *gorm.DB { return q.Where("orders.user_id = ?", userID) },
+	}
+	if len(p.Statuses) > 0 {
+		scopes = append(scopes, func(q *gorm.DB) *gorm.DB { return q.Where("orders.status IN ?", p.Statuses) })
+	}
+	if p.CreatedFrom != nil {
+		scopes = append(scopes, func(q *gorm.DB) *gorm.DB { return q.Where("orders.created_at >= ?", *p.CreatedFrom) })
+	}
+	if p.CreatedTo != nil {
+		scopes = append(scopes, func(q *gorm.DB) *gorm.DB { return q.Where("orders.created_at < ?", *p.CreatedTo) })
+	}
+	if p.MinAmount != nil {
+		scopes = append(scopes, func(q *gorm.DB) *gorm.DB { return q.Where("orders.pay_amount >= ?", *p.MinAmount) })
+	}
+	if p.MaxAmount != nil {
+		scopes = append(scopes, func(q *gorm.DB) *gorm.DB { return q.Where("orders.pay_amount <= ?", *p.MaxAmount) })
+	}
+	if keyword := strings.TrimSpace(p.Keyword); keyword != "" {
+		// 转义LIKE通配符，用户输入只作为普通文本匹配
+		pattern := "%" + strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(keyword) + "%"
+		// 课程名称用 EXISTS 子查询匹配，不JOIN订单项，订单不会因多个订单项匹配而重复
+		items := db.Session(&gorm.Session{NewDB: true}).Table(models.TableAs(db, "order_items")).Select("1").
+			Where("order_items.order_id = orders.id AND order_items.deleted_at IS NULL").
+			Where("order_items.course_name LIKE ? ESCAPE '!'", pattern)
+		scopes = append(scopes, func(q *gorm.DB) *gorm.DB {
+			return q.Where("orders.order_no LIKE ? ESCAPE '!' OR EXISTS (?)", pattern, items)
+		})
+	}
+	return scopes
+}
+
+// SearchUserOrders 按条件分页查询用户的订单，按下单时间倒序（游标分页时按id倒序），预加载订单项的课程和优惠券
+// 订单使用UUID主键时不支持游标分页
+func (s *OrderService) SearchUserOrders(userID uint, params OrderSearchParams) ([]models.Order, int64, error) {
+	if params.Page < 1 {
+		params.Page = 1
+	}
+	if params.PageSize < 1 {
+		params.PageSize = 20
+	}
+	if models.OrderUUID && params.Keyset() {
+		return nil, 0, ErrInvalidParam.WithMsg("error.invalid_param", "after_id").WithDetails(map[string]interface{}{"field": "after_id"})
+	}
+
+	var orders []models.Order
+	var total int64
+
+	query := s.db.Model(&models.Order{}).Table(models.TableAs(s.db, "orders")).
+		Scopes(params.scopes(s.db, userID)...)
+
+	// 获取总数
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// 本页订单较多时分批预加载订单项，避免超大的 IN 子句；游标分页时无法预先算出本页数量，按整页估计
+	expected := int64(params.PageSize)
+	if !params.Keyset() && total-int64(params.Offset()) < expected {
+		expected = total - int64(params.Offset())
+	}
+
+	query, err := Paginate(query, params.Pagination, "orders.id", "orders.created_at DESC")
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if expected > preloadBatchThreshold {
+		if err := query.Preload("Coupon").Find(&orders).Error; err != nil {
+			return nil, 0, err
+		}
+		return orders, total, PreloadBatched(s.db, "Items.Course", defaultPreloadChunk)(&orders)
+	}
+
+	// 分页查询
+	err = query.Preload("Items.Course").Preload("Coupon").Find(&orders).Error
+
+	return orders, total, err
+}
@@ -0,0 +1,113 @@
+package services_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+
+	"edu-platform/models"
+	"edu-platform/services"
+	"edu-platform/testhelpers"
+	"edu-platform/testhelpers/factory"
+)
+
+// TestSearchUserOrders 关键词、状态、金额和下单时间的组合筛选，结果按下单时间倒序，总数与列表一致
+func TestSearchUserOrders(t *testing.T) {
+	t.Parallel()
+	db := testhelpers.NewDB(t)
+	f := factory.New(t, db)
+	orders := services.NewOrderService(db)
+
+	student := f.User("student")
+	day := func(d int) time.Time { return time.Date(2024, 6, d, 10, 0, 0, 0, time.Local) }
+	seeded := map[string]*models.Order{
+		"go":    searchableOrder(t, f, db, student.ID, "Go语言入门", 9900, day(1), true),
+		"wild":  searchableOrder(t, f, db, student.ID, "Go_100%实战", 19900, day(10), false),
+		"react": searchableOrder(t, f, db, student.ID, "React前端", 29900, day(20), true),
+	}
+	// 其他用户的订单任何条件下都不出现
+	searchableOrder(t, f, db, f.User("student").ID, "Go语言入门", 9900, day(10), true)
+
+	amount := func(v int64) *int64 { return &v }
+	date := func(d int) *time.Time { v := day(d); return &v }
+	paid, pending := models.OrderStatusPaid, models.OrderStatusPending
+
+	cases := []struct {
+		name   string
+		params services.OrderSearchParams
+		want   []string // 按下单时间倒序
+	}{
+		{"no_filters", services.OrderSearchParams{}, []string{"react", "wild", "go"}},
+		{"status", services.OrderSearchParams{Statuses: []models.OrderStatus{paid}}, []string{"react", "go"}},
+		{"statuses_or", services.OrderSearchParams{Statuses: []models.OrderStatus{paid, pending}}, []string{"react", "wild", "go"}},
+		{"keyword_course_name", services.OrderSearchParams{Keyword: "Go"}, []string{"wild", "go"}},
+		{"keyword_trimmed", services.OrderSearchParams{Keyword: "  React  "}, []string{"react"}},
+		{"keyword_order_no", services.OrderSearchParams{Keyword: seeded["go"].OrderNo}, []string{"go"}},
+		// LIKE通配符按普通字符匹配
+		{"keyword_underscore", services.OrderSearchParams{Keyword: "_"}, []string{"wild"}},
+		{"keyword_percent", services.OrderSearchParams{Keyword: "%"}, []string{"wild"}},
+		{"keyword_no_match", services.OrderSearchParams{Keyword: "Python"}, nil},
+		{"min_amount", services.OrderSearchParams{MinAmount: amount(19900)}, []string{"react", "wild"}},
+		{"max_amount", services.OrderSearchParams{MaxAmount: amount(19900)}, []string{"wild", "go"}},
+		{"amount_range", services.OrderSearchParams{MinAmount: amount(10000), MaxAmount: amount(20000)}, []string{"wild"}},
+		{"created_from", services.OrderSearchParams{CreatedFrom: date(10)}, []string{"react", "wild"}},
+		// 上限不含
+		{"created_to", services.OrderSearchParams{CreatedTo: date(10)}, []string{"go"}},
+		{"keyword_and_status", services.OrderSearchParams{Keyword: "Go", Statuses: []models.OrderStatus{paid}}, []string{"go"}},
+		{"keyword_amount_date", services.OrderSearchParams{
+			Keyword: "go", MinAmount: amount(10000), CreatedFrom: date(5), CreatedTo: date(15),
+		}, []string{"wild"}},
+		{"all_filters_no_match", services.OrderSearchParams{
+			Keyword: "React", Statuses: []models.OrderStatus{pending}, CreatedFrom: date(1),
+		}, nil},
+	}
+
+	names := make(map[models.OrderID]string, len(seeded))
+	for name, order := range seeded {
+		names[order.ID] = name
+	}
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			list, total, err := orders.SearchUserOrders(student.ID, tc.params)
+			if err != nil {
+				t.Fatalf("查询失败: %v", err)
+			}
+			got := make([]string, 0, len(list))
+			for _, order := range list {
+				name, ok := names[order.ID]
+				if !ok {
+					name = "other:" + order.OrderNo
+				}
+				got = append(got, name)
+			}
+			if strings.Join(got, ",") != strings.Join(tc.want, ",") {
+				t.Errorf("结果为 %v，期望 %v", got, tc.want)
+			}
+			if total != int64(len(tc.want)) {
+				t.Errorf("总数为 %d，期望 %d", total, len(tc.want))
+			}
+		})
+	}
+}
+
+// searchableOrder 创建课程名称、金额和下单时间确定的订单
+func searchableOrder(t *testing.T, f *factory.Factory, db *gorm.DB, userID uint, title string, price int64, createdAt time.Time, paid bool) *models.Order {
+	t.Helper()
+	course := f.Course(price)
+	if err := db.Model(course).Update("title", title).Error; err != nil {
+		t.Fatalf("修改课程名称失败: %v", err)
+	}
+	var order *models.Order
+	if paid {
+		order = f.PaidOrder(userID, course.ID)
+	} else {
+		order = f.PendingOrder(userID, course.ID)
+	}
+	if err := db.Model(&models.Order{}).Where("id = ?", order.ID).Update("created_at", createdAt).Error; err != nil {
+		t.Fatalf("修改下单时间失败: %v", err)
+	}
+	return order
+}
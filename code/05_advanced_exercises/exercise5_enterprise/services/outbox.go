@@ -0,0 +1,181 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+
+	"edu-platform/models"
+)
+
+// 发件箱事件类型
+const (
+	EventOrderCreated  = "order.created"  // 订单已创建（待支付）
+	EventOrderPaid     = "order.paid"     // 订单已支付
+	EventOrderRefunded = "order.refunded" // 订单已退款（含部分退款）
+)
+
+// 发件箱事件状态
+const (
+	OutboxPending   int8 = 1 // 待投递
+	OutboxDelivered int8 = 2 // 已投递
+)
+
+const (
+	outboxRelayBatchSize = 100         // 投递任务每批读取的事件数
+	outboxRelayInterval  = time.Second // 投递任务轮询间隔
+)
+
+// WriteOutbox 在业务事务中写入发件箱事件，事件与业务数据一起提交，事务回滚时事件一并丢弃
+// 不关联具体业务记录的事件使用它；关联订单等记录的事件使用addOutboxEvent记录业务ID
+func WriteOutbox(tx *gorm.DB, eventType string, payload interface{}) error {
+	return addOutboxEvent(tx, eventType, "", payload)
+}
+
+// addOutboxEvent 在业务事务中写入发件箱事件，事务回滚时事件一并丢弃
+func addOutboxEvent(tx *gorm.DB, eventType string, aggregateID string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	return tx.Create(&models.OutboxEvent{
+		EventType:   eventType,
+		AggregateID: aggregateID,
+		Payload:     string(data),
+		Status:      OutboxPending,
+	}).Error
+}
+
+// OutboxDispatcher 发件箱事件投递器
+// 返回nil表示处理成功。处理成功但标记失败（如进程崩溃）时事件会再次投递，处理器需要保证幂等
+type OutboxDispatcher interface {
+	Dispatch(ctx context.Context, event models.OutboxEvent) error
+}
+
+// OutboxDispatcherFunc 让普通函数实现OutboxDispatcher
+type OutboxDispatcherFunc func(ctx context.Context, event models.OutboxEvent) error
+
+// Dispatch 调用函数本身
+func (f OutboxDispatcherFunc) Dispatch(ctx context.Context, event models.OutboxEvent) error {
+	return f(ctx, event)
+}
+
+// OutboxMux 按事件类型分发的投递器，没有处理器的事件类型直接视为已处理
+type OutboxMux map[string]OutboxDispatcherFunc
+
+// Dispatch 调用事件类型对应的处理器
+func (m OutboxMux) Dispatch(ctx context.Context, event models.OutboxEvent) error {
+	if handler, ok := m[event.EventType]; ok {
+		return handler(ctx, event)
+	}
+	return nil
+}
+
+// MergeOutboxMux 合并多个按事件类型分发的投递器，同一事件类型的处理器按参数顺序依次执行
+// 任一处理器失败时整个事件下一轮重试，之前成功的处理器会再次执行，因此都需要幂等
+func MergeOutboxMux(muxes ...OutboxMux) OutboxMux {
+	merged := make(OutboxMux)
+	for _, mux := range muxes {
+		for eventType, handler := range mux {
+			prev, ok := merged[eventType]
+			if !ok {
+				merged[eventType] = handler
+				continue
+			}
+			next := handler
+			merged[eventType] = func(ctx context.Context, event models.OutboxEvent) error {
+				if err := prev(ctx, event); err != nil {
+					return err
+				}
+				return next(ctx, event)
+			}
+		}
+	}
+	return merged
+}
+
+// OutboxService 发件箱服务
+type OutboxService struct {
+	db *gorm.DB
+}
+
+// NewOutboxService 创建发件箱服务
+func NewOutboxService(db *gorm.DB) *OutboxService {
+	return &OutboxService{db: db}
+}
+
+// RelayOutbox 投递一轮待投递事件，返回投递成功的事件数
+// 按ID顺序逐条投递，成功后标记为已投递；失败的事件记录失败次数和原因，留到下一轮重试，不影响后面的事件
+func (s *OutboxService) RelayOutbox(ctx context.Context, dispatcher OutboxDispatcher) (int, error) {
+	delivered := 0
+	var lastID uint
+	for {
+		var events []models.OutboxEvent
+		err := s.db.WithContext(ctx).
+			Where("status = ? AND id > ?", OutboxPending, lastID).
+			Order("id").Limit(outboxRelayBatchSize).
+			Find(&events).Error
+		if err != nil {
+			return delivered, err
+		}
+		if len(events) == 0 {
+			return delivered, nil
+		}
+		lastID = events[len(events)-1].ID
+
+		for _, event := range events {
+			if err := ctx.Err(); err != nil {
+				return delivered, err
+			}
+
+			if err := dispatcher.Dispatch(ctx, event); err != nil {
+				log.Printf("投递发件箱事件%d(%s)失败: %v", event.ID, event.EventType, err)
+				message := []rune(err.Error())
+				if len(message) > 500 {
+					message = message[:500]
+				}
+				if err := s.db.Model(&models.OutboxEvent{}).Where("id = ?", event.ID).Updates(map[string]interface{}{
+					"attempts":   gorm.Expr("attempts + 1"),
+					"last_error": string(message),
+				}).Error; err != nil {
+					return delivered, err
+				}
+				continue
+			}
+
+			now := time.Now()
+			if err := s.db.Model(&models.OutboxEvent{}).
+				Where("id = ? AND status = ?", event.ID, OutboxPending).
+				Updates(map[string]interface{}{
+					"status":       OutboxDelivered,
+					"attempts":     gorm.Expr("attempts + 1"),
+					"delivered_at": &now,
+				}).Error; err != nil {
+				return delivered, err
+			}
+			delivered++
+		}
+	}
+}
+
+// StartOutboxRelay 启动发件箱投递任务，定期把待投递事件交给dispatcher，ctx取消时退出
+func (s *OutboxService) StartOutboxRelay(ctx context.Context, dispatcher OutboxDispatcher) {
+	go func() {
+		ticker := time.NewTicker(outboxRelayInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := s.RelayOutbox(ctx, dispatcher); err != nil && ctx.Err() == nil {
+					log.Printf("投递发件箱事件失败: %v", err)
+				}
+			}
+		}
+	}()
+}
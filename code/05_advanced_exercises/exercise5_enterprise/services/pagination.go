@@ -0,0 +1,56 @@
+package services
+
+import (
+	"gorm.io/gorm"
+)
+
+// Pagination 列表分页参数，由控制器解析并检查上限后传入
+// AfterID不为0时为游标分页：按id倒序取 id < AfterID 的一页，不使用OFFSET；
+// Deep为true时（管理员接口的 deep_paging=true）按页码深分页，同样按id倒序，先定位上一页最后一条记录的id再按游标取
+type Pagination struct {
+	Page     int
+	PageSize int
+	AfterID  uint
+	Deep     bool
+}
+
+// Offset 按页码分页时跳过的记录数
+func (p Pagination) Offset() int {
+	if p.Page < 1 {
+		return 0
+	}
+	return (p.Page - 1) * p.PageSize
+}
+
+// Keyset 是否按id游标取数，此时结果按id倒序，忽略其他排序
+func (p Pagination) Keyset() bool {
+	return p.AfterID != 0 || p.Deep
+}
+
+// Paginate 对列表查询应用分页，idColumn为主键列（表有别名时带别名，如 orders.id），orderBy为按页码分页时的排序
+// 深分页没有传 AfterID 时，先只查询主键列定位上一页最后一条记录，再按 idColumn < 该id 取一页，
+// 不需要读出并丢弃前面各页的整行数据；页码超出范围时返回空结果
+func Paginate(query *gorm.DB, p Pagination, idColumn, orderBy string) (*gorm.DB, error) {
+	if !p.Keyset() {
+		return query.Order(orderBy).Limit(p.PageSize).Offset(p.Offset()), nil
+	}
+
+	afterID := p.AfterID
+	if afterID == 0 && p.Offset() > 0 {
+		var ids []uint
+		err := query.Session(&gorm.Session{}).Order(idColumn+" DESC").
+			Offset(p.Offset()-1).Limit(1).Pluck(idColumn, &ids).Error
+		if err != nil {
+			return nil, err
+		}
+		if len(ids) == 0 {
+			return query.Where("1 = 0"), nil
+		}
+		afterID = ids[0]
+	}
+
+	if afterID != 0 {
+		query = query.Where(idColumn+" < ?", afterID)
+	}
+	return query.Order(idColumn + " DESC").Limit(p.PageSize), nil
+}
@@ -0,0 +1,192 @@
+package services
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"log"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+
+	"edu-platform/models"
+)
+
+// minPasswordLength 修改和重置密码时新密码的最小长度
+const minPasswordLength = 8
+
+// PasswordService 密码服务：登录校验、修改密码和通过验证码重置密码
+// 密码以bcrypt哈希保存；早期数据中的明文密码在用户下次登录成功时改写为哈希。
+// 修改或重置密码后递增用户的 TokenVersion，之前签发的token全部失效
+type PasswordService struct {
+	db           *gorm.DB
+	verification *VerificationService
+}
+
+// NewPasswordService 创建密码服务
+func NewPasswordService(db *gorm.DB, verification *VerificationService) *PasswordService {
+	return &PasswordService{db: db, verification: verification}
+}
+
+// WithContext 返回使用ctx执行查询的副本，见 UserService.WithContext
+func (s *PasswordService) WithContext(ctx context.Context) *PasswordService {
+	return &PasswordService{db: s.db.WithContext(ctx), verification: s.verification}
+}
+
+// Authenticate 按邮箱和密码校验用户，失败时统一返回 auth.invalid_credentials，不区分用户不存在和密码错误
+// 保存的是明文密码（旧数据）时直接比较，通过后在同一事务中改写为bcrypt哈希
+func (s *PasswordService) Authenticate(email, password string) (*models.User, error) {
+	var user models.User
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Preload("Role").Where("email = ?", email).First(&user).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrUnauthorized.WithMsg("auth.invalid_credentials")
+			}
+			return err
+		}
+		if !checkPassword(user.Password, password) {
+			return ErrUnauthorized.WithMsg("auth.invalid_credentials")
+		}
+		if isPasswordHash(user.Password) {
+			return nil
+		}
+
+		hash, err := hashPassword(password)
+		if err != nil {
+			return err
+		}
+		// 按原值条件更新，同一用户并发登录时只改写一次
+		result := tx.Model(&models.User{}).Where("id = ? AND password = ?", user.ID, user.Password).
+			Update("password", hash)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected > 0 {
+			log.Printf("用户 %d 的明文密码已升级为bcrypt哈希", user.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// ChangePassword 修改密码，须提供当前密码；成功后该用户之前签发的token失效
+func (s *PasswordService) ChangePassword(userID uint, currentPassword, newPassword string) error {
+	var user models.User
+	if err := s.db.First(&user, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrNotFound.WithMsg("user.not_found")
+		}
+		return err
+	}
+	if !checkPassword(user.Password, currentPassword) {
+		return ErrValidation.WithMsg("password.incorrect")
+	}
+	if err := validatePassword(&user, newPassword); err != nil {
+		return err
+	}
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		return setPassword(tx, user.ID, newPassword)
+	})
+}
+
+// RequestReset 向邮箱或手机号发送重置密码的验证码
+// 没有对应用户时同样返回成功，不暴露账户是否存在
+func (s *PasswordService) RequestReset(target string) error {
+	if _, err := s.findByTarget(target); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	return s.verification.RequestCode(target, PurposeReset)
+}
+
+// ConfirmReset 校验重置密码的验证码并设置新密码，成功后该用户之前签发的token失效
+// 新密码不符合要求时不消耗验证码的尝试次数
+func (s *PasswordService) ConfirmReset(target, code, newPassword string) error {
+	user, err := s.findByTarget(target)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrValidation.WithMsg("verification.code_invalid")
+		}
+		return err
+	}
+	if err := validatePassword(user, newPassword); err != nil {
+		return err
+	}
+
+	token, err := s.verification.VerifyCode(target, PurposeReset, code)
+	if err != nil {
+		return err
+	}
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := consumeVerificationToken(tx, []string{target}, PurposeReset, token); err != nil {
+			return err
+		}
+		return setPassword(tx, user.ID, newPassword)
+	})
+}
+
+// findByTarget 按邮箱（不区分大小写）或手机号查找用户
+func (s *PasswordService) findByTarget(target string) (*models.User, error) {
+	target = normalizeTarget(target)
+	if target == "" {
+		return nil, ErrValidation
+	}
+	var user models.User
+	err := s.db.Where("LOWER(email) = ? OR phone = ?", target, target).First(&user).Error
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// validatePassword 新密码的强度要求：至少8位，不能与用户名或邮箱相同
+func validatePassword(user *models.User, password string) error {
+	if len(password) < minPasswordLength {
+		return ErrValidation.WithMsg("password.too_short", minPasswordLength)
+	}
+	if strings.EqualFold(password, user.Username) || strings.EqualFold(password, user.Email) {
+		return ErrValidation.WithMsg("password.same_as_account")
+	}
+	return nil
+}
+
+// setPassword 在事务中写入新密码的哈希，并递增token版本使已签发的token失效
+func setPassword(tx *gorm.DB, userID uint, password string) error {
+	hash, err := hashPassword(password)
+	if err != nil {
+		return err
+	}
+	return tx.Model(&models.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+		"password":      hash,
+		"token_version": gorm.Expr("token_version + 1"),
+	}).Error
+}
+
+// hashPassword 生成密码的bcrypt哈希
+func hashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", ErrInternal.Wrap(err)
+	}
+	return string(hash), nil
+}
+
+// isPasswordHash 保存的密码是否为bcrypt哈希，否则为旧数据中的明文
+func isPasswordHash(stored string) bool {
+	_, err := bcrypt.Cost([]byte(stored))
+	return err == nil
+}
+
+// checkPassword 校验密码，兼容旧数据中的明文密码
+func checkPassword(stored, password string) bool {
+	if isPasswordHash(stored) {
+		return bcrypt.CompareHashAndPassword([]byte(stored), []byte(password)) == nil
+	}
+	return stored != "" && subtle.ConstantTimeCompare([]byte(stored), []byte(password)) == 1
+}
@@ -0,0 +1,137 @@
+package services_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+
+	"edu-platform/models"
+	"edu-platform/services"
+	"edu-platform/testhelpers"
+	"edu-platform/testhelpers/factory"
+)
+
+// reloadUser 重新查询用户
+func reloadUser(t *testing.T, db *gorm.DB, id uint) models.User {
+	t.Helper()
+	var user models.User
+	if err := db.First(&user, id).Error; err != nil {
+		t.Fatalf("查询用户失败: %v", err)
+	}
+	return user
+}
+
+// TestAuthenticateUpgradesPlaintext 旧数据中的明文密码在登录成功后改写为bcrypt哈希，token版本不变；
+// 登录失败时不改写，用户不存在和密码错误返回同样的错误
+func TestAuthenticateUpgradesPlaintext(t *testing.T) {
+	t.Parallel()
+	db := testhelpers.NewDB(t)
+	f := factory.New(t, db)
+	passwords := services.NewPasswordService(db, nil)
+
+	user := f.User("student")
+	if _, err := passwords.Authenticate(user.Email, "wrong-password"); !errors.Is(err, services.ErrUnauthorized) {
+		t.Errorf("密码错误返回 %v，期望 ErrUnauthorized", err)
+	}
+	if stored := reloadUser(t, db, user.ID).Password; stored != factory.Password {
+		t.Errorf("登录失败后密码被改写为 %q", stored)
+	}
+	if _, err := passwords.Authenticate("nobody@example.test", factory.Password); !errors.Is(err, services.ErrUnauthorized) {
+		t.Errorf("用户不存在返回 %v，期望 ErrUnauthorized", err)
+	}
+
+	if _, err := passwords.Authenticate(user.Email, factory.Password); err != nil {
+		t.Fatalf("明文密码登录失败: %v", err)
+	}
+	upgraded := reloadUser(t, db, user.ID)
+	if _, err := bcrypt.Cost([]byte(upgraded.Password)); err != nil {
+		t.Errorf("登录后密码为 %q，期望改写为bcrypt哈希", upgraded.Password)
+	}
+	if upgraded.TokenVersion != user.TokenVersion {
+		t.Errorf("升级哈希后token版本为 %d，期望不变", upgraded.TokenVersion)
+	}
+
+	if _, err := passwords.Authenticate(user.Email, factory.Password); err != nil {
+		t.Errorf("升级后再次登录失败: %v", err)
+	}
+	if stored := reloadUser(t, db, user.ID).Password; stored != upgraded.Password {
+		t.Error("已是哈希的密码不应再次改写")
+	}
+}
+
+// TestChangePasswordBumpsTokenVersion 修改密码须提供正确的当前密码且新密码符合要求，成功后token版本加1
+func TestChangePasswordBumpsTokenVersion(t *testing.T) {
+	t.Parallel()
+	db := testhelpers.NewDB(t)
+	f := factory.New(t, db)
+	passwords := services.NewPasswordService(db, nil)
+
+	user := f.User("student")
+	for name, tc := range map[string]struct{ current, next string }{
+		"当前密码错误": {"wrong-password", "new-password"},
+		"新密码太短":  {factory.Password, "short"},
+		"与邮箱相同":  {factory.Password, strings.ToUpper(user.Email)},
+	} {
+		if err := passwords.ChangePassword(user.ID, tc.current, tc.next); !errors.Is(err, services.ErrValidation) {
+			t.Errorf("%s: 返回 %v，期望 ErrValidation", name, err)
+		}
+	}
+	if got := reloadUser(t, db, user.ID).TokenVersion; got != user.TokenVersion {
+		t.Errorf("修改失败后token版本为 %d，期望不变", got)
+	}
+
+	if err := passwords.ChangePassword(user.ID, factory.Password, "new-password"); err != nil {
+		t.Fatalf("修改密码失败: %v", err)
+	}
+	if got := reloadUser(t, db, user.ID).TokenVersion; got != user.TokenVersion+1 {
+		t.Errorf("修改密码后token版本为 %d，期望 %d", got, user.TokenVersion+1)
+	}
+	if _, err := passwords.Authenticate(user.Email, factory.Password); !errors.Is(err, services.ErrUnauthorized) {
+		t.Errorf("旧密码登录返回 %v，期望 ErrUnauthorized", err)
+	}
+	if _, err := passwords.Authenticate(user.Email, "new-password"); err != nil {
+		t.Errorf("新密码登录失败: %v", err)
+	}
+}
+
+// TestConfirmResetBumpsTokenVersion 通过验证码重置密码后token版本加1；新密码不符合要求时不消耗验证码；
+// 不存在的账户请求重置同样返回成功但不发送验证码
+func TestConfirmResetBumpsTokenVersion(t *testing.T) {
+	t.Parallel()
+	db := testhelpers.NewDB(t)
+	f := factory.New(t, db)
+	sender := &stubSender{}
+	passwords := services.NewPasswordService(db, services.NewVerificationService(db, sender))
+
+	if err := passwords.RequestReset("nobody@example.test"); err != nil || len(sender.codes) != 0 {
+		t.Errorf("不存在的账户请求重置返回 %v、发送了 %d 个验证码，期望成功且不发送", err, len(sender.codes))
+	}
+
+	user := f.User("student")
+	target := strings.ToUpper(user.Email)
+	if err := passwords.RequestReset(target); err != nil {
+		t.Fatalf("请求重置失败: %v", err)
+	}
+	code := sender.codes[0]
+	for i := 0; i < 6; i++ {
+		if err := passwords.ConfirmReset(target, code, "short"); !errors.Is(err, services.ErrValidation) {
+			t.Fatalf("新密码太短返回 %v，期望 ErrValidation", err)
+		}
+	}
+
+	if err := passwords.ConfirmReset(target, code, "reset-password"); err != nil {
+		t.Fatalf("重置密码失败: %v", err)
+	}
+	if got := reloadUser(t, db, user.ID).TokenVersion; got != user.TokenVersion+1 {
+		t.Errorf("重置密码后token版本为 %d，期望 %d", got, user.TokenVersion+1)
+	}
+	if _, err := passwords.Authenticate(user.Email, "reset-password"); err != nil {
+		t.Errorf("新密码登录失败: %v", err)
+	}
+	if err := passwords.ConfirmReset(target, code, "another-password"); !errors.Is(err, services.ErrValidation) {
+		t.Errorf("验证码重复使用返回 %v，期望 ErrValidation", err)
+	}
+}
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	defaultPreloadChunk   = 500 // 默认每批预加载的父记录数
+	preloadBatchThreshold = 500 // 父记录超过该数量时改用分批预加载
+)
+
+// PreloadBatched 分批预加载关联，避免父记录过多时生成超大的 IN (...) 子句
+// 返回的函数接收已查询出的父记录切片指针，按chunk个一批重新加载主键并预加载assoc，
+// 再把加载到的关联合并回原切片。assoc支持嵌套关联，例如 "Items.Course"
+//
+//	err := PreloadBatched(db, "Items.Course", 500)(&orders)
+func PreloadBatched(db *gorm.DB, assoc string, chunk int) func(dest interface{}) error {
+	if chunk <= 0 {
+		chunk = defaultPreloadChunk
+	}
+
+	return func(dest interface{}) error {
+		slice := reflect.Indirect(reflect.ValueOf(dest))
+		if slice.Kind() != reflect.Slice {
+			return fmt.Errorf("PreloadBatched: dest必须是切片指针，实际为%T", dest)
+		}
+		if slice.Len() == 0 {
+			return nil
+		}
+
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(dest); err != nil {
+			return err
+		}
+		pk := stmt.Schema.PrioritizedPrimaryField
+		if pk == nil {
+			return fmt.Errorf("PreloadBatched: %s 没有主键", stmt.Schema.Name)
+		}
+		name := strings.Split(assoc, ".")[0]
+		rel, ok := stmt.Schema.Relationships.Relations[name]
+		if !ok {
+			return fmt.Errorf("PreloadBatched: %s 不存在关联 %s", stmt.Schema.Name, name)
+		}
+
+		ctx := db.Statement.Context
+		if ctx == nil {
+			ctx = context.Background()
+		}
+
+		for start := 0; start < slice.Len(); start += chunk {
+			end := start + chunk
+			if end > slice.Len() {
+				end = slice.Len()
+			}
+
+			// 记录主键对应的位置，用于合并结果
+			ids := make([]interface{}, 0, end-start)
+			positions := make(map[interface{}]int, end-start)
+			for i := start; i < end; i++ {
+				id, _ := pk.ValueOf(ctx, slice.Index(i))
+				ids = append(ids, id)
+				positions[id] = i
+			}
+
+			loaded := reflect.New(slice.Type())
+			err := db.Session(&gorm.Session{NewDB: true}).
+				Preload(assoc).
+				Where(clause.IN{Column: clause.Column{Table: clause.CurrentTable, Name: pk.DBName}, Values: ids}).
+				Find(loaded.Interface()).Error
+			if err != nil {
+				return err
+			}
+
+			for j := 0; j < loaded.Elem().Len(); j++ {
+				item := loaded.Elem().Index(j)
+				id, _ := pk.ValueOf(ctx, item)
+				if i, ok := positions[id]; ok {
+					rel.Field.ReflectValueOf(ctx, slice.Index(i)).Set(rel.Field.ReflectValueOf(ctx, item))
+				}
+			}
+		}
+		return nil
+	}
+}
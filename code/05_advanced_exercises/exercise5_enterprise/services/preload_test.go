@@ -0,0 +1,92 @@
+package services_test
+
+import (
+	"strings"
+	"testing"
+
+	"gorm.io/gorm"
+
+	"edu-platform/models"
+	"edu-platform/services"
+	"edu-platform/testhelpers"
+	"edu-platform/testhelpers/factory"
+)
+
+// TestPreloadBatchedChunks 5个订单按每批2个预加载：订单、订单项、课程各查询3次，
+// 每个订单得到自己的订单项和课程，与切片中的顺序无关
+func TestPreloadBatchedChunks(t *testing.T) {
+	t.Parallel()
+	db := testhelpers.NewDB(t)
+	f := factory.New(t, db)
+
+	student := f.User("student")
+	itemCount := map[uint]int{}
+	for i := 1; i <= 5; i++ {
+		var courseIDs []uint
+		for j := 0; j < i%3+1; j++ {
+			courseIDs = append(courseIDs, f.Course(int64(100*i+j)).ID)
+		}
+		order := f.PaidOrder(student.ID, courseIDs...)
+		itemCount[order.ID] = len(courseIDs)
+	}
+
+	var orders []models.Order
+	if err := db.Order("id DESC").Find(&orders).Error; err != nil {
+		t.Fatalf("查询订单失败: %v", err)
+	}
+
+	queries := map[string]int{}
+	err := db.Callback().Query().After("gorm:query").Register("test:count_tables", func(tx *gorm.DB) {
+		queries[tx.Statement.Table]++
+	})
+	if err != nil {
+		t.Fatalf("注册回调失败: %v", err)
+	}
+
+	if err := services.PreloadBatched(db, "Items.Course", 2)(&orders); err != nil {
+		t.Fatalf("分批预加载失败: %v", err)
+	}
+
+	for _, table := range []string{"orders", "order_items", "courses"} {
+		if got := queries[models.Table(db, table)]; got != 3 {
+			t.Errorf("%s 查询了 %d 次，期望 3 次（5个订单每批2个）", table, got)
+		}
+	}
+	for _, order := range orders {
+		if len(order.Items) != itemCount[order.ID] {
+			t.Errorf("订单 %d 加载了 %d 个订单项，期望 %d", order.ID, len(order.Items), itemCount[order.ID])
+		}
+		for _, item := range order.Items {
+			if item.OrderID != order.ID || item.Course.ID != item.CourseID {
+				t.Errorf("订单 %d 的订单项 %d 属于订单 %d、课程为 %d，期望课程 %d",
+					order.ID, item.ID, item.OrderID, item.Course.ID, item.CourseID)
+			}
+		}
+	}
+}
+
+// TestPreloadBatchedInvalid dest不是切片或关联不存在时返回错误，空切片不查询
+func TestPreloadBatchedInvalid(t *testing.T) {
+	t.Parallel()
+	db := testhelpers.NewDB(t)
+	f := factory.New(t, db)
+	f.PaidOrder(f.User("student").ID, f.Course(9900).ID)
+
+	var order models.Order
+	if err := db.First(&order).Error; err != nil {
+		t.Fatalf("查询订单失败: %v", err)
+	}
+	if err := services.PreloadBatched(db, "Items", 0)(&order); err == nil || !strings.Contains(err.Error(), "切片") {
+		t.Errorf("dest不是切片时返回 %v，期望报错", err)
+	}
+
+	orders := []models.Order{order}
+	if err := services.PreloadBatched(db, "Teacher", 0)(&orders); err == nil || !strings.Contains(err.Error(), "Teacher") {
+		t.Errorf("关联不存在时返回 %v，期望报错", err)
+	}
+
+	var empty []models.Order
+	if err := services.PreloadBatched(db, "NoSuchAssoc", 0)(&empty); err != nil {
+		t.Errorf("空切片返回 %v，期望不处理", err)
+	}
+}
@@ -0,0 +1,172 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"edu-platform/models"
+)
+
+// PromotionService 课程限时促销服务
+// 售价只按时间窗口（starts_at <= now < ends_at）判断，课程的 Price 列不会被修改，促销结束后自动恢复原价
+type PromotionService struct {
+	db *gorm.DB
+}
+
+// NewPromotionService 创建限时促销服务
+func NewPromotionService(db *gorm.DB) *PromotionService {
+	return &PromotionService{db: db}
+}
+
+// PromotionRequest 创建促销的参数，时间窗口为 [StartsAt, EndsAt)
+type PromotionRequest struct {
+	PromoPrice int64     `json:"promo_price" binding:"min=0"` // 促销价（分）
+	StartsAt   time.Time `json:"starts_at" binding:"required"`
+	EndsAt     time.Time `json:"ends_at" binding:"required"`
+}
+
+// CreatePromotion 为课程创建限时促销，促销价须低于课程价格，与该课程已有的促销时间不能重叠
+// 锁住课程行后检查重叠，同一课程的并发创建依次执行，不会同时插入两个重叠的促销
+func (s *PromotionService) CreatePromotion(courseID, createdBy uint, req PromotionRequest) (*models.Promotion, error) {
+	if !req.EndsAt.After(req.StartsAt) {
+		return nil, ErrValidation.WithMsg("promotion.invalid_window")
+	}
+
+	promotion := &models.Promotion{
+		CourseID:   courseID,
+		PromoPrice: req.PromoPrice,
+		StartsAt:   req.StartsAt,
+		EndsAt:     req.EndsAt,
+		Status:     models.PromotionStatusAt(req.StartsAt, req.EndsAt, time.Now()),
+		CreatedBy:  createdBy,
+	}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var course models.Course
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id", "price").
+			First(&course, courseID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrNotFound.WithMsg("course.not_found")
+			}
+			return err
+		}
+		if req.PromoPrice >= course.Price {
+			return ErrValidation.WithMsg("promotion.invalid_price")
+		}
+
+		// 两个半开区间 [a, b) 与 [c, d) 重叠当且仅当 a < d 且 c < b，首尾相接不算重叠
+		var overlapping models.Promotion
+		err := tx.Where("course_id = ? AND starts_at < ? AND ends_at > ?", courseID, req.EndsAt, req.StartsAt).
+			Order("starts_at").First(&overlapping).Error
+		if err == nil {
+			return ErrConflict.WithMsg("promotion.overlap").WithDetails(map[string]interface{}{
+				"promotion_id": overlapping.ID,
+				"starts_at":    overlapping.StartsAt,
+				"ends_at":      overlapping.EndsAt,
+			})
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		return tx.Create(promotion).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return promotion, nil
+}
+
+// GetPromotions 课程的全部促销，按开始时间倒序
+func (s *PromotionService) GetPromotions(courseID uint) ([]models.Promotion, error) {
+	promotions := []models.Promotion{}
+	err := s.db.Where("course_id = ?", courseID).Order("starts_at DESC").Find(&promotions).Error
+	return promotions, err
+}
+
+// DeletePromotion 删除促销（软删除），进行中的促销删除后立即恢复原价；已下单的订单项保留下单时的价格
+func (s *PromotionService) DeletePromotion(id uint) error {
+	result := s.db.Delete(&models.Promotion{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound.WithMsg("promotion.not_found")
+	}
+	return nil
+}
+
+// activePromotions 查询课程在now时生效的促销，按课程ID返回，一条查询走 idx_promotion_course_window 索引
+// 时间窗口不重叠，每门课程最多一条
+func activePromotions(db *gorm.DB, courseIDs []uint, now time.Time) (map[uint]*models.Promotion, error) {
+	active := make(map[uint]*models.Promotion)
+	if len(courseIDs) == 0 {
+		return active, nil
+	}
+	var promotions []models.Promotion
+	if err := db.Where("course_id IN ? AND starts_at <= ? AND ends_at > ?", courseIDs, now, now).
+		Find(&promotions).Error; err != nil {
+		return nil, err
+	}
+	for i := range promotions {
+		active[promotions[i].CourseID] = &promotions[i]
+	}
+	return active, nil
+}
+
+// ApplyPromotions 为课程填写now时生效的促销（Course.ActivePromotion），之后 Course.EffectivePrice 返回实际售价
+// 课程列表、详情和下单都经过这里取售价
+func ApplyPromotions(db *gorm.DB, courses []models.Course, now time.Time) error {
+	ids := make([]uint, 0, len(courses))
+	for _, course := range courses {
+		ids = append(ids, course.ID)
+	}
+	active, err := activePromotions(db, ids, now)
+	if err != nil {
+		return err
+	}
+	for i := range courses {
+		courses[i].ActivePromotion = active[courses[i].ID]
+	}
+	return nil
+}
+
+// SyncStatuses 按时间更新促销状态：已开始的改为进行中，已结束的改为已结束，返回更新的数量
+// 状态只用于展示和报表，售价不依赖这里的结果
+func (s *PromotionService) SyncStatuses(ctx context.Context, now time.Time) (int64, error) {
+	db := s.db.WithContext(ctx)
+	started := db.Model(&models.Promotion{}).
+		Where("status = ? AND starts_at <= ? AND ends_at > ?", models.PromotionStatusScheduled, now, now).
+		Update("status", models.PromotionStatusActive)
+	if started.Error != nil {
+		return 0, started.Error
+	}
+	ended := db.Model(&models.Promotion{}).
+		Where("status IN ? AND ends_at <= ?", []models.PromotionStatus{models.PromotionStatusScheduled, models.PromotionStatusActive}, now).
+		Update("status", models.PromotionStatusEnded)
+	return started.RowsAffected + ended.RowsAffected, ended.Error
+}
+
+// StartStatusSync 启动促销状态同步任务，每隔interval执行一次，ctx取消时退出
+func (s *PromotionService) StartStatusSync(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			if _, err := s.SyncStatuses(ctx, time.Now()); err != nil && ctx.Err() == nil {
+				log.Printf("促销状态同步失败: %v", err)
+			}
+		}
+	}()
+}
@@ -0,0 +1,28 @@
+package services
+
+import (
+	"context"
+	"time"
+)
+
+// RateLimiter 固定窗口限流，计数保存在缓存中；底层为Redis时多个实例共享计数
+type RateLimiter struct {
+	cache Cache
+	now   func() time.Time
+}
+
+// NewRateLimiter 创建限流器
+func NewRateLimiter(cache Cache) *RateLimiter {
+	return &RateLimiter{cache: cache, now: time.Now}
+}
+
+// Allow 同一场景（scope）下同一对象（subject，如客户端IP）每个window内最多limit次，超过时返回false
+// 计数失败时放行并返回错误，由调用方记录日志，限流不应导致正常请求失败
+func (l *RateLimiter) Allow(ctx context.Context, scope, subject string, limit int, window time.Duration) (bool, error) {
+	windowIndex := l.now().UnixNano() / int64(window)
+	n, err := l.cache.Incr(ctx, rateLimitCacheKey(scope, subject, windowIndex), window)
+	if err != nil {
+		return true, err
+	}
+	return n <= int64(limit), nil
+}
@@ -0,0 +1,171 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+
+	"edu-platform/models"
+)
+
+const (
+	defaultRelatedLimit = 6                // 默认返回的相关课程数
+	maxRelatedLimit     = 20               // 最多返回的相关课程数
+	relatedCacheTTL     = 10 * time.Minute // 相关课程的缓存时间
+	relatedCacheKind    = "related"
+)
+
+// 相关课程的来源
+const (
+	RelatedSourceCoPurchase  = "co_purchase"  // 与目标课程出现在同一已付款订单中
+	RelatedSourceCategoryTop = "category_top" // 目标课程没有共同购买数据时，同分类的畅销课程
+)
+
+// RelatedCourse 相关课程（"购买了该课程的学员还购买了"）
+type RelatedCourse struct {
+	CourseID    uint    `json:"course_id"`
+	Title       string  `json:"title"`
+	Slug        string  `json:"slug"`
+	Cover       string  `json:"cover"`
+	Price       int64   `json:"price"`
+	CoPurchases int64   `json:"co_purchases"` // 与目标课程一起购买的订单数
+	Sales       int64   `json:"sales"`        // 该课程的总销量（未退款的已付款订单项数）
+	Score       float64 `json:"score"`        // CoPurchases / Sales，同分类畅销课程为0
+	Source      string  `json:"source"`
+}
+
+// RecommendationService 课程推荐服务
+type RecommendationService struct {
+	db    *gorm.DB
+	cache *CourseCache // 为nil时不缓存
+}
+
+// NewRecommendationService 创建课程推荐服务
+func NewRecommendationService(db *gorm.DB, cache *CourseCache) *RecommendationService {
+	return &RecommendationService{db: db, cache: cache}
+}
+
+// GetRelatedCourses 与课程一起购买的其他课程
+// 按共同购买的订单数除以候选课程的总销量打分（简化的提升度），畅销课程不会因为销量大而总排在前面；
+// 只统计已付款、已完成订单中未退款的订单项，排除目标课程本身和未发布的课程。
+// 目标课程没有共同购买数据时，返回同分类中销量最高的课程。
+// userID不为0时排除该用户已拥有的课程；不区分用户的结果按课程缓存10分钟，区分用户的结果不缓存
+func (s *RecommendationService) GetRelatedCourses(courseID uint, limit int, userID uint) ([]RelatedCourse, error) {
+	if limit <= 0 {
+		limit = defaultRelatedLimit
+	}
+	if limit > maxRelatedLimit {
+		limit = maxRelatedLimit
+	}
+
+	// 缓存最多条数的结果，不同limit的请求共用
+	if userID == 0 && s.cache != nil {
+		var related []RelatedCourse
+		if s.cache.Get(courseID, relatedCacheKind, &related) {
+			if len(related) > limit {
+				related = related[:limit]
+			}
+			return related, nil
+		}
+	}
+
+	var course models.Course
+	if err := s.db.Select("id", "category_id").First(&course, courseID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound.WithMsg("course.not_found")
+		}
+		return nil, err
+	}
+
+	fetch := maxRelatedLimit
+	if userID != 0 {
+		fetch = limit
+	}
+	related, err := s.coPurchased(courseID, userID, fetch)
+	if err != nil {
+		return nil, err
+	}
+	if len(related) == 0 {
+		if related, err = s.categoryTopSellers(&course, userID, fetch); err != nil {
+			return nil, err
+		}
+	}
+
+	if userID == 0 && s.cache != nil {
+		s.cache.Set(courseID, relatedCacheKind, related, relatedCacheTTL)
+	}
+	if len(related) > limit {
+		related = related[:limit]
+	}
+	return related, nil
+}
+
+// paidSalesSQL 各课程的销量：已付款、已完成订单中未退款的订单项数
+func (s *RecommendationService) paidSalesSQL() string {
+	return "SELECT oi.course_id, COUNT(*) AS sales FROM " + models.Table(s.db, "order_items") + " oi" +
+		" JOIN " + models.Table(s.db, "orders") + " o ON o.id = oi.order_id AND o.deleted_at IS NULL" +
+		" WHERE o.status IN ? AND oi.refund_id IS NULL AND oi.deleted_at IS NULL GROUP BY oi.course_id"
+}
+
+// ownedFilter 排除用户已拥有的课程（有效的开通记录），userID为0时不排除
+func (s *RecommendationService) ownedFilter(query *gorm.DB, userID uint) *gorm.DB {
+	if userID == 0 {
+		return query
+	}
+	return query.Where("NOT EXISTS (SELECT 1 FROM "+models.Table(s.db, "enrollments")+" e"+
+		" WHERE e.course_id = c.id AND e.user_id = ? AND e.status = ? AND e.deleted_at IS NULL)", userID, models.EnrollmentStatusActive)
+}
+
+// coPurchased 订单项自连接统计与目标课程出现在同一已付款订单中的课程
+func (s *RecommendationService) coPurchased(courseID, userID uint, limit int) ([]RelatedCourse, error) {
+	paid := []models.OrderStatus{models.OrderStatusPaid, models.OrderStatusCompleted}
+	query := s.db.Table(models.Table(s.db, "order_items")+" a").
+		Select("c.id AS course_id, c.title, c.slug, c.cover, c.price, "+
+			"COUNT(DISTINCT a.order_id) AS co_purchases, MAX(sales.sales) AS sales, "+
+			"COUNT(DISTINCT a.order_id) * 1.0 / MAX(sales.sales) AS score").
+		Joins("JOIN "+models.Table(s.db, "orders")+" o ON o.id = a.order_id AND o.status IN ? AND o.deleted_at IS NULL", paid).
+		Joins("JOIN "+models.Table(s.db, "order_items")+" b ON b.order_id = a.order_id AND b.course_id <> a.course_id"+
+			" AND b.refund_id IS NULL AND b.deleted_at IS NULL").
+		Joins("JOIN "+models.Table(s.db, "courses")+" c ON c.id = b.course_id").
+		Joins("JOIN ("+s.paidSalesSQL()+") sales ON sales.course_id = c.id", paid).
+		Scopes(AvailableCoursesIn("c")).
+		Where("a.course_id = ? AND a.refund_id IS NULL AND a.deleted_at IS NULL", courseID)
+	query = s.ownedFilter(query, userID)
+
+	related := make([]RelatedCourse, 0, limit)
+	err := query.Group("c.id, c.title, c.slug, c.cover, c.price").
+		Order("score DESC, co_purchases DESC, c.id ASC").
+		Limit(limit).
+		Scan(&related).Error
+	if err != nil {
+		return nil, err
+	}
+	for i := range related {
+		related[i].Source = RelatedSourceCoPurchase
+	}
+	return related, nil
+}
+
+// categoryTopSellers 同分类中销量最高的已发布课程，用于没有共同购买数据的新课程
+func (s *RecommendationService) categoryTopSellers(course *models.Course, userID uint, limit int) ([]RelatedCourse, error) {
+	paid := []models.OrderStatus{models.OrderStatusPaid, models.OrderStatusCompleted}
+	query := s.db.Table(models.Table(s.db, "courses")+" c").
+		Select("c.id AS course_id, c.title, c.slug, c.cover, c.price, COALESCE(sales.sales, 0) AS sales").
+		Joins("LEFT JOIN ("+s.paidSalesSQL()+") sales ON sales.course_id = c.id", paid).
+		Scopes(AvailableCoursesIn("c")).
+		Where("c.category_id = ? AND c.id <> ?", course.CategoryID, course.ID)
+	query = s.ownedFilter(query, userID)
+
+	related := make([]RelatedCourse, 0, limit)
+	err := query.Order("sales DESC, c.student_count DESC, c.id ASC").
+		Limit(limit).
+		Scan(&related).Error
+	if err != nil {
+		return nil, err
+	}
+	for i := range related {
+		related[i].Source = RelatedSourceCategoryTop
+	}
+	return related, nil
+}
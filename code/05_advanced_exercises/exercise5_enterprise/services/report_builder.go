@@ -0,0 +1,434 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"edu-platform/models"
+)
+
+// ReportFieldType 报表字段类型，决定可用的操作符和聚合
+type ReportFieldType string
+
+const (
+	ReportInt    ReportFieldType = "int"    // 整数（ID、状态、计数）
+	ReportMoney  ReportFieldType = "money"  // 金额（分）
+	ReportFloat  ReportFieldType = "float"  // 小数（评分）
+	ReportString ReportFieldType = "string" // 字符串
+	ReportBool   ReportFieldType = "bool"   // 布尔
+	ReportTime   ReportFieldType = "time"   // 时间，值为 2006-01-02 或 RFC3339 格式
+)
+
+// ReportField 报表可用字段
+type ReportField struct {
+	Column    string          `json:"-"` // 数据库列名，只来自登记信息，不使用请求中的字符串
+	Type      ReportFieldType `json:"type"`
+	Groupable bool            `json:"groupable"` // 是否可用于分组
+}
+
+// ReportEntity 报表对象的登记信息
+type ReportEntity struct {
+	model  func() interface{}
+	Fields map[string]ReportField `json:"fields"`
+}
+
+// reportEntities 允许出报表的对象，只有登记过的字段才能用于筛选、分组和聚合
+var reportEntities = map[string]ReportEntity{
+	"orders": {
+		model: func() interface{} { return &models.Order{} },
+		Fields: map[string]ReportField{
+			"status":          {Column: "status", Type: ReportInt, Groupable: true},
+			"user_id":         {Column: "user_id", Type: ReportInt, Groupable: true},
+			"coupon_id":       {Column: "coupon_id", Type: ReportInt, Groupable: true},
+			"payment_method":  {Column: "payment_method", Type: ReportString, Groupable: true},
+			"total_amount":    {Column: "total_amount", Type: ReportMoney},
+			"pay_amount":      {Column: "pay_amount", Type: ReportMoney},
+			"discount_amount": {Column: "discount_amount", Type: ReportMoney},
+			"refund_amount":   {Column: "refund_amount", Type: ReportMoney},
+			"created_at":      {Column: "created_at", Type: ReportTime},
+			"paid_at":         {Column: "paid_at", Type: ReportTime},
+		},
+	},
+	"users": {
+		model: func() interface{} { return &models.User{} },
+		Fields: map[string]ReportField{
+			"status":        {Column: "status", Type: ReportInt, Groupable: true},
+			"role_id":       {Column: "role_id", Type: ReportInt, Groupable: true},
+			"created_at":    {Column: "created_at", Type: ReportTime},
+			"last_login_at": {Column: "last_login_at", Type: ReportTime},
+		},
+	},
+	"courses": {
+		model: func() interface{} { return &models.Course{} },
+		Fields: map[string]ReportField{
+			"category_id":   {Column: "category_id", Type: ReportInt, Groupable: true},
+			"instructor_id": {Column: "instructor_id", Type: ReportInt, Groupable: true},
+			"level":         {Column: "level", Type: ReportInt, Groupable: true},
+			"status":        {Column: "status", Type: ReportInt, Groupable: true},
+			"is_free":       {Column: "is_free", Type: ReportBool, Groupable: true},
+			"is_recommend":  {Column: "is_recommend", Type: ReportBool, Groupable: true},
+			"price":         {Column: "price", Type: ReportMoney},
+			"student_count": {Column: "student_count", Type: ReportInt},
+			"review_count":  {Column: "review_count", Type: ReportInt},
+			"view_count":    {Column: "view_count", Type: ReportInt},
+			"rating":        {Column: "rating", Type: ReportFloat},
+			"created_at":    {Column: "created_at", Type: ReportTime},
+			"published_at":  {Column: "published_at", Type: ReportTime},
+		},
+	},
+}
+
+// reportOperators 各字段类型允许的操作符
+var reportOperators = map[ReportFieldType][]string{
+	ReportInt:    {"eq", "ne", "gt", "gte", "lt", "lte", "in", "between"},
+	ReportMoney:  {"eq", "ne", "gt", "gte", "lt", "lte", "between"},
+	ReportFloat:  {"gt", "gte", "lt", "lte", "between"},
+	ReportString: {"eq", "ne", "in", "contains"},
+	ReportBool:   {"eq"},
+	ReportTime:   {"gt", "gte", "lt", "lte", "between"},
+}
+
+// reportComparisons 比较类操作符对应的SQL
+var reportComparisons = map[string]string{
+	"eq":  "=",
+	"ne":  "<>",
+	"gt":  ">",
+	"gte": ">=",
+	"lt":  "<",
+	"lte": "<=",
+}
+
+// reportAggregations 聚合函数，count可以不指定字段，其余只能用于数值字段
+var reportAggregations = map[string]string{
+	"count": "COUNT",
+	"sum":   "SUM",
+	"avg":   "AVG",
+	"min":   "MIN",
+	"max":   "MAX",
+}
+
+const (
+	defaultReportLimit  = 100             // 默认返回行数
+	maxReportLimit      = 1000            // 返回行数上限
+	maxReportFilters    = 20              // 筛选条件数上限
+	maxReportGroupBy    = 3               // 分组字段数上限
+	maxReportAggregates = 10              // 聚合数上限
+	maxReportInValues   = 100             // in操作符的值个数上限
+	reportTimeout       = 5 * time.Second // 报表查询超时时间
+)
+
+// ReportFilter 报表筛选条件
+type ReportFilter struct {
+	Field string      `json:"field"`
+	Op    string      `json:"op"`
+	Value interface{} `json:"value"` // in为数组，between为两个元素的数组
+}
+
+// ReportAggregation 报表聚合
+type ReportAggregation struct {
+	Func  string `json:"func"`
+	Field string `json:"field"` // count可以为空，表示统计行数
+}
+
+// ReportSort 报表排序，列为结果列名（分组字段名或 count、sum_pay_amount 这样的聚合列名）
+type ReportSort struct {
+	Column string `json:"column"`
+	Desc   bool   `json:"desc"`
+}
+
+// ReportSpec 报表查询条件
+type ReportSpec struct {
+	Entity       string              `json:"entity"`
+	Filters      []ReportFilter      `json:"filters"`
+	GroupBy      []string            `json:"group_by"`
+	Aggregations []ReportAggregation `json:"aggregations"`
+	Sort         []ReportSort        `json:"sort"` // 不指定时按分组字段排序
+	Limit        int                 `json:"limit"`
+}
+
+// ReportResult 报表结果
+type ReportResult struct {
+	Columns   []string                 `json:"columns"`
+	Rows      []map[string]interface{} `json:"rows"`
+	Truncated bool                     `json:"truncated"` // 结果超过limit被截断
+}
+
+// ReportQueryBuilder 管理后台自定义报表
+// 请求中的对象、字段、操作符、聚合都要在登记信息中，列名和SQL片段只取自登记信息，筛选值一律作为绑定参数
+type ReportQueryBuilder struct {
+	db *gorm.DB
+}
+
+// NewReportQueryBuilder 创建报表查询构建器
+func NewReportQueryBuilder(db *gorm.DB) *ReportQueryBuilder {
+	return &ReportQueryBuilder{db: db}
+}
+
+// Schema 返回各报表对象的可用字段，供管理后台展示
+func (b *ReportQueryBuilder) Schema() map[string]ReportEntity {
+	return reportEntities
+}
+
+// Run 校验并执行报表查询
+func (b *ReportQueryBuilder) Run(ctx context.Context, spec ReportSpec) (*ReportResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, reportTimeout)
+	defer cancel()
+
+	query, columns, limit, err := b.Build(b.db.WithContext(ctx), spec)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []map[string]interface{}
+	if err := query.Limit(limit + 1).Scan(&rows).Error; err != nil {
+		if errors.Is(err, context.DeadlineExceeded) || errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, ErrInternal.WithMsg("report.timeout").Wrap(err)
+		}
+		return nil, err
+	}
+
+	result := &ReportResult{Columns: columns, Rows: rows}
+	if len(rows) > limit {
+		result.Rows = rows[:limit]
+		result.Truncated = true
+	}
+	for _, row := range result.Rows {
+		for key, value := range row {
+			// MySQL驱动以[]byte返回DECIMAL等类型，转成数字或字符串再输出
+			if raw, ok := value.([]byte); ok {
+				if f, err := strconv.ParseFloat(string(raw), 64); err == nil {
+					row[key] = f
+				} else {
+					row[key] = string(raw)
+				}
+			}
+		}
+	}
+	if result.Rows == nil {
+		result.Rows = []map[string]interface{}{}
+	}
+	return result, nil
+}
+
+// Build 校验报表条件并生成查询，返回查询、结果列和行数限制
+func (b *ReportQueryBuilder) Build(db *gorm.DB, spec ReportSpec) (*gorm.DB, []string, int, error) {
+	entity, ok := reportEntities[spec.Entity]
+	if !ok {
+		return nil, nil, 0, ErrValidation.WithMsg("report.unknown_entity", spec.Entity)
+	}
+	if len(spec.Filters) > maxReportFilters || len(spec.GroupBy) > maxReportGroupBy || len(spec.Aggregations) > maxReportAggregates {
+		return nil, nil, 0, ErrValidation.WithMsg("report.too_complex", maxReportFilters, maxReportGroupBy, maxReportAggregates)
+	}
+	if len(spec.Aggregations) == 0 {
+		return nil, nil, 0, ErrValidation.WithMsg("report.aggregation_required")
+	}
+
+	limit := spec.Limit
+	if limit <= 0 {
+		limit = defaultReportLimit
+	}
+	if limit > maxReportLimit {
+		limit = maxReportLimit
+	}
+
+	query := db.Model(entity.model())
+
+	// 筛选条件
+	for _, filter := range spec.Filters {
+		field, err := entity.field(spec.Entity, filter.Field)
+		if err != nil {
+			return nil, nil, 0, err
+		}
+		if query, err = applyReportFilter(query, filter, field); err != nil {
+			return nil, nil, 0, err
+		}
+	}
+
+	// 分组字段
+	var selects, columns, groups []string
+	for _, name := range spec.GroupBy {
+		field, err := entity.field(spec.Entity, name)
+		if err != nil {
+			return nil, nil, 0, err
+		}
+		if !field.Groupable {
+			return nil, nil, 0, ErrValidation.WithMsg("report.not_groupable", name)
+		}
+		selects = append(selects, field.Column+" AS "+name)
+		columns = append(columns, name)
+		groups = append(groups, field.Column)
+	}
+
+	// 聚合
+	for _, agg := range spec.Aggregations {
+		fn, ok := reportAggregations[agg.Func]
+		if !ok {
+			return nil, nil, 0, ErrValidation.WithMsg("report.invalid_aggregation", agg.Func, agg.Field)
+		}
+
+		if agg.Func == "count" && agg.Field == "" {
+			selects = append(selects, "COUNT(*) AS count")
+			columns = append(columns, "count")
+			continue
+		}
+
+		field, err := entity.field(spec.Entity, agg.Field)
+		if err != nil {
+			return nil, nil, 0, err
+		}
+		if agg.Func != "count" && !field.Type.numeric() {
+			return nil, nil, 0, ErrValidation.WithMsg("report.invalid_aggregation", agg.Func, agg.Field)
+		}
+		alias := agg.Func + "_" + agg.Field
+		selects = append(selects, fn+"("+field.Column+") AS "+alias)
+		columns = append(columns, alias)
+	}
+
+	query = query.Select(strings.Join(selects, ", "))
+	if len(groups) > 0 {
+		query = query.Group(strings.Join(groups, ", "))
+	}
+
+	// 排序：只能使用结果列，列名取自上面生成的列（登记的字段名和聚合别名），不使用请求中的字符串；
+	// 最后按分组字段排序，相同排序值的行顺序固定
+	if len(spec.Sort) > len(columns) {
+		return nil, nil, 0, ErrValidation.WithMsg("report.invalid_sort", spec.Sort[len(columns)].Column)
+	}
+	for _, sort := range spec.Sort {
+		column, ok := reportColumn(columns, sort.Column)
+		if !ok {
+			return nil, nil, 0, ErrValidation.WithMsg("report.invalid_sort", sort.Column)
+		}
+		query = query.Order(clause.OrderByColumn{Column: clause.Column{Name: column}, Desc: sort.Desc})
+	}
+	if len(groups) > 0 {
+		query = query.Order(strings.Join(groups, ", "))
+	}
+	return query, columns, limit, nil
+}
+
+// reportColumn 在结果列中查找name，返回结果列中的字符串
+func reportColumn(columns []string, name string) (string, bool) {
+	for _, column := range columns {
+		if column == name {
+			return column, true
+		}
+	}
+	return "", false
+}
+
+// field 查找登记的字段
+func (e ReportEntity) field(entity, name string) (ReportField, error) {
+	field, ok := e.Fields[name]
+	if !ok {
+		return ReportField{}, ErrValidation.WithMsg("report.unknown_field", entity, name)
+	}
+	return field, nil
+}
+
+// numeric 是否为可求和、求平均的数值类型
+func (t ReportFieldType) numeric() bool {
+	return t == ReportInt || t == ReportMoney || t == ReportFloat
+}
+
+// applyReportFilter 校验操作符和值并添加筛选条件
+func applyReportFilter(query *gorm.DB, filter ReportFilter, field ReportField) (*gorm.DB, error) {
+	allowed := false
+	for _, op := range reportOperators[field.Type] {
+		if op == filter.Op {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return nil, ErrValidation.WithMsg("report.invalid_operator", filter.Field, filter.Op)
+	}
+
+	invalid := ErrValidation.WithMsg("report.invalid_value", filter.Field, filter.Op)
+
+	switch filter.Op {
+	case "in":
+		values, ok := filter.Value.([]interface{})
+		if !ok || len(values) == 0 || len(values) > maxReportInValues {
+			return nil, invalid
+		}
+		args := make([]interface{}, 0, len(values))
+		for _, v := range values {
+			arg, ok := reportValue(field.Type, v)
+			if !ok {
+				return nil, invalid
+			}
+			args = append(args, arg)
+		}
+		return query.Where(field.Column+" IN ?", args), nil
+
+	case "between":
+		values, ok := filter.Value.([]interface{})
+		if !ok || len(values) != 2 {
+			return nil, invalid
+		}
+		from, ok1 := reportValue(field.Type, values[0])
+		to, ok2 := reportValue(field.Type, values[1])
+		if !ok1 || !ok2 {
+			return nil, invalid
+		}
+		return query.Where(field.Column+" BETWEEN ? AND ?", from, to), nil
+
+	case "contains":
+		s, ok := filter.Value.(string)
+		if !ok || s == "" {
+			return nil, invalid
+		}
+		// 转义LIKE通配符，值只作为普通文本匹配
+		escaped := strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(s)
+		return query.Where(field.Column+" LIKE ? ESCAPE '!'", "%"+escaped+"%"), nil
+
+	default:
+		arg, ok := reportValue(field.Type, filter.Value)
+		if !ok {
+			return nil, invalid
+		}
+		return query.Where(field.Column+" "+reportComparisons[filter.Op]+" ?", arg), nil
+	}
+}
+
+// reportValue 按字段类型校验并转换筛选值
+func reportValue(typ ReportFieldType, v interface{}) (interface{}, bool) {
+	switch typ {
+	case ReportInt, ReportMoney:
+		f, ok := v.(float64)
+		if !ok || f != math.Trunc(f) || math.Abs(f) > 1<<53 {
+			return nil, false
+		}
+		return int64(f), true
+	case ReportFloat:
+		f, ok := v.(float64)
+		return f, ok
+	case ReportString:
+		s, ok := v.(string)
+		return s, ok && len(s) <= 255
+	case ReportBool:
+		b, ok := v.(bool)
+		return b, ok
+	case ReportTime:
+		s, ok := v.(string)
+		if !ok {
+			return nil, false
+		}
+		if t, err := time.Parse(time.RFC3339, s); err == nil {
+			return t, true
+		}
+		if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+			return t, true
+		}
+		return nil, false
+	}
+	return nil, false
+}
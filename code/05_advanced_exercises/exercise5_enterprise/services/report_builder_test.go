@@ -0,0 +1,267 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gorm.io/gorm"
+
+	"edu-platform/models"
+	"edu-platform/services"
+	"edu-platform/testhelpers"
+	"edu-platform/testhelpers/factory"
+)
+
+const injection = "status; DROP TABLE users; --"
+
+// TestReportBuilderRejectsInjectedNames 字段名、操作符、聚合和排序列不在登记信息中时报错，不生成SQL
+func TestReportBuilderRejectsInjectedNames(t *testing.T) {
+	t.Parallel()
+	builder := services.NewReportQueryBuilder(testhelpers.NewDB(t))
+	count := []services.ReportAggregation{{Func: "count"}}
+
+	cases := []struct {
+		name  string
+		spec  services.ReportSpec
+		msgID string
+	}{
+		{
+			name:  "entity",
+			spec:  services.ReportSpec{Entity: "users; DROP TABLE users", Aggregations: count},
+			msgID: "report.unknown_entity",
+		},
+		{
+			name: "filter_field",
+			spec: services.ReportSpec{Entity: "orders", Aggregations: count,
+				Filters: []services.ReportFilter{{Field: "1=1 OR status", Op: "eq", Value: float64(1)}}},
+			msgID: "report.unknown_field",
+		},
+		{
+			// 模型中存在但未登记的列同样拒绝
+			name: "unregistered_column",
+			spec: services.ReportSpec{Entity: "users", Aggregations: count,
+				Filters: []services.ReportFilter{{Field: "password", Op: "eq", Value: "x"}}},
+			msgID: "report.unknown_field",
+		},
+		{
+			name: "filter_operator",
+			spec: services.ReportSpec{Entity: "orders", Aggregations: count,
+				Filters: []services.ReportFilter{{Field: "status", Op: "= 1 OR 1=1 --", Value: float64(1)}}},
+			msgID: "report.invalid_operator",
+		},
+		{
+			name: "operator_for_type",
+			spec: services.ReportSpec{Entity: "orders", Aggregations: count,
+				Filters: []services.ReportFilter{{Field: "status", Op: "contains", Value: "1"}}},
+			msgID: "report.invalid_operator",
+		},
+		{
+			name:  "group_by",
+			spec:  services.ReportSpec{Entity: "orders", Aggregations: count, GroupBy: []string{injection}},
+			msgID: "report.unknown_field",
+		},
+		{
+			name:  "group_by_not_groupable",
+			spec:  services.ReportSpec{Entity: "orders", Aggregations: count, GroupBy: []string{"pay_amount"}},
+			msgID: "report.not_groupable",
+		},
+		{
+			name: "aggregation_func",
+			spec: services.ReportSpec{Entity: "orders",
+				Aggregations: []services.ReportAggregation{{Func: "sleep(5)", Field: "pay_amount"}}},
+			msgID: "report.invalid_aggregation",
+		},
+		{
+			name: "aggregation_field",
+			spec: services.ReportSpec{Entity: "orders",
+				Aggregations: []services.ReportAggregation{{Func: "sum", Field: "(SELECT password FROM users)"}}},
+			msgID: "report.unknown_field",
+		},
+		{
+			name: "sort_column",
+			spec: services.ReportSpec{Entity: "orders", Aggregations: count, GroupBy: []string{"status"},
+				Sort: []services.ReportSort{{Column: injection}}},
+			msgID: "report.invalid_sort",
+		},
+		{
+			// 登记过但不在结果中的字段也不能排序
+			name: "sort_not_selected",
+			spec: services.ReportSpec{Entity: "orders", Aggregations: count, GroupBy: []string{"status"},
+				Sort: []services.ReportSort{{Column: "pay_amount"}}},
+			msgID: "report.invalid_sort",
+		},
+		{
+			name: "sort_too_many",
+			spec: services.ReportSpec{Entity: "orders", Aggregations: count,
+				Sort: []services.ReportSort{{Column: "count"}, {Column: "count"}}},
+			msgID: "report.invalid_sort",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := builder.Run(context.Background(), tc.spec)
+			var appErr *services.AppError
+			if !errors.As(err, &appErr) || !errors.Is(err, services.ErrValidation) {
+				t.Fatalf("期望校验错误 %s，实际 %v", tc.msgID, err)
+			}
+			if appErr.MsgID != tc.msgID {
+				t.Errorf("错误为 %s，期望 %s", appErr.MsgID, tc.msgID)
+			}
+		})
+	}
+}
+
+// TestReportBuilderBindsValues 筛选值作为绑定参数传给数据库，不出现在SQL文本中
+func TestReportBuilderBindsValues(t *testing.T) {
+	t.Parallel()
+	db := testhelpers.NewSeededDB(t)
+	builder := services.NewReportQueryBuilder(db)
+
+	filters := []services.ReportFilter{
+		{Field: "payment_method", Op: "eq", Value: injection},
+		{Field: "payment_method", Op: "contains", Value: "' OR '1'='1"},
+		{Field: "payment_method", Op: "in", Value: []interface{}{injection, "alipay"}},
+		{Field: "created_at", Op: "gte", Value: "2024-01-01"},
+	}
+	spec := services.ReportSpec{
+		Entity:       "orders",
+		Filters:      filters,
+		GroupBy:      []string{"payment_method"},
+		Aggregations: []services.ReportAggregation{{Func: "count"}},
+	}
+
+	query, _, _, err := builder.Build(db.Session(&gorm.Session{DryRun: true}), spec)
+	if err != nil {
+		t.Fatalf("生成查询失败: %v", err)
+	}
+	var rows []map[string]interface{}
+	stmt := query.Scan(&rows).Statement
+	sql := stmt.SQL.String()
+	for _, payload := range []string{"DROP TABLE", "'1'='1"} {
+		if strings.Contains(sql, payload) {
+			t.Errorf("筛选值被拼接进SQL: %s", sql)
+		}
+	}
+	wantVars := []interface{}{injection, "%' OR '1'='1%", injection, "alipay"}
+	for _, want := range wantVars {
+		if !containsVar(stmt.Vars, want) {
+			t.Errorf("绑定参数中没有 %q: %v", want, stmt.Vars)
+		}
+	}
+
+	// 实际执行：没有匹配的订单，users表不受影响
+	result, err := builder.Run(context.Background(), spec)
+	if err != nil {
+		t.Fatalf("执行报表失败: %v", err)
+	}
+	if len(result.Rows) != 0 {
+		t.Errorf("期望没有结果，实际 %v", result.Rows)
+	}
+	var users int64
+	if err := db.Model(&models.User{}).Count(&users).Error; err != nil || users == 0 {
+		t.Fatalf("users表被破坏: count=%d err=%v", users, err)
+	}
+}
+
+// TestReportBuilderGroupedReports 按示例数据验证分组报表的结果
+func TestReportBuilderGroupedReports(t *testing.T) {
+	t.Parallel()
+	db := testhelpers.NewSeededDB(t)
+	builder := services.NewReportQueryBuilder(db)
+
+	// 示例数据中的两门课程：golang-tutorial（初级，19900分）、react-tutorial（中级，24900分）
+	courseIDs := map[string]uint{}
+	var courses []models.Course
+	if err := db.Find(&courses).Error; err != nil {
+		t.Fatalf("查询课程失败: %v", err)
+	}
+	for _, c := range courses {
+		courseIDs[c.Slug] = c.ID
+	}
+
+	t.Run("courses_by_level", func(t *testing.T) {
+		result, err := builder.Run(context.Background(), services.ReportSpec{
+			Entity:  "courses",
+			GroupBy: []string{"level"},
+			Aggregations: []services.ReportAggregation{
+				{Func: "count"}, {Func: "sum", Field: "price"},
+			},
+		})
+		if err != nil {
+			t.Fatalf("执行报表失败: %v", err)
+		}
+		assertReport(t, result, []string{"level", "count", "sum_price"}, [][]int64{
+			{1, 1, 19900},
+			{2, 1, 24900},
+		})
+	})
+
+	t.Run("paid_orders_by_status", func(t *testing.T) {
+		f := factory.New(t, db)
+		s1, s2, s3 := f.User("student"), f.User("student"), f.User("student")
+		f.PaidOrder(s1.ID, courseIDs["golang-tutorial"])
+		f.PaidOrder(s2.ID, courseIDs["golang-tutorial"], courseIDs["react-tutorial"])
+		f.PendingOrder(s3.ID, courseIDs["react-tutorial"])
+
+		result, err := builder.Run(context.Background(), services.ReportSpec{
+			Entity: "orders",
+			Filters: []services.ReportFilter{
+				{Field: "pay_amount", Op: "gt", Value: float64(0)},
+			},
+			GroupBy: []string{"status"},
+			Aggregations: []services.ReportAggregation{
+				{Func: "count"}, {Func: "sum", Field: "pay_amount"},
+			},
+			Sort: []services.ReportSort{{Column: "sum_pay_amount", Desc: true}},
+		})
+		if err != nil {
+			t.Fatalf("执行报表失败: %v", err)
+		}
+		assertReport(t, result, []string{"status", "count", "sum_pay_amount"}, [][]int64{
+			{int64(models.OrderStatusPaid), 2, 19900 + 19900 + 24900},
+			{int64(models.OrderStatusPending), 1, 24900},
+		})
+	})
+}
+
+// assertReport 比较报表的列和各行的整数值
+func assertReport(t *testing.T, result *services.ReportResult, columns []string, want [][]int64) {
+	t.Helper()
+	if !reflect.DeepEqual(result.Columns, columns) {
+		t.Fatalf("结果列为 %v，期望 %v", result.Columns, columns)
+	}
+	got := make([][]int64, 0, len(result.Rows))
+	for _, row := range result.Rows {
+		values := make([]int64, 0, len(columns))
+		for _, column := range columns {
+			var v int64
+			if _, err := fmt.Sscan(fmt.Sprint(row[column]), &v); err != nil {
+				t.Fatalf("列 %s 的值 %v 不是整数", column, row[column])
+			}
+			values = append(values, v)
+		}
+		got = append(got, values)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("报表结果为 %v，期望 %v", got, want)
+	}
+}
+
+func containsVar(vars []interface{}, want interface{}) bool {
+	for _, v := range vars {
+		if reflect.DeepEqual(v, want) {
+			return true
+		}
+		if nested, ok := v.([]interface{}); ok && containsVar(nested, want) {
+			return true
+		}
+	}
+	return false
+}
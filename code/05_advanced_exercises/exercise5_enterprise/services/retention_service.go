@@ -0,0 +1,158 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"edu-platform/models"
+)
+
+// RetentionPolicy 可清理表的登记信息
+type RetentionPolicy struct {
+	Table       string // 表名（不带前缀）
+	TimeColumn  string // 判断过期的时间列
+	DefaultDays int    // 默认保留天数，可通过设置 retention.<表名>.days 覆盖
+}
+
+// retentionRegistry 允许清理的表，只有登记过的表和列才能被Purge删除
+var retentionRegistry = map[string]RetentionPolicy{
+	"learning_activities": {Table: "learning_activities", TimeColumn: "created_at", DefaultDays: 180},
+	"system_logs":         {Table: "system_logs", TimeColumn: "created_at", DefaultDays: 90},
+	// 验证码过期1天后清理（已验证的验证码同样以过期时间为准，届时凭证也已失效）
+	"verification_codes": {Table: "verification_codes", TimeColumn: "expires_at", DefaultDays: 1},
+	// 后台任务执行记录，管理接口只展示每个任务最近一次的执行
+	"job_runs": {Table: "job_runs", TimeColumn: "started_at", DefaultDays: 30},
+}
+
+const (
+	defaultPurgeBatchSize = 1000                   // 默认每批删除行数
+	purgeBatchPause       = 100 * time.Millisecond // 批次间暂停，降低主从复制延迟
+)
+
+// PurgeResult 清理结果
+type PurgeResult struct {
+	Table    string        `json:"table"`
+	Cutoff   time.Time     `json:"cutoff"`
+	DryRun   bool          `json:"dry_run"`
+	Rows     int64         `json:"rows"`    // 已删除行数（dry run时为将删除的行数）
+	Batches  int           `json:"batches"` // 执行的批次数
+	Duration time.Duration `json:"duration"`
+}
+
+// RetentionService 数据保留服务，分批清理过期的日志类数据
+type RetentionService struct {
+	db       *gorm.DB
+	settings *SettingsService
+}
+
+// NewRetentionService 创建数据保留服务
+func NewRetentionService(db *gorm.DB, settings *SettingsService) *RetentionService {
+	return &RetentionService{db: db, settings: settings}
+}
+
+// Policy 获取已登记的清理策略
+func (s *RetentionService) Policy(table string) (RetentionPolicy, error) {
+	policy, ok := retentionRegistry[table]
+	if !ok {
+		return RetentionPolicy{}, ErrValidation.WithMsg("retention.table_not_allowed", table)
+	}
+	return policy, nil
+}
+
+// RetentionDays 获取表的保留天数（设置优先，其次为默认值）
+func (s *RetentionService) RetentionDays(policy RetentionPolicy) int {
+	return s.settings.GetInt("retention."+policy.Table+".days", policy.DefaultDays)
+}
+
+// Count 统计早于olderThan的行数（dry run使用，不做任何修改）
+func (s *RetentionService) Count(table string, olderThan time.Duration) (PurgeResult, error) {
+	start := time.Now()
+	policy, err := s.Policy(table)
+	if err != nil {
+		return PurgeResult{}, err
+	}
+
+	result := PurgeResult{Table: table, Cutoff: time.Now().Add(-olderThan), DryRun: true}
+	err = s.db.Table(models.Table(s.db, policy.Table)).
+		Where(fmt.Sprintf("%s < ?", policy.TimeColumn), result.Cutoff).
+		Count(&result.Rows).Error
+	result.Duration = time.Since(start)
+	return result, err
+}
+
+// Purge 分批删除早于olderThan的行
+// MySQL使用 DELETE ... LIMIT n；SQLite不支持带LIMIT的DELETE，改用rowid子查询。
+// 每批之间短暂暂停，ctx取消时在当前批次完成后停止，并返回已删除的行数
+func (s *RetentionService) Purge(ctx context.Context, table string, olderThan time.Duration, batchSize int) (PurgeResult, error) {
+	start := time.Now()
+	policy, err := s.Policy(table)
+	if err != nil {
+		return PurgeResult{}, err
+	}
+	if batchSize <= 0 {
+		batchSize = defaultPurgeBatchSize
+	}
+
+	result := PurgeResult{Table: table, Cutoff: time.Now().Add(-olderThan)}
+	stmt := s.purgeStatement(policy)
+
+	for {
+		if err := ctx.Err(); err != nil {
+			result.Duration = time.Since(start)
+			return result, err
+		}
+
+		tx := s.db.WithContext(ctx).Exec(stmt, result.Cutoff, batchSize)
+		if tx.Error != nil {
+			result.Duration = time.Since(start)
+			return result, tx.Error
+		}
+
+		result.Batches++
+		result.Rows += tx.RowsAffected
+		if tx.RowsAffected < int64(batchSize) {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			result.Duration = time.Since(start)
+			return result, ctx.Err()
+		case <-time.After(purgeBatchPause):
+		}
+	}
+
+	result.Duration = time.Since(start)
+	return result, nil
+}
+
+// purgeStatement 按数据库方言生成单批删除语句，参数依次为截止时间和批大小
+func (s *RetentionService) purgeStatement(policy RetentionPolicy) string {
+	table := models.Table(s.db, policy.Table)
+	if s.db.Dialector.Name() == "sqlite" {
+		return fmt.Sprintf("DELETE FROM %s WHERE rowid IN (SELECT rowid FROM %s WHERE %s < ? LIMIT ?)",
+			table, table, policy.TimeColumn)
+	}
+	return fmt.Sprintf("DELETE FROM %s WHERE %s < ? LIMIT ?", table, policy.TimeColumn)
+}
+
+// PurgeAll 按各表的保留天数清理所有登记的表
+func (s *RetentionService) PurgeAll(ctx context.Context) ([]PurgeResult, error) {
+	var results []PurgeResult
+	for table, policy := range retentionRegistry {
+		days := s.RetentionDays(policy)
+		if days <= 0 {
+			continue // 保留天数<=0表示不清理
+		}
+
+		result, err := s.Purge(ctx, table, time.Duration(days)*24*time.Hour, defaultPurgeBatchSize)
+		results = append(results, result)
+		if err != nil {
+			return results, err
+		}
+	}
+	return results, nil
+}
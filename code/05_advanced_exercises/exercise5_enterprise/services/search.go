@@ -0,0 +1,211 @@
+package services
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"edu-platform/models"
+)
+
+const (
+	defaultSearchLimit     = 5   // 全站搜索每类默认返回的条数
+	maxSearchLimit         = 20  // 全站搜索每类最多返回的条数
+	maxSearchKeywordLength = 100 // 搜索关键词最大长度（字符）
+)
+
+// 全站搜索的分区名称，也是 SearchResults.Failed 中的取值
+const (
+	SearchSectionCourses = "courses"
+	SearchSectionPosts   = "posts"
+	SearchSectionUsers   = "users"
+)
+
+// SearchService 全站搜索服务
+type SearchService struct {
+	db *gorm.DB
+}
+
+// NewSearchService 创建全站搜索服务
+func NewSearchService(db *gorm.DB) *SearchService {
+	return &SearchService{db: db}
+}
+
+// CourseHit 课程搜索结果
+type CourseHit struct {
+	ID           uint   `json:"id"`
+	Title        string `json:"title"`
+	Subtitle     string `json:"subtitle"`
+	Cover        string `json:"cover"`
+	Price        int64  `json:"price"`
+	StudentCount int    `json:"student_count"`
+}
+
+// PostHit 帖子（课程讨论）搜索结果
+type PostHit struct {
+	ID          uint      `json:"id"`
+	CourseID    uint      `json:"course_id"`
+	CourseTitle string    `json:"course_title"`
+	Title       string    `json:"title"`
+	ReplyCount  int       `json:"reply_count"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// UserHit 用户搜索结果，只包含公开信息
+type UserHit struct {
+	ID       uint   `json:"id"`
+	Username string `json:"username"`
+	Nickname string `json:"nickname"`
+	Avatar   string `json:"avatar"`
+}
+
+// SearchResults 全站搜索结果，按类型分区；查询失败的分区为空列表，分区名记录在 Failed 中
+type SearchResults struct {
+	Courses []CourseHit `json:"courses"`
+	Posts   []PostHit   `json:"posts"`
+	Users   []UserHit   `json:"users"`
+	Failed  []string    `json:"failed,omitempty"`
+}
+
+// SearchError 全站搜索中部分分区查询失败，按分区记录错误；其他分区的结果照常返回
+type SearchError struct {
+	Sections map[string]error
+}
+
+func (e *SearchError) Error() string {
+	names := make([]string, 0, len(e.Sections))
+	for name := range e.Sections {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	msgs := make([]string, 0, len(names))
+	for _, name := range names {
+		msgs = append(msgs, name+": "+e.Sections[name].Error())
+	}
+	return "搜索失败: " + strings.Join(msgs, "; ")
+}
+
+// AllFailed 是否全部分区都查询失败
+func (e *SearchError) AllFailed() bool {
+	return len(e.Sections) == 3
+}
+
+// GlobalSearch 按关键词同时搜索课程、帖子和用户，每类最多返回limit条
+// 三类查询并行执行，只返回对外可见的内容：已发布的课程、已发布课程下的讨论帖、状态正常的用户；
+// 某一类查询失败不影响其他类，结果照常返回，同时返回 *SearchError 说明失败的分区
+func (s *SearchService) GlobalSearch(ctx context.Context, keyword string, limit int) (SearchResults, error) {
+	results := SearchResults{Courses: []CourseHit{}, Posts: []PostHit{}, Users: []UserHit{}}
+
+	keyword = strings.TrimSpace(keyword)
+	if keyword == "" {
+		return results, ErrValidation.WithMsg("search.keyword_required")
+	}
+	if len([]rune(keyword)) > maxSearchKeywordLength {
+		return results, ErrValidation.WithMsg("search.keyword_too_long", maxSearchKeywordLength)
+	}
+	if limit <= 0 {
+		limit = defaultSearchLimit
+	}
+	if limit > maxSearchLimit {
+		limit = maxSearchLimit
+	}
+
+	// 转义LIKE通配符，用户输入只作为普通文本匹配
+	pattern := "%" + strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(keyword) + "%"
+	db := s.db.WithContext(ctx)
+
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		errs   = make(map[string]error)
+		record = func(section string, err error) {
+			if err != nil {
+				mu.Lock()
+				errs[section] = err
+				mu.Unlock()
+			}
+		}
+	)
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		record(SearchSectionCourses, searchCourses(db, pattern, limit, &results.Courses))
+	}()
+	go func() {
+		defer wg.Done()
+		record(SearchSectionPosts, searchPosts(db, pattern, limit, &results.Posts))
+	}()
+	go func() {
+		defer wg.Done()
+		record(SearchSectionUsers, searchUsers(db, pattern, limit, &results.Users))
+	}()
+	wg.Wait()
+
+	if len(errs) == 0 {
+		return results, nil
+	}
+	for _, section := range []string{SearchSectionCourses, SearchSectionPosts, SearchSectionUsers} {
+		if _, failed := errs[section]; failed {
+			results.Failed = append(results.Failed, section)
+		}
+	}
+	return results, &SearchError{Sections: errs}
+}
+
+// searchCourses 标题或副标题包含关键词的已发布课程，学生多的在前
+func searchCourses(db *gorm.DB, pattern string, limit int, hits *[]CourseHit) error {
+	courses := []CourseHit{}
+	err := db.Model(&models.Course{}).
+		Select("id, title, subtitle, cover, price, student_count").
+		Scopes(AvailableCourses()).
+		Where("title LIKE ? ESCAPE '!' OR subtitle LIKE ? ESCAPE '!'", pattern, pattern).
+		Order("student_count DESC, id ASC").
+		Limit(limit).
+		Scan(&courses).Error
+	if err != nil {
+		return err
+	}
+	*hits = courses
+	return nil
+}
+
+// searchPosts 标题或内容包含关键词的讨论帖，只搜索已发布课程下的帖子，新帖在前
+func searchPosts(db *gorm.DB, pattern string, limit int, hits *[]PostHit) error {
+	posts := []PostHit{}
+	err := db.Table(models.TableAs(db, "course_threads")).
+		Select("course_threads.id, course_threads.course_id, courses.title AS course_title, "+
+			"course_threads.title, course_threads.reply_count, course_threads.created_at").
+		Joins("JOIN "+models.TableAs(db, "courses")+" ON courses.id = course_threads.course_id").
+		Scopes(AvailableCoursesIn("courses")).
+		Where("course_threads.deleted_at IS NULL").
+		Where("course_threads.title LIKE ? ESCAPE '!' OR course_threads.body LIKE ? ESCAPE '!'", pattern, pattern).
+		Order("course_threads.created_at DESC, course_threads.id DESC").
+		Limit(limit).
+		Scan(&posts).Error
+	if err != nil {
+		return err
+	}
+	*hits = posts
+	return nil
+}
+
+// searchUsers 用户名或昵称包含关键词的正常状态用户，只返回公开字段
+func searchUsers(db *gorm.DB, pattern string, limit int, hits *[]UserHit) error {
+	users := []UserHit{}
+	err := db.Model(&models.User{}).
+		Select("id, username, nickname, avatar").
+		Where("status = ?", models.UserStatusActive).
+		Where("username LIKE ? ESCAPE '!' OR nickname LIKE ? ESCAPE '!'", pattern, pattern).
+		Order("id ASC").
+		Limit(limit).
+		Scan(&users).Error
+	if err != nil {
+		return err
+	}
+	*hits = users
+	return nil
+}
@@ -0,0 +1,204 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"gorm.io/gorm"
+
+	"edu-platform/models"
+	"edu-platform/services"
+	"edu-platform/testhelpers"
+	"edu-platform/testhelpers/factory"
+)
+
+// TestGlobalSearchSections 三类结果合并在一个响应中：各分区只包含对外可见的内容，并按各自的规则排序
+func TestGlobalSearchSections(t *testing.T) {
+	t.Parallel()
+	db := testhelpers.NewDB(t)
+	f := factory.New(t, db)
+
+	intro := searchCourse(t, f, db, "Go 入门", models.CourseStatusPublished, 10)
+	advanced := searchCourse(t, f, db, "Go 进阶", models.CourseStatusPublished, 30)
+	searchCourse(t, f, db, "Go 草稿", models.CourseStatusDraft, 50)
+	unpublished := searchCourse(t, f, db, "高级Go", models.CourseStatusUnpublished, 40)
+	other := searchCourse(t, f, db, "React 入门", models.CourseStatusPublished, 20)
+
+	author := f.User("student")
+	first := searchThread(t, db, intro.ID, author.ID, "Go 的 defer 问题", "")
+	second := searchThread(t, db, other.ID, author.ID, "接口问题", "和 Go 比较")
+	searchThread(t, db, unpublished.ID, author.ID, "Go 下架课程的帖子", "")
+	deleted := searchThread(t, db, advanced.ID, author.ID, "Go 已删除的帖子", "")
+	if err := db.Delete(deleted).Error; err != nil {
+		t.Fatalf("删除帖子失败: %v", err)
+	}
+
+	gopher := f.User("student")
+	disabled := f.User("student")
+	if err := db.Model(gopher).Update("nickname", "Gopher").Error; err != nil {
+		t.Fatalf("修改昵称失败: %v", err)
+	}
+	if err := db.Model(disabled).Updates(map[string]interface{}{"nickname": "Gone", "status": models.UserStatusDisabled}).Error; err != nil {
+		t.Fatalf("禁用用户失败: %v", err)
+	}
+
+	results, err := services.NewSearchService(db).GlobalSearch(context.Background(), " go ", 0)
+	if err != nil {
+		t.Fatalf("搜索失败: %v", err)
+	}
+	// 课程按学生数倒序；帖子新帖在前；用户按ID
+	if got, want := courseHitIDs(results.Courses), []uint{advanced.ID, intro.ID}; !reflect.DeepEqual(got, want) {
+		t.Errorf("课程结果为 %v，期望 %v", got, want)
+	}
+	if got, want := postHitIDs(results.Posts), []uint{second.ID, first.ID}; !reflect.DeepEqual(got, want) {
+		t.Errorf("帖子结果为 %v，期望 %v", got, want)
+	}
+	if len(results.Posts) == 2 && results.Posts[1].CourseTitle != "Go 入门" {
+		t.Errorf("帖子的课程名称为 %q，期望 %q", results.Posts[1].CourseTitle, "Go 入门")
+	}
+	if len(results.Users) != 1 || results.Users[0].ID != gopher.ID {
+		t.Errorf("用户结果为 %+v，期望只有 %d", results.Users, gopher.ID)
+	}
+	if results.Failed != nil {
+		t.Errorf("Failed 为 %v，期望为空", results.Failed)
+	}
+}
+
+// TestGlobalSearchLimits 每类结果分别限制条数：未传时为5，超过20时按20
+func TestGlobalSearchLimits(t *testing.T) {
+	t.Parallel()
+	db := testhelpers.NewDB(t)
+	f := factory.New(t, db)
+
+	for i := 0; i < 4; i++ {
+		searchCourse(t, f, db, "limit 课程", models.CourseStatusPublished, i)
+	}
+	// 课程的讲师也是用户，这里另外再建够21个昵称匹配的用户
+	for i := 0; i < 21; i++ {
+		if err := db.Model(f.User("student")).Update("nickname", "limit 用户").Error; err != nil {
+			t.Fatalf("修改昵称失败: %v", err)
+		}
+	}
+
+	cases := []struct {
+		limit          int
+		courses, users int
+	}{
+		{limit: 0, courses: 4, users: 5},
+		{limit: -1, courses: 4, users: 5},
+		{limit: 3, courses: 3, users: 3},
+		{limit: 50, courses: 4, users: 20},
+	}
+	search := services.NewSearchService(db)
+	for _, tc := range cases {
+		results, err := search.GlobalSearch(context.Background(), "limit", tc.limit)
+		if err != nil {
+			t.Fatalf("limit=%d: 搜索失败: %v", tc.limit, err)
+		}
+		if len(results.Courses) != tc.courses || len(results.Users) != tc.users || len(results.Posts) != 0 {
+			t.Errorf("limit=%d: 课程 %d 条、用户 %d 条、帖子 %d 条，期望 %d、%d、0",
+				tc.limit, len(results.Courses), len(results.Users), len(results.Posts), tc.courses, tc.users)
+		}
+	}
+}
+
+// TestGlobalSearchEscapesWildcards 关键词中的 %、_ 和转义字符 ! 按普通字符匹配
+func TestGlobalSearchEscapesWildcards(t *testing.T) {
+	t.Parallel()
+	db := testhelpers.NewDB(t)
+	f := factory.New(t, db)
+
+	percent := searchCourse(t, f, db, "100% 实战", models.CourseStatusPublished, 0)
+	searchCourse(t, f, db, "100 实战", models.CourseStatusPublished, 0)
+	underscore := searchCourse(t, f, db, "snake_case 规范", models.CourseStatusPublished, 0)
+	searchCourse(t, f, db, "snakeXcase 规范", models.CourseStatusPublished, 0)
+	bang := searchCourse(t, f, db, "Hello!", models.CourseStatusPublished, 0)
+
+	cases := map[string][]uint{
+		"%":          {percent.ID},
+		"100%":       {percent.ID},
+		"snake_case": {underscore.ID},
+		"!":          {bang.ID},
+		"!%":         nil,
+	}
+	search := services.NewSearchService(db)
+	for keyword, want := range cases {
+		results, err := search.GlobalSearch(context.Background(), keyword, 0)
+		if err != nil {
+			t.Fatalf("%q: 搜索失败: %v", keyword, err)
+		}
+		if got := courseHitIDs(results.Courses); !reflect.DeepEqual(got, want) {
+			t.Errorf("%q: 课程结果为 %v，期望 %v", keyword, got, want)
+		}
+	}
+}
+
+// TestGlobalSearchPartialFailure 一个分区查询失败时其他分区照常返回，错误中只包含失败的分区
+func TestGlobalSearchPartialFailure(t *testing.T) {
+	t.Parallel()
+	db := testhelpers.NewDB(t)
+	f := factory.New(t, db)
+
+	course := searchCourse(t, f, db, "Go 入门", models.CourseStatusPublished, 0)
+	if err := db.Migrator().DropTable(&models.CourseThread{}); err != nil {
+		t.Fatalf("删除帖子表失败: %v", err)
+	}
+
+	results, err := services.NewSearchService(db).GlobalSearch(context.Background(), "Go", 0)
+	var searchErr *services.SearchError
+	if !errors.As(err, &searchErr) {
+		t.Fatalf("错误为 %v，期望 *SearchError", err)
+	}
+	if _, ok := searchErr.Sections[services.SearchSectionPosts]; !ok || len(searchErr.Sections) != 1 || searchErr.AllFailed() {
+		t.Errorf("失败的分区为 %v，期望只有 posts", searchErr.Sections)
+	}
+	if !reflect.DeepEqual(results.Failed, []string{services.SearchSectionPosts}) {
+		t.Errorf("Failed 为 %v，期望 [posts]", results.Failed)
+	}
+	if got := courseHitIDs(results.Courses); !reflect.DeepEqual(got, []uint{course.ID}) {
+		t.Errorf("课程结果为 %v，期望 [%d]", got, course.ID)
+	}
+	if results.Posts == nil || len(results.Posts) != 0 {
+		t.Errorf("失败分区的结果为 %v，期望空列表", results.Posts)
+	}
+}
+
+// searchCourse 创建指定标题、状态和学生数的课程
+func searchCourse(t *testing.T, f *factory.Factory, db *gorm.DB, title string, status models.CourseStatus, students int) *models.Course {
+	t.Helper()
+	course := f.Course(9900)
+	if err := db.Model(course).Updates(map[string]interface{}{
+		"title": title, "status": status, "student_count": students,
+	}).Error; err != nil {
+		t.Fatalf("修改课程失败: %v", err)
+	}
+	return course
+}
+
+// searchThread 创建讨论帖，后创建的帖子更新
+func searchThread(t *testing.T, db *gorm.DB, courseID, authorID uint, title, body string) *models.CourseThread {
+	t.Helper()
+	thread := &models.CourseThread{CourseID: courseID, AuthorID: authorID, Title: title, Body: body}
+	if err := db.Create(thread).Error; err != nil {
+		t.Fatalf("创建帖子失败: %v", err)
+	}
+	return thread
+}
+
+func courseHitIDs(hits []services.CourseHit) []uint {
+	var ids []uint
+	for _, hit := range hits {
+		ids = append(ids, hit.ID)
+	}
+	return ids
+}
+
+func postHitIDs(hits []services.PostHit) []uint {
+	var ids []uint
+	for _, hit := range hits {
+		ids = append(ids, hit.ID)
+	}
+	return ids
+}
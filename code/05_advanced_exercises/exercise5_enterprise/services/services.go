@@ -1,14 +1,24 @@
 package services
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"log"
 	"time"
 
 	"gorm.io/gorm"
-	"../models"
+	"gorm.io/gorm/clause"
+
+	"edu-platform/models"
 )
 
+// adminRoleName 管理员角色名
+const adminRoleName = "admin"
+
+// studentRoleName 学生角色名，注册用户的默认角色
+const studentRoleName = "student"
+
 // UserService 用户服务
 type UserService struct {
 	db *gorm.DB
@@ -19,30 +29,59 @@ func NewUserService(db *gorm.DB) *UserService {
 	return &UserService{db: db}
 }
 
-// CreateUser 创建用户
-func (s *UserService) CreateUser(user *models.User) error {
+// WithContext 返回使用ctx执行查询的副本，与共享的服务使用同一个数据库连接池
+// 控制器传入请求的context，请求取消时查询随之取消，X-Debug-SQL 也能记录到这些查询
+func (s *UserService) WithContext(ctx context.Context) *UserService {
+	return &UserService{db: s.db.WithContext(ctx)}
+}
+
+// CreateUser 创建用户，verificationToken为邮箱或手机号通过注册验证后得到的凭证，创建成功后失效
+// 未指定角色时使用学生角色，按角色名查找，不依赖角色ID
+func (s *UserService) CreateUser(user *models.User, verificationToken string) error {
 	// 检查用户名是否已存在
 	var count int64
 	s.db.Model(&models.User{}).Where("username = ?", user.Username).Count(&count)
 	if count > 0 {
-		return errors.New("用户名已存在")
+		return ErrConflict.WithMsg("user.username_exists")
 	}
 
 	// 检查邮箱是否已存在
 	s.db.Model(&models.User{}).Where("email = ?", user.Email).Count(&count)
 	if count > 0 {
-		return errors.New("邮箱已存在")
+		return ErrConflict.WithMsg("user.email_exists")
 	}
 
 	// 检查手机号是否已存在
 	if user.Phone != "" {
 		s.db.Model(&models.User{}).Where("phone = ?", user.Phone).Count(&count)
 		if count > 0 {
-			return errors.New("手机号已存在")
+			return ErrConflict.WithMsg("user.phone_exists")
+		}
+	}
+
+	// 密码以bcrypt哈希保存
+	if !isPasswordHash(user.Password) {
+		hash, err := hashPassword(user.Password)
+		if err != nil {
+			return err
 		}
+		user.Password = hash
 	}
 
-	return s.db.Create(user).Error
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		targets := []string{user.Email, user.Phone}
+		if err := consumeVerificationToken(tx, targets, PurposeRegister, verificationToken); err != nil {
+			return err
+		}
+		if user.RoleID == 0 {
+			var role models.Role
+			if err := tx.Where("name = ?", studentRoleName).First(&role).Error; err != nil {
+				return fmt.Errorf("查询学生角色失败: %w", err)
+			}
+			user.RoleID = role.ID
+		}
+		return tx.Create(user).Error
+	})
 }
 
 // GetUserByID 根据ID获取用户
@@ -51,7 +90,7 @@ func (s *UserService) GetUserByID(id uint) (*models.User, error) {
 	err := s.db.Preload("Role").Preload("Profile").First(&user, id).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("用户不存在")
+			return nil, ErrNotFound.WithMsg("user.not_found")
 		}
 		return nil, err
 	}
@@ -64,29 +103,58 @@ func (s *UserService) GetUserByEmail(email string) (*models.User, error) {
 	err := s.db.Preload("Role").Where("email = ?", email).First(&user).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("用户不存在")
+			return nil, ErrNotFound.WithMsg("user.not_found")
 		}
 		return nil, err
 	}
 	return &user, nil
 }
 
+// IsAdmin 用户是否为管理员角色
+func (s *UserService) IsAdmin(id uint) (bool, error) {
+	var count int64
+	err := s.db.Model(&models.User{}).Table(models.TableAs(s.db, "users")).
+		Joins("JOIN "+models.TableAs(s.db, "roles")+" ON roles.id = users.role_id").
+		Where("users.id = ? AND roles.name = ?", id, adminRoleName).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// TokenVersion 用户当前的token版本，认证中间件据此拒绝修改或重置密码前签发的token
+func (s *UserService) TokenVersion(id uint) (uint, error) {
+	var user models.User
+	if err := s.db.Select("id", "token_version").First(&user, id).Error; err != nil {
+		return 0, err
+	}
+	return user.TokenVersion, nil
+}
+
 // UpdateUser 更新用户信息
 func (s *UserService) UpdateUser(id uint, updates map[string]interface{}) error {
 	return s.db.Model(&models.User{}).Where("id = ?", id).Updates(updates).Error
 }
 
-// UpdateLastLogin 更新最后登录时间
-func (s *UserService) UpdateLastLogin(id uint, ip string) error {
+// UpdateLastLogin 更新最后登录时间，并记录登录历史
+func (s *UserService) UpdateLastLogin(id uint, ip, userAgent string) error {
 	now := time.Now()
-	return s.db.Model(&models.User{}).Where("id = ?", id).Updates(map[string]interface{}{
+	err := s.db.Model(&models.User{}).Where("id = ?", id).Updates(map[string]interface{}{
 		"last_login_at": &now,
 		"login_ip":      ip,
 	}).Error
+	if err != nil {
+		return err
+	}
+
+	return s.db.Create(&models.LoginHistory{
+		UserID:     id,
+		IP:         ip,
+		UserAgent:  userAgent,
+		LoggedInAt: now,
+	}).Error
 }
 
-// GetUsers 获取用户列表
-func (s *UserService) GetUsers(page, pageSize int, filters map[string]interface{}) ([]models.User, int64, error) {
+// GetUsers 获取用户列表，按注册时间倒序；游标分页和深分页时按id倒序
+func (s *UserService) GetUsers(p Pagination, filters map[string]interface{}) ([]models.User, int64, error) {
 	var users []models.User
 	var total int64
 
@@ -111,65 +179,138 @@ func (s *UserService) GetUsers(page, pageSize int, filters map[string]interface{
 	}
 
 	// 分页查询
-	offset := (page - 1) * pageSize
-	err := query.Preload("Role").Preload("Profile").
-		Order("created_at DESC").Limit(pageSize).Offset(offset).Find(&users).Error
+	query, err := Paginate(query, p, "id", "created_at DESC")
+	if err != nil {
+		return nil, 0, err
+	}
+	err = query.Preload("Role").Preload("Profile").Find(&users).Error
 
 	return users, total, err
 }
 
 // CourseService 课程服务
 type CourseService struct {
-	db *gorm.DB
+	db          *gorm.DB
+	suggestions *CourseSuggestIndex // 搜索建议索引，可为nil
+	slugs       *SlugGenerator
 }
 
 // NewCourseService 创建课程服务
-func NewCourseService(db *gorm.DB) *CourseService {
-	return &CourseService{db: db}
+func NewCourseService(db *gorm.DB, suggestions *CourseSuggestIndex) *CourseService {
+	return &CourseService{db: db, suggestions: suggestions, slugs: NewCourseSlugGenerator()}
 }
 
-// CreateCourse 创建课程
-func (s *CourseService) CreateCourse(course *models.Course) error {
-	// 检查课程标识是否已存在
-	var count int64
-	s.db.Model(&models.Course{}).Where("slug = ?", course.Slug).Count(&count)
-	if count > 0 {
-		return errors.New("课程标识已存在")
+// CreateCourse 创建课程，未指定标识时根据标题生成；tagNames 不为空时在同一事务中设置课程标签
+func (s *CourseService) CreateCourse(course *models.Course, tagNames []string) error {
+	if course.Slug == "" {
+		slug, err := s.slugs.Generate(s.db, course.Title)
+		if err != nil {
+			return err
+		}
+		course.Slug = slug
+	} else {
+		if err := s.slugs.Validate(course.Slug); err != nil {
+			return err
+		}
+		// 检查课程标识是否已存在（包括其他课程改名前的旧标识）
+		taken, err := s.slugs.Taken(s.db, course.Slug)
+		if err != nil {
+			return err
+		}
+		if taken {
+			return ErrConflict.WithMsg("course.slug_exists")
+		}
 	}
 
-	return s.db.Create(course).Error
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(course).Error; err != nil {
+			return err
+		}
+		if len(tagNames) == 0 {
+			return nil
+		}
+		tags, err := ReplaceCourseTags(tx, course.ID, tagNames)
+		course.Tags = tags
+		return err
+	})
 }
 
-// GetCourseByID 根据ID获取课程详情
-func (s *CourseService) GetCourseByID(id uint) (*models.Course, error) {
+// ResolveCourseSlug 按标识查找课程，返回课程ID和当前标识
+// 课程改名重新生成标识后，旧标识同样能找到课程，此时返回的当前标识与传入的不同，调用方据此跳转
+func (s *CourseService) ResolveCourseSlug(slug string) (uint, string, error) {
 	var course models.Course
-	err := s.db.Preload("Category").Preload("Instructor").
-		Preload("Chapters.Lessons").First(&course, id).Error
+	err := s.db.Select("id", "slug").Where("slug = ?", slug).First(&course).Error
+	if err == nil {
+		return course.ID, course.Slug, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, "", err
+	}
+
+	var redirect models.SlugRedirect
+	if err := s.db.Where("old_slug = ?", slug).First(&redirect).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, "", ErrNotFound.WithMsg("course.not_found")
+		}
+		return 0, "", err
+	}
+	if err := s.db.Select("id", "slug").First(&course, redirect.CourseID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, "", ErrNotFound.WithMsg("course.not_found")
+		}
+		return 0, "", err
+	}
+	return course.ID, course.Slug, nil
+}
+
+// GetCourseByID 根据ID获取课程详情，只预加载includes中的关联；viewerID不为0时记录该用户的浏览
+func (s *CourseService) GetCourseByID(ctx context.Context, id, viewerID uint, includes Includes) (*models.Course, error) {
+	db := s.db.WithContext(ctx)
+	var course models.Course
+	err := includes.Preload(db).First(&course, id).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("课程不存在")
+			return nil, ErrNotFound.WithMsg("course.not_found")
 		}
 		return nil, err
 	}
 
+	// 讲师或分类已被删除时，按设置返回占位记录，否则详情中不返回该关联
+	fillCoursePlaceholders(db, &course, includes)
+
+	promotions, err := activePromotions(db, []uint{course.ID}, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	course.ActivePromotion = promotions[course.ID]
+
 	// 增加浏览次数
-	s.db.Model(&course).Update("view_count", gorm.Expr("view_count + ?", 1))
+	db.Model(&course).Update("view_count", gorm.Expr("view_count + ?", 1))
+
+	if viewerID != 0 {
+		if err := s.RecordView(db, viewerID, course.ID); err != nil {
+			log.Printf("记录课程浏览失败: user=%d course=%d: %v", viewerID, course.ID, err)
+		}
+	}
 
 	return &course, nil
 }
 
 // GetCourses 获取课程列表
-func (s *CourseService) GetCourses(page, pageSize int, filters map[string]interface{}) ([]models.Course, int64, error) {
+// 游标分页按id倒序，只能与默认排序（最新）一起使用，指定其他排序时返回422
+func (s *CourseService) GetCourses(ctx context.Context, p Pagination, filters map[string]interface{}) ([]models.Course, int64, error) {
 	var courses []models.Course
 	var total int64
 
-	query := s.db.Model(&models.Course{})
+	query := s.db.WithContext(ctx).Model(&models.Course{})
 
 	// 应用过滤条件
 	for key, value := range filters {
 		switch key {
 		case "status":
 			query = query.Where("status = ?", value)
+		case "available":
+			query = query.Scopes(AvailableCourses())
 		case "category_id":
 			query = query.Where("category_id = ?", value)
 		case "instructor_id":
@@ -187,17 +328,17 @@ func (s *CourseService) GetCourses(page, pageSize int, filters map[string]interf
 			query = query.Where("price >= ?", value)
 		case "price_max":
 			query = query.Where("price <= ?", value)
+		case "tags":
+			if names := filterTagNames(value.([]string)); len(names) > 0 {
+				query = query.Where("id IN (?)", coursesWithTags(s.db, names, true))
+			}
+		case "any_tags":
+			if names := filterTagNames(value.([]string)); len(names) > 0 {
+				query = query.Where("id IN (?)", coursesWithTags(s.db, names, false))
+			}
 		}
 	}
 
-	// 获取总数
-	if err := query.Count(&total).Error; err != nil {
-		return nil, 0, err
-	}
-
-	// 分页查询
-	offset := (page - 1) * pageSize
-
 	// 排序
 	orderBy := "created_at DESC"
 	if sort, ok := filters["sort"]; ok {
@@ -214,25 +355,119 @@ func (s *CourseService) GetCourses(page, pageSize int, filters map[string]interf
 			orderBy = "created_at DESC"
 		}
 	}
+	if p.Keyset() && orderBy != "created_at DESC" {
+		return nil, 0, ErrInvalidParam.WithMsg("error.invalid_param", "sort").WithDetails(map[string]interface{}{"field": "sort"})
+	}
+
+	// 获取总数
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
 
-	err := query.Preload("Category").Preload("Instructor").
-		Order(orderBy).Limit(pageSize).Offset(offset).Find(&courses).Error
+	// 分页查询
+	query, err := Paginate(query, p, "id", orderBy)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := query.Preload("Category").Preload("Instructor").Preload("Tags").Find(&courses).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := ApplyPromotions(s.db.WithContext(ctx), courses, time.Now()); err != nil {
+		return nil, 0, err
+	}
 
-	return courses, total, err
+	return courses, total, nil
 }
 
 // UpdateCourse 更新课程信息
-func (s *CourseService) UpdateCourse(id uint, updates map[string]interface{}) error {
-	return s.db.Model(&models.Course{}).Where("id = ?", id).Updates(updates).Error
+// regenerateSlug为true且标题有变化时，根据新标题重新生成标识，旧标识记录到 slug_redirects，按旧标识访问时跳转到新标识
+func (s *CourseService) UpdateCourse(id uint, updates map[string]interface{}, regenerateSlug bool) error {
+	title, titleChanged := updates["title"].(string)
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if regenerateSlug && titleChanged {
+			var course models.Course
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id", "title", "slug").
+				First(&course, id).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return ErrNotFound.WithMsg("course.not_found")
+				}
+				return err
+			}
+			if course.Title != title && !s.slugs.DerivedFrom(course.Slug, title) {
+				slug, err := s.slugs.Generate(tx, title)
+				if err != nil {
+					return err
+				}
+				if err := tx.Create(&models.SlugRedirect{OldSlug: course.Slug, CourseID: course.ID}).Error; err != nil {
+					return err
+				}
+				updates["slug"] = slug
+			}
+		}
+		if err := tx.Model(&models.Course{}).Where("id = ?", id).Updates(updates).Error; err != nil {
+			return err
+		}
+		// 提高或取消人数上限后，空出的名额通知候补用户
+		if _, ok := updates["max_students"]; ok {
+			if _, err := offerSeats(tx, id, time.Now()); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// 标题或分类变化时刷新搜索建议
+	_, categoryChanged := updates["category_id"]
+	if titleChanged || categoryChanged {
+		s.refreshSuggestions()
+	}
+	return nil
 }
 
 // PublishCourse 发布课程
 func (s *CourseService) PublishCourse(id uint) error {
 	now := time.Now()
-	return s.db.Model(&models.Course{}).Where("id = ?", id).Updates(map[string]interface{}{
-		"status":       2, // 发布状态
+	err := s.db.Model(&models.Course{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":       models.CourseStatusPublished,
 		"published_at": &now,
 	}).Error
+	if err != nil {
+		return err
+	}
+
+	s.refreshSuggestions()
+	return nil
+}
+
+// UnpublishCourse 下架课程
+func (s *CourseService) UnpublishCourse(id uint) error {
+	if err := s.db.Model(&models.Course{}).Where("id = ?", id).Update("status", models.CourseStatusUnpublished).Error; err != nil {
+		return err
+	}
+
+	s.refreshSuggestions()
+	return nil
+}
+
+// SearchSuggestions 搜索框输入提示，从内存索引返回匹配的课程和分类名
+func (s *CourseService) SearchSuggestions(q string) SuggestResult {
+	if s.suggestions == nil {
+		return SuggestResult{Courses: []CourseSuggestion{}, Categories: []string{}}
+	}
+	return s.suggestions.Suggest(q, defaultSuggestLimit)
+}
+
+// refreshSuggestions 课程上下架后重建搜索建议索引，失败只记录日志
+func (s *CourseService) refreshSuggestions() {
+	if s.suggestions == nil {
+		return
+	}
+	if err := s.suggestions.Rebuild(); err != nil {
+		log.Printf("重建课程搜索建议索引失败: %v", err)
+	}
 }
 
 // OrderService 订单服务
@@ -245,8 +480,18 @@ func NewOrderService(db *gorm.DB) *OrderService {
 	return &OrderService{db: db}
 }
 
+// WithContext 返回使用ctx执行查询的副本，见 UserService.WithContext
+func (s *OrderService) WithContext(ctx context.Context) *OrderService {
+	return &OrderService{db: s.db.WithContext(ctx)}
+}
+
 // CreateOrder 创建订单
-func (s *OrderService) CreateOrder(userID uint, courseIDs []uint, couponCode string) (*models.Order, error) {
+// bundleIDs中的课程包会展开为其中的课程，课程包价格按课程原价比例分摊到各订单项
+func (s *OrderService) CreateOrder(userID uint, courseIDs, bundleIDs []uint, couponCode string) (*models.Order, error) {
+	if len(courseIDs) == 0 && len(bundleIDs) == 0 {
+		return nil, ErrValidation.WithMsg("order.empty")
+	}
+
 	// 开启事务
 	tx := s.db.Begin()
 	defer func() {
@@ -255,33 +500,83 @@ func (s *OrderService) CreateOrder(userID uint, courseIDs []uint, couponCode str
 		}
 	}()
 
-	// 查询课程信息
+	// 查询课程信息，同时JOIN分类和讲师用于订单项快照
 	var courses []models.Course
-	if err := tx.Where("id IN ? AND status = ?", courseIDs, 2).Find(&courses).Error; err != nil {
+	if len(courseIDs) > 0 {
+		if err := withSnapshotJoins(tx).Scopes(AvailableCourses()).Find(&courses, courseIDs).Error; err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+
+		if len(courses) != len(courseIDs) {
+			tx.Rollback()
+			return nil, ErrNotFound.WithMsg("course.unavailable")
+		}
+
+		// 检查用户是否已购买过这些课程（已退款的课程可以重新购买）
+		var enrolledCount int64
+		tx.Model(&models.Enrollment{}).
+			Where("user_id = ? AND course_id IN ? AND status = ?", userID, courseIDs, 1).
+			Count(&enrolledCount)
+
+		if enrolledCount > 0 {
+			tx.Rollback()
+			return nil, ErrConflict.WithMsg("order.already_purchased")
+		}
+	}
+
+	// 展开课程包
+	bundleLines, err := expandBundles(tx, userID, bundleIDs)
+	if err != nil {
 		tx.Rollback()
 		return nil, err
 	}
 
-	if len(courses) != len(courseIDs) {
-		tx.Rollback()
-		return nil, errors.New("部分课程不存在或已下架")
+	// 同一门课程不能在订单中出现多次
+	seen := make(map[uint]bool)
+	for _, course := range courses {
+		seen[course.ID] = true
+	}
+	for _, line := range bundleLines {
+		if seen[line.Course.ID] {
+			tx.Rollback()
+			return nil, ErrValidation.WithMsg("order.duplicate_course", line.Course.Title)
+		}
+		seen[line.Course.ID] = true
 	}
 
-	// 检查用户是否已购买过这些课程
-	var existingOrders []models.Order
-	tx.Joins("JOIN order_items ON orders.id = order_items.order_id").
-		Where("orders.user_id = ? AND order_items.course_id IN ? AND orders.status IN ?", 
-			userID, courseIDs, []int{2, 3}).Find(&existingOrders)
+	// 必须先学完先修课程
+	orderCourseIDs := make([]uint, 0, len(seen))
+	for id := range seen {
+		orderCourseIDs = append(orderCourseIDs, id)
+	}
+	if err := checkPrerequisites(tx, userID, orderCourseIDs); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
 
-	if len(existingOrders) > 0 {
+	// 限额课程满员时不能下单，只能加入候补（持有候补名额的用户除外）
+	orderCourses := make([]models.Course, 0, len(courses)+len(bundleLines))
+	orderCourses = append(orderCourses, courses...)
+	for _, line := range bundleLines {
+		orderCourses = append(orderCourses, line.Course)
+	}
+	if err := checkCourseCapacity(tx, userID, orderCourses, time.Now()); err != nil {
 		tx.Rollback()
-		return nil, errors.New("您已购买过部分课程")
+		return nil, err
 	}
 
-	// 计算总金额
+	// 计算总金额，单独购买的课程在限时促销期间按促销价，课程包按课程包价格
+	if err := ApplyPromotions(tx, courses, time.Now()); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
 	var totalAmount int64
 	for _, course := range courses {
-		totalAmount += course.Price
+		totalAmount += course.EffectivePrice()
+	}
+	for _, line := range bundleLines {
+		totalAmount += line.Price
 	}
 
 	// 处理优惠券
@@ -292,7 +587,7 @@ func (s *OrderService) CreateOrder(userID uint, courseIDs []uint, couponCode str
 			couponCode, 1, time.Now(), time.Now()).First(&coupon).Error; err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
 				tx.Rollback()
-				return nil, errors.New("优惠券不存在或已失效")
+				return nil, ErrValidation.WithMsg("order.coupon_invalid")
 			}
 			tx.Rollback()
 			return nil, err
@@ -301,7 +596,7 @@ func (s *OrderService) CreateOrder(userID uint, courseIDs []uint, couponCode str
 		// 检查最低消费金额
 		if totalAmount < coupon.MinAmount {
 			tx.Rollback()
-			return nil, fmt.Errorf("订单金额不满足优惠券使用条件，最低消费%.2f元", float64(coupon.MinAmount)/100)
+			return nil, ErrValidation.WithMsg("order.coupon_min_amount", float64(coupon.MinAmount)/100)
 		}
 
 		// 计算优惠金额
@@ -335,7 +630,7 @@ func (s *OrderService) CreateOrder(userID uint, courseIDs []uint, couponCode str
 		TotalAmount:    totalAmount,
 		PayAmount:      payAmount,
 		DiscountAmount: discountAmount,
-		Status:         1, // 待付款
+		Status:         models.OrderStatusPending,
 		ExpiredAt:      &[]time.Time{time.Now().Add(30 * time.Minute)}[0], // 30分钟后过期
 	}
 
@@ -350,24 +645,63 @@ func (s *OrderService) CreateOrder(userID uint, courseIDs []uint, couponCode str
 
 	// 创建订单项
 	for _, course := range courses {
-		orderItem := models.OrderItem{
-			OrderID:       order.ID,
-			CourseID:      course.ID,
-			CourseName:    course.Title,
-			CourseImage:   course.Cover,
-			Price:         course.Price,
-			OriginalPrice: course.OriginalPrice,
+		orderItem := newOrderItem(order.ID, course)
+		orderItem.Price = course.EffectivePrice()
+		orderItem.OriginalPrice = course.OriginalPrice
+		if course.ActivePromotion != nil {
+			orderItem.PromotionID = &course.ActivePromotion.ID
+		}
+		if err := tx.Create(&orderItem).Error; err != nil {
+			tx.Rollback()
+			return nil, err
 		}
+	}
+
+	// 创建课程包订单项
+	for _, line := range bundleLines {
+		bundleID := line.BundleID
+		orderItem := newOrderItem(order.ID, line.Course)
+		orderItem.Price = line.Price
+		orderItem.OriginalPrice = line.Course.Price
+		orderItem.BundleID = &bundleID
 		if err := tx.Create(&orderItem).Error; err != nil {
 			tx.Rollback()
 			return nil, err
 		}
 	}
 
+	// 下单事件与订单一起提交，下单通知邮件等由发件箱投递任务处理
+	if err := addOutboxEvent(tx, EventOrderCreated, fmt.Sprint(order.ID), map[string]interface{}{
+		"order_no":   order.OrderNo,
+		"user_id":    order.UserID,
+		"pay_amount": order.PayAmount,
+	}); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
 	tx.Commit()
 	return order, nil
 }
 
+// withSnapshotJoins 查询课程时JOIN分类和讲师，订单项快照需要它们的名称，一次查询完成，不逐个订单项查询
+func withSnapshotJoins(db *gorm.DB) *gorm.DB {
+	return db.Joins("Category").Joins("Instructor")
+}
+
+// newOrderItem 创建订单项并记录课程快照（名称、封面、讲师、分类），价格由调用方设置
+// course 须通过 withSnapshotJoins 查询
+func newOrderItem(orderID models.OrderID, course models.Course) models.OrderItem {
+	return models.OrderItem{
+		OrderID:        orderID,
+		CourseID:       course.ID,
+		CourseName:     course.Title,
+		CourseImage:    course.Cover,
+		InstructorName: course.Instructor.DisplayName(),
+		CategoryName:   course.Category.Name,
+	}
+}
+
 // generateOrderNo 生成订单号
 func (s *OrderService) generateOrderNo() string {
 	return fmt.Sprintf("EDU%d", time.Now().UnixNano())
@@ -384,10 +718,10 @@ func (s *OrderService) PayOrder(orderNo, paymentMethod, paymentNo string) error
 
 	// 查找订单
 	var order models.Order
-	if err := tx.Where("order_no = ? AND status = ?", orderNo, 1).First(&order).Error; err != nil {
+	if err := tx.Where("order_no = ? AND status = ?", orderNo, models.OrderStatusPending).First(&order).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			tx.Rollback()
-			return errors.New("订单不存在或状态异常")
+			return ErrNotFound.WithMsg("order.not_payable")
 		}
 		tx.Rollback()
 		return err
@@ -398,17 +732,29 @@ func (s *OrderService) PayOrder(orderNo, paymentMethod, paymentNo string) error
 		// 自动取消过期订单
 		now := time.Now()
 		tx.Model(&order).Updates(map[string]interface{}{
-			"status":       4, // 已取消
+			"status":       models.OrderStatusCancelled,
 			"cancelled_at": &now,
 		})
 		tx.Rollback()
-		return errors.New("订单已过期")
+		return ErrConflict.WithMsg("order.expired")
+	}
+
+	// 下单后到支付前可能新增了先修课程，支付时再检查一次
+	var orderItems []models.OrderItem
+	tx.Where("order_id = ?", order.ID).Find(&orderItems)
+	courseIDs := make([]uint, len(orderItems))
+	for i, item := range orderItems {
+		courseIDs[i] = item.CourseID
+	}
+	if err := checkPrerequisites(tx, order.UserID, courseIDs); err != nil {
+		tx.Rollback()
+		return err
 	}
 
 	// 更新订单状态
 	now := time.Now()
 	if err := tx.Model(&order).Updates(map[string]interface{}{
-		"status":         2, // 已付款
+		"status":         models.OrderStatusPaid,
 		"payment_method": paymentMethod,
 		"payment_no":     paymentNo,
 		"paid_at":        &now,
@@ -417,40 +763,50 @@ func (s *OrderService) PayOrder(orderNo, paymentMethod, paymentNo string) error
 		return err
 	}
 
-	// 更新课程学生数量
-	var orderItems []models.OrderItem
-	tx.Where("order_id = ?", order.ID).Find(&orderItems)
+	// 开通选课记录并更新课程学生数量，限额课程在此占用名额
 	for _, item := range orderItems {
-		tx.Model(&models.Course{}).Where("id = ?", item.CourseID).
-			Update("student_count", gorm.Expr("student_count + ?", 1))
-	}
-
-	tx.Commit()
-	return nil
-}
-
-// GetOrdersByUserID 获取用户订单列表
-func (s *OrderService) GetOrdersByUserID(userID uint, page, pageSize int, status *int8) ([]models.Order, int64, error) {
-	var orders []models.Order
-	var total int64
+		orderID, itemID := order.ID, item.ID
+		enrollment := models.Enrollment{
+			UserID:      order.UserID,
+			CourseID:    item.CourseID,
+			OrderID:     &orderID,
+			OrderItemID: &itemID,
+			Source:      models.EnrollmentSourcePurchase,
+			Status:      models.EnrollmentStatusActive,
+			EnrolledAt:  now,
+		}
+		if err := tx.Create(&enrollment).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
 
-	query := s.db.Model(&models.Order{}).Where("user_id = ?", userID)
-	if status != nil {
-		query = query.Where("status = ?", *status)
+		if err := reserveSeat(tx, order.UserID, item.CourseID, item.CourseName, now); err != nil {
+			tx.Rollback()
+			return err
+		}
 	}
 
-	offset := (page - 1) * pageSize
-
-	// 获取总数
-	if err := query.Count(&total).Error; err != nil {
-		return nil, 0, err
+	// 支付事件与订单一起提交，开发票等后续处理由发件箱投递任务完成，不增加支付耗时
+	if err := addOutboxEvent(tx, EventOrderPaid, fmt.Sprint(order.ID), map[string]interface{}{
+		"order_no":   order.OrderNo,
+		"user_id":    order.UserID,
+		"pay_amount": order.PayAmount,
+		"paid_at":    now,
+	}); err != nil {
+		tx.Rollback()
+		return err
 	}
 
-	// 分页查询
-	err := query.Preload("Items.Course").Preload("Coupon").
-		Order("created_at DESC").Limit(pageSize).Offset(offset).Find(&orders).Error
+	return tx.Commit().Error
+}
 
-	return orders, total, err
+// GetOrdersByUserID 获取用户订单列表，status不为nil时只返回该状态的订单
+func (s *OrderService) GetOrdersByUserID(userID uint, page, pageSize int, status *models.OrderStatus) ([]models.Order, int64, error) {
+	params := OrderSearchParams{Pagination: Pagination{Page: page, PageSize: pageSize}}
+	if status != nil {
+		params.Statuses = []models.OrderStatus{*status}
+	}
+	return s.SearchUserOrders(userID, params)
 }
 
 // CancelOrder 取消订单
@@ -464,10 +820,10 @@ func (s *OrderService) CancelOrder(orderNo string, userID uint) error {
 
 	// 查找订单
 	var order models.Order
-	if err := tx.Where("order_no = ? AND user_id = ? AND status = ?", orderNo, userID, 1).First(&order).Error; err != nil {
+	if err := tx.Where("order_no = ? AND user_id = ? AND status = ?", orderNo, userID, models.OrderStatusPending).First(&order).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			tx.Rollback()
-			return errors.New("订单不存在或无法取消")
+			return ErrNotFound.WithMsg("order.not_cancelable")
 		}
 		tx.Rollback()
 		return err
@@ -476,7 +832,7 @@ func (s *OrderService) CancelOrder(orderNo string, userID uint) error {
 	// 更新订单状态
 	now := time.Now()
 	if err := tx.Model(&order).Updates(map[string]interface{}{
-		"status":       4, // 已取消
+		"status":       models.OrderStatusCancelled,
 		"cancelled_at": &now,
 	}).Error; err != nil {
 		tx.Rollback()
@@ -489,6 +845,19 @@ func (s *OrderService) CancelOrder(orderNo string, userID uint) error {
 			Update("used_count", gorm.Expr("used_count - ?", 1))
 	}
 
+	// 待付款的订单不占名额，这里只是顺带检查订单中的限额课程，有空余名额时通知候补用户
+	var courseIDs []uint
+	if err := tx.Model(&models.OrderItem{}).Where("order_id = ?", order.ID).Pluck("course_id", &courseIDs).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	for _, courseID := range courseIDs {
+		if _, err := offerSeats(tx, courseID, now); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
 	tx.Commit()
 	return nil
 }
@@ -503,62 +872,80 @@ func NewLearningService(db *gorm.DB) *LearningService {
 	return &LearningService{db: db}
 }
 
+// WithContext 返回使用ctx执行查询的副本，见 UserService.WithContext
+func (s *LearningService) WithContext(ctx context.Context) *LearningService {
+	return &LearningService{db: s.db.WithContext(ctx)}
+}
+
 // UpdateProgress 更新学习进度
 func (s *LearningService) UpdateProgress(userID, courseID, lessonID uint, progress, watchTime int) error {
 	// 检查用户是否有权限学习该课程
-	var count int64
-	s.db.Table("orders").
-		Joins("JOIN order_items ON orders.id = order_items.order_id").
-		Where("orders.user_id = ? AND order_items.course_id = ? AND orders.status IN ?", 
-			userID, courseID, []int{2, 3}).Count(&count)
+	enrolled, err := isEnrolled(s.db, userID, courseID)
+	if err != nil {
+		return err
+	}
 
-	if count == 0 {
+	if !enrolled {
 		// 检查是否是免费课程或免费课时
 		var lesson models.Lesson
-		if err := s.db.Where("id = ? AND (is_free = ? OR EXISTS (SELECT 1 FROM courses WHERE id = ? AND is_free = ?))", 
+		if err := s.db.Where("id = ? AND (is_free = ? OR EXISTS (SELECT 1 FROM "+models.Table(s.db, "courses")+" WHERE id = ? AND is_free = ?))", 
 			lessonID, true, courseID, true).First(&lesson).Error; err != nil {
-			return errors.New("您没有权限学习该课程")
+			return ErrForbidden.WithMsg("learning.forbidden")
 		}
 	}
 
-	// 查找或创建学习进度记录
-	var learningProgress models.LearningProgress
-	err := s.db.Where("user_id = ? AND course_id = ? AND lesson_id = ?", userID, courseID, lessonID).
-		First(&learningProgress).Error
-
-	now := time.Now()
-	if err == gorm.ErrRecordNotFound {
-		// 创建新记录
-		learningProgress = models.LearningProgress{
-			UserID:      userID,
-			CourseID:    courseID,
-			LessonID:    lessonID,
-			Progress:    progress,
-			WatchTime:   watchTime,
-			LastWatchAt: &now,
-		}
-		if progress >= 100 {
-			learningProgress.IsCompleted = true
-			learningProgress.CompletedAt = &now
-		}
-		return s.db.Create(&learningProgress).Error
-	} else if err != nil {
+	// 记录学习行为日志
+	activity := models.LearningActivity{
+		UserID:    userID,
+		CourseID:  courseID,
+		LessonID:  lessonID,
+		Progress:  progress,
+		WatchTime: watchTime,
+	}
+	if err := s.db.Create(&activity).Error; err != nil {
 		return err
 	}
 
-	// 更新现有记录
-	updates := map[string]interface{}{
-		"progress":      progress,
-		"watch_time":    watchTime,
-		"last_watch_at": &now,
+	// 创建或更新学习进度记录，依赖 (user_id, course_id, lesson_id) 唯一索引，一条语句完成
+	now := time.Now()
+	learningProgress := models.LearningProgress{
+		UserID:      userID,
+		CourseID:    courseID,
+		LessonID:    lessonID,
+		Progress:    progress,
+		WatchTime:   watchTime,
+		LastWatchAt: &now,
+	}
+	if err := Upsert(s.db, &learningProgress,
+		[]string{"user_id", "course_id", "lesson_id"},
+		[]string{"progress", "watch_time", "last_watch_at"}); err != nil {
+		return err
 	}
 
-	if progress >= 100 && !learningProgress.IsCompleted {
-		updates["is_completed"] = true
-		updates["completed_at"] = &now
+	// 首次完成时记录完成时间，已完成的课时保持原完成时间
+	// 课时首次完成时检查是否学完了整门课程，学完时在同一事务中签发结业证书
+	if progress >= 100 {
+		return s.db.Transaction(func(tx *gorm.DB) error {
+			result := tx.Model(&models.LearningProgress{}).
+				Where("user_id = ? AND course_id = ? AND lesson_id = ? AND is_completed = ?", userID, courseID, lessonID, false).
+				Updates(map[string]interface{}{"is_completed": true, "completed_at": &now})
+			if result.Error != nil || result.RowsAffected == 0 {
+				return result.Error
+			}
+			_, err := issueCertificateIfCompleted(tx, userID, courseID, now)
+			return err
+		})
 	}
+	return nil
+}
 
-	return s.db.Model(&learningProgress).Updates(updates).Error
+// isEnrolled 用户是否有课程的有效选课记录
+func isEnrolled(db *gorm.DB, userID, courseID uint) (bool, error) {
+	var count int64
+	err := db.Model(&models.Enrollment{}).
+		Where("user_id = ? AND course_id = ? AND status = ?", userID, courseID, 1).
+		Count(&count).Error
+	return count > 0, err
 }
 
 // GetUserCourseProgress 获取用户课程学习进度
@@ -569,29 +956,53 @@ func (s *LearningService) GetUserCourseProgress(userID, courseID uint) ([]models
 	return progress, err
 }
 
-// GetUserLearningCourses 获取用户学习的课程列表
-func (s *LearningService) GetUserLearningCourses(userID uint, page, pageSize int) ([]models.Course, int64, error) {
-	var courses []models.Course
-	var total int64
+// LearningCourse 我的课程列表中的一门课程
+// 购买的课程按订单项上的下单时快照展示，不读取课程表，课程改名、更换讲师或被删除后仍显示购买时的信息；
+// 企业批量开通的课程没有订单项，按课程当前信息展示
+type LearningCourse struct {
+	CourseID       uint      `json:"course_id"`
+	CourseName     string    `json:"course_name"`
+	CourseCover    string    `json:"course_cover"`
+	InstructorName string    `json:"instructor_name"`
+	CategoryName   string    `json:"category_name"`
+	Source         int8      `json:"source"` // 1-购买,2-企业批量开通
+	EnrolledAt     time.Time `json:"enrolled_at"`
+}
 
-	// 子查询：获取用户已购买的课程ID
-	subQuery := s.db.Table("orders").
-		Select("DISTINCT order_items.course_id").
-		Joins("JOIN order_items ON orders.id = order_items.order_id").
-		Where("orders.user_id = ? AND orders.status IN ?", userID, []int{2, 3})
+// GetUserLearningCourses 获取用户学习的课程列表，按开通时间倒序
+func (s *LearningService) GetUserLearningCourses(userID uint, page, pageSize int) ([]LearningCourse, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 20
+	}
 
-	query := s.db.Model(&models.Course{}).Where("id IN (?)", subQuery)
+	var courses []LearningCourse
+	var total int64
 
-	offset := (page - 1) * pageSize
+	query := s.db.Table(models.TableAs(s.db, "enrollments")).
+		Where("enrollments.user_id = ? AND enrollments.status = ? AND enrollments.deleted_at IS NULL", userID, models.EnrollmentStatusActive)
 
 	// 获取总数
 	if err := query.Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
-	// 分页查询
-	err := query.Preload("Category").Preload("Instructor").
-		Order("created_at DESC").Limit(pageSize).Offset(offset).Find(&courses).Error
+	// 只有没有订单项的选课记录才JOIN课程表，购买的课程完全使用订单项快照
+	err := query.
+		Joins("LEFT JOIN " + models.TableAs(s.db, "order_items") + " ON order_items.id = enrollments.order_item_id").
+		Joins("LEFT JOIN " + models.TableAs(s.db, "courses") + " ON courses.id = enrollments.course_id AND enrollments.order_item_id IS NULL").
+		Joins("LEFT JOIN " + models.TableAs(s.db, "users") + " ON users.id = courses.instructor_id").
+		Joins("LEFT JOIN " + models.TableAs(s.db, "categories") + " ON categories.id = courses.category_id").
+		Select(`enrollments.course_id, enrollments.source, enrollments.enrolled_at,
+			COALESCE(order_items.course_name, courses.title, '') AS course_name,
+			COALESCE(order_items.course_image, courses.cover, '') AS course_cover,
+			COALESCE(order_items.instructor_name, NULLIF(users.nickname, ''), users.username, '') AS instructor_name,
+			COALESCE(order_items.category_name, categories.name, '') AS category_name`).
+		Order("enrollments.enrolled_at DESC").Order("enrollments.id DESC").
+		Limit(pageSize).Offset((page - 1) * pageSize).
+		Scan(&courses).Error
 
 	return courses, total, err
 }
\ No newline at end of file
@@ -0,0 +1,82 @@
+package services
+
+import (
+	"errors"
+	"strconv"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"edu-platform/models"
+)
+
+// SettingsService 系统设置服务
+type SettingsService struct {
+	db *gorm.DB
+}
+
+// NewSettingsService 创建系统设置服务
+func NewSettingsService(db *gorm.DB) *SettingsService {
+	return &SettingsService{db: db}
+}
+
+// Get 获取设置值，设置不存在时返回ok=false
+func (s *SettingsService) Get(key string) (value string, ok bool, err error) {
+	var setting models.Setting
+	err = s.db.Where("`key` = ?", key).First(&setting).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", false, nil
+		}
+		return "", false, err
+	}
+	return setting.Value, true, nil
+}
+
+// GetInt 获取整数设置，不存在或无法解析时返回默认值
+func (s *SettingsService) GetInt(key string, defaultValue int) int {
+	value, ok, err := s.Get(key)
+	if err != nil || !ok {
+		return defaultValue
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return defaultValue
+	}
+	return n
+}
+
+// GetFloat 获取浮点数设置，不存在或无法解析时返回默认值
+func (s *SettingsService) GetFloat(key string, defaultValue float64) float64 {
+	value, ok, err := s.Get(key)
+	if err != nil || !ok {
+		return defaultValue
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return defaultValue
+	}
+	return f
+}
+
+// GetBool 获取布尔设置，不存在或无法解析时返回默认值
+func (s *SettingsService) GetBool(key string, defaultValue bool) bool {
+	value, ok, err := s.Get(key)
+	if err != nil || !ok {
+		return defaultValue
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return defaultValue
+	}
+	return b
+}
+
+// Set 写入设置（不存在则创建，存在则覆盖）
+func (s *SettingsService) Set(key, value, valueType string) error {
+	setting := models.Setting{Key: key, Value: value, Type: valueType}
+	return s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"value", "type", "updated_at"}),
+	}).Create(&setting).Error
+}
@@ -0,0 +1,185 @@
+package services
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"gorm.io/gorm"
+
+	"edu-platform/models"
+)
+
+const (
+	maxSlugLength    = 80 // 标识最大长度
+	slugSuffixLength = 6  // 为冲突后缀（如 -99999）预留的长度
+	slugHashLength   = 8  // 标题含中文等无法转写的文字时追加的哈希长度
+)
+
+// slugPattern 标识只能由小写字母、数字和单个连字符组成，不能以连字符开头或结尾
+var slugPattern = regexp.MustCompile(`^[a-z0-9]+(?:-[a-z0-9]+)*$`)
+
+// slugTransliterations 常见带重音的拉丁字母转写为ASCII
+var slugTransliterations = map[rune]string{
+	'à': "a", 'á': "a", 'â': "a", 'ã': "a", 'ä': "a", 'å': "a", 'æ': "ae",
+	'ç': "c", 'è': "e", 'é': "e", 'ê': "e", 'ë': "e",
+	'ì': "i", 'í': "i", 'î': "i", 'ï': "i", 'ñ': "n",
+	'ò': "o", 'ó': "o", 'ô': "o", 'õ': "o", 'ö': "o", 'ø': "o", 'œ': "oe",
+	'ù': "u", 'ú': "u", 'û': "u", 'ü': "u", 'ý': "y", 'ÿ': "y", 'ß': "ss",
+}
+
+// slugColumn 需要查重的标识列，表名不带前缀
+type slugColumn struct {
+	table  string
+	column string
+}
+
+// SlugGenerator 根据标题生成URL标识，冲突时依次追加 -2、-3 ...
+// 查重覆盖 columns 中的所有列，如课程标识要同时避开现有课程和改名后保留的旧标识
+type SlugGenerator struct {
+	fallback string // 标题中没有可用字符或全是数字时使用的前缀
+	columns  []slugColumn
+}
+
+// NewCourseSlugGenerator 课程标识生成器，查重范围为 courses.slug 和 slug_redirects.old_slug
+func NewCourseSlugGenerator() *SlugGenerator {
+	return &SlugGenerator{
+		fallback: "course",
+		columns:  []slugColumn{{"courses", "slug"}, {"slug_redirects", "old_slug"}},
+	}
+}
+
+// Slugify 标题转为标识（不查重）：转小写，拉丁字母去掉重音，其他标点和空白替换为连字符，长度不超过80
+// 中文等无法转写的文字被去掉，改为追加标题的8位哈希，避免不同的中文标题得到同一标识；
+// 结果为空或全是数字（会与按ID访问混淆）时加上 fallback 前缀
+func (g *SlugGenerator) Slugify(title string) string {
+	var b strings.Builder
+	dropped := false
+	separate := func() {
+		if b.Len() > 0 && !strings.HasSuffix(b.String(), "-") {
+			b.WriteByte('-')
+		}
+	}
+	for _, r := range strings.ToLower(strings.TrimSpace(title)) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case slugTransliterations[r] != "":
+			b.WriteString(slugTransliterations[r])
+		case unicode.IsLetter(r) || unicode.IsNumber(r):
+			dropped = true
+			separate()
+		default:
+			separate()
+		}
+	}
+	slug := strings.Trim(b.String(), "-")
+
+	if dropped {
+		sum := sha1.Sum([]byte(strings.TrimSpace(title)))
+		hash := hex.EncodeToString(sum[:])[:slugHashLength]
+		slug = truncateSlug(slug, maxSlugLength-slugHashLength-1)
+		if slug == "" {
+			slug = hash
+		} else {
+			slug += "-" + hash
+		}
+	}
+	if slug == "" || strings.Trim(slug, "0123456789") == "" {
+		slug = strings.TrimSuffix(g.fallback+"-"+slug, "-")
+	}
+	return truncateSlug(slug, maxSlugLength)
+}
+
+// Validate 校验手动指定的标识，格式不符、超长或全是数字时返回 ErrValidation
+func (g *SlugGenerator) Validate(slug string) error {
+	if len(slug) > maxSlugLength || !slugPattern.MatchString(slug) || strings.Trim(slug, "0123456789") == "" {
+		return ErrValidation.WithMsg("course.slug_invalid", maxSlugLength)
+	}
+	return nil
+}
+
+// Generate 根据标题生成未被占用的标识
+// 用一条 LIKE 'base%' 查询取出所有以基础标识开头的已用标识，在内存中选出第一个空闲的 base、base-2、base-3 ...，
+// 不逐个重试查询；并发生成同一标识时由唯一索引拦截，调用方返回错误即可
+func (g *SlugGenerator) Generate(db *gorm.DB, title string) (string, error) {
+	base := g.Slugify(title)
+
+	prefix := base
+	if len(prefix) > maxSlugLength-slugSuffixLength {
+		prefix = prefix[:maxSlugLength-slugSuffixLength]
+	}
+	taken, err := g.takenWithPrefix(db, prefix)
+	if err != nil {
+		return "", err
+	}
+
+	if !taken[base] {
+		return base, nil
+	}
+	for n := 2; ; n++ {
+		suffix := fmt.Sprintf("-%d", n)
+		candidate := truncateSlug(base, maxSlugLength-len(suffix)) + suffix
+		if !taken[candidate] {
+			return candidate, nil
+		}
+	}
+}
+
+// DerivedFrom 标识是否就是由该标题生成的（基础标识或带冲突后缀），改名后标识不变时不需要重新生成
+func (g *SlugGenerator) DerivedFrom(slug, title string) bool {
+	base := g.Slugify(title)
+	if slug == base {
+		return true
+	}
+	i := strings.LastIndex(slug, "-")
+	if i <= 0 || i == len(slug)-1 || strings.Trim(slug[i+1:], "0123456789") != "" {
+		return false
+	}
+	return slug[:i] == truncateSlug(base, maxSlugLength-(len(slug)-i))
+}
+
+// Taken 标识是否已被占用
+func (g *SlugGenerator) Taken(db *gorm.DB, slug string) (bool, error) {
+	taken, err := g.query(db, "= ?", slug)
+	if err != nil {
+		return false, err
+	}
+	return taken[slug], nil
+}
+
+// takenWithPrefix 以prefix开头的已用标识，标识中不含 % 和 _，不需要转义
+func (g *SlugGenerator) takenWithPrefix(db *gorm.DB, prefix string) (map[string]bool, error) {
+	return g.query(db, "LIKE ?", prefix+"%")
+}
+
+// query 在所有查重列中按条件查找标识，各列用 UNION 合并为一条查询；软删除的课程仍占用唯一索引，一并计入
+func (g *SlugGenerator) query(db *gorm.DB, cond string, arg string) (map[string]bool, error) {
+	parts := make([]string, len(g.columns))
+	args := make([]interface{}, len(g.columns))
+	for i, col := range g.columns {
+		parts[i] = fmt.Sprintf("SELECT %s AS slug FROM %s WHERE %s %s", col.column, models.Table(db, col.table), col.column, cond)
+		args[i] = arg
+	}
+
+	var slugs []string
+	if err := db.Raw(strings.Join(parts, " UNION "), args...).Scan(&slugs).Error; err != nil {
+		return nil, err
+	}
+	taken := make(map[string]bool, len(slugs))
+	for _, slug := range slugs {
+		taken[slug] = true
+	}
+	return taken, nil
+}
+
+// truncateSlug 截断到n个字节（标识只含ASCII），并去掉末尾的连字符
+func truncateSlug(slug string, n int) string {
+	if len(slug) > n {
+		slug = slug[:n]
+	}
+	return strings.TrimRight(slug, "-")
+}
@@ -0,0 +1,112 @@
+package services_test
+
+import (
+	"errors"
+	"testing"
+
+	"edu-platform/models"
+	"edu-platform/services"
+	"edu-platform/testhelpers"
+	"edu-platform/testhelpers/factory"
+)
+
+// createCourse 通过 CourseService 创建课程，讲师和分类取自template
+func createCourse(t *testing.T, courses *services.CourseService, template *models.Course, title, slug string) (*models.Course, error) {
+	t.Helper()
+	course := &models.Course{
+		Title:        title,
+		Slug:         slug,
+		CategoryID:   template.CategoryID,
+		InstructorID: template.InstructorID,
+		Level:        1,
+		Status:       models.CourseStatusDraft,
+	}
+	return course, courses.CreateCourse(course, nil)
+}
+
+// TestCourseSlugCollisions 标题相同的课程依次追加 -2、-3，软删除的课程仍占用标识；
+// 手动指定的标识被占用时返回 ErrConflict，格式不符时返回 ErrValidation
+func TestCourseSlugCollisions(t *testing.T) {
+	t.Parallel()
+	db := testhelpers.NewDB(t)
+	f := factory.New(t, db)
+	courses := services.NewCourseService(db, nil)
+	template := f.Course(0)
+
+	var created []*models.Course
+	for _, title := range []string{"Go Basics", "Go Basics", "  go basics!", "Go Basics 2"} {
+		course, err := createCourse(t, courses, template, title, "")
+		if err != nil {
+			t.Fatalf("创建课程 %q 失败: %v", title, err)
+		}
+		created = append(created, course)
+	}
+	// 第四个标题本身生成 go-basics-2，与冲突后缀撞车，继续追加后缀
+	for i, want := range []string{"go-basics", "go-basics-2", "go-basics-3", "go-basics-2-2"} {
+		if created[i].Slug != want {
+			t.Errorf("第%d个课程的标识为 %s，期望 %s", i+1, created[i].Slug, want)
+		}
+	}
+
+	if err := db.Delete(created[2]).Error; err != nil {
+		t.Fatalf("删除课程失败: %v", err)
+	}
+	if course, err := createCourse(t, courses, template, "Go Basics", ""); err != nil || course.Slug != "go-basics-4" {
+		t.Errorf("软删除后生成的标识为 %s（%v），期望 go-basics-4", course.Slug, err)
+	}
+
+	if course, err := createCourse(t, courses, template, "2024", ""); err != nil || course.Slug != "course-2024" {
+		t.Errorf("全是数字的标题生成 %s（%v），期望 course-2024", course.Slug, err)
+	}
+	if _, err := createCourse(t, courses, template, "手动", "go-basics-3"); !errors.Is(err, services.ErrConflict) {
+		t.Errorf("手动指定已占用的标识返回 %v，期望 ErrConflict", err)
+	}
+	if _, err := createCourse(t, courses, template, "手动", "Go_Basics"); !errors.Is(err, services.ErrValidation) {
+		t.Errorf("手动指定格式不符的标识返回 %v，期望 ErrValidation", err)
+	}
+}
+
+// TestCourseSlugRedirect 改名重新生成标识后旧标识跳转到新标识，且不再分配给其他课程；
+// 新标题仍能生成当前标识时不改变标识
+func TestCourseSlugRedirect(t *testing.T) {
+	t.Parallel()
+	db := testhelpers.NewDB(t)
+	f := factory.New(t, db)
+	courses := services.NewCourseService(db, nil)
+	template := f.Course(0)
+
+	renamed, err := createCourse(t, courses, template, "Go Basics", "")
+	if err != nil {
+		t.Fatalf("创建课程失败: %v", err)
+	}
+	if err := courses.UpdateCourse(renamed.ID, map[string]interface{}{"title": "Rust Basics"}, true); err != nil {
+		t.Fatalf("修改标题失败: %v", err)
+	}
+	id, current, err := courses.ResolveCourseSlug("go-basics")
+	if err != nil || id != renamed.ID || current != "rust-basics" {
+		t.Errorf("旧标识解析为 %d %s（%v），期望课程 %d 的 rust-basics", id, current, err, renamed.ID)
+	}
+
+	// 旧标识保留给改名的课程
+	other, err := createCourse(t, courses, template, "Go Basics", "")
+	if err != nil || other.Slug != "go-basics-2" {
+		t.Fatalf("新课程标识为 %s（%v），期望 go-basics-2", other.Slug, err)
+	}
+	if _, err := createCourse(t, courses, template, "手动", "go-basics"); !errors.Is(err, services.ErrConflict) {
+		t.Errorf("手动指定旧标识返回 %v，期望 ErrConflict", err)
+	}
+
+	// 只改大小写，标识仍由新标题生成，不产生跳转
+	if err := courses.UpdateCourse(other.ID, map[string]interface{}{"title": "GO BASICS"}, true); err != nil {
+		t.Fatalf("修改标题失败: %v", err)
+	}
+	var redirects int64
+	db.Model(&models.SlugRedirect{}).Where("course_id = ?", other.ID).Count(&redirects)
+	if id, current, _ := courses.ResolveCourseSlug("go-basics-2"); id != other.ID || current != "go-basics-2" || redirects != 0 {
+		t.Errorf("标识变为 %s，产生 %d 条跳转，期望保持 go-basics-2", current, redirects)
+	}
+
+	if _, _, err := courses.ResolveCourseSlug("no-such-course"); !errors.Is(err, services.ErrNotFound) {
+		t.Errorf("未知标识返回 %v，期望 ErrNotFound", err)
+	}
+}
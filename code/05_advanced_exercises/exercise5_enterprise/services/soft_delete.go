@@ -0,0 +1,57 @@
+package services
+
+import (
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// 软删除计数
+// 带 gorm.DeletedAt 字段的模型，Count 默认只统计未删除的行；管理后台的报表有时需要包括已删除的行，
+// 用这里的函数统计，没有软删除字段的模型已删除数始终为0。三个函数满足 CountActive + CountDeleted = CountWithDeleted
+
+var deletedAtType = reflect.TypeOf(gorm.DeletedAt{})
+
+// CountWithDeleted 统计全部行数，包括软删除的行
+func CountWithDeleted(db *gorm.DB, model interface{}) (int64, error) {
+	var count int64
+	err := db.Unscoped().Model(model).Count(&count).Error
+	return count, err
+}
+
+// CountActive 统计未删除的行数，与 Count 相同
+func CountActive(db *gorm.DB, model interface{}) (int64, error) {
+	var count int64
+	err := db.Model(model).Count(&count).Error
+	return count, err
+}
+
+// CountDeleted 统计软删除的行数，模型没有软删除字段时返回0
+func CountDeleted(db *gorm.DB, model interface{}) (int64, error) {
+	field, err := softDeleteField(db, model)
+	if err != nil || field == nil {
+		return 0, err
+	}
+
+	var count int64
+	err = db.Unscoped().Model(model).
+		Where(clause.Neq{Column: clause.Column{Table: clause.CurrentTable, Name: field.DBName}, Value: nil}).
+		Count(&count).Error
+	return count, err
+}
+
+// softDeleteField 模型的软删除字段（gorm.DeletedAt类型），没有时返回nil
+func softDeleteField(db *gorm.DB, model interface{}) (*schema.Field, error) {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(model); err != nil {
+		return nil, err
+	}
+	for _, field := range stmt.Schema.Fields {
+		if field.FieldType == deletedAtType && field.DBName != "" {
+			return field, nil
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,132 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const (
+	sqlRecorderMaxStatements = 500 // 单个请求最多记录的语句数，超出的只计数
+	sqlDebugMaxParamLen      = 100 // 超过该长度的字符串参数在调试输出中隐藏
+)
+
+// SQLStatement 调试模式下记录的一条SQL
+type SQLStatement struct {
+	SQL        string  `json:"sql"`
+	DurationMs float64 `json:"duration_ms"`
+	Rows       int64   `json:"rows"`
+	Error      string  `json:"error,omitempty"`
+}
+
+// SQLRecorder 收集单个请求执行的SQL，放在请求的context中，由 SQLDebugLogger 写入
+type SQLRecorder struct {
+	mu         sync.Mutex
+	statements []SQLStatement
+	dropped    int
+}
+
+// NewSQLRecorder 创建SQL记录器
+func NewSQLRecorder() *SQLRecorder {
+	return &SQLRecorder{}
+}
+
+// Statements 已记录的SQL及因超出上限未记录的条数
+func (r *SQLRecorder) Statements() ([]SQLStatement, int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]SQLStatement(nil), r.statements...), r.dropped
+}
+
+func (r *SQLRecorder) add(stmt SQLStatement) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.statements) >= sqlRecorderMaxStatements {
+		r.dropped++
+		return
+	}
+	r.statements = append(r.statements, stmt)
+}
+
+type sqlRecorderKey struct{}
+
+// WithSQLRecorder 返回带有SQL记录器的context，使用该context执行的查询（db.WithContext(ctx)）都会被记录
+func WithSQLRecorder(ctx context.Context, r *SQLRecorder) context.Context {
+	return context.WithValue(ctx, sqlRecorderKey{}, r)
+}
+
+// SQLRecorderFrom 取出context中的SQL记录器，没有时返回nil
+func SQLRecorderFrom(ctx context.Context) *SQLRecorder {
+	if ctx == nil {
+		return nil
+	}
+	r, _ := ctx.Value(sqlRecorderKey{}).(*SQLRecorder)
+	return r
+}
+
+// SQLDebugLogger 包装全局的GORM日志：context中有SQL记录器时，不论全局日志级别都把SQL写入记录器，
+// 其他请求的日志行为不变（生产环境全局日志保持Warn级别）
+type SQLDebugLogger struct {
+	logger.Interface
+}
+
+// NewSQLDebugLogger 基于全局日志创建调试日志
+func NewSQLDebugLogger(base logger.Interface) *SQLDebugLogger {
+	return &SQLDebugLogger{Interface: base}
+}
+
+// LogMode 修改全局日志级别，保留调试记录功能
+func (l *SQLDebugLogger) LogMode(level logger.LogLevel) logger.Interface {
+	return &SQLDebugLogger{Interface: l.Interface.LogMode(level)}
+}
+
+// Trace 记录SQL到请求的记录器，再交给全局日志
+func (l *SQLDebugLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	if r := SQLRecorderFrom(ctx); r != nil {
+		sql, rows := fc()
+		stmt := SQLStatement{
+			SQL:        sql,
+			DurationMs: float64(time.Since(begin).Microseconds()) / 1000,
+			Rows:       rows,
+		}
+		if err != nil {
+			stmt.Error = err.Error()
+		}
+		r.add(stmt)
+	}
+	l.Interface.Trace(ctx, begin, fc, err)
+}
+
+// ParamsFilter 实现 gorm.ParamsFilter，调试请求中隐藏过长的参数（如密码哈希、正文），避免整段写入响应
+func (l *SQLDebugLogger) ParamsFilter(ctx context.Context, sql string, params ...interface{}) (string, []interface{}) {
+	if SQLRecorderFrom(ctx) != nil {
+		return sql, redactSQLParams(params)
+	}
+	if f, ok := l.Interface.(gorm.ParamsFilter); ok {
+		return f.ParamsFilter(ctx, sql, params...)
+	}
+	return sql, params
+}
+
+// redactSQLParams 把超过长度限制的字符串、字节参数替换为占位说明
+func redactSQLParams(params []interface{}) []interface{} {
+	redacted := make([]interface{}, len(params))
+	for i, p := range params {
+		redacted[i] = p
+		switch v := p.(type) {
+		case string:
+			if len(v) > sqlDebugMaxParamLen {
+				redacted[i] = fmt.Sprintf("<redacted %d chars>", len(v))
+			}
+		case []byte:
+			if len(v) > sqlDebugMaxParamLen {
+				redacted[i] = fmt.Sprintf("<redacted %d bytes>", len(v))
+			}
+		}
+	}
+	return redacted
+}
@@ -0,0 +1,232 @@
+package services
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	defaultTimelineLimit = 20  // 默认每页事件数
+	maxTimelineLimit     = 100 // 每页事件数上限
+)
+
+// TimelineEvent 时间线事件，各数据源的记录统一转换成这个结构
+type TimelineEvent struct {
+	Time      time.Time `json:"time"`
+	Type      string    `json:"type"`       // 事件类型，与数据源的Type()一致
+	Actor     *uint     `json:"actor"`      // 操作人用户ID，系统行为为空
+	Summary   string    `json:"summary"`    // 事件描述
+	EntityRef string    `json:"entity_ref"` // 关联实体，格式为 实体:ID，如 order:12
+
+	key string // 排序键（类型:子类型），同一时间的事件按它排序
+	id  string // 来源记录ID，同一排序键下按它排序
+}
+
+// TimelineCursor 时间线分页游标，指向上一页最后一个事件
+// 事件按 时间、排序键、记录ID 倒序排列，游标之后的事件即下一页
+type TimelineCursor struct {
+	Time time.Time `json:"t"`
+	Key  string    `json:"k"`
+	ID   string    `json:"i"`
+}
+
+// Encode 将游标编码为URL安全的字符串
+func (c TimelineCursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeTimelineCursor 解析游标字符串
+func DecodeTimelineCursor(s string) (*TimelineCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrValidation.WithMsg("timeline.invalid_cursor")
+	}
+	var cursor TimelineCursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.Key == "" {
+		return nil, ErrValidation.WithMsg("timeline.invalid_cursor")
+	}
+	return &cursor, nil
+}
+
+// TimelineQuery 传给数据源的查询条件
+type TimelineQuery struct {
+	Start time.Time       // 时间窗口起点（含）
+	End   time.Time       // 时间窗口终点（不含）
+	Limit int             // 每类事件最多返回的条数
+	After *TimelineCursor // 上一页的游标，为空表示第一页
+}
+
+// Scope 为一类事件的查询加上时间窗口、游标条件、排序和数量限制
+// key为事件的排序键，timeColumn和idColumn为对应的时间列和主键列
+func (q TimelineQuery) Scope(key, timeColumn, idColumn string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		db = db.Where(timeColumn+" >= ? AND "+timeColumn+" < ?", q.Start, q.End)
+
+		if c := q.After; c != nil {
+			// 同一时间的事件按排序键倒序，排序键小的在游标之后
+			switch {
+			case key < c.Key:
+				db = db.Where(timeColumn+" <= ?", c.Time)
+			case key > c.Key:
+				db = db.Where(timeColumn+" < ?", c.Time)
+			default:
+				db = db.Where(timeColumn+" < ? OR ("+timeColumn+" = ? AND "+idColumn+" < ?)", c.Time, c.Time, c.ID)
+			}
+		}
+
+		return db.Order(timeColumn + " DESC").Order(idColumn + " DESC").Limit(q.Limit)
+	}
+}
+
+// NewTimelineEvent 创建时间线事件，kind为同一类型下的子类型（如订单的paid、cancelled）
+// id为来源记录的主键，自增ID和UUID都可以
+func NewTimelineEvent(typ, kind string, id interface{}, at time.Time, actor *uint, summary, entityRef string) TimelineEvent {
+	return TimelineEvent{
+		Time:      at,
+		Type:      typ,
+		Actor:     actor,
+		Summary:   summary,
+		EntityRef: entityRef,
+		key:       TimelineKey(typ, kind),
+		id:        fmt.Sprint(id),
+	}
+}
+
+// timelineIDLess 比较两个记录ID，自增ID按数值比较，UUID等定长ID按字典序比较
+func timelineIDLess(a, b string) bool {
+	if len(a) != len(b) {
+		return len(a) < len(b)
+	}
+	return a < b
+}
+
+// TimelineKey 事件排序键
+func TimelineKey(typ, kind string) string {
+	return typ + ":" + kind
+}
+
+// TimelineSource 时间线数据源，每个数据源负责一种事件类型
+// Fetch需要对每个子类型的查询使用TimelineQuery.Scope，保证查询有界且分页正确
+type TimelineSource interface {
+	Type() string
+	Fetch(db *gorm.DB, q TimelineQuery) ([]TimelineEvent, error)
+}
+
+// timelineRegistry 已登记的时间线数据源，按事件类型索引
+var timelineRegistry = map[string]TimelineSource{}
+
+// RegisterTimelineSource 登记时间线数据源，新的事件类型在init中调用
+func RegisterTimelineSource(source TimelineSource) {
+	timelineRegistry[source.Type()] = source
+}
+
+// TimelineTypes 返回已登记的事件类型
+func TimelineTypes() []string {
+	types := make([]string, 0, len(timelineRegistry))
+	for typ := range timelineRegistry {
+		types = append(types, typ)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// TimelinePage 时间线分页结果
+type TimelinePage struct {
+	List       []TimelineEvent `json:"list"`
+	NextCursor string          `json:"next_cursor,omitempty"` // 为空表示没有更多事件
+}
+
+// TimelineService 管理后台时间线服务，把多个数据源的事件合并成一条按时间倒序的事件流
+type TimelineService struct {
+	db *gorm.DB
+}
+
+// NewTimelineService 创建时间线服务
+func NewTimelineService(db *gorm.DB) *TimelineService {
+	return &TimelineService{db: db}
+}
+
+// GetTimeline 获取某一天的事件流
+// types为空时查询全部类型；未请求的类型不会执行查询
+func (s *TimelineService) GetTimeline(date time.Time, types []string, cursor string, limit int) (*TimelinePage, error) {
+	if limit <= 0 {
+		limit = defaultTimelineLimit
+	}
+	if limit > maxTimelineLimit {
+		limit = maxTimelineLimit
+	}
+
+	sources, err := s.sources(types)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+	q := TimelineQuery{Start: start, End: start.AddDate(0, 0, 1), Limit: limit + 1}
+	if cursor != "" {
+		if q.After, err = DecodeTimelineCursor(cursor); err != nil {
+			return nil, err
+		}
+	}
+
+	// 每个数据源各取limit+1条，合并排序后截取一页，多出来的说明还有下一页
+	var events []TimelineEvent
+	for _, source := range sources {
+		fetched, err := source.Fetch(s.db, q)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, fetched...)
+	}
+	sort.Slice(events, func(i, j int) bool {
+		a, b := events[i], events[j]
+		if !a.Time.Equal(b.Time) {
+			return a.Time.After(b.Time)
+		}
+		if a.key != b.key {
+			return a.key > b.key
+		}
+		return timelineIDLess(b.id, a.id)
+	})
+
+	page := &TimelinePage{List: events}
+	if len(events) > limit {
+		page.List = events[:limit]
+		last := page.List[limit-1]
+		page.NextCursor = TimelineCursor{Time: last.Time, Key: last.key, ID: last.id}.Encode()
+	}
+	if page.List == nil {
+		page.List = []TimelineEvent{}
+	}
+	return page, nil
+}
+
+// sources 按请求的类型取数据源，未登记的类型返回校验错误
+func (s *TimelineService) sources(types []string) ([]TimelineSource, error) {
+	if len(types) == 0 {
+		types = TimelineTypes()
+	}
+
+	seen := make(map[string]bool)
+	var sources []TimelineSource
+	for _, typ := range types {
+		typ = strings.TrimSpace(typ)
+		if typ == "" || seen[typ] {
+			continue
+		}
+		source, ok := timelineRegistry[typ]
+		if !ok {
+			return nil, ErrValidation.WithMsg("timeline.unknown_type", typ)
+		}
+		seen[typ] = true
+		sources = append(sources, source)
+	}
+	return sources, nil
+}
@@ -0,0 +1,129 @@
+package services
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"edu-platform/models"
+)
+
+func init() {
+	RegisterTimelineSource(auditTimelineSource{})
+	RegisterTimelineSource(userTimelineSource{})
+	RegisterTimelineSource(orderTimelineSource{})
+	RegisterTimelineSource(courseTimelineSource{})
+}
+
+// auditTimelineSource 操作日志
+type auditTimelineSource struct{}
+
+func (auditTimelineSource) Type() string { return "audit" }
+
+func (src auditTimelineSource) Fetch(db *gorm.DB, q TimelineQuery) ([]TimelineEvent, error) {
+	key := TimelineKey(src.Type(), "log")
+	var logs []models.SystemLog
+	if err := db.Scopes(q.Scope(key, "created_at", "id")).Find(&logs).Error; err != nil {
+		return nil, err
+	}
+
+	events := make([]TimelineEvent, 0, len(logs))
+	for _, entry := range logs {
+		summary := fmt.Sprintf("[%s] %s（%s %s，状态%d）", entry.Module, entry.Action, entry.Method, entry.URL, entry.Status)
+		events = append(events, NewTimelineEvent(src.Type(), "log", entry.ID, entry.CreatedAt, entry.UserID,
+			summary, fmt.Sprintf("system_log:%d", entry.ID)))
+	}
+	return events, nil
+}
+
+// userTimelineSource 用户注册
+type userTimelineSource struct{}
+
+func (userTimelineSource) Type() string { return "user" }
+
+func (src userTimelineSource) Fetch(db *gorm.DB, q TimelineQuery) ([]TimelineEvent, error) {
+	key := TimelineKey(src.Type(), "registered")
+	var users []models.User
+	if err := db.Select("id", "username", "created_at").
+		Scopes(q.Scope(key, "created_at", "id")).Find(&users).Error; err != nil {
+		return nil, err
+	}
+
+	events := make([]TimelineEvent, 0, len(users))
+	for _, user := range users {
+		id := user.ID
+		events = append(events, NewTimelineEvent(src.Type(), "registered", user.ID, user.CreatedAt, &id,
+			"新用户注册: "+user.Username, fmt.Sprintf("user:%d", user.ID)))
+	}
+	return events, nil
+}
+
+// orderTimelineSource 订单状态变化，由订单上各状态的时间字段还原
+type orderTimelineSource struct{}
+
+func (orderTimelineSource) Type() string { return "order" }
+
+// orderTimelineKinds 订单事件子类型及对应的时间列
+var orderTimelineKinds = []struct {
+	kind   string
+	column string
+	label  string
+}{
+	{"created", "created_at", "已下单"},
+	{"paid", "paid_at", "已支付"},
+	{"cancelled", "cancelled_at", "已取消"},
+	{"refunded", "refunded_at", "已退款"},
+}
+
+func (src orderTimelineSource) Fetch(db *gorm.DB, q TimelineQuery) ([]TimelineEvent, error) {
+	var events []TimelineEvent
+	for _, k := range orderTimelineKinds {
+		var orders []models.Order
+		err := db.Select("id", "order_no", "user_id", "pay_amount", "refund_amount", "created_at", "paid_at", "cancelled_at", "refunded_at").
+			Scopes(q.Scope(TimelineKey(src.Type(), k.kind), k.column, "id")).
+			Find(&orders).Error
+		if err != nil {
+			return nil, err
+		}
+
+		for _, order := range orders {
+			at, amount := order.CreatedAt, order.PayAmount
+			switch k.kind {
+			case "paid":
+				at = *order.PaidAt
+			case "cancelled":
+				at = *order.CancelledAt
+			case "refunded":
+				at, amount = *order.RefundedAt, order.RefundAmount
+			}
+
+			userID := order.UserID
+			summary := fmt.Sprintf("订单 %s %s，金额 %.2f元", order.OrderNo, k.label, float64(amount)/100)
+			events = append(events, NewTimelineEvent(src.Type(), k.kind, order.ID, at, &userID,
+				summary, fmt.Sprintf("order:%v", order.ID)))
+		}
+	}
+	return events, nil
+}
+
+// courseTimelineSource 课程发布
+type courseTimelineSource struct{}
+
+func (courseTimelineSource) Type() string { return "course" }
+
+func (src courseTimelineSource) Fetch(db *gorm.DB, q TimelineQuery) ([]TimelineEvent, error) {
+	key := TimelineKey(src.Type(), "published")
+	var courses []models.Course
+	if err := db.Select("id", "title", "instructor_id", "published_at").
+		Scopes(q.Scope(key, "published_at", "id")).Find(&courses).Error; err != nil {
+		return nil, err
+	}
+
+	events := make([]TimelineEvent, 0, len(courses))
+	for _, course := range courses {
+		instructorID := course.InstructorID
+		events = append(events, NewTimelineEvent(src.Type(), "published", course.ID, *course.PublishedAt, &instructorID,
+			"课程《"+course.Title+"》已发布", fmt.Sprintf("course:%d", course.ID)))
+	}
+	return events, nil
+}
@@ -0,0 +1,55 @@
+package services
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Upsert 插入一行，唯一索引冲突时改为更新已有行的updateCols，一条语句完成，代替“先查询、不存在则创建、否则更新”
+// conflictCols 为冲突判断的列，须与一个唯一索引的列完全一致；updateCols 为空时冲突则什么都不做。
+// 模型有 updated_at 列时冲突更新会一并更新它。
+//
+// 各数据库的语法不同：SQLite（及PostgreSQL）为 ON CONFLICT (conflictCols) DO UPDATE，必须指定冲突列；
+// MySQL为 ON DUPLICATE KEY UPDATE，不能指定冲突列，任一唯一索引冲突都会走更新，conflictCols 只作说明。
+// 冲突更新时MySQL不会回填已有行的主键，row.ID 不可靠，需要主键时重新查询
+//
+//	err := Upsert(db, &progress, []string{"user_id", "course_id", "lesson_id"}, []string{"progress", "watch_time"})
+func Upsert[T any](db *gorm.DB, row *T, conflictCols []string, updateCols []string) error {
+	var onConflict clause.OnConflict
+	if db.Dialector.Name() != "mysql" {
+		if len(conflictCols) == 0 {
+			return fmt.Errorf("Upsert: %s 必须指定冲突列", db.Dialector.Name())
+		}
+		for _, col := range conflictCols {
+			onConflict.Columns = append(onConflict.Columns, clause.Column{Name: col})
+		}
+	}
+
+	if len(updateCols) == 0 {
+		onConflict.DoNothing = true
+		return db.Clauses(onConflict).Create(row).Error
+	}
+
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(row); err != nil {
+		return err
+	}
+	cols := updateCols
+	if field := stmt.Schema.LookUpField("UpdatedAt"); field != nil && field.DBName != "" && !containsString(updateCols, field.DBName) {
+		cols = append(append([]string(nil), updateCols...), field.DBName)
+	}
+	onConflict.DoUpdates = clause.AssignmentColumns(cols)
+	return db.Clauses(onConflict).Create(row).Error
+}
+
+// containsString 切片中是否包含s
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,77 @@
+package services_test
+
+import (
+	"testing"
+	"time"
+
+	"edu-platform/models"
+	"edu-platform/services"
+	"edu-platform/testhelpers"
+	"edu-platform/testhelpers/factory"
+)
+
+// TestUpsert 不存在时插入，冲突时只更新updateCols和updated_at，其他列保持不变；updateCols为空时冲突什么都不做
+func TestUpsert(t *testing.T) {
+	t.Parallel()
+	db := testhelpers.NewDB(t)
+	f := factory.New(t, db)
+
+	student := f.User("student")
+	course := f.Course(9900)
+	lesson := f.Lessons(course.ID)[0]
+	conflict := []string{"user_id", "course_id", "lesson_id"}
+
+	first := models.LearningProgress{UserID: student.ID, CourseID: course.ID, LessonID: lesson.ID, Progress: 30, WatchTime: 120, IsCompleted: true}
+	if err := services.Upsert(db, &first, conflict, []string{"progress", "watch_time"}); err != nil {
+		t.Fatalf("插入失败: %v", err)
+	}
+	stale := time.Now().Add(-time.Hour)
+	db.Model(&models.LearningProgress{}).Where("id = ?", first.ID).UpdateColumn("updated_at", stale)
+
+	second := models.LearningProgress{UserID: student.ID, CourseID: course.ID, LessonID: lesson.ID, Progress: 80, WatchTime: 480}
+	if err := services.Upsert(db, &second, conflict, []string{"progress", "watch_time"}); err != nil {
+		t.Fatalf("冲突更新失败: %v", err)
+	}
+
+	var rows []models.LearningProgress
+	db.Where("user_id = ?", student.ID).Find(&rows)
+	if len(rows) != 1 {
+		t.Fatalf("有 %d 行进度，期望冲突时更新同一行", len(rows))
+	}
+	got := rows[0]
+	if got.Progress != 80 || got.WatchTime != 480 {
+		t.Errorf("进度 %d、观看时长 %d，期望更新为 80、480", got.Progress, got.WatchTime)
+	}
+	if !got.IsCompleted {
+		t.Error("不在updateCols中的列被覆盖")
+	}
+	if !got.UpdatedAt.After(stale.Add(time.Minute)) {
+		t.Errorf("updated_at为 %v，期望一并更新", got.UpdatedAt)
+	}
+
+	// updateCols为空时冲突什么都不做
+	ignored := models.LearningProgress{UserID: student.ID, CourseID: course.ID, LessonID: lesson.ID, Progress: 5}
+	if err := services.Upsert(db, &ignored, conflict, nil); err != nil {
+		t.Fatalf("冲突忽略失败: %v", err)
+	}
+	db.First(&got, got.ID)
+	if got.Progress != 80 {
+		t.Errorf("进度变为 %d，期望冲突时保持 80", got.Progress)
+	}
+
+	// 其他模型同样适用
+	var setting models.Setting
+	for _, value := range []string{"旧名称", "新名称"} {
+		setting = models.Setting{Key: "site.name", Value: value, Type: "string"}
+		if err := services.Upsert(db, &setting, []string{"key"}, []string{"value"}); err != nil {
+			t.Fatalf("写入设置失败: %v", err)
+		}
+	}
+	if value, _, _ := services.NewSettingsService(db).Get("site.name"); value != "新名称" {
+		t.Errorf("设置值为 %q，期望 新名称", value)
+	}
+
+	if err := services.Upsert(db, &setting, nil, []string{"value"}); err == nil {
+		t.Error("SQLite下未指定冲突列时应返回错误")
+	}
+}
@@ -0,0 +1,348 @@
+package services
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"time"
+
+	"gorm.io/gorm"
+
+	"edu-platform/models"
+)
+
+// exportBatchSize 导出时每批查询的记录数，保证内存占用有上限
+const exportBatchSize = 200
+
+// UserDataExporter 用户数据导出服务
+// 将用户的个人数据导出为一个JSON文档，各部分通过FindInBatches分批查询并流式写出
+type UserDataExporter struct {
+	db *gorm.DB
+}
+
+// NewUserDataExporter 创建用户数据导出服务
+func NewUserDataExporter(db *gorm.DB) *UserDataExporter {
+	return &UserDataExporter{db: db}
+}
+
+// 导出DTO：只包含可以返回给用户本人的字段，密码、支付流水号等内部数据不导出
+
+type exportProfile struct {
+	ID              uint       `json:"id"`
+	Username        string     `json:"username"`
+	Email           string     `json:"email"`
+	Phone           string     `json:"phone"`
+	Nickname        string     `json:"nickname"`
+	Avatar          string     `json:"avatar"`
+	LastLoginAt     *time.Time `json:"last_login_at"`
+	LoginIP         string     `json:"login_ip"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+	PhoneVerifiedAt *time.Time `json:"phone_verified_at"`
+	RealName        string     `json:"real_name"`
+	Gender          int8       `json:"gender"`
+	Birthday        *time.Time `json:"birthday"`
+	Bio             string     `json:"bio"`
+	Location        string     `json:"location"`
+	Website         string     `json:"website"`
+	Company         string     `json:"company"`
+	Position        string     `json:"position"`
+	Education       string     `json:"education"`
+	Experience      int        `json:"experience"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+type exportOrder struct {
+	OrderNo        string             `json:"order_no"`
+	TotalAmount    int64              `json:"total_amount"`
+	PayAmount      int64              `json:"pay_amount"`
+	DiscountAmount int64              `json:"discount_amount"`
+	RefundAmount   int64              `json:"refund_amount"`
+	Status         models.OrderStatus `json:"status"`
+	PaymentMethod  string             `json:"payment_method"`
+	PaidAt         *time.Time         `json:"paid_at"`
+	CancelledAt    *time.Time         `json:"cancelled_at"`
+	RefundedAt     *time.Time         `json:"refunded_at"`
+	Remark         string             `json:"remark"`
+	RefundReason   string             `json:"refund_reason"`
+	CreatedAt      time.Time          `json:"created_at"`
+	Items          []exportOrderItem  `json:"items"`
+}
+
+type exportOrderItem struct {
+	CourseID       uint   `json:"course_id"`
+	CourseName     string `json:"course_name"`
+	Price          int64  `json:"price"`
+	OriginalPrice  int64  `json:"original_price"`
+	DiscountAmount int64  `json:"discount_amount"`
+	Refunded       bool   `json:"refunded"`
+}
+
+type exportProgress struct {
+	CourseID    uint       `json:"course_id"`
+	LessonID    uint       `json:"lesson_id"`
+	Progress    int        `json:"progress"`
+	WatchTime   int        `json:"watch_time"`
+	IsCompleted bool       `json:"is_completed"`
+	CompletedAt *time.Time `json:"completed_at"`
+	LastWatchAt *time.Time `json:"last_watch_at"`
+}
+
+type exportFavorite struct {
+	CourseID  uint      `json:"course_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type exportReview struct {
+	CourseID  uint      `json:"course_id"`
+	Rating    float32   `json:"rating"`
+	Content   string    `json:"content"`
+	Status    int8      `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type exportNotification struct {
+	Title     string     `json:"title"`
+	Content   string     `json:"content"`
+	Type      int8       `json:"type"`
+	IsRead    bool       `json:"is_read"`
+	ReadAt    *time.Time `json:"read_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+type exportLogin struct {
+	IP         string    `json:"ip"`
+	UserAgent  string    `json:"user_agent"`
+	LoggedInAt time.Time `json:"logged_in_at"`
+}
+
+// Export 导出用户数据，依次写出 profile、orders、learning_progress、favorites、
+// reviews、notifications、login_history 各部分
+func (e *UserDataExporter) Export(userID uint, w io.Writer) error {
+	var user models.User
+	if err := e.db.Preload("Profile").First(&user, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrNotFound.WithMsg("user.not_found")
+		}
+		return err
+	}
+
+	s := &exportStream{w: bufio.NewWriter(w)}
+	s.raw(`{"exported_at":`)
+	s.value(time.Now())
+	s.raw(`,"profile":`)
+	s.value(exportProfile{
+		ID:              user.ID,
+		Username:        user.Username,
+		Email:           user.Email,
+		Phone:           user.Phone,
+		Nickname:        user.Nickname,
+		Avatar:          user.Avatar,
+		LastLoginAt:     user.LastLoginAt,
+		LoginIP:         user.LoginIP,
+		EmailVerifiedAt: user.EmailVerifiedAt,
+		PhoneVerifiedAt: user.PhoneVerifiedAt,
+		RealName:        user.Profile.RealName,
+		Gender:          user.Profile.Gender,
+		Birthday:        user.Profile.Birthday,
+		Bio:             user.Profile.Bio,
+		Location:        user.Profile.Location,
+		Website:         user.Profile.Website,
+		Company:         user.Profile.Company,
+		Position:        user.Profile.Position,
+		Education:       user.Profile.Education,
+		Experience:      user.Profile.Experience,
+		CreatedAt:       user.CreatedAt,
+	})
+
+	sections := []struct {
+		name   string
+		export func(s *exportStream, userID uint) error
+	}{
+		{"orders", e.exportOrders},
+		{"learning_progress", e.exportProgress},
+		{"favorites", e.exportFavorites},
+		{"reviews", e.exportReviews},
+		{"notifications", e.exportNotifications},
+		{"login_history", e.exportLogins},
+	}
+	for _, section := range sections {
+		s.beginArray(section.name)
+		if err := section.export(s, userID); err != nil {
+			return err
+		}
+		s.raw("]")
+	}
+	s.raw("}\n")
+
+	if s.err != nil {
+		return s.err
+	}
+	return s.w.Flush()
+}
+
+func (e *UserDataExporter) exportOrders(s *exportStream, userID uint) error {
+	var orders []models.Order
+	return e.db.Preload("Items").Where("user_id = ?", userID).
+		FindInBatches(&orders, exportBatchSize, func(tx *gorm.DB, batch int) error {
+			for _, order := range orders {
+				items := make([]exportOrderItem, 0, len(order.Items))
+				for _, item := range order.Items {
+					items = append(items, exportOrderItem{
+						CourseID:       item.CourseID,
+						CourseName:     item.CourseName,
+						Price:          item.Price,
+						OriginalPrice:  item.OriginalPrice,
+						DiscountAmount: item.DiscountAmount,
+						Refunded:       item.RefundID != nil,
+					})
+				}
+				if err := s.item(exportOrder{
+					OrderNo:        order.OrderNo,
+					TotalAmount:    order.TotalAmount,
+					PayAmount:      order.PayAmount,
+					DiscountAmount: order.DiscountAmount,
+					RefundAmount:   order.RefundAmount,
+					Status:         order.Status,
+					PaymentMethod:  order.PaymentMethod,
+					PaidAt:         order.PaidAt,
+					CancelledAt:    order.CancelledAt,
+					RefundedAt:     order.RefundedAt,
+					Remark:         order.Remark,
+					RefundReason:   order.RefundReason,
+					CreatedAt:      order.CreatedAt,
+					Items:          items,
+				}); err != nil {
+					return err
+				}
+			}
+			return nil
+		}).Error
+}
+
+func (e *UserDataExporter) exportProgress(s *exportStream, userID uint) error {
+	var progresses []models.LearningProgress
+	return e.db.Where("user_id = ?", userID).
+		FindInBatches(&progresses, exportBatchSize, func(tx *gorm.DB, batch int) error {
+			for _, p := range progresses {
+				if err := s.item(exportProgress{
+					CourseID:    p.CourseID,
+					LessonID:    p.LessonID,
+					Progress:    p.Progress,
+					WatchTime:   p.WatchTime,
+					IsCompleted: p.IsCompleted,
+					CompletedAt: p.CompletedAt,
+					LastWatchAt: p.LastWatchAt,
+				}); err != nil {
+					return err
+				}
+			}
+			return nil
+		}).Error
+}
+
+func (e *UserDataExporter) exportFavorites(s *exportStream, userID uint) error {
+	var favorites []models.CourseFavorite
+	return e.db.Where("user_id = ?", userID).
+		FindInBatches(&favorites, exportBatchSize, func(tx *gorm.DB, batch int) error {
+			for _, f := range favorites {
+				if err := s.item(exportFavorite{CourseID: f.CourseID, CreatedAt: f.CreatedAt}); err != nil {
+					return err
+				}
+			}
+			return nil
+		}).Error
+}
+
+func (e *UserDataExporter) exportReviews(s *exportStream, userID uint) error {
+	var reviews []models.CourseReview
+	return e.db.Where("user_id = ?", userID).
+		FindInBatches(&reviews, exportBatchSize, func(tx *gorm.DB, batch int) error {
+			for _, r := range reviews {
+				if err := s.item(exportReview{
+					CourseID:  r.CourseID,
+					Rating:    r.Rating,
+					Content:   r.Content,
+					Status:    r.Status,
+					CreatedAt: r.CreatedAt,
+				}); err != nil {
+					return err
+				}
+			}
+			return nil
+		}).Error
+}
+
+func (e *UserDataExporter) exportNotifications(s *exportStream, userID uint) error {
+	var notifications []models.Notification
+	return e.db.Where("user_id = ?", userID).
+		FindInBatches(&notifications, exportBatchSize, func(tx *gorm.DB, batch int) error {
+			for _, n := range notifications {
+				if err := s.item(exportNotification{
+					Title:     n.Title,
+					Content:   n.Content,
+					Type:      n.Type,
+					IsRead:    n.IsRead,
+					ReadAt:    n.ReadAt,
+					CreatedAt: n.CreatedAt,
+				}); err != nil {
+					return err
+				}
+			}
+			return nil
+		}).Error
+}
+
+func (e *UserDataExporter) exportLogins(s *exportStream, userID uint) error {
+	var logins []models.LoginHistory
+	return e.db.Where("user_id = ?", userID).
+		FindInBatches(&logins, exportBatchSize, func(tx *gorm.DB, batch int) error {
+			for _, l := range logins {
+				if err := s.item(exportLogin{IP: l.IP, UserAgent: l.UserAgent, LoggedInAt: l.LoggedInAt}); err != nil {
+					return err
+				}
+			}
+			return nil
+		}).Error
+}
+
+// exportStream 流式JSON写入器，记录第一个写入错误，之后的写入直接跳过
+type exportStream struct {
+	w     *bufio.Writer
+	count int // 当前数组已写入的元素数
+	err   error
+}
+
+func (s *exportStream) raw(str string) {
+	if s.err == nil {
+		_, s.err = s.w.WriteString(str)
+	}
+}
+
+func (s *exportStream) value(v interface{}) {
+	if s.err != nil {
+		return
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		s.err = err
+		return
+	}
+	_, s.err = s.w.Write(data)
+}
+
+// beginArray 开始写出一个数组字段
+func (s *exportStream) beginArray(name string) {
+	s.raw(`,"` + name + `":[`)
+	s.count = 0
+}
+
+// item 写出数组元素
+func (s *exportStream) item(v interface{}) error {
+	if s.count > 0 {
+		s.raw(",")
+	}
+	s.count++
+	s.value(v)
+	return s.err
+}
@@ -0,0 +1,191 @@
+package services_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+
+	"edu-platform/models"
+	"edu-platform/services"
+	"edu-platform/testhelpers"
+	"edu-platform/testhelpers/factory"
+)
+
+// exportDocument 导出文件中测试关心的字段
+type exportDocument struct {
+	Profile struct {
+		ID    uint   `json:"id"`
+		Email string `json:"email"`
+	} `json:"profile"`
+	Orders []struct {
+		OrderNo string `json:"order_no"`
+	} `json:"orders"`
+	Favorites []struct {
+		CourseID uint `json:"course_id"`
+	} `json:"favorites"`
+	Reviews []struct {
+		Content string `json:"content"`
+	} `json:"reviews"`
+	Notifications []struct {
+		Title string `json:"title"`
+	} `json:"notifications"`
+	LoginHistory []struct {
+		IP string `json:"ip"`
+	} `json:"login_history"`
+}
+
+// exportFixture 一个用户的可导出数据，标识性的值用于在导出结果中查找
+type exportFixture struct {
+	user     *models.User
+	orderNo  string
+	courseID uint
+	marker   string // 评价内容、通知标题和登录IP中包含的标记
+}
+
+// TestExportOnlyOwnData 导出结果的每个部分都只包含请求用户自己的数据，其他用户的数据不出现在文件中
+func TestExportOnlyOwnData(t *testing.T) {
+	t.Parallel()
+	db := testhelpers.NewDB(t)
+	f := factory.New(t, db)
+
+	alice := seedExportData(t, f, db, "10.0.0.1")
+	bob := seedExportData(t, f, db, "10.0.0.2")
+
+	var buf bytes.Buffer
+	if err := services.NewUserDataExporter(db).Export(alice.user.ID, &buf); err != nil {
+		t.Fatalf("导出失败: %v", err)
+	}
+	assertExportedOnly(t, buf.Bytes(), alice)
+
+	for _, foreign := range []string{bob.user.Email, bob.orderNo, bob.marker} {
+		if bytes.Contains(buf.Bytes(), []byte(foreign)) {
+			t.Errorf("导出结果包含其他用户的数据 %q", foreign)
+		}
+	}
+}
+
+// TestExportJobCompletes 后台导出任务完成后状态为completed，文件内容为请求用户的数据；其他用户查不到该任务
+func TestExportJobCompletes(t *testing.T) {
+	t.Parallel()
+	db := testhelpers.NewDB(t)
+	f := factory.New(t, db)
+
+	alice := seedExportData(t, f, db, "10.0.0.1")
+	bob := seedExportData(t, f, db, "10.0.0.2")
+	jobs := services.NewExportJobManager(services.NewUserDataExporter(db), t.TempDir())
+
+	job, err := jobs.Enqueue(alice.user.ID)
+	if err != nil {
+		t.Fatalf("创建导出任务失败: %v", err)
+	}
+	if job.Status != services.ExportJobPending {
+		t.Errorf("新任务状态为 %s，期望 %s", job.Status, services.ExportJobPending)
+	}
+	if _, err := jobs.Get(job.ID, bob.user.ID); err == nil {
+		t.Error("其他用户查询到了导出任务")
+	}
+
+	done := waitExportJob(t, jobs, job.ID, alice.user.ID)
+	if done.Status != services.ExportJobCompleted || done.Error != "" {
+		t.Fatalf("任务状态为 %s（%s），期望 %s", done.Status, done.Error, services.ExportJobCompleted)
+	}
+	if done.CompletedAt == nil || done.ExpiresAt == nil || !done.ExpiresAt.After(*done.CompletedAt) {
+		t.Errorf("完成时间 %v、过期时间 %v 不正确", done.CompletedAt, done.ExpiresAt)
+	}
+	data, err := os.ReadFile(done.FilePath)
+	if err != nil {
+		t.Fatalf("读取导出文件失败: %v", err)
+	}
+	assertExportedOnly(t, data, alice)
+}
+
+// TestExportJobUnknownUser 用户不存在时任务失败，不留下导出文件
+func TestExportJobUnknownUser(t *testing.T) {
+	t.Parallel()
+	db := testhelpers.NewDB(t)
+	dir := t.TempDir()
+	jobs := services.NewExportJobManager(services.NewUserDataExporter(db), dir)
+
+	job, err := jobs.Enqueue(999)
+	if err != nil {
+		t.Fatalf("创建导出任务失败: %v", err)
+	}
+	done := waitExportJob(t, jobs, job.ID, 999)
+	if done.Status != services.ExportJobFailed || done.Error == "" {
+		t.Errorf("任务状态为 %s（%q），期望 %s 并带错误信息", done.Status, done.Error, services.ExportJobFailed)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("失败的任务留下了 %d 个文件", len(entries))
+	}
+}
+
+// seedExportData 创建用户及其订单、收藏、评价、通知和登录记录，marker 同时用作登录IP
+func seedExportData(t *testing.T, f *factory.Factory, db *gorm.DB, marker string) exportFixture {
+	t.Helper()
+	user := f.User("student")
+	course := f.Course(9900)
+	order := f.PaidOrder(user.ID, course.ID)
+
+	rows := []interface{}{
+		&models.CourseFavorite{UserID: user.ID, CourseID: course.ID},
+		&models.CourseReview{UserID: user.ID, CourseID: course.ID, Rating: 5, Content: "评价 " + marker, Status: 1},
+		&models.Notification{UserID: user.ID, Title: "通知 " + marker, Type: 1},
+		&models.LoginHistory{UserID: user.ID, IP: marker, UserAgent: "test", LoggedInAt: time.Now()},
+	}
+	for _, row := range rows {
+		if err := db.Create(row).Error; err != nil {
+			t.Fatalf("创建 %T 失败: %v", row, err)
+		}
+	}
+	return exportFixture{user: user, orderNo: order.OrderNo, courseID: course.ID, marker: marker}
+}
+
+// assertExportedOnly 导出内容的每个部分恰好是fixture用户的一条数据
+func assertExportedOnly(t *testing.T, data []byte, want exportFixture) {
+	t.Helper()
+	var doc exportDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("导出内容不是有效的JSON: %v", err)
+	}
+	if doc.Profile.ID != want.user.ID || doc.Profile.Email != want.user.Email {
+		t.Errorf("profile 为 %+v，期望用户 %d", doc.Profile, want.user.ID)
+	}
+	if len(doc.Orders) != 1 || doc.Orders[0].OrderNo != want.orderNo {
+		t.Errorf("orders 为 %+v，期望只有 %s", doc.Orders, want.orderNo)
+	}
+	if len(doc.Favorites) != 1 || doc.Favorites[0].CourseID != want.courseID {
+		t.Errorf("favorites 为 %+v，期望只有课程 %d", doc.Favorites, want.courseID)
+	}
+	if len(doc.Reviews) != 1 || doc.Reviews[0].Content != "评价 "+want.marker {
+		t.Errorf("reviews 为 %+v，期望只有自己的评价", doc.Reviews)
+	}
+	if len(doc.Notifications) != 1 || doc.Notifications[0].Title != "通知 "+want.marker {
+		t.Errorf("notifications 为 %+v，期望只有自己的通知", doc.Notifications)
+	}
+	if len(doc.LoginHistory) != 1 || doc.LoginHistory[0].IP != want.marker {
+		t.Errorf("login_history 为 %+v，期望只有 %s", doc.LoginHistory, want.marker)
+	}
+}
+
+// waitExportJob 等待任务结束（完成或失败），超时则测试失败
+func waitExportJob(t *testing.T, jobs *services.ExportJobManager, jobID string, userID uint) services.ExportJob {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		job, err := jobs.Get(jobID, userID)
+		if err != nil {
+			t.Fatalf("查询导出任务失败: %v", err)
+		}
+		if job.Status == services.ExportJobCompleted || job.Status == services.ExportJobFailed {
+			return job
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("导出任务5秒内没有结束，状态为 %s", job.Status)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,229 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"edu-platform/models"
+)
+
+// 验证码用途
+const (
+	PurposeRegister = "register" // 注册
+	PurposeReset    = "reset"    // 重置密码
+)
+
+const (
+	verificationCodeTTL     = 10 * time.Minute // 验证码有效期
+	verificationTokenTTL    = 30 * time.Minute // 验证通过后凭证的有效期
+	verificationMaxAttempts = 5                // 每个验证码最多尝试次数，用完后作废
+	verificationPerMinute   = 1                // 每个目标每分钟最多发送次数
+	verificationPerHour     = 5                // 每个目标每小时最多发送次数
+)
+
+// CodeSender 验证码发送器，按目标类型发送邮件或短信
+type CodeSender interface {
+	SendCode(target, purpose, code string) error
+}
+
+// LogCodeSender 把验证码写入日志，只用于开发环境
+type LogCodeSender struct{}
+
+// SendCode 实现 CodeSender
+func (LogCodeSender) SendCode(target, purpose, code string) error {
+	log.Printf("[验证码] target=%s purpose=%s code=%s", target, purpose, code)
+	return nil
+}
+
+// VerificationService 验证码服务
+// 发送频率按数据表统计（每个目标每分钟1次、每小时5次），多实例部署时同样有效；表中只保存验证码和凭证的哈希
+type VerificationService struct {
+	db     *gorm.DB
+	sender CodeSender
+}
+
+// NewVerificationService 创建验证码服务，sender为nil时使用 LogCodeSender
+func NewVerificationService(db *gorm.DB, sender CodeSender) *VerificationService {
+	if sender == nil {
+		sender = LogCodeSender{}
+	}
+	return &VerificationService{db: db, sender: sender}
+}
+
+// RequestCode 生成6位验证码并发送，超出发送频率时返回 ErrTooManyRequests
+func (s *VerificationService) RequestCode(target, purpose string) error {
+	target = normalizeTarget(target)
+	if target == "" {
+		return ErrValidation
+	}
+	if purpose != PurposeRegister && purpose != PurposeReset {
+		return ErrValidation.WithMsg("verification.invalid_purpose")
+	}
+
+	now := time.Now()
+	for _, limit := range []struct {
+		window time.Duration
+		max    int64
+	}{{time.Minute, verificationPerMinute}, {time.Hour, verificationPerHour}} {
+		var count int64
+		if err := s.db.Model(&models.VerificationCode{}).
+			Where("target = ? AND created_at > ?", target, now.Add(-limit.window)).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count >= limit.max {
+			return ErrTooManyRequests.WithMsg("verification.too_frequent")
+		}
+	}
+
+	code, err := randomDigits(6)
+	if err != nil {
+		return err
+	}
+	record := models.VerificationCode{
+		Target:    target,
+		Purpose:   purpose,
+		CodeHash:  verificationHash(target, purpose, code),
+		ExpiresAt: now.Add(verificationCodeTTL),
+	}
+	if err := s.db.Create(&record).Error; err != nil {
+		return err
+	}
+
+	if err := s.sender.SendCode(target, purpose, code); err != nil {
+		// 发送失败的验证码用户收不到，删除后不计入发送频率
+		s.db.Delete(&record)
+		return ErrInternal.Wrap(err)
+	}
+	return nil
+}
+
+// VerifyCode 校验目标最近一次发送的验证码，通过后返回一次性凭证（30分钟内有效）
+// 每次校验先占用一次尝试次数，次数用完后验证码作废，需要重新获取
+func (s *VerificationService) VerifyCode(target, purpose, code string) (string, error) {
+	target = normalizeTarget(target)
+
+	var record models.VerificationCode
+	err := s.db.Where("target = ? AND purpose = ?", target, purpose).
+		Order("id DESC").First(&record).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", ErrValidation.WithMsg("verification.code_invalid")
+	}
+	if err != nil {
+		return "", err
+	}
+	if record.ConsumedAt != nil || time.Now().After(record.ExpiresAt) {
+		return "", ErrValidation.WithMsg("verification.code_invalid")
+	}
+
+	// 条件更新占用尝试次数，并发请求也不会超过上限
+	tx := s.db.Model(&models.VerificationCode{}).
+		Where("id = ? AND attempts < ? AND consumed_at IS NULL", record.ID, verificationMaxAttempts).
+		Update("attempts", gorm.Expr("attempts + 1"))
+	if tx.Error != nil {
+		return "", tx.Error
+	}
+	if tx.RowsAffected == 0 {
+		return "", ErrValidation.WithMsg("verification.code_invalid")
+	}
+
+	hash := verificationHash(target, purpose, code)
+	if subtle.ConstantTimeCompare([]byte(hash), []byte(record.CodeHash)) != 1 {
+		remaining := verificationMaxAttempts - record.Attempts - 1
+		if remaining <= 0 {
+			return "", ErrValidation.WithMsg("verification.code_invalid")
+		}
+		return "", ErrValidation.WithMsg("verification.code_mismatch", remaining)
+	}
+
+	token, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
+	tx = s.db.Model(&models.VerificationCode{}).
+		Where("id = ? AND consumed_at IS NULL", record.ID).
+		Updates(map[string]interface{}{"consumed_at": &now, "token_hash": hashToken(token)})
+	if tx.Error != nil {
+		return "", tx.Error
+	}
+	if tx.RowsAffected == 0 {
+		return "", ErrValidation.WithMsg("verification.code_invalid")
+	}
+	return token, nil
+}
+
+// consumeVerificationToken 使用验证凭证，凭证须属于targets之一、用途一致、未过期且未使用过，在调用方的事务中执行
+func consumeVerificationToken(tx *gorm.DB, targets []string, purpose, token string) error {
+	if token == "" {
+		return ErrValidation.WithMsg("verification.token_invalid")
+	}
+	normalized := make([]string, 0, len(targets))
+	for _, t := range targets {
+		if t = normalizeTarget(t); t != "" {
+			normalized = append(normalized, t)
+		}
+	}
+
+	now := time.Now()
+	result := tx.Model(&models.VerificationCode{}).
+		Where("token_hash = ? AND target IN ? AND purpose = ? AND token_used_at IS NULL AND consumed_at > ?",
+			hashToken(token), normalized, purpose, now.Add(-verificationTokenTTL)).
+		Update("token_used_at", &now)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrValidation.WithMsg("verification.token_invalid")
+	}
+	return nil
+}
+
+// normalizeTarget 邮箱不区分大小写，统一为小写
+func normalizeTarget(target string) string {
+	return strings.ToLower(strings.TrimSpace(target))
+}
+
+// verificationHash 验证码的哈希，混入目标和用途，同一验证码在不同目标下哈希不同
+func verificationHash(target, purpose, code string) string {
+	sum := sha256.Sum256([]byte(purpose + ":" + target + ":" + code))
+	return hex.EncodeToString(sum[:])
+}
+
+// hashToken 凭证的哈希
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// randomDigits 生成n位随机数字
+func randomDigits(n int) (string, error) {
+	max := big.NewInt(1)
+	for i := 0; i < n; i++ {
+		max.Mul(max, big.NewInt(10))
+	}
+	v, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%0*d", n, v), nil
+}
+
+// randomToken 生成32字节的随机凭证
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,129 @@
+package services_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+
+	"edu-platform/models"
+	"edu-platform/services"
+	"edu-platform/testhelpers"
+)
+
+// stubSender 记录发送的验证码，err不为nil时发送失败
+type stubSender struct {
+	codes []string
+	err   error
+}
+
+func (s *stubSender) SendCode(target, purpose, code string) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.codes = append(s.codes, code)
+	return nil
+}
+
+// backdateCodes 把目标的所有验证码发送时间提前d
+func backdateCodes(t *testing.T, db *gorm.DB, target string, d time.Duration) {
+	t.Helper()
+	var codes []models.VerificationCode
+	if err := db.Where("target = ?", target).Find(&codes).Error; err != nil {
+		t.Fatalf("查询验证码失败: %v", err)
+	}
+	for _, code := range codes {
+		if err := db.Model(&code).Update("created_at", code.CreatedAt.Add(d)).Error; err != nil {
+			t.Fatalf("修改发送时间失败: %v", err)
+		}
+	}
+}
+
+// TestRequestCodeRateLimit 每个目标每分钟1次、每小时5次，目标不区分大小写；发送失败的不计入频率
+func TestRequestCodeRateLimit(t *testing.T) {
+	t.Parallel()
+	db := testhelpers.NewDB(t)
+	sender := &stubSender{}
+	verification := services.NewVerificationService(db, sender)
+
+	const target = "limit@example.test"
+	if err := verification.RequestCode(target, services.PurposeRegister); err != nil {
+		t.Fatalf("发送验证码失败: %v", err)
+	}
+	if err := verification.RequestCode(" LIMIT@example.test", services.PurposeReset); !errors.Is(err, services.ErrTooManyRequests) {
+		t.Errorf("一分钟内再次发送返回 %v，期望 ErrTooManyRequests", err)
+	}
+	if err := verification.RequestCode("other@example.test", services.PurposeRegister); err != nil {
+		t.Errorf("其他目标发送返回 %v，期望不受影响", err)
+	}
+
+	// 每次间隔超过一分钟，一小时内最多发送5次
+	for i := 2; i <= 5; i++ {
+		backdateCodes(t, db, target, -2*time.Minute)
+		if err := verification.RequestCode(target, services.PurposeRegister); err != nil {
+			t.Fatalf("第%d次发送失败: %v", i, err)
+		}
+	}
+	backdateCodes(t, db, target, -2*time.Minute)
+	if err := verification.RequestCode(target, services.PurposeRegister); !errors.Is(err, services.ErrTooManyRequests) {
+		t.Errorf("一小时内第6次发送返回 %v，期望 ErrTooManyRequests", err)
+	}
+	backdateCodes(t, db, target, -time.Hour)
+	if err := verification.RequestCode(target, services.PurposeRegister); err != nil {
+		t.Errorf("一小时后发送返回 %v，期望成功", err)
+	}
+	if len(sender.codes) != 7 {
+		t.Errorf("发送了 %d 个验证码，期望 7 个", len(sender.codes))
+	}
+
+	// 发送失败的验证码被删除，可以立即重新发送
+	sender.err = errors.New("smtp unavailable")
+	if err := verification.RequestCode("retry@example.test", services.PurposeRegister); !errors.Is(err, services.ErrInternal) {
+		t.Errorf("发送失败返回 %v，期望 ErrInternal", err)
+	}
+	sender.err = nil
+	if err := verification.RequestCode("retry@example.test", services.PurposeRegister); err != nil {
+		t.Errorf("发送失败后重新发送返回 %v，期望成功", err)
+	}
+}
+
+// TestVerifyCodeAttempts 每个验证码最多尝试5次，用完后正确的验证码也无效；验证通过后不能再次使用
+func TestVerifyCodeAttempts(t *testing.T) {
+	t.Parallel()
+	db := testhelpers.NewDB(t)
+	sender := &stubSender{}
+	verification := services.NewVerificationService(db, sender)
+
+	if err := verification.RequestCode("guess@example.test", services.PurposeRegister); err != nil {
+		t.Fatalf("发送验证码失败: %v", err)
+	}
+	code := sender.codes[0]
+	wrong := "000000"
+	if code == wrong {
+		wrong = "111111"
+	}
+	for i := 1; i <= 5; i++ {
+		if _, err := verification.VerifyCode("guess@example.test", services.PurposeRegister, wrong); !errors.Is(err, services.ErrValidation) {
+			t.Fatalf("第%d次错误尝试返回 %v，期望 ErrValidation", i, err)
+		}
+	}
+	if _, err := verification.VerifyCode("guess@example.test", services.PurposeRegister, code); !errors.Is(err, services.ErrValidation) {
+		t.Errorf("尝试次数用完后正确的验证码返回 %v，期望 ErrValidation", err)
+	}
+
+	if err := verification.RequestCode("ok@example.test", services.PurposeReset); err != nil {
+		t.Fatalf("发送验证码失败: %v", err)
+	}
+	code = sender.codes[1]
+	if _, err := verification.VerifyCode("OK@example.test", services.PurposeRegister, code); !errors.Is(err, services.ErrValidation) {
+		t.Errorf("用途不一致时返回 %v，期望 ErrValidation", err)
+	}
+	token, err := verification.VerifyCode("OK@example.test", services.PurposeReset, code)
+	if err != nil || token == "" {
+		t.Fatalf("验证返回 %q, %v，期望得到凭证", token, err)
+	}
+	if _, err := verification.VerifyCode("ok@example.test", services.PurposeReset, code); !errors.Is(err, services.ErrValidation) {
+		t.Errorf("验证码重复使用返回 %v，期望 ErrValidation", err)
+	}
+}
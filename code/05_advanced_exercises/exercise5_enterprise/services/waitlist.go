@@ -0,0 +1,295 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"edu-platform/models"
+)
+
+// waitlistOfferTTL 候补用户收到名额通知后的保留时间，过期未购买则通知下一位
+const waitlistOfferTTL = 48 * time.Hour
+
+// WaitlistService 限额课程的候补服务
+// 空余名额 = 人数上限 - 学生数 - 未过期的候补通知数，已通知的用户在保留期内占用一个名额，
+// 其他用户下单和支付时都按此计算
+type WaitlistService struct {
+	db *gorm.DB
+}
+
+// NewWaitlistService 创建候补服务
+func NewWaitlistService(db *gorm.DB) *WaitlistService {
+	return &WaitlistService{db: db}
+}
+
+// WaitlistPosition 用户在候补名单中的状态
+type WaitlistPosition struct {
+	CourseID       uint                  `json:"course_id"`
+	Status         models.WaitlistStatus `json:"status"`
+	Place          int64                 `json:"place,omitempty"`            // 等待中的排名，从1开始
+	OfferExpiresAt *time.Time            `json:"offer_expires_at,omitempty"` // 已通知时购买的截止时间
+}
+
+// Join 加入课程的候补名单，只有满员的限额课程可以候补
+// 候补顺序由计数器在事务中分配，并发加入的用户不会得到相同的位置；通知过期或退款后再次加入时排到队尾
+func (s *WaitlistService) Join(userID, courseID uint) (*WaitlistPosition, error) {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var course models.Course
+		if err := tx.Select("id", "title", "status", "student_count", "max_students").
+			First(&course, courseID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrNotFound.WithMsg("course.not_found")
+			}
+			return err
+		}
+		if !course.Status.IsPublished() {
+			return ErrNotFound.WithMsg("course.not_found")
+		}
+		if course.MaxStudents == nil {
+			return ErrConflict.WithMsg("waitlist.not_limited")
+		}
+
+		var entry models.Waitlist
+		result := tx.Where("course_id = ? AND user_id = ?", courseID, userID).Limit(1).Find(&entry)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected > 0 && entry.Status.IsActive() {
+			return ErrConflict.WithMsg("waitlist.already_joined")
+		}
+
+		enrolled, err := isEnrolled(tx, userID, courseID)
+		if err != nil {
+			return err
+		}
+		if enrolled {
+			return ErrConflict.WithMsg("order.already_purchased")
+		}
+		seats, err := availableSeats(tx, &course, userID, time.Now())
+		if err != nil {
+			return err
+		}
+		if seats > 0 {
+			return ErrConflict.WithMsg("waitlist.seats_available")
+		}
+
+		position, err := nextDocumentNo(tx, fmt.Sprintf("waitlist:%d", courseID))
+		if err != nil {
+			return err
+		}
+		if result.RowsAffected == 0 {
+			return tx.Create(&models.Waitlist{
+				CourseID: courseID,
+				UserID:   userID,
+				Position: position,
+				Status:   models.WaitlistStatusWaiting,
+			}).Error
+		}
+
+		// 按原状态条件更新，并发重复加入时只有一个成功
+		rejoin := tx.Model(&models.Waitlist{}).Where("id = ? AND status = ?", entry.ID, entry.Status).
+			Updates(map[string]interface{}{
+				"status":           models.WaitlistStatusWaiting,
+				"position":         position,
+				"offered_at":       nil,
+				"offer_expires_at": nil,
+			})
+		if rejoin.Error != nil {
+			return rejoin.Error
+		}
+		if rejoin.RowsAffected == 0 {
+			return ErrConflict.WithMsg("waitlist.already_joined")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.Position(userID, courseID)
+}
+
+// Position 查询用户在课程候补名单中的状态
+// 排名为排在自己前面（含自己）仍在等待的人数，由一条COUNT查询得出，他人购买或过期后自动前移
+func (s *WaitlistService) Position(userID, courseID uint) (*WaitlistPosition, error) {
+	var entry models.Waitlist
+	if err := s.db.Where("course_id = ? AND user_id = ?", courseID, userID).First(&entry).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound.WithMsg("waitlist.not_joined")
+		}
+		return nil, err
+	}
+
+	position := &WaitlistPosition{CourseID: courseID, Status: entry.Status}
+	switch entry.Status {
+	case models.WaitlistStatusWaiting:
+		if err := s.db.Model(&models.Waitlist{}).
+			Where("course_id = ? AND status = ? AND position <= ?", courseID, models.WaitlistStatusWaiting, entry.Position).
+			Count(&position.Place).Error; err != nil {
+			return nil, err
+		}
+	case models.WaitlistStatusOffered:
+		position.OfferExpiresAt = entry.OfferExpiresAt
+	}
+	return position, nil
+}
+
+// ExpireOffers 把保留期已过的候补通知标记为过期，空出的名额依次通知下一位候补用户
+// 返回过期的通知数量
+func (s *WaitlistService) ExpireOffers(ctx context.Context, now time.Time) (int, error) {
+	var due []models.Waitlist
+	if err := s.db.WithContext(ctx).Select("id", "course_id").
+		Where("status = ? AND offer_expires_at <= ?", models.WaitlistStatusOffered, now).
+		Order("offer_expires_at").Find(&due).Error; err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for _, entry := range due {
+		if ctx.Err() != nil {
+			return expired, ctx.Err()
+		}
+		err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			// 按状态条件更新，期间用户已购买的不再过期
+			result := tx.Model(&models.Waitlist{}).
+				Where("id = ? AND status = ?", entry.ID, models.WaitlistStatusOffered).
+				Update("status", models.WaitlistStatusExpired)
+			if result.Error != nil || result.RowsAffected == 0 {
+				return result.Error
+			}
+			expired++
+			_, err := offerSeats(tx, entry.CourseID, now)
+			return err
+		})
+		if err != nil {
+			return expired, err
+		}
+	}
+	return expired, nil
+}
+
+// offerSeats 课程有空余名额时，按候补顺序通知等待中的用户，名额保留 waitlistOfferTTL
+// 在退款、取消订单、调整人数上限和通知过期的事务中调用，返回本次通知的候补记录
+func offerSeats(tx *gorm.DB, courseID uint, now time.Time) ([]models.Waitlist, error) {
+	var course models.Course
+	if err := tx.Select("id", "title", "student_count", "max_students").First(&course, courseID).Error; err != nil {
+		return nil, err
+	}
+	if course.MaxStudents == nil {
+		return nil, nil
+	}
+	seats, err := availableSeats(tx, &course, 0, now)
+	if err != nil || seats <= 0 {
+		return nil, err
+	}
+
+	var next []models.Waitlist
+	if err := tx.Where("course_id = ? AND status = ?", courseID, models.WaitlistStatusWaiting).
+		Order("position").Limit(int(seats)).Find(&next).Error; err != nil {
+		return nil, err
+	}
+
+	expiresAt := now.Add(waitlistOfferTTL)
+	offered := make([]models.Waitlist, 0, len(next))
+	for _, entry := range next {
+		result := tx.Model(&models.Waitlist{}).
+			Where("id = ? AND status = ?", entry.ID, models.WaitlistStatusWaiting).
+			Updates(map[string]interface{}{
+				"status":           models.WaitlistStatusOffered,
+				"offered_at":       now,
+				"offer_expires_at": expiresAt,
+			})
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+
+		data, err := json.Marshal(map[string]interface{}{
+			"course_id":  courseID,
+			"expires_at": expiresAt,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := createNotification(tx, &models.Notification{
+			UserID:  entry.UserID,
+			Title:   "候补名额已为您保留",
+			Content: "课程《" + course.Title + "》有名额空出，请在" + expiresAt.Format("2006-01-02 15:04") + "前完成购买，逾期名额将让给下一位候补同学",
+			Type:    2, // 课程通知
+			Data:    string(data),
+		}); err != nil {
+			return nil, err
+		}
+
+		entry.Status = models.WaitlistStatusOffered
+		entry.OfferedAt = &now
+		entry.OfferExpiresAt = &expiresAt
+		offered = append(offered, entry)
+	}
+	return offered, nil
+}
+
+// availableSeats 限额课程对某个用户的空余名额，course 须包含 student_count 和 max_students
+// 其他用户未过期的候补通知占用名额；exceptUserID 本人的通知不计入，使被通知的用户可以购买
+func availableSeats(tx *gorm.DB, course *models.Course, exceptUserID uint, now time.Time) (int64, error) {
+	var offers int64
+	if err := tx.Model(&models.Waitlist{}).
+		Where("course_id = ? AND status = ? AND offer_expires_at > ? AND user_id <> ?",
+			course.ID, models.WaitlistStatusOffered, now, exceptUserID).
+		Count(&offers).Error; err != nil {
+		return 0, err
+	}
+	return int64(*course.MaxStudents) - int64(course.StudentCount) - offers, nil
+}
+
+// checkCourseCapacity 下单时检查限额课程是否已满员，满员时返回 ErrCourseFull，前端据此提示加入候补
+func checkCourseCapacity(tx *gorm.DB, userID uint, courses []models.Course, now time.Time) error {
+	for i := range courses {
+		if courses[i].MaxStudents == nil {
+			continue
+		}
+		seats, err := availableSeats(tx, &courses[i], userID, now)
+		if err != nil {
+			return err
+		}
+		if seats <= 0 {
+			return courseFullError(courses[i].ID, courses[i].Title)
+		}
+	}
+	return nil
+}
+
+// reserveSeat 支付时为用户占用课程名额并增加学生数
+// 人数上限在同一条UPDATE中检查，并发支付不会超员；候补用户购买后候补记录标记为已购买
+func reserveSeat(tx *gorm.DB, userID, courseID uint, courseTitle string, now time.Time) error {
+	result := tx.Model(&models.Course{}).Where("id = ?", courseID).
+		Where("max_students IS NULL OR student_count + (SELECT COUNT(*) FROM "+models.Table(tx, "waitlists")+
+			" WHERE course_id = ? AND status = ? AND offer_expires_at > ? AND user_id <> ? AND deleted_at IS NULL) < max_students",
+			courseID, models.WaitlistStatusOffered, now, userID).
+		Update("student_count", gorm.Expr("student_count + ?", 1))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return courseFullError(courseID, courseTitle)
+	}
+
+	return tx.Model(&models.Waitlist{}).
+		Where("course_id = ? AND user_id = ? AND status IN ?", courseID, userID,
+			[]models.WaitlistStatus{models.WaitlistStatusWaiting, models.WaitlistStatusOffered}).
+		Update("status", models.WaitlistStatusConverted).Error
+}
+
+// courseFullError 课程已满员的错误，Details 中带上课程ID，前端据此提供加入候补的入口
+func courseFullError(courseID uint, title string) error {
+	return ErrCourseFull.WithMsg("course.full", title).WithDetails(map[string]interface{}{
+		"course_id": courseID,
+		"waitlist":  true,
+	})
+}
@@ -0,0 +1,132 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+
+	"edu-platform/models"
+	"edu-platform/services"
+	"edu-platform/testhelpers"
+	"edu-platform/testhelpers/factory"
+)
+
+// waitlistStatus 查询用户的候补状态和等待排名
+func waitlistStatus(t *testing.T, waitlist *services.WaitlistService, userID, courseID uint) (models.WaitlistStatus, int64) {
+	t.Helper()
+	position, err := waitlist.Position(userID, courseID)
+	if err != nil {
+		t.Fatalf("查询用户 %d 的候补状态失败: %v", userID, err)
+	}
+	return position.Status, position.Place
+}
+
+// limitCourse 把课程的人数上限设为max
+func limitCourse(t *testing.T, db *gorm.DB, courseID uint, max int) {
+	t.Helper()
+	if err := db.Model(&models.Course{}).Where("id = ?", courseID).Update("max_students", max).Error; err != nil {
+		t.Fatalf("设置人数上限失败: %v", err)
+	}
+}
+
+// TestWaitlistPromotion 空出名额时按加入顺序通知第一位候补用户，通知期间名额只保留给他；
+// 通知过期后名额转给下一位，过期的用户重新加入时排到队尾
+func TestWaitlistPromotion(t *testing.T) {
+	t.Parallel()
+	db := testhelpers.NewDB(t)
+	f := factory.New(t, db)
+	waitlist := services.NewWaitlistService(db)
+	orders := services.NewOrderService(db)
+
+	course := f.Course(9900)
+	limitCourse(t, db, course.ID, 1)
+	f.PaidOrder(f.User("student").ID, course.ID)
+
+	first, second, third, outsider := f.User("student"), f.User("student"), f.User("student"), f.User("student")
+	for _, user := range []*models.User{first, second, third} {
+		if _, err := waitlist.Join(user.ID, course.ID); err != nil {
+			t.Fatalf("加入候补失败: %v", err)
+		}
+	}
+	if _, err := waitlist.Join(first.ID, course.ID); !errors.Is(err, services.ErrConflict) {
+		t.Errorf("重复加入返回 %v，期望 ErrConflict", err)
+	}
+
+	// 提高人数上限后通知第一位候补用户
+	if err := services.NewCourseService(db, nil).UpdateCourse(course.ID, map[string]interface{}{"max_students": 2}, false); err != nil {
+		t.Fatalf("修改人数上限失败: %v", err)
+	}
+	if status, _ := waitlistStatus(t, waitlist, first.ID, course.ID); status != models.WaitlistStatusOffered {
+		t.Errorf("第一位候补用户状态为 %v，期望已通知", status)
+	}
+	if status, place := waitlistStatus(t, waitlist, second.ID, course.ID); status != models.WaitlistStatusWaiting || place != 1 {
+		t.Errorf("第二位候补用户状态 %v、排名 %d，期望等待中、第1位", status, place)
+	}
+	var notified int64
+	db.Model(&models.Notification{}).Where("user_id = ?", first.ID).Count(&notified)
+	if notified != 1 {
+		t.Errorf("第一位候补用户收到 %d 条通知，期望 1 条", notified)
+	}
+	for _, user := range []*models.User{second, outsider} {
+		if _, err := orders.CreateOrder(user.ID, []uint{course.ID}, nil, ""); !errors.Is(err, services.ErrCourseFull) {
+			t.Errorf("名额保留期间用户 %d 下单返回 %v，期望 ErrCourseFull", user.ID, err)
+		}
+	}
+
+	// 通知过期后名额转给下一位
+	expired, err := waitlist.ExpireOffers(context.Background(), time.Now().Add(49*time.Hour))
+	if err != nil || expired != 1 {
+		t.Fatalf("ExpireOffers 返回 %d, %v，期望过期 1 个通知", expired, err)
+	}
+	if status, _ := waitlistStatus(t, waitlist, first.ID, course.ID); status != models.WaitlistStatusExpired {
+		t.Errorf("过期后第一位候补用户状态为 %v，期望已过期", status)
+	}
+	if status, _ := waitlistStatus(t, waitlist, second.ID, course.ID); status != models.WaitlistStatusOffered {
+		t.Errorf("第二位候补用户状态为 %v，期望已通知", status)
+	}
+
+	f.PaidOrder(second.ID, course.ID)
+	if status, _ := waitlistStatus(t, waitlist, second.ID, course.ID); status != models.WaitlistStatusConverted {
+		t.Errorf("购买后候补状态为 %v，期望已购买", status)
+	}
+
+	// 过期的用户重新加入排在仍在等待的用户之后
+	if _, err := waitlist.Join(first.ID, course.ID); err != nil {
+		t.Fatalf("重新加入候补失败: %v", err)
+	}
+	if _, place := waitlistStatus(t, waitlist, third.ID, course.ID); place != 1 {
+		t.Errorf("第三位候补用户排名为 %d，期望前移到第1位", place)
+	}
+	if status, place := waitlistStatus(t, waitlist, first.ID, course.ID); status != models.WaitlistStatusWaiting || place != 2 {
+		t.Errorf("重新加入后状态 %v、排名 %d，期望等待中、第2位", status, place)
+	}
+}
+
+// TestWaitlistJoinRejected 不限人数的课程、有空余名额的课程和已购买的用户不能加入候补
+func TestWaitlistJoinRejected(t *testing.T) {
+	t.Parallel()
+	db := testhelpers.NewDB(t)
+	f := factory.New(t, db)
+	waitlist := services.NewWaitlistService(db)
+
+	unlimited, limited := f.Course(9900), f.Course(9900)
+	limitCourse(t, db, limited.ID, 1)
+	buyer, student := f.User("student"), f.User("student")
+
+	if _, err := waitlist.Join(student.ID, unlimited.ID); !errors.Is(err, services.ErrConflict) {
+		t.Errorf("不限人数的课程返回 %v，期望 ErrConflict", err)
+	}
+	if _, err := waitlist.Join(student.ID, limited.ID); !errors.Is(err, services.ErrConflict) {
+		t.Errorf("有空余名额时返回 %v，期望 ErrConflict", err)
+	}
+	f.PaidOrder(buyer.ID, limited.ID)
+	if _, err := waitlist.Join(buyer.ID, limited.ID); !errors.Is(err, services.ErrConflict) {
+		t.Errorf("已购买的用户返回 %v，期望 ErrConflict", err)
+	}
+	if _, err := waitlist.Position(buyer.ID, limited.ID); !errors.Is(err, services.ErrNotFound) {
+		t.Errorf("未加入时查询返回 %v，期望 ErrNotFound", err)
+	}
+}
@@ -0,0 +1,28 @@
+// Package testhelpers 测试辅助：每个测试独立的内存SQLite数据库、测试数据工厂、
+// 基于 SetupRoutes 的HTTP测试客户端，以及去掉易变字段后与golden JSON文件比较响应
+package testhelpers
+
+import (
+	"testing"
+
+	"gorm.io/gorm"
+
+	"edu-platform/fixtures"
+	"edu-platform/testhelpers/testdb"
+)
+
+// NewDB 创建独立的内存SQLite数据库并迁移全部模型，见 testdb.New
+func NewDB(t testing.TB) *gorm.DB {
+	t.Helper()
+	return testdb.New(t)
+}
+
+// NewSeededDB 创建测试数据库并加载 fixtures 中的示例数据（角色、用户、分类、课程），数据内容固定
+func NewSeededDB(t testing.TB) *gorm.DB {
+	t.Helper()
+	db := NewDB(t)
+	if err := fixtures.Load(db); err != nil {
+		t.Fatalf("加载示例数据失败: %v", err)
+	}
+	return db
+}
@@ -0,0 +1,158 @@
+// Package factory 测试数据工厂：按测试需要创建用户、课程和订单，
+// 字段带有递增序号保证唯一，相同的创建顺序得到相同的数据
+package factory
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+
+	"edu-platform/models"
+	"edu-platform/services"
+)
+
+// Password 工厂创建的用户的登录密码，以明文保存，首次登录时升级为哈希
+const Password = "password"
+
+// Factory 测试数据工厂，创建失败时直接结束测试
+type Factory struct {
+	t   testing.TB
+	db  *gorm.DB
+	seq int
+}
+
+// New 创建工厂
+func New(t testing.TB, db *gorm.DB) *Factory {
+	return &Factory{t: t, db: db}
+}
+
+func (f *Factory) next() int {
+	f.seq++
+	return f.seq
+}
+
+func (f *Factory) create(value interface{}) {
+	f.t.Helper()
+	if err := f.db.Create(value).Error; err != nil {
+		f.t.Fatalf("创建测试数据 %T 失败: %v", value, err)
+	}
+}
+
+// Role 返回角色ID，角色不存在时创建
+func (f *Factory) Role(name string) uint {
+	f.t.Helper()
+	role := models.Role{Name: name}
+	if err := f.db.Where("name = ?", name).FirstOrCreate(&role).Error; err != nil {
+		f.t.Fatalf("创建角色 %s 失败: %v", name, err)
+	}
+	return role.ID
+}
+
+// User 创建指定角色的用户，用户名为 test_<role><序号>（与示例数据中的用户名不冲突），邮箱为 <用户名>@example.test，密码为 Password
+func (f *Factory) User(role string) *models.User {
+	f.t.Helper()
+	n := f.next()
+	username := fmt.Sprintf("test_%s%d", role, n)
+	user := &models.User{
+		Username: username,
+		Email:    username + "@example.test",
+		Phone:    fmt.Sprintf("139%08d", n),
+		Password: Password,
+		Nickname: username,
+		Status:   models.UserStatusActive,
+		RoleID:   f.Role(role),
+	}
+	f.create(user)
+	return user
+}
+
+// Category 创建分类
+func (f *Factory) Category() *models.Category {
+	f.t.Helper()
+	n := f.next()
+	category := &models.Category{
+		Name:   fmt.Sprintf("分类%d", n),
+		Slug:   fmt.Sprintf("category-%d", n),
+		Status: 1,
+	}
+	f.create(category)
+	return category
+}
+
+// Course 创建已发布的课程，价格单位为分；课程有一个章节、两个课时，讲师和分类同时创建
+func (f *Factory) Course(price int64) *models.Course {
+	f.t.Helper()
+	instructor := f.User("instructor")
+	category := f.Category()
+	n := f.next()
+	now := time.Now()
+	course := &models.Course{
+		Title:         fmt.Sprintf("测试课程%d", n),
+		Slug:          fmt.Sprintf("course-%d", n),
+		CategoryID:    category.ID,
+		InstructorID:  instructor.ID,
+		Price:         price,
+		OriginalPrice: price,
+		Level:         1,
+		Status:        models.CourseStatusPublished,
+		IsFree:        price == 0,
+		PublishedAt:   &now,
+		LessonCount:   2,
+	}
+	f.create(course)
+
+	chapter := &models.Chapter{CourseID: course.ID, Title: "第一章", Sort: 1, Status: 1, LessonCount: 2}
+	f.create(chapter)
+	for i := 1; i <= 2; i++ {
+		f.create(&models.Lesson{
+			ChapterID: chapter.ID,
+			Title:     fmt.Sprintf("课时%d", i),
+			Duration:  600,
+			Sort:      i,
+			Status:    1,
+		})
+	}
+	return course
+}
+
+// Lessons 课程的全部课时，按章节和课时顺序排列
+func (f *Factory) Lessons(courseID uint) []models.Lesson {
+	f.t.Helper()
+	var lessons []models.Lesson
+	err := f.db.Joins("JOIN "+models.TableAs(f.db, "chapters")+" ON chapters.id = lessons.chapter_id").
+		Where("chapters.course_id = ?", courseID).
+		Order("chapters.sort, lessons.sort").
+		Find(&lessons).Error
+	if err != nil {
+		f.t.Fatalf("查询课程 %d 的课时失败: %v", courseID, err)
+	}
+	return lessons
+}
+
+// PendingOrder 通过 OrderService 为用户创建待付款订单
+func (f *Factory) PendingOrder(userID uint, courseIDs ...uint) *models.Order {
+	f.t.Helper()
+	order, err := services.NewOrderService(f.db).CreateOrder(userID, courseIDs, nil, "")
+	if err != nil {
+		f.t.Fatalf("创建订单失败: %v", err)
+	}
+	return order
+}
+
+// PaidOrder 通过 OrderService 创建并支付订单，返回支付后的订单（含订单项）
+func (f *Factory) PaidOrder(userID uint, courseIDs ...uint) *models.Order {
+	f.t.Helper()
+	order := f.PendingOrder(userID, courseIDs...)
+	paymentNo := fmt.Sprintf("PAY%d", f.next())
+	if err := services.NewOrderService(f.db).PayOrder(order.OrderNo, "alipay", paymentNo); err != nil {
+		f.t.Fatalf("支付订单 %s 失败: %v", order.OrderNo, err)
+	}
+
+	var paid models.Order
+	if err := f.db.Preload("Items").Where("order_no = ?", order.OrderNo).First(&paid).Error; err != nil {
+		f.t.Fatalf("查询订单 %s 失败: %v", order.OrderNo, err)
+	}
+	return &paid
+}
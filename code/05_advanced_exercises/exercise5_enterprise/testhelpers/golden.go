@@ -0,0 +1,109 @@
+package testhelpers
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// update 为true时用实际响应重新生成golden文件：go test ./e2e/ -update
+var update = flag.Bool("update", false, "用实际响应重新生成 testdata 下的golden文件")
+
+// volatileKeys 每次运行都会变化的字段，比较前替换为占位符
+var volatileKeys = map[string]string{
+	"token":              "<token>",
+	"verification_token": "<token>",
+	"order_no":           "<order_no>",
+	"payment_no":         "<payment_no>",
+	"invoice_no":         "<invoice_no>",
+	"credit_note_no":     "<credit_note_no>",
+	"serial":             "<serial>",
+	"request_id":         "<request_id>",
+	"expires_at":         "<time>",
+}
+
+// Scrub 去掉响应中的易变字段：ID（id 和 *_id）、时间（可解析为RFC3339的字符串）、token、订单号等，
+// 返回按键排序、缩进后的JSON，相同的响应内容得到相同的结果
+func Scrub(t testing.TB, body []byte) []byte {
+	t.Helper()
+	var value interface{}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		t.Fatalf("解析响应失败: %v\n%s", err, body)
+	}
+
+	// 不转义 <、>，占位符在golden文件中保持可读
+	var out bytes.Buffer
+	encoder := json.NewEncoder(&out)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(scrubValue("", value)); err != nil {
+		t.Fatalf("编码响应失败: %v", err)
+	}
+	return out.Bytes()
+}
+
+func scrubValue(key string, value interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+	// 空值保留，golden文件中能看出字段是否有值
+	if placeholder, ok := volatileKeys[key]; ok && value != "" {
+		return placeholder
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for k, item := range v {
+			v[k] = scrubValue(k, item)
+		}
+		return v
+	case []interface{}:
+		for i, item := range v {
+			v[i] = scrubValue(key, item)
+		}
+		return v
+	case json.Number:
+		if key == "id" || strings.HasSuffix(key, "_id") {
+			return "<id>"
+		}
+		return v
+	case string:
+		if _, err := time.Parse(time.RFC3339Nano, v); err == nil {
+			return "<time>"
+		}
+		return v
+	}
+	return value
+}
+
+// AssertGolden 比较去掉易变字段后的响应与 testdata/<name>.golden.json，带 -update 运行时改为写入该文件
+func AssertGolden(t testing.TB, name string, body []byte) {
+	t.Helper()
+	actual := Scrub(t, body)
+	path := filepath.Join("testdata", name+".golden.json")
+
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("创建golden目录失败: %v", err)
+		}
+		if err := os.WriteFile(path, actual, 0o644); err != nil {
+			t.Fatalf("写入golden文件失败: %v", err)
+		}
+		return
+	}
+
+	expected, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("读取golden文件失败（首次运行请加 -update 生成）: %v", err)
+	}
+	if !bytes.Equal(expected, actual) {
+		t.Errorf("响应与 %s 不一致（确认变更无误后加 -update 重新生成）\n期望:\n%s\n实际:\n%s", path, expected, actual)
+	}
+}
@@ -0,0 +1,161 @@
+package testhelpers
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"edu-platform/config"
+	"edu-platform/controllers"
+)
+
+// JWTSecret 测试服务使用的 jwt.secret，用于签发和校验模拟登录token
+const JWTSecret = "test-jwt-secret"
+
+// Server 基于 SetupRoutes 的测试服务，请求直接交给路由处理，不监听端口
+type Server struct {
+	t      testing.TB
+	DB     *gorm.DB
+	Router *gin.Engine
+	Codes  *CodeRecorder // 注册、重置密码时发送的验证码
+}
+
+// NewServer 在db上创建测试服务，验证码不发送而是记录在 Codes 中
+func NewServer(t testing.TB, db *gorm.DB) *Server {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	cfg := &config.Config{
+		JWT: config.JWTConfig{Secret: JWTSecret, ExpireDuration: time.Hour},
+	}
+	codes := &CodeRecorder{codes: map[string]string{}}
+	return &Server{
+		t:      t,
+		DB:     db,
+		Router: controllers.SetupRoutes(db, cfg, controllers.WithCodeSender(codes)),
+		Codes:  codes,
+	}
+}
+
+// Response 接口响应
+type Response struct {
+	Status int
+	Body   []byte
+}
+
+// envelope 统一响应结构，data 保留原始JSON由调用方解析
+type envelope struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// Code 响应体中的业务码
+func (r *Response) Code(t testing.TB) int {
+	t.Helper()
+	var env envelope
+	if err := json.Unmarshal(r.Body, &env); err != nil {
+		t.Fatalf("解析响应失败: %v\n%s", err, r.Body)
+	}
+	return env.Code
+}
+
+// Data 把响应体中的data字段解析到dest
+func (r *Response) Data(t testing.TB, dest interface{}) {
+	t.Helper()
+	var env envelope
+	if err := json.Unmarshal(r.Body, &env); err != nil {
+		t.Fatalf("解析响应失败: %v\n%s", err, r.Body)
+	}
+	if err := json.Unmarshal(env.Data, dest); err != nil {
+		t.Fatalf("解析响应data失败: %v\n%s", err, r.Body)
+	}
+}
+
+// Do 发送请求，body不为nil时按JSON编码；token不为空时放入Authorization头
+func (s *Server) Do(method, path, token string, body interface{}) *Response {
+	s.t.Helper()
+	return s.DoWithHeaders(method, path, token, body, nil)
+}
+
+// DoWithHeaders 发送请求并附加请求头
+func (s *Server) DoWithHeaders(method, path, token string, body interface{}, headers map[string]string) *Response {
+	s.t.Helper()
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			s.t.Fatalf("编码请求体失败: %v", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req := httptest.NewRequest(method, path, reader)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	w := httptest.NewRecorder()
+	s.Router.ServeHTTP(w, req)
+	return &Response{Status: w.Code, Body: w.Body.Bytes()}
+}
+
+// MustOK 发送请求并要求返回200，否则测试失败
+func (s *Server) MustOK(method, path, token string, body interface{}) *Response {
+	s.t.Helper()
+	resp := s.Do(method, path, token, body)
+	if resp.Status != http.StatusOK {
+		s.t.Fatalf("%s %s 返回 %d: %s", method, path, resp.Status, resp.Body)
+	}
+	return resp
+}
+
+// Login 用邮箱和密码登录，返回token
+func (s *Server) Login(email, password string) string {
+	s.t.Helper()
+	resp := s.MustOK(http.MethodPost, "/api/v1/users/login", "", map[string]string{
+		"email":    email,
+		"password": password,
+	})
+	var data struct {
+		Token string `json:"token"`
+	}
+	resp.Data(s.t, &data)
+	return data.Token
+}
+
+// CodeRecorder 记录发送的验证码，代替邮件和短信发送
+type CodeRecorder struct {
+	mu    sync.Mutex
+	codes map[string]string
+}
+
+// SendCode 实现 services.CodeSender
+func (r *CodeRecorder) SendCode(target, purpose, code string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.codes[purpose+":"+target] = code
+	return nil
+}
+
+// Code 最近一次发送给target的验证码，未发送时返回空字符串
+func (r *CodeRecorder) Code(target, purpose string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.codes[purpose+":"+target]
+}
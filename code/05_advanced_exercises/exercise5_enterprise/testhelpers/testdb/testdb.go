@@ -0,0 +1,43 @@
+// Package testdb 测试用的SQLite数据库，只依赖models，services包内部的测试也可以使用
+package testdb
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"edu-platform/models"
+)
+
+var seq int64
+
+// New 创建独立的内存SQLite数据库并迁移全部模型，测试结束时关闭
+// 每次调用都是新的数据库，测试之间互不影响，可以并行运行
+func New(t testing.TB) *gorm.DB {
+	t.Helper()
+	// 共享缓存让同一个数据库的多个连接看到相同的数据，并发写入按 busy_timeout 排队
+	dsn := fmt.Sprintf("file:testdb%d?mode=memory&cache=shared&_pragma=busy_timeout(5000)", atomic.AddInt64(&seq, 1))
+	return open(t, dsn)
+}
+
+func open(t testing.TB, dsn string) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("打开测试数据库失败: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("获取数据库连接失败: %v", err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	if err := db.AutoMigrate(models.All()...); err != nil {
+		t.Fatalf("迁移测试数据库失败: %v", err)
+	}
+	return db
+}
@@ -0,0 +1,122 @@
+// Package models 定义各练习共用的模型
+// 统计、性能优化两个练习都基于同一套电商表结构，模型只在这里维护一份；
+// exercise5 的订单关联课程而不是商品，只共用 BaseModel 和 Category
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// BaseModel 基础模型
+type BaseModel struct {
+	ID        uint           `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
+}
+
+// User 用户
+type User struct {
+	BaseModel
+	Username    string     `gorm:"uniqueIndex;size:50;not null" json:"username"`
+	Email       string     `gorm:"uniqueIndex;size:100;not null" json:"email"`
+	Phone       string     `gorm:"uniqueIndex;size:20" json:"phone"`
+	Password    string     `gorm:"size:255;not null" json:"-"`
+	Nickname    string     `gorm:"size:50" json:"nickname"`
+	Status      int8       `gorm:"default:1;comment:1-正常,2-禁用" json:"status"`
+	LastLoginAt *time.Time `json:"last_login_at"`
+}
+
+// Category 分类，电商练习中是商品分类，exercise5 中是课程分类
+// Description 之后的展示字段和 CourseCount 只有 exercise5 使用，电商练习中保持默认值
+type Category struct {
+	BaseModel
+	Name        string `gorm:"size:50;not null" json:"name" validate:"required,max=50"`
+	Slug        string `gorm:"uniqueIndex;size:100;not null" json:"slug" validate:"required,max=100"`
+	Description string `gorm:"type:text" json:"description" validate:"omitempty,max=500"`
+	Icon        string `gorm:"size:255" json:"icon"`
+	Cover       string `gorm:"size:255" json:"cover"`
+	ParentID    *uint  `gorm:"index" json:"parent_id"`
+	Sort        int    `gorm:"default:0" json:"sort"`
+	Status      int8   `gorm:"default:1;comment:1-启用,2-禁用" json:"status"`
+	CourseCount int    `gorm:"default:0;comment:课程数量" json:"course_count"`
+
+	// 关联
+	Parent   *Category  `gorm:"foreignKey:ParentID" json:"parent,omitempty"`
+	Children []Category `gorm:"foreignKey:ParentID" json:"children,omitempty"`
+}
+
+// TableName 指定表名，命名策略中配置了前缀时加上前缀
+func (Category) TableName(namer schema.Namer) string {
+	return PrefixedTable(namer, "categories")
+}
+
+// Brand 品牌
+type Brand struct {
+	BaseModel
+	Name   string `gorm:"uniqueIndex;size:50;not null" json:"name"`
+	Slug   string `gorm:"uniqueIndex;size:100;not null" json:"slug"`
+	Status int8   `gorm:"default:1;comment:1-启用,2-禁用" json:"status"`
+}
+
+// Product 商品
+type Product struct {
+	BaseModel
+	Name       string `gorm:"size:255;not null" json:"name"`
+	SKU        string `gorm:"uniqueIndex;size:100;not null" json:"sku"`
+	CategoryID uint   `gorm:"index;not null" json:"category_id"`
+	BrandID    *uint  `gorm:"index" json:"brand_id"`
+	Price      int64  `gorm:"not null;comment:价格(分)" json:"price"`
+	Stock      int    `gorm:"default:0" json:"stock"`
+	Sales      int    `gorm:"default:0" json:"sales"`
+	Views      int    `gorm:"default:0" json:"views"`
+	Status     int8   `gorm:"default:1;comment:1-上架,2-下架" json:"status"`
+}
+
+// Order 订单
+type Order struct {
+	BaseModel
+	OrderNo        string     `gorm:"uniqueIndex;size:50;not null" json:"order_no"`
+	UserID         uint       `gorm:"index;not null" json:"user_id"`
+	Status         int8       `gorm:"index;default:1;comment:1-待付款,2-待发货,3-待收货,4-已完成,5-已取消" json:"status"`
+	TotalAmount    int64      `gorm:"not null;comment:商品总金额(分)" json:"total_amount"`
+	PayAmount      int64      `gorm:"not null;comment:实付金额(分)" json:"pay_amount"`
+	FreightAmount  int64      `gorm:"default:0;comment:运费(分)" json:"freight_amount"`
+	DiscountAmount int64      `gorm:"default:0;comment:优惠金额(分)" json:"discount_amount"`
+	PaidAt         *time.Time `json:"paid_at"`
+	FinishedAt     *time.Time `json:"finished_at"`
+}
+
+// OrderItem 订单明细
+type OrderItem struct {
+	BaseModel
+	OrderID     uint   `gorm:"index;not null" json:"order_id"`
+	ProductID   uint   `gorm:"index;not null" json:"product_id"`
+	Quantity    int    `gorm:"not null" json:"quantity"`
+	Price       int64  `gorm:"not null;comment:单价(分)" json:"price"`
+	TotalPrice  int64  `gorm:"not null;comment:总价(分)" json:"total_price"`
+	ProductName string `gorm:"size:255;not null" json:"product_name"`
+}
+
+// All 返回需要迁移的全部模型，按外键依赖顺序排列
+func All() []interface{} {
+	return []interface{}{
+		&User{}, &Category{}, &Brand{}, &Product{}, &Order{}, &OrderItem{},
+	}
+}
+
+// PrefixedTable 固定表名加上命名策略中配置的前缀（如 edu_），没有配置前缀时就是表名本身
+func PrefixedTable(namer schema.Namer, name string) string {
+	switch ns := namer.(type) {
+	case schema.NamingStrategy:
+		return ns.TablePrefix + name
+	case *schema.NamingStrategy:
+		if ns != nil {
+			return ns.TablePrefix + name
+		}
+	}
+	return name
+}